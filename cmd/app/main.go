@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/database"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/middleware"
@@ -504,19 +505,61 @@ func getAvailableTimeSlots(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		_, err := time.Parse("2006-01-02", date)
+		var org models.Organization
+		if err := db.Where("id = ?", getOrganizationID(db)).First(&org).Error; err != nil {
+			c.JSON(500, errorResponse("Failed to fetch organization"))
+			return
+		}
+		loc, err := time.LoadLocation(org.BusinessHours.Timezone)
+		if err != nil || org.BusinessHours.Timezone == "" {
+			loc, _ = time.LoadLocation("Australia/Adelaide")
+		}
+
+		from, err := time.ParseInLocation("2006-01-02", date, loc)
 		if err != nil {
 			c.JSON(400, errorResponse("Invalid date format. Use YYYY-MM-DD"))
 			return
 		}
+		to := from
+		if endDate := c.Query("end_date"); endDate != "" {
+			if to, err = time.ParseInLocation("2006-01-02", endDate, loc); err != nil || to.Before(from) {
+				c.JSON(400, errorResponse("Invalid end date. Use YYYY-MM-DD on or after the date"))
+				return
+			}
+		}
+
+		duration, _ := strconv.Atoi(c.DefaultQuery("duration", "60"))
+		if duration <= 0 {
+			duration = 60
+		}
+		query := availability.Query{
+			Location: loc,
+			From:     from,
+			To:       to,
+			Duration: time.Duration(duration) * time.Minute,
+			Buffer:   time.Duration(org.BookingSettings.BufferTime) * time.Minute,
+		}
+		if staffID := c.Query("staff_id"); staffID != "" {
+			query.StaffIDs = []string{staffID}
+		}
+
+		days, err := availability.Find(db, &org, query)
+		if err != nil {
+			c.JSON(500, errorResponse("Failed to check availability"))
+			return
+		}
 
-		// Mock available slots - in real implementation, check business hours and existing bookings
-		availableSlots := []string{"09:00", "10:00", "11:00", "14:00", "15:00", "16:00"}
+		availableSlots := []string{}
+		for _, slot := range days[0].Slots {
+			availableSlots = append(availableSlots, slot.Time)
+		}
 
 		c.JSON(200, gin.H{
 			"success":         true,
-			"date":           date,
+			"date":            date,
+			"timezone":        loc.String(),
 			"available_slots": availableSlots,
+			"days":            days,
 		})
 	}
 }
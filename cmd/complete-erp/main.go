@@ -210,12 +210,17 @@ func autoMigrateAllModules(db *gorm.DB) error {
 		&finance.BillLineItem{},
 		&finance.Vendor{},
 		&finance.Payment{},
+		&finance.PaymentAllocation{},
+		&finance.CreditNote{},
 		&finance.BankAccount{},
 		&finance.BankTransaction{},
+		&finance.BankStatementImport{},
 		&finance.GeneralLedger{},
 		&finance.AccountBalance{},
 		&finance.AuditTrail{},
 		&finance.AccountingPeriod{},
+		&finance.AccountMapping{},
+		&finance.LedgerPosting{},
 	); err != nil {
 		return err
 	}
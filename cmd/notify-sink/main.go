@@ -0,0 +1,43 @@
+// Command notify-sink stands in for the SMTP server and the SMS and WhatsApp
+// APIs so booking notifications can be tried out locally. Point the app at it
+// with:
+//
+//	SMTP_HOST=localhost SMTP_PORT=1025
+//	SMS_API_URL=http://localhost:8025/sms
+//	WHATSAPP_API_URL=http://localhost:8025 WHATSAPP_PHONE_ID=local
+//
+// Everything received is logged and listed at http://localhost:8025/messages.
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+)
+
+func main() {
+	smtpAddr := getEnv("SINK_SMTP_ADDR", ":1025")
+	httpAddr := getEnv("SINK_HTTP_ADDR", ":8025")
+	sink := &notify.Sink{}
+
+	listener, err := net.Listen("tcp", smtpAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen for SMTP on %s: %v", smtpAddr, err)
+	}
+	go func() {
+		log.Fatal(sink.ServeSMTP(listener))
+	}()
+
+	log.Printf("notify-sink accepting SMTP on %s and HTTP on %s", smtpAddr, httpAddr)
+	log.Fatal(http.ListenAndServe(httpAddr, sink))
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,640 @@
+// Package availability works out when an organization can take bookings. Times
+// are worked out on the organization's local wall clock, so business hours stay
+// put across daylight saving changes.
+package availability
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaxDays is the longest date range a single query can cover
+const MaxDays = 62
+
+// Interval is a span of time that blocks bookings
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Usage is units of a resource held by a booking
+type Usage struct {
+	Start    time.Time
+	End      time.Time
+	Quantity int
+}
+
+// Requirement is a resource a booking needs for its whole length: one
+// particular resource, or any resource of a type
+type Requirement struct {
+	ResourceID   string `json:"resource_id,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Quantity     int    `json:"quantity"`
+}
+
+// Allocation is the units of a resource set aside for a booking
+type Allocation struct {
+	ResourceID string `json:"resource_id"`
+	Quantity   int    `json:"quantity"`
+}
+
+// Slot is a start time that can take a booking
+type Slot struct {
+	Start     time.Time    `json:"start_time"`
+	End       time.Time    `json:"end_time"`
+	Time      string       `json:"time"`                // Local start time, HH:MM
+	StaffIDs  []string     `json:"staff_ids,omitempty"` // Staff free for the slot, when staff were asked for
+	Resources []Allocation `json:"resources,omitempty"` // Resources the booking would use, when it needs any
+}
+
+// Day is one local date in a query's range
+type Day struct {
+	Date   string `json:"date"`
+	Closed bool   `json:"closed"`
+	Reason string `json:"reason,omitempty"` // Why the organization is closed, e.g. a holiday name
+	Slots  []Slot `json:"slots"`
+}
+
+// Query describes the bookings being looked for
+type Query struct {
+	Location         *time.Location
+	From             time.Time     // First local date
+	To               time.Time     // Last local date, inclusive
+	Duration         time.Duration // Length of the booking
+	Buffer           time.Duration // Gap kept free either side of other bookings
+	Interval         time.Duration // Time between slot starts; defaults to Duration + Buffer
+	StaffIDs         []string      // Staff who could take the booking
+	Requirements     []Requirement // Resources the booking needs. With no staff or resources the organization is one calendar
+	ExcludeBookingID string        // Booking being moved, left out of the conflict check
+	ExcludeHoldID    string        // Waitlist offer being taken up, left out of the conflict check
+	NotBefore        time.Time     // Earliest start, zero for no limit
+	NotAfter         time.Time     // Latest start, zero for no limit
+}
+
+// Schedule is everything that decides availability over a query's range
+type Schedule struct {
+	Hours        models.BusinessHours
+	Breaks       []models.BusinessBreak
+	Closures     []models.BusinessClosure
+	Holidays     map[string]string // Local date to holiday name
+	StaffWindows map[string][]models.StaffAvailability
+	StaffLeave   map[string][]Interval
+	Busy         map[string][]Interval // Bookings and held slots by staff ID; "" holds all of them
+	Resources    []models.Resource
+	ResourceBusy map[string][]Usage // Bookings holding each resource, by resource ID
+}
+
+// ParseClock converts HH:MM to minutes after midnight. 24:00 is accepted as the end of the day.
+func ParseClock(value string) (int, bool) {
+	if value == "24:00" {
+		return 24 * 60, true
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// localDate is midnight on t's calendar date in UTC, for comparing dates
+func localDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Covers reports whether a staff member's weekly availability windows cover
+// the whole of start to end. Time running past midnight must be covered on both days.
+func Covers(windows []models.StaffAvailability, start, end time.Time, loc *time.Location) bool {
+	start, end = start.In(loc), end.In(loc)
+	for segmentStart := start; segmentStart.Before(end); {
+		nextDay := time.Date(segmentStart.Year(), segmentStart.Month(), segmentStart.Day()+1, 0, 0, 0, 0, loc)
+		segmentEnd, to := end, 24*60
+		if end.Before(nextDay) {
+			to = end.Hour()*60 + end.Minute()
+		} else {
+			segmentEnd = nextDay
+		}
+		from := segmentStart.Hour()*60 + segmentStart.Minute()
+		date := localDate(segmentStart)
+
+		covered := false
+		for _, window := range windows {
+			if time.Weekday(window.Weekday) != segmentStart.Weekday() {
+				continue
+			}
+			if window.EffectiveFrom != nil && date.Before(localDate(*window.EffectiveFrom)) {
+				continue
+			}
+			if window.EffectiveTo != nil && date.After(localDate(*window.EffectiveTo)) {
+				continue
+			}
+			windowStart, startOK := ParseClock(window.StartTime)
+			windowEnd, endOK := ParseClock(window.EndTime)
+			if startOK && endOK && windowStart <= from && windowEnd >= to {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+		segmentStart = segmentEnd
+	}
+	return true
+}
+
+// openingHours returns the opening and closing times for a weekday, blank when closed
+func openingHours(hours models.BusinessHours, day time.Weekday) (string, string) {
+	switch day {
+	case time.Monday:
+		return hours.MondayOpen, hours.MondayClose
+	case time.Tuesday:
+		return hours.TuesdayOpen, hours.TuesdayClose
+	case time.Wednesday:
+		return hours.WednesdayOpen, hours.WednesdayClose
+	case time.Thursday:
+		return hours.ThursdayOpen, hours.ThursdayClose
+	case time.Friday:
+		return hours.FridayOpen, hours.FridayClose
+	case time.Saturday:
+		return hours.SaturdayOpen, hours.SaturdayClose
+	case time.Sunday:
+		return hours.SundayOpen, hours.SundayClose
+	}
+	return "", ""
+}
+
+// openWindows splits a day's business hours around its breaks, as minutes after midnight
+func (s *Schedule) openWindows(day time.Weekday) [][2]int {
+	openTime, closeTime := openingHours(s.Hours, day)
+	open, openOK := ParseClock(openTime)
+	closing, closeOK := ParseClock(closeTime)
+	if !openOK || !closeOK || closing <= open {
+		return nil
+	}
+
+	windows := [][2]int{{open, closing}}
+	for _, brk := range s.Breaks {
+		if brk.Weekday != nil && time.Weekday(*brk.Weekday) != day {
+			continue
+		}
+		from, fromOK := ParseClock(brk.StartTime)
+		to, toOK := ParseClock(brk.EndTime)
+		if !fromOK || !toOK || to <= from {
+			continue
+		}
+		var split [][2]int
+		for _, window := range windows {
+			if to <= window[0] || from >= window[1] {
+				split = append(split, window)
+				continue
+			}
+			if from > window[0] {
+				split = append(split, [2]int{window[0], from})
+			}
+			if to < window[1] {
+				split = append(split, [2]int{to, window[1]})
+			}
+		}
+		windows = split
+	}
+	return windows
+}
+
+// closedReason reports why the organization is closed on a local date, if it is
+func (s *Schedule) closedReason(date time.Time) (string, bool) {
+	day := localDate(date)
+	for _, closure := range s.Closures {
+		if !day.Before(localDate(closure.StartDate.UTC())) && !day.After(localDate(closure.EndDate.UTC())) {
+			return closure.Reason, true
+		}
+	}
+	if name, ok := s.Holidays[day.Format("2006-01-02")]; ok {
+		return name, true
+	}
+	return "", false
+}
+
+// overlaps reports whether start to end comes within buffer of any interval
+func overlaps(intervals []Interval, start, end time.Time, buffer time.Duration) bool {
+	for _, interval := range intervals {
+		if start.Before(interval.End.Add(buffer)) && end.After(interval.Start.Add(-buffer)) {
+			return true
+		}
+	}
+	return false
+}
+
+// freeStaff lists the staff who work and are free for the whole of start to end
+func (s *Schedule) freeStaff(q Query, start, end time.Time) []string {
+	var free []string
+	for _, staffID := range q.StaffIDs {
+		if windows := s.StaffWindows[staffID]; len(windows) > 0 && !Covers(windows, start, end, q.Location) {
+			continue
+		}
+		if overlaps(s.StaffLeave[staffID], start, end, 0) || overlaps(s.Busy[staffID], start, end, q.Buffer) {
+			continue
+		}
+		free = append(free, staffID)
+	}
+	return free
+}
+
+// peakUsage is the most units of a resource in use at any moment from start
+// to end, counting buffer either side of each booking
+func peakUsage(usages []Usage, start, end time.Time, buffer time.Duration) int {
+	var overlapping []Usage
+	for _, usage := range usages {
+		usage.Start, usage.End = usage.Start.Add(-buffer), usage.End.Add(buffer)
+		if start.Before(usage.End) && end.After(usage.Start) {
+			overlapping = append(overlapping, usage)
+		}
+	}
+
+	// Usage only rises where a booking starts, so checking those moments finds the peak
+	peak := 0
+	for _, candidate := range overlapping {
+		at := candidate.Start
+		if at.Before(start) {
+			at = start
+		}
+		inUse := 0
+		for _, usage := range overlapping {
+			if !at.Before(usage.Start) && at.Before(usage.End) {
+				inUse += usage.Quantity
+			}
+		}
+		if inUse > peak {
+			peak = inUse
+		}
+	}
+	return peak
+}
+
+// Allocate picks resources with room for a booking from start to end, reporting
+// false when any requirement can't be met. Requirements for a particular
+// resource are filled before those for any resource of a type.
+func (s *Schedule) Allocate(requirements []Requirement, start, end time.Time, buffer time.Duration) ([]Allocation, bool) {
+	if len(requirements) == 0 {
+		return nil, true
+	}
+	ordered := append([]Requirement(nil), requirements...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ResourceID != "" && ordered[j].ResourceID == ""
+	})
+
+	taken := make(map[string]int) // Units allocated so far, by resource ID
+	var used []string
+	for _, requirement := range ordered {
+		needed := requirement.Quantity
+		if needed < 1 {
+			needed = 1
+		}
+		for _, resource := range s.Resources {
+			if needed == 0 {
+				break
+			}
+			if requirement.ResourceID != "" && resource.ID != requirement.ResourceID {
+				continue
+			}
+			if requirement.ResourceID == "" && !strings.EqualFold(resource.Type, requirement.ResourceType) {
+				continue
+			}
+			capacity := resource.Capacity
+			if capacity < 1 {
+				capacity = 1
+			}
+			free := capacity - taken[resource.ID] - peakUsage(s.ResourceBusy[resource.ID], start, end, buffer)
+			if free <= 0 {
+				continue
+			}
+			if free > needed {
+				free = needed
+			}
+			if taken[resource.ID] == 0 {
+				used = append(used, resource.ID)
+			}
+			taken[resource.ID] += free
+			needed -= free
+		}
+		if needed > 0 {
+			return nil, false
+		}
+	}
+
+	allocations := make([]Allocation, 0, len(used))
+	for _, resourceID := range used {
+		allocations = append(allocations, Allocation{ResourceID: resourceID, Quantity: taken[resourceID]})
+	}
+	return allocations, true
+}
+
+// Days lists every local date in the query's range with its open slots
+func (s *Schedule) Days(q Query) []Day {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	q.Location = loc
+	step := q.Interval
+	if step <= 0 {
+		step = q.Duration + q.Buffer
+	}
+	stepMinutes := int(step / time.Minute)
+	if stepMinutes < 1 {
+		stepMinutes = 1
+	}
+
+	var days []Day
+	first := time.Date(q.From.Year(), q.From.Month(), q.From.Day(), 0, 0, 0, 0, loc)
+	last := time.Date(q.To.Year(), q.To.Month(), q.To.Day(), 0, 0, 0, 0, loc)
+	for i := 0; i < MaxDays; i++ {
+		date := time.Date(first.Year(), first.Month(), first.Day()+i, 0, 0, 0, 0, loc)
+		if date.After(last) {
+			break
+		}
+		day := Day{Date: date.Format("2006-01-02"), Slots: []Slot{}}
+
+		if reason, closed := s.closedReason(date); closed {
+			day.Closed, day.Reason = true, reason
+			days = append(days, day)
+			continue
+		}
+		windows := s.openWindows(date.Weekday())
+		if len(windows) == 0 {
+			day.Closed = true
+			days = append(days, day)
+			continue
+		}
+
+		var previous time.Time
+		for _, window := range windows {
+			// Slot times come from the wall clock so a DST change moves
+			// absolute times rather than the published hours
+			windowEnd := time.Date(date.Year(), date.Month(), date.Day(), 0, window[1], 0, 0, loc)
+			for minute := window[0]; minute < window[1]; minute += stepMinutes {
+				start := time.Date(date.Year(), date.Month(), date.Day(), 0, minute, 0, 0, loc)
+				end := start.Add(q.Duration)
+				if end.After(windowEnd) {
+					break
+				}
+				if !start.After(previous) {
+					continue
+				}
+				previous = start
+				if slot, ok := s.fit(q, start, end); ok {
+					day.Slots = append(day.Slots, slot)
+				}
+			}
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// fit checks that staff and resources are free for a booking from start to
+// end, leaving business hours to the caller
+func (s *Schedule) fit(q Query, start, end time.Time) (Slot, bool) {
+	slot := Slot{Start: start, End: end, Time: start.In(q.Location).Format("15:04")}
+	if (!q.NotBefore.IsZero() && start.Before(q.NotBefore)) || (!q.NotAfter.IsZero() && start.After(q.NotAfter)) {
+		return slot, false
+	}
+	if len(q.StaffIDs) > 0 {
+		if slot.StaffIDs = s.freeStaff(q, start, end); len(slot.StaffIDs) == 0 {
+			return slot, false
+		}
+	} else if len(q.Requirements) == 0 && overlaps(s.Busy[""], start, end, q.Buffer) {
+		return slot, false
+	}
+	if len(q.Requirements) > 0 {
+		var ok bool
+		if slot.Resources, ok = s.Allocate(q.Requirements, start, end, q.Buffer); !ok {
+			return slot, false
+		}
+	}
+	return slot, true
+}
+
+// Check reports whether a booking can start at start, which needn't fall on
+// the slot grid Days uses: the organization must be open for its whole length
+// and the staff and resources free
+func (s *Schedule) Check(q Query, start time.Time) (Slot, bool) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	local := start.In(q.Location)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, q.Location)
+	if _, closed := s.closedReason(date); closed {
+		return Slot{}, false
+	}
+
+	end := start.Add(q.Duration)
+	for _, window := range s.openWindows(date.Weekday()) {
+		windowStart := time.Date(date.Year(), date.Month(), date.Day(), 0, window[0], 0, 0, q.Location)
+		windowEnd := time.Date(date.Year(), date.Month(), date.Day(), 0, window[1], 0, 0, q.Location)
+		if !start.Before(windowStart) && !end.After(windowEnd) {
+			return s.fit(q, start, end)
+		}
+	}
+	return Slot{}, false
+}
+
+// Load reads an organization's hours, breaks, closures, holidays, staff
+// availability and bookings for a query's range
+func Load(db *gorm.DB, org *models.Organization, q Query) (*Schedule, error) {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	rangeStart := time.Date(q.From.Year(), q.From.Month(), q.From.Day()-1, 0, 0, 0, 0, loc)
+	rangeEnd := time.Date(q.To.Year(), q.To.Month(), q.To.Day()+2, 0, 0, 0, 0, loc)
+
+	schedule := &Schedule{
+		Hours:        org.BusinessHours,
+		Holidays:     make(map[string]string),
+		StaffWindows: make(map[string][]models.StaffAvailability),
+		StaffLeave:   make(map[string][]Interval),
+		Busy:         make(map[string][]Interval),
+		ResourceBusy: make(map[string][]Usage),
+	}
+
+	if err := db.Where("organization_id = ?", org.ID).Find(&schedule.Breaks).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("organization_id = ? AND start_date < ? AND end_date >= ?", org.ID, rangeEnd, rangeStart.AddDate(0, 0, -1)).
+		Find(&schedule.Closures).Error; err != nil {
+		return nil, err
+	}
+
+	if org.BookingSettings.CloseOnPublicHolidays {
+		var holidays []models.PublicHoliday
+		if err := db.Where("date >= ? AND date < ? AND (state = ? OR state = ? OR state IS NULL)",
+			localDate(rangeStart), localDate(rangeEnd), "", strings.ToUpper(org.Address.State)).
+			Find(&holidays).Error; err != nil {
+			return nil, err
+		}
+		for _, holiday := range holidays {
+			schedule.Holidays[holiday.Date.UTC().Format("2006-01-02")] = holiday.Name
+		}
+	}
+
+	bookingQuery := db.Model(&models.Booking{}).
+		Where("organization_id = ? AND start_time < ? AND end_time > ? AND status NOT IN ?",
+			org.ID, rangeEnd, rangeStart, []string{"cancelled", "no_show"})
+	if q.ExcludeBookingID != "" {
+		bookingQuery = bookingQuery.Where("id != ?", q.ExcludeBookingID)
+	}
+	var bookings []models.Booking
+	if err := bookingQuery.Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		interval := Interval{Start: booking.StartTime, End: booking.EndTime}
+		schedule.Busy[""] = append(schedule.Busy[""], interval)
+		if booking.StaffID != nil {
+			schedule.Busy[*booking.StaffID] = append(schedule.Busy[*booking.StaffID], interval)
+		}
+	}
+
+	// Staff on a step of a multi-service booking are only busy for that step
+	stepQuery := db.Model(&models.BookingStep{}).
+		Joins("JOIN bookings ON bookings.id = booking_steps.booking_id").
+		Where("bookings.organization_id = ? AND bookings.deleted_at IS NULL AND bookings.status NOT IN ?", org.ID, []string{"cancelled", "no_show"}).
+		Where("booking_steps.staff_id IS NOT NULL AND booking_steps.start_time < ? AND booking_steps.end_time > ?", rangeEnd, rangeStart)
+	if q.ExcludeBookingID != "" {
+		stepQuery = stepQuery.Where("booking_steps.booking_id != ?", q.ExcludeBookingID)
+	}
+	var steps []models.BookingStep
+	if err := stepQuery.Find(&steps).Error; err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		schedule.Busy[*step.StaffID] = append(schedule.Busy[*step.StaffID], Interval{Start: step.StartTime, End: step.EndTime})
+	}
+
+	// Slots offered to waitlisted customers stay held until the offer is answered
+	holdQuery := db.Model(&models.WaitlistOffer{}).
+		Where("organization_id = ? AND status = ? AND expires_at > ? AND start_time < ? AND end_time > ?",
+			org.ID, "pending", time.Now(), rangeEnd, rangeStart)
+	if q.ExcludeHoldID != "" {
+		holdQuery = holdQuery.Where("id != ?", q.ExcludeHoldID)
+	}
+	var holds []models.WaitlistOffer
+	if err := holdQuery.Find(&holds).Error; err != nil {
+		return nil, err
+	}
+	for _, hold := range holds {
+		interval := Interval{Start: hold.StartTime, End: hold.EndTime}
+		schedule.Busy[""] = append(schedule.Busy[""], interval)
+		if hold.StaffID != nil {
+			schedule.Busy[*hold.StaffID] = append(schedule.Busy[*hold.StaffID], interval)
+		}
+	}
+
+	if len(q.Requirements) > 0 {
+		if err := schedule.loadResources(db, org.ID, rangeStart, rangeEnd, q.ExcludeBookingID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(q.StaffIDs) > 0 {
+		var windows []models.StaffAvailability
+		if err := db.Where("organization_id = ? AND staff_id IN ?", org.ID, q.StaffIDs).Find(&windows).Error; err != nil {
+			return nil, err
+		}
+		for _, window := range windows {
+			schedule.StaffWindows[window.StaffID] = append(schedule.StaffWindows[window.StaffID], window)
+		}
+
+		var leave []models.StaffLeave
+		if err := db.Where("organization_id = ? AND staff_id IN ? AND status = ? AND start_time < ? AND end_time > ?",
+			org.ID, q.StaffIDs, "approved", rangeEnd, rangeStart).Find(&leave).Error; err != nil {
+			return nil, err
+		}
+		for _, l := range leave {
+			schedule.StaffLeave[l.StaffID] = append(schedule.StaffLeave[l.StaffID], Interval{Start: l.StartTime, End: l.EndTime})
+		}
+	}
+
+	return schedule, nil
+}
+
+// loadResources reads an organization's active resources and the bookings
+// holding them between from and to
+func (s *Schedule) loadResources(db *gorm.DB, orgID string, from, to time.Time, excludeBookingID string) error {
+	if err := db.Where("organization_id = ? AND is_active = ?", orgID, true).Order("name").Find(&s.Resources).Error; err != nil {
+		return err
+	}
+
+	query := db.Table("booking_resources").
+		Select("booking_resources.resource_id, booking_resources.quantity, bookings.start_time, bookings.end_time").
+		Joins("JOIN bookings ON bookings.id = booking_resources.booking_id").
+		Where("bookings.organization_id = ? AND bookings.deleted_at IS NULL AND bookings.start_time < ? AND bookings.end_time > ? AND bookings.status NOT IN ?",
+			orgID, to, from, []string{"cancelled", "no_show"})
+	if excludeBookingID != "" {
+		query = query.Where("bookings.id != ?", excludeBookingID)
+	}
+	var held []struct {
+		ResourceID string
+		Quantity   int
+		StartTime  time.Time
+		EndTime    time.Time
+	}
+	if err := query.Scan(&held).Error; err != nil {
+		return err
+	}
+	for _, h := range held {
+		s.ResourceBusy[h.ResourceID] = append(s.ResourceBusy[h.ResourceID], Usage{Start: h.StartTime, End: h.EndTime, Quantity: h.Quantity})
+	}
+	return nil
+}
+
+// AllocateResources checks an organization's resources can take a booking from
+// start to end and picks the ones it would use
+func AllocateResources(db *gorm.DB, orgID string, requirements []Requirement, start, end time.Time, buffer time.Duration, excludeBookingID string) ([]Allocation, bool, error) {
+	if len(requirements) == 0 {
+		return nil, true, nil
+	}
+	schedule := &Schedule{ResourceBusy: make(map[string][]Usage)}
+	if err := schedule.loadResources(db, orgID, start.Add(-buffer), end.Add(buffer), excludeBookingID); err != nil {
+		return nil, false, err
+	}
+	allocations, ok := schedule.Allocate(requirements, start, end, buffer)
+	return allocations, ok, nil
+}
+
+// Check loads an organization's schedule and reports whether a booking can
+// start at start
+func Check(db *gorm.DB, org *models.Organization, q Query, start time.Time) (Slot, bool, error) {
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	q.From, q.To = start.In(q.Location), start.In(q.Location)
+	schedule, err := Load(db, org, q)
+	if err != nil {
+		return Slot{}, false, err
+	}
+	slot, ok := schedule.Check(q, start)
+	return slot, ok, nil
+}
+
+// Find loads an organization's schedule and lists its open slots for a query
+func Find(db *gorm.DB, org *models.Organization, q Query) ([]Day, error) {
+	schedule, err := Load(db, org, q)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Days(q), nil
+}
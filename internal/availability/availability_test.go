@@ -0,0 +1,176 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func slotTimes(day Day) []string {
+	times := []string{}
+	for _, slot := range day.Slots {
+		times = append(times, slot.Time)
+	}
+	return times
+}
+
+func TestCovers(t *testing.T) {
+	loc := time.UTC
+	windows := []models.StaffAvailability{
+		{Weekday: int(time.Monday), StartTime: "18:00", EndTime: "24:00"},
+		{Weekday: int(time.Tuesday), StartTime: "00:00", EndTime: "06:00"},
+	}
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, loc)
+
+	assert.True(t, Covers(windows, monday.Add(19*time.Hour), monday.Add(22*time.Hour), loc))
+	assert.True(t, Covers(windows, monday.Add(22*time.Hour), monday.Add(30*time.Hour), loc))
+	assert.False(t, Covers(windows, monday.Add(22*time.Hour), monday.Add(31*time.Hour), loc))
+	assert.False(t, Covers(windows, monday.Add(9*time.Hour), monday.Add(12*time.Hour), loc))
+}
+
+func TestDaysFollowLocalHoursAcrossDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	schedule := &Schedule{Hours: models.BusinessHours{
+		SaturdayOpen: "09:00", SaturdayClose: "10:00",
+		SundayOpen: "01:00", SundayClose: "05:00",
+	}}
+
+	// Daylight saving starts at 2am on Sunday 4 October 2026
+	days := schedule.Days(Query{
+		Location: loc,
+		From:     time.Date(2026, 10, 3, 0, 0, 0, 0, loc),
+		To:       time.Date(2026, 10, 4, 0, 0, 0, 0, loc),
+		Duration: time.Hour,
+	})
+	assert.Len(t, days, 2)
+	assert.Equal(t, []string{"09:00"}, slotTimes(days[0]))
+	assert.Equal(t, 9, days[0].Slots[0].Start.Hour())
+	assert.Equal(t, time.Date(2026, 10, 2, 23, 30, 0, 0, time.UTC), days[0].Slots[0].Start.UTC())
+
+	// 2am doesn't exist on the day, so the hour is skipped rather than repeated
+	assert.Equal(t, []string{"01:00", "03:00", "04:00"}, slotTimes(days[1]))
+}
+
+func TestDaysHonourBreaksClosuresAndBookings(t *testing.T) {
+	loc := time.UTC
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, loc)
+	schedule := &Schedule{
+		Hours: models.BusinessHours{
+			MondayOpen: "09:00", MondayClose: "14:00",
+			TuesdayOpen: "09:00", TuesdayClose: "14:00",
+			WednesdayOpen: "09:00", WednesdayClose: "14:00",
+		},
+		Breaks:   []models.BusinessBreak{{StartTime: "12:00", EndTime: "13:00", Label: "Lunch"}},
+		Closures: []models.BusinessClosure{{StartDate: monday.AddDate(0, 0, 1), EndDate: monday.AddDate(0, 0, 1), Reason: "Stocktake"}},
+		Holidays: map[string]string{"2026-10-21": "Show Day"},
+		Busy: map[string][]Interval{
+			"": {{Start: monday.Add(9 * time.Hour), End: monday.Add(9*time.Hour + 30*time.Minute)}},
+		},
+	}
+
+	days := schedule.Days(Query{
+		Location: loc,
+		From:     monday,
+		To:       monday.AddDate(0, 0, 3),
+		Duration: time.Hour,
+		Buffer:   15 * time.Minute,
+		Interval: 30 * time.Minute,
+	})
+	assert.Len(t, days, 4)
+
+	// The booking holds its buffer after it, and nothing runs into lunch
+	assert.Equal(t, []string{"10:00", "10:30", "11:00", "13:00"}, slotTimes(days[0]))
+	assert.True(t, days[1].Closed)
+	assert.Equal(t, "Stocktake", days[1].Reason)
+	assert.Equal(t, "Show Day", days[2].Reason)
+	assert.True(t, days[3].Closed)
+	assert.Empty(t, days[3].Reason)
+}
+
+func TestDaysForStaff(t *testing.T) {
+	loc := time.UTC
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, loc)
+	schedule := &Schedule{
+		Hours: models.BusinessHours{MondayOpen: "09:00", MondayClose: "13:00"},
+		StaffWindows: map[string][]models.StaffAvailability{
+			"part-time": {{Weekday: int(time.Monday), StartTime: "09:00", EndTime: "11:00"}},
+		},
+		StaffLeave: map[string][]Interval{
+			"on-leave": {{Start: monday, End: monday.AddDate(0, 0, 1)}},
+		},
+		Busy: map[string][]Interval{
+			"":          {{Start: monday.Add(9 * time.Hour), End: monday.Add(10 * time.Hour)}},
+			"part-time": {{Start: monday.Add(9 * time.Hour), End: monday.Add(10 * time.Hour)}},
+		},
+	}
+
+	days := schedule.Days(Query{
+		Location: loc,
+		From:     monday,
+		To:       monday,
+		Duration: time.Hour,
+		StaffIDs: []string{"part-time", "on-leave", "full-time"},
+	})
+	assert.Equal(t, []string{"09:00", "10:00", "11:00", "12:00"}, slotTimes(days[0]))
+	assert.Equal(t, []string{"full-time"}, days[0].Slots[0].StaffIDs)
+	assert.Equal(t, []string{"part-time", "full-time"}, days[0].Slots[1].StaffIDs)
+	assert.Equal(t, []string{"full-time"}, days[0].Slots[2].StaffIDs)
+}
+
+func TestDaysWithResources(t *testing.T) {
+	loc := time.UTC
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, loc)
+	nine, ten := monday.Add(9*time.Hour), monday.Add(10*time.Hour)
+	schedule := &Schedule{
+		Hours: models.BusinessHours{MondayOpen: "09:00", MondayClose: "11:00"},
+		Resources: []models.Resource{
+			{ID: "bay-1", Type: "bay", Capacity: 1},
+			{ID: "bay-2", Type: "bay", Capacity: 1},
+			{ID: "hoist", Type: "hoist", Capacity: 2},
+		},
+		Busy: map[string][]Interval{
+			"": {{Start: nine, End: ten}, {Start: nine, End: ten}},
+		},
+		ResourceBusy: map[string][]Usage{
+			"bay-1": {{Start: nine, End: ten, Quantity: 1}},
+			"hoist": {{Start: nine, End: ten, Quantity: 1}},
+		},
+	}
+	query := Query{Location: loc, From: monday, To: monday, Duration: time.Hour}
+
+	// Other bookings don't block the organization when resources decide capacity
+	query.Requirements = []Requirement{{ResourceType: "bay", Quantity: 1}}
+	days := schedule.Days(query)
+	assert.Equal(t, []string{"09:00", "10:00"}, slotTimes(days[0]))
+	assert.Equal(t, []Allocation{{ResourceID: "bay-2", Quantity: 1}}, days[0].Slots[0].Resources)
+	assert.Equal(t, []Allocation{{ResourceID: "bay-1", Quantity: 1}}, days[0].Slots[1].Resources)
+
+	// Every requirement has to be met at once
+	query.Requirements = []Requirement{{ResourceType: "bay", Quantity: 1}, {ResourceID: "hoist", Quantity: 2}}
+	assert.Equal(t, []string{"10:00"}, slotTimes(schedule.Days(query)[0]))
+
+	query.Requirements = []Requirement{{ResourceType: "bay", Quantity: 2}}
+	assert.Equal(t, []string{"10:00"}, slotTimes(schedule.Days(query)[0]))
+
+	query.Requirements = []Requirement{{ResourceType: "room", Quantity: 1}}
+	assert.Empty(t, schedule.Days(query)[0].Slots)
+}
+
+func TestPeakUsage(t *testing.T) {
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	usages := []Usage{
+		{Start: base, End: base.Add(time.Hour), Quantity: 1},
+		{Start: base.Add(30 * time.Minute), End: base.Add(90 * time.Minute), Quantity: 1},
+		{Start: base.Add(2 * time.Hour), End: base.Add(3 * time.Hour), Quantity: 1},
+	}
+
+	assert.Equal(t, 2, peakUsage(usages, base, base.Add(2*time.Hour), 0))
+	assert.Equal(t, 1, peakUsage(usages, base.Add(75*time.Minute), base.Add(2*time.Hour), 0))
+	assert.Equal(t, 0, peakUsage(usages, base.Add(90*time.Minute), base.Add(2*time.Hour), 0))
+	assert.Equal(t, 2, peakUsage(usages, base.Add(75*time.Minute), base.Add(2*time.Hour), 30*time.Minute))
+}
@@ -20,6 +20,17 @@ type Config struct {
 	SMTPPort           int
 	SMTPUsername       string
 	SMTPPassword       string
+	SMTPFrom           string
+	SMSAPIURL          string // HTTP gateway messages are POSTed to as JSON
+	SMSAPIKey          string
+	SMSFrom            string
+	WhatsAppAPIURL     string
+	WhatsAppPhoneID    string
+	WhatsAppToken      string
+	AppURL             string // Public base URL used in links sent to customers
+	PaymentProvider    string // Card payment provider for deposits and fees; blank collects nothing
+	PaymentCurrency    string
+	RegoLookupProvider string // Registration lookup service; blank only decodes VINs
 }
 
 func Load() *Config {
@@ -36,6 +47,17 @@ func Load() *Config {
 		SMTPPort:           parseInt(getEnv("SMTP_PORT", "587")),
 		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
 		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", ""),
+		SMSAPIURL:          getEnv("SMS_API_URL", ""),
+		SMSAPIKey:          getEnv("SMS_API_KEY", ""),
+		SMSFrom:            getEnv("SMS_FROM", ""),
+		WhatsAppAPIURL:     getEnv("WHATSAPP_API_URL", "https://graph.facebook.com/v17.0"),
+		WhatsAppPhoneID:    getEnv("WHATSAPP_PHONE_ID", ""),
+		WhatsAppToken:      getEnv("WHATSAPP_TOKEN", ""),
+		AppURL:             getEnv("APP_URL", "http://localhost:8080"),
+		PaymentProvider:    getEnv("PAYMENT_PROVIDER", ""),
+		PaymentCurrency:    getEnv("PAYMENT_CURRENCY", "AUD"),
+		RegoLookupProvider: getEnv("REGO_LOOKUP_PROVIDER", ""),
 	}
 }
 
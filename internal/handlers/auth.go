@@ -28,6 +28,7 @@ type UserResponse struct {
 	FirstName      string    `json:"first_name"`
 	LastName       string    `json:"last_name"`
 	Phone          string    `json:"phone"`
+	Gender         string    `json:"gender,omitempty"`
 	Role           string    `json:"role"`
 	OrganizationID string    `json:"organization_id"`
 	IsActive       bool      `json:"is_active"`
@@ -142,6 +143,7 @@ func (h *Handler) Login(c *gin.Context) {
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Phone:          user.Phone,
+		Gender:         user.Gender,
 		Role:           user.Role,
 		OrganizationID: user.OrganizationID,
 		IsActive:       user.IsActive,
@@ -32,6 +32,9 @@ func setupTestHandler() (*Handler, *gin.Engine) {
 		&models.Customer{},
 		&models.Service{},
 		&models.Booking{},
+		&models.BookingStep{},
+		&models.Discount{},
+		&models.TaxRate{},
 		&models.Participant{},
 	)
 	if err != nil {
@@ -75,6 +78,32 @@ func setupTestHandler() (*Handler, *gin.Engine) {
 	return handler, router
 }
 
+// doRequest sends payload as JSON through the router signed in as the test
+// user and decodes the JSON response
+func doRequest(handler *Handler, router *gin.Engine, method, path string, payload interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	return sendRequest(router, method, path, payload, "Bearer "+getTestToken(handler))
+}
+
+// doPublicRequest is doRequest without signing in
+func doPublicRequest(router *gin.Engine, method, path string, payload interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	return sendRequest(router, method, path, payload, "")
+}
+
+func sendRequest(router *gin.Engine, method, path string, payload interface{}, authorization string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
 func TestLogin(t *testing.T) {
 	_, router := setupTestHandler()
 
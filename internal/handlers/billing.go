@@ -1,27 +1,100 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// BillingRecord represents a billing/invoice record
+// BillingRecord is the API view of a persisted participant invoice
 type BillingRecord struct {
-	ID            string    `json:"id"`
-	ParticipantID string    `json:"participant_id"`
-	ShiftIDs      []string  `json:"shift_ids"`
-	InvoiceNumber string    `json:"invoice_number"`
-	Amount        float64   `json:"amount"`
-	Status        string    `json:"status"` // draft, sent, paid, overdue
-	IssueDate     time.Time `json:"issue_date"`
-	DueDate       time.Time `json:"due_date"`
-	PaidDate      *time.Time `json:"paid_date,omitempty"`
-	Description   string    `json:"description"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	models.ParticipantInvoice
+	ShiftIDs []string `json:"shift_ids"`
+	Balance  float64  `json:"balance"`
+}
+
+func newBillingRecord(invoice models.ParticipantInvoice) BillingRecord {
+	shiftIDs := make([]string, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		shiftIDs = append(shiftIDs, line.ShiftID)
+	}
+	return BillingRecord{
+		ParticipantInvoice: invoice,
+		ShiftIDs:           shiftIDs,
+		Balance:            roundCurrency(invoice.Amount - invoice.PaidAmount),
+	}
+}
+
+// roundCurrency rounds an amount to whole cents
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// markOverdueInvoices moves issued invoices past their due date to overdue.
+// It runs from the job queue.
+func (h *Handler) markOverdueInvoices(ctx context.Context) error {
+	return h.DB.WithContext(ctx).Model(&models.ParticipantInvoice{}).
+		Where("status = ? AND due_date < ?", "sent", time.Now()).
+		Update("status", "overdue").Error
+}
+
+// nextInvoiceNumber returns the next sequential invoice number for the
+// organization. tx must be the transaction that creates the invoice.
+func nextInvoiceNumber(tx *gorm.DB, orgID interface{}, issueDate time.Time) (string, error) {
+	number, err := nextSequenceValue(tx, orgID, "participant_invoice", func() (int64, error) {
+		var count int64
+		err := tx.Unscoped().Model(&models.ParticipantInvoice{}).Where("organization_id = ?", orgID).Count(&count).Error
+		return count, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INV-%d-%05d", issueDate.Year(), number), nil
+}
+
+// findInvoice loads an invoice and its lines scoped to the organization
+func (h *Handler) findInvoice(c *gin.Context, db *gorm.DB, orgID interface{}) (*models.ParticipantInvoice, bool) {
+	var invoice models.ParticipantInvoice
+	if err := db.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Preload("Lines").Preload("Participant").
+		First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVOICE_NOT_FOUND",
+					"message": "Invoice not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice",
+			},
+		})
+		return nil, false
+	}
+	return &invoice, true
+}
+
+// adjustParticipantBudget draws down (positive amount) or restores (negative
+// amount) a participant's used budget and keeps the remaining budget in sync
+func adjustParticipantBudget(tx *gorm.DB, participantID string, amount float64) error {
+	return tx.Model(&models.Participant{}).Where("id = ?", participantID).Updates(map[string]interface{}{
+		"funding_used_budget":      gorm.Expr("funding_used_budget + ?", amount),
+		"funding_remaining_budget": gorm.Expr("funding_total_budget - (funding_used_budget + ?)", amount),
+	}).Error
 }
 
 func (h *Handler) GetBilling(c *gin.Context) {
@@ -37,12 +110,46 @@ func (h *Handler) GetBilling(c *gin.Context) {
 		return
 	}
 
-	// For MVP, generate mock billing data based on completed shifts
-	var shifts []models.Shift
-	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
-		Where("participants.organization_id = ? AND shifts.status = ?", orgID, "completed")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	participantID := c.Query("participant_id")
+	status := c.Query("status")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := h.DB.Model(&models.ParticipantInvoice{}).Where("organization_id = ?", orgID)
+	if participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if startDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", startDate); err == nil {
+			query = query.Where("issue_date >= ?", parsedDate)
+		}
+	}
+	if endDate != "" {
+		if parsedDate, err := time.Parse("2006-01-02", endDate); err == nil {
+			query = query.Where("issue_date < ?", parsedDate.Add(24*time.Hour))
+		}
+	}
+
+	var total int64
+	query.Count(&total)
 
-	if err := query.Preload("Participant").Find(&shifts).Error; err != nil {
+	var invoices []models.ParticipantInvoice
+	if err := query.Preload("Lines").Preload("Participant").
+		Limit(limit).Offset(offset).Order("issue_date DESC, invoice_number DESC").
+		Find(&invoices).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -53,42 +160,9 @@ func (h *Handler) GetBilling(c *gin.Context) {
 		return
 	}
 
-	// Generate mock billing records
-	billingRecords := []BillingRecord{}
-	participantBilling := make(map[string]*BillingRecord)
-
-	for _, shift := range shifts {
-		if _, exists := participantBilling[shift.ParticipantID]; !exists {
-			billingRecord := &BillingRecord{
-				ID:            "bill_" + shift.ParticipantID,
-				ParticipantID: shift.ParticipantID,
-				ShiftIDs:      []string{},
-				InvoiceNumber: "INV-2025-" + shift.ParticipantID[0:8],
-				Amount:        0,
-				Status:        "draft",
-				IssueDate:     time.Now(),
-				DueDate:       time.Now().Add(30 * 24 * time.Hour),
-				Description:   "Care services for " + shift.Participant.FirstName + " " + shift.Participant.LastName,
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-			}
-			participantBilling[shift.ParticipantID] = billingRecord
-		}
-
-		// Calculate shift cost
-		hours := shift.EndTime.Sub(shift.StartTime).Hours()
-		cost := hours * shift.HourlyRate
-		
-		participantBilling[shift.ParticipantID].Amount += cost
-		participantBilling[shift.ParticipantID].ShiftIDs = append(
-			participantBilling[shift.ParticipantID].ShiftIDs, 
-			shift.ID,
-		)
-	}
-
-	// Convert map to slice
-	for _, billing := range participantBilling {
-		billingRecords = append(billingRecords, *billing)
+	billingRecords := make([]BillingRecord, 0, len(invoices))
+	for _, invoice := range invoices {
+		billingRecords = append(billingRecords, newBillingRecord(invoice))
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -96,46 +170,106 @@ func (h *Handler) GetBilling(c *gin.Context) {
 		"data": gin.H{
 			"billing": billingRecords,
 			"pagination": gin.H{
-				"page":        1,
-				"limit":       len(billingRecords),
-				"total":       len(billingRecords),
-				"total_pages": 1,
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
 			},
 		},
 	})
 }
 
 func (h *Handler) GetBillingRecord(c *gin.Context) {
-	billingID := c.Param("id")
-	
-	// For MVP, return mock data
-	billingRecord := BillingRecord{
-		ID:            billingID,
-		ParticipantID: "participant_1",
-		InvoiceNumber: "INV-2025-001",
-		Amount:        450.00,
-		Status:        "sent",
-		IssueDate:     time.Now().Add(-7 * 24 * time.Hour),
-		DueDate:       time.Now().Add(23 * 24 * time.Hour),
-		Description:   "Care services",
-		CreatedAt:     time.Now().Add(-7 * 24 * time.Hour),
-		UpdatedAt:     time.Now(),
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	invoice, ok := h.findInvoice(c, h.DB, orgID)
+	if !ok {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    billingRecord,
+		"data":    newBillingRecord(*invoice),
+	})
+}
+
+// GetUnbilledShifts lists completed shifts that have not been invoiced yet
+func (h *Handler) GetUnbilledShifts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.status = ? AND shifts.invoice_id IS NULL", orgID, "completed")
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("shifts.participant_id = ?", participantID)
+	}
+
+	var shifts []models.Shift
+	if err := query.Preload("Participant").Order("shifts.start_time ASC").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch unbilled shifts",
+			},
+		})
+		return
+	}
+
+	var totalAmount float64
+	for _, shift := range shifts {
+		totalAmount += shift.TotalCost
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shifts":       shifts,
+			"total":        len(shifts),
+			"total_amount": roundCurrency(totalAmount),
+		},
 	})
 }
 
 type GenerateInvoiceRequest struct {
 	ParticipantID string   `json:"participant_id" binding:"required"`
-	ShiftIDs      []string `json:"shift_ids" binding:"required"`
+	ShiftIDs      []string `json:"shift_ids" binding:"required,min=1"`
 	DueDate       string   `json:"due_date"`
 	Description   string   `json:"description"`
 }
 
 func (h *Handler) GenerateInvoice(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
 	var req GenerateInvoiceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -149,28 +283,294 @@ func (h *Handler) GenerateInvoice(c *gin.Context) {
 		return
 	}
 
-	// For MVP, return mock generated invoice
-	invoiceRecord := BillingRecord{
-		ID:            "bill_" + req.ParticipantID + "_" + time.Now().Format("20060102"),
-		ParticipantID: req.ParticipantID,
-		ShiftIDs:      req.ShiftIDs,
-		InvoiceNumber: "INV-2025-" + time.Now().Format("001"),
-		Amount:        375.50, // Mock calculation
-		Status:        "draft",
-		IssueDate:     time.Now(),
-		DueDate:       time.Now().Add(30 * 24 * time.Hour),
-		Description:   req.Description,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+	issueDate := time.Now()
+	dueDate := issueDate.Add(30 * 24 * time.Hour)
+	if req.DueDate != "" {
+		parsedDate, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil || parsedDate.Before(issueDate.Truncate(24*time.Hour)) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DUE_DATE",
+					"message": "Due date must be a future date in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		dueDate = parsedDate
+	}
+
+	// Verify participant belongs to organization
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", req.ParticipantID, orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PARTICIPANT",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	// De-duplicate requested shift IDs so a shift can only appear once
+	seen := make(map[string]bool)
+	shiftIDs := make([]string, 0, len(req.ShiftIDs))
+	for _, id := range req.ShiftIDs {
+		if !seen[id] {
+			seen[id] = true
+			shiftIDs = append(shiftIDs, id)
+		}
+	}
+
+	tx := h.DB.Begin()
+
+	var shifts []models.Shift
+	if err := tx.Where("id IN ? AND participant_id = ?", shiftIDs, req.ParticipantID).
+		Order("start_time ASC").Find(&shifts).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shifts",
+			},
+		})
+		return
+	}
+
+	if len(shifts) != len(shiftIDs) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SHIFTS",
+				"message": "One or more shifts were not found for this participant",
+			},
+		})
+		return
+	}
+
+	for _, shift := range shifts {
+		if shift.Status != "completed" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_COMPLETED",
+					"message": "Only completed shifts can be invoiced",
+					"details": shift.ID,
+				},
+			})
+			return
+		}
+		if shift.InvoiceID != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_ALREADY_BILLED",
+					"message": "Shift has already been invoiced",
+					"details": shift.ID,
+				},
+			})
+			return
+		}
+	}
+
+	// Shifts already submitted to the NDIS bulk payment system can't also be invoiced
+	var claimedShifts int64
+	tx.Model(&models.NDISClaimLine{}).Where("shift_id IN ? AND status NOT IN ?", shiftIDs, []string{"rejected", "superseded"}).Count(&claimedShifts)
+	if claimedShifts > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_CLAIMED",
+				"message": "One or more shifts have already been claimed through NDIS bulk payment",
+			},
+		})
+		return
+	}
+
+	invoiceNumber, err := nextInvoiceNumber(tx, orgID, issueDate)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to allocate invoice number",
+			},
+		})
+		return
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "Care services for " + participant.FirstName + " " + participant.LastName
+	}
+
+	invoice := models.ParticipantInvoice{
+		OrganizationID: fmt.Sprintf("%v", orgID),
+		ParticipantID:  req.ParticipantID,
+		InvoiceNumber:  invoiceNumber,
+		Status:         "draft",
+		IssueDate:      issueDate,
+		DueDate:        dueDate,
+		Description:    description,
+		CreatedBy:      c.GetString("user_id"),
 	}
 
+	var amount float64
+	for _, shift := range shifts {
+		hours := shift.EndTime.Sub(shift.StartTime).Hours()
+		lineAmount := roundCurrency(hours * shift.HourlyRate)
+		amount += lineAmount
+		invoice.Lines = append(invoice.Lines, models.ParticipantInvoiceLine{
+			ShiftID:     shift.ID,
+			ServiceDate: shift.StartTime,
+			ServiceType: shift.ServiceType,
+			Hours:       roundCurrency(hours),
+			HourlyRate:  shift.HourlyRate,
+			Amount:      lineAmount,
+		})
+	}
+	invoice.Amount = roundCurrency(amount)
+
+	if err := tx.Create(&invoice).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create invoice",
+			},
+		})
+		return
+	}
+
+	// Lock the shifts to this invoice; the IS NULL guard stops a concurrent
+	// request from billing the same shift twice
+	result := tx.Model(&models.Shift{}).
+		Where("id IN ? AND invoice_id IS NULL", shiftIDs).
+		Update("invoice_id", invoice.ID)
+	if result.Error != nil || result.RowsAffected != int64(len(shiftIDs)) {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_ALREADY_BILLED",
+				"message": "One or more shifts were invoiced by another request",
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save invoice",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Participant").First(&invoice, "id = ?", invoice.ID)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"data":    invoiceRecord,
+		"data":    newBillingRecord(invoice),
 		"message": "Invoice generated successfully",
 	})
 }
 
+// SendInvoice issues a draft invoice and draws the amount down from the participant's budget
+func (h *Handler) SendInvoice(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	// Lock the invoice so concurrent sends can't both draw down the budget
+	tx := h.DB.Begin()
+
+	invoice, ok := h.findInvoice(c, tx.Clauses(clause.Locking{Strength: "UPDATE"}), orgID)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	if invoice.Status != "draft" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TRANSITION",
+				"message": "Only draft invoices can be sent",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := tx.Model(invoice).Updates(map[string]interface{}{
+		"status":     "sent",
+		"issue_date": now,
+		"sent_at":    now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to send invoice",
+			},
+		})
+		return
+	}
+
+	if err := adjustParticipantBudget(tx, invoice.ParticipantID, invoice.Amount); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update participant budget",
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to send invoice",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Participant").First(invoice, "id = ?", invoice.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newBillingRecord(*invoice),
+		"message": "Invoice sent successfully",
+	})
+}
+
 type PaymentRequest struct {
 	Amount      float64   `json:"amount" binding:"required,gt=0"`
 	PaymentDate time.Time `json:"payment_date"`
@@ -179,8 +579,18 @@ type PaymentRequest struct {
 }
 
 func (h *Handler) MarkAsPaid(c *gin.Context) {
-	billingID := c.Param("id")
-	
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
 	var req PaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -194,38 +604,224 @@ func (h *Handler) MarkAsPaid(c *gin.Context) {
 		return
 	}
 
-	// For MVP, return updated record
-	now := time.Now()
-	billingRecord := BillingRecord{
-		ID:            billingID,
-		ParticipantID: "participant_1",
-		InvoiceNumber: "INV-2025-001",
-		Amount:        req.Amount,
-		Status:        "paid",
-		IssueDate:     now.Add(-7 * 24 * time.Hour),
-		DueDate:       now.Add(23 * 24 * time.Hour),
-		PaidDate:      &now,
-		Description:   "Care services",
-		CreatedAt:     now.Add(-7 * 24 * time.Hour),
-		UpdatedAt:     now,
+	// Lock the invoice so concurrent payments can't both add to the paid amount
+	tx := h.DB.Begin()
+
+	invoice, ok := h.findInvoice(c, tx.Clauses(clause.Locking{Strength: "UPDATE"}), orgID)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	if !invoice.IsOutstanding() {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TRANSITION",
+				"message": "Payments can only be recorded against sent or overdue invoices",
+			},
+		})
+		return
+	}
+
+	balance := roundCurrency(invoice.Amount - invoice.PaidAmount)
+	if roundCurrency(req.Amount) > balance {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OVERPAYMENT",
+				"message": fmt.Sprintf("Payment exceeds the outstanding balance of %.2f", balance),
+			},
+		})
+		return
+	}
+
+	paymentDate := req.PaymentDate
+	if paymentDate.IsZero() {
+		paymentDate = time.Now()
+	}
+
+	paidAmount := roundCurrency(invoice.PaidAmount + req.Amount)
+	updates := map[string]interface{}{
+		"paid_amount":       paidAmount,
+		"payment_method":    req.Method,
+		"payment_reference": req.Reference,
+	}
+	if paidAmount >= invoice.Amount {
+		updates["status"] = "paid"
+		updates["paid_date"] = paymentDate
+	}
+
+	if err := tx.Model(invoice).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record payment",
+			},
+		})
+		return
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record payment",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Participant").First(invoice, "id = ?", invoice.ID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    billingRecord,
+		"data":    newBillingRecord(*invoice),
 		"message": "Payment recorded successfully",
 	})
 }
 
+// CancelInvoice voids an unpaid invoice, releasing its shifts for re-billing
+// and restoring any budget it had drawn down
+func (h *Handler) CancelInvoice(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	// Lock the invoice so a payment or send can't land while it's cancelled
+	tx := h.DB.Begin()
+
+	invoice, ok := h.findInvoice(c, tx.Clauses(clause.Locking{Strength: "UPDATE"}), orgID)
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	if invoice.Status == "paid" || invoice.Status == "cancelled" || invoice.PaidAmount > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TRANSITION",
+				"message": "Only unpaid invoices can be cancelled",
+			},
+		})
+		return
+	}
+
+	wasIssued := invoice.IsOutstanding()
+	now := time.Now()
+	if err := tx.Model(invoice).Updates(map[string]interface{}{
+		"status":       "cancelled",
+		"cancelled_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to cancel invoice",
+			},
+		})
+		return
+	}
+
+	if err := tx.Model(&models.Shift{}).Where("invoice_id = ?", invoice.ID).
+		Update("invoice_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to release invoiced shifts",
+			},
+		})
+		return
+	}
+
+	if wasIssued {
+		if err := adjustParticipantBudget(tx, invoice.ParticipantID, -invoice.Amount); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to restore participant budget",
+				},
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to cancel invoice",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Lines").Preload("Participant").First(invoice, "id = ?", invoice.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newBillingRecord(*invoice),
+		"message": "Invoice cancelled successfully",
+	})
+}
+
 func (h *Handler) DownloadInvoice(c *gin.Context) {
-	billingID := c.Param("id")
-	
-	// For MVP, return mock PDF response
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	invoice, ok := h.findInvoice(c, h.DB, orgID)
+	if !ok {
+		return
+	}
+
+	var lines []models.ParticipantInvoiceLine
+	if err := h.DB.Where("invoice_id = ?", invoice.ID).Preload("Shift").Order("service_date ASC").Find(&lines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch invoice lines",
+			},
+		})
+		return
+	}
+
 	c.Header("Content-Type", "application/pdf")
-	c.Header("Content-Disposition", "attachment; filename=invoice_"+billingID+".pdf")
-	
-	// Return mock PDF content (in real implementation, generate actual PDF)
-	mockPDFContent := "%PDF-1.4\n1 0 obj\n<<\n/Type /Catalog\n/Pages 2 0 R\n>>\nendobj\n\n2 0 obj\n<<\n/Type /Pages\n/Kids [3 0 R]\n/Count 1\n>>\nendobj\n\n3 0 obj\n<<\n/Type /Page\n/Parent 2 0 R\n/MediaBox [0 0 612 792]\n>>\nendobj\n\nxref\n0 4\n0000000000 65535 f \n0000000010 00000 n \n0000000053 00000 n \n0000000104 00000 n \ntrailer\n<<\n/Size 4\n/Root 1 0 R\n>>\nstartxref\n164\n%%EOF"
-	
-	c.String(http.StatusOK, mockPDFContent)
-}
\ No newline at end of file
+	c.Header("Content-Disposition", "attachment; filename="+invoice.InvoiceNumber+".pdf")
+	c.Status(http.StatusOK)
+
+	head := h.letterhead(orgID, "INVOICE", invoice.InvoiceNumber)
+	if err := renderInvoicePDF(c.Writer, head, invoice, lines, h.organizationLocation(orgID)); err != nil {
+		c.Error(err)
+	}
+}
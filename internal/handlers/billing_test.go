@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceLifecycle(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.ParticipantInvoice{}, &models.ParticipantInvoiceLine{}, &models.NDISClaimLine{},
+		&models.NumberSequence{})
+
+	participant := models.Participant{
+		ID:             "billing-participant",
+		FirstName:      "Billing",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "BILL123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+		Funding: models.FundingInformation{
+			TotalBudget:     10000,
+			RemainingBudget: 10000,
+		},
+	}
+	handler.DB.Create(&participant)
+
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	shifts := []models.Shift{
+		{ID: "billing-shift-1", ParticipantID: participant.ID, StaffID: "test-user", StartTime: start, EndTime: start.Add(2 * time.Hour), ServiceType: "Personal Care", Location: "Home", Status: "completed", HourlyRate: 60},
+		{ID: "billing-shift-2", ParticipantID: participant.ID, StaffID: "test-user", StartTime: start.Add(24 * time.Hour), EndTime: start.Add(27 * time.Hour), ServiceType: "Personal Care", Location: "Home", Status: "completed", HourlyRate: 50},
+		{ID: "billing-shift-3", ParticipantID: participant.ID, StaffID: "test-user", StartTime: start.Add(48 * time.Hour), EndTime: start.Add(50 * time.Hour), ServiceType: "Personal Care", Location: "Home", Status: "scheduled", HourlyRate: 50},
+	}
+	for i := range shifts {
+		handler.DB.Create(&shifts[i])
+	}
+
+	var invoiceID string
+
+	t.Run("Rejects shifts that are not completed", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": participant.ID,
+			"shift_ids":      []string{"billing-shift-3"},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Generates a draft invoice from completed shifts", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": participant.ID,
+			"shift_ids":      []string{"billing-shift-1", "billing-shift-2"},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		data := response["data"].(map[string]interface{})
+		invoiceID = data["id"].(string)
+		assert.Equal(t, "draft", data["status"])
+		assert.Equal(t, fmt.Sprintf("INV-%d-00001", time.Now().Year()), data["invoice_number"])
+		assert.Equal(t, 270.0, data["amount"])
+		assert.Len(t, data["shift_ids"], 2)
+
+		var shift models.Shift
+		handler.DB.First(&shift, "id = ?", "billing-shift-1")
+		assert.NotNil(t, shift.InvoiceID)
+	})
+
+	t.Run("Prevents billing the same shift twice", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/billing/generate", map[string]interface{}{
+			"participant_id": participant.ID,
+			"shift_ids":      []string{"billing-shift-2"},
+		})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Sending draws down the participant budget", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/billing/"+invoiceID+"/send", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "sent", response["data"].(map[string]interface{})["status"])
+
+		var updated models.Participant
+		handler.DB.First(&updated, "id = ?", participant.ID)
+		assert.Equal(t, 270.0, updated.Funding.UsedBudget)
+		assert.Equal(t, 9730.0, updated.Funding.RemainingBudget)
+	})
+
+	t.Run("Invoices past their due date go overdue", func(t *testing.T) {
+		handler.DB.Model(&models.ParticipantInvoice{}).Where("id = ?", invoiceID).Update("due_date", time.Now().AddDate(0, 0, -1))
+		assert.NoError(t, handler.markOverdueInvoices(context.Background()))
+
+		var invoice models.ParticipantInvoice
+		handler.DB.First(&invoice, "id = ?", invoiceID)
+		assert.Equal(t, "overdue", invoice.Status)
+	})
+
+	t.Run("Overpayment is rejected", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 500,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Partial then full payment settles the invoice", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount": 100,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "overdue", response["data"].(map[string]interface{})["status"])
+
+		w, response = doRequest(handler, router, "POST", "/api/v1/billing/"+invoiceID+"/payment", map[string]interface{}{
+			"amount":    170,
+			"reference": "NDIA-REMIT-1",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "paid", data["status"])
+		assert.Equal(t, 0.0, data["balance"])
+	})
+
+	t.Run("Invoice ids are stable across reads", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/billing", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		billing := response["data"].(map[string]interface{})["billing"].([]interface{})
+		assert.Len(t, billing, 1)
+		assert.Equal(t, invoiceID, billing[0].(map[string]interface{})["id"])
+	})
+
+	t.Run("Invoice numbers carry on from the organization's sequence", func(t *testing.T) {
+		tx := handler.DB.Begin()
+		defer tx.Rollback()
+		first, err := nextInvoiceNumber(tx, "test-org", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		second, err := nextInvoiceNumber(tx, "test-org", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-2025-00002", first)
+		assert.Equal(t, "INV-2025-00003", second)
+	})
+}
+
+func TestClaimBatchLifecycle(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.ParticipantInvoice{}, &models.NDISClaimBatch{}, &models.NDISClaimLine{})
+
+	handler.DB.Create(&models.Participant{ID: "claim-participant", FirstName: "Claim", LastName: "Participant",
+		DateOfBirth: time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC), NDISNumber: "430000001", OrganizationID: "test-org", IsActive: true})
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"claim-shift-1", "claim-shift-2"} {
+		handler.DB.Create(&models.Shift{ID: id, ParticipantID: "claim-participant", StaffID: "test-user", StartTime: start.AddDate(0, 0, i),
+			EndTime: start.AddDate(0, 0, i).Add(2 * time.Hour), ServiceType: "Personal Care", Location: "Home", Status: "completed", HourlyRate: 65})
+	}
+
+	createBatch := func() (*httptest.ResponseRecorder, map[string]interface{}) {
+		return doRequest(handler, router, "POST", "/api/v1/billing/claims", map[string]interface{}{
+			"registration_number":  "4050000001",
+			"start_date":           "2025-03-01",
+			"end_date":             "2025-03-31",
+			"default_support_item": "01_011_0107_1_1",
+		})
+	}
+	export := func(batchID string) [][]string {
+		req := httptest.NewRequest("GET", "/api/v1/billing/claims/"+batchID+"/export", nil)
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		records, err := csv.NewReader(w.Body).ReadAll()
+		assert.NoError(t, err)
+		return records[1:]
+	}
+
+	var batchID string
+	references := make(map[string]string)
+	t.Run("Batches claim each shift once", func(t *testing.T) {
+		w, response := createBatch()
+		assert.Equal(t, http.StatusCreated, w.Code)
+		batch := response["data"].(map[string]interface{})["batch"].(map[string]interface{})
+		batchID = batch["id"].(string)
+		assert.Equal(t, 260.0, batch["total_amount"])
+		for _, l := range batch["lines"].([]interface{}) {
+			line := l.(map[string]interface{})
+			references[line["shift_id"].(string)] = line["claim_reference"].(string)
+		}
+		assert.Len(t, references, 2)
+
+		w, response = createBatch()
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "NO_CLAIMABLE_SHIFTS", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Export writes the bulk payment file", func(t *testing.T) {
+		records := export(batchID)
+		assert.Len(t, records, 2)
+		assert.Equal(t, "430000001", records[0][1])
+		assert.Equal(t, "01_011_0107_1_1", records[0][4])
+
+		var batch models.NDISClaimBatch
+		handler.DB.First(&batch, "id = ?", batchID)
+		assert.Equal(t, "submitted", batch.Status)
+	})
+
+	importRemittance := func() map[string]interface{} {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", "remittance.csv")
+		part.Write([]byte("ClaimReference,PaidTotalAmount,Payment Request Status,Error Message\n" +
+			references["claim-shift-1"] + ",130.00,SUCCESSFUL,\n" +
+			references["claim-shift-2"] + ",0,ERROR,Service booking not found\n"))
+		writer.Close()
+		req := httptest.NewRequest("POST", "/api/v1/billing/claims/"+batchID+"/remittance", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["data"].(map[string]interface{})
+	}
+
+	t.Run("Remittance settles and rejects lines", func(t *testing.T) {
+		result := importRemittance()
+		assert.Equal(t, 1.0, result["paid"])
+		assert.Equal(t, 1.0, result["rejected"])
+
+		var batch models.NDISClaimBatch
+		handler.DB.First(&batch, "id = ?", batchID)
+		assert.Equal(t, "reconciled", batch.Status)
+		assert.Equal(t, 130.0, batch.PaidAmount)
+		// Only the rejected line is left to resubmit
+		records := export(batchID)
+		assert.Len(t, records, 1)
+		assert.Equal(t, references["claim-shift-2"], records[0][5])
+	})
+
+	t.Run("Importing the same remittance again changes nothing", func(t *testing.T) {
+		var before models.NDISClaimLine
+		handler.DB.First(&before, "claim_reference = ?", references["claim-shift-1"])
+
+		result := importRemittance()
+		assert.Equal(t, 0.0, result["paid"])
+		assert.Equal(t, 0.0, result["rejected"])
+		assert.Len(t, result["already_processed"], 2)
+
+		var after models.NDISClaimLine
+		handler.DB.First(&after, "claim_reference = ?", references["claim-shift-1"])
+		assert.Equal(t, before.ProcessedAt.UnixNano(), after.ProcessedAt.UnixNano())
+		var batch models.NDISClaimBatch
+		handler.DB.First(&batch, "id = ?", batchID)
+		assert.Equal(t, 130.0, batch.PaidAmount)
+	})
+
+	t.Run("A rejected shift claimed again leaves its old batch", func(t *testing.T) {
+		w, response := createBatch()
+		assert.Equal(t, http.StatusCreated, w.Code)
+		lines := response["data"].(map[string]interface{})["batch"].(map[string]interface{})["lines"].([]interface{})
+		assert.Len(t, lines, 1)
+		assert.Equal(t, "claim-shift-2", lines[0].(map[string]interface{})["shift_id"])
+
+		var old models.NDISClaimLine
+		handler.DB.First(&old, "claim_reference = ?", references["claim-shift-2"])
+		assert.Equal(t, "superseded", old.Status)
+		assert.Len(t, export(batchID), 0)
+
+		w, _ = createBatch()
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestParseRemittanceCSV(t *testing.T) {
+	file := "\ufeffRegistrationNumber,NDISNumber,ClaimReference,Payment Request Number,PaidTotalAmount,Payment Request Status,Error Message\n" +
+		"4050000001,430000001,BPR-20250101-abc123-0001,PR100,\"$1,250.50\",SUCCESSFUL,\n" +
+		"4050000001,430000002,BPR-20250101-abc123-0002,PR101,0,ERROR,Participant plan has insufficient funds\n" +
+		"4050000001,430000003,BPR-20250101-abc123-0003,,,PENDING_PAYMENT,\n"
+
+	rows, err := parseRemittanceCSV(strings.NewReader(file))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.Equal(t, "BPR-20250101-abc123-0001", rows[0].ClaimReference)
+	assert.Equal(t, 1250.50, rows[0].PaidAmount)
+	assert.Equal(t, "PR100", rows[0].PaymentRequestNumber)
+	assert.Equal(t, "paid", remittanceOutcome(rows[0]))
+
+	assert.Equal(t, "rejected", remittanceOutcome(rows[1]))
+	assert.Equal(t, "Participant plan has insufficient funds", rows[1].Message)
+
+	assert.Equal(t, "", remittanceOutcome(rows[2]))
+
+	_, err = parseRemittanceCSV(strings.NewReader("NDISNumber,Amount\n1,2\n"))
+	assert.Error(t, err)
+}
+
+func TestBulkPaymentCSVDates(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	assert.NoError(t, err)
+
+	// 7am AEDT is the previous day in UTC
+	from := time.Date(2025, 3, 4, 7, 0, 0, 0, loc).UTC()
+	batch := &models.NDISClaimBatch{RegistrationNumber: "4050000001", Lines: []models.NDISClaimLine{
+		{NDISNumber: "430000001", SupportsFrom: from, SupportsTo: from.Add(2 * time.Hour), Status: "pending"},
+	}}
+
+	var out bytes.Buffer
+	assert.NoError(t, writeBulkPaymentCSV(&out, batch, "12345678901", loc))
+	records, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-03-04", records[1][2])
+	assert.Equal(t, "2025-03-04", records[1][3])
+}
@@ -0,0 +1,587 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/jobs"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/payments"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"gorm.io/gorm"
+)
+
+// tenderAccount stands for the account the payment provider pays out to in
+// bookingPaymentPostings
+const tenderAccount = "tender"
+
+// bookingPaymentPostings gives the mapping key debited and the key credited
+// for each kind of booking payment. Deposits are held as a liability until
+// they're refunded or forfeited.
+var bookingPaymentPostings = map[string][2]string{
+	"deposit":     {tenderAccount, finance.AccountCustomerDeposits},
+	"refund":      {finance.AccountCustomerDeposits, tenderAccount},
+	"forfeit":     {finance.AccountCustomerDeposits, finance.AccountBookingFees},
+	"no_show_fee": {tenderAccount, finance.AccountBookingFees},
+}
+
+var bookingPaymentDescriptions = map[string]string{
+	"deposit":     "Booking deposit received",
+	"refund":      "Booking deposit refunded",
+	"forfeit":     "Booking deposit forfeited",
+	"no_show_fee": "No-show fee charged",
+}
+
+type bookingPaymentPayload struct {
+	PaymentID string `json:"payment_id"`
+}
+
+// bookingDeposit is the deposit due on a set of services
+func bookingDeposit(services []models.Service) float64 {
+	var deposit float64
+	for _, service := range services {
+		switch service.DepositType {
+		case "fixed":
+			deposit += service.DepositValue
+		case "percentage":
+			deposit += service.Price * service.DepositValue / 100
+		}
+	}
+	return roundCurrency(deposit)
+}
+
+// holdForDeposit makes a new booking wait for its deposit if its services
+// need one. The booking keeps its slot until the hold expires.
+func (h *Handler) holdForDeposit(org *models.Organization, booking *models.Booking, services []models.Service) {
+	deposit := bookingDeposit(services)
+	if h.Payments == nil || deposit <= 0 {
+		return
+	}
+	minutes := org.BookingSettings.DepositHoldMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+	expires := time.Now().Add(time.Duration(minutes) * time.Minute)
+	booking.Status = "pending_payment"
+	booking.DepositAmount, booking.DepositStatus, booking.HoldExpiresAt = deposit, "pending", &expires
+}
+
+// requestDeposit starts collecting a held booking's deposit and returns the
+// payment with the checkout the customer pays at
+func (h *Handler) requestDeposit(ctx context.Context, booking *models.Booking) (*models.BookingPayment, error) {
+	var customer models.Customer
+	if err := h.DB.Where("id = ?", booking.CustomerID).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	payment := models.BookingPayment{
+		OrganizationID: booking.OrganizationID,
+		BookingID:      booking.ID,
+		CustomerID:     booking.CustomerID,
+		Kind:           "deposit",
+		Amount:         booking.DepositAmount,
+		Currency:       h.Config.PaymentCurrency,
+		Status:         "pending",
+		Provider:       h.Payments.Name(),
+	}
+	if err := h.DB.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+
+	result, err := h.Payments.Create(ctx, payments.Request{
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Description:   "Booking deposit",
+		Reference:     payment.ID,
+		CustomerEmail: customer.Email,
+	})
+	if err != nil {
+		h.DB.Model(&payment).Updates(map[string]interface{}{"status": "failed", "failure_reason": err.Error()})
+		return nil, err
+	}
+	payment.ProviderRef, payment.CheckoutURL = result.Ref, result.CheckoutURL
+	if err := h.DB.Model(&payment).Updates(map[string]interface{}{"provider_ref": result.Ref, "checkout_url": result.CheckoutURL}).Error; err != nil {
+		return nil, err
+	}
+	return &payment, h.applyDepositResult(&payment, result)
+}
+
+// releaseHeldBooking gives up a booking that is still waiting for its deposit
+func (h *Handler) releaseHeldBooking(booking *models.Booking, depositStatus string) (bool, error) {
+	released := h.DB.Model(&models.Booking{}).Where("id = ? AND status = ?", booking.ID, "pending_payment").
+		Updates(map[string]interface{}{"status": "cancelled", "deposit_status": depositStatus, "hold_expires_at": nil})
+	if released.Error != nil || released.RowsAffected == 0 {
+		return false, released.Error
+	}
+	booking.Status, booking.DepositStatus, booking.HoldExpiresAt = "cancelled", depositStatus, nil
+	return true, h.dropPendingDeposit(booking, depositStatus)
+}
+
+// dropPendingDeposit stops waiting for an unpaid deposit, either because the
+// booking was cancelled or because staff confirmed it without one
+func (h *Handler) dropPendingDeposit(booking *models.Booking, depositStatus string) error {
+	if err := h.DB.Model(&models.Booking{}).Where("id = ? AND deposit_status = ?", booking.ID, "pending").
+		Updates(map[string]interface{}{"deposit_status": depositStatus, "hold_expires_at": nil}).Error; err != nil {
+		return err
+	}
+	return h.DB.Model(&models.BookingPayment{}).
+		Where("booking_id = ? AND kind = ? AND status = ?", booking.ID, "deposit", "pending").
+		Update("status", "cancelled").Error
+}
+
+// applyDepositResult records what the provider says about a deposit. A paid
+// deposit confirms the booking it was holding, or is refunded if the hold
+// ran out first.
+func (h *Handler) applyDepositResult(payment *models.BookingPayment, result *payments.Result) error {
+	switch result.Status {
+	case payments.Failed:
+		payment.Status, payment.FailureReason = "failed", result.FailureReason
+		return h.DB.Model(&models.BookingPayment{}).Where("id = ? AND status = ?", payment.ID, "pending").
+			Updates(map[string]interface{}{"status": "failed", "failure_reason": result.FailureReason}).Error
+	case payments.Succeeded:
+	default:
+		return nil
+	}
+
+	// The provider may report the same payment more than once; only the first
+	// counts. It stays pending if it can't be posted, e.g. to a closed period.
+	now := time.Now()
+	var counted bool
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		paid := tx.Model(&models.BookingPayment{}).Where("id = ? AND status IN ?", payment.ID, []string{"pending", "cancelled"}).
+			Updates(map[string]interface{}{"status": "succeeded", "payment_method": result.PaymentMethod, "checkout_url": "", "paid_at": now})
+		if paid.Error != nil || paid.RowsAffected == 0 {
+			return paid.Error
+		}
+		counted = true
+		succeeded := *payment
+		succeeded.Status, succeeded.PaymentMethod, succeeded.CheckoutURL, succeeded.PaidAt = "succeeded", result.PaymentMethod, "", &now
+		if err := h.postBookingPayment(tx, &succeeded); err != nil {
+			return err
+		}
+		*payment = succeeded
+		return nil
+	})
+	if err != nil || !counted {
+		return err
+	}
+
+	var booking models.Booking
+	if err := h.DB.Where("id = ?", payment.BookingID).First(&booking).Error; err != nil {
+		return err
+	}
+	if booking.Status != "pending_payment" {
+		if err := h.DB.Model(&booking).Update("deposit_status", "paid").Error; err != nil {
+			return err
+		}
+		if booking.Status == "cancelled" {
+			// The hold ran out before the payment landed, so give the money back
+			return h.refundDeposit(&booking, payment)
+		}
+		return nil
+	}
+
+	var org models.Organization
+	if err := h.DB.Where("id = ?", booking.OrganizationID).First(&org).Error; err != nil {
+		return err
+	}
+	status := "scheduled"
+	if org.BookingSettings.RequireApproval {
+		status = "pending_approval"
+	}
+	if err := h.DB.Model(&booking).Updates(map[string]interface{}{"status": status, "deposit_status": "paid", "hold_expires_at": nil}).Error; err != nil {
+		return err
+	}
+	h.scheduleBookingNotifications(booking.ID)
+	return nil
+}
+
+// checkDeposit asks the provider whether a held booking's deposit has been
+// paid. It returns the latest deposit payment, if there is one.
+func (h *Handler) checkDeposit(ctx context.Context, booking *models.Booking) (*models.BookingPayment, error) {
+	var payment models.BookingPayment
+	if err := h.DB.Where("booking_id = ? AND kind = ?", booking.ID, "deposit").Order("created_at DESC").First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if payment.Status != "pending" || payment.ProviderRef == "" || h.Payments == nil {
+		return &payment, nil
+	}
+	result, err := h.Payments.Get(ctx, payment.ProviderRef)
+	if err != nil {
+		return &payment, err
+	}
+	return &payment, h.applyDepositResult(&payment, result)
+}
+
+// expireDepositHolds releases bookings whose deposit wasn't paid in time and
+// offers their slots to the waitlist
+func (h *Handler) expireDepositHolds(ctx context.Context) error {
+	var held []models.Booking
+	if err := h.DB.Where("status = ? AND hold_expires_at <= ?", "pending_payment", time.Now()).Find(&held).Error; err != nil {
+		return err
+	}
+	for i := range held {
+		booking := &held[i]
+		// The customer may have paid without coming back to the booking page
+		if _, err := h.checkDeposit(ctx, booking); err != nil {
+			log.Printf("Failed to check deposit for booking %s: %v", booking.ID, err)
+		}
+		released, err := h.releaseHeldBooking(booking, "expired")
+		if err != nil {
+			return err
+		}
+		if released {
+			if _, err := h.offerFreedSlot(booking); err != nil {
+				log.Printf("Failed to offer freed slot from booking %s: %v", booking.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// queueBookingPayment saves a refund or fee and queues a job to collect or
+// pay it through the provider
+func (h *Handler) queueBookingPayment(payment *models.BookingPayment) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		_, err := jobs.Enqueue(tx, bookingPaymentJob, bookingPaymentPayload{PaymentID: payment.ID}, time.Now(), "booking-payment:"+payment.ID)
+		return err
+	})
+}
+
+// refundDeposit queues a refund of a paid deposit
+func (h *Handler) refundDeposit(booking *models.Booking, deposit *models.BookingPayment) error {
+	if err := h.DB.Model(booking).Update("deposit_status", "refunding").Error; err != nil {
+		return err
+	}
+	return h.queueBookingPayment(&models.BookingPayment{
+		OrganizationID: booking.OrganizationID,
+		BookingID:      booking.ID,
+		CustomerID:     booking.CustomerID,
+		Kind:           "refund",
+		Amount:         deposit.Amount,
+		Currency:       deposit.Currency,
+		Status:         "pending",
+		Provider:       deposit.Provider,
+	})
+}
+
+// forfeitDeposit keeps a paid deposit as a fee
+func (h *Handler) forfeitDeposit(booking *models.Booking, deposit *models.BookingPayment) error {
+	now := time.Now()
+	forfeit := models.BookingPayment{
+		OrganizationID: booking.OrganizationID,
+		BookingID:      booking.ID,
+		CustomerID:     booking.CustomerID,
+		Kind:           "forfeit",
+		Amount:         deposit.Amount,
+		Currency:       deposit.Currency,
+		Status:         "succeeded",
+		PaidAt:         &now,
+	}
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&forfeit).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(booking).Update("deposit_status", "forfeited").Error; err != nil {
+			return err
+		}
+		return h.postBookingPayment(tx, &forfeit)
+	})
+}
+
+// settleBooking deals with money once a booking is cancelled or marked a
+// no-show. Deposits are refunded for cancellations made before the
+// organization's cancellation window and kept otherwise; refund overrides
+// that for cancellations staff make. No-shows also pay any no-show fee not
+// already covered by the deposit.
+func (h *Handler) settleBooking(booking *models.Booking, refund *bool) error {
+	if err := h.DB.Preload("Services").Where("id = ?", booking.ID).First(booking).Error; err != nil {
+		return err
+	}
+	if booking.DepositStatus == "pending" {
+		// Nothing was paid, and there's no card on file to charge a fee to
+		return h.dropPendingDeposit(booking, "cancelled")
+	}
+
+	var forfeited float64
+	if booking.DepositStatus == "paid" {
+		var deposit models.BookingPayment
+		if err := h.DB.Where("booking_id = ? AND kind = ? AND status = ?", booking.ID, "deposit", "succeeded").
+			Order("paid_at DESC").First(&deposit).Error; err != nil {
+			return err
+		}
+
+		keep := booking.Status == "no_show"
+		if booking.Status == "cancelled" {
+			var org models.Organization
+			if err := h.DB.Where("id = ?", booking.OrganizationID).First(&org).Error; err != nil {
+				return err
+			}
+			deadline := booking.StartTime.Add(-time.Duration(org.BookingSettings.CancellationWindow) * time.Hour)
+			keep = !time.Now().Before(deadline)
+			if refund != nil {
+				keep = !*refund
+			}
+		}
+		if !keep {
+			return h.refundDeposit(booking, &deposit)
+		}
+		if err := h.forfeitDeposit(booking, &deposit); err != nil {
+			return err
+		}
+		forfeited = deposit.Amount
+	}
+
+	if booking.Status != "no_show" || h.Payments == nil {
+		return nil
+	}
+	var fee float64
+	for _, service := range booking.Services {
+		fee += service.NoShowFee
+	}
+	if fee = roundCurrency(fee - forfeited); fee <= 0 {
+		return nil
+	}
+	return h.queueBookingPayment(&models.BookingPayment{
+		OrganizationID: booking.OrganizationID,
+		BookingID:      booking.ID,
+		CustomerID:     booking.CustomerID,
+		Kind:           "no_show_fee",
+		Amount:         fee,
+		Currency:       h.Config.PaymentCurrency,
+		Status:         "pending",
+		Provider:       h.Payments.Name(),
+	})
+}
+
+// processBookingPayment pays a queued refund or charges a queued no-show fee
+// through the provider. Declines are recorded rather than retried. Once the
+// provider has paid, a retry only records and posts the payment.
+func (h *Handler) processBookingPayment(ctx context.Context, job *models.Job) error {
+	var payload bookingPaymentPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	var payment models.BookingPayment
+	if err := h.DB.Where("id = ?", payload.PaymentID).First(&payment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	if payment.Status != "pending" {
+		return nil
+	}
+	if payment.ProviderRef == "" {
+		if err := h.collectBookingPayment(ctx, &payment); err != nil || payment.Status == "failed" {
+			return err
+		}
+	}
+
+	now := time.Now()
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&payment).Updates(map[string]interface{}{"status": "succeeded", "paid_at": now}).Error; err != nil {
+			return err
+		}
+		if payment.Kind == "refund" {
+			if err := tx.Model(&models.Booking{}).Where("id = ?", payment.BookingID).Update("deposit_status", "refunded").Error; err != nil {
+				return err
+			}
+		}
+		return h.postBookingPayment(tx, &payment)
+	})
+}
+
+// collectBookingPayment asks the provider to pay a refund or charge a fee,
+// saving its reference so the payment is only made once. Declined payments
+// are marked failed.
+func (h *Handler) collectBookingPayment(ctx context.Context, payment *models.BookingPayment) error {
+	fail := func(reason string) error {
+		payment.Status = "failed"
+		return h.DB.Model(payment).Updates(map[string]interface{}{"status": "failed", "failure_reason": reason}).Error
+	}
+	if h.Payments == nil {
+		return fail("Payments are not set up")
+	}
+
+	// Refunds go back to the deposit and fees are charged to the card it was paid with
+	var deposit models.BookingPayment
+	if err := h.DB.Where("booking_id = ? AND kind = ? AND status = ?", payment.BookingID, "deposit", "succeeded").
+		Order("paid_at DESC").First(&deposit).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	var result *payments.Result
+	var err error
+	switch payment.Kind {
+	case "refund":
+		if deposit.ID == "" {
+			return fail("No paid deposit to refund")
+		}
+		result, err = h.Payments.Refund(ctx, deposit.ProviderRef, payment.Amount)
+	case "no_show_fee":
+		if deposit.PaymentMethod == "" {
+			return fail("No saved card to charge")
+		}
+		result, err = h.Payments.Create(ctx, payments.Request{
+			Amount:        payment.Amount,
+			Currency:      payment.Currency,
+			Description:   "No-show fee",
+			Reference:     payment.ID,
+			PaymentMethod: deposit.PaymentMethod,
+		})
+	default:
+		return fail(fmt.Sprintf("Unknown payment kind %q", payment.Kind))
+	}
+	if errors.Is(err, payments.ErrDeclined) {
+		return fail(err.Error())
+	}
+	if err != nil {
+		h.DB.Model(payment).Update("failure_reason", err.Error())
+		return err
+	}
+	if result.Status == payments.Failed {
+		return fail(result.FailureReason)
+	}
+	return h.DB.Model(payment).Updates(map[string]interface{}{"provider_ref": result.Ref, "failure_reason": ""}).Error
+}
+
+// postBookingPayment posts a successful booking payment to the general
+// ledger in tx, the transaction that records it as paid. Money the provider
+// takes or pays out posts to its tender account.
+func (h *Handler) postBookingPayment(tx *gorm.DB, payment *models.BookingPayment) error {
+	accounts, ok := bookingPaymentPostings[payment.Kind]
+	if !ok {
+		return fmt.Errorf("no ledger accounts for %s payments", payment.Kind)
+	}
+	for i, account := range accounts {
+		if account == tenderAccount {
+			accounts[i] = finance.TenderAccount(payment.Provider)
+		}
+	}
+
+	posting := finance.Posting{
+		SourceType:  finance.SourceBookingPayment,
+		SourceID:    payment.ID,
+		Description: bookingPaymentDescriptions[payment.Kind],
+		Reference:   "booking-payment:" + payment.ID,
+	}
+	if payment.PaidAt != nil {
+		posting.Date = *payment.PaidAt
+	}
+	posting.Debit(accounts[0], "", payment.Amount)
+	posting.Credit(accounts[1], "", payment.Amount)
+
+	entry, err := finance.NewService(h.DB).Post(tx, payment.OrganizationID, posting)
+	if err != nil || entry == nil {
+		return err
+	}
+	payment.JournalEntryID = &entry.ID
+	return tx.Model(payment).Update("journal_entry_id", entry.ID).Error
+}
+
+// PayBookingDeposit returns where a guest pays their booking's deposit, or
+// confirms the booking once the provider reports it paid. A new checkout is
+// started if the last attempt failed.
+func (h *Handler) PayBookingDeposit(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+	booking, ok := h.findManagedBooking(c, org)
+	if !ok {
+		return
+	}
+	if booking.Status != "pending_payment" || h.Payments == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_DEPOSIT_DUE",
+				"message": "This booking isn't waiting for a deposit",
+			},
+		})
+		return
+	}
+
+	payment, err := h.checkDeposit(c.Request.Context(), booking)
+	if err == nil && (payment == nil || payment.Status == "failed" || payment.Status == "cancelled") {
+		payment, err = h.requestDeposit(c.Request.Context(), booking)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PAYMENT_ERROR",
+				"message": "Payments are unavailable right now, please try again",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Customer").Preload("Services").First(booking, "id = ?", booking.ID)
+	message := "Pay the deposit to confirm your booking"
+	if booking.Status != "pending_payment" {
+		message = "Deposit paid, booking confirmed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.publicBookingView(org, booking),
+		"message": message,
+	})
+}
+
+// GetBookingPayments lists the deposits, refunds and fees for a booking
+func (h *Handler) GetBookingPayments(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var count int64
+	h.DB.Model(&models.Booking{}).Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BOOKING_NOT_FOUND",
+				"message": "Booking not found",
+			},
+		})
+		return
+	}
+
+	var bookingPayments []models.BookingPayment
+	if err := h.DB.Where("booking_id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Order("created_at ASC").Find(&bookingPayments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch payments",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    bookingPayments,
+	})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/payments"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingDeposits(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{},
+		&models.BookingPayment{}, &finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{},
+		&finance.GeneralLedger{}, &finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountingPeriod{},
+		&finance.AccountMapping{}, &finance.LedgerPosting{})
+	handler.Notifier = &notify.Outbox{}
+	provider := &payments.Fake{CheckoutURL: "https://pay.example.com"}
+	handler.Payments = provider
+	queue := handler.JobQueue()
+
+	hours := models.BusinessHours{Timezone: "Australia/Adelaide"}
+	hours.MondayOpen, hours.MondayClose = "09:00", "17:00"
+	hours.TuesdayOpen, hours.TuesdayClose = "09:00", "17:00"
+	hours.WednesdayOpen, hours.WednesdayClose = "09:00", "17:00"
+	hours.ThursdayOpen, hours.ThursdayClose = "09:00", "17:00"
+	hours.FridayOpen, hours.FridayClose = "09:00", "17:00"
+	hours.SaturdayOpen, hours.SaturdayClose = "09:00", "17:00"
+	hours.SundayOpen, hours.SundayClose = "09:00", "17:00"
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Updates(models.Organization{BusinessHours: hours})
+
+	handler.DB.Create(&models.Service{ID: "colour", OrganizationID: "test-org", Name: "Colour", Category: "beauty", Duration: 60, Price: 80,
+		DepositType: "percentage", DepositValue: 25, NoShowFee: 50, IsActive: true})
+
+	loc, _ := time.LoadLocation("Australia/Adelaide")
+	book := func(t *testing.T, daysAhead int) (string, string, map[string]interface{}) {
+		day := time.Now().In(loc).AddDate(0, 0, daysAhead)
+		w, response := doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings", map[string]interface{}{
+			"service_ids": []string{"colour"},
+			"start_time":  time.Date(day.Year(), day.Month(), day.Day(), 9, 0, 0, 0, loc),
+			"first_name":  "Dee",
+			"last_name":   "Posit",
+			"email":       "dee@example.com",
+			"phone":       "0400555666",
+		})
+		if !assert.Equal(t, http.StatusCreated, w.Code) {
+			t.Fatal(response)
+		}
+		data := response["data"].(map[string]interface{})
+		booking := data["booking"].(map[string]interface{})
+		return booking["id"].(string), data["manage_token"].(string), booking
+	}
+	deposit := func(bookingID string) models.BookingPayment {
+		var payment models.BookingPayment
+		handler.DB.Where("booking_id = ? AND kind = ?", bookingID, "deposit").Order("created_at DESC").First(&payment)
+		return payment
+	}
+	bookingPayments := func(bookingID string) map[string]models.BookingPayment {
+		var found []models.BookingPayment
+		handler.DB.Where("booking_id = ?", bookingID).Find(&found)
+		byKind := make(map[string]models.BookingPayment)
+		for _, payment := range found {
+			byKind[payment.Kind] = payment
+		}
+		return byKind
+	}
+	booking := func(id string) models.Booking {
+		var found models.Booking
+		handler.DB.Where("id = ?", id).First(&found)
+		return found
+	}
+	// balance is an account's ledger balance, debits less credits
+	balance := func(code string) float64 {
+		var account finance.ChartOfAccount
+		handler.DB.Where("organization_id = ? AND code = ?", "test-org", code).First(&account)
+		var accountBalance finance.AccountBalance
+		handler.DB.Where("account_id = ?", account.ID).First(&accountBalance)
+		return accountBalance.Balance
+	}
+	payDeposit := func(t *testing.T, bookingID, token, paymentMethod string) {
+		provider.Complete(deposit(bookingID).ProviderRef, paymentMethod)
+		w, _ := doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	var paidID string
+	t.Run("Online bookings are held until the deposit is paid", func(t *testing.T) {
+		id, token, view := book(t, 3)
+		assert.Equal(t, "pending_payment", view["status"])
+		held := view["deposit"].(map[string]interface{})
+		assert.Equal(t, float64(20), held["amount"])
+		assert.Equal(t, "pending", held["status"])
+		assert.Contains(t, held["checkout_url"], "https://pay.example.com/")
+		assert.NotNil(t, held["expires_at"])
+
+		var notifications int64
+		handler.DB.Model(&models.BookingNotification{}).Where("booking_id = ?", id).Count(&notifications)
+		assert.Zero(t, notifications)
+
+		// Asking again before paying returns the same checkout
+		w, response := doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, held["checkout_url"], response["data"].(map[string]interface{})["deposit"].(map[string]interface{})["checkout_url"])
+
+		provider.Complete(deposit(id).ProviderRef, "pm_card_visa")
+		w, response = doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "scheduled", data["status"])
+		assert.Equal(t, "paid", data["deposit"].(map[string]interface{})["status"])
+
+		handler.DB.Model(&models.BookingNotification{}).Where("booking_id = ?", id).Count(&notifications)
+		assert.NotZero(t, notifications)
+		assert.Equal(t, float64(20), balance("1100"))
+		assert.Equal(t, float64(-20), balance("2100"))
+
+		// Confirming again doesn't post the deposit twice
+		w, _ = doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, float64(-20), balance("2100"))
+		paidID = id
+	})
+
+	t.Run("Unpaid holds expire and free the slot", func(t *testing.T) {
+		id, _, _ := book(t, 4)
+		handler.DB.Model(&models.Booking{}).Where("id = ?", id).Update("hold_expires_at", time.Now().Add(-time.Minute))
+		assert.NoError(t, handler.expireDepositHolds(context.Background()))
+
+		expired := booking(id)
+		assert.Equal(t, "cancelled", expired.Status)
+		assert.Equal(t, "expired", expired.DepositStatus)
+		assert.Equal(t, "cancelled", deposit(id).Status)
+
+		book(t, 4)
+	})
+
+	t.Run("Holds are confirmed if the deposit was paid before they expire", func(t *testing.T) {
+		id, _, _ := book(t, 5)
+		provider.Complete(deposit(id).ProviderRef, "pm_card_visa")
+		handler.DB.Model(&models.Booking{}).Where("id = ?", id).Update("hold_expires_at", time.Now().Add(-time.Minute))
+		assert.NoError(t, handler.expireDepositHolds(context.Background()))
+
+		assert.Equal(t, "scheduled", booking(id).Status)
+		assert.Equal(t, "paid", booking(id).DepositStatus)
+	})
+
+	t.Run("Cancelling before the cancellation window refunds the deposit", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+paidID+"/status", map[string]interface{}{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "refunding", booking(paidID).DepositStatus)
+
+		queue.RunDue(context.Background())
+		refund := bookingPayments(paidID)["refund"]
+		assert.Equal(t, "succeeded", refund.Status)
+		assert.Equal(t, float64(20), refund.Amount)
+		assert.NotNil(t, refund.JournalEntryID)
+		assert.Equal(t, "refunded", booking(paidID).DepositStatus)
+
+		w, response := doRequest(handler, router, "GET", "/api/v1/bookings/"+paidID+"/payments", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"], 2)
+	})
+
+	t.Run("Late cancellations keep the deposit unless staff refund it", func(t *testing.T) {
+		handler.DB.Model(&models.Organization{ID: "test-org"}).Update("booking_cancellation_window", 24*14)
+		depositsBefore, feesBefore := balance("2100"), balance("4100")
+
+		late, lateToken, _ := book(t, 6)
+		payDeposit(t, late, lateToken, "pm_card_visa")
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+late+"/status", map[string]interface{}{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "forfeited", booking(late).DepositStatus)
+		assert.Equal(t, "succeeded", bookingPayments(late)["forfeit"].Status)
+		assert.Equal(t, depositsBefore, balance("2100"))
+		assert.Equal(t, feesBefore-20, balance("4100"))
+
+		waived, waivedToken, _ := book(t, 7)
+		payDeposit(t, waived, waivedToken, "pm_card_visa")
+		w, _ = doRequest(handler, router, "PATCH", "/api/v1/bookings/"+waived+"/status", map[string]interface{}{"status": "cancelled", "refund_deposit": true})
+		assert.Equal(t, http.StatusOK, w.Code)
+		queue.RunDue(context.Background())
+		assert.Equal(t, "refunded", booking(waived).DepositStatus)
+	})
+
+	t.Run("No-shows lose the deposit and pay the rest of the fee", func(t *testing.T) {
+		feesBefore, cashBefore := balance("4100"), balance("1100")
+		id, token, _ := book(t, 8)
+		payDeposit(t, id, token, "pm_card_visa")
+
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+id+"/status", map[string]interface{}{"status": "no_show"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		queue.RunDue(context.Background())
+
+		found := bookingPayments(id)
+		assert.Equal(t, "succeeded", found["forfeit"].Status)
+		fee := found["no_show_fee"]
+		assert.Equal(t, "succeeded", fee.Status)
+		assert.Equal(t, float64(30), fee.Amount)
+		assert.NotEmpty(t, fee.ProviderRef)
+		assert.Equal(t, feesBefore-50, balance("4100"))
+		assert.Equal(t, cashBefore+50, balance("1100"))
+	})
+
+	t.Run("Declined no-show fees are recorded", func(t *testing.T) {
+		id, token, _ := book(t, 9)
+		payDeposit(t, id, token, payments.DeclinedMethod)
+		assert.Equal(t, "paid", booking(id).DepositStatus)
+
+		doRequest(handler, router, "PATCH", "/api/v1/bookings/"+id+"/status", map[string]interface{}{"status": "no_show"})
+		queue.RunDue(context.Background())
+
+		fee := bookingPayments(id)["no_show_fee"]
+		assert.Equal(t, "failed", fee.Status)
+		assert.Equal(t, "Card declined", fee.FailureReason)
+		assert.Nil(t, fee.JournalEntryID)
+	})
+
+	t.Run("Deposits stay pending while the period is closed", func(t *testing.T) {
+		today := time.Now().Truncate(24 * time.Hour)
+		period := finance.AccountingPeriod{ID: "closed-period", OrganizationID: "test-org", Name: "This month",
+			StartDate: today.AddDate(0, 0, -30), EndDate: today.AddDate(0, 0, 1), Status: "closed"}
+		handler.DB.Create(&period)
+
+		id, token, _ := book(t, 12)
+		provider.Complete(deposit(id).ProviderRef, "pm_card_visa")
+		w, _ := doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Equal(t, "pending", deposit(id).Status)
+		assert.Equal(t, "pending_payment", booking(id).Status)
+
+		handler.DB.Model(&period).Update("status", "open")
+		w, _ = doRequest(handler, router, "POST", "/api/v1/public/test-org/bookings/"+token+"/deposit", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "succeeded", deposit(id).Status)
+		assert.NotNil(t, deposit(id).JournalEntryID)
+	})
+
+	t.Run("Staff confirming a held booking waive the deposit", func(t *testing.T) {
+		id, _, _ := book(t, 10)
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+id+"/status", map[string]interface{}{"status": "confirmed"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		confirmed := booking(id)
+		assert.Equal(t, "waived", confirmed.DepositStatus)
+		assert.Nil(t, confirmed.HoldExpiresAt)
+		assert.Equal(t, "cancelled", deposit(id).Status)
+	})
+
+	t.Run("Services without a deposit are booked straight away", func(t *testing.T) {
+		handler.DB.Model(&models.Service{ID: "colour"}).Update("deposit_type", "none")
+		_, _, view := book(t, 11)
+		assert.Equal(t, "scheduled", view["status"])
+		assert.Nil(t, view["deposit"])
+	})
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// bookingStepRequest is one service in a booking request
+type bookingStepRequest struct {
+	ServiceID    string  `json:"service_id" binding:"required"`
+	StaffID      *string `json:"staff_id"`
+	WithPrevious bool    `json:"with_previous"` // Run alongside the step before instead of after it
+}
+
+// newBookingStep is the step at position i that performs a service
+func newBookingStep(i int, service *models.Service, staffID *string, withPrevious bool) models.BookingStep {
+	return models.BookingStep{
+		ServiceID:    service.ID,
+		StaffID:      staffID,
+		Position:     i + 1,
+		WithPrevious: withPrevious && i > 0,
+		Price:        service.Price,
+		Service:      service,
+	}
+}
+
+// stepsForServices does a booking's services one after another
+func stepsForServices(services []models.Service, staffID *string) []models.BookingStep {
+	steps := make([]models.BookingStep, len(services))
+	for i := range services {
+		steps[i] = newBookingStep(i, &services[i], staffID, false)
+	}
+	return steps
+}
+
+// planBookingSteps turns the services in a booking request into steps.
+// Requests list either service_ids, done one after another, or steps; steps
+// without their own staff member are done by staffID. The distinct services
+// booked are returned alongside for checking resources and deposits. problem
+// is set when the request can't be booked as asked.
+func planBookingSteps(db *gorm.DB, orgID string, staffID *string, serviceIDs []string, requested []bookingStepRequest) (steps []models.BookingStep, services []models.Service, problem string, err error) {
+	if len(requested) == 0 {
+		for _, id := range serviceIDs {
+			requested = append(requested, bookingStepRequest{ServiceID: id})
+		}
+	}
+	if len(requested) == 0 {
+		return nil, nil, "At least one service is required", nil
+	}
+
+	var ids, staffIDs []string
+	seen := make(map[string]bool)
+	for _, r := range requested {
+		if !seen[r.ServiceID] {
+			seen[r.ServiceID] = true
+			ids = append(ids, r.ServiceID)
+		}
+		if r.StaffID != nil && !seen["staff:"+*r.StaffID] {
+			seen["staff:"+*r.StaffID] = true
+			staffIDs = append(staffIDs, *r.StaffID)
+		}
+	}
+
+	if err := db.Preload("ResourceRequirements").Where("id IN ? AND organization_id = ?", ids, orgID).Find(&services).Error; err != nil {
+		return nil, nil, "", err
+	}
+	if len(services) != len(ids) {
+		return nil, nil, "Some service IDs are invalid", nil
+	}
+	if len(staffIDs) > 0 {
+		var count int64
+		if err := db.Model(&models.User{}).Where("id IN ? AND organization_id = ?", staffIDs, orgID).Count(&count).Error; err != nil {
+			return nil, nil, "", err
+		}
+		if int(count) != len(staffIDs) {
+			return nil, nil, "Invalid staff ID", nil
+		}
+	}
+
+	byID := make(map[string]*models.Service, len(services))
+	for i := range services {
+		byID[services[i].ID] = &services[i]
+	}
+	steps = make([]models.BookingStep, len(requested))
+	for i, r := range requested {
+		stepStaff := r.StaffID
+		if stepStaff == nil {
+			stepStaff = staffID
+		}
+		steps[i] = newBookingStep(i, byID[r.ServiceID], stepStaff, r.WithPrevious)
+	}
+	return steps, services, "", nil
+}
+
+// scheduleBookingSteps times each step from start and returns when the last
+// one finishes. A step starts once every step before it is done unless it
+// runs alongside the previous one.
+func scheduleBookingSteps(steps []models.BookingStep, start time.Time) time.Time {
+	groupStart, end := start, start
+	for i := range steps {
+		if !steps[i].WithPrevious {
+			groupStart = end
+		}
+		steps[i].StartTime = groupStart
+		steps[i].EndTime = groupStart.Add(time.Duration(steps[i].Service.Duration) * time.Minute)
+		if steps[i].EndTime.After(end) {
+			end = steps[i].EndTime
+		}
+	}
+	return end
+}
+
+// stepStaffProblem checks no one is asked to do two steps of a booking at once
+func stepStaffProblem(steps []models.BookingStep) string {
+	for i, a := range steps {
+		for _, b := range steps[i+1:] {
+			if a.StaffID != nil && b.StaffID != nil && *a.StaffID == *b.StaffID &&
+				a.StartTime.Before(b.EndTime) && b.StartTime.Before(a.EndTime) {
+				return "A staff member can't do two steps at the same time"
+			}
+		}
+	}
+	return ""
+}
+
+// staffBooked reports whether a staff member already has another booking, or
+// a step of one, between start and end
+func staffBooked(db *gorm.DB, orgID, staffID string, start, end time.Time, excludeBookingID string) (bool, error) {
+	inactive := []string{"cancelled", "no_show"}
+	bookingQuery := db.Model(&models.Booking{}).
+		Where("organization_id = ? AND staff_id = ? AND status NOT IN ? AND start_time < ? AND end_time > ?", orgID, staffID, inactive, end, start)
+	stepQuery := db.Model(&models.BookingStep{}).
+		Joins("JOIN bookings ON bookings.id = booking_steps.booking_id").
+		Where("bookings.organization_id = ? AND bookings.deleted_at IS NULL AND bookings.status NOT IN ?", orgID, inactive).
+		Where("booking_steps.staff_id = ? AND booking_steps.start_time < ? AND booking_steps.end_time > ?", staffID, end, start)
+	if excludeBookingID != "" {
+		bookingQuery = bookingQuery.Where("id != ?", excludeBookingID)
+		stepQuery = stepQuery.Where("booking_steps.booking_id != ?", excludeBookingID)
+	}
+
+	var count int64
+	if err := bookingQuery.Count(&count).Error; err != nil || count > 0 {
+		return count > 0, err
+	}
+	if err := stepQuery.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// stepsStaffed reports whether any step has a staff member assigned
+func stepsStaffed(steps []models.BookingStep) bool {
+	for _, step := range steps {
+		if step.StaffID != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// stepsStaffBooked reports whether anyone doing a step is already booked elsewhere then
+func stepsStaffBooked(db *gorm.DB, orgID string, steps []models.BookingStep, excludeBookingID string) (bool, error) {
+	for _, step := range steps {
+		if step.StaffID == nil {
+			continue
+		}
+		booked, err := staffBooked(db, orgID, *step.StaffID, step.StartTime, step.EndTime, excludeBookingID)
+		if err != nil || booked {
+			return booked, err
+		}
+	}
+	return false, nil
+}
+
+// saveBookingSteps replaces a booking's steps
+func saveBookingSteps(tx *gorm.DB, bookingID string, steps []models.BookingStep) error {
+	if err := tx.Where("booking_id = ?", bookingID).Delete(&models.BookingStep{}).Error; err != nil {
+		return err
+	}
+	for i := range steps {
+		steps[i].ID = ""
+		steps[i].BookingID = bookingID
+		if err := tx.Omit(clause.Associations).Create(&steps[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveBookingSteps shifts a booking's steps along with the booking
+func moveBookingSteps(tx *gorm.DB, bookingID string, by time.Duration) error {
+	var steps []models.BookingStep
+	if err := tx.Where("booking_id = ?", bookingID).Find(&steps).Error; err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := tx.Model(&step).Updates(map[string]interface{}{
+			"start_time": step.StartTime.Add(by),
+			"end_time":   step.EndTime.Add(by),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currentBookingSteps loads a booking's steps, or works them out from its
+// services for bookings made before bookings had steps
+func currentBookingSteps(db *gorm.DB, booking *models.Booking) ([]models.BookingStep, error) {
+	var steps []models.BookingStep
+	if err := db.Preload("Service").Where("booking_id = ?", booking.ID).Order("position ASC").Find(&steps).Error; err != nil {
+		return nil, err
+	}
+	if len(steps) > 0 {
+		return steps, nil
+	}
+	var services []models.Service
+	if err := db.Model(booking).Association("Services").Find(&services); err != nil {
+		return nil, err
+	}
+	return stepsForServices(services, booking.StaffID), nil
+}
+
+// orderedSteps preloads a booking's steps in the order they run
+func orderedSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC")
+}
+
+// bookingPrice is what a booking costs: its services less any discount, plus tax
+type bookingPrice struct {
+	SubTotal       float64
+	DiscountID     *string
+	DiscountAmount float64
+	TaxRate        float64
+	TaxAmount      float64
+	Total          float64
+}
+
+// priceBooking applies a discount and then tax to the price of a booking's steps
+func priceBooking(steps []models.BookingStep, discount *models.Discount, tax *models.TaxRate) bookingPrice {
+	var subTotal float64
+	for _, step := range steps {
+		subTotal += step.Price
+	}
+	price := bookingPrice{SubTotal: roundCurrency(subTotal)}
+	if discount != nil {
+		amount := discount.Value
+		if discount.Type == "percentage" {
+			amount = price.SubTotal * discount.Value / 100
+			if discount.MaxDiscount > 0 && amount > discount.MaxDiscount {
+				amount = discount.MaxDiscount
+			}
+		}
+		price.DiscountID = &discount.ID
+		price.DiscountAmount = roundCurrency(math.Min(amount, price.SubTotal))
+	}
+	if tax != nil {
+		price.TaxRate = tax.Rate
+		price.TaxAmount = roundCurrency((price.SubTotal - price.DiscountAmount) * tax.Rate / 100)
+	}
+	price.Total = roundCurrency(price.SubTotal - price.DiscountAmount + price.TaxAmount)
+	return price
+}
+
+// applyTo sets a booking's price fields
+func (p bookingPrice) applyTo(booking *models.Booking) {
+	booking.SubTotal = p.SubTotal
+	booking.DiscountID = p.DiscountID
+	booking.DiscountAmount = p.DiscountAmount
+	booking.TaxRate = p.TaxRate
+	booking.TaxAmount = p.TaxAmount
+	booking.TotalPrice = p.Total
+}
+
+// columns is the price as booking column updates
+func (p bookingPrice) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"sub_total":       p.SubTotal,
+		"discount_id":     p.DiscountID,
+		"discount_amount": p.DiscountAmount,
+		"tax_rate":        p.TaxRate,
+		"tax_amount":      p.TaxAmount,
+		"total_price":     p.Total,
+	}
+}
+
+// discountProblem says why a POS discount can't be applied to a booking worth
+// subTotal, or returns "" when it can. Usage limits are enforced when the
+// discount is claimed.
+func discountProblem(discount *models.Discount, subTotal float64, now time.Time) string {
+	switch {
+	case !discount.IsActive:
+		return "Discount is not active"
+	case now.Before(discount.StartDate):
+		return "Discount has not started yet"
+	case discount.EndDate != nil && now.After(*discount.EndDate):
+		return "Discount has expired"
+	case discount.ApplicableTo != "" && discount.ApplicableTo != "all":
+		return "Discount only applies to selected products"
+	case discount.Type != "percentage" && discount.Type != "fixed_amount":
+		return "Discount can't be applied to bookings"
+	case subTotal < discount.MinPurchase:
+		return fmt.Sprintf("Discount needs a minimum spend of %.2f", discount.MinPurchase)
+	}
+	return ""
+}
+
+// claimDiscount counts a use of a discount, failing with false when it has
+// reached its usage limit
+func claimDiscount(tx *gorm.DB, discountID string) (bool, error) {
+	result := tx.Model(&models.Discount{}).
+		Where("id = ? AND (usage_limit = 0 OR usage_count < usage_limit)", discountID).
+		UpdateColumn("usage_count", gorm.Expr("usage_count + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// releaseDiscount gives back a use of a discount taken off a booking
+func releaseDiscount(tx *gorm.DB, discountID string) error {
+	return tx.Model(&models.Discount{}).Where("id = ? AND usage_count > 0", discountID).
+		UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error
+}
+
+// defaultTaxRate is the organization's default tax rate, or nil when it
+// doesn't charge tax
+func defaultTaxRate(db *gorm.DB, orgID string) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := db.Where("organization_id = ? AND is_default = ? AND is_active = ?", orgID, true, true).
+		Order("created_at ASC").First(&rate).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiServiceBookings(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{})
+
+	handler.DB.Create(&models.Service{ID: "colour", OrganizationID: "test-org", Name: "Colour", Category: "hair", Duration: 60, Price: 100, IsActive: true})
+	handler.DB.Create(&models.Service{ID: "manicure", OrganizationID: "test-org", Name: "Manicure", Category: "nails", Duration: 30, Price: 40, IsActive: true})
+	handler.DB.Create(&models.Service{ID: "blow-dry", OrganizationID: "test-org", Name: "Blow dry", Category: "hair", Duration: 30, Price: 50, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "salon-customer", OrganizationID: "test-org", FirstName: "Salon", LastName: "Customer", Email: "salon@example.com", IsActive: true})
+	handler.DB.Create(&models.User{ID: "nail-tech", OrganizationID: "test-org", Email: "nails@example.com", FirstName: "Nail", LastName: "Tech", Role: "staff", IsActive: true})
+	handler.DB.Create(&models.TaxRate{OrganizationID: "test-org", Name: "GST", Rate: 10, IsDefault: true, IsActive: true})
+	handler.DB.Create(&models.Discount{ID: "launch", OrganizationID: "test-org", Name: "Launch", Type: "percentage", Value: 20, MaxDiscount: 30,
+		StartDate: time.Now().Add(-time.Hour), IsActive: true, UsageLimit: 1})
+	handler.DB.Create(&models.Discount{ID: "next-month", OrganizationID: "test-org", Name: "Next month", Type: "fixed_amount", Value: 10,
+		StartDate: time.Now().AddDate(0, 1, 0), IsActive: true})
+
+	parseTime := func(value interface{}) time.Time {
+		parsed, _ := time.Parse(time.RFC3339, value.(string))
+		return parsed
+	}
+
+	start := time.Now().AddDate(0, 0, 2).Truncate(time.Hour).UTC()
+	var sequentialID string
+
+	t.Run("Services run back to back and are priced with tax", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "salon-customer",
+			"service_ids": []string{"colour", "blow-dry"},
+			"start_time":  start,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		booking := response["booking"].(map[string]interface{})
+		sequentialID = booking["id"].(string)
+		assert.True(t, parseTime(booking["end_time"]).Equal(start.Add(90*time.Minute)))
+		assert.Equal(t, 150.0, booking["sub_total"])
+		assert.Equal(t, 15.0, booking["tax_amount"])
+		assert.Equal(t, 165.0, booking["total_price"])
+
+		steps := booking["steps"].([]interface{})
+		assert.Len(t, steps, 2)
+		assert.True(t, parseTime(steps[1].(map[string]interface{})["start_time"]).Equal(start.Add(time.Hour)))
+	})
+
+	parallelStart := start.Add(3 * time.Hour)
+	t.Run("Parallel steps share the start time and can have their own staff", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "salon-customer",
+			"staff_id":    "test-user",
+			"start_time":  parallelStart,
+			"steps": []map[string]interface{}{
+				{"service_id": "colour"},
+				{"service_id": "manicure", "staff_id": "nail-tech", "with_previous": true},
+				{"service_id": "blow-dry"},
+			},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		booking := response["booking"].(map[string]interface{})
+		assert.True(t, parseTime(booking["end_time"]).Equal(parallelStart.Add(90*time.Minute)))
+		assert.Equal(t, 209.0, booking["total_price"])
+
+		steps := booking["steps"].([]interface{})
+		manicure := steps[1].(map[string]interface{})
+		assert.Equal(t, "nail-tech", manicure["staff_id"])
+		assert.True(t, parseTime(manicure["start_time"]).Equal(parallelStart))
+		assert.Equal(t, "test-user", steps[2].(map[string]interface{})["staff_id"])
+	})
+
+	t.Run("Staff are only busy for their own step", func(t *testing.T) {
+		booking := map[string]interface{}{
+			"customer_id": "salon-customer",
+			"staff_id":    "nail-tech",
+			"service_ids": []string{"manicure"},
+			"start_time":  parallelStart.Add(15 * time.Minute),
+		}
+		w, _ := doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		booking["start_time"] = parallelStart.Add(30 * time.Minute)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("One staff member can't do two steps at once", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "salon-customer",
+			"staff_id":    "test-user",
+			"start_time":  start.Add(24 * time.Hour),
+			"steps": []map[string]interface{}{
+				{"service_id": "colour"},
+				{"service_id": "manicure", "with_previous": true},
+			},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Discounts must be usable and are counted", func(t *testing.T) {
+		booking := map[string]interface{}{
+			"customer_id": "salon-customer",
+			"service_ids": []string{"colour", "manicure", "blow-dry"},
+			"start_time":  start.Add(48 * time.Hour),
+			"discount_id": "next-month",
+		}
+		w, _ := doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// 20% of 190 is capped at 30, then 10% tax on 160
+		booking["discount_id"] = "launch"
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		created := response["booking"].(map[string]interface{})
+		assert.Equal(t, 30.0, created["discount_amount"])
+		assert.Equal(t, 176.0, created["total_price"])
+
+		booking["start_time"] = start.Add(72 * time.Hour)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		// Taking the discount off the booking frees it up again
+		w, response = doRequest(handler, router, "PUT", "/api/v1/bookings/"+created["id"].(string), map[string]interface{}{"discount_id": ""})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 209.0, response["booking"].(map[string]interface{})["total_price"])
+		var discount models.Discount
+		handler.DB.First(&discount, "id = ?", "launch")
+		assert.Equal(t, 0, discount.UsageCount)
+	})
+
+	t.Run("Changing services re-times and re-prices the booking", func(t *testing.T) {
+		w, response := doRequest(handler, router, "PUT", "/api/v1/bookings/"+sequentialID, map[string]interface{}{
+			"service_ids": []string{"manicure"},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		booking := response["booking"].(map[string]interface{})
+		assert.True(t, parseTime(booking["end_time"]).Equal(start.Add(30*time.Minute)))
+		assert.Equal(t, 44.0, booking["total_price"])
+		assert.Len(t, booking["steps"], 1)
+
+		moved := start.Add(time.Hour)
+		w, response = doRequest(handler, router, "PUT", "/api/v1/bookings/"+sequentialID, map[string]interface{}{"start_time": moved})
+		assert.Equal(t, http.StatusOK, w.Code)
+		booking = response["booking"].(map[string]interface{})
+		assert.True(t, parseTime(booking["end_time"]).Equal(moved.Add(30*time.Minute)))
+		assert.True(t, parseTime(booking["steps"].([]interface{})[0].(map[string]interface{})["start_time"]).Equal(moved))
+	})
+}
@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetBookings retrieves all bookings for the organization with optional filters
@@ -26,7 +31,7 @@ func (h *Handler) GetBookings(c *gin.Context) {
 	dateFrom := c.Query("date_from")
 	dateTo := c.Query("date_to")
 
-	query := h.DB.Where("organization_id = ?", orgID).Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services")
+	query := h.DB.Where("organization_id = ?", orgID).Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").Preload("Resources.Resource")
 
 	// Apply filters
 	if status != "" {
@@ -72,7 +77,8 @@ func (h *Handler) GetBooking(c *gin.Context) {
 	id := c.Param("id")
 	var booking models.Booking
 	if err := h.DB.Where("id = ? AND organization_id = ?", id, orgID).
-		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").
+		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").Preload("Resources.Resource").
+		Preload("Steps", orderedSteps).Preload("Steps.Service").Preload("Steps.Staff").
 		First(&booking).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
@@ -85,7 +91,9 @@ func (h *Handler) GetBooking(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"booking": booking})
 }
 
-// CreateBooking creates a new booking
+// CreateBooking creates a new booking. The end time and price are worked out
+// from the services booked, applying any discount and the organization's
+// default tax rate.
 func (h *Handler) CreateBooking(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
 	if orgID == "" {
@@ -94,14 +102,15 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 	}
 
 	var request struct {
-		CustomerID    string    `json:"customer_id" binding:"required"`
-		VehicleID     *string   `json:"vehicle_id"`
-		StaffID       *string   `json:"staff_id"`
-		StartTime     time.Time `json:"start_time" binding:"required"`
-		EndTime       time.Time `json:"end_time" binding:"required"`
-		ServiceIDs    []string  `json:"service_ids" binding:"required"`
-		Notes         string    `json:"notes"`
-		InternalNotes string    `json:"internal_notes"`
+		CustomerID    string               `json:"customer_id" binding:"required"`
+		VehicleID     *string              `json:"vehicle_id"`
+		StaffID       *string              `json:"staff_id"`
+		StartTime     time.Time            `json:"start_time" binding:"required"`
+		ServiceIDs    []string             `json:"service_ids"`
+		Steps         []bookingStepRequest `json:"steps" binding:"dive"`
+		DiscountID    *string              `json:"discount_id"`
+		Notes         string               `json:"notes"`
+		InternalNotes string               `json:"internal_notes"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -134,43 +143,73 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		}
 	}
 
-	// Validate services exist and belong to organization
-	var services []models.Service
-	if err := h.DB.Where("id IN ? AND organization_id = ?", request.ServiceIDs, orgID).Find(&services).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service IDs"})
+	// Validate services and lay them out from the start time
+	steps, services, problem, err := planBookingSteps(h.DB, orgID, request.StaffID, request.ServiceIDs, request.Steps)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch services"})
 		return
 	}
-
-	if len(services) != len(request.ServiceIDs) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Some service IDs are invalid"})
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	endTime := scheduleBookingSteps(steps, request.StartTime)
+	if problem := stepStaffProblem(steps); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
 		return
 	}
 
-	// Check for booking conflicts
-	var conflictCount int64
-	conflictQuery := h.DB.Model(&models.Booking{}).Where(
-		"organization_id = ? AND status NOT IN ? AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-		orgID, []string{"cancelled", "no_show"}, request.StartTime, request.StartTime, request.EndTime, request.EndTime,
-	)
-
-	if request.StaffID != nil {
-		conflictQuery = conflictQuery.Where("staff_id = ?", *request.StaffID)
+	var org models.Organization
+	if err := h.DB.Where("id = ?", orgID).First(&org).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organization"})
+		return
 	}
+	requirements := serviceRequirements(services)
 
-	if request.VehicleID != nil {
-		conflictQuery = conflictQuery.Where("vehicle_id = ?", *request.VehicleID)
+	// Check for booking conflicts. Staff are checked step by step, and
+	// services that need resources are limited by those resources rather than
+	// the organization as a whole.
+	booked, err := stepsStaffBooked(h.DB, orgID, steps, "")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check staff availability"})
+		return
 	}
+	if !booked && (request.VehicleID != nil || (len(requirements) == 0 && !stepsStaffed(steps))) {
+		var conflictCount int64
+		conflictQuery := h.DB.Model(&models.Booking{}).Where(
+			"organization_id = ? AND status NOT IN ? AND start_time < ? AND end_time > ?",
+			orgID, []string{"cancelled", "no_show"}, endTime, request.StartTime,
+		)
+
+		if request.VehicleID != nil {
+			conflictQuery = conflictQuery.Where("vehicle_id = ?", *request.VehicleID)
+		}
 
-	conflictQuery.Count(&conflictCount)
-	if conflictCount > 0 {
+		conflictQuery.Count(&conflictCount)
+		booked = conflictCount > 0
+	}
+	if booked {
 		c.JSON(http.StatusConflict, gin.H{"error": "Booking time conflicts with existing appointment"})
 		return
 	}
 
-	// Calculate total price
-	var totalPrice float64
-	for _, service := range services {
-		totalPrice += service.Price
+	// Price the booking
+	var discount *models.Discount
+	if request.DiscountID != nil && *request.DiscountID != "" {
+		discount = &models.Discount{}
+		if err := h.DB.Where("id = ? AND organization_id = ?", *request.DiscountID, orgID).First(discount).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount ID"})
+			return
+		}
+		if problem := discountProblem(discount, priceBooking(steps, nil, nil).SubTotal, time.Now()); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+	}
+	tax, err := defaultTaxRate(h.DB, orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tax rate"})
+		return
 	}
 
 	// Create booking
@@ -180,12 +219,12 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		VehicleID:      request.VehicleID,
 		StaffID:        request.StaffID,
 		StartTime:      request.StartTime,
-		EndTime:        request.EndTime,
+		EndTime:        endTime,
 		Status:         "scheduled",
-		TotalPrice:     totalPrice,
 		Notes:          request.Notes,
 		InternalNotes:  request.InternalNotes,
 	}
+	priceBooking(steps, discount, tax).applyTo(&booking)
 
 	// Start transaction
 	tx := h.DB.Begin()
@@ -207,18 +246,65 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate services"})
 		return
 	}
+	if err := saveBookingSteps(tx, booking.ID, steps); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save booking steps"})
+		return
+	}
+
+	if discount != nil {
+		claimed, err := claimDiscount(tx, discount.ID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply discount"})
+			return
+		}
+		if !claimed {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Discount has reached its usage limit"})
+			return
+		}
+	}
+
+	// Set aside the resources the services need. The schedule lock stops
+	// another booking taking the last of a resource between check and save.
+	if err := lockBookingSchedule(tx, orgID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resources"})
+		return
+	}
+	allocations, available, err := availability.AllocateResources(tx, orgID, requirements, booking.StartTime, booking.EndTime, bookingBuffer(&org, services), booking.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resources"})
+		return
+	}
+	if !available {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Required resources are not available at that time"})
+		return
+	}
+	if err := saveBookingResources(tx, booking.ID, allocations); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate resources"})
+		return
+	}
 
 	tx.Commit()
+	h.scheduleBookingNotifications(booking.ID)
 
 	// Reload booking with relationships
 	h.DB.Where("id = ?", booking.ID).
-		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").
+		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").Preload("Resources.Resource").
+		Preload("Steps", orderedSteps).
 		First(&booking)
 
 	c.JSON(http.StatusCreated, gin.H{"booking": booking})
 }
 
-// UpdateBooking updates an existing booking
+// UpdateBooking updates an existing booking. Moving the booking or changing
+// its services or staff re-times its steps, and changing its services or
+// discount re-prices it.
 func (h *Handler) UpdateBooking(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
 	if orgID == "" {
@@ -238,14 +324,15 @@ func (h *Handler) UpdateBooking(c *gin.Context) {
 	}
 
 	var request struct {
-		VehicleID     *string   `json:"vehicle_id"`
-		StaffID       *string   `json:"staff_id"`
-		StartTime     time.Time `json:"start_time"`
-		EndTime       time.Time `json:"end_time"`
-		Status        string    `json:"status"`
-		ServiceIDs    []string  `json:"service_ids"`
-		Notes         string    `json:"notes"`
-		InternalNotes string    `json:"internal_notes"`
+		VehicleID     *string              `json:"vehicle_id"`
+		StaffID       *string              `json:"staff_id"`
+		StartTime     time.Time            `json:"start_time"`
+		Status        string               `json:"status"`
+		ServiceIDs    []string             `json:"service_ids"`
+		Steps         []bookingStepRequest `json:"steps" binding:"dive"`
+		DiscountID    *string              `json:"discount_id"` // An empty ID removes the discount
+		Notes         string               `json:"notes"`
+		InternalNotes string               `json:"internal_notes"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -271,21 +358,117 @@ func (h *Handler) UpdateBooking(c *gin.Context) {
 		}
 	}
 
+	servicesChanged := len(request.ServiceIDs) > 0 || len(request.Steps) > 0
+	retime := servicesChanged || !request.StartTime.IsZero() || request.StaffID != nil
+	reprice := servicesChanged || request.DiscountID != nil
+
+	// Work out the booking's steps as they'll be after the update
+	var steps []models.BookingStep
+	var services []models.Service
+	if servicesChanged {
+		staffID := booking.StaffID
+		if request.StaffID != nil {
+			staffID = request.StaffID
+		}
+		var problem string
+		var err error
+		steps, services, problem, err = planBookingSteps(h.DB, orgID, staffID, request.ServiceIDs, request.Steps)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch services"})
+			return
+		}
+		if problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+	} else if retime || reprice {
+		var err error
+		if steps, err = currentBookingSteps(h.DB, &booking); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking steps"})
+			return
+		}
+		// Steps done by the booking's staff member move with it
+		if request.StaffID != nil {
+			for i := range steps {
+				if steps[i].StaffID == nil || (booking.StaffID != nil && *steps[i].StaffID == *booking.StaffID) {
+					steps[i].StaffID = request.StaffID
+				}
+			}
+		}
+	}
+
 	// Update booking
 	updates := make(map[string]interface{})
-	
+
+	if retime {
+		start := booking.StartTime
+		if !request.StartTime.IsZero() {
+			start = request.StartTime
+		}
+		end := scheduleBookingSteps(steps, start)
+		if len(steps) == 0 {
+			end = start.Add(booking.EndTime.Sub(booking.StartTime))
+		}
+		if problem := stepStaffProblem(steps); problem != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+			return
+		}
+		booked, err := stepsStaffBooked(h.DB, orgID, steps, booking.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check staff availability"})
+			return
+		}
+		if booked {
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking time conflicts with existing appointment"})
+			return
+		}
+		updates["start_time"] = start
+		updates["end_time"] = end
+	}
+
+	// A discount already on the booking stays while the booking meets its
+	// minimum spend; a new one must be usable now
+	var discount, newDiscount *models.Discount
+	if reprice {
+		discountID := booking.DiscountID
+		if request.DiscountID != nil {
+			discountID = request.DiscountID
+		}
+		subTotal := priceBooking(steps, nil, nil).SubTotal
+		if discountID != nil && *discountID != "" {
+			discount = &models.Discount{}
+			if err := h.DB.Unscoped().Where("id = ? AND organization_id = ?", *discountID, orgID).First(discount).Error; err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discount ID"})
+				return
+			}
+			problem := ""
+			if booking.DiscountID == nil || *booking.DiscountID != discount.ID {
+				newDiscount = discount
+				problem = discountProblem(discount, subTotal, time.Now())
+			} else if subTotal < discount.MinPurchase {
+				problem = fmt.Sprintf("Discount needs a minimum spend of %.2f", discount.MinPurchase)
+			}
+			if problem != "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+				return
+			}
+		}
+		tax, err := defaultTaxRate(h.DB, orgID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tax rate"})
+			return
+		}
+		for column, value := range priceBooking(steps, discount, tax).columns() {
+			updates[column] = value
+		}
+	}
+
 	if request.VehicleID != nil {
 		updates["vehicle_id"] = *request.VehicleID
 	}
 	if request.StaffID != nil {
 		updates["staff_id"] = *request.StaffID
 	}
-	if !request.StartTime.IsZero() {
-		updates["start_time"] = request.StartTime
-	}
-	if !request.EndTime.IsZero() {
-		updates["end_time"] = request.EndTime
-	}
 	if request.Status != "" {
 		updates["status"] = request.Status
 	}
@@ -296,6 +479,8 @@ func (h *Handler) UpdateBooking(c *gin.Context) {
 		updates["internal_notes"] = request.InternalNotes
 	}
 
+	oldDiscountID := booking.DiscountID
+
 	// Start transaction
 	tx := h.DB.Begin()
 	defer func() {
@@ -311,46 +496,91 @@ func (h *Handler) UpdateBooking(c *gin.Context) {
 	}
 
 	// Update services if provided
-	if len(request.ServiceIDs) > 0 {
-		var services []models.Service
-		if err := tx.Where("id IN ? AND organization_id = ?", request.ServiceIDs, orgID).Find(&services).Error; err != nil {
+	if servicesChanged {
+		if err := tx.Model(&booking).Association("Services").Replace(&services); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service IDs"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update services"})
 			return
 		}
-
-		if len(services) != len(request.ServiceIDs) {
+	}
+	if retime {
+		if err := saveBookingSteps(tx, booking.ID, steps); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Some service IDs are invalid"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save booking steps"})
 			return
 		}
+	}
 
-		// Calculate new total price
-		var totalPrice float64
-		for _, service := range services {
-			totalPrice += service.Price
+	// Move the discount's usage over when it changes
+	if reprice && (newDiscount != nil || (discount == nil && oldDiscountID != nil)) {
+		if oldDiscountID != nil {
+			if err := releaseDiscount(tx, *oldDiscountID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update discount"})
+				return
+			}
 		}
+		if newDiscount != nil {
+			claimed, err := claimDiscount(tx, newDiscount.ID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply discount"})
+				return
+			}
+			if !claimed {
+				tx.Rollback()
+				c.JSON(http.StatusConflict, gin.H{"error": "Discount has reached its usage limit"})
+				return
+			}
+		}
+	}
 
-		// Update services association
-		if err := tx.Model(&booking).Association("Services").Replace(&services); err != nil {
+	// Re-allocate resources when the booking moves or its services change
+	if retime {
+		var updated models.Booking
+		var org models.Organization
+		if err := tx.Preload("Services.ResourceRequirements").Where("id = ?", booking.ID).First(&updated).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update services"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch booking"})
+			return
+		}
+		if err := lockBookingSchedule(tx, orgID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resources"})
+			return
+		}
+		if err := tx.Where("id = ?", orgID).First(&org).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organization"})
 			return
 		}
 
-		// Update total price
-		if err := tx.Model(&booking).Update("total_price", totalPrice).Error; err != nil {
+		allocations, available, err := availability.AllocateResources(tx, orgID, serviceRequirements(updated.Services),
+			updated.StartTime, updated.EndTime, bookingBuffer(&org, updated.Services), booking.ID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resources"})
+			return
+		}
+		if !available {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update total price"})
+			c.JSON(http.StatusConflict, gin.H{"error": "Required resources are not available at that time"})
+			return
+		}
+		if err := saveBookingResources(tx, booking.ID, allocations); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to allocate resources"})
 			return
 		}
 	}
 
 	tx.Commit()
+	h.scheduleBookingNotifications(booking.ID)
 
 	// Reload booking with relationships
 	h.DB.Where("id = ?", booking.ID).
-		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").
+		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").Preload("Resources.Resource").
+		Preload("Steps", orderedSteps).
 		First(&booking)
 
 	c.JSON(http.StatusOK, gin.H{"booking": booking})
@@ -383,7 +613,49 @@ func (h *Handler) DeleteBooking(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Booking deleted successfully"})
 }
 
-// GetAvailableTimeSlots returns available booking time slots for a given date
+// splitIDs parses a comma separated list of IDs from a query parameter
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// bookingBuffer is the gap kept free around a booking for the given services:
+// the longest service buffer, or the organization's buffer time when none is set
+func bookingBuffer(org *models.Organization, services []models.Service) time.Duration {
+	buffer := 0
+	for _, service := range services {
+		if service.BufferMinutes > buffer {
+			buffer = service.BufferMinutes
+		}
+	}
+	if buffer == 0 {
+		buffer = org.BookingSettings.BufferTime
+	}
+	return time.Duration(buffer) * time.Minute
+}
+
+// findBookingSlots runs the availability engine in the organization's timezone
+func (h *Handler) findBookingSlots(db *gorm.DB, org *models.Organization, q availability.Query) ([]availability.Day, error) {
+	q.Location = h.organizationLocation(org.ID)
+	return availability.Find(db, org, q)
+}
+
+// lockBookingSchedule locks the organization's row for the rest of tx so
+// that checking a time is free and booking it can't interleave with another
+// booking doing the same
+func lockBookingSchedule(tx *gorm.DB, orgID string) error {
+	var org models.Organization
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", orgID).First(&org).Error
+}
+
+// GetAvailableTimeSlots returns available booking time slots for a date, or for
+// each date from start_date to end_date, in the organization's timezone. The
+// booking length comes from service_ids, or from duration in minutes.
 func (h *Handler) GetAvailableTimeSlots(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
 	if orgID == "" {
@@ -392,104 +664,84 @@ func (h *Handler) GetAvailableTimeSlots(c *gin.Context) {
 	}
 
 	date := c.Query("date")
-	staffID := c.Query("staff_id")
-	duration, _ := strconv.Atoi(c.DefaultQuery("duration", "60")) // Default 60 minutes
-
-	if date == "" {
+	startDate, endDate := c.DefaultQuery("start_date", date), c.DefaultQuery("end_date", date)
+	if startDate == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Date parameter is required"})
 		return
 	}
 
-	parsedDate, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
-		return
-	}
-
 	// Get organization to check business hours
 	var org models.Organization
 	if err := h.DB.Where("id = ?", orgID).First(&org).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organization"})
 		return
 	}
+	loc := h.organizationLocation(org.ID)
 
-	// Get business hours for the day
-	dayOfWeek := parsedDate.Weekday()
-	var openTime, closeTime string
-
-	switch dayOfWeek {
-	case time.Monday:
-		openTime, closeTime = org.BusinessHours.MondayOpen, org.BusinessHours.MondayClose
-	case time.Tuesday:
-		openTime, closeTime = org.BusinessHours.TuesdayOpen, org.BusinessHours.TuesdayClose
-	case time.Wednesday:
-		openTime, closeTime = org.BusinessHours.WednesdayOpen, org.BusinessHours.WednesdayClose
-	case time.Thursday:
-		openTime, closeTime = org.BusinessHours.ThursdayOpen, org.BusinessHours.ThursdayClose
-	case time.Friday:
-		openTime, closeTime = org.BusinessHours.FridayOpen, org.BusinessHours.FridayClose
-	case time.Saturday:
-		openTime, closeTime = org.BusinessHours.SaturdayOpen, org.BusinessHours.SaturdayClose
-	case time.Sunday:
-		openTime, closeTime = org.BusinessHours.SundayOpen, org.BusinessHours.SundayClose
+	from, err := time.ParseInLocation("2006-01-02", startDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
 	}
-
-	if openTime == "" || closeTime == "" {
-		c.JSON(http.StatusOK, gin.H{"available_slots": []string{}})
+	to := from
+	if endDate != "" {
+		if to, err = time.ParseInLocation("2006-01-02", endDate, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) || to.Sub(from) >= availability.MaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Date range must run forwards and cover at most %d days", availability.MaxDays)})
 		return
 	}
 
-	// Parse business hours
-	startTime, err := time.Parse("15:04", openTime)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid business hours format"})
-		return
+	query := availability.Query{From: from, To: to, StaffIDs: splitIDs(c.Query("staff_id"))}
+	if serviceIDs := splitIDs(c.Query("service_ids")); len(serviceIDs) > 0 {
+		var services []models.Service
+		h.DB.Preload("ResourceRequirements").Where("id IN ? AND organization_id = ?", serviceIDs, orgID).Find(&services)
+		if len(services) != len(serviceIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Some service IDs are invalid"})
+			return
+		}
+		for _, service := range services {
+			query.Duration += time.Duration(service.Duration) * time.Minute
+		}
+		query.Buffer = bookingBuffer(&org, services)
+		query.Requirements = serviceRequirements(services)
+	} else {
+		duration, _ := strconv.Atoi(c.DefaultQuery("duration", "60")) // Default 60 minutes
+		if duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duration must be greater than 0"})
+			return
+		}
+		query.Duration = time.Duration(duration) * time.Minute
+		query.Buffer = bookingBuffer(&org, nil)
+	}
+	if interval, _ := strconv.Atoi(c.Query("interval")); interval > 0 {
+		query.Interval = time.Duration(interval) * time.Minute
 	}
 
-	endTime, err := time.Parse("15:04", closeTime)
+	days, err := h.findBookingSlots(h.DB, &org, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid business hours format"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check availability"})
 		return
 	}
 
-	// Convert to full datetime
-	startDateTime := time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), startTime.Hour(), startTime.Minute(), 0, 0, parsedDate.Location())
-	endDateTime := time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(), endTime.Hour(), endTime.Minute(), 0, 0, parsedDate.Location())
-
-	// Get existing bookings for the day
-	var bookings []models.Booking
-	query := h.DB.Where("organization_id = ? AND DATE(start_time) = ? AND status NOT IN ?", 
-		orgID, parsedDate.Format("2006-01-02"), []string{"cancelled", "no_show"})
-	
-	if staffID != "" {
-		query = query.Where("staff_id = ?", staffID)
+	response := gin.H{
+		"timezone": loc.String(),
+		"duration": int(query.Duration / time.Minute),
+		"days":     days,
 	}
-	
-	query.Find(&bookings)
-
-	// Generate time slots
-	slotDuration := time.Duration(duration) * time.Minute
-	bufferTime := time.Duration(org.BookingSettings.BufferTime) * time.Minute
-	var availableSlots []string
-
-	for current := startDateTime; current.Add(slotDuration).Before(endDateTime) || current.Add(slotDuration).Equal(endDateTime); current = current.Add(slotDuration + bufferTime) {
-		slotEnd := current.Add(slotDuration)
-		
-		// Check if this slot conflicts with any existing booking
-		conflict := false
-		for _, booking := range bookings {
-			if (current.Before(booking.EndTime) && slotEnd.After(booking.StartTime)) {
-				conflict = true
-				break
-			}
-		}
-
-		if !conflict {
-			availableSlots = append(availableSlots, current.Format("15:04"))
+	if date != "" && len(days) == 1 {
+		// Kept for clients that only read the day's start times
+		availableSlots := []string{}
+		for _, slot := range days[0].Slots {
+			availableSlots = append(availableSlots, slot.Time)
 		}
+		response["available_slots"] = availableSlots
 	}
 
-	c.JSON(http.StatusOK, gin.H{"available_slots": availableSlots})
+	c.JSON(http.StatusOK, response)
 }
 
 // UpdateBookingStatus updates just the status of a booking
@@ -502,7 +754,9 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 
 	id := c.Param("id")
 	var request struct {
-		Status string `json:"status" binding:"required"`
+		Status        string `json:"status" binding:"required"`
+		RefundDeposit *bool  `json:"refund_deposit"` // Overrides the cancellation window when cancelling
+		Odometer      *int   `json:"odometer"`       // Vehicle's odometer, usually read on arrival or completion
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -510,7 +764,7 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	validStatuses := []string{"scheduled", "confirmed", "in_progress", "completed", "cancelled", "no_show"}
+	validStatuses := []string{"pending_approval", "scheduled", "confirmed", "in_progress", "completed", "cancelled", "no_show"}
 	valid := false
 	for _, status := range validStatuses {
 		if request.Status == status {
@@ -534,15 +788,62 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.DB.Model(&booking).Update("status", request.Status).Error; err != nil {
+	freed := (request.Status == "cancelled" || request.Status == "no_show") &&
+		booking.Status != "cancelled" && booking.Status != "no_show"
+
+	problem := ""
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if request.Odometer != nil && booking.VehicleID != nil {
+			var vehicle models.Vehicle
+			if err := tx.Where("id = ?", *booking.VehicleID).First(&vehicle).Error; err != nil {
+				return err
+			}
+			var err error
+			problem, err = recordOdometer(tx, &vehicle, models.OdometerReading{
+				BookingID: &booking.ID, Reading: *request.Odometer, Source: "booking", RecordedBy: c.GetString("user_id"),
+			})
+			if err != nil || problem != "" {
+				return err
+			}
+		}
+		return tx.Model(&booking).Update("status", request.Status).Error
+	})
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking status"})
 		return
 	}
+	h.scheduleBookingNotifications(booking.ID)
+
+	// Refund or keep the deposit and charge any no-show fee. Confirming a
+	// booking that's waiting for its deposit waives the deposit.
+	if freed {
+		booking.Status = request.Status
+		if err := h.settleBooking(&booking, request.RefundDeposit); err != nil {
+			log.Printf("Failed to settle payments for booking %s: %v", booking.ID, err)
+		}
+	} else if booking.DepositStatus == "pending" {
+		if err := h.dropPendingDeposit(&booking, "waived"); err != nil {
+			log.Printf("Failed to waive deposit for booking %s: %v", booking.ID, err)
+		}
+	}
+
+	// Offer the freed slot to the waitlist
+	var offer *models.WaitlistOffer
+	if freed {
+		var err error
+		if offer, err = h.offerFreedSlot(&booking); err != nil {
+			log.Printf("Failed to offer freed slot from booking %s: %v", booking.ID, err)
+		}
+	}
 
 	// Reload booking with relationships
 	h.DB.Where("id = ?", booking.ID).
-		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").
+		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").Preload("Resources.Resource").
 		First(&booking)
 
-	c.JSON(http.StatusOK, gin.H{"booking": booking})
+	c.JSON(http.StatusOK, gin.H{"booking": booking, "waitlist_offer": offer})
 }
\ No newline at end of file
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+)
+
+// GetBusinessBreaks lists the organization's recurring breaks in business hours
+func (h *Handler) GetBusinessBreaks(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var breaks []models.BusinessBreak
+	if err := h.DB.Where("organization_id = ?", orgID).Order("weekday, start_time").Find(&breaks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch breaks",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    breaks,
+	})
+}
+
+type BusinessBreakRequest struct {
+	Weekday   *int   `json:"weekday" binding:"omitempty,min=0,max=6"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+	Label     string `json:"label"`
+}
+
+// CreateBusinessBreak adds a recurring break, such as lunch, to business hours
+func (h *Handler) CreateBusinessBreak(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req BusinessBreakRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	start, startOK := availability.ParseClock(req.StartTime)
+	end, endOK := availability.ParseClock(req.EndTime)
+	if !startOK || !endOK || end <= start {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME",
+				"message": "Break times must be HH:MM with the end after the start",
+			},
+		})
+		return
+	}
+
+	brk := models.BusinessBreak{
+		OrganizationID: fmt.Sprintf("%v", orgID),
+		Weekday:        req.Weekday,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Label:          req.Label,
+	}
+	if err := h.DB.Create(&brk).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create break",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    brk,
+		"message": "Break created successfully",
+	})
+}
+
+// DeleteBusinessBreak removes a recurring break
+func (h *Handler) DeleteBusinessBreak(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	result := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Delete(&models.BusinessBreak{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete break",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BREAK_NOT_FOUND",
+				"message": "Break not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Break deleted successfully",
+	})
+}
+
+// GetBusinessClosures lists the organization's closures, optionally only those
+// still to come
+func (h *Handler) GetBusinessClosures(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("organization_id = ?", orgID)
+	if c.Query("upcoming") == "true" {
+		query = query.Where("end_date >= ?", dateOnly(time.Now().In(h.organizationLocation(orgID))))
+	}
+
+	var closures []models.BusinessClosure
+	if err := query.Order("start_date").Find(&closures).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch closures",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    closures,
+	})
+}
+
+type BusinessClosureRequest struct {
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`                      // YYYY-MM-DD; defaults to the start date
+	Reason    string `json:"reason"`
+}
+
+// CreateBusinessClosure closes the organization for bookings on a run of dates
+func (h *Handler) CreateBusinessClosure(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req BusinessClosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.EndDate == "" {
+		req.EndDate = req.StartDate
+	}
+
+	startDate, startErr := time.Parse("2006-01-02", req.StartDate)
+	endDate, endErr := time.Parse("2006-01-02", req.EndDate)
+	if startErr != nil || endErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": "End date must be on or after start date",
+			},
+		})
+		return
+	}
+
+	closure := models.BusinessClosure{
+		OrganizationID: fmt.Sprintf("%v", orgID),
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Reason:         req.Reason,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if err := h.DB.Create(&closure).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create closure",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    closure,
+		"message": "Closure created successfully",
+	})
+}
+
+// DeleteBusinessClosure reopens the dates of a closure
+func (h *Handler) DeleteBusinessClosure(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	result := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Delete(&models.BusinessClosure{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete closure",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CLOSURE_NOT_FOUND",
+				"message": "Closure not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Closure deleted successfully",
+	})
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/ical"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+)
+
+// Calendar feeds cover a month back and a year ahead
+const (
+	calendarFeedHistory = 30 * 24 * time.Hour
+	calendarFeedHorizon = 365 * 24 * time.Hour
+)
+
+// calendarUID is the iCalendar UID for a booking or shift. It never changes,
+// so calendars update the existing event when the booking or shift moves.
+func (h *Handler) calendarUID(kind, id string) string {
+	host := "das-crm"
+	if parsed, err := url.Parse(h.Config.AppURL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	return fmt.Sprintf("%s-%s@%s", kind, id, host)
+}
+
+// formatAddress joins the parts of an address that are filled in
+func formatAddress(address models.Address) string {
+	var parts []string
+	for _, part := range []string{address.Street, address.Suburb, address.State, address.Postcode} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bookingEvent describes a booking as a calendar event. It expects the
+// booking's customer and services to be loaded.
+func (h *Handler) bookingEvent(org *models.Organization, booking *models.Booking) ical.Event {
+	names := make([]string, len(booking.Services))
+	for i, service := range booking.Services {
+		names[i] = service.Name
+	}
+	location := org.Name
+	if address := formatAddress(org.Address); address != "" {
+		location += ", " + address
+	}
+	status := "CONFIRMED"
+	switch booking.Status {
+	case "cancelled":
+		status = "CANCELLED"
+	case "pending_approval":
+		status = "TENTATIVE"
+	}
+
+	return ical.Event{
+		UID: h.calendarUID("booking", booking.ID),
+		// Bookings have no revision counter; the last update time always
+		// increases, which is all calendars need from the sequence
+		Sequence:     int(booking.UpdatedAt.Unix()),
+		Start:        booking.StartTime,
+		End:          booking.EndTime,
+		Summary:      strings.Join(names, ", "),
+		Location:     location,
+		Status:       status,
+		LastModified: booking.UpdatedAt,
+	}
+}
+
+// shiftEvent describes a shift as a calendar event for the staff member working it
+func (h *Handler) shiftEvent(shift *models.Shift) ical.Event {
+	summary := shift.ServiceType
+	if name := strings.TrimSpace(shift.Participant.FirstName + " " + shift.Participant.LastName); name != "" {
+		summary += " - " + name
+	}
+	status := "CONFIRMED"
+	if shift.Status == "cancelled" {
+		status = "CANCELLED"
+	}
+
+	return ical.Event{
+		UID:          h.calendarUID("shift", shift.ID),
+		Sequence:     int(shift.UpdatedAt.Unix()),
+		Start:        shift.StartTime,
+		End:          shift.EndTime,
+		Summary:      summary,
+		Description:  shift.Notes,
+		Location:     shift.Location,
+		Status:       status,
+		LastModified: shift.UpdatedAt,
+	}
+}
+
+// bookingInvite is an .ics attachment that adds, updates or removes a
+// booking in the customer's calendar
+func (h *Handler) bookingInvite(org *models.Organization, booking *models.Booking, method string) notify.Attachment {
+	event := h.bookingEvent(org, booking)
+	event.Organizer = org.Email
+	if event.Organizer == "" {
+		event.Organizer = h.Config.SMTPFrom
+	}
+	event.Attendee = booking.Customer.Email
+	if method == ical.MethodCancel {
+		event.Status = "CANCELLED"
+	}
+
+	cal := ical.Calendar{Method: method, Events: []ical.Event{event}}
+	return notify.Attachment{
+		Filename:    "booking.ics",
+		ContentType: "text/calendar; charset=UTF-8; method=" + method,
+		Data:        []byte(cal.String()),
+	}
+}
+
+// calendarFeedURL is the subscription address for a feed token
+func (h *Handler) calendarFeedURL(token string) string {
+	return fmt.Sprintf("%s/api/v1/calendar/feeds/%s.ics", strings.TrimRight(h.Config.AppURL, "/"), token)
+}
+
+// newCalendarToken generates an unguessable feed token
+func newCalendarToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetCalendarFeed returns the current user's private calendar subscription
+// URL, creating it the first time
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	h.calendarFeed(c, false)
+}
+
+// ResetCalendarFeed replaces the current user's subscription URL so links
+// shared before stop working
+func (h *Handler) ResetCalendarFeed(c *gin.Context) {
+	h.calendarFeed(c, true)
+}
+
+func (h *Handler) calendarFeed(c *gin.Context, reset bool) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+
+	failed := func() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to set up calendar feed",
+			},
+		})
+	}
+
+	var feed models.CalendarFeed
+	found := h.DB.Where("user_id = ?", userID).First(&feed).Error == nil
+	if !found || reset {
+		token, err := newCalendarToken()
+		if err != nil {
+			failed()
+			return
+		}
+		feed.Token = token
+		if found {
+			err = h.DB.Model(&feed).Update("token", token).Error
+		} else {
+			feed.OrganizationID, feed.UserID = fmt.Sprintf("%v", orgID), userID
+			err = h.DB.Create(&feed).Error
+		}
+		if err != nil {
+			failed()
+			return
+		}
+	}
+
+	feedURL := h.calendarFeedURL(feed.Token)
+	webcalURL := feedURL
+	if _, rest, ok := strings.Cut(feedURL, "://"); ok {
+		webcalURL = "webcal://" + rest
+	}
+	message := "Calendar feed ready"
+	if reset {
+		message = "Calendar feed reset; subscribe again with the new URL"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"url":             feedURL,
+			"webcal_url":      webcalURL,
+			"last_fetched_at": feed.LastFetchedAt,
+		},
+		"message": message,
+	})
+}
+
+// GetCalendarFeedICS serves a user's bookings and shifts as an iCalendar
+// feed. It needs no login: the token in the URL is the credential.
+func (h *Handler) GetCalendarFeedICS(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	var feed models.CalendarFeed
+	if token == "" || h.DB.Where("token = ?", token).First(&feed).Error != nil {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return
+	}
+	var user models.User
+	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", feed.UserID, feed.OrganizationID, true).First(&user).Error; err != nil {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return
+	}
+	var org models.Organization
+	if err := h.DB.Where("id = ?", feed.OrganizationID).First(&org).Error; err != nil {
+		c.String(http.StatusNotFound, "Calendar not found")
+		return
+	}
+
+	now := time.Now()
+	from, to := now.Add(-calendarFeedHistory), now.Add(calendarFeedHorizon)
+	cal := ical.Calendar{Name: fmt.Sprintf("%s - %s", org.Name, user.FirstName), Refresh: time.Hour}
+
+	var bookings []models.Booking
+	if err := h.DB.Preload("Customer").Preload("Services").
+		Where("organization_id = ? AND staff_id = ? AND start_time < ? AND end_time > ?", org.ID, user.ID, to, from).
+		Order("start_time ASC").Find(&bookings).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load calendar")
+		return
+	}
+	for i := range bookings {
+		event := h.bookingEvent(&org, &bookings[i])
+		// Staff see who the booking is for
+		if name := strings.TrimSpace(bookings[i].Customer.FirstName + " " + bookings[i].Customer.LastName); name != "" {
+			event.Summary += " - " + name
+		}
+		event.Description = strings.TrimSpace(strings.Join([]string{bookings[i].Customer.Phone, bookings[i].Notes}, "\n"))
+		cal.Events = append(cal.Events, event)
+	}
+
+	var shifts []models.Shift
+	if err := h.DB.Preload("Participant").
+		Where("staff_id = ? AND start_time < ? AND end_time > ?", user.ID, to, from).
+		Order("start_time ASC").Find(&shifts).Error; err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load calendar")
+		return
+	}
+	for i := range shifts {
+		cal.Events = append(cal.Events, h.shiftEvent(&shifts[i]))
+	}
+
+	h.DB.Model(&feed).Update("last_fetched_at", now)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(cal.String()))
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.CalendarFeed{}, &models.Job{}, &models.BookingNotification{})
+	handler.Config.AppURL = "https://crm.example.com"
+
+	handler.DB.Create(&models.Service{ID: "haircut", OrganizationID: "test-org", Name: "Haircut", Category: "hair", Duration: 30, Price: 40, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "feed-customer", OrganizationID: "test-org", FirstName: "Cal", LastName: "Endar", IsActive: true})
+	handler.DB.Create(&models.Participant{ID: "feed-participant", FirstName: "Pia", LastName: "Participant", DateOfBirth: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), NDISNumber: "430000001"})
+
+	staff, other := "test-user", "someone-else"
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	booking := models.Booking{OrganizationID: "test-org", CustomerID: "feed-customer", StaffID: &staff, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: "confirmed"}
+	handler.DB.Create(&booking)
+	handler.DB.Model(&booking).Association("Services").Append(&models.Service{ID: "haircut"})
+	handler.DB.Create(&models.Booking{OrganizationID: "test-org", CustomerID: "feed-customer", StaffID: &other, StartTime: start, EndTime: start.Add(30 * time.Minute), Status: "confirmed"})
+	shift := models.Shift{ParticipantID: "feed-participant", StaffID: "test-user", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(4 * time.Hour), ServiceType: "Community access", Location: "Library", HourlyRate: 65}
+	handler.DB.Create(&shift)
+
+	fetch := func(feedURL string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", strings.TrimPrefix(feedURL, handler.Config.AppURL), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var feedURL string
+
+	t.Run("Staff get a private subscription URL", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/calendar/feed", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		feedURL = data["url"].(string)
+		assert.True(t, strings.HasPrefix(feedURL, "https://crm.example.com/api/v1/calendar/feeds/"))
+		assert.True(t, strings.HasSuffix(feedURL, ".ics"))
+		assert.Equal(t, "webcal://"+strings.TrimPrefix(feedURL, "https://"), data["webcal_url"])
+
+		// Asking again returns the same URL
+		_, response = doRequest(handler, router, "GET", "/api/v1/calendar/feed", nil)
+		assert.Equal(t, feedURL, response["data"].(map[string]interface{})["url"])
+	})
+
+	t.Run("The feed lists the user's bookings and shifts without logging in", func(t *testing.T) {
+		w := fetch(feedURL)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/calendar")
+
+		body := w.Body.String()
+		assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+		assert.Contains(t, body, "UID:booking-"+booking.ID+"@crm.example.com\r\n")
+		assert.Contains(t, body, "SUMMARY:Haircut - Cal Endar\r\n")
+		assert.Contains(t, body, "UID:shift-"+shift.ID+"@crm.example.com\r\n")
+		assert.Contains(t, body, "SUMMARY:Community access - Pia Participant\r\n")
+		assert.Contains(t, body, "DTSTART:"+start.UTC().Format("20060102T150405Z")+"\r\n")
+		assert.NotContains(t, body, "METHOD:")
+
+		var feed models.CalendarFeed
+		handler.DB.Where("user_id = ?", "test-user").First(&feed)
+		assert.NotNil(t, feed.LastFetchedAt)
+	})
+
+	t.Run("Cancelled bookings stay in the feed as cancelled", func(t *testing.T) {
+		handler.DB.Model(&booking).Update("status", "cancelled")
+		body := fetch(feedURL).Body.String()
+		assert.Contains(t, body, "STATUS:CANCELLED\r\n")
+	})
+
+	t.Run("Resetting the feed retires the old URL", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/calendar/feed/reset", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		newURL := response["data"].(map[string]interface{})["url"].(string)
+		assert.NotEqual(t, feedURL, newURL)
+
+		assert.Equal(t, http.StatusNotFound, fetch(feedURL).Code)
+		assert.Equal(t, http.StatusOK, fetch(newURL).Code)
+	})
+
+	t.Run("Feeds stop working for deactivated users", func(t *testing.T) {
+		_, response := doRequest(handler, router, "GET", "/api/v1/calendar/feed", nil)
+		feedURL = response["data"].(map[string]interface{})["url"].(string)
+		handler.DB.Model(&models.User{}).Where("id = ?", "test-user").Update("is_active", false)
+		assert.Equal(t, http.StatusNotFound, fetch(feedURL).Code)
+	})
+}
@@ -0,0 +1,787 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type CarePlanGoalRequest struct {
+	Title            string `json:"title" binding:"required"`
+	OutcomeDomain    string `json:"outcome_domain" binding:"omitempty,oneof=daily_living home health_wellbeing lifelong_learning work social_community relationships choice_control"`
+	Description      string `json:"description"`
+	MeasurableTarget string `json:"measurable_target"`
+	ReviewDate       string `json:"review_date"` // YYYY-MM-DD
+	Status           string `json:"status" binding:"omitempty,oneof=active achieved discontinued"`
+	SortOrder        *int   `json:"sort_order,omitempty"`
+}
+
+// apply copies the request onto a goal, returning an error for a bad review date
+func (r *CarePlanGoalRequest) apply(goal *models.CarePlanGoal) error {
+	goal.Title = strings.TrimSpace(r.Title)
+	goal.OutcomeDomain = r.OutcomeDomain
+	goal.Description = r.Description
+	goal.MeasurableTarget = r.MeasurableTarget
+	goal.ReviewDate = nil
+	if r.ReviewDate != "" {
+		reviewDate, err := time.Parse("2006-01-02", r.ReviewDate)
+		if err != nil {
+			return err
+		}
+		goal.ReviewDate = &reviewDate
+	}
+	goal.Status = "active"
+	if r.Status != "" {
+		goal.Status = r.Status
+	}
+	if r.SortOrder != nil {
+		goal.SortOrder = *r.SortOrder
+	}
+	return nil
+}
+
+// orderedGoals preloads a plan's goals in display order
+func orderedGoals(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order ASC, created_at ASC")
+}
+
+// syncCarePlanGoalSummary rewrites CarePlan.Goals from the plan's goal records
+// so clients that read the old JSON field still see the current goals
+func syncCarePlanGoalSummary(tx *gorm.DB, planID string) error {
+	var titles []string
+	if err := tx.Model(&models.CarePlanGoal{}).Where("care_plan_id = ? AND status != ?", planID, "discontinued").
+		Order("sort_order ASC, created_at ASC").Pluck("title", &titles).Error; err != nil {
+		return err
+	}
+	summary := ""
+	if len(titles) > 0 {
+		encoded, _ := json.Marshal(titles)
+		summary = string(encoded)
+	}
+	return tx.Model(&models.CarePlan{}).Where("id = ?", planID).Update("goals", summary).Error
+}
+
+// reconcileLegacyGoals updates a plan's goal records from a list of titles sent
+// in the old Goals field: matching titles are kept, others are added or removed
+func reconcileLegacyGoals(tx *gorm.DB, planID string, titles []string) error {
+	var existing []models.CarePlanGoal
+	if err := tx.Where("care_plan_id = ?", planID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool)
+	for i, title := range titles {
+		matched := false
+		for _, goal := range existing {
+			if !kept[goal.ID] && strings.EqualFold(goal.Title, title) {
+				kept[goal.ID] = true
+				matched = true
+				if err := tx.Model(&goal).Update("sort_order", i).Error; err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if !matched {
+			if err := tx.Create(&models.CarePlanGoal{CarePlanID: planID, Title: title, Status: "active", SortOrder: i}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	for _, goal := range existing {
+		if !kept[goal.ID] {
+			if err := tx.Delete(&goal).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findCarePlan loads the care plan named by :id with its goals, writing the
+// error response when it isn't in the organization
+func (h *Handler) findCarePlan(c *gin.Context, orgID interface{}) (*models.CarePlan, bool) {
+	var carePlan models.CarePlan
+	if err := h.DB.Joins("JOIN participants ON care_plans.participant_id = participants.id").
+		Where("care_plans.id = ? AND participants.organization_id = ?", c.Param("id"), orgID).
+		Preload("GoalItems", orderedGoals).
+		First(&carePlan).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CARE_PLAN_NOT_FOUND",
+					"message": "Care plan not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch care plan",
+			},
+		})
+		return nil, false
+	}
+	return &carePlan, true
+}
+
+// carePlanLockedResponse writes the error for changes to an approved plan version
+func carePlanLockedResponse(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "CARE_PLAN_LOCKED",
+			"message": "This care plan version has been approved and can't be changed; create a new version to make changes",
+		},
+	})
+}
+
+// draftCarePlanVersion returns the open draft of a plan's lineage, copying the
+// given version and its goals into a new draft when there isn't one
+func draftCarePlanVersion(tx *gorm.DB, source *models.CarePlan, userID string) (*models.CarePlan, bool, error) {
+	lineageID := source.LineageID
+	if lineageID == "" {
+		lineageID = source.ID
+	}
+
+	var draft models.CarePlan
+	err := tx.Where("(lineage_id = ? OR id = ?) AND approved_at IS NULL AND status = ?", lineageID, lineageID, "draft").
+		Order("version DESC").First(&draft).Error
+	if err == nil {
+		return &draft, false, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, err
+	}
+
+	var latest int
+	if err := tx.Model(&models.CarePlan{}).Where("lineage_id = ? OR id = ?", lineageID, lineageID).
+		Select("COALESCE(MAX(version), 1)").Scan(&latest).Error; err != nil {
+		return nil, false, err
+	}
+
+	draft = models.CarePlan{
+		ParticipantID:     source.ParticipantID,
+		Title:             source.Title,
+		Description:       source.Description,
+		Goals:             source.Goals,
+		StartDate:         source.StartDate,
+		EndDate:           source.EndDate,
+		Status:            "draft",
+		Version:           latest + 1,
+		LineageID:         lineageID,
+		PreviousVersionID: &source.ID,
+		CreatedBy:         userID,
+	}
+	if err := tx.Create(&draft).Error; err != nil {
+		return nil, false, err
+	}
+
+	var goals []models.CarePlanGoal
+	if err := tx.Where("care_plan_id = ?", source.ID).Find(&goals).Error; err != nil {
+		return nil, false, err
+	}
+	for _, goal := range goals {
+		goal.ID = ""
+		goal.CarePlanID = draft.ID
+		goal.CreatedAt, goal.UpdatedAt = time.Time{}, time.Time{}
+		if err := tx.Create(&goal).Error; err != nil {
+			return nil, false, err
+		}
+	}
+	return &draft, true, nil
+}
+
+// ReviseCarePlan opens a draft version of an approved plan for a plan review
+func (h *Handler) ReviseCarePlan(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+
+	tx := h.DB.Begin()
+	draft, created, err := draftCarePlanVersion(tx, carePlan, c.GetString("user_id"))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create care plan version",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create care plan version",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Creator").Preload("GoalItems", orderedGoals).First(draft, "id = ?", draft.ID)
+
+	status, message := http.StatusOK, "A draft version is already open for this care plan"
+	if created {
+		status, message = http.StatusCreated, fmt.Sprintf("Draft version %d created", draft.Version)
+	}
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    draft,
+		"message": message,
+	})
+}
+
+// GetCarePlanVersions lists every version of a care plan, oldest first
+func (h *Handler) GetCarePlanVersions(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+	lineageID := carePlan.LineageID
+	if lineageID == "" {
+		lineageID = carePlan.ID
+	}
+
+	var versions []models.CarePlan
+	if err := h.DB.Where("lineage_id = ? OR id = ?", lineageID, lineageID).
+		Preload("Creator").Preload("Approver").Order("version ASC").
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch care plan versions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// FieldChange is one field that differs between two versions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// GoalChange is a goal carried between versions with the fields that changed
+type GoalChange struct {
+	GoalKey string        `json:"goal_key"`
+	Title   string        `json:"title"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// CarePlanDiff describes what changed between two versions of a care plan
+type CarePlanDiff struct {
+	FromID       string                `json:"from_id"`
+	FromVersion  int                   `json:"from_version"`
+	ToID         string                `json:"to_id"`
+	ToVersion    int                   `json:"to_version"`
+	Fields       []FieldChange         `json:"fields"`
+	GoalsAdded   []models.CarePlanGoal `json:"goals_added"`
+	GoalsRemoved []models.CarePlanGoal `json:"goals_removed"`
+	GoalsChanged []GoalChange          `json:"goals_changed"`
+}
+
+// formatOptionalDate renders an optional date for a diff
+func formatOptionalDate(date *time.Time) interface{} {
+	if date == nil {
+		return nil
+	}
+	return date.Format("2006-01-02")
+}
+
+// diffCarePlans compares two versions, matching goals by GoalKey
+func diffCarePlans(from, to *models.CarePlan) CarePlanDiff {
+	diff := CarePlanDiff{
+		FromID:       from.ID,
+		FromVersion:  from.Version,
+		ToID:         to.ID,
+		ToVersion:    to.Version,
+		Fields:       []FieldChange{},
+		GoalsAdded:   []models.CarePlanGoal{},
+		GoalsRemoved: []models.CarePlanGoal{},
+		GoalsChanged: []GoalChange{},
+	}
+
+	compare := func(changes []FieldChange, field string, before, after interface{}) []FieldChange {
+		if before != after {
+			changes = append(changes, FieldChange{Field: field, From: before, To: after})
+		}
+		return changes
+	}
+	diff.Fields = compare(diff.Fields, "title", from.Title, to.Title)
+	diff.Fields = compare(diff.Fields, "description", from.Description, to.Description)
+	diff.Fields = compare(diff.Fields, "start_date", from.StartDate.Format("2006-01-02"), to.StartDate.Format("2006-01-02"))
+	diff.Fields = compare(diff.Fields, "end_date", formatOptionalDate(from.EndDate), formatOptionalDate(to.EndDate))
+
+	previous := make(map[string]models.CarePlanGoal)
+	for _, goal := range from.GoalItems {
+		previous[goal.GoalKey] = goal
+	}
+	for _, goal := range to.GoalItems {
+		before, found := previous[goal.GoalKey]
+		if !found {
+			diff.GoalsAdded = append(diff.GoalsAdded, goal)
+			continue
+		}
+		delete(previous, goal.GoalKey)
+
+		var changes []FieldChange
+		changes = compare(changes, "title", before.Title, goal.Title)
+		changes = compare(changes, "outcome_domain", before.OutcomeDomain, goal.OutcomeDomain)
+		changes = compare(changes, "description", before.Description, goal.Description)
+		changes = compare(changes, "measurable_target", before.MeasurableTarget, goal.MeasurableTarget)
+		changes = compare(changes, "review_date", formatOptionalDate(before.ReviewDate), formatOptionalDate(goal.ReviewDate))
+		changes = compare(changes, "status", before.Status, goal.Status)
+		if len(changes) > 0 {
+			diff.GoalsChanged = append(diff.GoalsChanged, GoalChange{GoalKey: goal.GoalKey, Title: goal.Title, Changes: changes})
+		}
+	}
+	for _, goal := range from.GoalItems {
+		if _, removed := previous[goal.GoalKey]; removed {
+			diff.GoalsRemoved = append(diff.GoalsRemoved, goal)
+		}
+	}
+	return diff
+}
+
+// GetCarePlanDiff compares a care plan version with the version it was
+// revised from, or with the version named by ?compare_to
+func (h *Handler) GetCarePlanDiff(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+
+	compareTo := c.Query("compare_to")
+	if compareTo == "" {
+		if carePlan.PreviousVersionID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "NO_PREVIOUS_VERSION",
+					"message": "This is the first version of the care plan",
+				},
+			})
+			return
+		}
+		compareTo = *carePlan.PreviousVersionID
+	}
+
+	lineageID := carePlan.LineageID
+	if lineageID == "" {
+		lineageID = carePlan.ID
+	}
+	var other models.CarePlan
+	if err := h.DB.Where("id = ? AND (lineage_id = ? OR id = ?)", compareTo, lineageID, lineageID).
+		Preload("GoalItems", orderedGoals).First(&other).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_VERSION",
+				"message": "The version to compare with is not part of this care plan",
+			},
+		})
+		return
+	}
+
+	from, to := &other, carePlan
+	if other.Version > carePlan.Version {
+		from, to = carePlan, &other
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    diffCarePlans(from, to),
+	})
+}
+
+// CreateCarePlanGoal adds a goal to a care plan that hasn't been approved
+func (h *Handler) CreateCarePlanGoal(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CarePlanGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+	if carePlan.IsLocked() {
+		carePlanLockedResponse(c)
+		return
+	}
+
+	goal := models.CarePlanGoal{CarePlanID: carePlan.ID, SortOrder: len(carePlan.GoalItems)}
+	if err := req.apply(&goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid review date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Create(&goal).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create goal",
+			},
+		})
+		return
+	}
+	if err := syncCarePlanGoalSummary(tx, carePlan.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create goal",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create goal",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    goal,
+		"message": "Goal created successfully",
+	})
+}
+
+// UpdateCarePlanGoal replaces a goal's details on a care plan that hasn't been approved
+func (h *Handler) UpdateCarePlanGoal(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CarePlanGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+	if carePlan.IsLocked() {
+		carePlanLockedResponse(c)
+		return
+	}
+
+	var goal models.CarePlanGoal
+	if err := h.DB.Where("id = ? AND care_plan_id = ?", c.Param("goalId"), carePlan.ID).First(&goal).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "GOAL_NOT_FOUND",
+				"message": "Goal not found",
+			},
+		})
+		return
+	}
+	if err := req.apply(&goal); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid review date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Save(&goal).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update goal",
+			},
+		})
+		return
+	}
+	if err := syncCarePlanGoalSummary(tx, carePlan.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update goal",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update goal",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    goal,
+		"message": "Goal updated successfully",
+	})
+}
+
+// DeleteCarePlanGoal removes a goal from a care plan that hasn't been approved
+func (h *Handler) DeleteCarePlanGoal(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+	if carePlan.IsLocked() {
+		carePlanLockedResponse(c)
+		return
+	}
+
+	tx := h.DB.Begin()
+	result := tx.Where("id = ? AND care_plan_id = ?", c.Param("goalId"), carePlan.ID).Delete(&models.CarePlanGoal{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "GOAL_NOT_FOUND",
+				"message": "Goal not found",
+			},
+		})
+		return
+	}
+	if result.Error != nil || syncCarePlanGoalSummary(tx, carePlan.ID) != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete goal",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete goal",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Goal deleted successfully",
+	})
+}
+
+// GetCarePlanGoalProgress returns the progress recorded against a goal on
+// shifts, across every version of the plan the goal has been carried into
+func (h *Handler) GetCarePlanGoalProgress(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	carePlan, ok := h.findCarePlan(c, orgID)
+	if !ok {
+		return
+	}
+
+	var goal *models.CarePlanGoal
+	for i := range carePlan.GoalItems {
+		if carePlan.GoalItems[i].ID == c.Param("goalId") {
+			goal = &carePlan.GoalItems[i]
+		}
+	}
+	if goal == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "GOAL_NOT_FOUND",
+				"message": "Goal not found",
+			},
+		})
+		return
+	}
+
+	type progressEntry struct {
+		ProgressNoteID string    `json:"progress_note_id"`
+		ShiftID        string    `json:"shift_id"`
+		ShiftDate      time.Time `json:"shift_date"`
+		StaffID        string    `json:"staff_id"`
+		Rating         string    `json:"rating"`
+		Comment        string    `json:"comment"`
+	}
+	var entries []progressEntry
+	if err := h.DB.Table("progress_note_goals").
+		Select("progress_note_goals.progress_note_id, shift_progress_notes.shift_id, shifts.start_time AS shift_date, shift_progress_notes.staff_id, progress_note_goals.rating, progress_note_goals.comment").
+		Joins("JOIN shift_progress_notes ON progress_note_goals.progress_note_id = shift_progress_notes.id").
+		Joins("JOIN shifts ON shift_progress_notes.shift_id = shifts.id").
+		Where("progress_note_goals.goal_key = ? AND shift_progress_notes.participant_id = ? AND shift_progress_notes.deleted_at IS NULL", goal.GoalKey, carePlan.ParticipantID).
+		Order("shifts.start_time ASC").Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch goal progress",
+			},
+		})
+		return
+	}
+
+	ratings := map[string]int{"no_progress": 0, "some_progress": 0, "good_progress": 0, "achieved": 0}
+	for _, entry := range entries {
+		ratings[entry.Rating]++
+	}
+	latest := ""
+	if len(entries) > 0 {
+		latest = entries[len(entries)-1].Rating
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"goal":          goal,
+			"entries":       entries,
+			"ratings":       ratings,
+			"latest_rating": latest,
+		},
+	})
+}
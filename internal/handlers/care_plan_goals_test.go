@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCarePlanGoalsAndVersions(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.CarePlan{}, &models.CarePlanGoal{}, &models.ShiftProgressNote{},
+		&models.ProgressNoteGoal{}, &models.OrganizationSettings{})
+
+	participant := models.Participant{
+		ID:             "goals-participant",
+		FirstName:      "Goals",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "GOAL123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+
+	var planID, goalID string
+
+	t.Run("Creating a plan creates goal records", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/care-plans", map[string]interface{}{
+			"participant_id": participant.ID,
+			"title":          "Independence",
+			"start_date":     time.Now().AddDate(0, -1, 0),
+			"goal_items": []map[string]interface{}{
+				{"title": "Catch the bus", "outcome_domain": "social_community", "measurable_target": "Three trips a week unassisted", "review_date": "2026-12-01"},
+				{"title": "Cook a meal", "outcome_domain": "daily_living"},
+			},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		planID = data["id"].(string)
+		goals := data["goal_items"].([]interface{})
+		assert.Len(t, goals, 2)
+		goalID = goals[0].(map[string]interface{})["id"].(string)
+		assert.Equal(t, `["Catch the bus","Cook a meal"]`, data["goals"])
+	})
+
+	t.Run("Progress is recorded against the goal record", func(t *testing.T) {
+		shift := models.Shift{
+			ParticipantID: participant.ID,
+			StaffID:       "test-user",
+			StartTime:     time.Now().Add(-time.Hour),
+			EndTime:       time.Now().Add(time.Hour),
+			ServiceType:   "Community Access",
+			Location:      "Community",
+			Status:        "in_progress",
+			HourlyRate:    50,
+		}
+		handler.DB.Create(&shift)
+
+		w, response := doRequest(handler, router, "PUT", "/api/v1/shifts/"+shift.ID+"/progress-note", map[string]interface{}{
+			"summary": "Caught the bus to the library",
+			"goals":   []map[string]interface{}{{"goal_id": goalID, "rating": "good_progress"}},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		goal := response["data"].(map[string]interface{})["goals"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, goalID, goal["goal_id"])
+		assert.Equal(t, "Catch the bus", goal["goal"])
+	})
+
+	t.Run("Approved plans are locked and edits open a new version", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/care-plans/"+planID+"/approve", map[string]interface{}{"approval_action": "approve"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/care-plans/"+planID+"/goals", map[string]interface{}{"title": "Make a friend"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "CARE_PLAN_LOCKED", response["error"].(map[string]interface{})["code"])
+
+		w, response = doRequest(handler, router, "PUT", "/api/v1/care-plans/"+planID, map[string]interface{}{"title": "Independence 2026"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		draft := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), draft["version"])
+		assert.Equal(t, "draft", draft["status"])
+		assert.Equal(t, planID, draft["previous_version_id"])
+		draftID := draft["id"].(string)
+
+		var original models.CarePlan
+		handler.DB.First(&original, "id = ?", planID)
+		assert.Equal(t, "Independence", original.Title)
+
+		// Change the draft's goals, then compare the versions
+		draftGoals := draft["goal_items"].([]interface{})
+		cookID := draftGoals[1].(map[string]interface{})["id"].(string)
+		w, _ = doRequest(handler, router, "DELETE", "/api/v1/care-plans/"+draftID+"/goals/"+cookID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/care-plans/"+draftID+"/goals", map[string]interface{}{"title": "Make a friend", "outcome_domain": "relationships"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		busID := draftGoals[0].(map[string]interface{})["id"].(string)
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/care-plans/"+draftID+"/goals/"+busID, map[string]interface{}{
+			"title": "Catch the bus", "outcome_domain": "social_community", "measurable_target": "Five trips a week unassisted", "review_date": "2026-12-01",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/care-plans/"+draftID+"/diff", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		diff := response["data"].(map[string]interface{})
+		assert.Len(t, diff["fields"], 1)
+		assert.Len(t, diff["goals_added"], 1)
+		assert.Len(t, diff["goals_removed"], 1)
+		changed := diff["goals_changed"].([]interface{})
+		assert.Len(t, changed, 1)
+		assert.Equal(t, "measurable_target", changed[0].(map[string]interface{})["changes"].([]interface{})[0].(map[string]interface{})["field"])
+
+		w, _ = doRequest(handler, router, "PATCH", "/api/v1/care-plans/"+draftID+"/approve", map[string]interface{}{"approval_action": "approve"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		handler.DB.First(&original, "id = ?", planID)
+		assert.Equal(t, "superseded", original.Status)
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/care-plans/"+planID+"/versions", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"], 2)
+
+		// Progress recorded on version 1 follows the goal into version 2
+		w, response = doRequest(handler, router, "GET", "/api/v1/care-plans/"+draftID+"/goals/"+busID+"/progress", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		progress := response["data"].(map[string]interface{})
+		assert.Len(t, progress["entries"], 1)
+		assert.Equal(t, "good_progress", progress["latest_rating"])
+	})
+
+	t.Run("Approved plans can't be deleted", func(t *testing.T) {
+		w, response := doRequest(handler, router, "DELETE", "/api/v1/care-plans/"+planID, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "CARE_PLAN_LOCKED", response["error"].(map[string]interface{})["code"])
+	})
+}
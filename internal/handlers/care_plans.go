@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -113,7 +114,7 @@ func (h *Handler) GetCarePlan(c *gin.Context) {
 	var carePlan models.CarePlan
 	if err := h.DB.Joins("JOIN participants ON care_plans.participant_id = participants.id").
 		Where("care_plans.id = ? AND participants.organization_id = ?", carePlanID, orgID).
-		Preload("Participant").Preload("Creator").Preload("Approver").
+		Preload("Participant").Preload("Creator").Preload("Approver").Preload("GoalItems", orderedGoals).
 		First(&carePlan).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -145,7 +146,8 @@ type CreateCarePlanRequest struct {
 	ParticipantID string    `json:"participant_id" binding:"required"`
 	Title         string    `json:"title" binding:"required"`
 	Description   string    `json:"description"`
-	Goals         string    `json:"goals"`
+	Goals         string    `json:"goals"` // Legacy JSON or one goal per line, used when goal_items is empty
+	GoalItems     []CarePlanGoalRequest `json:"goal_items" binding:"dive"`
 	StartDate     time.Time `json:"start_date" binding:"required"`
 	EndDate       *time.Time `json:"end_date,omitempty"`
 }
@@ -203,6 +205,28 @@ func (h *Handler) CreateCarePlan(c *gin.Context) {
 		return
 	}
 
+	// Build goal records, falling back to the legacy goals text
+	goals := make([]models.CarePlanGoal, 0, len(req.GoalItems))
+	for i := range req.GoalItems {
+		goal := models.CarePlanGoal{SortOrder: i}
+		if err := req.GoalItems[i].apply(&goal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid review date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+		goals = append(goals, goal)
+	}
+	if len(goals) == 0 {
+		for i, title := range carePlanGoals(req.Goals) {
+			goals = append(goals, models.CarePlanGoal{Title: title, Status: "active", SortOrder: i})
+		}
+	}
+
 	// Create care plan
 	carePlan := models.CarePlan{
 		ParticipantID: req.ParticipantID,
@@ -215,7 +239,46 @@ func (h *Handler) CreateCarePlan(c *gin.Context) {
 		CreatedBy:     userID.(string),
 	}
 
-	if err := h.DB.Create(&carePlan).Error; err != nil {
+	tx := h.DB.Begin()
+	if err := tx.Create(&carePlan).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create care plan",
+			},
+		})
+		return
+	}
+	for i := range goals {
+		goals[i].CarePlanID = carePlan.ID
+		if err := tx.Create(&goals[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to create care plan goals",
+				},
+			})
+			return
+		}
+	}
+	if len(goals) > 0 {
+		if err := syncCarePlanGoalSummary(tx, carePlan.ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to create care plan goals",
+				},
+			})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -227,7 +290,7 @@ func (h *Handler) CreateCarePlan(c *gin.Context) {
 	}
 
 	// Fetch care plan with related data
-	h.DB.Preload("Participant").Preload("Creator").First(&carePlan, "id = ?", carePlan.ID)
+	h.DB.Preload("Participant").Preload("Creator").Preload("GoalItems", orderedGoals).First(&carePlan, "id = ?", carePlan.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -239,7 +302,7 @@ func (h *Handler) CreateCarePlan(c *gin.Context) {
 type UpdateCarePlanRequest struct {
 	Title       *string    `json:"title,omitempty"`
 	Description *string    `json:"description,omitempty"`
-	Goals       *string    `json:"goals,omitempty"`
+	Goals       *string    `json:"goals,omitempty"` // Legacy goal list; reconciled with the plan's goal records
 	StartDate   *time.Time `json:"start_date,omitempty"`
 	EndDate     *time.Time `json:"end_date,omitempty"`
 	Status      *string    `json:"status,omitempty" binding:"omitempty,oneof=active completed cancelled"`
@@ -327,20 +390,76 @@ func (h *Handler) UpdateCarePlan(c *gin.Context) {
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
-	if req.Goals != nil {
-		updates["goals"] = *req.Goals
-	}
 	if req.StartDate != nil {
 		updates["start_date"] = *req.StartDate
 	}
 	if req.EndDate != nil {
 		updates["end_date"] = *req.EndDate
 	}
-	if req.Status != nil {
+
+	// An approved version can't change; content edits go to a draft version
+	// while status changes still apply to the approved plan
+	tx := h.DB.Begin()
+	target := &carePlan
+	draftCreated := false
+	if carePlan.IsLocked() && (len(updates) > 0 || req.Goals != nil) {
+		draft, created, err := draftCarePlanVersion(tx, &carePlan, c.GetString("user_id"))
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to create care plan version",
+				},
+			})
+			return
+		}
+		target, draftCreated = draft, created
+		if req.Status != nil {
+			if err := tx.Model(&carePlan).Update("status", *req.Status).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "DATABASE_ERROR",
+						"message": "Failed to update care plan",
+					},
+				})
+				return
+			}
+		}
+	} else if req.Status != nil {
 		updates["status"] = *req.Status
 	}
 
-	if err := h.DB.Model(&carePlan).Updates(updates).Error; err != nil {
+	if len(updates) > 0 {
+		if err := tx.Model(target).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update care plan",
+				},
+			})
+			return
+		}
+	}
+	if req.Goals != nil {
+		if err := reconcileLegacyGoals(tx, target.ID, carePlanGoals(*req.Goals)); err != nil || syncCarePlanGoalSummary(tx, target.ID) != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update care plan goals",
+				},
+			})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -352,12 +471,21 @@ func (h *Handler) UpdateCarePlan(c *gin.Context) {
 	}
 
 	// Fetch updated care plan
-	h.DB.Preload("Participant").Preload("Creator").Preload("Approver").First(&carePlan, "id = ?", carePlanID)
-
-	c.JSON(http.StatusOK, gin.H{
+	targetID := target.ID
+	var updated models.CarePlan
+	h.DB.Preload("Participant").Preload("Creator").Preload("Approver").Preload("GoalItems", orderedGoals).First(&updated, "id = ?", targetID)
+
+	status, message := http.StatusOK, "Care plan updated successfully"
+	if targetID != carePlan.ID {
+		message = fmt.Sprintf("Care plan is approved; changes saved to draft version %d", updated.Version)
+		if draftCreated {
+			status = http.StatusCreated
+		}
+	}
+	c.JSON(status, gin.H{
 		"success": true,
-		"data":    carePlan,
-		"message": "Care plan updated successfully",
+		"data":    updated,
+		"message": message,
 	})
 }
 
@@ -380,7 +508,7 @@ func (h *Handler) ApproveCarePlan(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("role")
+	userRole := c.GetString("user_role")
 
 	// Only admin and manager can approve care plans
 	if userRole != "admin" && userRole != "manager" {
@@ -432,18 +560,63 @@ func (h *Handler) ApproveCarePlan(c *gin.Context) {
 		return
 	}
 
-	// Update approval fields
+	if carePlan.IsLocked() {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ALREADY_APPROVED",
+				"message": "This care plan version has already been approved or rejected",
+			},
+		})
+		return
+	}
+
+	// Update approval fields. Approving a version makes it the active plan
+	// and supersedes the version it replaces.
 	now := time.Now()
 	updates := map[string]interface{}{
 		"approved_by": userID.(string),
 		"approved_at": now,
+		"status":      "active",
 	}
 
 	if req.ApprovalAction == "reject" {
 		updates["status"] = "cancelled"
 	}
 
-	if err := h.DB.Model(&carePlan).Updates(updates).Error; err != nil {
+	lineageID := carePlan.LineageID
+	if lineageID == "" {
+		lineageID = carePlan.ID
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Model(&carePlan).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update care plan approval",
+			},
+		})
+		return
+	}
+	if req.ApprovalAction == "approve" {
+		if err := tx.Model(&models.CarePlan{}).
+			Where("(lineage_id = ? OR id = ?) AND id != ? AND approved_at IS NOT NULL AND status = ?", lineageID, lineageID, carePlan.ID, "active").
+			Update("status", "superseded").Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update care plan approval",
+				},
+			})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -455,7 +628,7 @@ func (h *Handler) ApproveCarePlan(c *gin.Context) {
 	}
 
 	// Fetch updated care plan
-	h.DB.Preload("Participant").Preload("Creator").Preload("Approver").First(&carePlan, "id = ?", carePlanID)
+	h.DB.Preload("Participant").Preload("Creator").Preload("Approver").Preload("GoalItems", orderedGoals).First(&carePlan, "id = ?", carePlanID)
 
 	message := "Care plan approved successfully"
 	if req.ApprovalAction == "reject" {
@@ -520,6 +693,18 @@ func (h *Handler) DeleteCarePlan(c *gin.Context) {
 		return
 	}
 
+	// Approved versions are kept as the record of what was agreed
+	if carePlan.IsLocked() && carePlan.Status != "cancelled" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CARE_PLAN_LOCKED",
+				"message": "Approved care plans cannot be deleted",
+			},
+		})
+		return
+	}
+
 	// Soft delete care plan
 	if err := h.DB.Delete(&carePlan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
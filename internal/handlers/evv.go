@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+)
+
+const (
+	// defaultGeofenceRadius applies when the organization hasn't configured one, in metres
+	defaultGeofenceRadius = 200
+	// visitVarianceTolerance is how far from the rostered time a clock event can be
+	// before the visit verification report flags it, in minutes
+	visitVarianceTolerance = 15
+	earthRadiusMeters      = 6371000
+)
+
+// distanceMeters returns the great-circle distance between two coordinates
+func distanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// isVisitException reports whether a clock event needs an auditor's attention
+func isVisitException(event *models.ShiftClockEvent) bool {
+	if event.WithinGeofence == nil || !*event.WithinGeofence {
+		return true
+	}
+	return event.VarianceMinutes > visitVarianceTolerance || event.VarianceMinutes < -visitVarianceTolerance
+}
+
+type ClockEventRequest struct {
+	Latitude        *float64 `json:"latitude" binding:"required,min=-90,max=90"`
+	Longitude       *float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	AccuracyMeters  *float64 `json:"accuracy_meters,omitempty" binding:"omitempty,min=0"`
+	DeviceTime      string   `json:"device_time"`       // When the device captured the event, if it was queued offline
+	PhotoDocumentID string   `json:"photo_document_id"` // Uploaded photo; required when the organization requires photo evidence
+	ExceptionReason string   `json:"exception_reason"`  // Required to clock in or out from outside the geofence
+	DeviceInfo      string   `json:"device_info"`
+	CompletionNotes string   `json:"completion_notes"` // Clock-out only
+}
+
+// ClockInShift starts a shift with time-stamped location evidence
+func (h *Handler) ClockInShift(c *gin.Context) {
+	h.recordClockEvent(c, "clock_in")
+}
+
+// ClockOutShift completes a shift with time-stamped location evidence
+func (h *Handler) ClockOutShift(c *gin.Context) {
+	h.recordClockEvent(c, "clock_out")
+}
+
+// recordClockEvent checks the assigned staff member's location against the
+// participant's address, stores the evidence and moves the shift on. Clocking
+// in starts the shift and clocking out completes it, drawing down the plan budget.
+func (h *Handler) recordClockEvent(c *gin.Context, eventType string) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+
+	var req ClockEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var deviceTime *time.Time
+	if req.DeviceTime != "" {
+		parsed, err := parseTimeFromString(req.DeviceTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DEVICE_TIME",
+					"message": "Invalid device time format. Use ISO format or local datetime.",
+				},
+			})
+			return
+		}
+		deviceTime = &parsed
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+
+	if shift.InvoiceID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_INVOICED",
+				"message": "Shift has been invoiced and can no longer be modified",
+			},
+		})
+		return
+	}
+
+	if shift.StaffID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INSUFFICIENT_PERMISSIONS",
+				"message": "Only the staff member assigned to the shift can clock in or out",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	scheduled := shift.StartTime
+	requiredStatus := "scheduled"
+	if eventType == "clock_out" {
+		scheduled = shift.EndTime
+		requiredStatus = "in_progress"
+	}
+	if shift.Status != requiredStatus {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TRANSITION",
+				"message": fmt.Sprintf("Can't %s a shift that is %s", strings.ReplaceAll(eventType, "_", " "), shift.Status),
+			},
+		})
+		return
+	}
+
+	// Same 30-minute rule as starting a shift by status
+	if eventType == "clock_in" && now.Before(shift.StartTime.Add(-30*time.Minute)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "TOO_EARLY_TO_START",
+				"message": fmt.Sprintf("Cannot start shift more than 30 minutes early. Shift starts in %d minutes.", int(shift.StartTime.Sub(now).Minutes())),
+			},
+		})
+		return
+	}
+
+	var settings models.OrganizationSettings
+	h.DB.Where("organization_id = ?", orgID).First(&settings)
+	radius := settings.GeofenceRadiusMeters
+	if radius <= 0 {
+		radius = defaultGeofenceRadius
+	}
+
+	if eventType == "clock_out" && settings.RequireShiftNotes && !h.shiftHasProgressNote(shift.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PROGRESS_NOTE_REQUIRED",
+				"message": "A progress note must be written before clocking out",
+			},
+		})
+		return
+	}
+
+	var photoID *string
+	if req.PhotoDocumentID != "" {
+		var document models.Document
+		if err := h.DB.Where("id = ? AND uploaded_by = ? AND is_active = ?", req.PhotoDocumentID, userID, true).First(&document).Error; err != nil || !strings.HasPrefix(document.FileType, "image/") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_PHOTO",
+					"message": "Photo evidence must be an image you uploaded",
+				},
+			})
+			return
+		}
+		photoID = &document.ID
+	} else if settings.RequirePhotoEvidence {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PHOTO_REQUIRED",
+				"message": "A photo must be uploaded to clock in or out",
+			},
+		})
+		return
+	}
+
+	event := models.ShiftClockEvent{
+		ShiftID:         shift.ID,
+		StaffID:         userID,
+		EventType:       eventType,
+		RecordedAt:      now,
+		DeviceTime:      deviceTime,
+		Latitude:        req.Latitude,
+		Longitude:       req.Longitude,
+		AccuracyMeters:  req.AccuracyMeters,
+		GeofenceRadius:  radius,
+		VarianceMinutes: int(math.Round(now.Sub(scheduled).Minutes())),
+		PhotoDocumentID: photoID,
+		ExceptionReason: strings.TrimSpace(req.ExceptionReason),
+		DeviceInfo:      req.DeviceInfo,
+	}
+
+	// Participants without coordinates can't be verified, so the event is recorded as an exception
+	address := shift.Participant.Address
+	if address.Latitude != nil && address.Longitude != nil {
+		distance := math.Round(distanceMeters(*req.Latitude, *req.Longitude, *address.Latitude, *address.Longitude))
+		within := distance <= float64(radius)
+		event.DistanceMeters = &distance
+		event.WithinGeofence = &within
+
+		if !within && event.ExceptionReason == "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "OUTSIDE_GEOFENCE",
+					"message": fmt.Sprintf("You are %.0f m from the participant's address; the limit is %d m. Give an exception reason to continue.", distance, radius),
+					"details": gin.H{
+						"distance_meters": distance,
+						"geofence_radius": radius,
+					},
+				},
+			})
+			return
+		}
+	}
+
+	updates := map[string]interface{}{}
+	if eventType == "clock_in" {
+		updates["status"] = "in_progress"
+		updates["actual_start_time"] = now
+	} else {
+		updates["status"] = "completed"
+		updates["actual_end_time"] = now
+		if notes := strings.TrimSpace(req.CompletionNotes); notes != "" {
+			updates["completion_notes"] = notes
+		}
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record clock event",
+			},
+		})
+		return
+	}
+	if err := tx.Model(shift).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift status",
+			},
+		})
+		return
+	}
+
+	// Completed shifts draw down the participant's plan budget for the support category
+	if eventType == "clock_out" {
+		budget, err := drawDownPlanBudget(tx, shift, userID)
+		if err == nil && budget != nil {
+			err = raiseBudgetAlerts(tx, budget, now)
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to draw down plan budget",
+				},
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record clock event",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(shift, "id = ?", shift.ID)
+
+	message := "Clocked in successfully"
+	if eventType == "clock_out" {
+		message = "Clocked out successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shift": shift,
+			"event": event,
+		},
+		"message": message,
+	})
+}
+
+// GetShiftClockEvents returns the visit verification evidence recorded for a shift
+func (h *Handler) GetShiftClockEvents(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+
+	var events []models.ShiftClockEvent
+	if err := h.DB.Where("shift_id = ?", shift.ID).Order("recorded_at ASC").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch clock events",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    events,
+	})
+}
+
+// GetVisitVerificationReport lists clock events for auditors, flagging those
+// outside the geofence, without a verifiable location or well off the roster,
+// and completed shifts that have no clock events at all
+func (h *Handler) GetVisitVerificationReport(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	loc := h.organizationLocation(orgID)
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	from := to.AddDate(0, 0, -30)
+	for _, bound := range []struct {
+		value  string
+		target *time.Time
+		offset int
+	}{{c.Query("start_date"), &from, 0}, {c.Query("end_date"), &to, 1}} {
+		if bound.value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", bound.value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+		*bound.target = date.AddDate(0, 0, bound.offset)
+	}
+
+	query := h.DB.Preload("Staff").
+		Joins("JOIN shifts ON shift_clock_events.shift_id = shifts.id").
+		Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shift_clock_events.recorded_at >= ? AND shift_clock_events.recorded_at < ?", orgID, from, to)
+	if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("shift_clock_events.staff_id = ?", staffID)
+	}
+
+	var events []models.ShiftClockEvent
+	if err := query.Order("shift_clock_events.recorded_at ASC").Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch clock events",
+			},
+		})
+		return
+	}
+
+	exceptionsOnly := c.Query("exceptions_only") == "true"
+	rows := make([]gin.H, 0, len(events))
+	exceptions := 0
+	for i := range events {
+		exception := isVisitException(&events[i])
+		if exception {
+			exceptions++
+		}
+		if exceptionsOnly && !exception {
+			continue
+		}
+		rows = append(rows, gin.H{
+			"event":     events[i],
+			"exception": exception,
+		})
+	}
+
+	var unverified []models.Shift
+	unverifiedQuery := h.DB.Preload("Participant").Preload("Staff").
+		Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.status = ? AND shifts.start_time >= ? AND shifts.start_time < ?", orgID, "completed", from, to).
+		Where("NOT EXISTS (SELECT 1 FROM shift_clock_events WHERE shift_clock_events.shift_id = shifts.id)")
+	if staffID := c.Query("staff_id"); staffID != "" {
+		unverifiedQuery = unverifiedQuery.Where("shifts.staff_id = ?", staffID)
+	}
+	if err := unverifiedQuery.Order("shifts.start_time ASC").Find(&unverified).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch unverified shifts",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"events":            rows,
+			"unverified_shifts": unverified,
+			"summary": gin.H{
+				"total_events":      len(events),
+				"exceptions":        exceptions,
+				"unverified_shifts": len(unverified),
+			},
+			"start_date":       from.Format("2006-01-02"),
+			"end_date":         to.AddDate(0, 0, -1).Format("2006-01-02"),
+			"report_generated": time.Now(),
+		},
+	})
+}
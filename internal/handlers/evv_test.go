@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceMeters(t *testing.T) {
+	// Two points in the Adelaide CBD about half a kilometre apart
+	distance := distanceMeters(-34.9256, 138.5996, -34.9210, 138.5984)
+	assert.InDelta(t, 525, distance, 25)
+	assert.Equal(t, 0.0, distanceMeters(-34.9, 138.6, -34.9, 138.6))
+}
+
+func TestShiftClockInOut(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.ShiftClockEvent{}, &models.OrganizationSettings{}, &models.Document{},
+		&models.PlanBudget{}, &models.PlanBudgetTransaction{})
+
+	latitude, longitude := -34.9285, 138.6007
+	participant := models.Participant{
+		ID:             "evv-participant",
+		FirstName:      "Visit",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1975, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "EVV123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+		Address:        models.Address{Street: "1 King William St", Suburb: "Adelaide", Latitude: &latitude, Longitude: &longitude},
+	}
+	handler.DB.Create(&participant)
+
+	shift := models.Shift{
+		ParticipantID: participant.ID,
+		StaffID:       "test-user",
+		StartTime:     time.Now().Add(-10 * time.Minute),
+		EndTime:       time.Now().Add(110 * time.Minute),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "scheduled",
+		HourlyRate:    50,
+	}
+	handler.DB.Create(&shift)
+
+	t.Run("Clocking in away from the participant needs a reason", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/clock-in", map[string]interface{}{
+			"latitude":  -34.9500,
+			"longitude": 138.6007,
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "OUTSIDE_GEOFENCE", response["error"].(map[string]interface{})["code"])
+
+		handler.DB.First(&shift, "id = ?", shift.ID)
+		assert.Equal(t, "scheduled", shift.Status)
+	})
+
+	t.Run("Clocking in on site starts the shift", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/clock-in", map[string]interface{}{
+			"latitude":        -34.9286,
+			"longitude":       138.6008,
+			"accuracy_meters": 12,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		event := response["data"].(map[string]interface{})["event"].(map[string]interface{})
+		assert.Equal(t, true, event["within_geofence"])
+		assert.Equal(t, float64(10), event["variance_minutes"])
+
+		handler.DB.First(&shift, "id = ?", shift.ID)
+		assert.Equal(t, "in_progress", shift.Status)
+		assert.NotNil(t, shift.ActualStartTime)
+	})
+
+	t.Run("Photo evidence is enforced when the organization requires it", func(t *testing.T) {
+		handler.DB.Create(&models.OrganizationSettings{ID: "evv-settings", OrganizationID: "test-org", RequirePhotoEvidence: true})
+		clockOut := map[string]interface{}{"latitude": -34.9286, "longitude": 138.6008}
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/clock-out", clockOut)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "PHOTO_REQUIRED", response["error"].(map[string]interface{})["code"])
+
+		photo := models.Document{ID: "evv-photo", UploadedBy: "test-user", Filename: "visit.jpg", OriginalFilename: "visit.jpg",
+			Title: "Visit photo", Category: "visit_evidence", FileType: "image/jpeg", FileSize: 1024, FilePath: "uploads/visit.jpg", IsActive: true}
+		handler.DB.Create(&photo)
+		clockOut["photo_document_id"] = photo.ID
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/clock-out", clockOut)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		handler.DB.First(&shift, "id = ?", shift.ID)
+		assert.Equal(t, "completed", shift.Status)
+		assert.NotNil(t, shift.ActualEndTime)
+	})
+
+	t.Run("Report flags an early clock-out", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/reports/visit-verification?exceptions_only=true", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), data["summary"].(map[string]interface{})["total_events"])
+		events := data["events"].([]interface{})
+		assert.Len(t, events, 1)
+		event := events[0].(map[string]interface{})["event"].(map[string]interface{})
+		assert.Equal(t, "clock_out", event["event_type"])
+		assert.Equal(t, "evv-photo", event["photo_document_id"])
+	})
+}
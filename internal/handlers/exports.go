@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/pdf"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/spreadsheet"
+)
+
+// maxLogoBytes caps the size of a branding logo read for a document
+const maxLogoBytes = 2 << 20
+
+var defaultBrandColor = pdf.Color{R: 0x66, G: 0x7e, B: 0xea}
+
+// reportDefinition describes an exportable report. Stream emits one row at a
+// time so exports of long date ranges never hold the full result in memory.
+type reportDefinition struct {
+	Name    string
+	Columns []pdf.Column
+	Stream  func(h *Handler, orgID interface{}, from, to time.Time, loc *time.Location, emit func([]interface{}) error) error
+}
+
+// exportableReports are keyed by the template ids returned from GetReportTemplates
+var exportableReports = map[string]reportDefinition{
+	"revenue_monthly": {
+		Name: "Monthly Revenue Report",
+		Columns: []pdf.Column{
+			{Header: "Month", Width: 2},
+			{Header: "Revenue", Width: 2, Align: pdf.AlignRight},
+			{Header: "Hours", Width: 1.5, Align: pdf.AlignRight},
+			{Header: "Shifts", Width: 1.5, Align: pdf.AlignRight},
+		},
+		Stream: streamMonthlyRevenue,
+	},
+	"participant_summary": {
+		Name: "Participant Summary",
+		Columns: []pdf.Column{
+			{Header: "Participant", Width: 3},
+			{Header: "NDIS Number", Width: 2},
+			{Header: "Shifts", Width: 1, Align: pdf.AlignRight},
+			{Header: "Total Hours", Width: 1.5, Align: pdf.AlignRight},
+			{Header: "Total Cost", Width: 1.8, Align: pdf.AlignRight},
+			{Header: "Last Service", Width: 1.7, Align: pdf.AlignRight},
+		},
+		Stream: streamParticipantSummary,
+	},
+	"staff_utilization": {
+		Name: "Staff Utilization Report",
+		Columns: []pdf.Column{
+			{Header: "Staff Name", Width: 3},
+			{Header: "Role", Width: 2},
+			{Header: "Shifts Completed", Width: 1.6, Align: pdf.AlignRight},
+			{Header: "Hours Worked", Width: 1.5, Align: pdf.AlignRight},
+			{Header: "Rostered Hours", Width: 1.5, Align: pdf.AlignRight},
+			{Header: "Utilization %", Width: 1.4, Align: pdf.AlignRight},
+		},
+		Stream: streamStaffUtilization,
+	},
+}
+
+func streamMonthlyRevenue(h *Handler, orgID interface{}, from, to time.Time, loc *time.Location, emit func([]interface{}) error) error {
+	rows, err := h.DB.Model(&models.Shift{}).
+		Select("shifts.start_time, shifts.end_time, shifts.hourly_rate").
+		Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.status = ? AND shifts.start_time >= ? AND shifts.start_time < ?", orgID, "completed", from, to).
+		Order("shifts.start_time ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var month string
+	var revenue, hours float64
+	var shifts int
+	flush := func() error {
+		if month == "" {
+			return nil
+		}
+		return emit([]interface{}{month, roundCurrency(revenue), roundCurrency(hours), shifts})
+	}
+
+	for rows.Next() {
+		var shift struct {
+			StartTime  time.Time
+			EndTime    time.Time
+			HourlyRate float64
+		}
+		if err := h.DB.ScanRows(rows, &shift); err != nil {
+			return err
+		}
+
+		key := shift.StartTime.In(loc).Format("2006-01")
+		if key != month {
+			if err := flush(); err != nil {
+				return err
+			}
+			month, revenue, hours, shifts = key, 0, 0, 0
+		}
+		duration := shift.EndTime.Sub(shift.StartTime).Hours()
+		hours += duration
+		revenue += duration * shift.HourlyRate
+		shifts++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func streamParticipantSummary(h *Handler, orgID interface{}, from, to time.Time, loc *time.Location, emit func([]interface{}) error) error {
+	rows, err := h.DB.Table("participants").
+		Select("participants.id, participants.first_name, participants.last_name, participants.ndis_number, shifts.start_time, shifts.end_time, shifts.hourly_rate").
+		Joins("LEFT JOIN shifts ON shifts.participant_id = participants.id AND shifts.status = ? AND shifts.deleted_at IS NULL AND shifts.start_time >= ? AND shifts.start_time < ?", "completed", from, to).
+		Where("participants.organization_id = ? AND participants.deleted_at IS NULL", orgID).
+		Order("participants.last_name ASC, participants.first_name ASC, participants.id ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current, name, ndisNumber string
+	var hours, cost float64
+	var shifts int
+	var lastService *time.Time
+	flush := func() error {
+		if current == "" {
+			return nil
+		}
+		var last interface{}
+		if lastService != nil {
+			last = lastService.In(loc)
+		}
+		return emit([]interface{}{name, ndisNumber, shifts, roundCurrency(hours), roundCurrency(cost), last})
+	}
+
+	for rows.Next() {
+		var row struct {
+			ID         string
+			FirstName  string
+			LastName   string
+			NDISNumber string
+			StartTime  *time.Time
+			EndTime    *time.Time
+			HourlyRate *float64
+		}
+		if err := h.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+
+		if row.ID != current {
+			if err := flush(); err != nil {
+				return err
+			}
+			current, name, ndisNumber = row.ID, row.FirstName+" "+row.LastName, row.NDISNumber
+			hours, cost, shifts, lastService = 0, 0, 0, nil
+		}
+		if row.StartTime == nil || row.EndTime == nil || row.HourlyRate == nil {
+			continue
+		}
+		duration := row.EndTime.Sub(*row.StartTime).Hours()
+		hours += duration
+		cost += duration * *row.HourlyRate
+		shifts++
+		if lastService == nil || row.StartTime.After(*lastService) {
+			lastService = row.StartTime
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func streamStaffUtilization(h *Handler, orgID interface{}, from, to time.Time, loc *time.Location, emit func([]interface{}) error) error {
+	rows, err := h.DB.Table("users").
+		Select("users.id, users.first_name, users.last_name, users.role, shifts.status, shifts.start_time, shifts.end_time").
+		Joins("LEFT JOIN shifts ON shifts.staff_id = users.id AND shifts.status <> ? AND shifts.deleted_at IS NULL AND shifts.start_time >= ? AND shifts.start_time < ?", "cancelled", from, to).
+		Where("users.organization_id = ? AND users.deleted_at IS NULL AND users.role IN ?", orgID, []string{"care_worker", "support_coordinator"}).
+		Order("users.last_name ASC, users.first_name ASC, users.id ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current, name, role string
+	var worked, rostered float64
+	var completed int
+	flush := func() error {
+		if current == "" {
+			return nil
+		}
+		utilization := 0.0
+		if rostered > 0 {
+			utilization = worked / rostered * 100
+		}
+		return emit([]interface{}{name, role, completed, roundCurrency(worked), roundCurrency(rostered), roundCurrency(utilization)})
+	}
+
+	for rows.Next() {
+		var row struct {
+			ID        string
+			FirstName string
+			LastName  string
+			Role      string
+			Status    *string
+			StartTime *time.Time
+			EndTime   *time.Time
+		}
+		if err := h.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+
+		if row.ID != current {
+			if err := flush(); err != nil {
+				return err
+			}
+			current, name, role = row.ID, row.FirstName+" "+row.LastName, row.Role
+			worked, rostered, completed = 0, 0, 0
+		}
+		if row.Status == nil || row.StartTime == nil || row.EndTime == nil {
+			continue
+		}
+		duration := row.EndTime.Sub(*row.StartTime).Hours()
+		rostered += duration
+		if *row.Status == "completed" {
+			worked += duration
+			completed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// loadBrandingLogo loads the organization logo for documents. Logos can be
+// data URIs or files under the upload directory. Remote URLs are never
+// fetched, so a logo URL can't be used to reach internal addresses or stall
+// a render; any failure simply leaves the logo off the document.
+func (h *Handler) loadBrandingLogo(location string) image.Image {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil
+	}
+
+	var data []byte
+	switch {
+	case strings.HasPrefix(location, "data:"):
+		comma := strings.Index(location, ",")
+		if comma < 0 || !strings.Contains(location[:comma], ";base64") {
+			return nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(location[comma+1:])
+		if err != nil || len(decoded) > maxLogoBytes {
+			return nil
+		}
+		data = decoded
+	case strings.Contains(location, "://"):
+		return nil
+	default:
+		if h.Config == nil || h.Config.UploadPath == "" {
+			return nil
+		}
+		root, err := filepath.Abs(h.Config.UploadPath)
+		if err != nil {
+			return nil
+		}
+		path, err := filepath.Abs(filepath.Join(root, strings.TrimPrefix(filepath.Clean("/"+location), "/uploads")))
+		if err != nil || !strings.HasPrefix(path, root+string(filepath.Separator)) {
+			return nil
+		}
+		body, err := os.ReadFile(path)
+		if err != nil || len(body) > maxLogoBytes {
+			return nil
+		}
+		data = body
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// letterhead builds the branded page header and footer for an organization's documents
+func (h *Handler) letterhead(orgID interface{}, title, subtitle string) pdf.Letterhead {
+	head := pdf.Letterhead{
+		Title:    title,
+		Subtitle: subtitle,
+		Primary:  defaultBrandColor,
+	}
+
+	var organization models.Organization
+	if err := h.DB.Where("id = ?", orgID).First(&organization).Error; err == nil {
+		head.OrganizationName = organization.Name
+
+		address := strings.Join(nonEmpty(organization.Address.Street, organization.Address.Suburb,
+			strings.TrimSpace(organization.Address.State+" "+organization.Address.Postcode)), ", ")
+		contact := strings.Join(nonEmpty(organization.Phone, organization.Email), " | ")
+		if organization.ABN != "" {
+			head.Details = append(head.Details, "ABN "+organization.ABN)
+		}
+		head.Details = append(head.Details, nonEmpty(address, contact)...)
+	}
+
+	var branding models.OrganizationBranding
+	if err := h.DB.Where("organization_id = ?", orgID).First(&branding).Error; err == nil {
+		head.Primary = pdf.ParseHexColor(branding.PrimaryColor, defaultBrandColor)
+		head.Footer = branding.FooterText
+		head.Logo = h.loadBrandingLogo(branding.LogoURL)
+	}
+
+	return head
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// newExportWriter sets the download headers for the requested format and
+// returns a writer that streams rows to the response
+func newExportWriter(c *gin.Context, format, filename string, head pdf.Letterhead, columns []pdf.Column) (spreadsheet.RowWriter, error) {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Header
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	switch format {
+	case "pdf":
+		c.Header("Content-Type", "application/pdf")
+		return pdf.NewTableReport(c.Writer, head, columns), nil
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		return spreadsheet.NewCSVWriter(c.Writer, header)
+	case "xlsx":
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		return spreadsheet.NewXLSXWriter(c.Writer, head.Title, header)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+// renderInvoicePDF writes a branded participant invoice
+func renderInvoicePDF(w io.Writer, head pdf.Letterhead, invoice *models.ParticipantInvoice, lines []models.ParticipantInvoiceLine, loc *time.Location) error {
+	doc := pdf.NewBranded(w, head)
+	y := doc.NewPage()
+	right := doc.Width - 40
+
+	// Bill to
+	participant := invoice.Participant
+	doc.SetFillColor(pdf.Gray)
+	doc.SetFont(true, 8)
+	doc.Text(40, y, "BILL TO")
+	doc.SetFillColor(pdf.Black)
+	doc.SetFont(true, 11)
+	doc.Text(40, y+15, participant.FirstName+" "+participant.LastName)
+	doc.SetFont(false, 9)
+	billTo := nonEmpty(
+		"NDIS Number: "+participant.NDISNumber,
+		participant.Address.Street,
+		strings.TrimSpace(strings.Join(nonEmpty(participant.Address.Suburb, participant.Address.State, participant.Address.Postcode), " ")),
+	)
+	for i, line := range billTo {
+		doc.Text(40, y+29+float64(i)*12, line)
+	}
+
+	// Invoice details
+	details := [][2]string{
+		{"Invoice Number", invoice.InvoiceNumber},
+		{"Issue Date", invoice.IssueDate.In(loc).Format("02/01/2006")},
+		{"Due Date", invoice.DueDate.In(loc).Format("02/01/2006")},
+		{"Status", strings.ToUpper(invoice.Status)},
+	}
+	for i, detail := range details {
+		rowY := y + 15 + float64(i)*13
+		doc.SetFillColor(pdf.Gray)
+		doc.SetFont(false, 9)
+		doc.TextRight(right-110, rowY, detail[0])
+		doc.SetFillColor(pdf.Black)
+		doc.SetFont(true, 9)
+		doc.TextRight(right, rowY, detail[1])
+	}
+	y += 90
+
+	if invoice.Description != "" {
+		doc.SetFont(false, 9)
+		doc.Text(40, y, doc.Fit(invoice.Description, doc.ContentWidth()))
+		y += 18
+	}
+
+	table := doc.NewTable(y, []pdf.Column{
+		{Header: "Date", Width: 1.4},
+		{Header: "Service", Width: 3},
+		{Header: "Support Item", Width: 2.2},
+		{Header: "Hours", Width: 1, Align: pdf.AlignRight},
+		{Header: "Rate", Width: 1.2, Align: pdf.AlignRight},
+		{Header: "Amount", Width: 1.4, Align: pdf.AlignRight},
+	})
+	for _, line := range lines {
+		table.Row([]string{
+			line.ServiceDate.In(loc).Format("02/01/2006"),
+			line.ServiceType,
+			line.Shift.SupportItemNumber,
+			pdf.FormatAmount(line.Hours),
+			pdf.FormatAmount(line.HourlyRate),
+			pdf.FormatAmount(line.Amount),
+		})
+	}
+
+	// Totals
+	y = table.Ensure(70) + 14
+	totals := [][2]string{
+		{"Total", "$" + pdf.FormatAmount(invoice.Amount)},
+		{"Paid", "$" + pdf.FormatAmount(invoice.PaidAmount)},
+		{"Balance Due", "$" + pdf.FormatAmount(roundCurrency(invoice.Amount-invoice.PaidAmount))},
+	}
+	for i, total := range totals {
+		doc.SetFont(i == len(totals)-1, 10)
+		doc.TextRight(right-110, y, total[0])
+		doc.TextRight(right, y, total[1])
+		y += 16
+	}
+
+	return doc.Close()
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/spreadsheet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportReport(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.OrganizationBranding{})
+
+	participant := models.Participant{
+		ID:             "export-participant",
+		FirstName:      "Export",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "EXP123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+	handler.DB.Create(&models.OrganizationBranding{ID: "export-branding", OrganizationID: "test-org", PrimaryColor: "#123456", FooterText: "Thank you for choosing us"})
+
+	start := time.Now().AddDate(0, 0, -3).Truncate(time.Hour)
+	for i := 0; i < 3; i++ {
+		handler.DB.Create(&models.Shift{
+			ParticipantID: participant.ID,
+			StaffID:       "test-user",
+			StartTime:     start.Add(time.Duration(i) * time.Hour * 24),
+			EndTime:       start.Add(time.Duration(i)*time.Hour*24 + 2*time.Hour),
+			ServiceType:   "Personal Care",
+			Location:      "Home",
+			Status:        "completed",
+			HourlyRate:    50,
+		})
+	}
+
+	export := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("CSV export contains report rows", func(t *testing.T) {
+		w := export("/api/v1/reports/participant_summary/export?format=csv")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		assert.Equal(t, "Participant,NDIS Number,Shifts,Total Hours,Total Cost,Last Service", lines[0])
+		assert.Contains(t, w.Body.String(), "Export Participant,EXP123,3,6.00,300.00,")
+	})
+
+	t.Run("XLSX export can be read back", func(t *testing.T) {
+		w := export("/api/v1/reports/participant_summary/export?format=xlsx")
+		assert.Equal(t, http.StatusOK, w.Code)
+		rows, err := spreadsheet.ReadRows(bytes.NewReader(w.Body.Bytes()), "report.xlsx")
+		assert.NoError(t, err)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, "Export Participant", rows[1][0])
+		assert.Equal(t, "300", rows[1][4])
+	})
+
+	t.Run("PDF export is a complete document", func(t *testing.T) {
+		w := export("/api/v1/reports/revenue_monthly/export?format=pdf")
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, "%PDF-1.4"))
+		assert.True(t, strings.HasSuffix(body, "%%EOF\n"))
+		assert.Contains(t, body, "/Type /Catalog")
+	})
+
+	t.Run("Unknown reports and formats are rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, export("/api/v1/reports/nope/export").Code)
+		assert.Equal(t, http.StatusBadRequest, export("/api/v1/reports/revenue_monthly/export?format=doc").Code)
+	})
+}
+
+func TestLoadBrandingLogo(t *testing.T) {
+	handler, _ := setupTestHandler()
+	handler.Config.UploadPath = t.TempDir()
+
+	var logo bytes.Buffer
+	png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	os.WriteFile(filepath.Join(handler.Config.UploadPath, "logo.png"), logo.Bytes(), 0644)
+
+	assert.NotNil(t, handler.loadBrandingLogo("data:image/png;base64,"+base64.StdEncoding.EncodeToString(logo.Bytes())))
+	assert.NotNil(t, handler.loadBrandingLogo("/uploads/logo.png"))
+	assert.Nil(t, handler.loadBrandingLogo("../../etc/passwd"))
+
+	// Remote logos are never fetched
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(logo.Bytes())
+	}))
+	defer server.Close()
+	assert.Nil(t, handler.loadBrandingLogo(server.URL+"/logo.png"))
+	assert.Equal(t, 0, requests)
+}
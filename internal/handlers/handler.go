@@ -5,17 +5,27 @@ import (
 	"gorm.io/gorm"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/middleware"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/payments"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/vehiclelookup"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
 )
 
 type Handler struct {
-	DB     *gorm.DB
-	Config *config.Config
+	DB       *gorm.DB
+	Config   *config.Config
+	Notifier notify.Sender
+	Payments payments.Provider // nil when no payment provider is set up
+	VehicleLookup *vehiclelookup.Lookup
 }
 
 func NewHandler(db *gorm.DB, cfg *config.Config) *Handler {
 	return &Handler{
-		DB:     db,
-		Config: cfg,
+		DB:       db,
+		Config:   cfg,
+		Notifier: notify.FromConfig(cfg),
+		Payments: payments.FromConfig(cfg),
+		VehicleLookup: vehiclelookup.FromConfig(cfg),
 	}
 }
 
@@ -49,6 +59,25 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 			auth.GET("/test-accounts", h.GetTestAccounts)
 		}
 
+		// Public online booking, keyed by organization slug
+		public := v1.Group("/public/:slug")
+		{
+			public.GET("", h.GetPublicOrganization)
+			public.GET("/services", h.GetPublicServices)
+			public.GET("/availability", h.GetPublicAvailability)
+			public.POST("/bookings", h.CreatePublicBooking)
+			public.GET("/bookings/:token", h.GetPublicBooking)
+			public.POST("/bookings/:token/reschedule", h.ReschedulePublicBooking)
+			public.POST("/bookings/:token/cancel", h.CancelPublicBooking)
+			public.POST("/bookings/:token/deposit", h.PayBookingDeposit)
+			public.GET("/waitlist-offers/:token", h.GetPublicWaitlistOffer)
+			public.POST("/waitlist-offers/:token/accept", h.AcceptWaitlistOffer)
+			public.POST("/waitlist-offers/:token/decline", h.DeclineWaitlistOffer)
+		}
+
+		// Private calendar subscriptions; the token in the URL is the credential
+		v1.GET("/calendar/feeds/:token", h.GetCalendarFeedICS)
+
 		// Protected routes (require authentication)
 		protected := v1.Group("/")
 		protected.Use(middleware.AuthRequired(h.Config))
@@ -67,6 +96,25 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				users.POST("", middleware.RequireRole("admin"), h.CreateUser)
 				users.PUT("/:id", h.UpdateUser)
 				users.DELETE("/:id", middleware.RequireRole("admin"), h.DeleteUser)
+				users.GET("/:id/availability", h.GetStaffAvailability)
+				users.PUT("/:id/availability", h.SetStaffAvailability)
+				users.GET("/:id/leave", h.GetStaffLeave)
+				users.POST("/:id/leave", h.CreateStaffLeave)
+				users.PATCH("/:id/leave/:leaveId", middleware.RequireRole("admin", "manager"), h.UpdateStaffLeaveStatus)
+				users.DELETE("/:id/leave/:leaveId", h.DeleteStaffLeave)
+				users.GET("/:id/qualifications", h.GetStaffQualifications)
+				users.POST("/:id/qualifications", h.SaveStaffQualification)
+				users.PUT("/:id/qualifications/:qualificationId", h.SaveStaffQualification)
+				users.DELETE("/:id/qualifications/:qualificationId", h.DeleteStaffQualification)
+			}
+
+			// Staff qualification compliance routes
+			protected.GET("/staff/qualifications/expiring", middleware.RequireRole("admin", "manager"), h.GetExpiringQualifications)
+			qualificationRequirements := protected.Group("/qualification-requirements")
+			{
+				qualificationRequirements.GET("", h.GetQualificationRequirements)
+				qualificationRequirements.POST("", middleware.RequireRole("admin", "manager"), h.CreateQualificationRequirement)
+				qualificationRequirements.DELETE("/:id", middleware.RequireRole("admin", "manager"), h.DeleteQualificationRequirement)
 			}
 
 			// Participant routes
@@ -77,6 +125,16 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				participants.POST("", h.CreateParticipant)
 				participants.PUT("/:id", h.UpdateParticipant)
 				participants.DELETE("/:id", h.DeleteParticipant)
+				participants.GET("/:id/budgets", h.GetParticipantBudgets)
+				participants.POST("/:id/budgets", middleware.RequireRole("admin", "manager"), h.CreateParticipantBudget)
+				participants.PUT("/:id/budgets/:budgetId", middleware.RequireRole("admin", "manager"), h.UpdateParticipantBudget)
+				participants.DELETE("/:id/budgets/:budgetId", middleware.RequireRole("admin", "manager"), h.DeleteParticipantBudget)
+				participants.GET("/:id/budgets/:budgetId/transactions", h.GetBudgetTransactions)
+				participants.POST("/:id/budgets/:budgetId/adjustments", middleware.RequireRole("admin", "manager"), h.AdjustParticipantBudget)
+				participants.GET("/:id/progress-notes", h.GetParticipantProgressNotes)
+				participants.GET("/:id/worker-preferences", h.GetParticipantWorkerPreferences)
+				participants.POST("/:id/worker-preferences", middleware.RequireRole("admin", "manager"), h.CreateParticipantWorkerPreference)
+				participants.DELETE("/:id/worker-preferences/:preferenceId", middleware.RequireRole("admin", "manager"), h.DeleteParticipantWorkerPreference)
 			}
 
 			// Shift routes
@@ -85,11 +143,44 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				shifts.GET("", h.GetShifts)
 				shifts.GET("/:id", h.GetShift)
 				shifts.POST("", h.CreateShift)
+				shifts.POST("/auto-assign", middleware.RequireRole("admin", "manager"), h.AutoAssignShifts)
+				shifts.GET("/:id/candidates", h.GetShiftCandidates)
+				shifts.POST("/:id/assign", middleware.RequireRole("admin", "manager"), h.AssignShift)
 				shifts.PUT("/:id", h.UpdateShift)
 				shifts.PATCH("/:id/status", h.UpdateShiftStatus)
+				shifts.POST("/:id/clock-in", h.ClockInShift)
+				shifts.POST("/:id/clock-out", h.ClockOutShift)
+				shifts.GET("/:id/clock-events", h.GetShiftClockEvents)
+				shifts.GET("/:id/progress-note", h.GetShiftProgressNote)
+				shifts.PUT("/:id/progress-note", h.SaveShiftProgressNote)
 				shifts.DELETE("/:id", h.DeleteShift)
 			}
 
+			// Incident reporting routes
+			incidents := protected.Group("/incidents")
+			{
+				incidents.GET("", h.GetIncidents)
+				incidents.GET("/:id", h.GetIncident)
+				incidents.POST("", h.CreateIncident)
+				incidents.PUT("/:id", middleware.RequireRole("admin", "manager"), h.UpdateIncident)
+				incidents.PATCH("/:id/status", middleware.RequireRole("admin", "manager"), h.UpdateIncidentStatus)
+				incidents.POST("/:id/notifications", middleware.RequireRole("admin", "manager"), h.RecordIncidentNotification)
+				incidents.POST("/:id/steps", middleware.RequireRole("admin", "manager"), h.CreateInvestigationStep)
+				incidents.PATCH("/:id/steps/:stepId", h.UpdateInvestigationStep)
+				incidents.POST("/:id/documents", h.AttachIncidentDocument)
+			}
+
+			// Recurring shift series routes
+			shiftSeries := protected.Group("/shift-series")
+			{
+				shiftSeries.GET("", h.GetShiftSeriesList)
+				shiftSeries.POST("", h.CreateShiftSeries)
+				shiftSeries.POST("/generate", middleware.RequireRole("admin", "manager"), h.GenerateShiftSeries)
+				shiftSeries.GET("/:id", h.GetShiftSeries)
+				shiftSeries.PUT("/:id", h.UpdateShiftSeries)
+				shiftSeries.DELETE("/:id", h.DeleteShiftSeries)
+			}
+
 			// Document routes
 			documents := protected.Group("/documents")
 			{
@@ -179,20 +270,55 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				carePlans.GET("/:id", h.GetCarePlan)
 				carePlans.POST("", h.CreateCarePlan)
 				carePlans.PUT("/:id", h.UpdateCarePlan)
-				carePlans.PATCH("/:id/approve", middleware.RequireRole("admin,manager"), h.ApproveCarePlan)
+				carePlans.PATCH("/:id/approve", middleware.RequireRole("admin", "manager"), h.ApproveCarePlan)
 				carePlans.DELETE("/:id", h.DeleteCarePlan)
+				carePlans.POST("/:id/revise", h.ReviseCarePlan)
+				carePlans.GET("/:id/versions", h.GetCarePlanVersions)
+				carePlans.GET("/:id/diff", h.GetCarePlanDiff)
+				carePlans.POST("/:id/goals", h.CreateCarePlanGoal)
+				carePlans.PUT("/:id/goals/:goalId", h.UpdateCarePlanGoal)
+				carePlans.DELETE("/:id/goals/:goalId", h.DeleteCarePlanGoal)
+				carePlans.GET("/:id/goals/:goalId/progress", h.GetCarePlanGoalProgress)
 			}
 
 			// Billing routes
 			billing := protected.Group("/billing")
 			{
 				billing.GET("", h.GetBilling)
+				billing.GET("/unbilled-shifts", h.GetUnbilledShifts)
+				billing.GET("/claims", h.GetClaimBatches)
+				billing.POST("/claims", h.CreateClaimBatch)
+				billing.GET("/claims/:id", h.GetClaimBatch)
+				billing.GET("/claims/:id/export", h.ExportClaimBatch)
+				billing.POST("/claims/:id/remittance", h.ImportRemittance)
 				billing.GET("/:id", h.GetBillingRecord)
 				billing.POST("/generate", h.GenerateInvoice)
+				billing.POST("/:id/send", h.SendInvoice)
 				billing.POST("/:id/payment", h.MarkAsPaid)
+				billing.POST("/:id/cancel", middleware.RequireRole("admin", "manager"), h.CancelInvoice)
 				billing.GET("/:id/download", h.DownloadInvoice)
 			}
 
+			// NDIS support catalogue routes. The catalogue and public holidays
+			// are shared by every organization, so only super admins change them.
+			ndis := protected.Group("/ndis")
+			{
+				ndis.GET("/catalogue/versions", h.GetSupportCatalogueVersions)
+				ndis.POST("/catalogue/import", middleware.RequireSuperAdmin(), h.ImportSupportCatalogue)
+				ndis.GET("/catalogue/items", h.GetSupportCatalogueItems)
+				ndis.GET("/catalogue/resolve", h.ResolveSupportItem)
+				ndis.GET("/public-holidays", h.GetPublicHolidays)
+				ndis.POST("/public-holidays", middleware.RequireSuperAdmin(), h.CreatePublicHoliday)
+				ndis.DELETE("/public-holidays/:id", middleware.RequireSuperAdmin(), h.DeletePublicHoliday)
+			}
+
+			// Plan budget alert routes
+			budgetAlerts := protected.Group("/budget-alerts")
+			{
+				budgetAlerts.GET("", h.GetBudgetAlerts)
+				budgetAlerts.POST("/:id/acknowledge", h.AcknowledgeBudgetAlert)
+			}
+
 			// Reports routes
 			reports := protected.Group("/reports")
 			{
@@ -201,7 +327,9 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				reports.GET("/shifts", h.GetShiftsReport)
 				reports.GET("/service-hours", h.GetServiceHoursReport)
 				reports.GET("/participants", h.GetParticipantReport)
+				reports.GET("/budget-forecast", h.GetBudgetForecastReport)
 				reports.GET("/staff-performance", h.GetStaffPerformance)
+				reports.GET("/visit-verification", middleware.RequireRole("admin", "manager"), h.GetVisitVerificationReport)
 				reports.GET("/:type/export", h.ExportReport)
 				reports.GET("/templates", h.GetReportTemplates)
 			}
@@ -224,11 +352,19 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 			{
 				vehicles.GET("", h.GetVehicles)
 				vehicles.GET("/stats", h.GetVehicleStats)
+				vehicles.GET("/lookup", h.LookupVehicle)
+				vehicles.GET("/reminder-rules", h.GetServiceReminderRules)
+				vehicles.POST("/reminder-rules", h.CreateServiceReminderRule)
+				vehicles.PUT("/reminder-rules/:ruleId", h.UpdateServiceReminderRule)
+				vehicles.DELETE("/reminder-rules/:ruleId", h.DeleteServiceReminderRule)
 				vehicles.GET("/:id", h.GetVehicle)
 				vehicles.POST("", h.CreateVehicle)
 				vehicles.PUT("/:id", h.UpdateVehicle)
 				vehicles.PATCH("/:id/toggle-status", h.ToggleVehicleStatus)
 				vehicles.PATCH("/:id/mileage", h.UpdateVehicleMileage)
+				vehicles.GET("/:id/odometer", h.GetOdometerReadings)
+				vehicles.POST("/:id/odometer", h.RecordOdometerReading)
+				vehicles.GET("/:id/history", h.GetVehicleServiceHistory)
 				vehicles.DELETE("/:id", h.DeleteVehicle)
 			}
 
@@ -244,6 +380,34 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				services.PUT("/:id", h.UpdateService)
 				services.PATCH("/:id/toggle-status", h.ToggleServiceStatus)
 				services.DELETE("/:id", h.DeleteService)
+				services.GET("/:id/resources", h.GetServiceResources)
+				services.PUT("/:id/resources", middleware.RequireRole("admin", "manager"), h.SetServiceResources)
+			}
+
+			// Bookable resources such as service bays, rooms and chairs
+			resources := protected.Group("/resources")
+			{
+				resources.GET("", h.GetResources)
+				resources.GET("/:id", h.GetResource)
+				resources.POST("", middleware.RequireRole("admin", "manager"), h.CreateResource)
+				resources.PUT("/:id", middleware.RequireRole("admin", "manager"), h.UpdateResource)
+				resources.DELETE("/:id", middleware.RequireRole("admin", "manager"), h.DeleteResource)
+			}
+
+			// Calendar subscription for the current user's bookings and shifts
+			calendar := protected.Group("/calendar")
+			{
+				calendar.GET("/feed", h.GetCalendarFeed)
+				calendar.POST("/feed/reset", h.ResetCalendarFeed)
+			}
+
+			// Booking waitlist
+			waitlist := protected.Group("/waitlist")
+			{
+				waitlist.GET("", h.GetWaitlist)
+				waitlist.POST("", h.CreateWaitlistEntry)
+				waitlist.GET("/offers", h.GetWaitlistOffers)
+				waitlist.DELETE("/:id", h.CancelWaitlistEntry)
 			}
 
 			// Booking routes
@@ -255,9 +419,26 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				bookings.POST("", h.CreateBooking)
 				bookings.PUT("/:id", h.UpdateBooking)
 				bookings.PATCH("/:id/status", h.UpdateBookingStatus)
+				bookings.GET("/:id/notifications", h.GetBookingNotifications)
+				bookings.GET("/:id/payments", h.GetBookingPayments)
+				bookings.GET("/:id/job-card", h.GetJobCard)
+				bookings.GET("/:id/job-card/download", h.DownloadJobCard)
+				bookings.POST("/:id/job-card/items", h.AddJobCardItem)
+				bookings.DELETE("/:id/job-card/items/:itemId", h.DeleteJobCardItem)
 				bookings.DELETE("/:id", h.DeleteBooking)
 			}
 
+			// Breaks and closures in business hours, used by booking availability
+			businessHours := protected.Group("/business-hours")
+			{
+				businessHours.GET("/breaks", h.GetBusinessBreaks)
+				businessHours.POST("/breaks", middleware.RequireRole("admin", "manager"), h.CreateBusinessBreak)
+				businessHours.DELETE("/breaks/:id", middleware.RequireRole("admin", "manager"), h.DeleteBusinessBreak)
+				businessHours.GET("/closures", h.GetBusinessClosures)
+				businessHours.POST("/closures", middleware.RequireRole("admin", "manager"), h.CreateBusinessClosure)
+				businessHours.DELETE("/closures/:id", middleware.RequireRole("admin", "manager"), h.DeleteBusinessClosure)
+			}
+
 			// ERP - Inventory Management routes
 			inventory := protected.Group("/inventory")
 			{
@@ -363,6 +544,18 @@ func (h *Handler) SetupRoutes(router *gin.Engine) {
 				pos.GET("/report", h.GetPOSReport)
 			}
 
+			// General ledger: where sales, stock and payments are posted, invoicing,
+			// period close and bank reconciliation
+			ledgerHandler := finance.NewHandler(finance.NewService(h.DB))
+			ledgerHandler.CustomerNames = h.customerNames
+			ledgerHandler.RequireAdmin = middleware.RequireRole("admin")
+			ledger := protected.Group("/ledger")
+			ledger.Use(middleware.RequireRole("admin", "manager"), func(c *gin.Context) {
+				c.Set("organization_id", h.getOrganizationID(c))
+				c.Next()
+			})
+			ledgerHandler.RegisterRoutes(ledger)
+
 			// Organization Module Configuration routes
 			moduleConfig := protected.Group("/module-config")
 			moduleConfig.Use(middleware.RequireRole("admin", "super_admin"))
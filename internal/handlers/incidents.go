@@ -0,0 +1,1004 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// reportableIncidentCategories are the incident types the NDIS Commission must be notified of
+var reportableIncidentCategories = map[string]bool{
+	"death":                true,
+	"serious_injury":       true,
+	"abuse_neglect":        true,
+	"unlawful_contact":     true,
+	"sexual_misconduct":    true,
+	"restrictive_practice": true,
+}
+
+const (
+	// incidentNotificationHours is the deadline for notifying the Commission of most reportable incidents
+	incidentNotificationHours = 24
+	// incidentReportBusinessDays is the deadline for the five-day report, and for
+	// notifying unauthorised restrictive practices that caused no harm
+	incidentReportBusinessDays = 5
+)
+
+// addBusinessDays moves forward the given number of days, skipping weekends
+// and public holidays. Days are counted in from's location, so convert to the
+// organization's timezone first.
+func (h *Handler) addBusinessDays(from time.Time, days int, state string) time.Time {
+	date := from
+	for days > 0 {
+		date = date.AddDate(0, 0, 1)
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday || h.isPublicHoliday(date, state) {
+			continue
+		}
+		days--
+	}
+	return date
+}
+
+// setIncidentDeadlines works out the Commission notification and five-day
+// report deadlines from when the organization became aware of the incident
+func (h *Handler) setIncidentDeadlines(incident *models.Incident) {
+	incident.NotificationDueAt = nil
+	incident.FinalReportDueAt = nil
+	if !incident.IsReportable {
+		return
+	}
+
+	state := ""
+	var organization models.Organization
+	if err := h.DB.Select("id", "address_state").Where("id = ?", incident.OrganizationID).First(&organization).Error; err == nil {
+		state = organization.Address.State
+	}
+
+	awareAt := incident.AwareAt.In(h.organizationLocation(incident.OrganizationID))
+	reportDue := h.addBusinessDays(awareAt, incidentReportBusinessDays, state)
+	if incident.Category == "restrictive_practice" {
+		incident.NotificationDueAt = &reportDue
+		return
+	}
+	notificationDue := incident.AwareAt.Add(incidentNotificationHours * time.Hour)
+	incident.NotificationDueAt = &notificationDue
+	incident.FinalReportDueAt = &reportDue
+}
+
+// nextIncidentNumber returns the next sequential incident number for the
+// organization. tx must be the transaction that creates the incident.
+func nextIncidentNumber(tx *gorm.DB, orgID interface{}, reportedAt time.Time) (string, error) {
+	number, err := nextSequenceValue(tx, orgID, "incident", func() (int64, error) {
+		var count int64
+		err := tx.Unscoped().Model(&models.Incident{}).Where("organization_id = ?", orgID).Count(&count).Error
+		return count, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("INC-%d-%05d", reportedAt.Year(), number), nil
+}
+
+// findIncident loads the incident named by :id, writing the error response when it isn't in the organization
+func (h *Handler) findIncident(c *gin.Context, orgID interface{}) (*models.Incident, bool) {
+	var incident models.Incident
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Preload("Participant").Preload("Reporter").Preload("Documents").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&incident).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INCIDENT_NOT_FOUND",
+					"message": "Incident not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch incident",
+			},
+		})
+		return nil, false
+	}
+	return &incident, true
+}
+
+// GetIncidents lists incident reports, optionally only those with a missed Commission deadline
+func (h *Handler) GetIncidents(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := h.DB.Model(&models.Incident{}).Where("organization_id = ?", orgID)
+	for _, filter := range []string{"status", "severity", "category", "participant_id", "shift_id"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+	if reportable := c.Query("reportable"); reportable != "" {
+		query = query.Where("is_reportable = ?", reportable == "true")
+	}
+	if c.Query("overdue") == "true" {
+		now := time.Now()
+		query = query.Where("(notified_at IS NULL AND notification_due_at < ?) OR (final_report_at IS NULL AND final_report_due_at < ?)", now, now)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var incidents []models.Incident
+	if err := query.Preload("Participant").Preload("Reporter").
+		Order("occurred_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Find(&incidents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch incidents",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"incidents": incidents,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// GetIncident returns an incident with its investigation steps and documents
+func (h *Handler) GetIncident(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+	})
+}
+
+type CreateIncidentRequest struct {
+	ParticipantID    string `json:"participant_id"`
+	ShiftID          string `json:"shift_id"`
+	OccurredAt       string `json:"occurred_at" binding:"required"`
+	AwareAt          string `json:"aware_at"` // Defaults to now
+	Location         string `json:"location"`
+	Category         string `json:"category" binding:"required,oneof=death serious_injury abuse_neglect unlawful_contact sexual_misconduct restrictive_practice injury illness property_damage behaviour medication_error other"`
+	Severity         string `json:"severity" binding:"required,oneof=low medium high critical"`
+	IsReportable     bool   `json:"is_reportable"` // Marks an incident outside the reportable categories as reportable
+	Description      string `json:"description" binding:"required"`
+	ImmediateActions string `json:"immediate_actions"`
+	Witnesses        string `json:"witnesses"`
+}
+
+// CreateIncident records an incident report. Any staff member can report an
+// incident; reportable incidents get their Commission deadlines straight away.
+func (h *Handler) CreateIncident(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	occurredAt, err := parseTimeFromString(req.OccurredAt)
+	if err != nil || occurredAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_OCCURRED_AT",
+				"message": "Occurred time must be a valid time that isn't in the future",
+			},
+		})
+		return
+	}
+	awareAt := now
+	if req.AwareAt != "" {
+		if awareAt, err = parseTimeFromString(req.AwareAt); err != nil || awareAt.Before(occurredAt) || awareAt.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_AWARE_AT",
+					"message": "Aware time must be between when the incident occurred and now",
+				},
+			})
+			return
+		}
+	}
+
+	incident := models.Incident{
+		OrganizationID:   fmt.Sprintf("%v", orgID),
+		ReportedBy:       c.GetString("user_id"),
+		OccurredAt:       occurredAt,
+		AwareAt:          awareAt,
+		Location:         req.Location,
+		Category:         req.Category,
+		Severity:         req.Severity,
+		IsReportable:     req.IsReportable || reportableIncidentCategories[req.Category],
+		Description:      req.Description,
+		ImmediateActions: req.ImmediateActions,
+		Witnesses:        req.Witnesses,
+		Status:           "open",
+	}
+
+	if req.ShiftID != "" {
+		var shift models.Shift
+		if err := h.DB.Joins("JOIN participants ON shifts.participant_id = participants.id").
+			Where("shifts.id = ? AND participants.organization_id = ?", req.ShiftID, orgID).First(&shift).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SHIFT",
+					"message": "Shift not found",
+				},
+			})
+			return
+		}
+		incident.ShiftID = &shift.ID
+		if req.ParticipantID == "" {
+			req.ParticipantID = shift.ParticipantID
+		}
+	}
+	if req.ParticipantID != "" {
+		var participant models.Participant
+		if err := h.DB.Where("id = ? AND organization_id = ?", req.ParticipantID, orgID).First(&participant).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_PARTICIPANT",
+					"message": "Participant not found",
+				},
+			})
+			return
+		}
+		incident.ParticipantID = &participant.ID
+	}
+
+	h.setIncidentDeadlines(&incident)
+
+	tx := h.DB.Begin()
+	number, err := nextIncidentNumber(tx, orgID, now)
+	if err == nil {
+		incident.IncidentNumber = number
+		err = tx.Create(&incident).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create incident",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create incident",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Reporter").First(&incident, "id = ?", incident.ID)
+
+	message := "Incident reported successfully"
+	if incident.IsReportable {
+		message = fmt.Sprintf("Reportable incident recorded; notify the NDIS Commission by %s", incident.NotificationDueAt.Format("2006-01-02 15:04"))
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    incident,
+		"message": message,
+	})
+}
+
+type UpdateIncidentRequest struct {
+	Location         *string `json:"location,omitempty"`
+	Category         *string `json:"category,omitempty" binding:"omitempty,oneof=death serious_injury abuse_neglect unlawful_contact sexual_misconduct restrictive_practice injury illness property_damage behaviour medication_error other"`
+	Severity         *string `json:"severity,omitempty" binding:"omitempty,oneof=low medium high critical"`
+	IsReportable     *bool   `json:"is_reportable,omitempty"`
+	Description      *string `json:"description,omitempty"`
+	ImmediateActions *string `json:"immediate_actions,omitempty"`
+	Witnesses        *string `json:"witnesses,omitempty"`
+}
+
+// UpdateIncident corrects the details of an open incident, recalculating the
+// Commission deadlines if it becomes reportable or changes category
+func (h *Handler) UpdateIncident(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+	if incident.Status == "closed" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INCIDENT_CLOSED",
+				"message": "Closed incidents can't be changed",
+			},
+		})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Location != nil {
+		updates["location"] = *req.Location
+	}
+	if req.Severity != nil {
+		updates["severity"] = *req.Severity
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.ImmediateActions != nil {
+		updates["immediate_actions"] = *req.ImmediateActions
+	}
+	if req.Witnesses != nil {
+		updates["witnesses"] = *req.Witnesses
+	}
+	if req.Category != nil || req.IsReportable != nil {
+		if req.Category != nil {
+			incident.Category = *req.Category
+		}
+		if req.IsReportable != nil {
+			incident.IsReportable = *req.IsReportable
+		}
+		// A reportable category can't be downgraded by clearing the flag
+		incident.IsReportable = incident.IsReportable || reportableIncidentCategories[incident.Category]
+		h.setIncidentDeadlines(incident)
+		updates["category"] = incident.Category
+		updates["is_reportable"] = incident.IsReportable
+		updates["notification_due_at"] = incident.NotificationDueAt
+		updates["final_report_due_at"] = incident.FinalReportDueAt
+	}
+
+	if err := h.DB.Model(incident).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update incident",
+			},
+		})
+		return
+	}
+
+	incident, _ = h.findIncident(c, orgID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+		"message": "Incident updated successfully",
+	})
+}
+
+type RecordIncidentNotificationRequest struct {
+	Type        string `json:"type" binding:"required,oneof=initial final"`
+	SubmittedAt string `json:"submitted_at"` // Defaults to now
+	Reference   string `json:"reference"`    // Commission reference number, required for the initial notification
+}
+
+// RecordIncidentNotification records that the Commission was notified of a
+// reportable incident, or that the five-day report was submitted
+func (h *Handler) RecordIncidentNotification(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req RecordIncidentNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+	if !incident.IsReportable {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NOT_REPORTABLE",
+				"message": "Only reportable incidents are notified to the NDIS Commission",
+			},
+		})
+		return
+	}
+
+	submittedAt := time.Now()
+	if req.SubmittedAt != "" {
+		parsed, err := parseTimeFromString(req.SubmittedAt)
+		if err != nil || parsed.After(submittedAt) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SUBMITTED_AT",
+					"message": "Submitted time must be a valid time that isn't in the future",
+				},
+			})
+			return
+		}
+		submittedAt = parsed
+	}
+
+	updates := map[string]interface{}{}
+	switch req.Type {
+	case "initial":
+		if strings.TrimSpace(req.Reference) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "REFERENCE_REQUIRED",
+					"message": "The Commission reference number is required for the initial notification",
+				},
+			})
+			return
+		}
+		updates["notified_at"] = submittedAt
+		updates["notification_reference"] = strings.TrimSpace(req.Reference)
+	case "final":
+		if incident.NotifiedAt == nil || incident.FinalReportDueAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_OPERATION",
+					"message": "The five-day report follows the initial notification of a 24-hour reportable incident",
+				},
+			})
+			return
+		}
+		updates["final_report_at"] = submittedAt
+	}
+	if incident.Status == "open" {
+		updates["status"] = "under_investigation"
+	}
+
+	if err := h.DB.Model(incident).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record notification",
+			},
+		})
+		return
+	}
+
+	incident, _ = h.findIncident(c, orgID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+		"message": "Notification recorded successfully",
+	})
+}
+
+type CreateInvestigationStepRequest struct {
+	Description string `json:"description" binding:"required"`
+	AssignedTo  string `json:"assigned_to"`
+	DueDate     string `json:"due_date"` // YYYY-MM-DD
+}
+
+// CreateInvestigationStep adds a follow-up action to an incident and moves it under investigation
+func (h *Handler) CreateInvestigationStep(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateInvestigationStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+	if incident.Status == "closed" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INCIDENT_CLOSED",
+				"message": "Closed incidents can't be changed",
+			},
+		})
+		return
+	}
+
+	step := models.IncidentInvestigationStep{
+		IncidentID:  incident.ID,
+		Description: req.Description,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if req.AssignedTo != "" {
+		var assignee models.User
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.AssignedTo, orgID, true).First(&assignee).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return
+		}
+		step.AssignedTo = &assignee.ID
+	}
+	if req.DueDate != "" {
+		dueDate, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+		step.DueDate = &dueDate
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Create(&step).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create investigation step",
+			},
+		})
+		return
+	}
+	if incident.Status == "open" {
+		if err := tx.Model(incident).Update("status", "under_investigation").Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update incident",
+				},
+			})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create investigation step",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    step,
+		"message": "Investigation step added successfully",
+	})
+}
+
+type UpdateInvestigationStepRequest struct {
+	Findings  *string `json:"findings,omitempty"`
+	Completed *bool   `json:"completed,omitempty"`
+}
+
+// UpdateInvestigationStep records findings against a step and marks it complete.
+// The assignee can update their own steps; managers can update any.
+func (h *Handler) UpdateInvestigationStep(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+	role := c.GetString("user_role")
+
+	var req UpdateInvestigationStepRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+	if incident.Status == "closed" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INCIDENT_CLOSED",
+				"message": "Closed incidents can't be changed",
+			},
+		})
+		return
+	}
+
+	var step models.IncidentInvestigationStep
+	if err := h.DB.Where("id = ? AND incident_id = ?", c.Param("stepId"), incident.ID).First(&step).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "STEP_NOT_FOUND",
+				"message": "Investigation step not found",
+			},
+		})
+		return
+	}
+
+	if role != "admin" && role != "manager" && (step.AssignedTo == nil || *step.AssignedTo != userID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INSUFFICIENT_PERMISSIONS",
+				"message": "You can only update investigation steps assigned to you",
+			},
+		})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Findings != nil {
+		updates["findings"] = *req.Findings
+	}
+	if req.Completed != nil {
+		if *req.Completed {
+			updates["completed_at"] = time.Now()
+			updates["completed_by"] = userID
+		} else {
+			updates["completed_at"] = nil
+			updates["completed_by"] = nil
+		}
+	}
+
+	if err := h.DB.Model(&step).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update investigation step",
+			},
+		})
+		return
+	}
+
+	h.DB.First(&step, "id = ?", step.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    step,
+		"message": "Investigation step updated successfully",
+	})
+}
+
+type AttachIncidentDocumentRequest struct {
+	DocumentID string `json:"document_id" binding:"required"`
+}
+
+// AttachIncidentDocument links an uploaded incident_report document to an incident
+func (h *Handler) AttachIncidentDocument(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req AttachIncidentDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+
+	var document models.Document
+	if err := h.DB.Joins("LEFT JOIN participants ON documents.participant_id = participants.id").
+		Joins("JOIN users ON documents.uploaded_by = users.id").
+		Where("documents.id = ? AND ((documents.participant_id IS NULL AND users.organization_id = ?) OR participants.organization_id = ?)", req.DocumentID, orgID, orgID).
+		First(&document).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DOCUMENT",
+				"message": "Document not found",
+			},
+		})
+		return
+	}
+	if document.Category != "incident_report" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DOCUMENT",
+				"message": "Only documents in the incident_report category can be attached to an incident",
+			},
+		})
+		return
+	}
+	if document.IncidentID != nil && *document.IncidentID != incident.ID {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DOCUMENT_ATTACHED",
+				"message": "Document is already attached to another incident",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Model(&document).Update("incident_id", incident.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to attach document",
+			},
+		})
+		return
+	}
+
+	incident, _ = h.findIncident(c, orgID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+		"message": "Document attached successfully",
+	})
+}
+
+type UpdateIncidentStatusRequest struct {
+	Status  string `json:"status" binding:"required,oneof=open under_investigation closed"`
+	Outcome string `json:"outcome"`
+}
+
+// UpdateIncidentStatus moves an incident through its workflow. Closing needs
+// an outcome, every investigation step complete and, for reportable incidents,
+// the Commission notified and the five-day report submitted.
+func (h *Handler) UpdateIncidentStatus(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateIncidentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	incident, ok := h.findIncident(c, orgID)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{"status": req.Status}
+	switch req.Status {
+	case "closed":
+		var outstanding []string
+		if strings.TrimSpace(req.Outcome) == "" {
+			outstanding = append(outstanding, "An outcome is required")
+		}
+		for _, step := range incident.Steps {
+			if step.CompletedAt == nil {
+				outstanding = append(outstanding, "Investigation step not complete: "+step.Description)
+			}
+		}
+		if incident.IsReportable && incident.NotifiedAt == nil {
+			outstanding = append(outstanding, "The NDIS Commission has not been notified")
+		}
+		if incident.FinalReportDueAt != nil && incident.FinalReportAt == nil {
+			outstanding = append(outstanding, "The five-day report has not been submitted")
+		}
+		if len(outstanding) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INCIDENT_NOT_READY",
+					"message": "Incident can't be closed yet",
+					"details": outstanding,
+				},
+			})
+			return
+		}
+		updates["outcome"] = strings.TrimSpace(req.Outcome)
+		updates["closed_at"] = time.Now()
+		updates["closed_by"] = c.GetString("user_id")
+	default:
+		// Reopening clears the closure
+		updates["closed_at"] = nil
+		updates["closed_by"] = nil
+	}
+
+	if err := h.DB.Model(incident).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update incident status",
+			},
+		})
+		return
+	}
+
+	incident, _ = h.findIncident(c, orgID)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+		"message": "Incident status updated successfully",
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncidentDeadlines(t *testing.T) {
+	handler, _ := setupTestHandler()
+	handler.DB.AutoMigrate(&models.PublicHoliday{}, &models.OrganizationSettings{})
+	handler.DB.Create(&models.OrganizationSettings{ID: "incident-settings", OrganizationID: "test-org", Timezone: "UTC"})
+
+	// Friday afternoon, with the following Monday a public holiday
+	awareAt := time.Date(2026, 10, 2, 15, 0, 0, 0, time.UTC)
+	handler.DB.Create(&models.PublicHoliday{ID: "incident-holiday", Date: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), Name: "Labour Day"})
+
+	incident := models.Incident{OrganizationID: "test-org", Category: "serious_injury", IsReportable: true, AwareAt: awareAt}
+	handler.setIncidentDeadlines(&incident)
+	assert.Equal(t, awareAt.Add(24*time.Hour), *incident.NotificationDueAt)
+	assert.Equal(t, time.Date(2026, 10, 12, 15, 0, 0, 0, time.UTC), *incident.FinalReportDueAt)
+
+	incident = models.Incident{OrganizationID: "test-org", Category: "restrictive_practice", IsReportable: true, AwareAt: awareAt}
+	handler.setIncidentDeadlines(&incident)
+	assert.Equal(t, time.Date(2026, 10, 12, 15, 0, 0, 0, time.UTC), *incident.NotificationDueAt)
+	assert.Nil(t, incident.FinalReportDueAt)
+
+	incident = models.Incident{OrganizationID: "test-org", Category: "property_damage", AwareAt: awareAt}
+	handler.setIncidentDeadlines(&incident)
+	assert.Nil(t, incident.NotificationDueAt)
+
+	// Friday afternoon in UTC is already Saturday in Sydney, so the
+	// weekend doesn't count towards the five business days
+	handler.DB.Model(&models.OrganizationSettings{}).Where("organization_id = ?", "test-org").Update("timezone", "Australia/Sydney")
+	awareAt = time.Date(2026, 10, 9, 15, 0, 0, 0, time.UTC)
+	incident = models.Incident{OrganizationID: "test-org", Category: "serious_injury", IsReportable: true, AwareAt: awareAt}
+	handler.setIncidentDeadlines(&incident)
+	assert.Equal(t, time.Date(2026, 10, 15, 15, 0, 0, 0, time.UTC), incident.FinalReportDueAt.UTC())
+}
+
+func TestIncidentWorkflow(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.PublicHoliday{}, &models.Document{},
+		&models.Incident{}, &models.IncidentInvestigationStep{}, &models.NumberSequence{})
+
+	participant := models.Participant{
+		ID:             "incident-participant",
+		FirstName:      "Incident",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "INC123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+
+	w, response := doRequest(handler, router, "POST", "/api/v1/incidents", map[string]interface{}{
+		"participant_id": participant.ID,
+		"occurred_at":    time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+		"category":       "serious_injury",
+		"severity":       "high",
+		"description":    "Fall in the bathroom",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	incident := response["data"].(map[string]interface{})
+	incidentID := incident["id"].(string)
+	assert.Equal(t, true, incident["is_reportable"])
+	assert.NotNil(t, incident["notification_due_at"])
+	assert.Equal(t, fmt.Sprintf("INC-%d-00001", time.Now().Year()), incident["incident_number"])
+
+	t.Run("Overdue filter finds missed notifications", func(t *testing.T) {
+		handler.DB.Model(&models.Incident{}).Where("id = ?", incidentID).Update("notification_due_at", time.Now().Add(-time.Hour))
+		_, response := doRequest(handler, router, "GET", "/api/v1/incidents?overdue=true", nil)
+		assert.Len(t, response["data"].(map[string]interface{})["incidents"], 1)
+	})
+
+	t.Run("Only incident report documents can be attached", func(t *testing.T) {
+		for _, document := range []models.Document{
+			{ID: "incident-doc", Category: "incident_report"},
+			{ID: "medical-doc", Category: "medical_record"},
+		} {
+			document.UploadedBy, document.Filename, document.OriginalFilename = "test-user", document.ID+".pdf", document.ID+".pdf"
+			document.Title, document.FileType, document.FileSize, document.FilePath = document.ID, "application/pdf", 100, "uploads/"+document.ID
+			handler.DB.Create(&document)
+		}
+
+		w, _ := doRequest(handler, router, "POST", "/api/v1/incidents/"+incidentID+"/documents", map[string]interface{}{"document_id": "medical-doc"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, response := doRequest(handler, router, "POST", "/api/v1/incidents/"+incidentID+"/documents", map[string]interface{}{"document_id": "incident-doc"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"].(map[string]interface{})["documents"], 1)
+	})
+
+	t.Run("Incident closes once the workflow is complete", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/incidents/"+incidentID+"/steps", map[string]interface{}{
+			"description": "Review bathroom safety rails",
+			"assigned_to": "test-user",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		stepID := response["data"].(map[string]interface{})["id"].(string)
+
+		w, response = doRequest(handler, router, "PATCH", "/api/v1/incidents/"+incidentID+"/status", map[string]interface{}{"status": "closed", "outcome": "Rails installed"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Len(t, response["error"].(map[string]interface{})["details"], 3)
+
+		doRequest(handler, router, "PATCH", "/api/v1/incidents/"+incidentID+"/steps/"+stepID, map[string]interface{}{"findings": "Rail was loose", "completed": true})
+		w, _ = doRequest(handler, router, "POST", "/api/v1/incidents/"+incidentID+"/notifications", map[string]interface{}{"type": "initial", "reference": "NDIS-0001"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/incidents/"+incidentID+"/notifications", map[string]interface{}{"type": "final"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, response = doRequest(handler, router, "PATCH", "/api/v1/incidents/"+incidentID+"/status", map[string]interface{}{"status": "closed", "outcome": "Rails installed"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "closed", response["data"].(map[string]interface{})["status"])
+	})
+}
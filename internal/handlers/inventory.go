@@ -317,8 +317,17 @@ func (h *Handler) AdjustInventory(c *gin.Context) {
 		return
 	}
 
+	// Stock is valued at the cost given, or else the product's cost price
+	unitCost := req.UnitCost
+	if unitCost == 0 {
+		unitCost = product.CostPrice
+	}
+
+	tx := h.DB.Begin()
+
 	// Update product stock
-	if err := h.DB.Model(&product).Update("current_stock", newQuantity).Error; err != nil {
+	if err := tx.Model(&product).Update("current_stock", newQuantity).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update stock"})
 		return
 	}
@@ -338,11 +347,23 @@ func (h *Handler) AdjustInventory(c *gin.Context) {
 		CreatedBy:        c.GetString("user_id"),
 	}
 
-	if err := h.DB.Create(&movement).Error; err != nil {
+	if err := tx.Create(&movement).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movement record"})
 		return
 	}
 
+	if err := h.postInventoryAdjustment(tx, &movement, unitCost); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post adjustment to the ledger"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust inventory"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":           "Inventory adjusted successfully",
 		"previous_quantity": previousQuantity,
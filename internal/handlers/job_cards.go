@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/pdf"
+	"gorm.io/gorm"
+)
+
+// jobCard is everything the workshop needs for a booking: who and what it's
+// for, the work booked, and the parts and labour used
+type jobCard struct {
+	Booking     models.Booking       `json:"booking"`
+	Odometer    *int                 `json:"odometer,omitempty"` // Reading taken when the vehicle came in
+	Items       []models.JobCardItem `json:"items"`
+	PartsTotal  float64              `json:"parts_total"`
+	LabourTotal float64              `json:"labour_total"`
+}
+
+// loadJobCard builds the job card for one of the organization's bookings
+func (h *Handler) loadJobCard(c *gin.Context, orgID interface{}) (*jobCard, bool) {
+	var card jobCard
+	err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Preload("Customer").Preload("Vehicle").Preload("Staff").Preload("Services").
+		Preload("Steps", orderedSteps).Preload("Steps.Service").Preload("Steps.Staff").
+		First(&card.Booking).Error
+	if err == nil {
+		err = h.DB.Where("booking_id = ?", card.Booking.ID).Preload("Product").Preload("Staff").
+			Order("created_at ASC").Find(&card.Items).Error
+	}
+	if err == nil && card.Booking.VehicleID != nil {
+		var reading models.OdometerReading
+		err = h.DB.Where("booking_id = ?", card.Booking.ID).Order("recorded_at DESC").First(&reading).Error
+		if err == nil {
+			card.Odometer = &reading.Reading
+		} else if err == gorm.ErrRecordNotFound {
+			err = nil
+		}
+	}
+	if err != nil {
+		status, code, message := http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch job card"
+		if err == gorm.ErrRecordNotFound {
+			status, code, message = http.StatusNotFound, "BOOKING_NOT_FOUND", "Booking not found"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    code,
+				"message": message,
+			},
+		})
+		return nil, false
+	}
+
+	for _, item := range card.Items {
+		if item.Type == "part" {
+			card.PartsTotal += item.Total
+		} else {
+			card.LabourTotal += item.Total
+		}
+	}
+	card.PartsTotal, card.LabourTotal = roundCurrency(card.PartsTotal), roundCurrency(card.LabourTotal)
+	return &card, true
+}
+
+// GetJobCard returns a booking's job card
+func (h *Handler) GetJobCard(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	card, ok := h.loadJobCard(c, orgID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    card,
+	})
+}
+
+// AddJobCardItem records a part fitted or labour done on a booking. Parts are
+// taken out of stock and priced at the product's selling price unless a
+// price is given.
+func (h *Handler) AddJobCardItem(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req struct {
+		Type        string   `json:"type" binding:"required,oneof=part labour"`
+		ProductID   *string  `json:"product_id"`
+		StaffID     *string  `json:"staff_id"`
+		Description string   `json:"description"`
+		Quantity    float64  `json:"quantity" binding:"required,gt=0"`
+		UnitPrice   *float64 `json:"unit_price"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	invalid := func(message string) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_ITEM",
+				"message": message,
+			},
+		})
+	}
+
+	var booking models.Booking
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&booking).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BOOKING_NOT_FOUND",
+				"message": "Booking not found",
+			},
+		})
+		return
+	}
+	if booking.Status == "cancelled" || booking.Status == "no_show" {
+		invalid(fmt.Sprintf("Booking is %s", booking.Status))
+		return
+	}
+
+	item := models.JobCardItem{
+		OrganizationID: booking.OrganizationID,
+		BookingID:      booking.ID,
+		Type:           req.Type,
+		Description:    strings.TrimSpace(req.Description),
+		Quantity:       req.Quantity,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if req.UnitPrice != nil {
+		if *req.UnitPrice < 0 {
+			invalid("Unit price can't be negative")
+			return
+		}
+		item.UnitPrice = *req.UnitPrice
+	}
+
+	var product models.Product
+	switch req.Type {
+	case "part":
+		if req.ProductID == nil {
+			invalid("Parts need a product")
+			return
+		}
+		if req.Quantity != math.Trunc(req.Quantity) {
+			invalid("Parts are used in whole units")
+			return
+		}
+		if err := h.DB.Where("id = ? AND organization_id = ?", *req.ProductID, orgID).First(&product).Error; err != nil {
+			invalid("Invalid product ID")
+			return
+		}
+		item.ProductID = &product.ID
+		if item.Description == "" {
+			item.Description = product.Name
+		}
+		if req.UnitPrice == nil {
+			item.UnitPrice = product.SellingPrice
+		}
+	case "labour":
+		if item.Description == "" {
+			invalid("Labour needs a description")
+			return
+		}
+		if req.StaffID != nil {
+			var count int64
+			h.DB.Model(&models.User{}).Where("id = ? AND organization_id = ?", *req.StaffID, orgID).Count(&count)
+			if count == 0 {
+				invalid("Invalid staff ID")
+				return
+			}
+			item.StaffID = req.StaffID
+		}
+	}
+	item.Total = roundCurrency(item.Quantity * item.UnitPrice)
+
+	outOfStock := false
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if item.Type == "part" {
+			quantity := int(item.Quantity)
+			// Only take stock that's there, even with two parts added at once
+			result := tx.Model(&models.Product{}).Where("id = ? AND current_stock >= ?", product.ID, quantity).
+				UpdateColumn("current_stock", gorm.Expr("current_stock - ?", quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				outOfStock = true
+				return nil
+			}
+			if err := tx.Where("id = ?", product.ID).First(&product).Error; err != nil {
+				return err
+			}
+			movement := models.InventoryMovement{
+				OrganizationID:   booking.OrganizationID,
+				ProductID:        product.ID,
+				MovementType:     "out",
+				Quantity:         quantity,
+				PreviousQuantity: product.CurrentStock + quantity,
+				NewQuantity:      product.CurrentStock,
+				UnitCost:         product.CostPrice,
+				Reference:        booking.ID,
+				ReferenceType:    "booking",
+				Notes:            "Used on job card",
+				CreatedBy:        item.CreatedBy,
+			}
+			if err := tx.Create(&movement).Error; err != nil {
+				return err
+			}
+			item.InventoryMovementID = &movement.ID
+		}
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to add job card item",
+			},
+		})
+		return
+	}
+	if outOfStock {
+		invalid("Insufficient stock")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    item,
+		"message": "Job card item added",
+	})
+}
+
+// DeleteJobCardItem removes a part or labour from a booking's job card,
+// putting parts back into stock
+func (h *Handler) DeleteJobCardItem(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var item models.JobCardItem
+	if err := h.DB.Where("id = ? AND booking_id = ? AND organization_id = ?", c.Param("itemId"), c.Param("id"), orgID).
+		First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ITEM_NOT_FOUND",
+				"message": "Job card item not found",
+			},
+		})
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if item.ProductID != nil && item.InventoryMovementID != nil {
+			quantity := int(item.Quantity)
+			if err := tx.Model(&models.Product{}).Where("id = ?", *item.ProductID).
+				UpdateColumn("current_stock", gorm.Expr("current_stock + ?", quantity)).Error; err != nil {
+				return err
+			}
+			var product models.Product
+			if err := tx.Where("id = ?", *item.ProductID).First(&product).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&models.InventoryMovement{
+				OrganizationID:   item.OrganizationID,
+				ProductID:        product.ID,
+				MovementType:     "in",
+				Quantity:         quantity,
+				PreviousQuantity: product.CurrentStock - quantity,
+				NewQuantity:      product.CurrentStock,
+				UnitCost:         product.CostPrice,
+				Reference:        item.BookingID,
+				ReferenceType:    "booking",
+				Notes:            "Returned from job card",
+				CreatedBy:        c.GetString("user_id"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&item).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to remove job card item",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Job card item removed",
+	})
+}
+
+// DownloadJobCard returns a booking's job card as a PDF to print for the workshop
+func (h *Handler) DownloadJobCard(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	card, ok := h.loadJobCard(c, orgID)
+	if !ok {
+		return
+	}
+
+	number := card.Booking.ID
+	if len(number) > 8 {
+		number = number[:8]
+	}
+	number = "JC-" + strings.ToUpper(number)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", "attachment; filename="+number+".pdf")
+	c.Status(http.StatusOK)
+
+	head := h.letterhead(orgID, "JOB CARD", number)
+	if err := renderJobCardPDF(c.Writer, head, card, h.organizationLocation(orgID)); err != nil {
+		c.Error(err)
+	}
+}
+
+// jobCardItemTypes labels item types on printed job cards
+var jobCardItemTypes = map[string]string{"part": "Part", "labour": "Labour"}
+
+// renderJobCardPDF writes a branded job card
+func renderJobCardPDF(w io.Writer, head pdf.Letterhead, card *jobCard, loc *time.Location) error {
+	doc := pdf.NewBranded(w, head)
+	y := doc.NewPage()
+	right := doc.Width - 40
+	booking := &card.Booking
+
+	// Customer and vehicle
+	customer := booking.Customer
+	doc.SetFillColor(pdf.Gray)
+	doc.SetFont(true, 8)
+	doc.Text(40, y, "CUSTOMER")
+	doc.SetFillColor(pdf.Black)
+	doc.SetFont(true, 11)
+	doc.Text(40, y+15, strings.TrimSpace(customer.FirstName+" "+customer.LastName))
+	doc.SetFont(false, 9)
+	lines := nonEmpty(customer.Phone, customer.Email)
+	if vehicle := booking.Vehicle; vehicle != nil {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%d %s %s", vehicle.Year, vehicle.Make, vehicle.Model)))
+		if vehicle.LicensePlate != "" {
+			lines = append(lines, "Rego "+vehicle.LicensePlate)
+		}
+		if vehicle.VIN != "" {
+			lines = append(lines, "VIN "+vehicle.VIN)
+		}
+		if card.Odometer != nil {
+			lines = append(lines, fmt.Sprintf("Odometer %d km", *card.Odometer))
+		}
+	}
+	for i, line := range lines {
+		doc.Text(40, y+29+float64(i)*12, line)
+	}
+
+	// Booking details
+	staff := ""
+	if booking.Staff != nil {
+		staff = strings.TrimSpace(booking.Staff.FirstName + " " + booking.Staff.LastName)
+	}
+	details := [][2]string{
+		{"Date", booking.StartTime.In(loc).Format("02/01/2006")},
+		{"Time", booking.StartTime.In(loc).Format("3:04 PM") + " - " + booking.EndTime.In(loc).Format("3:04 PM")},
+		{"Technician", staff},
+		{"Status", strings.ToUpper(strings.ReplaceAll(booking.Status, "_", " "))},
+	}
+	for i, detail := range details {
+		rowY := y + 15 + float64(i)*13
+		doc.SetFillColor(pdf.Gray)
+		doc.SetFont(false, 9)
+		doc.TextRight(right-110, rowY, detail[0])
+		doc.SetFillColor(pdf.Black)
+		doc.SetFont(true, 9)
+		doc.TextRight(right, rowY, detail[1])
+	}
+	y += 100
+
+	// Work booked
+	work := doc.NewTable(y, []pdf.Column{
+		{Header: "Work", Width: 4},
+		{Header: "Start", Width: 1.2},
+		{Header: "Technician", Width: 2},
+		{Header: "Done", Width: 0.8},
+	})
+	if len(booking.Steps) > 0 {
+		for _, step := range booking.Steps {
+			name, who := "", ""
+			if step.Service != nil {
+				name = step.Service.Name
+			}
+			if step.Staff != nil {
+				who = strings.TrimSpace(step.Staff.FirstName + " " + step.Staff.LastName)
+			}
+			work.Row([]string{name, step.StartTime.In(loc).Format("3:04 PM"), who, "[  ]"})
+		}
+	} else {
+		for _, service := range booking.Services {
+			work.Row([]string{service.Name, "", staff, "[  ]"})
+		}
+	}
+	y = work.Ensure(60) + 20
+
+	// Parts and labour
+	items := doc.NewTable(y, []pdf.Column{
+		{Header: "Type", Width: 1},
+		{Header: "Description", Width: 4},
+		{Header: "Qty", Width: 1, Align: pdf.AlignRight},
+		{Header: "Unit Price", Width: 1.3, Align: pdf.AlignRight},
+		{Header: "Total", Width: 1.3, Align: pdf.AlignRight},
+	})
+	for _, item := range card.Items {
+		items.Row([]string{
+			jobCardItemTypes[item.Type],
+			item.Description,
+			pdf.FormatAmount(item.Quantity),
+			pdf.FormatAmount(item.UnitPrice),
+			pdf.FormatAmount(item.Total),
+		})
+	}
+
+	// Totals
+	y = items.Ensure(70) + 14
+	totals := [][2]string{
+		{"Parts", "$" + pdf.FormatAmount(card.PartsTotal)},
+		{"Labour", "$" + pdf.FormatAmount(card.LabourTotal)},
+		{"Total", "$" + pdf.FormatAmount(roundCurrency(card.PartsTotal+card.LabourTotal))},
+	}
+	for i, total := range totals {
+		doc.SetFont(i == len(totals)-1, 10)
+		doc.TextRight(right-110, y, total[0])
+		doc.TextRight(right, y, total[1])
+		y += 16
+	}
+
+	if booking.InternalNotes != "" || booking.Notes != "" {
+		y += 10
+		doc.SetFont(true, 9)
+		doc.Text(40, y, "Notes")
+		doc.SetFont(false, 9)
+		for i, note := range nonEmpty(booking.Notes, booking.InternalNotes) {
+			doc.Text(40, y+14+float64(i)*12, doc.Fit(note, doc.ContentWidth()))
+		}
+	}
+
+	return doc.Close()
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBankReconciliation(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{},
+		&finance.GeneralLedger{}, &finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountMapping{},
+		&finance.LedgerPosting{}, &finance.AccountingPeriod{}, &finance.Payment{}, &finance.BankAccount{},
+		&finance.BankTransaction{}, &finance.BankStatementImport{})
+	ledger := finance.NewService(handler.DB)
+
+	upload := func(bankAccountID, fileName, content string, fields map[string]string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", fileName)
+		part.Write([]byte(content))
+		for name, value := range fields {
+			writer.WriteField(name, value)
+		}
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/ledger/bank-accounts/"+bankAccountID+"/import", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		data, _ := response["data"].(map[string]interface{})
+		return w, data
+	}
+	createBankAccount := func(name string) string {
+		w, response := doRequest(handler, router, "POST", "/api/v1/ledger/bank-accounts", map[string]interface{}{
+			"account_name": name, "account_number": "123456", "bank_name": "Westpac",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		return response["data"].(map[string]interface{})["id"].(string)
+	}
+	day := func(d int) time.Time { return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC) }
+
+	// The ledger side: a customer payment, a day's card takings, a bank fee
+	// and a cheque to a supplier that hasn't been presented yet
+	receipt := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", Amount: 250, PaymentDate: day(6),
+		PaymentMethod: "bank_transfer", Reference: "INV-1001"}
+	assert.NoError(t, ledger.CreatePayment(&receipt))
+	for id, sale := range map[string]struct {
+		method string
+		amount float64
+	}{"sale-1": {"card", 40}, "sale-2": {"eftpos", 60}} {
+		posting := finance.Posting{SourceType: finance.SourcePOSSale, SourceID: id, Date: day(7).Add(10 * time.Hour)}
+		posting.Debit(finance.TenderAccount(sale.method), "", sale.amount)
+		posting.Credit(finance.AccountSales, "", sale.amount)
+		_, err := ledger.Post(nil, "test-org", posting)
+		assert.NoError(t, err)
+	}
+	fee := finance.Posting{SourceType: "test", SourceID: "fee", Date: day(20), Description: "Bank fee"}
+	fee.Debit(finance.AccountExpenses, "Bank fee", 5)
+	fee.Credit(finance.AccountBank, "Bank fee", 5)
+	_, err := ledger.Post(nil, "test-org", fee)
+	assert.NoError(t, err)
+	cheque := finance.Payment{OrganizationID: "test-org", Type: "vendor_payment", Amount: 80, PaymentDate: day(25),
+		PaymentMethod: "check", Reference: "CHQ 301"}
+	assert.NoError(t, ledger.CreatePayment(&cheque))
+
+	bankAccount := createBankAccount("Operating")
+	statement := "Date,Description,Reference,Amount,Balance\n" +
+		"06/01/2025,Transfer from customer INV-1001,,250.00,250.00\n" +
+		"08/01/2025,Merchant settlement,,100.00,350.00\n" +
+		"20/01/2025,Account fee,,-5.00,345.00\n" +
+		"28/01/2025,Interest,,0.50,345.50\n"
+
+	t.Run("Statements import once", func(t *testing.T) {
+		w, response := upload(bankAccount, "january.csv", statement, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 4.0, response["import"].(map[string]interface{})["imported"])
+
+		w, response = upload(bankAccount, "january.csv", statement, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 0.0, response["import"].(map[string]interface{})["imported"])
+		assert.Equal(t, 4.0, response["import"].(map[string]interface{})["duplicates"])
+
+		w, _ = upload(bankAccount, "broken.csv", "When,What\n06/01/2025,Something\n", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Oversized files are refused rather than cut short
+		w, _ = upload(bankAccount, "huge.csv", statement+strings.Repeat(" ", 10<<20), nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("OFX and QIF statements", func(t *testing.T) {
+		savings := createBankAccount("Savings")
+		ofx := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20250110120000[+10:AEST]<TRNAMT>1000.00<FITID>A1<NAME>Opening deposit</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20250112<TRNAMT>-19.95<FITID>A2<NAME>Coffee &amp; Co<MEMO>EFTPOS
+</BANKTRANLIST><LEDGERBAL><BALAMT>980.05<DTASOF>20250131</LEDGERBAL></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+		w, response := upload(savings, "savings.ofx", ofx, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		transactions := response["transactions"].([]interface{})
+		assert.Len(t, transactions, 2)
+		assert.Equal(t, "Coffee & Co EFTPOS", transactions[1].(map[string]interface{})["description"])
+		assert.Equal(t, 980.05, transactions[1].(map[string]interface{})["balance"])
+
+		// QIF dates are month first unless the file says otherwise, and two
+		// identical transactions on a day are both kept
+		qif := "!Type:Bank\nD02/03/2025\nT-12.00\nPParking\n^\nD02/03/2025\nT-12.00\nPParking\n^\n"
+		w, response = upload(savings, "march.qif", qif, nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2.0, response["import"].(map[string]interface{})["imported"])
+		assert.Equal(t, "2025-02-03", response["transactions"].([]interface{})[0].(map[string]interface{})["date"].(string)[:10])
+		w, response = upload(savings, "march.qif", qif, map[string]string{"date_format": "DD/MM/YYYY"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2.0, response["import"].(map[string]interface{})["imported"])
+		// FITIDs catch OFX exports that overlap
+		w, response = upload(savings, "overlap.ofx", ofx, nil)
+		assert.Equal(t, 2.0, response["import"].(map[string]interface{})["duplicates"])
+	})
+
+	suggestions := make(map[float64]map[string]interface{})
+	t.Run("Matches are suggested by amount, date and reference", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/bank-accounts/"+bankAccount+"/matches", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		for _, s := range response["data"].([]interface{}) {
+			suggestion := s.(map[string]interface{})
+			matches := suggestion["matches"].([]interface{})
+			amount := suggestion["transaction"].(map[string]interface{})["amount"].(float64)
+			if len(matches) > 0 {
+				suggestions[amount] = matches[0].(map[string]interface{})
+			}
+		}
+		assert.Len(t, suggestions, 3)
+		assert.Equal(t, "payment", suggestions[250]["type"])
+		assert.Equal(t, receipt.ID, suggestions[250]["id"])
+		assert.Equal(t, 100.0, suggestions[250]["score"])
+		assert.Equal(t, "pos_settlement", suggestions[100]["type"])
+		assert.Equal(t, "2025-01-07", suggestions[100]["id"])
+		assert.Equal(t, "journal_line", suggestions[-5]["type"])
+	})
+
+	t.Run("Reconciling needs matching amounts", func(t *testing.T) {
+		transactions := make(map[float64]string)
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/bank-accounts/"+bankAccount+"/transactions?reconciled=false", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		for _, tx := range response["data"].([]interface{}) {
+			transaction := tx.(map[string]interface{})
+			transactions[transaction["amount"].(float64)] = transaction["id"].(string)
+		}
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/bank-transactions/"+transactions[0.5]+"/reconcile",
+			map[string]interface{}{"match_type": "payment", "match_id": receipt.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		for _, amount := range []float64{250, 100, -5} {
+			w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/bank-transactions/"+transactions[amount]+"/reconcile",
+				map[string]interface{}{"match_type": suggestions[amount]["type"], "match_id": suggestions[amount]["id"]})
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/bank-transactions/"+transactions[250]+"/reconcile",
+			map[string]interface{}{"match_type": "payment", "match_id": receipt.ID})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/ledger/bank-accounts/"+bankAccount+"/matches", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"].([]interface{}), 1)
+	})
+
+	t.Run("The report explains the difference", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/bank-accounts/"+bankAccount+"/reconciliation?as_of_date=2025-01-31", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		report := response["data"].(map[string]interface{})
+		assert.Equal(t, 345.5, report["statement_balance"])
+		assert.Equal(t, 265.0, report["ledger_balance"])
+		assert.Equal(t, 80.5, report["difference"])
+		assert.Len(t, report["unreconciled_statement_items"], 1)
+		assert.Len(t, report["unreconciled_ledger_items"], 1)
+		assert.Equal(t, 0.5, report["unreconciled_statement_total"])
+		assert.Equal(t, -80.0, report["unreconciled_ledger_total"])
+		assert.Equal(t, 0.0, report["unexplained_difference"])
+		assert.Equal(t, true, report["reconciled"])
+
+		// Before the card takings were banked, the settlement is outstanding
+		// on both sides
+		w, response = doRequest(handler, router, "GET", "/api/v1/ledger/bank-accounts/"+bankAccount+"/reconciliation?as_of_date=2025-01-07", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		report = response["data"].(map[string]interface{})
+		assert.Equal(t, 250.0, report["statement_balance"])
+		assert.Equal(t, 350.0, report["ledger_balance"])
+		assert.Equal(t, 100.0, report["unreconciled_ledger_total"])
+		assert.Equal(t, true, report["reconciled"])
+	})
+}
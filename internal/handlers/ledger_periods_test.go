@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountingPeriods(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{},
+		&finance.GeneralLedger{}, &finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountMapping{},
+		&finance.LedgerPosting{}, &finance.AccountingPeriod{})
+	ledger := finance.NewService(handler.DB)
+
+	post := func(id string, date time.Time, debit, credit string, amount float64) (*finance.JournalEntry, error) {
+		posting := finance.Posting{SourceType: "test", SourceID: id, Date: date, Description: id}
+		posting.Debit(debit, "", amount)
+		posting.Credit(credit, "", amount)
+		return ledger.Post(nil, "test-org", posting)
+	}
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	// trialBalance maps account codes to their debit (positive) or credit
+	// (negative) balance
+	trialBalance := func(query string) map[string]float64 {
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/reports/trial-balance?"+query, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		report := response["data"].(map[string]interface{})
+		assert.Equal(t, report["total_debits"], report["total_credits"])
+		balances := make(map[string]float64)
+		for _, a := range report["accounts"].([]interface{}) {
+			account := a.(map[string]interface{})
+			balances[account["account_code"].(string)] = account["debit_balance"].(float64) - account["credit_balance"].(float64)
+		}
+		return balances
+	}
+
+	var january, february string
+	t.Run("Periods can't overlap", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/ledger/periods", map[string]interface{}{"name": "Jan 2025", "start_date": "2025-01-01", "end_date": "2025-01-31"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		january = response["data"].(map[string]interface{})["id"].(string)
+		w, response = doRequest(handler, router, "POST", "/api/v1/ledger/periods", map[string]interface{}{"name": "Feb 2025", "start_date": "2025-02-01", "end_date": "2025-02-28"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		february = response["data"].(map[string]interface{})["id"].(string)
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods", map[string]interface{}{"name": "Mid", "start_date": "2025-01-15", "end_date": "2025-02-15"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods", map[string]interface{}{"name": "Backwards", "start_date": "2025-04-30", "end_date": "2025-04-01"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	var sale *finance.JournalEntry
+	t.Run("Closed periods can't be posted to", func(t *testing.T) {
+		var err error
+		sale, err = post("sale", day(time.January, 10).Add(15*time.Hour), finance.AccountCash, finance.AccountSales, 100)
+		assert.NoError(t, err)
+
+		// Periods close in order
+		w, _ := doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+february+"/close", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/close", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, err = post("late", day(time.January, 31).Add(23*time.Hour), finance.AccountCash, finance.AccountSales, 5)
+		assert.True(t, errors.Is(err, finance.ErrPeriodClosed))
+		_, err = post("early", day(time.January, 1).AddDate(0, 0, -5), finance.AccountCash, finance.AccountSales, 5)
+		assert.True(t, errors.Is(err, finance.ErrPeriodClosed))
+
+		draft := finance.JournalEntry{OrganizationID: "test-org", Date: day(time.January, 20), Status: "draft"}
+		assert.NoError(t, ledger.CreateJournalEntry(&draft))
+		assert.True(t, errors.Is(ledger.PostJournalEntry(draft.ID, "test-user"), finance.ErrPeriodClosed))
+
+		_, err = post("expense", day(time.February, 5), finance.AccountExpenses, finance.AccountCash, 30)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Trial balances for closed periods don't change", func(t *testing.T) {
+		balances := trialBalance("period_id=" + january)
+		assert.Equal(t, 100.0, balances["1000"])
+		assert.Equal(t, -100.0, balances["4000"])
+		assert.Equal(t, 0.0, balances["5000"])
+
+		balances = trialBalance("as_of_date=2025-02-28")
+		assert.Equal(t, 70.0, balances["1000"])
+		assert.Equal(t, 30.0, balances["5000"])
+	})
+
+	t.Run("Corrections are reversing entries in an open period", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/ledger/journal-entries/"+sale.ID+"/reverse", map[string]interface{}{"date": "2025-01-20"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/journal-entries/"+sale.ID+"/reverse", map[string]interface{}{"date": "2025-02-10"})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/journal-entries/"+sale.ID+"/reverse", map[string]interface{}{"date": "2025-02-10"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var reversed int64
+		handler.DB.Model(&finance.GeneralLedger{}).Where("journal_entry_id = ? AND reversed = ?", sale.ID, true).Count(&reversed)
+		assert.Equal(t, int64(2), reversed)
+		assert.Equal(t, -100.0, trialBalance("period_id=" + january)["4000"])
+		assert.Equal(t, 0.0, trialBalance("as_of_date=2025-02-28")["4000"])
+	})
+
+	t.Run("Locked periods can't be reopened", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/reopen", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/lock", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/close", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/lock", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "POST", "/api/v1/ledger/periods/"+january+"/reopen", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Year-end close moves profit to retained earnings", func(t *testing.T) {
+		_, err := post("sale-2", day(time.February, 12), finance.AccountCash, finance.AccountSales, 250)
+		assert.NoError(t, err)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/ledger/year-end-close", map[string]interface{}{"start_date": "2025-01-01", "end_date": "2025-02-28"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		closing := response["data"].(map[string]interface{})["id"]
+
+		balances := trialBalance("as_of_date=2025-02-28")
+		assert.Equal(t, 0.0, balances["4000"])
+		assert.Equal(t, 0.0, balances["5000"])
+		assert.Equal(t, -220.0, balances["3200"])
+		assert.Equal(t, 220.0, balances["1000"])
+
+		var period finance.AccountingPeriod
+		handler.DB.First(&period, "id = ?", february)
+		assert.Equal(t, "closed", period.Status)
+
+		// Closing again gives back the same entry
+		w, response = doRequest(handler, router, "POST", "/api/v1/ledger/year-end-close", map[string]interface{}{"start_date": "2025-01-01", "end_date": "2025-02-28"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, closing, response["data"].(map[string]interface{})["id"])
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"gorm.io/gorm"
+)
+
+// Sales, voids and stock movements are posted to the general ledger in the
+// same database transaction that records them, so a document can't be saved
+// without its journal entry or posted twice. Accounts come from the
+// organization's account mappings.
+
+// postPOSSale posts a completed sale: what was tendered against sales and GST
+// collected, and the cost of the goods sold out of inventory
+func (h *Handler) postPOSSale(tx *gorm.DB, transaction *models.POSTransaction, payments []models.POSPayment, costOfSales float64) error {
+	posting := finance.Posting{
+		SourceType:  finance.SourcePOSSale,
+		SourceID:    transaction.ID,
+		Date:        transaction.CreatedAt,
+		Description: "POS sale " + transaction.TransactionNumber,
+		Reference:   transaction.TransactionNumber,
+		PostedBy:    transaction.CashierID,
+	}
+	for _, payment := range payments {
+		posting.Debit(finance.TenderAccount(payment.Method), "Tendered by "+payment.Method, payment.Amount)
+	}
+	// Change comes out of the till; anything left unpaid is owed by the customer
+	if transaction.ChangeAmount > 0 {
+		posting.Credit(finance.TenderAccount("cash"), "Change given", transaction.ChangeAmount)
+	} else {
+		posting.Debit(finance.AccountReceivable, "Balance owing", -transaction.ChangeAmount)
+	}
+	posting.Credit(finance.AccountSales, "", transaction.SubTotal-transaction.DiscountAmount)
+	posting.Credit(finance.AccountGSTCollected, "", transaction.TaxAmount)
+	posting.Debit(finance.AccountCostOfSales, "", costOfSales)
+	posting.Credit(finance.AccountInventory, "", costOfSales)
+
+	_, err := finance.NewService(h.DB).Post(tx, transaction.OrganizationID, posting)
+	return err
+}
+
+// postPOSVoid reverses the entry a sale was posted as
+func (h *Handler) postPOSVoid(tx *gorm.DB, transaction *models.POSTransaction, userID string) error {
+	ledger := finance.NewService(h.DB)
+	reversal, err := ledger.Reversal(tx, transaction.OrganizationID, finance.SourcePOSSale, transaction.ID)
+	if err != nil || reversal == nil {
+		return err
+	}
+	reversal.SourceType, reversal.SourceID = finance.SourcePOSVoid, transaction.ID
+	reversal.Description = "POS void " + transaction.TransactionNumber
+	reversal.Reference, reversal.PostedBy = transaction.TransactionNumber, userID
+	_, err = ledger.Post(tx, transaction.OrganizationID, *reversal)
+	return err
+}
+
+// postStockReceipt posts stock received against a purchase order to
+// inventory and goods received not invoiced. The supplier's bill clears it
+// to accounts payable. Each receipt is keyed on its first movement, as an
+// order can be received in several deliveries.
+func (h *Handler) postStockReceipt(tx *gorm.DB, order *models.PurchaseOrder, movements []models.InventoryMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	var value float64
+	for _, movement := range movements {
+		value += float64(movement.Quantity) * movement.UnitCost
+	}
+	posting := finance.Posting{
+		SourceType:  finance.SourcePurchaseReceipt,
+		SourceID:    movements[0].ID,
+		Date:        movements[0].CreatedAt,
+		Description: "Stock received on " + order.OrderNumber,
+		Reference:   order.OrderNumber,
+		PostedBy:    movements[0].CreatedBy,
+	}
+	posting.Debit(finance.AccountInventory, "", value)
+	posting.Credit(finance.AccountGoodsReceived, "", value)
+
+	_, err := finance.NewService(h.DB).Post(tx, order.OrganizationID, posting)
+	return err
+}
+
+// postInventoryAdjustment posts the value of stock gained or written off by
+// a manual adjustment
+func (h *Handler) postInventoryAdjustment(tx *gorm.DB, movement *models.InventoryMovement, unitCost float64) error {
+	value := float64(movement.NewQuantity-movement.PreviousQuantity) * unitCost
+	posting := finance.Posting{
+		SourceType:  finance.SourceInventoryAdjustment,
+		SourceID:    movement.ID,
+		Date:        movement.CreatedAt,
+		Description: "Stock adjustment",
+		Reference:   movement.Reference,
+		PostedBy:    movement.CreatedBy,
+	}
+	// A negative value writes stock off
+	posting.Debit(finance.AccountInventory, "", value)
+	posting.Credit(finance.AccountInventoryAdjustments, "", value)
+
+	_, err := finance.NewService(h.DB).Post(tx, movement.OrganizationID, posting)
+	return err
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerPostings(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Product{}, &models.InventoryMovement{}, &models.POSTransaction{}, &models.POSItem{},
+		&models.POSPayment{}, &models.Supplier{}, &models.PurchaseOrder{}, &models.PurchaseOrderItem{},
+		&finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{}, &finance.GeneralLedger{},
+		&finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountMapping{}, &finance.LedgerPosting{},
+		&finance.Invoice{}, &finance.InvoiceLineItem{}, &finance.Bill{}, &finance.BillLineItem{}, &finance.Payment{},
+		&finance.PaymentAllocation{}, &finance.CreditNote{},
+		&finance.AccountingPeriod{})
+	ledger := finance.NewService(handler.DB)
+
+	product := models.Product{OrganizationID: "test-org", Name: "Wiper Blades", CostPrice: 6, SellingPrice: 10, CurrentStock: 10}
+	handler.DB.Create(&product)
+
+	// balance is an account's debits less its credits
+	balance := func(code string) float64 {
+		var total float64
+		handler.DB.Table("journal_entry_lines").
+			Select("COALESCE(SUM(journal_entry_lines.debit_amount - journal_entry_lines.credit_amount), 0)").
+			Joins("JOIN chart_of_accounts ON chart_of_accounts.id = journal_entry_lines.chart_of_account_id").
+			Where("chart_of_accounts.code = ?", code).
+			Scan(&total)
+		return total
+	}
+	sell := func(quantity int, method string, amount float64) string {
+		w, response := doRequest(handler, router, "POST", "/api/v1/pos/transactions", map[string]interface{}{
+			"items":    []map[string]interface{}{{"product_id": product.ID, "quantity": quantity, "tax_rate": 10}},
+			"payments": []map[string]interface{}{{"method": method, "amount": amount}},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		return response["transaction"].(map[string]interface{})["id"].(string)
+	}
+
+	var cardSale string
+	t.Run("POS sales are posted with their cost of sales", func(t *testing.T) {
+		cardSale = sell(2, "card", 22)
+		assert.Equal(t, 22.0, balance("1100"))
+		assert.Equal(t, -20.0, balance("4000"))
+		assert.Equal(t, -2.0, balance("2200"))
+		assert.Equal(t, 12.0, balance("5200"))
+		assert.Equal(t, -12.0, balance("1300"))
+
+		// Change comes back out of the till
+		sell(1, "cash", 20)
+		assert.Equal(t, 11.0, balance("1000"))
+		assert.Equal(t, -30.0, balance("4000"))
+	})
+
+	t.Run("Voids reverse the sale", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/pos/transactions/"+cardSale+"/void", map[string]interface{}{"reason": "Wrong size"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0.0, balance("1100"))
+		assert.Equal(t, -10.0, balance("4000"))
+		assert.Equal(t, -6.0, balance("1300"))
+
+		var postings int64
+		handler.DB.Model(&finance.LedgerPosting{}).Where("source_id = ?", cardSale).Count(&postings)
+		assert.Equal(t, int64(2), postings)
+	})
+
+	t.Run("Tenders can be mapped to other accounts", func(t *testing.T) {
+		clearing := finance.ChartOfAccount{OrganizationID: "test-org", Code: "1150", Name: "Card Clearing", AccountType: "Asset"}
+		assert.NoError(t, ledger.CreateAccount(&clearing))
+
+		w, _ := doRequest(handler, router, "PUT", "/api/v1/ledger/account-mappings/tender:card", map[string]interface{}{"account_id": clearing.ID})
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/ledger/account-mappings/nonsense", map[string]interface{}{"account_id": clearing.ID})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		sell(1, "card", 11)
+		assert.Equal(t, 11.0, balance("1150"))
+		assert.Equal(t, 0.0, balance("1100"))
+
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/account-mappings", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		found := false
+		for _, m := range response["data"].([]interface{}) {
+			mapping := m.(map[string]interface{})
+			if mapping["key"] == "tender:card" {
+				found = true
+				assert.Equal(t, "1150", mapping["account"].(map[string]interface{})["code"])
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("Stock adjustments and receipts are posted", func(t *testing.T) {
+		before := balance("1300")
+		w, _ := doRequest(handler, router, "POST", "/api/v1/inventory/items/adjust", map[string]interface{}{
+			"product_id": product.ID, "quantity": 1, "movement_type": "out", "notes": "Damaged",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, before-6, balance("1300"))
+		assert.Equal(t, 6.0, balance("5300"))
+
+		supplier := models.Supplier{OrganizationID: "test-org", Name: "Parts Co"}
+		handler.DB.Create(&supplier)
+		order := models.PurchaseOrder{OrganizationID: "test-org", SupplierID: supplier.ID, OrderDate: time.Now(), CreatedBy: "test-user",
+			Items: []models.PurchaseOrderItem{{ProductID: product.ID, Quantity: 10, UnitCost: 5}}}
+		handler.DB.Create(&order)
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/suppliers/purchase-orders/"+order.ID+"/receive", map[string]interface{}{
+			"received_items": []map[string]interface{}{{"item_id": order.Items[0].ID, "quantity_received": 4}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, before+14, balance("1300"))
+		assert.Equal(t, -20.0, balance("2050"))
+		assert.Equal(t, 0.0, balance("2000"))
+	})
+
+	t.Run("Received stock is only counted once when it's billed", func(t *testing.T) {
+		supplier := models.Supplier{OrganizationID: "test-org", Name: "Filters Co"}
+		handler.DB.Create(&supplier)
+		order := models.PurchaseOrder{OrganizationID: "test-org", SupplierID: supplier.ID, OrderDate: time.Now(), CreatedBy: "test-user",
+			Items: []models.PurchaseOrderItem{{ProductID: product.ID, Quantity: 3, UnitCost: 5}}}
+		handler.DB.Create(&order)
+		inventory, grni, payable := balance("1300"), balance("2050"), balance("2000")
+
+		w, _ := doRequest(handler, router, "POST", "/api/v1/suppliers/purchase-orders/"+order.ID+"/receive", map[string]interface{}{
+			"received_items": []map[string]interface{}{{"item_id": order.Items[0].ID, "quantity_received": 3}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		productID := product.ID
+		bill := finance.Bill{OrganizationID: "test-org", VendorID: "vendor", BillDate: time.Now(), DueDate: time.Now(),
+			SubTotal: 15, Total: 15, LineItems: []finance.BillLineItem{
+				{ProductID: &productID, Description: "Blades", Quantity: 3, UnitPrice: 5, LineTotal: 15},
+			}}
+		assert.NoError(t, ledger.CreateBill(&bill))
+
+		assert.Equal(t, inventory+15, balance("1300"))
+		assert.Equal(t, payable-15, balance("2000"))
+		assert.Equal(t, grni, balance("2050"))
+	})
+
+	t.Run("Invoices, bills and payments are posted", func(t *testing.T) {
+		invoice := finance.Invoice{OrganizationID: "test-org", CustomerID: "customer", Status: "sent", IssueDate: time.Now(),
+			DueDate: time.Now(), SubTotal: 100, TaxAmount: 10, Total: 110}
+		assert.NoError(t, ledger.CreateInvoice(&invoice))
+		assert.Equal(t, 110.0, balance("1200"))
+
+		payment := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", InvoiceID: &invoice.ID,
+			Amount: 110, PaymentDate: time.Now(), PaymentMethod: "bank_transfer"}
+		assert.NoError(t, ledger.CreatePayment(&payment))
+		assert.Equal(t, 0.0, balance("1200"))
+
+		productID := product.ID
+		bill := finance.Bill{OrganizationID: "test-org", VendorID: "vendor", BillDate: time.Now(), DueDate: time.Now(),
+			SubTotal: 80, TaxAmount: 8, Total: 88, LineItems: []finance.BillLineItem{
+				{ProductID: &productID, Description: "Blades", Quantity: 10, UnitPrice: 5, LineTotal: 50},
+				{Description: "Freight", Quantity: 1, UnitPrice: 30, LineTotal: 30},
+			}}
+		inventory, grni, payable := balance("1300"), balance("2050"), balance("2000")
+		assert.NoError(t, ledger.CreateBill(&bill))
+		assert.Equal(t, inventory, balance("1300"))
+		assert.Equal(t, grni+50, balance("2050"))
+		assert.Equal(t, 30.0, balance("5000"))
+		assert.Equal(t, 8.0, balance("2210"))
+		assert.Equal(t, payable-88, balance("2000"))
+	})
+
+	t.Run("Documents are only posted once and must balance", func(t *testing.T) {
+		posting := finance.Posting{SourceType: "test", SourceID: "doc-1", Description: "Test"}
+		posting.Debit(finance.AccountCash, "", 5)
+		posting.Credit(finance.AccountSales, "", 5)
+		first, err := ledger.Post(nil, "test-org", posting)
+		assert.NoError(t, err)
+		second, err := ledger.Post(nil, "test-org", posting)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+
+		unbalanced := finance.Posting{SourceType: "test", SourceID: "doc-2"}
+		unbalanced.Debit(finance.AccountCash, "", 5)
+		unbalanced.Credit(finance.AccountSales, "", 4.99)
+		_, err = ledger.Post(nil, "test-org", unbalanced)
+		assert.True(t, errors.Is(err, finance.ErrUnbalanced))
+
+		// The ledger as a whole balances
+		var total float64
+		handler.DB.Table("journal_entry_lines").Select("COALESCE(SUM(debit_amount - credit_amount), 0)").Scan(&total)
+		assert.InDelta(t, 0, total, 0.001)
+	})
+}
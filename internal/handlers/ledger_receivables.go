@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+)
+
+// customerNames gives the ledger's receivables reports the names of the
+// organization's customers, including ones since deleted
+func (h *Handler) customerNames(orgID string, customerIDs []string) (map[string]string, error) {
+	var customers []models.Customer
+	if err := h.DB.Unscoped().Where("organization_id = ? AND id IN ?", orgID, customerIDs).Find(&customers).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(customers))
+	for _, customer := range customers {
+		names[customer.ID] = strings.TrimSpace(customer.FirstName + " " + customer.LastName)
+	}
+	return names, nil
+}
+
+// markOverdueLedgerInvoices moves ledger invoices past their due date to overdue
+func (h *Handler) markOverdueLedgerInvoices(ctx context.Context) error {
+	_, err := finance.NewService(h.DB.WithContext(ctx)).MarkOverdueInvoices(time.Now())
+	return err
+}
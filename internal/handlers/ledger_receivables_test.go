@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceivables(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{},
+		&finance.GeneralLedger{}, &finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountMapping{},
+		&finance.LedgerPosting{}, &finance.AccountingPeriod{}, &finance.Invoice{}, &finance.InvoiceLineItem{},
+		&finance.Payment{}, &finance.PaymentAllocation{}, &finance.CreditNote{})
+	ledger := finance.NewService(handler.DB)
+
+	handler.DB.Create(&models.Customer{ID: "ar-alice", OrganizationID: "test-org", FirstName: "Alice", LastName: "Avery", Phone: "0400111222", IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "ar-bob", OrganizationID: "test-org", FirstName: "Bob", LastName: "Brown", Phone: "0400333444", IsActive: true})
+
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	invoices := make(map[string]*finance.Invoice)
+	raise := func(number, customerID string, issued time.Time, total float64) {
+		invoice := finance.Invoice{OrganizationID: "test-org", InvoiceNumber: number, CustomerID: customerID, Status: "sent",
+			IssueDate: issued, DueDate: issued.AddDate(0, 0, 30), SubTotal: total / 1.1, TaxAmount: total - total/1.1, Total: total}
+		assert.NoError(t, ledger.CreateInvoice(&invoice))
+		invoices[number] = &invoice
+	}
+	reload := func(number string) finance.Invoice {
+		var invoice finance.Invoice
+		handler.DB.First(&invoice, "id = ?", invoices[number].ID)
+		return invoice
+	}
+
+	raise("INV-A1", "ar-alice", day(time.January, 1), 110)
+	raise("INV-A2", "ar-alice", day(time.February, 1), 220)
+	raise("INV-A3", "ar-alice", day(time.March, 1), 55)
+	raise("INV-B1", "ar-bob", day(time.January, 10), 330)
+
+	t.Run("Payments are allocated to invoices", func(t *testing.T) {
+		// Part payment of the first invoice
+		partial := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", Amount: 50, PaymentDate: day(time.February, 10),
+			PaymentMethod: "bank_transfer", InvoiceID: &invoices["INV-A1"].ID}
+		assert.NoError(t, ledger.CreatePayment(&partial))
+		assert.Equal(t, "ar-alice", partial.CustomerID)
+		assert.Equal(t, 50.0, reload("INV-A1").PaidAmount)
+		assert.Equal(t, "sent", reload("INV-A1").Status)
+
+		// More than is owing, or another customer's invoice, is refused
+		tooMuch := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", Amount: 500, PaymentDate: day(time.March, 1),
+			PaymentMethod: "cash", Allocations: []finance.PaymentAllocation{{InvoiceID: invoices["INV-A1"].ID, Amount: 100}}}
+		assert.True(t, errors.Is(ledger.CreatePayment(&tooMuch), finance.ErrInvalidAllocation))
+		wrongCustomer := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", CustomerID: "ar-alice", Amount: 10,
+			PaymentDate: day(time.March, 1), PaymentMethod: "cash", Allocations: []finance.PaymentAllocation{{InvoiceID: invoices["INV-B1"].ID}}}
+		assert.True(t, errors.Is(ledger.CreatePayment(&wrongCustomer), finance.ErrInvalidAllocation))
+		w, _ := doRequest(handler, router, "POST", "/api/v1/ledger/payments", map[string]interface{}{
+			"type": "customer_payment", "amount": 500, "payment_date": "2025-03-01T00:00:00Z", "payment_method": "cash",
+			"allocations": []map[string]interface{}{{"invoice_id": invoices["INV-A1"].ID, "amount": 100}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// One payment settling two invoices, with the rest held as credit
+		payment := finance.Payment{OrganizationID: "test-org", Type: "customer_payment", Amount: 300, PaymentDate: day(time.March, 5),
+			PaymentMethod: "bank_transfer", Allocations: []finance.PaymentAllocation{
+				{InvoiceID: invoices["INV-A1"].ID}, {InvoiceID: invoices["INV-A2"].ID},
+			}}
+		assert.NoError(t, ledger.CreatePayment(&payment))
+		assert.Equal(t, 280.0, payment.AllocatedAmount)
+		assert.Len(t, payment.Allocations, 2)
+		assert.Equal(t, 60.0, payment.Allocations[0].Amount)
+		assert.Equal(t, "paid", reload("INV-A1").Status)
+		assert.Equal(t, "paid", reload("INV-A2").Status)
+
+		var count int64
+		handler.DB.Model(&finance.Payment{}).Where("organization_id = ?", "test-org").Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("Credit notes reduce what's owing", func(t *testing.T) {
+		note := finance.CreditNote{OrganizationID: "test-org", InvoiceID: &invoices["INV-B1"].ID, IssueDate: day(time.February, 15),
+			Reason: "Damaged item", SubTotal: 30, TaxAmount: 3}
+		assert.NoError(t, ledger.CreateCreditNote(&note))
+		assert.Equal(t, "ar-bob", note.CustomerID)
+		assert.Equal(t, 33.0, note.Total)
+		assert.Equal(t, "applied", note.Status)
+		assert.Equal(t, 33.0, reload("INV-B1").CreditedAmount)
+		assert.Equal(t, "sent", reload("INV-B1").Status)
+	})
+
+	t.Run("Unpaid invoices go overdue", func(t *testing.T) {
+		// Listing invoices leaves that to the job
+		w, _ := doRequest(handler, router, "GET", "/api/v1/ledger/invoices", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "sent", reload("INV-B1").Status)
+
+		marked, err := ledger.MarkOverdueInvoices(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), marked)
+		assert.Equal(t, "overdue", reload("INV-B1").Status)
+		assert.Equal(t, "overdue", reload("INV-A3").Status)
+		assert.Equal(t, "paid", reload("INV-A1").Status)
+	})
+
+	t.Run("Aging splits balances by days overdue", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/reports/ar-aging?as_of_date=2025-03-15", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		report := response["data"].(map[string]interface{})
+		customers := report["customers"].([]interface{})
+		assert.Len(t, customers, 2)
+		alice := customers[0].(map[string]interface{})
+		assert.Equal(t, "Alice Avery", alice["customer_name"])
+		assert.Equal(t, 55.0, alice["current"])
+		assert.Equal(t, 20.0, alice["unapplied_credits"])
+		assert.Equal(t, 35.0, alice["balance"])
+		bob := customers[1].(map[string]interface{})
+		assert.Equal(t, 297.0, bob["days_31_60"])
+		assert.Equal(t, 352.0, report["totals"].(map[string]interface{})["total"])
+		assert.Equal(t, 332.0, report["balance"])
+
+		// Earlier on, only the part payment had been made
+		w, response = doRequest(handler, router, "GET", "/api/v1/ledger/reports/ar-aging?as_of_date=2025-02-20", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		alice = response["data"].(map[string]interface{})["customers"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, 220.0, alice["current"])
+		assert.Equal(t, 60.0, alice["days_1_30"])
+		assert.Equal(t, 0.0, alice["unapplied_credits"])
+
+		w, _ = doRequest(handler, router, "GET", "/api/v1/ledger/reports/ar-aging?as_of_date=15-03-2025", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Statements run from the opening balance", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/ledger/customers/ar-alice/statement?start_date=2025-02-01&end_date=2025-03-15", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		statement := response["data"].(map[string]interface{})
+		assert.Equal(t, "Alice Avery", statement["customer_name"])
+		assert.Equal(t, 110.0, statement["opening_balance"])
+		lines := statement["lines"].([]interface{})
+		assert.Len(t, lines, 4)
+		balances := make([]float64, 0, len(lines))
+		for _, line := range lines {
+			balances = append(balances, line.(map[string]interface{})["balance"].(float64))
+		}
+		assert.Equal(t, []float64{330, 280, 335, 35}, balances)
+		assert.Equal(t, 35.0, statement["closing_balance"])
+		assert.Equal(t, statement["closing_balance"], statement["aging"].(map[string]interface{})["balance"])
+		outstanding := statement["outstanding_invoices"].([]interface{})
+		assert.Len(t, outstanding, 1)
+		assert.Equal(t, "INV-A3", outstanding[0].(map[string]interface{})["invoice_number"])
+
+		w, _ = doRequest(handler, router, "GET", "/api/v1/ledger/customers/nobody/statement", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
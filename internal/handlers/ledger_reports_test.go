@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/pkg/finance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCashFlowStatement(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&finance.ChartOfAccount{}, &finance.JournalEntry{}, &finance.JournalEntryLine{},
+		&finance.GeneralLedger{}, &finance.AccountBalance{}, &finance.AuditTrail{}, &finance.AccountMapping{},
+		&finance.LedgerPosting{}, &finance.AccountingPeriod{})
+	ledger := finance.NewService(handler.DB)
+
+	account := func(code, name, accountType, subType string) string {
+		a := finance.ChartOfAccount{OrganizationID: "test-org", Code: code, Name: name, AccountType: accountType, SubType: subType}
+		assert.NoError(t, ledger.CreateAccount(&a))
+		return "account:" + a.ID
+	}
+	equipment := account("1500", "Equipment", "Asset", "Fixed Asset")
+	loan := account("2500", "Bank Loan", "Liability", "Long-term Liability")
+	equity := account("3000", "Owner's Equity", "Equity", "Capital")
+
+	post := func(id string, date time.Time, debit, credit string, amount float64) {
+		posting := finance.Posting{SourceType: "test", SourceID: id, Date: date, Description: id}
+		posting.Debit(debit, "", amount)
+		posting.Credit(credit, "", amount)
+		_, err := ledger.Post(nil, "test-org", posting)
+		assert.NoError(t, err)
+	}
+	january := func(d int) time.Time { return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC) }
+
+	post("capital", january(1).AddDate(0, 0, -10), finance.AccountCash, equity, 1000)
+	post("credit-sale", january(5), finance.AccountReceivable, finance.AccountSales, 500)
+	post("receipt", january(8), finance.TenderAccount("eftpos"), finance.AccountReceivable, 300)
+	post("equipment", january(10), equipment, finance.AccountCash, 400)
+	post("loan", january(12), finance.AccountBank, loan, 2000)
+	post("expense", january(15), finance.AccountExpenses, finance.AccountPayable, 100)
+	// Closing the year moves no cash
+	_, err := ledger.CloseYear("test-org", january(1), january(31), "test-user")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/v1/ledger/reports/cash-flow?start_date=2025-01-01&end_date=2025-01-31", nil)
+	req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		CashFlow finance.CashFlowStatement `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	statement := response.CashFlow
+
+	assert.Equal(t, finance.CashFlowActivity{Description: "Net income", Amount: 400}, statement.OperatingActivities[0])
+	assert.Contains(t, statement.OperatingActivities, finance.CashFlowActivity{Description: "Increase in Accounts Receivable", Amount: -200})
+	assert.Contains(t, statement.OperatingActivities, finance.CashFlowActivity{Description: "Increase in Accounts Payable", Amount: 100})
+	assert.Equal(t, 300.0, statement.NetOperatingCash)
+	assert.Equal(t, []finance.CashFlowActivity{{Description: "Increase in Equipment", Amount: -400}}, statement.InvestingActivities)
+	assert.Equal(t, []finance.CashFlowActivity{{Description: "Increase in Bank Loan", Amount: 2000}}, statement.FinancingActivities)
+	assert.Equal(t, 1900.0, statement.NetCashFlow)
+	assert.Equal(t, 1000.0, statement.BeginningCash)
+	assert.Equal(t, 2900.0, statement.EndingCash)
+	assert.True(t, statement.Reconciled)
+	assert.Len(t, statement.CashAccounts, 2)
+}
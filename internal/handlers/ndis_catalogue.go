@@ -0,0 +1,788 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/spreadsheet"
+	"gorm.io/gorm"
+)
+
+var (
+	errSupportItemNotFound = errors.New("support item not found in the catalogue in effect for this date")
+	errNoTimeBandVariant   = errors.New("support item has no variant for this shift's time band")
+)
+
+// catalogueTimeBands maps the phrases used in catalogue item names to time bands
+var catalogueTimeBands = []struct {
+	phrase string
+	band   string
+}{
+	{"public holiday", "public_holiday"},
+	{"saturday", "saturday"},
+	{"sunday", "sunday"},
+	{"weekday night", "night"},
+	{"weekday evening", "weekday_evening"},
+	{"weekday daytime", "weekday_daytime"},
+}
+
+// splitTimeBand separates the time band suffix from a catalogue item name so
+// that all variants of a support share the same group
+func splitTimeBand(name string) (string, string) {
+	lower := strings.ToLower(name)
+	for _, tb := range catalogueTimeBands {
+		if idx := strings.LastIndex(lower, tb.phrase); idx >= 0 {
+			group := strings.TrimSpace(name[:idx] + name[idx+len(tb.phrase):])
+			group = strings.TrimRight(strings.TrimSpace(strings.TrimRight(group, " -")), " -")
+			return group, tb.band
+		}
+	}
+	return strings.TrimSpace(name), "any"
+}
+
+// timeBandFor classifies a shift into an NDIS pricing time band. Times must
+// already be in the organization's local timezone.
+func timeBandFor(start, end time.Time, publicHoliday bool) string {
+	if publicHoliday {
+		return "public_holiday"
+	}
+	switch start.Weekday() {
+	case time.Saturday:
+		return "saturday"
+	case time.Sunday:
+		return "sunday"
+	}
+
+	eveningStart := time.Date(start.Year(), start.Month(), start.Day(), 20, 0, 0, 0, start.Location())
+	midnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+
+	if start.Hour() < 6 || end.After(midnight) {
+		return "night"
+	}
+	if end.After(eveningStart) {
+		return "weekday_evening"
+	}
+	return "weekday_daytime"
+}
+
+// parseCataloguePrice reads a catalogue price cell such as "$67.56"; blank cells mean no limit
+func parseCataloguePrice(value string) float64 {
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(strings.TrimSpace(value))
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return roundCurrency(price)
+}
+
+func normalizeHeader(name string) string {
+	var b strings.Builder
+	for _, ch := range strings.ToLower(name) {
+		if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') {
+			b.WriteRune(ch)
+		}
+	}
+	return b.String()
+}
+
+// parseCatalogueRows converts the rows of the official NDIS Support Catalogue
+// spreadsheet into catalogue items
+func parseCatalogueRows(rows [][]string) ([]models.SupportCatalogueItem, error) {
+	headerRow := -1
+	columns := make(map[string]int)
+	for i, row := range rows {
+		for _, cell := range row {
+			if normalizeHeader(cell) == "supportitemnumber" {
+				headerRow = i
+				break
+			}
+		}
+		if headerRow >= 0 {
+			for j, cell := range row {
+				key := normalizeHeader(cell)
+				if _, exists := columns[key]; !exists {
+					columns[key] = j
+				}
+			}
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, fmt.Errorf("no 'Support Item Number' header found")
+	}
+
+	column := func(keys ...string) int {
+		for _, key := range keys {
+			if idx, ok := columns[key]; ok {
+				return idx
+			}
+		}
+		return -1
+	}
+	field := func(row []string, idx int) string {
+		if idx < 0 || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	numberCol := column("supportitemnumber")
+	nameCol := column("supportitemname")
+	if nameCol < 0 {
+		return nil, fmt.Errorf("no 'Support Item Name' header found")
+	}
+	regGroupCol := column("registrationgroupname", "registrationgroup")
+	categoryCol := column("supportcategoryname", "supportcategorynamepace", "supportcategory")
+	unitCol := column("unit", "unitofmeasure")
+	quoteCol := column("quote", "quoterequired")
+
+	var items []models.SupportCatalogueItem
+	for _, row := range rows[headerRow+1:] {
+		number := field(row, numberCol)
+		name := field(row, nameCol)
+		if number == "" || name == "" {
+			continue
+		}
+
+		group, band := splitTimeBand(name)
+		quote := strings.ToUpper(field(row, quoteCol))
+		items = append(items, models.SupportCatalogueItem{
+			ItemNumber:        number,
+			ItemName:          name,
+			SupportGroup:      group,
+			TimeBand:          band,
+			RegistrationGroup: field(row, regGroupCol),
+			SupportCategory:   field(row, categoryCol),
+			Unit:              strings.ToUpper(field(row, unitCol)),
+			QuoteRequired:     quote == "Y" || quote == "YES" || quote == "TRUE",
+			PriceACT:          parseCataloguePrice(field(row, column("act"))),
+			PriceNSW:          parseCataloguePrice(field(row, column("nsw"))),
+			PriceNT:           parseCataloguePrice(field(row, column("nt"))),
+			PriceQLD:          parseCataloguePrice(field(row, column("qld"))),
+			PriceSA:           parseCataloguePrice(field(row, column("sa"))),
+			PriceTAS:          parseCataloguePrice(field(row, column("tas"))),
+			PriceVIC:          parseCataloguePrice(field(row, column("vic"))),
+			PriceWA:           parseCataloguePrice(field(row, column("wa"))),
+			PriceRemote:       parseCataloguePrice(field(row, column("remote"))),
+			PriceVeryRemote:   parseCataloguePrice(field(row, column("veryremote"))),
+		})
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("catalogue contains no support items")
+	}
+	return items, nil
+}
+
+// organizationLocation returns the organization's configured timezone, falling
+// back to its business hours timezone and then the platform default
+func (h *Handler) organizationLocation(orgID interface{}) *time.Location {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err == nil && settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	var organization models.Organization
+	if err := h.DB.Select("id", "hours_timezone").Where("id = ?", orgID).First(&organization).Error; err == nil && organization.BusinessHours.Timezone != "" {
+		if loc, err := time.LoadLocation(organization.BusinessHours.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	if loc, err := time.LoadLocation("Australia/Adelaide"); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// isPublicHoliday reports whether the local calendar date is a national or state public holiday
+func (h *Handler) isPublicHoliday(date time.Time, state string) bool {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	var count int64
+	h.DB.Model(&models.PublicHoliday{}).
+		Where("date >= ? AND date < ? AND (state = ? OR state = ? OR state IS NULL)", day, day.Add(24*time.Hour), "", strings.ToUpper(state)).
+		Count(&count)
+	return count > 0
+}
+
+// catalogueVersionAt returns the active catalogue version in effect on the given date
+func (h *Handler) catalogueVersionAt(date time.Time) (*models.SupportCatalogueVersion, error) {
+	var version models.SupportCatalogueVersion
+	err := h.DB.Where("is_active = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", true, date, date).
+		Order("effective_from DESC").First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// resolveSupportItem finds the catalogue line item for a shift. When an item
+// number is supplied it selects the variant of that support matching the
+// shift's time band; otherwise the service type is matched against support
+// groups. A nil item with a nil error means the shift can't be matched to the
+// catalogue and no price limit applies.
+func (h *Handler) resolveSupportItem(orgID interface{}, participant *models.Participant, serviceType, itemNumber string, start, end time.Time) (*models.SupportCatalogueItem, error) {
+	version, err := h.catalogueVersionAt(start)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			if itemNumber != "" {
+				return nil, errSupportItemNotFound
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var anchor models.SupportCatalogueItem
+	if itemNumber != "" {
+		if err := h.DB.Where("version_id = ? AND item_number = ?", version.ID, itemNumber).First(&anchor).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errSupportItemNotFound
+			}
+			return nil, err
+		}
+	} else {
+		if err := h.DB.Where("version_id = ? AND (LOWER(support_group) = ? OR item_number = ?)", version.ID, strings.ToLower(strings.TrimSpace(serviceType)), serviceType).
+			Order("item_number ASC").First(&anchor).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	loc := h.organizationLocation(orgID)
+	localStart, localEnd := start.In(loc), end.In(loc)
+	band := timeBandFor(localStart, localEnd, h.isPublicHoliday(localStart, participant.Address.State))
+
+	var variants []models.SupportCatalogueItem
+	if err := h.DB.Where("version_id = ? AND support_group = ?", version.ID, anchor.SupportGroup).Find(&variants).Error; err != nil {
+		return nil, err
+	}
+
+	var fallback *models.SupportCatalogueItem
+	for i := range variants {
+		if variants[i].TimeBand == band {
+			return &variants[i], nil
+		}
+		if variants[i].TimeBand == "any" {
+			fallback = &variants[i]
+		}
+	}
+	if fallback != nil {
+		return fallback, nil
+	}
+	if anchor.TimeBand == "any" {
+		return &anchor, nil
+	}
+	return nil, errNoTimeBandVariant
+}
+
+// hourlyPriceLimit is the hourly cap for an item in the participant's state and
+// remoteness, or 0 when the item isn't priced by the hour or is quote based
+func hourlyPriceLimit(item *models.SupportCatalogueItem, participant *models.Participant) float64 {
+	if item.Unit != "H" || item.QuoteRequired {
+		return 0
+	}
+	return item.PriceFor(participant.Address.State, participant.Remoteness)
+}
+
+// priceShift resolves the catalogue item for a shift and enforces its price
+// limit, writing the error response and returning false when the shift can't be priced
+func (h *Handler) priceShift(c *gin.Context, orgID interface{}, participant *models.Participant, serviceType, itemNumber string, start, end time.Time, hourlyRate float64) (*models.SupportCatalogueItem, float64, bool) {
+	item, err := h.resolveSupportItem(orgID, participant, serviceType, itemNumber, start, end)
+	if err != nil {
+		if err == errSupportItemNotFound || err == errNoTimeBandVariant {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SUPPORT_ITEM",
+					"message": "Unable to resolve NDIS support item",
+					"details": err.Error(),
+				},
+			})
+			return nil, 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to look up support catalogue",
+			},
+		})
+		return nil, 0, false
+	}
+
+	if item == nil {
+		return nil, 0, true
+	}
+
+	priceLimit := hourlyPriceLimit(item, participant)
+	if priceLimit > 0 && roundCurrency(hourlyRate) > priceLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PRICE_LIMIT_EXCEEDED",
+				"message": fmt.Sprintf("Hourly rate %.2f exceeds the NDIS price limit of %.2f for %s", hourlyRate, priceLimit, item.ItemNumber),
+				"details": gin.H{
+					"support_item_number": item.ItemNumber,
+					"support_item_name":   item.ItemName,
+					"time_band":           item.TimeBand,
+					"price_limit":         priceLimit,
+				},
+			},
+		})
+		return nil, 0, false
+	}
+
+	return item, priceLimit, true
+}
+
+// ImportSupportCatalogue loads a new catalogue version from the official NDIS spreadsheet (XLSX or CSV)
+func (h *Handler) ImportSupportCatalogue(c *gin.Context) {
+	name := c.PostForm("name")
+	effectiveFromStr := c.PostForm("effective_from")
+	if name == "" || effectiveFromStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "name and effective_from are required",
+			},
+		})
+		return
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", effectiveFromStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "effective_from must be in YYYY-MM-DD format",
+			},
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_FILE",
+				"message": "Catalogue file is required",
+			},
+		})
+		return
+	}
+
+	if fileHeader.Size > spreadsheet.MaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FILE_TOO_LARGE",
+				"message": "File size exceeds 20MB limit",
+			},
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_FILE",
+				"message": "Failed to open catalogue file",
+			},
+		})
+		return
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.ReadRows(file, fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_FILE",
+				"message": "Failed to read catalogue file",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	items, err := parseCatalogueRows(rows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_CATALOGUE",
+				"message": "Failed to parse support catalogue",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	version := models.SupportCatalogueVersion{
+		Name:          name,
+		EffectiveFrom: effectiveFrom,
+		SourceFile:    fileHeader.Filename,
+		ItemCount:     len(items),
+		IsActive:      true,
+		ImportedBy:    c.GetString("user_id"),
+	}
+
+	tx := h.DB.Begin()
+
+	// Close off the open-ended version that this release supersedes
+	if err := tx.Model(&models.SupportCatalogueVersion{}).
+		Where("effective_to IS NULL AND effective_from < ?", effectiveFrom).
+		Update("effective_to", effectiveFrom).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update previous catalogue version",
+			},
+		})
+		return
+	}
+
+	if err := tx.Create(&version).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create catalogue version",
+			},
+		})
+		return
+	}
+
+	for i := range items {
+		items[i].VersionID = version.ID
+	}
+	if err := tx.CreateInBatches(&items, 200).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "IMPORT_FAILED",
+				"message": "Failed to import catalogue items",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save catalogue",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    version,
+		"message": fmt.Sprintf("Imported %d support items", len(items)),
+	})
+}
+
+func (h *Handler) GetSupportCatalogueVersions(c *gin.Context) {
+	var versions []models.SupportCatalogueVersion
+	if err := h.DB.Order("effective_from DESC").Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch catalogue versions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+func (h *Handler) GetSupportCatalogueItems(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	versionID := c.Query("version_id")
+	if versionID == "" {
+		version, err := h.catalogueVersionAt(time.Now())
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CATALOGUE_NOT_FOUND",
+					"message": "No support catalogue is currently in effect",
+				},
+			})
+			return
+		}
+		versionID = version.ID
+	}
+
+	query := h.DB.Model(&models.SupportCatalogueItem{}).Where("version_id = ?", versionID)
+	if search := c.Query("search"); search != "" {
+		like := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(item_name) LIKE ? OR item_number LIKE ?", like, like)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("support_category = ?", category)
+	}
+	if band := c.Query("time_band"); band != "" {
+		query = query.Where("time_band = ?", band)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.SupportCatalogueItem
+	if err := query.Order("item_number ASC").Limit(limit).Offset((page - 1) * limit).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch catalogue items",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items": items,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+// ResolveSupportItem previews which line item and price limit a shift would be priced against
+func (h *Handler) ResolveSupportItem(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	startTime, err := parseTimeFromString(c.Query("start_time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_START_TIME",
+				"message": "Invalid start time format",
+			},
+		})
+		return
+	}
+	endTime, err := parseTimeFromString(c.Query("end_time"))
+	if err != nil || !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_END_TIME",
+				"message": "End time must be a valid time after the start time",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Query("participant_id"), orgID).First(&participant).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PARTICIPANT",
+				"message": "Participant not found",
+			},
+		})
+		return
+	}
+
+	item, err := h.resolveSupportItem(orgID, &participant, c.Query("service_type"), c.Query("support_item_number"), startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SUPPORT_ITEM",
+				"message": "Unable to resolve NDIS support item",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if item == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SUPPORT_ITEM_NOT_FOUND",
+				"message": "No catalogue item matches this service type",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"item":        item,
+			"time_band":   item.TimeBand,
+			"price_limit": item.PriceFor(participant.Address.State, participant.Remoteness),
+		},
+	})
+}
+
+func (h *Handler) GetPublicHolidays(c *gin.Context) {
+	query := h.DB.Model(&models.PublicHoliday{})
+	if state := c.Query("state"); state != "" {
+		query = query.Where("state = ? OR state = ?", strings.ToUpper(state), "")
+	}
+	if year, err := strconv.Atoi(c.Query("year")); err == nil {
+		from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("date >= ? AND date < ?", from, from.AddDate(1, 0, 0))
+	}
+
+	var holidays []models.PublicHoliday
+	if err := query.Order("date ASC").Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch public holidays",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    holidays,
+	})
+}
+
+type CreatePublicHolidayRequest struct {
+	Date  string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name  string `json:"name" binding:"required"`
+	State string `json:"state" binding:"omitempty,oneof=ACT NSW NT QLD SA TAS VIC WA"`
+}
+
+func (h *Handler) CreatePublicHoliday(c *gin.Context) {
+	var req CreatePublicHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Date must be in YYYY-MM-DD format",
+			},
+		})
+		return
+	}
+
+	holiday := models.PublicHoliday{
+		Date:  date,
+		Name:  req.Name,
+		State: req.State,
+	}
+	if err := h.DB.Create(&holiday).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create public holiday",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    holiday,
+		"message": "Public holiday created successfully",
+	})
+}
+
+func (h *Handler) DeletePublicHoliday(c *gin.Context) {
+	result := h.DB.Delete(&models.PublicHoliday{}, "id = ?", c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete public holiday",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "HOLIDAY_NOT_FOUND",
+				"message": "Public holiday not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Public holiday deleted successfully",
+	})
+}
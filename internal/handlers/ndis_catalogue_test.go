@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeBandFor(t *testing.T) {
+	loc := time.UTC
+	at := func(day, hour int) time.Time {
+		// 2025-07-07 is a Monday
+		return time.Date(2025, 7, 7+day, hour, 0, 0, 0, loc)
+	}
+
+	assert.Equal(t, "weekday_daytime", timeBandFor(at(0, 9), at(0, 17), false))
+	assert.Equal(t, "weekday_evening", timeBandFor(at(0, 16), at(0, 21), false))
+	assert.Equal(t, "night", timeBandFor(at(0, 22), at(1, 2), false))
+	assert.Equal(t, "night", timeBandFor(at(0, 5), at(0, 8), false))
+	assert.Equal(t, "saturday", timeBandFor(at(5, 9), at(5, 17), false))
+	assert.Equal(t, "sunday", timeBandFor(at(6, 9), at(6, 17), false))
+	assert.Equal(t, "public_holiday", timeBandFor(at(0, 9), at(0, 17), true))
+}
+
+func TestParseCatalogueRows(t *testing.T) {
+	rows := [][]string{
+		{"NDIS Support Catalogue 2025-26"},
+		{"Support Item Number", "Support Item Name", "Registration Group Name", "Support Category Name", "Unit", "Quote", "ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA", "Remote", "Very Remote"},
+		{"01_011_0107_1_1", "Assistance With Self-Care Activities - Standard - Weekday Daytime", "Daily Personal Activities", "Assistance with Daily Life", "H", "N", "$70.23", "$70.23", "$70.23", "$70.23", "$70.23", "$70.23", "$70.23", "$70.23", "$98.32", "$105.35"},
+		{"01_013_0107_1_1", "Assistance With Self-Care Activities - Standard - Saturday", "Daily Personal Activities", "Assistance with Daily Life", "H", "N", "$98.83", "$98.83", "$98.83", "$98.83", "$98.83", "$98.83", "$98.83", "$98.83", "$138.36", "$148.25"},
+		{"", "", "", "", "", "", "", "", "", "", "", "", "", "", "", ""},
+	}
+
+	items, err := parseCatalogueRows(rows)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	assert.Equal(t, "Assistance With Self-Care Activities - Standard", items[0].SupportGroup)
+	assert.Equal(t, "weekday_daytime", items[0].TimeBand)
+	assert.Equal(t, "saturday", items[1].TimeBand)
+	assert.Equal(t, items[0].SupportGroup, items[1].SupportGroup)
+	assert.Equal(t, 70.23, items[0].PriceFor("SA", "standard"))
+	assert.Equal(t, 105.35, items[0].PriceFor("SA", "very_remote"))
+
+	_, err = parseCatalogueRows([][]string{{"Item", "Price"}})
+	assert.Error(t, err)
+}
+
+func TestShiftPriceLimit(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.SupportCatalogueVersion{}, &models.SupportCatalogueItem{}, &models.PublicHoliday{})
+
+	participant := models.Participant{
+		ID:             "catalogue-participant",
+		FirstName:      "Catalogue",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "CAT123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+		Address:        models.Address{State: "SA"},
+	}
+	handler.DB.Create(&participant)
+
+	version := models.SupportCatalogueVersion{Name: "2025-26", EffectiveFrom: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), IsActive: true, ImportedBy: "test-user"}
+	handler.DB.Create(&version)
+	handler.DB.Create(&[]models.SupportCatalogueItem{
+		{VersionID: version.ID, ItemNumber: "01_011_0107_1_1", ItemName: "Self-Care - Weekday Daytime", SupportGroup: "Personal Care", TimeBand: "weekday_daytime", Unit: "H", PriceSA: 70.23},
+		{VersionID: version.ID, ItemNumber: "01_013_0107_1_1", ItemName: "Self-Care - Saturday", SupportGroup: "Personal Care", TimeBand: "saturday", Unit: "H", PriceSA: 98.83},
+	})
+
+	createShift := func(start time.Time, rate float64) (*httptest.ResponseRecorder, map[string]interface{}) {
+		body, _ := json.Marshal(map[string]interface{}{
+			"participant_id": participant.ID,
+			"staff_id":       "test-user",
+			"start_time":     start.Format(time.RFC3339),
+			"end_time":       start.Add(2 * time.Hour).Format(time.RFC3339),
+			"service_type":   "Personal Care",
+			"location":       "Home",
+			"hourly_rate":    rate,
+		})
+		req := httptest.NewRequest("POST", "/api/v1/shifts", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	// Tuesday 10:00 Adelaide time
+	weekday := time.Date(2025, 7, 8, 10, 0, 0, 0, handler.organizationLocation("test-org"))
+
+	t.Run("Rate above the weekday limit is rejected", func(t *testing.T) {
+		w, response := createShift(weekday, 80)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "PRICE_LIMIT_EXCEEDED", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Rate within the limit records the line item", func(t *testing.T) {
+		w, response := createShift(weekday, 70)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "01_011_0107_1_1", data["support_item_number"])
+		assert.Equal(t, 70.23, data["price_limit"])
+	})
+
+	t.Run("Saturday shifts use the Saturday variant", func(t *testing.T) {
+		w, response := createShift(weekday.AddDate(0, 0, 4), 95)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "01_013_0107_1_1", response["data"].(map[string]interface{})["support_item_number"])
+	})
+}
+
+func TestSharedCatalogueRequiresSuperAdmin(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.SupportCatalogueVersion{}, &models.SupportCatalogueItem{}, &models.PublicHoliday{})
+
+	holiday := models.PublicHoliday{Date: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas Day"}
+	handler.DB.Create(&holiday)
+
+	// The test user is an organization admin
+	w, _ := doRequest(handler, router, "POST", "/api/v1/ndis/public-holidays", map[string]interface{}{"date": "2025-12-26", "name": "Boxing Day"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = doRequest(handler, router, "DELETE", "/api/v1/ndis/public-holidays/"+holiday.ID, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w, _ = doRequest(handler, router, "POST", "/api/v1/ndis/catalogue/import", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var holidays int64
+	handler.DB.Model(&models.PublicHoliday{}).Count(&holidays)
+	assert.Equal(t, int64(1), holidays)
+}
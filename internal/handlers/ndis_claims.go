@@ -0,0 +1,759 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ndisBulkPaymentHeader is the column layout required by the NDIS bulk payment request upload
+var ndisBulkPaymentHeader = []string{
+	"RegistrationNumber",
+	"NDISNumber",
+	"SupportsDeliveredFrom",
+	"SupportsDeliveredTo",
+	"SupportNumber",
+	"ClaimReference",
+	"Quantity",
+	"Hours",
+	"UnitPrice",
+	"GSTCode",
+	"AuthorisedBy",
+	"ParticipantApproved",
+	"InKindFundingProgram",
+	"ClaimType",
+	"CancellationReason",
+	"ABN of Support Provider",
+}
+
+type CreateClaimBatchRequest struct {
+	RegistrationNumber   string            `json:"registration_number" binding:"required"`
+	StartDate            string            `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate              string            `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	ParticipantID        string            `json:"participant_id"`
+	DefaultSupportItem   string            `json:"default_support_item"`
+	SupportItemMap       map[string]string `json:"support_item_map"` // service_type -> support item number
+	GSTCode              string            `json:"gst_code" binding:"omitempty,oneof=P1 P2 P5"`
+	IncludeCancellations bool              `json:"include_cancellations"`
+}
+
+// activeClaimExists is the subquery used to exclude shifts that already sit on a claim awaiting or holding payment.
+// Rejected lines can be claimed again; superseded lines are rejected lines that already have been.
+const activeClaimExists = "EXISTS (SELECT 1 FROM ndis_claim_lines WHERE ndis_claim_lines.shift_id = shifts.id AND ndis_claim_lines.status NOT IN ('rejected', 'superseded'))"
+
+func (h *Handler) GetClaimBatches(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := h.DB.Model(&models.NDISClaimBatch{}).Where("organization_id = ?", orgID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var batches []models.NDISClaimBatch
+	if err := query.Order("created_at DESC").Limit(limit).Offset((page - 1) * limit).Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch claim batches",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"batches": batches,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
+
+func (h *Handler) GetClaimBatch(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	batch, ok := h.findClaimBatch(c, orgID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    batch,
+	})
+}
+
+func (h *Handler) findClaimBatch(c *gin.Context, orgID interface{}) (*models.NDISClaimBatch, bool) {
+	var batch models.NDISClaimBatch
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("claim_reference ASC") }).
+		First(&batch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CLAIM_BATCH_NOT_FOUND",
+					"message": "Claim batch not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch claim batch",
+			},
+		})
+		return nil, false
+	}
+	return &batch, true
+}
+
+// CreateClaimBatch builds a bulk payment request from unclaimed shifts in a date range
+func (h *Handler) CreateClaimBatch(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateClaimBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	// Shifts are claimed by the day they fall on in the organization's timezone
+	loc := h.organizationLocation(orgID)
+	startDate, err := time.ParseInLocation("2006-01-02", req.StartDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_START_DATE",
+				"message": "Start date must be in YYYY-MM-DD format",
+			},
+		})
+		return
+	}
+	endDate, err := time.ParseInLocation("2006-01-02", req.EndDate, loc)
+	if err != nil || endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_END_DATE",
+				"message": "End date must be in YYYY-MM-DD format and not before the start date",
+			},
+		})
+		return
+	}
+
+	gstCode := req.GSTCode
+	if gstCode == "" {
+		gstCode = "P2"
+	}
+
+	statuses := []string{"completed"}
+	if req.IncludeCancellations {
+		statuses = append(statuses, "no_show")
+	}
+
+	claimable := func(db *gorm.DB) *gorm.DB {
+		query := db.Model(&models.Shift{}).Joins("JOIN participants ON shifts.participant_id = participants.id").
+			Where("participants.organization_id = ? AND shifts.status IN ? AND shifts.start_time >= ? AND shifts.start_time < ?",
+				orgID, statuses, startDate, endDate.AddDate(0, 0, 1)).
+			Where("shifts.invoice_id IS NULL").
+			Where("NOT " + activeClaimExists)
+		if req.ParticipantID != "" {
+			query = query.Where("shifts.participant_id = ?", req.ParticipantID)
+		}
+		return query
+	}
+
+	tx := h.DB.Begin()
+
+	// Lock the shifts so a concurrent batch for the same period waits for this
+	// one, then read them again: the second read sees the lines that batch
+	// committed while this one waited
+	var shiftIDs []string
+	var shifts []models.Shift
+	err = claimable(tx).Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "shifts"}}).
+		Pluck("shifts.id", &shiftIDs).Error
+	if err == nil {
+		err = claimable(tx).Where("shifts.id IN ?", shiftIDs).
+			Preload("Participant").Order("shifts.start_time ASC").Find(&shifts).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch claimable shifts",
+			},
+		})
+		return
+	}
+
+	batch := models.NDISClaimBatch{
+		OrganizationID:     fmt.Sprintf("%v", orgID),
+		RegistrationNumber: req.RegistrationNumber,
+		PeriodStart:        startDate,
+		PeriodEnd:          endDate,
+		Status:             "generated",
+		CreatedBy:          c.GetString("user_id"),
+	}
+
+	type skippedShift struct {
+		ShiftID string `json:"shift_id"`
+		Reason  string `json:"reason"`
+	}
+	skipped := []skippedShift{}
+
+	for _, shift := range shifts {
+		if shift.Participant.NDISNumber == "" {
+			skipped = append(skipped, skippedShift{ShiftID: shift.ID, Reason: "participant has no NDIS number"})
+			continue
+		}
+
+		// Shifts priced against the catalogue carry their own time band line item
+		supportItem := shift.SupportItemNumber
+		if supportItem == "" {
+			supportItem = req.SupportItemMap[shift.ServiceType]
+		}
+		if supportItem == "" {
+			supportItem = req.DefaultSupportItem
+		}
+		if supportItem == "" {
+			skipped = append(skipped, skippedShift{ShiftID: shift.ID, Reason: "no support item number for service type " + shift.ServiceType})
+			continue
+		}
+
+		hours := roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours())
+		line := models.NDISClaimLine{
+			ShiftID:           shift.ID,
+			ParticipantID:     shift.ParticipantID,
+			NDISNumber:        shift.Participant.NDISNumber,
+			SupportItemNumber: supportItem,
+			SupportsFrom:      shift.StartTime,
+			SupportsTo:        shift.EndTime,
+			Quantity:          hours,
+			UnitPrice:         shift.HourlyRate,
+			GSTCode:           gstCode,
+			Amount:            roundCurrency(hours * shift.HourlyRate),
+			Status:            "pending",
+		}
+		if shift.Status == "no_show" {
+			line.ClaimType = "CANC"
+			line.CancellationReason = "NSDO" // No show due to other reason
+		}
+		batch.Lines = append(batch.Lines, line)
+		batch.TotalAmount += line.Amount
+	}
+
+	if len(batch.Lines) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_CLAIMABLE_SHIFTS",
+				"message": "No claimable shifts found for the selected period",
+				"details": skipped,
+			},
+		})
+		return
+	}
+
+	batch.LineCount = len(batch.Lines)
+	batch.TotalAmount = roundCurrency(batch.TotalAmount)
+
+	// Claim references must be assigned after the batch number is generated
+	if err := tx.Omit("Lines").Create(&batch).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create claim batch",
+			},
+		})
+		return
+	}
+
+	for i := range batch.Lines {
+		batch.Lines[i].BatchID = batch.ID
+		batch.Lines[i].ClaimReference = fmt.Sprintf("%s-%04d", batch.BatchNumber, i+1)
+	}
+	if err := tx.Create(&batch.Lines).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create claim lines",
+			},
+		})
+		return
+	}
+
+	// Rejected lines claimed again here drop out of their old batch's export,
+	// so the same shift can't be submitted from both batches
+	claimedShiftIDs := make([]string, 0, len(batch.Lines))
+	for _, line := range batch.Lines {
+		claimedShiftIDs = append(claimedShiftIDs, line.ShiftID)
+	}
+	if err := tx.Model(&models.NDISClaimLine{}).
+		Where("shift_id IN ? AND status = ? AND batch_id <> ?", claimedShiftIDs, "rejected", batch.ID).
+		Update("status", "superseded").Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to supersede rejected claim lines",
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save claim batch",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"batch":   batch,
+			"skipped": skipped,
+		},
+		"message": "Claim batch generated successfully",
+	})
+}
+
+// ExportClaimBatch streams the batch as an NDIS bulk payment request CSV
+func (h *Handler) ExportClaimBatch(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	batch, ok := h.findClaimBatch(c, orgID)
+	if !ok {
+		return
+	}
+
+	var organization models.Organization
+	h.DB.Select("id", "abn").First(&organization, "id = ?", orgID)
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename="+batch.BatchNumber+".csv")
+	c.Status(http.StatusOK)
+
+	if err := writeBulkPaymentCSV(c.Writer, batch, organization.ABN, h.organizationLocation(orgID)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if batch.Status == "generated" {
+		h.DB.Model(batch).Update("status", "submitted")
+	}
+}
+
+// writeBulkPaymentCSV writes the pending and previously rejected lines of a batch in bulk upload format,
+// dating supports in loc. Rejected lines that have since been claimed on another batch are left out.
+func writeBulkPaymentCSV(w io.Writer, batch *models.NDISClaimBatch, providerABN string, loc *time.Location) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ndisBulkPaymentHeader); err != nil {
+		return err
+	}
+
+	for _, line := range batch.Lines {
+		if line.Status == "paid" || line.Status == "superseded" {
+			continue
+		}
+		record := []string{
+			batch.RegistrationNumber,
+			line.NDISNumber,
+			line.SupportsFrom.In(loc).Format("2006-01-02"),
+			line.SupportsTo.In(loc).Format("2006-01-02"),
+			line.SupportItemNumber,
+			line.ClaimReference,
+			strconv.FormatFloat(line.Quantity, 'f', 2, 64),
+			"",
+			strconv.FormatFloat(line.UnitPrice, 'f', 2, 64),
+			line.GSTCode,
+			"",
+			"",
+			"",
+			line.ClaimType,
+			line.CancellationReason,
+			providerABN,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// remittanceRow is the subset of an NDIS payment response row needed for reconciliation
+type remittanceRow struct {
+	ClaimReference       string
+	Status               string
+	PaidAmount           float64
+	PaymentRequestNumber string
+	Message              string
+}
+
+// parseRemittanceCSV reads an NDIS remittance/payment response file, locating
+// columns by header so that column order changes in the portal export don't break imports
+func parseRemittanceCSV(r io.Reader) ([]remittanceRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remittance header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), " ", ""))
+		columns[key] = i
+	}
+
+	column := func(names ...string) int {
+		for _, name := range names {
+			if idx, ok := columns[name]; ok {
+				return idx
+			}
+		}
+		return -1
+	}
+
+	refCol := column("claimreference")
+	if refCol < 0 {
+		return nil, fmt.Errorf("remittance file has no ClaimReference column")
+	}
+	statusCol := column("paymentrequeststatus", "claimstatus", "status")
+	paidCol := column("paidtotalamount", "paidamount", "amountpaid")
+	requestCol := column("paymentrequestnumber")
+	messageCol := column("errormessage", "rejectionreason", "message")
+
+	field := func(record []string, idx int) string {
+		if idx < 0 || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var rows []remittanceRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remittance row: %w", err)
+		}
+
+		ref := field(record, refCol)
+		if ref == "" {
+			continue
+		}
+
+		paid, _ := strconv.ParseFloat(strings.ReplaceAll(strings.TrimPrefix(field(record, paidCol), "$"), ",", ""), 64)
+		rows = append(rows, remittanceRow{
+			ClaimReference:       ref,
+			Status:               strings.ToUpper(field(record, statusCol)),
+			PaidAmount:           paid,
+			PaymentRequestNumber: field(record, requestCol),
+			Message:              field(record, messageCol),
+		})
+	}
+
+	return rows, nil
+}
+
+// remittanceOutcome maps a portal status to a claim line status; an empty
+// result means the claim is still being processed
+func remittanceOutcome(row remittanceRow) string {
+	switch row.Status {
+	case "SUCCESSFUL", "PAID", "APPROVED", "COMPLETE", "COMPLETED":
+		return "paid"
+	case "ERROR", "REJECTED", "FAILED", "CANCELLED", "DECLINED":
+		return "rejected"
+	case "":
+		if row.PaidAmount > 0 {
+			return "paid"
+		}
+		if row.Message != "" {
+			return "rejected"
+		}
+	}
+	return ""
+}
+
+// ImportRemittance applies a returned remittance file to the lines of a claim batch.
+// Paid lines and outcomes already recorded are left alone, so importing the
+// same file again changes nothing.
+func (h *Handler) ImportRemittance(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	batch, ok := h.findClaimBatch(c, orgID)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "NO_FILE",
+				"message": "Remittance file is required",
+			},
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_FILE",
+				"message": "Failed to open remittance file",
+			},
+		})
+		return
+	}
+	defer file.Close()
+
+	rows, err := parseRemittanceCSV(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_REMITTANCE",
+				"message": "Failed to parse remittance file",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	linesByRef := make(map[string]*models.NDISClaimLine, len(batch.Lines))
+	for i := range batch.Lines {
+		linesByRef[batch.Lines[i].ClaimReference] = &batch.Lines[i]
+	}
+
+	now := time.Now()
+	paidCount, rejectedCount, pendingCount := 0, 0, 0
+	unmatched, alreadyProcessed := []string{}, []string{}
+
+	tx := h.DB.Begin()
+	for _, row := range rows {
+		line, found := linesByRef[row.ClaimReference]
+		if !found {
+			unmatched = append(unmatched, row.ClaimReference)
+			continue
+		}
+		if line.Status == "superseded" {
+			// Claimed again on a later batch; that batch's remittance settles it
+			continue
+		}
+		if line.Status == "paid" {
+			alreadyProcessed = append(alreadyProcessed, row.ClaimReference)
+			continue
+		}
+
+		outcome := remittanceOutcome(row)
+		if outcome == "" {
+			pendingCount++
+			continue
+		}
+		if line.Status == outcome && line.PaymentRequestNumber == row.PaymentRequestNumber {
+			alreadyProcessed = append(alreadyProcessed, row.ClaimReference)
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"status":                 outcome,
+			"payment_request_number": row.PaymentRequestNumber,
+			"processed_at":           now,
+		}
+		if outcome == "paid" {
+			paid := row.PaidAmount
+			if paid == 0 {
+				paid = line.Amount
+			}
+			updates["paid_amount"] = roundCurrency(paid)
+			updates["rejection_reason"] = ""
+		} else {
+			updates["paid_amount"] = 0
+			updates["rejection_reason"] = row.Message
+		}
+
+		// A concurrent import may have settled the line since it was read
+		result := tx.Model(&models.NDISClaimLine{}).Where("id = ? AND status NOT IN ?", line.ID, []string{"paid", "superseded"}).
+			Updates(updates)
+		if err := result.Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update claim line",
+				},
+			})
+			return
+		}
+		switch {
+		case result.RowsAffected == 0:
+			alreadyProcessed = append(alreadyProcessed, row.ClaimReference)
+		case outcome == "paid":
+			paidCount++
+		default:
+			rejectedCount++
+		}
+	}
+
+	// Roll the line outcomes up onto the batch
+	var paidTotal float64
+	var outstanding int64
+	tx.Model(&models.NDISClaimLine{}).Where("batch_id = ? AND status = ?", batch.ID, "paid").
+		Select("COALESCE(SUM(paid_amount), 0)").Scan(&paidTotal)
+	tx.Model(&models.NDISClaimLine{}).Where("batch_id = ? AND status = ?", batch.ID, "pending").Count(&outstanding)
+
+	batchStatus := "submitted"
+	if outstanding == 0 {
+		batchStatus = "reconciled"
+	}
+	if err := tx.Model(batch).Updates(map[string]interface{}{
+		"paid_amount":            roundCurrency(paidTotal),
+		"status":                 batchStatus,
+		"remittance_imported_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update claim batch",
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to import remittance",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"paid":              paidCount,
+			"rejected":          rejectedCount,
+			"pending":           pendingCount,
+			"unmatched":         unmatched,
+			"already_processed": alreadyProcessed,
+		},
+		"message": "Remittance imported successfully",
+	})
+}
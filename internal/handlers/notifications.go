@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/ical"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/jobs"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+// Background job types
+const (
+	bookingNotificationJob = "booking.notification"
+	bookingPaymentJob      = "booking.payment"
+	expireDepositHoldsJob  = "booking.expire_holds"
+	expireWaitlistJob      = "waitlist.expire"
+	overdueInvoicesJob     = "invoices.mark_overdue"
+	pruneJobsJob           = "jobs.prune"
+	serviceRemindersJob    = "vehicles.service_reminders"
+	shiftSeriesJob         = "shifts.generate_series"
+	vehicleReminderJob     = "vehicles.reminder"
+)
+
+// notifiableBookingStatuses are the statuses customers get confirmations and reminders for
+var notifiableBookingStatuses = map[string]bool{"scheduled": true, "confirmed": true}
+
+type bookingNotificationPayload struct {
+	NotificationID string `json:"notification_id"`
+}
+
+// JobQueue returns a queue that runs the handlers' background work: booking
+// confirmations and reminders, deposit refunds and no-show fees, releasing
+// unpaid booking holds, expiring waitlist offers, vehicle service reminders,
+// marking unpaid invoices overdue, topping up recurring shift series and
+// clearing out old jobs. Start it once per app instance.
+func (h *Handler) JobQueue() *jobs.Queue {
+	queue := jobs.New(h.DB)
+	queue.Handle(bookingNotificationJob, h.deliverBookingNotification)
+	queue.Handle(bookingPaymentJob, h.processBookingPayment)
+	queue.Every(expireDepositHoldsJob, time.Minute, func(ctx context.Context, job *models.Job) error {
+		return h.expireDepositHolds(ctx)
+	})
+	queue.Every(expireWaitlistJob, time.Minute, func(ctx context.Context, job *models.Job) error {
+		var orgIDs []string
+		if err := h.DB.Model(&models.WaitlistOffer{}).Where("status = ? AND expires_at <= ?", "pending", time.Now()).
+			Distinct().Pluck("organization_id", &orgIDs).Error; err != nil {
+			return err
+		}
+		for _, orgID := range orgIDs {
+			h.expireWaitlistOffers(orgID)
+		}
+		return nil
+	})
+	queue.Handle(vehicleReminderJob, h.deliverVehicleReminder)
+	queue.Every(serviceRemindersJob, 24*time.Hour, func(ctx context.Context, job *models.Job) error {
+		return h.queueServiceReminders(ctx)
+	})
+	queue.Every(overdueInvoicesJob, time.Hour, func(ctx context.Context, job *models.Job) error {
+		if err := h.markOverdueInvoices(ctx); err != nil {
+			return err
+		}
+		return h.markOverdueLedgerInvoices(ctx)
+	})
+	queue.Every(shiftSeriesJob, 24*time.Hour, func(ctx context.Context, job *models.Job) error {
+		return h.topUpShiftSeries(ctx)
+	})
+	queue.Every(pruneJobsJob, 24*time.Hour, func(ctx context.Context, job *models.Job) error {
+		return jobs.Prune(h.DB, time.Now().AddDate(0, 0, -30))
+	})
+	return queue
+}
+
+// notificationRecipients maps each channel the organization sends booking
+// notifications over to the customer's address on it
+func notificationRecipients(org *models.Organization, customer *models.Customer) map[string]string {
+	recipients := make(map[string]string)
+	for _, channel := range strings.Split(org.BookingSettings.NotificationChannels, ",") {
+		switch channel = strings.ToLower(strings.TrimSpace(channel)); channel {
+		case notify.Email:
+			if customer.Email != "" {
+				recipients[channel] = customer.Email
+			}
+		case notify.SMS, notify.WhatsApp:
+			if customer.Phone != "" {
+				recipients[channel] = customer.Phone
+			}
+		}
+	}
+	return recipients
+}
+
+// scheduleBookingNotifications brings a booking's notifications in line with
+// its current state: a confirmation is queued the first time the booking is
+// accepted, the customer is told when it moves or is cancelled, reminders
+// follow the booking when it moves and are dropped for bookings that won't go
+// ahead. Call it after any change to a booking.
+func (h *Handler) scheduleBookingNotifications(bookingID string) {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var booking models.Booking
+		if err := tx.Preload("Customer").Where("id = ?", bookingID).First(&booking).Error; err != nil {
+			return err
+		}
+		var org models.Organization
+		if err := tx.Where("id = ?", booking.OrganizationID).First(&org).Error; err != nil {
+			return err
+		}
+		var existing []models.BookingNotification
+		if err := tx.Where("booking_id = ?", booking.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		active := notifiableBookingStatuses[booking.Status]
+		var remindAt time.Time
+		if active && org.BookingSettings.SendReminders && org.BookingSettings.ReminderHours > 0 {
+			remindAt = booking.StartTime.Add(-time.Duration(org.BookingSettings.ReminderHours) * time.Hour)
+		}
+
+		// Find what the customer was last told about the booking and drop
+		// reminders that no longer match it
+		var told *models.BookingNotification
+		reminded := false
+		for i, n := range existing {
+			switch {
+			case n.Kind != "reminder":
+				if n.Status != "skipped" && (told == nil || n.CreatedAt.After(told.CreatedAt)) {
+					told = &existing[i]
+				}
+			case n.Status == "scheduled" && !n.ScheduledFor.Equal(remindAt):
+				reason := fmt.Sprintf("Booking is %s", booking.Status)
+				if active {
+					reason = "Booking was moved"
+				}
+				if err := tx.Model(&models.BookingNotification{}).Where("id = ?", n.ID).
+					Updates(map[string]interface{}{"status": "skipped", "error": reason}).Error; err != nil {
+					return err
+				}
+			case n.Status != "skipped" && n.ScheduledFor.Equal(remindAt):
+				reminded = true
+			}
+		}
+		if booking.Customer.ID == "" {
+			return nil
+		}
+
+		recipients := notificationRecipients(&org, &booking.Customer)
+		schedule := func(kind string, at time.Time) error {
+			for channel, recipient := range recipients {
+				n := models.BookingNotification{
+					OrganizationID: org.ID,
+					BookingID:      booking.ID,
+					Kind:           kind,
+					Channel:        channel,
+					Recipient:      recipient,
+					Status:         "scheduled",
+					ScheduledFor:   at,
+					BookingStart:   booking.StartTime,
+				}
+				if err := tx.Create(&n).Error; err != nil {
+					return err
+				}
+				job, err := jobs.Enqueue(tx, bookingNotificationJob, bookingNotificationPayload{NotificationID: n.ID}, at, "booking-notification:"+n.ID)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&n).Update("job_id", job.ID).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		// Anything still waiting to go out describes the booking as it is when
+		// sent, so only delivered messages can be out of date
+		toldSent := told != nil && told.Status == "sent"
+		var kind string
+		switch {
+		case booking.Status == "cancelled":
+			if toldSent && told.Kind != "cancellation" {
+				kind = "cancellation"
+			}
+		case !active:
+			// Awaiting approval or a no show; nothing new to tell the customer
+		case told == nil:
+			if org.BookingSettings.SendConfirmations {
+				kind = "confirmation"
+			}
+		case toldSent && (told.Kind == "cancellation" || !told.BookingStart.Equal(booking.StartTime)):
+			kind = "rescheduled"
+		}
+		if kind != "" {
+			if err := schedule(kind, time.Now()); err != nil {
+				return err
+			}
+		}
+		if !remindAt.IsZero() && !reminded && remindAt.After(time.Now()) {
+			if err := schedule("reminder", remindAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to schedule notifications for booking %s: %v", bookingID, err)
+	}
+}
+
+// bookingNotificationEvents maps notification kinds to their templates
+var bookingNotificationEvents = map[string]string{
+	"confirmation": notify.BookingConfirmation,
+	"reminder":     notify.BookingReminder,
+	"rescheduled":  notify.BookingRescheduled,
+	"cancellation": notify.BookingCancellation,
+}
+
+// deliverBookingNotification sends a scheduled notification, rendered from
+// the booking as it stands when the job runs. Emails other than reminders
+// carry a calendar invite that adds, moves or removes the booking.
+func (h *Handler) deliverBookingNotification(ctx context.Context, job *models.Job) error {
+	var payload bookingNotificationPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	var n models.BookingNotification
+	if err := h.DB.Where("id = ?", payload.NotificationID).First(&n).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	if n.Status == "sent" || n.Status == "skipped" {
+		return nil
+	}
+	skip := func(reason string) error {
+		return h.DB.Model(&n).Updates(map[string]interface{}{"status": "skipped", "error": reason}).Error
+	}
+
+	var booking models.Booking
+	if err := h.DB.Preload("Customer").Preload("Staff").Preload("Services").Where("id = ?", n.BookingID).First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return skip("Booking was deleted")
+		}
+		return err
+	}
+	if (n.Kind == "cancellation") != (booking.Status == "cancelled") ||
+		(n.Kind != "cancellation" && !notifiableBookingStatuses[booking.Status]) {
+		return skip(fmt.Sprintf("Booking is %s", booking.Status))
+	}
+	if n.Kind == "reminder" && !booking.StartTime.After(time.Now()) {
+		return skip("Booking has already started")
+	}
+	var org models.Organization
+	if err := h.DB.Where("id = ?", booking.OrganizationID).First(&org).Error; err != nil {
+		return err
+	}
+
+	details := notify.BookingDetails{
+		OrganizationName:  org.Name,
+		OrganizationPhone: org.Phone,
+		When:              booking.StartTime.In(h.organizationLocation(org.ID)).Format("Monday 2 January at 3:04 PM"),
+		CustomerName:      booking.Customer.FirstName,
+	}
+	if booking.Staff != nil {
+		details.Staff = strings.TrimSpace(booking.Staff.FirstName + " " + booking.Staff.LastName)
+	}
+	names := make([]string, len(booking.Services))
+	for i, service := range booking.Services {
+		names[i] = service.Name
+	}
+	details.Services = strings.Join(names, ", ")
+	if org.BookingSettings.EnableOnlineBooking && n.Kind != "cancellation" {
+		details.ManageURL = fmt.Sprintf("%s/api/v1/public/%s/bookings/%s", h.Config.AppURL, org.Slug, h.bookingManageToken(booking.ID))
+	}
+
+	msg, err := notify.Render(bookingNotificationEvents[n.Kind], n.Channel, n.Recipient, details)
+	if err != nil {
+		// Retrying won't fix a broken template
+		h.DB.Model(&n).Updates(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return nil
+	}
+	if n.Channel == notify.Email && n.Kind != "reminder" {
+		method := ical.MethodRequest
+		if n.Kind == "cancellation" {
+			method = ical.MethodCancel
+		}
+		msg.Attachments = []notify.Attachment{h.bookingInvite(&org, &booking, method)}
+	}
+
+	updates := map[string]interface{}{
+		"subject":       msg.Subject,
+		"body":          msg.Body,
+		"booking_start": booking.StartTime,
+		"attempts":      gorm.Expr("attempts + 1"),
+	}
+	if err := h.Notifier.Send(msg); err != nil {
+		updates["status"], updates["error"] = "failed", err.Error()
+		h.DB.Model(&n).Updates(updates)
+		return err
+	}
+	updates["status"], updates["error"], updates["sent_at"] = "sent", "", time.Now()
+	return h.DB.Model(&n).Updates(updates).Error
+}
+
+// GetBookingNotifications lists the messages sent or due to be sent about a
+// booking and whether each was delivered
+func (h *Handler) GetBookingNotifications(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var count int64
+	h.DB.Model(&models.Booking{}).Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BOOKING_NOT_FOUND",
+				"message": "Booking not found",
+			},
+		})
+		return
+	}
+
+	var notifications []models.BookingNotification
+	if err := h.DB.Where("booking_id = ? AND organization_id = ?", c.Param("id"), orgID).
+		Order("scheduled_for ASC, created_at ASC").Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch notifications",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notifications,
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSender struct{}
+
+func (failingSender) Send(msg notify.Message) error {
+	return errors.New("provider unavailable")
+}
+
+func TestBookingNotifications(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{})
+	outbox := &notify.Outbox{}
+	handler.Notifier = outbox
+	queue := handler.JobQueue()
+
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Update("booking_notification_channels", "email,sms")
+	handler.DB.Create(&models.Service{ID: "facial", OrganizationID: "test-org", Name: "Facial", Category: "beauty", Duration: 60, Price: 95, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "notified-customer", OrganizationID: "test-org", FirstName: "Nora", LastName: "Notified", Email: "nora@example.com", Phone: "0400333444", IsActive: true})
+
+	createBooking := func(t *testing.T, start time.Time) string {
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "notified-customer",
+			"service_ids": []string{"facial"},
+			"start_time":  start,
+			"end_time":    start.Add(time.Hour),
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		return response["booking"].(map[string]interface{})["id"].(string)
+	}
+	notifications := func(bookingID string) []models.BookingNotification {
+		var found []models.BookingNotification
+		handler.DB.Where("booking_id = ?", bookingID).Order("scheduled_for ASC, channel ASC").Find(&found)
+		return found
+	}
+	// makeDue brings a booking's queued notifications forward so the queue runs them now
+	makeDue := func(bookingID string) {
+		var jobIDs []string
+		handler.DB.Model(&models.BookingNotification{}).Where("booking_id = ?", bookingID).Pluck("job_id", &jobIDs)
+		handler.DB.Model(&models.Job{}).Where("id IN ? AND status = ?", jobIDs, "pending").Update("run_at", time.Now())
+	}
+
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Hour)
+	var bookingID string
+
+	t.Run("Creating a booking sends a confirmation and queues reminders", func(t *testing.T) {
+		bookingID = createBooking(t, start)
+
+		found := notifications(bookingID)
+		assert.Len(t, found, 4)
+		for _, n := range found[2:] {
+			assert.Equal(t, "reminder", n.Kind)
+			assert.Equal(t, "scheduled", n.Status)
+			assert.True(t, n.ScheduledFor.Equal(start.Add(-24*time.Hour)))
+		}
+
+		_, err := queue.RunDue(context.Background())
+		assert.NoError(t, err)
+
+		messages := outbox.Messages()
+		assert.Len(t, messages, 2)
+		for _, msg := range messages {
+			assert.Contains(t, msg.Body, "Facial")
+			if msg.Channel == notify.Email {
+				assert.Len(t, msg.Attachments, 1)
+				invite := string(msg.Attachments[0].Data)
+				assert.Contains(t, invite, "METHOD:REQUEST\r\n")
+				assert.Contains(t, invite, "UID:"+handler.calendarUID("booking", bookingID)+"\r\n")
+			} else {
+				assert.Empty(t, msg.Attachments)
+			}
+		}
+
+		w, response := doRequest(handler, router, "GET", "/api/v1/bookings/"+bookingID+"/notifications", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].([]interface{})
+		assert.Len(t, data, 4)
+		assert.Equal(t, "sent", data[0].(map[string]interface{})["status"])
+		assert.Equal(t, "sent", data[1].(map[string]interface{})["status"])
+	})
+
+	t.Run("Moving a booking updates the customer's calendar and reminders", func(t *testing.T) {
+		moved := start.Add(4 * time.Hour)
+		w, _ := doRequest(handler, router, "PUT", "/api/v1/bookings/"+bookingID, map[string]interface{}{
+			"start_time": moved,
+			"end_time":   moved.Add(time.Hour),
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var skipped, rescheduled, reminders []models.BookingNotification
+		for _, n := range notifications(bookingID) {
+			switch {
+			case n.Status == "skipped":
+				skipped = append(skipped, n)
+			case n.Kind == "rescheduled":
+				rescheduled = append(rescheduled, n)
+			case n.Kind == "reminder":
+				reminders = append(reminders, n)
+			}
+		}
+		assert.Len(t, skipped, 2)
+		assert.Len(t, rescheduled, 2)
+		assert.Len(t, reminders, 2)
+		assert.True(t, reminders[0].ScheduledFor.Equal(moved.Add(-24*time.Hour)))
+
+		queue.RunDue(context.Background())
+		messages := outbox.Messages()
+		assert.Len(t, messages, 4)
+		for _, msg := range messages[2:] {
+			assert.Contains(t, msg.Body, "moved")
+			if msg.Channel == notify.Email {
+				invite := string(msg.Attachments[0].Data)
+				assert.Contains(t, invite, "UID:"+handler.calendarUID("booking", bookingID)+"\r\n")
+				assert.Contains(t, invite, "DTSTART:"+moved.UTC().Format("20060102T150405Z")+"\r\n")
+			}
+		}
+
+		// Saving the booking again at the same time sends nothing new
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/bookings/"+bookingID, map[string]interface{}{"notes": "Bring a towel"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, notifications(bookingID), 8)
+
+		makeDue(bookingID)
+		queue.RunDue(context.Background())
+		messages = outbox.Messages()
+		assert.Len(t, messages, 6)
+		for _, msg := range messages[4:] {
+			assert.Contains(t, msg.Subject+msg.Body, "Reminder")
+			assert.Empty(t, msg.Attachments)
+		}
+	})
+
+	t.Run("Cancelling removes the booking from the customer's calendar", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+bookingID+"/status", map[string]interface{}{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		queue.RunDue(context.Background())
+
+		messages := outbox.Messages()
+		assert.Len(t, messages, 8)
+		for _, msg := range messages[6:] {
+			assert.Contains(t, msg.Body, "cancelled")
+			if msg.Channel == notify.Email {
+				invite := string(msg.Attachments[0].Data)
+				assert.Contains(t, invite, "METHOD:CANCEL\r\n")
+				assert.Contains(t, invite, "STATUS:CANCELLED\r\n")
+				assert.Contains(t, invite, "UID:"+handler.calendarUID("booking", bookingID)+"\r\n")
+			}
+		}
+	})
+
+	t.Run("Cancelled bookings get no reminder", func(t *testing.T) {
+		cancelled := createBooking(t, start.Add(24*time.Hour))
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+cancelled+"/status", map[string]interface{}{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		sent := len(outbox.Messages())
+		makeDue(cancelled)
+		queue.RunDue(context.Background())
+		assert.Len(t, outbox.Messages(), sent)
+		for _, n := range notifications(cancelled) {
+			assert.Equal(t, "skipped", n.Status)
+		}
+	})
+
+	t.Run("Failed deliveries are recorded and retried", func(t *testing.T) {
+		handler.Notifier = failingSender{}
+		defer func() { handler.Notifier = outbox }()
+
+		failing := createBooking(t, start.Add(48*time.Hour))
+		queue.RunDue(context.Background())
+
+		found := notifications(failing)
+		assert.Equal(t, "failed", found[0].Status)
+		assert.Equal(t, "provider unavailable", found[0].Error)
+		assert.Equal(t, 1, found[0].Attempts)
+
+		var job models.Job
+		handler.DB.First(&job, "id = ?", found[0].JobID)
+		assert.Equal(t, "pending", job.Status)
+		assert.True(t, job.RunAt.After(time.Now()))
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
@@ -52,11 +53,14 @@ func (h *Handler) GetOrganization(c *gin.Context) {
 
 type UpdateOrganizationRequest struct {
 	Name    *string         `json:"name,omitempty"`
+	Slug    *string         `json:"slug,omitempty"`
 	ABN     *string         `json:"abn,omitempty"`
 	Phone   *string         `json:"phone,omitempty"`
 	Email   *string         `json:"email,omitempty" binding:"omitempty,email"`
 	Website *string         `json:"website,omitempty"`
 	Address *models.Address `json:"address,omitempty"`
+	BusinessHours   *models.BusinessHours   `json:"business_hours,omitempty"`
+	BookingSettings *models.BookingSettings `json:"booking_settings,omitempty"`
 	NDISReg *models.NDISReg `json:"ndis_registration,omitempty"`
 }
 
@@ -124,11 +128,39 @@ func (h *Handler) UpdateOrganization(c *gin.Context) {
 		}
 	}
 
+	// Check slug is usable and unique if being updated
+	if req.Slug != nil && *req.Slug != organization.Slug {
+		if models.Slugify(*req.Slug) != *req.Slug {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_SLUG",
+					"message": "Slug may only contain lowercase letters, digits and single hyphens",
+				},
+			})
+			return
+		}
+		var existingOrg models.Organization
+		if err := h.DB.Unscoped().Where("slug = ? AND id != ?", *req.Slug, orgID).First(&existingOrg).Error; err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SLUG_EXISTS",
+					"message": "Another organization already uses this slug",
+				},
+			})
+			return
+		}
+	}
+
 	// Update fields
 	updates := make(map[string]interface{})
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
+	if req.Slug != nil {
+		updates["slug"] = *req.Slug
+	}
 	if req.ABN != nil {
 		updates["abn"] = *req.ABN
 	}
@@ -151,6 +183,66 @@ func (h *Handler) UpdateOrganization(c *gin.Context) {
 		updates["address_country"] = req.Address.Country
 	}
 
+	if req.BusinessHours != nil {
+		if req.BusinessHours.Timezone != "" {
+			if _, err := time.LoadLocation(req.BusinessHours.Timezone); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_TIMEZONE",
+						"message": "Timezone must be an IANA name such as Australia/Adelaide",
+					},
+				})
+				return
+			}
+		}
+		hours := req.BusinessHours
+		for _, day := range []struct {
+			name        string
+			open, close string
+		}{
+			{"monday", hours.MondayOpen, hours.MondayClose},
+			{"tuesday", hours.TuesdayOpen, hours.TuesdayClose},
+			{"wednesday", hours.WednesdayOpen, hours.WednesdayClose},
+			{"thursday", hours.ThursdayOpen, hours.ThursdayClose},
+			{"friday", hours.FridayOpen, hours.FridayClose},
+			{"saturday", hours.SaturdayOpen, hours.SaturdayClose},
+			{"sunday", hours.SundayOpen, hours.SundayClose},
+		} {
+			updates["hours_"+day.name+"_open"] = day.open
+			updates["hours_"+day.name+"_close"] = day.close
+		}
+		if hours.Timezone != "" {
+			updates["hours_timezone"] = hours.Timezone
+		}
+	}
+
+	if req.BookingSettings != nil {
+		settings := req.BookingSettings
+		updates["booking_enable_online_booking"] = settings.EnableOnlineBooking
+		updates["booking_booking_window"] = settings.BookingWindow
+		updates["booking_min_advance_booking"] = settings.MinAdvanceBooking
+		updates["booking_max_advance_booking"] = settings.MaxAdvanceBooking
+		updates["booking_default_slot_duration"] = settings.DefaultSlotDuration
+		updates["booking_buffer_time"] = settings.BufferTime
+		updates["booking_require_approval"] = settings.RequireApproval
+		updates["booking_send_confirmations"] = settings.SendConfirmations
+		updates["booking_send_reminders"] = settings.SendReminders
+		updates["booking_reminder_hours"] = settings.ReminderHours
+		updates["booking_allow_cancellation"] = settings.AllowCancellation
+		updates["booking_cancellation_window"] = settings.CancellationWindow
+		updates["booking_close_on_public_holidays"] = settings.CloseOnPublicHolidays
+		if settings.WaitlistHoldMinutes > 0 {
+			updates["booking_waitlist_hold_minutes"] = settings.WaitlistHoldMinutes
+		}
+		if settings.NotificationChannels != "" {
+			updates["booking_notification_channels"] = settings.NotificationChannels
+		}
+		if settings.DepositHoldMinutes > 0 {
+			updates["booking_deposit_hold_minutes"] = settings.DepositHoldMinutes
+		}
+	}
+
 	if req.NDISReg != nil {
 		updates["ndis_registration_number"] = req.NDISReg.RegistrationNumber
 		updates["ndis_registration_status"] = req.NDISReg.RegistrationStatus
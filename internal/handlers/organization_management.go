@@ -182,6 +182,7 @@ func (h *Handler) GetOrganizationSettings(c *gin.Context) {
 			DefaultShiftDuration:     120,
 			MaxShiftDuration:         720,
 			MinShiftNotice:           30,
+			GeofenceRadiusMeters:     200,
 			EnableSMSNotifications:   true,
 			EnableEmailNotifications: true,
 		}
@@ -206,6 +207,7 @@ type UpdateSettingsRequest struct {
 	RequireShiftNotes        *bool   `json:"require_shift_notes,omitempty"`
 	RequirePhotoEvidence     *bool   `json:"require_photo_evidence,omitempty"`
 	AutoAssignShifts         *bool   `json:"auto_assign_shifts,omitempty"`
+	GeofenceRadiusMeters     *int    `json:"geofence_radius_meters,omitempty" binding:"omitempty,min=10"`
 	EnableSMSNotifications   *bool   `json:"enable_sms_notifications,omitempty"`
 	EnableEmailNotifications *bool   `json:"enable_email_notifications,omitempty"`
 }
@@ -290,6 +292,9 @@ func (h *Handler) UpdateOrganizationSettings(c *gin.Context) {
 	if req.AutoAssignShifts != nil {
 		updates["auto_assign_shifts"] = *req.AutoAssignShifts
 	}
+	if req.GeofenceRadiusMeters != nil {
+		updates["geofence_radius_meters"] = *req.GeofenceRadiusMeters
+	}
 	if req.EnableSMSNotifications != nil {
 		updates["enable_sms_notifications"] = *req.EnableSMSNotifications
 	}
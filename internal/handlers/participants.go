@@ -175,6 +175,7 @@ type CreateParticipantRequest struct {
 	Address        models.Address            `json:"address"`
 	MedicalInfo    models.MedicalInformation `json:"medical_information"`
 	Funding        models.FundingInformation `json:"funding"`
+	GenderPreference string                  `json:"gender_preference" binding:"omitempty,oneof=female male non_binary"`
 	EmergencyContacts []CreateParticipantEmergencyContactRequest `json:"emergency_contacts,omitempty"`
 }
 
@@ -238,6 +239,7 @@ func (h *Handler) CreateParticipant(c *gin.Context) {
 		NDISNumber:     req.NDISNumber,
 		Email:          req.Email,
 		Phone:          req.Phone,
+		GenderPreference: req.GenderPreference,
 		Address:        req.Address,
 		MedicalInfo:    req.MedicalInfo,
 		Funding:        req.Funding,
@@ -304,6 +306,7 @@ type UpdateParticipantRequest struct {
 	MedicalInfo *models.MedicalInformation `json:"medical_information,omitempty"`
 	Funding     *models.FundingInformation `json:"funding,omitempty"`
 	IsActive    *bool                      `json:"is_active,omitempty"`
+	GenderPreference *string               `json:"gender_preference,omitempty" binding:"omitempty,oneof=female male non_binary"`
 }
 
 func (h *Handler) UpdateParticipant(c *gin.Context) {
@@ -394,6 +397,9 @@ func (h *Handler) UpdateParticipant(c *gin.Context) {
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.GenderPreference != nil {
+		updates["gender_preference"] = *req.GenderPreference
+	}
 
 	// Handle embedded structs
 	if req.Address != nil {
@@ -402,6 +408,8 @@ func (h *Handler) UpdateParticipant(c *gin.Context) {
 		updates["address_state"] = req.Address.State
 		updates["address_postcode"] = req.Address.Postcode
 		updates["address_country"] = req.Address.Country
+		updates["address_latitude"] = req.Address.Latitude
+		updates["address_longitude"] = req.Address.Longitude
 	}
 
 	if req.MedicalInfo != nil {
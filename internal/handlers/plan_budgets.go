@@ -0,0 +1,946 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Shifts without a catalogue line item are drawn from Assistance with Daily Life
+const defaultBudgetCategory = "01"
+
+// burnRateWindow is the trailing period used to measure a budget's current spend rate
+const burnRateWindow = 28 * 24 * time.Hour
+
+// BudgetForecast is a plan budget with its spend position and projected burn
+type BudgetForecast struct {
+	models.PlanBudget
+	RemainingAmount         float64    `json:"remaining_amount"`
+	Utilisation             float64    `json:"utilisation"`          // Percentage of the allocation spent
+	ExpectedUtilisation     float64    `json:"expected_utilisation"` // Percentage of the plan period elapsed
+	DailyBurnRate           float64    `json:"daily_burn_rate"`
+	ProjectedSpend          float64    `json:"projected_spend"`
+	ProjectedExhaustionDate *time.Time `json:"projected_exhaustion_date,omitempty"`
+	Status                  string     `json:"status"` // not_started, on_track, at_risk, overspent, ended
+}
+
+// forecastBudget projects a budget's spend to the end of its plan. The burn
+// rate is the spend over the trailing window once the plan has run that long,
+// otherwise the average since the plan started.
+func forecastBudget(budget models.PlanBudget, recentSpend float64, now time.Time) BudgetForecast {
+	forecast := BudgetForecast{
+		PlanBudget:      budget,
+		RemainingAmount: roundCurrency(budget.RemainingAmount()),
+		ProjectedSpend:  budget.SpentAmount,
+	}
+
+	totalDays := budget.PlanEndDate.Sub(budget.PlanStartDate).Hours() / 24
+	if totalDays < 1 {
+		totalDays = 1
+	}
+	elapsedDays := now.Sub(budget.PlanStartDate).Hours() / 24
+	if elapsedDays < 0 {
+		elapsedDays = 0
+	}
+	if elapsedDays > totalDays {
+		elapsedDays = totalDays
+	}
+
+	if budget.AllocatedAmount > 0 {
+		forecast.Utilisation = roundCurrency(budget.SpentAmount / budget.AllocatedAmount * 100)
+	}
+	forecast.ExpectedUtilisation = roundCurrency(elapsedDays / totalDays * 100)
+
+	windowDays := burnRateWindow.Hours() / 24
+	switch {
+	case elapsedDays >= windowDays:
+		forecast.DailyBurnRate = recentSpend / windowDays
+	case elapsedDays > 0:
+		forecast.DailyBurnRate = budget.SpentAmount / elapsedDays
+	}
+	forecast.DailyBurnRate = roundCurrency(forecast.DailyBurnRate)
+
+	remainingDays := totalDays - elapsedDays
+	forecast.ProjectedSpend = roundCurrency(budget.SpentAmount + forecast.DailyBurnRate*remainingDays)
+
+	if forecast.DailyBurnRate > 0 && budget.SpentAmount < budget.AllocatedAmount {
+		daysLeft := (budget.AllocatedAmount - budget.SpentAmount) / forecast.DailyBurnRate
+		exhaustion := now.Add(time.Duration(daysLeft * 24 * float64(time.Hour)))
+		if exhaustion.Before(budget.PlanEndDate) {
+			forecast.ProjectedExhaustionDate = &exhaustion
+		}
+	}
+
+	switch {
+	case budget.SpentAmount > budget.AllocatedAmount:
+		forecast.Status = "overspent"
+	case now.Before(budget.PlanStartDate):
+		forecast.Status = "not_started"
+	case now.After(budget.PlanEndDate):
+		forecast.Status = "ended"
+	case forecast.ProjectedExhaustionDate != nil:
+		forecast.Status = "at_risk"
+	default:
+		forecast.Status = "on_track"
+	}
+
+	return forecast
+}
+
+// recentBudgetSpend sums the drawdowns in the trailing burn rate window for each budget
+func recentBudgetSpend(db *gorm.DB, budgetIDs []string, now time.Time) map[string]float64 {
+	spend := make(map[string]float64)
+	if len(budgetIDs) == 0 {
+		return spend
+	}
+
+	var rows []struct {
+		BudgetID string
+		Total    float64
+	}
+	db.Model(&models.PlanBudgetTransaction{}).
+		Select("budget_id, COALESCE(SUM(amount), 0) AS total").
+		Where("budget_id IN ? AND occurred_at >= ?", budgetIDs, now.Add(-burnRateWindow)).
+		Group("budget_id").
+		Scan(&rows)
+	for _, row := range rows {
+		spend[row.BudgetID] = row.Total
+	}
+	return spend
+}
+
+func forecastBudgets(db *gorm.DB, budgets []models.PlanBudget, now time.Time) []BudgetForecast {
+	ids := make([]string, 0, len(budgets))
+	for _, budget := range budgets {
+		ids = append(ids, budget.ID)
+	}
+	recent := recentBudgetSpend(db, ids, now)
+
+	forecasts := make([]BudgetForecast, 0, len(budgets))
+	for _, budget := range budgets {
+		forecasts = append(forecasts, forecastBudget(budget, recent[budget.ID], now))
+	}
+	return forecasts
+}
+
+// drawDownPlanBudget charges a completed shift against the participant's plan
+// budget for the shift's support category. Shifts that fall outside every
+// plan budget are left unallocated, and shifts already charged to a budget,
+// including by a concurrent completion, aren't charged again.
+func drawDownPlanBudget(tx *gorm.DB, shift *models.Shift, userID string) (*models.PlanBudget, error) {
+	if shift.BudgetID != nil {
+		return nil, nil
+	}
+
+	category := models.SupportCategoryForItem(shift.SupportItemNumber)
+	if category == "" {
+		category = defaultBudgetCategory
+	}
+
+	serviceDate := time.Date(shift.StartTime.Year(), shift.StartTime.Month(), shift.StartTime.Day(), 0, 0, 0, 0, time.UTC)
+	var budget models.PlanBudget
+	if err := tx.Where("participant_id = ? AND support_category = ? AND plan_start_date <= ? AND plan_end_date >= ?",
+		shift.ParticipantID, category, shift.StartTime, serviceDate).
+		Order("plan_start_date DESC").First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Claim the shift for the budget first; only the claim that sets it is
+	// charged
+	claim := tx.Model(&models.Shift{}).Where("id = ? AND budget_id IS NULL", shift.ID).UpdateColumn("budget_id", budget.ID)
+	if claim.Error != nil {
+		return nil, claim.Error
+	}
+	if claim.RowsAffected != 1 {
+		return nil, nil
+	}
+
+	amount := roundCurrency(shift.EndTime.Sub(shift.StartTime).Hours() * shift.HourlyRate)
+	shiftID := shift.ID
+	transaction := models.PlanBudgetTransaction{
+		BudgetID:    budget.ID,
+		ShiftID:     &shiftID,
+		Type:        "drawdown",
+		Amount:      amount,
+		Description: fmt.Sprintf("%s on %s", shift.ServiceType, shift.StartTime.Format("2006-01-02")),
+		OccurredAt:  shift.StartTime,
+		CreatedBy:   userID,
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&models.PlanBudget{}).Where("id = ?", budget.ID).
+		UpdateColumn("spent_amount", gorm.Expr("spent_amount + ?", amount)).Error; err != nil {
+		return nil, err
+	}
+
+	shift.BudgetID = &budget.ID
+	budget.SpentAmount = roundCurrency(budget.SpentAmount + amount)
+	return &budget, nil
+}
+
+// raiseBudgetAlerts records an alert for each condition the budget now meets,
+// skipping conditions that already have an unacknowledged alert
+func raiseBudgetAlerts(tx *gorm.DB, budget *models.PlanBudget, now time.Time) error {
+	recent := recentBudgetSpend(tx, []string{budget.ID}, now)
+	forecast := forecastBudget(*budget, recent[budget.ID], now)
+
+	var alerts []models.BudgetAlert
+	if forecast.Status == "overspent" {
+		alerts = append(alerts, models.BudgetAlert{
+			Type:    "overspent",
+			Message: fmt.Sprintf("%s budget is overspent by $%.2f", budget.CategoryName, -forecast.RemainingAmount),
+		})
+	}
+	if budget.AlertThreshold > 0 && forecast.Utilisation >= budget.AlertThreshold && forecast.Status != "overspent" {
+		alerts = append(alerts, models.BudgetAlert{
+			Type:    "threshold",
+			Message: fmt.Sprintf("%s budget is %.0f%% spent", budget.CategoryName, forecast.Utilisation),
+		})
+	}
+	if forecast.Status == "at_risk" {
+		alerts = append(alerts, models.BudgetAlert{
+			Type: "forecast_overspend",
+			Message: fmt.Sprintf("%s budget is forecast to run out on %s, before the plan ends on %s",
+				budget.CategoryName, forecast.ProjectedExhaustionDate.Format("2006-01-02"), budget.PlanEndDate.Format("2006-01-02")),
+			ProjectedExhaustionDate: forecast.ProjectedExhaustionDate,
+		})
+	}
+
+	for _, alert := range alerts {
+		var open int64
+		tx.Model(&models.BudgetAlert{}).
+			Where("budget_id = ? AND type = ? AND acknowledged_at IS NULL", budget.ID, alert.Type).
+			Count(&open)
+		if open > 0 {
+			continue
+		}
+
+		alert.OrganizationID = budget.OrganizationID
+		alert.ParticipantID = budget.ParticipantID
+		alert.BudgetID = budget.ID
+		if err := tx.Create(&alert).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOrgParticipant loads the participant in the :id route parameter, writing
+// the error response and returning false when it isn't in the organization
+func (h *Handler) findOrgParticipant(c *gin.Context, orgID interface{}) (*models.Participant, bool) {
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&participant).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PARTICIPANT_NOT_FOUND",
+					"message": "Participant not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant",
+			},
+		})
+		return nil, false
+	}
+	return &participant, true
+}
+
+func (h *Handler) findPlanBudget(c *gin.Context, participantID string) (*models.PlanBudget, bool) {
+	var budget models.PlanBudget
+	if err := h.DB.Where("id = ? AND participant_id = ?", c.Param("budgetId"), participantID).First(&budget).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "BUDGET_NOT_FOUND",
+					"message": "Plan budget not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch plan budget",
+			},
+		})
+		return nil, false
+	}
+	return &budget, true
+}
+
+func (h *Handler) GetParticipantBudgets(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+
+	query := h.DB.Where("participant_id = ?", participant.ID)
+	if c.Query("include_expired") != "true" {
+		query = query.Where("plan_end_date >= ?", time.Now().AddDate(0, 0, -1))
+	}
+
+	var budgets []models.PlanBudget
+	if err := query.Order("support_category ASC, plan_start_date DESC").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch plan budgets",
+			},
+		})
+		return
+	}
+
+	forecasts := forecastBudgets(h.DB, budgets, time.Now())
+
+	// Roll the categories up into the core, capacity building and capital purposes
+	purposes := make(map[string]gin.H)
+	for _, forecast := range forecasts {
+		totals, ok := purposes[forecast.SupportPurpose]
+		if !ok {
+			totals = gin.H{"allocated_amount": 0.0, "spent_amount": 0.0, "remaining_amount": 0.0}
+			purposes[forecast.SupportPurpose] = totals
+		}
+		totals["allocated_amount"] = roundCurrency(totals["allocated_amount"].(float64) + forecast.AllocatedAmount)
+		totals["spent_amount"] = roundCurrency(totals["spent_amount"].(float64) + forecast.SpentAmount)
+		totals["remaining_amount"] = roundCurrency(totals["remaining_amount"].(float64) + forecast.RemainingAmount)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"budgets":  forecasts,
+			"purposes": purposes,
+		},
+	})
+}
+
+type CreatePlanBudgetRequest struct {
+	SupportCategory string  `json:"support_category" binding:"required"` // 01-15
+	PlanStartDate   string  `json:"plan_start_date"`                     // YYYY-MM-DD, defaults to the participant's plan
+	PlanEndDate     string  `json:"plan_end_date"`                       // YYYY-MM-DD, defaults to the participant's plan
+	AllocatedAmount float64 `json:"allocated_amount" binding:"required,gt=0"`
+	AlertThreshold  float64 `json:"alert_threshold" binding:"omitempty,gt=0,lte=100"`
+	Notes           string  `json:"notes"`
+}
+
+func (h *Handler) CreateParticipantBudget(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreatePlanBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	category, ok := models.NDISSupportCategories[req.SupportCategory]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_CATEGORY",
+				"message": "support_category must be an NDIS support category code from 01 to 15",
+			},
+		})
+		return
+	}
+
+	participant, found := h.findOrgParticipant(c, orgID)
+	if !found {
+		return
+	}
+
+	planStart, planEnd := participant.Funding.PlanStartDate, participant.Funding.PlanEndDate
+	if req.PlanStartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.PlanStartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "plan_start_date must be in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		planStart = &parsed
+	}
+	if req.PlanEndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.PlanEndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "plan_end_date must be in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		planEnd = &parsed
+	}
+	if planStart == nil || planEnd == nil || !planEnd.After(*planStart) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PLAN_DATES",
+				"message": "A plan start and end date are required and the end must be after the start",
+			},
+		})
+		return
+	}
+
+	// A category can only have one budget for any given day of a plan
+	var overlapping int64
+	h.DB.Model(&models.PlanBudget{}).
+		Where("participant_id = ? AND support_category = ? AND plan_start_date <= ? AND plan_end_date >= ?",
+			participant.ID, category.Code, *planEnd, *planStart).
+		Count(&overlapping)
+	if overlapping > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BUDGET_EXISTS",
+				"message": "A budget for this support category already covers part of this plan period",
+			},
+		})
+		return
+	}
+
+	budget := models.PlanBudget{
+		OrganizationID:  participant.OrganizationID,
+		ParticipantID:   participant.ID,
+		SupportPurpose:  category.Purpose,
+		SupportCategory: category.Code,
+		CategoryName:    category.Name,
+		PlanStartDate:   *planStart,
+		PlanEndDate:     *planEnd,
+		AllocatedAmount: roundCurrency(req.AllocatedAmount),
+		AlertThreshold:  req.AlertThreshold,
+		Notes:           req.Notes,
+	}
+	if budget.AlertThreshold == 0 {
+		budget.AlertThreshold = 80
+	}
+
+	if err := h.DB.Create(&budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create plan budget",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    forecastBudget(budget, 0, time.Now()),
+		"message": "Plan budget created successfully",
+	})
+}
+
+type UpdatePlanBudgetRequest struct {
+	PlanEndDate     *string  `json:"plan_end_date,omitempty"`
+	AllocatedAmount *float64 `json:"allocated_amount,omitempty" binding:"omitempty,gt=0"`
+	AlertThreshold  *float64 `json:"alert_threshold,omitempty" binding:"omitempty,gt=0,lte=100"`
+	Notes           *string  `json:"notes,omitempty"`
+}
+
+func (h *Handler) UpdateParticipantBudget(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdatePlanBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+	budget, ok := h.findPlanBudget(c, participant.ID)
+	if !ok {
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.PlanEndDate != nil {
+		planEnd, err := time.Parse("2006-01-02", *req.PlanEndDate)
+		if err != nil || !planEnd.After(budget.PlanStartDate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_PLAN_DATES",
+					"message": "plan_end_date must be a YYYY-MM-DD date after the plan start",
+				},
+			})
+			return
+		}
+		updates["plan_end_date"] = planEnd
+	}
+	if req.AllocatedAmount != nil {
+		updates["allocated_amount"] = roundCurrency(*req.AllocatedAmount)
+	}
+	if req.AlertThreshold != nil {
+		updates["alert_threshold"] = *req.AlertThreshold
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	if err := h.DB.Model(budget).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update plan budget",
+			},
+		})
+		return
+	}
+
+	h.DB.First(budget, "id = ?", budget.ID)
+	now := time.Now()
+	recent := recentBudgetSpend(h.DB, []string{budget.ID}, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    forecastBudget(*budget, recent[budget.ID], now),
+		"message": "Plan budget updated successfully",
+	})
+}
+
+func (h *Handler) DeleteParticipantBudget(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+	budget, ok := h.findPlanBudget(c, participant.ID)
+	if !ok {
+		return
+	}
+
+	// Budgets that shifts have been drawn from keep their history
+	var drawdowns int64
+	h.DB.Model(&models.PlanBudgetTransaction{}).Where("budget_id = ?", budget.ID).Count(&drawdowns)
+	if drawdowns > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BUDGET_IN_USE",
+				"message": "Plan budget has transactions and cannot be deleted",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Delete(budget).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete plan budget",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Plan budget deleted successfully",
+	})
+}
+
+func (h *Handler) GetBudgetTransactions(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+	budget, ok := h.findPlanBudget(c, participant.ID)
+	if !ok {
+		return
+	}
+
+	var transactions []models.PlanBudgetTransaction
+	if err := h.DB.Where("budget_id = ?", budget.ID).Order("occurred_at DESC").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch budget transactions",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    transactions,
+	})
+}
+
+type BudgetAdjustmentRequest struct {
+	Amount      float64 `json:"amount" binding:"required"` // Positive consumes budget, negative returns it
+	Description string  `json:"description" binding:"required"`
+	Date        string  `json:"date"` // YYYY-MM-DD, defaults to today
+}
+
+// AdjustParticipantBudget records a manual correction, such as spend with another provider
+func (h *Handler) AdjustParticipantBudget(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req BudgetAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	occurredAt := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "date must be in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		occurredAt = parsed
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+	budget, ok := h.findPlanBudget(c, participant.ID)
+	if !ok {
+		return
+	}
+
+	amount := roundCurrency(req.Amount)
+	tx := h.DB.Begin()
+
+	transaction := models.PlanBudgetTransaction{
+		BudgetID:    budget.ID,
+		Type:        "adjustment",
+		Amount:      amount,
+		Description: req.Description,
+		OccurredAt:  occurredAt,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record adjustment",
+			},
+		})
+		return
+	}
+	if err := tx.Model(&models.PlanBudget{}).Where("id = ?", budget.ID).
+		UpdateColumn("spent_amount", gorm.Expr("spent_amount + ?", amount)).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update plan budget",
+			},
+		})
+		return
+	}
+
+	budget.SpentAmount = roundCurrency(budget.SpentAmount + amount)
+	if err := raiseBudgetAlerts(tx, budget, time.Now()); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record budget alerts",
+			},
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save adjustment",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    transaction,
+		"message": "Budget adjustment recorded successfully",
+	})
+}
+
+// GetBudgetForecastReport lists current plan budgets across the organization,
+// worst first, so coordinators can see who will run out before their plan ends
+func (h *Handler) GetBudgetForecastReport(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	query := h.DB.Where("organization_id = ? AND plan_start_date <= ? AND plan_end_date >= ?", orgID, now, now)
+	if purpose := c.Query("purpose"); purpose != "" {
+		query = query.Where("support_purpose = ?", purpose)
+	}
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+
+	var budgets []models.PlanBudget
+	if err := query.Preload("Participant").Find(&budgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch plan budgets",
+			},
+		})
+		return
+	}
+
+	statusFilter := c.Query("status")
+	forecasts := make([]BudgetForecast, 0, len(budgets))
+	summary := map[string]int{"on_track": 0, "at_risk": 0, "overspent": 0}
+	for _, forecast := range forecastBudgets(h.DB, budgets, now) {
+		summary[forecast.Status]++
+		if statusFilter != "" && forecast.Status != statusFilter {
+			continue
+		}
+		forecasts = append(forecasts, forecast)
+	}
+
+	statusRank := map[string]int{"overspent": 0, "at_risk": 1, "on_track": 2}
+	sort.SliceStable(forecasts, func(i, j int) bool {
+		if statusRank[forecasts[i].Status] != statusRank[forecasts[j].Status] {
+			return statusRank[forecasts[i].Status] < statusRank[forecasts[j].Status]
+		}
+		return forecasts[i].Utilisation-forecasts[i].ExpectedUtilisation > forecasts[j].Utilisation-forecasts[j].ExpectedUtilisation
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"budgets":          forecasts,
+			"summary":          summary,
+			"report_generated": now,
+		},
+	})
+}
+
+func (h *Handler) GetBudgetAlerts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("organization_id = ?", orgID)
+	if c.Query("include_acknowledged") != "true" {
+		query = query.Where("acknowledged_at IS NULL")
+	}
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+
+	var alerts []models.BudgetAlert
+	if err := query.Preload("Budget").Preload("Participant").Order("created_at DESC").Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch budget alerts",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    alerts,
+	})
+}
+
+func (h *Handler) AcknowledgeBudgetAlert(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	result := h.DB.Model(&models.BudgetAlert{}).
+		Where("id = ? AND organization_id = ? AND acknowledged_at IS NULL", c.Param("id"), orgID).
+		Updates(map[string]interface{}{
+			"acknowledged_at": now,
+			"acknowledged_by": c.GetString("user_id"),
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to acknowledge alert",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ALERT_NOT_FOUND",
+				"message": "Budget alert not found or already acknowledged",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Budget alert acknowledged",
+	})
+}
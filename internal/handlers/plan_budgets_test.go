@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecastBudget(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget := models.PlanBudget{
+		PlanStartDate:   start,
+		PlanEndDate:     start.AddDate(0, 0, 100),
+		AllocatedAmount: 10000,
+		SpentAmount:     2000,
+	}
+
+	// 20% spent at 20% through the plan
+	forecast := forecastBudget(budget, 0, start.AddDate(0, 0, 20))
+	assert.Equal(t, "on_track", forecast.Status)
+	assert.Equal(t, 100.0, forecast.DailyBurnRate)
+	assert.Equal(t, 10000.0, forecast.ProjectedSpend)
+	assert.Nil(t, forecast.ProjectedExhaustionDate)
+
+	// The trailing window drives the burn rate once the plan has run long enough
+	forecast = forecastBudget(budget, 5600, start.AddDate(0, 0, 40))
+	assert.Equal(t, "at_risk", forecast.Status)
+	assert.Equal(t, 200.0, forecast.DailyBurnRate)
+	assert.NotNil(t, forecast.ProjectedExhaustionDate)
+	assert.True(t, forecast.ProjectedExhaustionDate.Before(budget.PlanEndDate))
+
+	budget.SpentAmount = 10500
+	forecast = forecastBudget(budget, 0, start.AddDate(0, 0, 50))
+	assert.Equal(t, "overspent", forecast.Status)
+	assert.Equal(t, -500.0, forecast.RemainingAmount)
+}
+
+func TestShiftCompletionDrawsDownPlanBudget(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.PlanBudget{}, &models.PlanBudgetTransaction{}, &models.BudgetAlert{})
+
+	participant := models.Participant{
+		ID:             "budget-participant",
+		FirstName:      "Budget",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "BUD123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+
+	now := time.Now()
+	w, response := doRequest(handler, router, "POST", "/api/v1/participants/"+participant.ID+"/budgets", map[string]interface{}{
+		"support_category": "01",
+		"plan_start_date":  now.AddDate(0, 0, -10).Format("2006-01-02"),
+		"plan_end_date":    now.AddDate(0, 0, 355).Format("2006-01-02"),
+		"allocated_amount": 1000,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	budgetID := response["data"].(map[string]interface{})["id"].(string)
+	assert.Equal(t, "core", response["data"].(map[string]interface{})["support_purpose"])
+
+	shift := models.Shift{
+		ID:            "budget-shift",
+		ParticipantID: participant.ID,
+		StaffID:       "test-user",
+		StartTime:     now.Add(-3 * time.Hour),
+		EndTime:       now.Add(-1 * time.Hour),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "in_progress",
+		HourlyRate:    450,
+	}
+	handler.DB.Create(&shift)
+
+	w, _ = doRequest(handler, router, "PATCH", "/api/v1/shifts/"+shift.ID+"/status", map[string]interface{}{"status": "completed"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var budget models.PlanBudget
+	handler.DB.First(&budget, "id = ?", budgetID)
+	assert.Equal(t, 900.0, budget.SpentAmount)
+
+	var updated models.Shift
+	handler.DB.First(&updated, "id = ?", shift.ID)
+	assert.NotNil(t, updated.BudgetID)
+
+	// A copy of the shift read before it was charged isn't charged again
+	charged, err := drawDownPlanBudget(handler.DB, &shift, "test-user")
+	assert.NoError(t, err)
+	assert.Nil(t, charged)
+	handler.DB.First(&budget, "id = ?", budgetID)
+	assert.Equal(t, 900.0, budget.SpentAmount)
+	var drawdowns int64
+	handler.DB.Model(&models.PlanBudgetTransaction{}).Where("shift_id = ?", shift.ID).Count(&drawdowns)
+	assert.Equal(t, int64(1), drawdowns)
+
+	// 90% spent ten days into a year-long plan trips both the threshold and the forecast
+	w, response = doRequest(handler, router, "GET", "/api/v1/budget-alerts", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	alertTypes := []string{}
+	for _, alert := range response["data"].([]interface{}) {
+		alertTypes = append(alertTypes, alert.(map[string]interface{})["type"].(string))
+	}
+	assert.ElementsMatch(t, []string{"threshold", "forecast_overspend"}, alertTypes)
+
+	w, response = doRequest(handler, router, "GET", "/api/v1/reports/budget-forecast?status=at_risk", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, response["data"].(map[string]interface{})["budgets"], 1)
+}
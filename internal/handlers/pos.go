@@ -52,7 +52,7 @@ func (h *Handler) CreatePOSTransaction(c *gin.Context) {
 	}
 
 	// Process items and calculate totals
-	var subTotal, taxTotal float64
+	var subTotal, taxTotal, costOfSales float64
 	for _, item := range req.Items {
 		item.TransactionID = transaction.ID
 
@@ -100,6 +100,7 @@ func (h *Handler) CreatePOSTransaction(c *gin.Context) {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create inventory movement"})
 				return
 			}
+			costOfSales += float64(item.Quantity) * product.CostPrice
 
 			item.ItemName = product.Name
 			item.UnitPrice = product.SellingPrice
@@ -165,6 +166,12 @@ func (h *Handler) CreatePOSTransaction(c *gin.Context) {
 		return
 	}
 
+	if err := h.postPOSSale(tx, &transaction, req.Payments, costOfSales); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post sale to the ledger"})
+		return
+	}
+
 	tx.Commit()
 
 	// Reload with relationships
@@ -319,6 +326,12 @@ func (h *Handler) VoidPOSTransaction(c *gin.Context) {
 		return
 	}
 
+	if err := h.postPOSVoid(tx, &transaction, c.GetString("user_id")); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reverse sale in the ledger"})
+		return
+	}
+
 	tx.Commit()
 
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction voided successfully"})
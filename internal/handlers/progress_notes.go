@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// carePlanGoals lists the goals in a care plan's Goals field, which holds a
+// JSON array of strings or goal objects, or plain text with one goal per line
+func carePlanGoals(goals string) []string {
+	goals = strings.TrimSpace(goals)
+	if goals == "" {
+		return nil
+	}
+
+	var plain []string
+	if err := json.Unmarshal([]byte(goals), &plain); err == nil {
+		return plain
+	}
+
+	var titles []string
+
+	var objects []map[string]interface{}
+	if err := json.Unmarshal([]byte(goals), &objects); err == nil {
+		for _, object := range objects {
+			for _, key := range []string{"title", "goal", "name", "description"} {
+				if title, ok := object[key].(string); ok && strings.TrimSpace(title) != "" {
+					titles = append(titles, strings.TrimSpace(title))
+					break
+				}
+			}
+		}
+		return titles
+	}
+
+	for _, line := range strings.Split(goals, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if line != "" {
+			titles = append(titles, line)
+		}
+	}
+	return titles
+}
+
+// shiftHasProgressNote reports whether a progress note has been written for the shift
+func (h *Handler) shiftHasProgressNote(shiftID string) bool {
+	var count int64
+	h.DB.Model(&models.ShiftProgressNote{}).Where("shift_id = ?", shiftID).Count(&count)
+	return count > 0
+}
+
+// progressNoteRequired reports whether the shift can't be completed until a
+// progress note is written, as set by OrganizationSettings.RequireShiftNotes
+func (h *Handler) progressNoteRequired(orgID interface{}, shiftID string) bool {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil || !settings.RequireShiftNotes {
+		return false
+	}
+	return !h.shiftHasProgressNote(shiftID)
+}
+
+// GetShiftProgressNote returns the progress note written for a shift
+func (h *Handler) GetShiftProgressNote(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+
+	var note models.ShiftProgressNote
+	if err := h.DB.Preload("Goals").Preload("Staff").Where("shift_id = ?", shift.ID).First(&note).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "PROGRESS_NOTE_NOT_FOUND",
+					"message": "No progress note has been written for this shift",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch progress note",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    note,
+	})
+}
+
+type ProgressNoteGoalRequest struct {
+	GoalID  string `json:"goal_id"`
+	Goal    string `json:"goal" binding:"required_without=GoalID"`
+	Rating  string `json:"rating" binding:"required,oneof=no_progress some_progress good_progress achieved"`
+	Comment string `json:"comment"`
+}
+
+type SaveProgressNoteRequest struct {
+	Summary    string                    `json:"summary" binding:"required"`
+	Mood       string                    `json:"mood" binding:"omitempty,oneof=very_low low neutral good very_good"`
+	Activities []string                  `json:"activities"`
+	Concerns   string                    `json:"concerns"`
+	CarePlanID string                    `json:"care_plan_id"` // Defaults to the participant's active care plan when goals are given
+	Goals      []ProgressNoteGoalRequest `json:"goals" binding:"dive"`
+}
+
+// SaveShiftProgressNote writes or replaces the progress note for a shift that
+// has started. Goals must come from the participant's care plan.
+func (h *Handler) SaveShiftProgressNote(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+	role := c.GetString("user_role")
+
+	var req SaveProgressNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+
+	if shift.StaffID != userID && role != "admin" && role != "manager" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INSUFFICIENT_PERMISSIONS",
+				"message": "You can only write progress notes for your own shifts",
+			},
+		})
+		return
+	}
+
+	if shift.Status != "in_progress" && shift.Status != "completed" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_OPERATION",
+				"message": "Progress notes can only be written once a shift has started",
+			},
+		})
+		return
+	}
+
+	var carePlan *models.CarePlan
+	if req.CarePlanID != "" || len(req.Goals) > 0 {
+		var plan models.CarePlan
+		query := h.DB.Where("participant_id = ?", shift.ParticipantID)
+		if req.CarePlanID != "" {
+			query = query.Where("id = ?", req.CarePlanID)
+		} else {
+			query = query.Where("status = ?", "active").Order("start_date DESC")
+		}
+		if err := query.First(&plan).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_CARE_PLAN",
+					"message": "Participant has no matching care plan to record goal progress against",
+				},
+			})
+			return
+		}
+		carePlan = &plan
+	}
+
+	goals := make([]models.ProgressNoteGoal, 0, len(req.Goals))
+	if carePlan != nil {
+		// Goals are matched against the plan's goal records, or the legacy
+		// Goals text for plans written before goal records existed
+		var goalRecords []models.CarePlanGoal
+		h.DB.Where("care_plan_id = ? AND status = ?", carePlan.ID, "active").Order("sort_order ASC").Find(&goalRecords)
+		planGoals := carePlanGoals(carePlan.Goals)
+		if len(goalRecords) > 0 {
+			planGoals = make([]string, 0, len(goalRecords))
+			for _, record := range goalRecords {
+				planGoals = append(planGoals, record.Title)
+			}
+		}
+
+		for _, goal := range req.Goals {
+			title := strings.TrimSpace(goal.Goal)
+			matched := ""
+			var record *models.CarePlanGoal
+			if len(goalRecords) > 0 {
+				for i := range goalRecords {
+					if (goal.GoalID != "" && goalRecords[i].ID == goal.GoalID) ||
+						(goal.GoalID == "" && strings.EqualFold(goalRecords[i].Title, title)) {
+						record = &goalRecords[i]
+						matched = record.Title
+						break
+					}
+				}
+			} else if goal.GoalID == "" {
+				for _, planGoal := range planGoals {
+					if strings.EqualFold(planGoal, title) {
+						matched = planGoal
+						break
+					}
+				}
+			}
+			if matched == "" {
+				if title == "" {
+					title = goal.GoalID
+				}
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "UNKNOWN_GOAL",
+						"message": "Goal '" + title + "' is not in the participant's care plan",
+						"details": planGoals,
+					},
+				})
+				return
+			}
+			progress := models.ProgressNoteGoal{
+				CarePlanID: carePlan.ID,
+				Goal:       matched,
+				Rating:     goal.Rating,
+				Comment:    goal.Comment,
+			}
+			if record != nil {
+				progress.GoalID = &record.ID
+				progress.GoalKey = record.GoalKey
+			}
+			goals = append(goals, progress)
+		}
+	}
+
+	activities := ""
+	if len(req.Activities) > 0 {
+		encoded, _ := json.Marshal(req.Activities)
+		activities = string(encoded)
+	}
+
+	tx := h.DB.Begin()
+	var note models.ShiftProgressNote
+	isNew := tx.Where("shift_id = ?", shift.ID).First(&note).Error == gorm.ErrRecordNotFound
+	note.ShiftID = shift.ID
+	note.ParticipantID = shift.ParticipantID
+	note.StaffID = userID
+	note.Mood = req.Mood
+	note.Activities = activities
+	note.Summary = req.Summary
+	note.Concerns = req.Concerns
+	note.CarePlanID = nil
+	if carePlan != nil {
+		note.CarePlanID = &carePlan.ID
+	}
+
+	if err := tx.Save(&note).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save progress note",
+			},
+		})
+		return
+	}
+
+	if err := tx.Where("progress_note_id = ?", note.ID).Delete(&models.ProgressNoteGoal{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save progress note",
+			},
+		})
+		return
+	}
+	for i := range goals {
+		goals[i].ProgressNoteID = note.ID
+		if err := tx.Create(&goals[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to save goal progress",
+				},
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save progress note",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Goals").Preload("Staff").First(&note, "id = ?", note.ID)
+
+	status, message := http.StatusOK, "Progress note updated successfully"
+	if isNew {
+		status, message = http.StatusCreated, "Progress note created successfully"
+	}
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    note,
+		"message": message,
+	})
+}
+
+// GetParticipantProgressNotes lists a participant's progress notes, newest shift first
+func (h *Handler) GetParticipantProgressNotes(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := h.DB.Model(&models.ShiftProgressNote{}).
+		Joins("JOIN shifts ON shift_progress_notes.shift_id = shifts.id").
+		Where("shift_progress_notes.participant_id = ?", participant.ID)
+	if carePlanID := c.Query("care_plan_id"); carePlanID != "" {
+		query = query.Where("shift_progress_notes.care_plan_id = ?", carePlanID)
+	}
+	for _, bound := range []struct {
+		param string
+		where string
+		days  int
+	}{{"start_date", "shifts.start_time >= ?", 0}, {"end_date", "shifts.start_time < ?", 1}} {
+		if value := c.Query(bound.param); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INVALID_DATE",
+						"message": "Invalid date format. Use YYYY-MM-DD",
+					},
+				})
+				return
+			}
+			query = query.Where(bound.where, date.AddDate(0, 0, bound.days))
+		}
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var notes []models.ShiftProgressNote
+	if err := query.Preload("Goals").Preload("Staff").
+		Order("shifts.start_time DESC").Offset((page - 1) * limit).Limit(limit).
+		Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch progress notes",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"progress_notes": notes,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + int64(limit) - 1) / int64(limit),
+			},
+		},
+	})
+}
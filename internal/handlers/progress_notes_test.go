@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCarePlanGoals(t *testing.T) {
+	assert.Equal(t, []string{"Cook a meal", "Catch the bus"}, carePlanGoals(`["Cook a meal", "Catch the bus"]`))
+	assert.Equal(t, []string{"Cook a meal", "Catch the bus"}, carePlanGoals(`[{"title": "Cook a meal"}, {"goal": "Catch the bus"}]`))
+	assert.Equal(t, []string{"Cook a meal", "Catch the bus"}, carePlanGoals("- Cook a meal\n\n* Catch the bus"))
+	assert.Equal(t, []string{"Independence"}, carePlanGoals("Independence"))
+	assert.Empty(t, carePlanGoals("  "))
+}
+
+func TestShiftProgressNotes(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.CarePlan{}, &models.ShiftProgressNote{}, &models.ProgressNoteGoal{},
+		&models.OrganizationSettings{}, &models.PlanBudget{}, &models.PlanBudgetTransaction{})
+
+	participant := models.Participant{
+		ID:             "notes-participant",
+		FirstName:      "Notes",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "NOTE123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+	handler.DB.Create(&models.CarePlan{
+		ID:            "notes-plan",
+		ParticipantID: participant.ID,
+		Title:         "Daily living",
+		Goals:         `["Cook a meal", "Catch the bus"]`,
+		StartDate:     time.Now().AddDate(0, -1, 0),
+		Status:        "active",
+		CreatedBy:     "test-user",
+	})
+	handler.DB.Create(&models.OrganizationSettings{ID: "notes-settings", OrganizationID: "test-org", RequireShiftNotes: true})
+
+	shift := models.Shift{
+		ParticipantID: participant.ID,
+		StaffID:       "test-user",
+		StartTime:     time.Now().Add(-time.Hour),
+		EndTime:       time.Now().Add(time.Hour),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "in_progress",
+		HourlyRate:    50,
+	}
+	handler.DB.Create(&shift)
+
+	t.Run("Shift can't be completed without a progress note", func(t *testing.T) {
+		w, response := doRequest(handler, router, "PATCH", "/api/v1/shifts/"+shift.ID+"/status", map[string]interface{}{"status": "completed"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "PROGRESS_NOTE_REQUIRED", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Goals must come from the care plan", func(t *testing.T) {
+		w, response := doRequest(handler, router, "PUT", "/api/v1/shifts/"+shift.ID+"/progress-note", map[string]interface{}{
+			"summary": "Went shopping",
+			"goals":   []map[string]interface{}{{"goal": "Learn to fly", "rating": "some_progress"}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "UNKNOWN_GOAL", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Progress note records goals worked on", func(t *testing.T) {
+		w, response := doRequest(handler, router, "PUT", "/api/v1/shifts/"+shift.ID+"/progress-note", map[string]interface{}{
+			"summary":    "Cooked pasta together",
+			"mood":       "good",
+			"activities": []string{"cooking", "shopping"},
+			"goals":      []map[string]interface{}{{"goal": "cook a meal", "rating": "good_progress", "comment": "Chopped the vegetables"}},
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "notes-plan", data["care_plan_id"])
+		assert.Equal(t, `["cooking","shopping"]`, data["activities"])
+		goals := data["goals"].([]interface{})
+		assert.Len(t, goals, 1)
+		assert.Equal(t, "Cook a meal", goals[0].(map[string]interface{})["goal"])
+
+		// Saving again replaces the note rather than adding another
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/shifts/"+shift.ID+"/progress-note", map[string]interface{}{"summary": "Cooked pasta"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var goalCount int64
+		handler.DB.Model(&models.ProgressNoteGoal{}).Count(&goalCount)
+		assert.Equal(t, int64(0), goalCount)
+	})
+
+	t.Run("Shift completes once the note is written", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/shifts/"+shift.ID+"/status", map[string]interface{}{"status": "completed"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, response := doRequest(handler, router, "GET", "/api/v1/participants/"+participant.ID+"/progress-notes", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"].(map[string]interface{})["progress_notes"], 1)
+	})
+}
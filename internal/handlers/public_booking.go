@@ -0,0 +1,766 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// manageableBookingStatuses are the statuses a guest can still reschedule or cancel from
+var manageableBookingStatuses = map[string]bool{"pending_approval": true, "scheduled": true, "confirmed": true}
+
+// signedToken signs an ID for a purpose, for links that work without an account
+func (h *Handler) signedToken(purpose, id string) string {
+	mac := hmac.New(sha256.New, []byte(h.Config.JWTSecret))
+	mac.Write([]byte(purpose + ":" + id))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// idFromSignedToken checks a token's signature for a purpose and returns the ID
+func (h *Handler) idFromSignedToken(purpose, token string) (string, bool) {
+	dot := strings.LastIndex(token, ".")
+	if dot <= 0 {
+		return "", false
+	}
+	id := token[:dot]
+	return id, hmac.Equal([]byte(token), []byte(h.signedToken(purpose, id)))
+}
+
+// bookingManageToken signs a booking ID so the guest who made the booking can
+// manage it without an account
+func (h *Handler) bookingManageToken(bookingID string) string {
+	return h.signedToken("booking", bookingID)
+}
+
+// bookingIDFromManageToken checks a manage token's signature and returns the booking ID
+func (h *Handler) bookingIDFromManageToken(token string) (string, bool) {
+	return h.idFromSignedToken("booking", token)
+}
+
+// findPublicOrganization loads the organization named by :slug, writing the
+// error response when it doesn't exist or doesn't take online bookings
+func (h *Handler) findPublicOrganization(c *gin.Context) (*models.Organization, bool) {
+	slug := c.Param("slug")
+	var org models.Organization
+	if err := h.DB.Where("slug = ?", slug).First(&org).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ORGANIZATION_NOT_FOUND",
+				"message": "Organization not found",
+			},
+		})
+		return nil, false
+	}
+	if !org.BookingSettings.EnableOnlineBooking {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "ONLINE_BOOKING_DISABLED",
+				"message": "This organization doesn't take online bookings",
+			},
+		})
+		return nil, false
+	}
+	return &org, true
+}
+
+// publicServices loads the organization's active services with the given IDs,
+// writing the error response when any are missing
+func (h *Handler) publicServices(c *gin.Context, org *models.Organization, serviceIDs []string) ([]models.Service, bool) {
+	var services []models.Service
+	if len(serviceIDs) > 0 {
+		h.DB.Preload("ResourceRequirements").Where("id IN ? AND organization_id = ? AND is_active = ?", serviceIDs, org.ID, true).Find(&services)
+	}
+	if len(serviceIDs) == 0 || len(services) != len(serviceIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_SERVICES",
+				"message": "Choose one or more of the organization's services",
+			},
+		})
+		return nil, false
+	}
+	return services, true
+}
+
+// servicesDuration is the booking length for a set of services
+func servicesDuration(services []models.Service) time.Duration {
+	duration := 0
+	for _, service := range services {
+		duration += service.Duration
+	}
+	return time.Duration(duration) * time.Minute
+}
+
+// advanceBookingLimits are the earliest and latest start times the organization
+// accepts online bookings for
+func advanceBookingLimits(settings models.BookingSettings, now time.Time) (time.Time, time.Time) {
+	notBefore := now.Add(time.Duration(settings.MinAdvanceBooking) * time.Hour)
+	var notAfter time.Time
+	if settings.MaxAdvanceBooking > 0 {
+		notAfter = now.Add(time.Duration(settings.MaxAdvanceBooking) * time.Hour)
+	}
+	return notBefore, notAfter
+}
+
+// checkPublicSlot finds the open slot a booking described by q can take at
+// start, writing the error response and returning false when there isn't one.
+// tx should hold lockBookingSchedule so the slot is still open at commit.
+func (h *Handler) checkPublicSlot(c *gin.Context, tx *gorm.DB, org *models.Organization, start time.Time, q availability.Query) (*availability.Slot, bool) {
+	notBefore, notAfter := advanceBookingLimits(org.BookingSettings, time.Now())
+	if start.Before(notBefore) || (!notAfter.IsZero() && start.After(notAfter)) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OUTSIDE_BOOKING_WINDOW",
+				"message": fmt.Sprintf("Bookings must be made between %d hours and %d hours in advance", org.BookingSettings.MinAdvanceBooking, org.BookingSettings.MaxAdvanceBooking),
+			},
+		})
+		return nil, false
+	}
+
+	loc := h.organizationLocation(org.ID)
+	q.From, q.To = start.In(loc), start.In(loc)
+	if err := lockBookingSchedule(tx, org.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check availability",
+			},
+		})
+		return nil, false
+	}
+	days, err := h.findBookingSlots(tx, org, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check availability",
+			},
+		})
+		return nil, false
+	}
+	for _, day := range days {
+		for i := range day.Slots {
+			if day.Slots[i].Start.Equal(start) {
+				return &day.Slots[i], true
+			}
+		}
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"success": false,
+		"error": gin.H{
+			"code":    "SLOT_UNAVAILABLE",
+			"message": "That time is no longer available",
+		},
+	})
+	return nil, false
+}
+
+// publicBookingView is what a guest sees of their booking
+func (h *Handler) publicBookingView(org *models.Organization, booking *models.Booking) gin.H {
+	services := make([]gin.H, 0, len(booking.Services))
+	for _, service := range booking.Services {
+		services = append(services, gin.H{
+			"id":       service.ID,
+			"name":     service.Name,
+			"duration": service.Duration,
+			"price":    service.Price,
+		})
+	}
+
+	changeDeadline := booking.StartTime.Add(-time.Duration(org.BookingSettings.CancellationWindow) * time.Hour)
+	canChange := org.BookingSettings.AllowCancellation && manageableBookingStatuses[booking.Status] && time.Now().Before(changeDeadline)
+
+	view := gin.H{
+		"id":              booking.ID,
+		"status":          booking.Status,
+		"start_time":      booking.StartTime,
+		"end_time":        booking.EndTime,
+		"total_price":     booking.TotalPrice,
+		"notes":           booking.Notes,
+		"services":        services,
+		"customer_name":   strings.TrimSpace(booking.Customer.FirstName + " " + booking.Customer.LastName),
+		"can_change":      canChange,
+		"change_deadline": changeDeadline,
+	}
+	if booking.DepositStatus != "" {
+		deposit := gin.H{
+			"amount":     booking.DepositAmount,
+			"status":     booking.DepositStatus,
+			"expires_at": booking.HoldExpiresAt,
+		}
+		var payment models.BookingPayment
+		if booking.DepositStatus == "pending" && h.DB.Where("booking_id = ? AND kind = ? AND status = ?", booking.ID, "deposit", "pending").
+			Order("created_at DESC").First(&payment).Error == nil {
+			deposit["checkout_url"] = payment.CheckoutURL
+		}
+		view["deposit"] = deposit
+	}
+	return view
+}
+
+// startDeposit asks the payment provider for a new booking's deposit. If the
+// provider can't take it the hold is released and an error response written.
+func (h *Handler) startDeposit(c *gin.Context, booking *models.Booking) bool {
+	if _, err := h.requestDeposit(c.Request.Context(), booking); err != nil {
+		log.Printf("Failed to request deposit for booking %s: %v", booking.ID, err)
+		h.releaseHeldBooking(booking, "failed")
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PAYMENT_ERROR",
+				"message": "Payments are unavailable right now, please try again",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetPublicOrganization returns what the booking page needs to know about an organization
+func (h *Handler) GetPublicOrganization(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"name":           org.Name,
+			"slug":           org.Slug,
+			"business_type":  org.BusinessType,
+			"phone":          org.Phone,
+			"email":          org.Email,
+			"website":        org.Website,
+			"address":        org.Address,
+			"business_hours": org.BusinessHours,
+			"booking": gin.H{
+				"min_advance_booking": org.BookingSettings.MinAdvanceBooking,
+				"max_advance_booking": org.BookingSettings.MaxAdvanceBooking,
+				"require_approval":    org.BookingSettings.RequireApproval,
+				"allow_cancellation":  org.BookingSettings.AllowCancellation,
+				"cancellation_window": org.BookingSettings.CancellationWindow,
+			},
+		},
+	})
+}
+
+// GetPublicServices lists the services that can be booked online
+func (h *Handler) GetPublicServices(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+
+	query := h.DB.Where("organization_id = ? AND is_active = ?", org.ID, true)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var services []models.Service
+	if err := query.Order("category, name").Find(&services).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch services",
+			},
+		})
+		return
+	}
+
+	data := make([]gin.H, 0, len(services))
+	for _, service := range services {
+		data = append(data, gin.H{
+			"id":               service.ID,
+			"name":             service.Name,
+			"description":      service.Description,
+			"category":         service.Category,
+			"duration":         service.Duration,
+			"price":            service.Price,
+			"requires_vehicle": service.RequiresVehicle,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// GetPublicAvailability lists the start times, in the organization's timezone,
+// that can take a booking for the chosen services on a date, or on each date
+// from start_date to end_date
+func (h *Handler) GetPublicAvailability(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+
+	services, ok := h.publicServices(c, org, splitIDs(c.Query("service_ids")))
+	if !ok {
+		return
+	}
+
+	loc := h.organizationLocation(org.ID)
+	startDate := c.DefaultQuery("start_date", c.Query("date"))
+	from, err := time.ParseInLocation("2006-01-02", startDate, loc)
+	to := from
+	if endDate := c.DefaultQuery("end_date", c.Query("date")); err == nil && endDate != "" {
+		to, err = time.ParseInLocation("2006-01-02", endDate, loc)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+	if to.Before(from) || to.Sub(from) >= availability.MaxDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": fmt.Sprintf("Date range must run forwards and cover at most %d days", availability.MaxDays),
+			},
+		})
+		return
+	}
+
+	notBefore, notAfter := advanceBookingLimits(org.BookingSettings, time.Now())
+	query := availability.Query{
+		From:         from,
+		To:           to,
+		Duration:     servicesDuration(services),
+		Buffer:       bookingBuffer(org, services),
+		Requirements: serviceRequirements(services),
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	days, err := h.findBookingSlots(h.DB, org, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check availability",
+			},
+		})
+		return
+	}
+	// Guests don't need to see which bay or chair they would get
+	for _, day := range days {
+		for i := range day.Slots {
+			day.Slots[i].Resources = nil
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"timezone": loc.String(),
+			"duration": int(query.Duration / time.Minute),
+			"days":     days,
+		},
+	})
+}
+
+type PublicBookingRequest struct {
+	ServiceIDs []string  `json:"service_ids" binding:"required,min=1"`
+	StartTime  time.Time `json:"start_time" binding:"required"`
+	FirstName  string    `json:"first_name" binding:"required"`
+	LastName   string    `json:"last_name" binding:"required"`
+	Email      string    `json:"email" binding:"required,email"`
+	Phone      string    `json:"phone" binding:"required"`
+	Notes      string    `json:"notes"`
+}
+
+// CreatePublicBooking books services for a guest, matching an existing customer
+// only when both email and phone agree. The booking waits for staff approval
+// when the organization requires it.
+func (h *Handler) CreatePublicBooking(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+
+	var req PublicBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	services, ok := h.publicServices(c, org, req.ServiceIDs)
+	if !ok {
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	phone := strings.TrimSpace(req.Phone)
+
+	tx := h.DB.Begin()
+	slot, ok := h.checkPublicSlot(c, tx, org, req.StartTime, availability.Query{
+		Duration:     servicesDuration(services),
+		Buffer:       bookingBuffer(org, services),
+		Requirements: serviceRequirements(services),
+	})
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	var customer models.Customer
+	err := tx.Where("organization_id = ? AND LOWER(email) = ? AND phone = ?", org.ID, email, phone).
+		Order("created_at ASC").First(&customer).Error
+	if err == gorm.ErrRecordNotFound {
+		customer = models.Customer{
+			FirstName:      strings.TrimSpace(req.FirstName),
+			LastName:       strings.TrimSpace(req.LastName),
+			Email:          email,
+			Phone:          phone,
+			Notes:          "Created from an online booking",
+			OrganizationID: org.ID,
+			IsActive:       true,
+		}
+		err = tx.Create(&customer).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save customer details",
+			},
+		})
+		return
+	}
+
+	status := "scheduled"
+	if org.BookingSettings.RequireApproval {
+		status = "pending_approval"
+	}
+	tax, err := defaultTaxRate(tx, org.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to price booking",
+			},
+		})
+		return
+	}
+	steps := stepsForServices(services, nil)
+
+	booking := models.Booking{
+		CustomerID:     customer.ID,
+		OrganizationID: org.ID,
+		StartTime:      req.StartTime,
+		EndTime:        scheduleBookingSteps(steps, req.StartTime),
+		Status:         status,
+		Source:         "online",
+		Notes:          req.Notes,
+	}
+	priceBooking(steps, nil, tax).applyTo(&booking)
+	h.holdForDeposit(org, &booking, services)
+	if err := tx.Create(&booking).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create booking",
+			},
+		})
+		return
+	}
+	err = tx.Model(&booking).Association("Services").Append(&services)
+	if err == nil {
+		err = saveBookingSteps(tx, booking.ID, steps)
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create booking",
+			},
+		})
+		return
+	}
+	if err := saveBookingResources(tx, booking.ID, slot.Resources); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create booking",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create booking",
+			},
+		})
+		return
+	}
+	if booking.Status == "pending_payment" && !h.startDeposit(c, &booking) {
+		return
+	}
+	h.scheduleBookingNotifications(booking.ID)
+
+	h.DB.Preload("Customer").Preload("Services").First(&booking, "id = ?", booking.ID)
+
+	message := "Booking confirmed"
+	switch booking.Status {
+	case "pending_approval":
+		message = "Booking received and awaiting confirmation"
+	case "pending_payment":
+		message = "Booking held until the deposit is paid"
+	}
+	// Echo the name the guest gave rather than what's on file for the customer
+	view := h.publicBookingView(org, &booking)
+	view["customer_name"] = strings.TrimSpace(strings.TrimSpace(req.FirstName) + " " + strings.TrimSpace(req.LastName))
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"booking":      view,
+			"manage_token": h.bookingManageToken(booking.ID),
+		},
+		"message": message,
+	})
+}
+
+// findManagedBooking loads the booking a manage token was issued for, writing
+// the error response when the token is invalid
+func (h *Handler) findManagedBooking(c *gin.Context, org *models.Organization) (*models.Booking, bool) {
+	bookingID, valid := h.bookingIDFromManageToken(c.Param("token"))
+	var booking models.Booking
+	if !valid || h.DB.Preload("Customer").Preload("Services.ResourceRequirements").
+		Where("id = ? AND organization_id = ?", bookingID, org.ID).First(&booking).Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "BOOKING_NOT_FOUND",
+				"message": "Booking not found",
+			},
+		})
+		return nil, false
+	}
+	return &booking, true
+}
+
+// checkBookingChangeable writes the error response and returns false when a
+// guest can no longer reschedule or cancel the booking
+func checkBookingChangeable(c *gin.Context, org *models.Organization, booking *models.Booking) bool {
+	if !org.BookingSettings.AllowCancellation {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CHANGES_NOT_ALLOWED",
+				"message": "Please contact the organization to change this booking",
+			},
+		})
+		return false
+	}
+	if !manageableBookingStatuses[booking.Status] {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_STATUS",
+				"message": "This booking can no longer be changed",
+			},
+		})
+		return false
+	}
+	if time.Now().Add(time.Duration(org.BookingSettings.CancellationWindow) * time.Hour).After(booking.StartTime) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "CANCELLATION_WINDOW_PASSED",
+				"message": fmt.Sprintf("Bookings can only be changed up to %d hours before the appointment", org.BookingSettings.CancellationWindow),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetPublicBooking shows a guest their booking
+func (h *Handler) GetPublicBooking(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+	booking, ok := h.findManagedBooking(c, org)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.publicBookingView(org, booking),
+	})
+}
+
+// ReschedulePublicBooking moves a guest's booking to another available time
+func (h *Handler) ReschedulePublicBooking(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		StartTime time.Time `json:"start_time" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	booking, ok := h.findManagedBooking(c, org)
+	if !ok || !checkBookingChangeable(c, org, booking) {
+		return
+	}
+
+	duration := booking.EndTime.Sub(booking.StartTime)
+	tx := h.DB.Begin()
+	slot, ok := h.checkPublicSlot(c, tx, org, req.StartTime, availability.Query{
+		Duration:         duration,
+		Buffer:           bookingBuffer(org, booking.Services),
+		Requirements:     serviceRequirements(booking.Services),
+		ExcludeBookingID: booking.ID,
+	})
+	if !ok {
+		tx.Rollback()
+		return
+	}
+
+	updates := map[string]interface{}{
+		"start_time": req.StartTime,
+		"end_time":   req.StartTime.Add(duration),
+	}
+	if org.BookingSettings.RequireApproval {
+		updates["status"] = "pending_approval"
+	}
+	moveBy := req.StartTime.Sub(booking.StartTime)
+	err := tx.Model(booking).Updates(updates).Error
+	if err == nil {
+		err = moveBookingSteps(tx, booking.ID, moveBy)
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to reschedule booking",
+			},
+		})
+		return
+	}
+	if err := saveBookingResources(tx, booking.ID, slot.Resources); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to reschedule booking",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to reschedule booking",
+			},
+		})
+		return
+	}
+	h.scheduleBookingNotifications(booking.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.publicBookingView(org, booking),
+		"message": "Booking rescheduled",
+	})
+}
+
+// CancelPublicBooking cancels a guest's booking
+func (h *Handler) CancelPublicBooking(c *gin.Context) {
+	org, ok := h.findPublicOrganization(c)
+	if !ok {
+		return
+	}
+	booking, ok := h.findManagedBooking(c, org)
+	if !ok || !checkBookingChangeable(c, org, booking) {
+		return
+	}
+
+	if err := h.DB.Model(booking).Update("status", "cancelled").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to cancel booking",
+			},
+		})
+		return
+	}
+	h.scheduleBookingNotifications(booking.ID)
+	if err := h.settleBooking(booking, nil); err != nil {
+		log.Printf("Failed to settle payments for booking %s: %v", booking.ID, err)
+	}
+	if _, err := h.offerFreedSlot(booking); err != nil {
+		log.Printf("Failed to offer freed slot from booking %s: %v", booking.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.publicBookingView(org, booking),
+		"message": "Booking cancelled",
+	})
+}
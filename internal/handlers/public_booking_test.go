@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookingManageToken(t *testing.T) {
+	handler, _ := setupTestHandler()
+
+	token := handler.bookingManageToken("booking-1")
+	bookingID, valid := handler.bookingIDFromManageToken(token)
+	assert.True(t, valid)
+	assert.Equal(t, "booking-1", bookingID)
+
+	_, valid = handler.bookingIDFromManageToken("booking-2" + token[len("booking-1"):])
+	assert.False(t, valid)
+	_, valid = handler.bookingIDFromManageToken("booking-1")
+	assert.False(t, valid)
+}
+
+func TestPublicBooking(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{})
+
+	hours := models.BusinessHours{Timezone: "Australia/Adelaide"}
+	hours.MondayOpen, hours.MondayClose = "09:00", "17:00"
+	hours.TuesdayOpen, hours.TuesdayClose = "09:00", "17:00"
+	hours.WednesdayOpen, hours.WednesdayClose = "09:00", "17:00"
+	hours.ThursdayOpen, hours.ThursdayClose = "09:00", "17:00"
+	hours.FridayOpen, hours.FridayClose = "09:00", "17:00"
+	hours.SaturdayOpen, hours.SaturdayClose = "09:00", "17:00"
+	hours.SundayOpen, hours.SundayClose = "09:00", "17:00"
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Updates(models.Organization{BusinessHours: hours})
+
+	service := models.Service{ID: "public-service", OrganizationID: "test-org", Name: "Haircut", Category: "beauty", Duration: 45, Price: 60, IsActive: true}
+	handler.DB.Create(&service)
+
+	loc, _ := time.LoadLocation("Australia/Adelaide")
+	date := time.Now().In(loc).AddDate(0, 0, 3).Format("2006-01-02")
+
+	var slots []interface{}
+	t.Run("Availability lists open slots for the services", func(t *testing.T) {
+		w, response := doPublicRequest(router, "GET", "/api/v1/public/test-org/availability?service_ids=public-service&date="+date, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(45), data["duration"])
+		slots = data["days"].([]interface{})[0].(map[string]interface{})["slots"].([]interface{})
+		assert.NotEmpty(t, slots)
+		assert.Equal(t, "09:00", slots[0].(map[string]interface{})["time"])
+	})
+
+	guest := func(start interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"service_ids": []string{service.ID},
+			"start_time":  start,
+			"first_name":  "Gina",
+			"last_name":   "Guest",
+			"email":       "Gina@Example.com",
+			"phone":       "0400111222",
+		}
+	}
+
+	var token string
+	t.Run("Guest books an open slot", func(t *testing.T) {
+		w, response := doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings", guest(slots[0].(map[string]interface{})["start_time"]))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		booking := data["booking"].(map[string]interface{})
+		assert.Equal(t, "scheduled", booking["status"])
+		assert.Equal(t, true, booking["can_change"])
+		token = data["manage_token"].(string)
+
+		w, response = doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings", guest(slots[0].(map[string]interface{})["start_time"]))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "SLOT_UNAVAILABLE", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Returning guests are matched to their customer record", func(t *testing.T) {
+		handler.DB.Model(&models.Organization{ID: "test-org"}).Update("booking_require_approval", true)
+
+		w, response := doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings", guest(slots[2].(map[string]interface{})["start_time"]))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "pending_approval", response["data"].(map[string]interface{})["booking"].(map[string]interface{})["status"])
+
+		var customers int64
+		handler.DB.Model(&models.Customer{}).Where("organization_id = ?", "test-org").Count(&customers)
+		assert.Equal(t, int64(1), customers)
+
+		// Sharing only a phone number doesn't attach the booking to Gina's record
+		// or show her name back
+		stranger := guest(slots[3].(map[string]interface{})["start_time"])
+		stranger["first_name"], stranger["email"] = "Sam", "sam@example.com"
+		w, response = doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings", stranger)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "Sam Guest", response["data"].(map[string]interface{})["booking"].(map[string]interface{})["customer_name"])
+		handler.DB.Model(&models.Customer{}).Where("organization_id = ?", "test-org").Count(&customers)
+		assert.Equal(t, int64(2), customers)
+	})
+
+	t.Run("Bookings must respect the minimum notice", func(t *testing.T) {
+		w, response := doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings", guest(time.Now().Add(10*time.Minute)))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "OUTSIDE_BOOKING_WINDOW", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Guest reschedules and cancels with the manage link", func(t *testing.T) {
+		w, _ := doPublicRequest(router, "GET", "/api/v1/public/test-org/bookings/"+token+"x", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w, response := doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings/"+token+"/reschedule", map[string]interface{}{
+			"start_time": slots[1].(map[string]interface{})["start_time"],
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		booking := response["data"].(map[string]interface{})
+		assert.Equal(t, "pending_approval", booking["status"])
+
+		w, response = doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings/"+token+"/cancel", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "cancelled", response["data"].(map[string]interface{})["status"])
+
+		w, response = doPublicRequest(router, "POST", "/api/v1/public/test-org/bookings/"+token+"/cancel", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "INVALID_STATUS", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Organizations are only found by their own slug", func(t *testing.T) {
+		other := models.Organization{ID: "other-org", Name: "Test Org", ABN: "11111111111"}
+		assert.NoError(t, handler.DB.Create(&other).Error)
+		assert.Equal(t, "test-org-othero", other.Slug)
+		handler.DB.Model(&other).Update("booking_enable_online_booking", true)
+
+		w, _ := doPublicRequest(router, "GET", "/api/v1/public/other-org/services", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		w, _ = doPublicRequest(router, "GET", "/api/v1/public/"+other.Slug+"/services", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Error(t, handler.DB.Create(&models.Organization{Name: "Copycat", Slug: "test-org", ABN: "22222222222"}).Error)
+	})
+
+	t.Run("Organizations can turn online booking off", func(t *testing.T) {
+		handler.DB.Model(&models.Organization{ID: "test-org"}).Update("booking_enable_online_booking", false)
+		w, response := doPublicRequest(router, "GET", "/api/v1/public/test-org/services", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "ONLINE_BOOKING_DISABLED", response["error"].(map[string]interface{})["code"])
+	})
+}
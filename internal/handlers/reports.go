@@ -445,21 +445,33 @@ func (h *Handler) GetStaffPerformance(c *gin.Context) {
 }
 
 func (h *Handler) ExportReport(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
 	reportType := c.Param("type")
 	format := c.DefaultQuery("format", "pdf")
 
-	// For MVP, return mock export
-	filename := reportType + "_report_" + time.Now().Format("2006_01_02") + "." + format
-	
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	
-	if format == "pdf" {
-		c.Header("Content-Type", "application/pdf")
-		c.String(http.StatusOK, "Mock PDF content for "+reportType+" report")
-	} else if format == "csv" {
-		c.Header("Content-Type", "text/csv")
-		c.String(http.StatusOK, "Date,Type,Value\n2025-01-01,"+reportType+",100")
-	} else {
+	report, ok := exportableReports[reportType]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "REPORT_NOT_FOUND",
+				"message": "Unknown report type",
+			},
+		})
+		return
+	}
+	if format != "pdf" && format != "csv" && format != "xlsx" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error": gin.H{
@@ -467,6 +479,65 @@ func (h *Handler) ExportReport(c *gin.Context) {
 				"message": "Unsupported export format",
 			},
 		})
+		return
+	}
+
+	// Default to the twelve months up to and including today
+	loc := h.organizationLocation(orgID)
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	from := to.AddDate(-1, 0, 0)
+	if startDate := c.Query("start_date"); startDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", startDate, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "start_date must be in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		from = parsed
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", endDate, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "end_date must be in YYYY-MM-DD format",
+				},
+			})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	subtitle := from.Format("02/01/2006") + " - " + to.AddDate(0, 0, -1).Format("02/01/2006")
+	filename := reportType + "_report_" + time.Now().Format("2006_01_02")
+
+	writer, err := newExportWriter(c, format, filename, h.letterhead(orgID, report.Name, subtitle), report.Columns)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "EXPORT_FAILED",
+				"message": "Failed to start export",
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusOK)
+	if err := report.Stream(h, orgID, from, to, loc, writer.WriteRow); err != nil {
+		// Headers have already been sent, so the best we can do is stop the stream
+		c.Error(err)
+	}
+	if err := writer.Close(); err != nil {
+		c.Error(err)
 	}
 }
 
@@ -477,18 +548,21 @@ func (h *Handler) GetReportTemplates(c *gin.Context) {
 			"name": "Monthly Revenue Report",
 			"description": "Monthly breakdown of revenue from completed shifts",
 			"fields": []string{"month", "revenue", "hours", "shifts"},
+			"formats": []string{"pdf", "csv", "xlsx"},
 		},
 		{
 			"id": "participant_summary",
 			"name": "Participant Summary",
 			"description": "Overview of all participants and their service usage",
 			"fields": []string{"participant", "total_hours", "total_cost", "last_service"},
+			"formats": []string{"pdf", "csv", "xlsx"},
 		},
 		{
 			"id": "staff_utilization",
 			"name": "Staff Utilization Report",
 			"description": "Staff performance and utilization metrics",
 			"fields": []string{"staff_name", "shifts_completed", "hours_worked", "utilization_rate"},
+			"formats": []string{"pdf", "csv", "xlsx"},
 		},
 	}
 
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// serviceRequirements combines the resource requirements of a booking's
+// services. Services in one booking are done in the same place, so each
+// resource or resource type is needed at the largest quantity any service asks for.
+func serviceRequirements(services []models.Service) []availability.Requirement {
+	var requirements []availability.Requirement
+	index := make(map[string]int)
+	for _, service := range services {
+		for _, req := range service.ResourceRequirements {
+			requirement := availability.Requirement{ResourceType: strings.ToLower(req.ResourceType), Quantity: req.Quantity}
+			key := "type:" + requirement.ResourceType
+			if req.ResourceID != nil {
+				requirement = availability.Requirement{ResourceID: *req.ResourceID, Quantity: req.Quantity}
+				key = "id:" + requirement.ResourceID
+			}
+			if requirement.Quantity < 1 {
+				requirement.Quantity = 1
+			}
+
+			if i, ok := index[key]; ok {
+				if requirement.Quantity > requirements[i].Quantity {
+					requirements[i].Quantity = requirement.Quantity
+				}
+				continue
+			}
+			index[key] = len(requirements)
+			requirements = append(requirements, requirement)
+		}
+	}
+	return requirements
+}
+
+// saveBookingResources replaces the resources allocated to a booking
+func saveBookingResources(tx *gorm.DB, bookingID string, allocations []availability.Allocation) error {
+	if err := tx.Where("booking_id = ?", bookingID).Delete(&models.BookingResource{}).Error; err != nil {
+		return err
+	}
+	for _, allocation := range allocations {
+		if err := tx.Create(&models.BookingResource{
+			BookingID:  bookingID,
+			ResourceID: allocation.ResourceID,
+			Quantity:   allocation.Quantity,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findOrgResource loads the resource named by :id, writing the error response
+// when it isn't in the organization
+func (h *Handler) findOrgResource(c *gin.Context, orgID interface{}) (*models.Resource, bool) {
+	var resource models.Resource
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&resource).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "RESOURCE_NOT_FOUND",
+					"message": "Resource not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch resource",
+			},
+		})
+		return nil, false
+	}
+	return &resource, true
+}
+
+// GetResources lists the organization's bookable resources, optionally by type
+func (h *Handler) GetResources(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("organization_id = ?", orgID)
+	if resourceType := c.Query("type"); resourceType != "" {
+		query = query.Where("type = ?", strings.ToLower(resourceType))
+	}
+	if active := c.Query("is_active"); active != "" {
+		query = query.Where("is_active = ?", active == "true")
+	}
+
+	var resources []models.Resource
+	if err := query.Order("type, name").Find(&resources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch resources",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resources,
+	})
+}
+
+// GetResource returns a resource with its bookings for a day, today by default
+func (h *Handler) GetResource(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	resource, ok := h.findOrgResource(c, orgID)
+	if !ok {
+		return
+	}
+
+	loc := h.organizationLocation(orgID)
+	day := dateOnly(time.Now().In(loc))
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+		day = parsed
+	}
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+
+	var bookings []models.Booking
+	if err := h.DB.Joins("JOIN booking_resources ON booking_resources.booking_id = bookings.id").
+		Where("booking_resources.resource_id = ? AND bookings.start_time < ? AND bookings.end_time > ? AND bookings.status NOT IN ?",
+			resource.ID, dayStart.AddDate(0, 0, 1), dayStart, []string{"cancelled", "no_show"}).
+		Preload("Customer").Preload("Services").Order("bookings.start_time").Find(&bookings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch resource bookings",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"resource": resource,
+			"date":     dayStart.Format("2006-01-02"),
+			"bookings": bookings,
+		},
+	})
+}
+
+type ResourceRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+	Capacity int    `json:"capacity" binding:"omitempty,min=1"`
+	IsActive *bool  `json:"is_active"`
+	Notes    string `json:"notes"`
+}
+
+// CreateResource adds a bookable resource such as a service bay or chair
+func (h *Handler) CreateResource(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req ResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.Capacity == 0 {
+		req.Capacity = 1
+	}
+
+	resource := models.Resource{
+		OrganizationID: fmt.Sprintf("%v", orgID),
+		Name:           strings.TrimSpace(req.Name),
+		Type:           strings.ToLower(strings.TrimSpace(req.Type)),
+		Capacity:       req.Capacity,
+		IsActive:       req.IsActive == nil || *req.IsActive,
+		Notes:          req.Notes,
+	}
+	if err := h.DB.Create(&resource).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create resource",
+			},
+		})
+		return
+	}
+	if !resource.IsActive {
+		// The column default would otherwise override false on create
+		h.DB.Model(&resource).Update("is_active", false)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    resource,
+		"message": "Resource created successfully",
+	})
+}
+
+// UpdateResource changes a resource's details, capacity or active state
+func (h *Handler) UpdateResource(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	resource, ok := h.findOrgResource(c, orgID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name     *string `json:"name"`
+		Type     *string `json:"type"`
+		Capacity *int    `json:"capacity" binding:"omitempty,min=1"`
+		IsActive *bool   `json:"is_active"`
+		Notes    *string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Type != nil && strings.TrimSpace(*req.Type) != "" {
+		updates["type"] = strings.ToLower(strings.TrimSpace(*req.Type))
+	}
+	if req.Capacity != nil {
+		updates["capacity"] = *req.Capacity
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+
+	if err := h.DB.Model(resource).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update resource",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resource,
+		"message": "Resource updated successfully",
+	})
+}
+
+// DeleteResource removes a resource that no upcoming booking is using
+func (h *Handler) DeleteResource(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	resource, ok := h.findOrgResource(c, orgID)
+	if !ok {
+		return
+	}
+
+	var upcoming int64
+	h.DB.Model(&models.BookingResource{}).
+		Joins("JOIN bookings ON bookings.id = booking_resources.booking_id").
+		Where("booking_resources.resource_id = ? AND bookings.deleted_at IS NULL AND bookings.end_time > ? AND bookings.status NOT IN ?",
+			resource.ID, time.Now(), []string{"cancelled", "no_show", "completed"}).
+		Count(&upcoming)
+	if upcoming > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "RESOURCE_IN_USE",
+				"message": fmt.Sprintf("Resource is held by %d upcoming bookings. Move them or mark the resource inactive instead", upcoming),
+			},
+		})
+		return
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Where("resource_id = ?", resource.ID).Delete(&models.ServiceResourceRequirement{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete resource",
+			},
+		})
+		return
+	}
+	if err := tx.Delete(resource).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete resource",
+			},
+		})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Resource deleted successfully",
+	})
+}
+
+// GetServiceResources lists the resources a service needs
+func (h *Handler) GetServiceResources(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var service models.Service
+	if err := h.DB.Preload("ResourceRequirements.Resource").
+		Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&service).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SERVICE_NOT_FOUND",
+				"message": "Service not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    service.ResourceRequirements,
+	})
+}
+
+type ServiceResourceRequest struct {
+	ResourceID   *string `json:"resource_id" binding:"required_without=ResourceType"`
+	ResourceType string  `json:"resource_type" binding:"required_without=ResourceID"`
+	Quantity     int     `json:"quantity" binding:"omitempty,min=1"`
+}
+
+// SetServiceResources replaces the resources a service needs. Each requirement
+// names a particular resource or any resource of a type.
+func (h *Handler) SetServiceResources(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var service models.Service
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&service).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SERVICE_NOT_FOUND",
+				"message": "Service not found",
+			},
+		})
+		return
+	}
+
+	var req struct {
+		Requirements []ServiceResourceRequest `json:"requirements" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	requirements := make([]models.ServiceResourceRequirement, 0, len(req.Requirements))
+	for _, r := range req.Requirements {
+		requirement := models.ServiceResourceRequirement{ServiceID: service.ID, Quantity: r.Quantity}
+		if requirement.Quantity == 0 {
+			requirement.Quantity = 1
+		}
+
+		var matching int64
+		if r.ResourceID != nil && *r.ResourceID != "" {
+			requirement.ResourceID = r.ResourceID
+			h.DB.Model(&models.Resource{}).Where("id = ? AND organization_id = ?", *r.ResourceID, orgID).Count(&matching)
+		} else {
+			requirement.ResourceType = strings.ToLower(strings.TrimSpace(r.ResourceType))
+			h.DB.Model(&models.Resource{}).Where("type = ? AND organization_id = ?", requirement.ResourceType, orgID).Count(&matching)
+		}
+		if matching == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_RESOURCE",
+					"message": "Each requirement must name one of the organization's resources or resource types",
+				},
+			})
+			return
+		}
+		requirements = append(requirements, requirement)
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Where("service_id = ?", service.ID).Delete(&models.ServiceResourceRequirement{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update service resources",
+			},
+		})
+		return
+	}
+	for i := range requirements {
+		if err := tx.Create(&requirements[i]).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update service resources",
+				},
+			})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requirements,
+		"message": "Service resources updated successfully",
+	})
+}
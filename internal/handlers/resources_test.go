@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceBooking(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{})
+
+	hours := models.BusinessHours{Timezone: "Australia/Adelaide"}
+	hours.MondayOpen, hours.MondayClose = "09:00", "17:00"
+	hours.TuesdayOpen, hours.TuesdayClose = "09:00", "17:00"
+	hours.WednesdayOpen, hours.WednesdayClose = "09:00", "17:00"
+	hours.ThursdayOpen, hours.ThursdayClose = "09:00", "17:00"
+	hours.FridayOpen, hours.FridayClose = "09:00", "17:00"
+	hours.SaturdayOpen, hours.SaturdayClose = "09:00", "17:00"
+	hours.SundayOpen, hours.SundayClose = "09:00", "17:00"
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Updates(models.Organization{BusinessHours: hours})
+
+	handler.DB.Create(&models.Service{ID: "oil-change", OrganizationID: "test-org", Name: "Oil change", Category: "maintenance", Duration: 60, Price: 90, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "bay-customer", OrganizationID: "test-org", FirstName: "Bay", LastName: "Customer", Email: "bay@example.com", IsActive: true})
+
+	t.Run("Services require resources the organization has", func(t *testing.T) {
+		for _, name := range []string{"Bay 1", "Bay 2"} {
+			w, _ := doRequest(handler, router, "POST", "/api/v1/resources", map[string]interface{}{"name": name, "type": "Bay"})
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w, response := doRequest(handler, router, "PUT", "/api/v1/services/oil-change/resources", map[string]interface{}{
+			"requirements": []map[string]interface{}{{"resource_type": "hoist"}},
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_RESOURCE", response["error"].(map[string]interface{})["code"])
+
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/services/oil-change/resources", map[string]interface{}{
+			"requirements": []map[string]interface{}{{"resource_type": "bay", "quantity": 1}},
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/services/oil-change/resources", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"], 1)
+	})
+
+	loc, _ := time.LoadLocation("Australia/Adelaide")
+	day := time.Now().In(loc).AddDate(0, 0, 3)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 10, 0, 0, 0, loc)
+	booking := map[string]interface{}{
+		"customer_id": "bay-customer",
+		"service_ids": []string{"oil-change"},
+		"start_time":  start,
+		"end_time":    start.Add(time.Hour),
+	}
+
+	t.Run("Bookings run side by side until every bay is taken", func(t *testing.T) {
+		allocated := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			w, response := doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			resources := response["booking"].(map[string]interface{})["resources"].([]interface{})
+			assert.Len(t, resources, 1)
+			allocated[resources[0].(map[string]interface{})["resource_id"].(string)] = true
+		}
+		assert.Len(t, allocated, 2)
+
+		w, _ := doRequest(handler, router, "POST", "/api/v1/bookings", booking)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Availability skips times with no free bay", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/bookings/available-slots?interval=30&service_ids=oil-change&date="+start.Format("2006-01-02"), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		slots := response["available_slots"].([]interface{})
+		assert.NotContains(t, slots, "10:00")
+		assert.NotContains(t, slots, "11:00") // Inside the bays' buffer after the bookings
+		assert.Contains(t, slots, "11:30")
+	})
+
+	t.Run("Resources held by upcoming bookings can't be deleted", func(t *testing.T) {
+		var resource models.Resource
+		handler.DB.Where("organization_id = ? AND name = ?", "test-org", "Bay 1").First(&resource)
+
+		w, response := doRequest(handler, router, "DELETE", "/api/v1/resources/"+resource.ID, nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "RESOURCE_IN_USE", response["error"].(map[string]interface{})["code"])
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/resources/"+resource.ID+"?date="+start.Format("2006-01-02"), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"].(map[string]interface{})["bookings"], 1)
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nextSequenceValue takes the next number from the organization's named
+// sequence. Call it inside the transaction that uses the number: the
+// increment locks the sequence row until that transaction ends. The first
+// time a sequence is used it carries on from seed, the count of documents
+// numbered before the sequence existed.
+func nextSequenceValue(tx *gorm.DB, orgID interface{}, name string, seed func() (int64, error)) (int64, error) {
+	increment := func() (int64, error) {
+		result := tx.Model(&models.NumberSequence{}).Where("organization_id = ? AND name = ?", orgID, name).
+			Update("last_value", gorm.Expr("last_value + 1"))
+		return result.RowsAffected, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return 0, err
+	}
+	if updated == 0 {
+		start, err := seed()
+		if err != nil {
+			return 0, err
+		}
+		// Another request may be creating the row too; whichever insert
+		// loses waits for the winner and then increments its row
+		sequence := models.NumberSequence{OrganizationID: fmt.Sprintf("%v", orgID), Name: name, LastValue: start}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sequence).Error; err != nil {
+			return 0, err
+		}
+		if _, err := increment(); err != nil {
+			return 0, err
+		}
+	}
+
+	var sequence models.NumberSequence
+	if err := tx.Where("organization_id = ? AND name = ?", orgID, name).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastValue, nil
+}
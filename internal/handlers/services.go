@@ -105,6 +105,15 @@ func (h *Handler) CreateService(c *gin.Context) {
 		return
 	}
 
+	if problem := checkDepositRule(service.DepositType, service.DepositValue, service.Price); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if service.NoShowFee < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No-show fee must be greater than or equal to 0"})
+		return
+	}
+
 	// Check if service with same name already exists in the category
 	var existingService models.Service
 	if err := h.DB.Where("name = ? AND category = ? AND organization_id = ?", 
@@ -121,6 +130,26 @@ func (h *Handler) CreateService(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"service": service})
 }
 
+// checkDepositRule describes what's wrong with a service's deposit settings,
+// or returns "" if they're valid
+func checkDepositRule(depositType string, value, price float64) string {
+	switch depositType {
+	case "", "none":
+		return ""
+	case "fixed":
+		if value <= 0 || value > price {
+			return "Fixed deposit must be more than 0 and no more than the price"
+		}
+	case "percentage":
+		if value <= 0 || value > 100 {
+			return "Deposit percentage must be more than 0 and at most 100"
+		}
+	default:
+		return "Deposit type must be fixed, percentage or none"
+	}
+	return ""
+}
+
 // UpdateService updates an existing service
 func (h *Handler) UpdateService(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
@@ -158,6 +187,26 @@ func (h *Handler) UpdateService(c *gin.Context) {
 		return
 	}
 
+	// Check the deposit rule against the service as it will be after the update
+	depositType, depositValue, price := service.DepositType, service.DepositValue, service.Price
+	if updateData.DepositType != "" {
+		depositType = updateData.DepositType
+	}
+	if updateData.DepositValue != 0 {
+		depositValue = updateData.DepositValue
+	}
+	if updateData.Price != 0 {
+		price = updateData.Price
+	}
+	if problem := checkDepositRule(depositType, depositValue, price); problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": problem})
+		return
+	}
+	if updateData.NoShowFee < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No-show fee must be greater than or equal to 0"})
+		return
+	}
+
 	// Check if name/category combination is being changed and if it conflicts
 	if (updateData.Name != "" && updateData.Name != service.Name) || 
 	   (updateData.Category != "" && updateData.Category != service.Category) {
@@ -0,0 +1,570 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// rosterableRoles are the user roles the assignment engine considers for shifts
+var rosterableRoles = []string{"care_worker", "manager"}
+
+const (
+	// continuityWindowDays is how far back shifts with the participant count towards continuity of care
+	continuityWindowDays = 90
+	// qualificationWarningDays flags qualifications that expire soon after the shift
+	qualificationWarningDays = 30
+)
+
+// StaffCandidate is a staff member evaluated for a shift
+type StaffCandidate struct {
+	StaffID  string   `json:"staff_id"`
+	Name     string   `json:"name"`
+	Eligible bool     `json:"eligible"`
+	Score    float64  `json:"score"`
+	Reasons  []string `json:"reasons,omitempty"` // Why the staff member can't take the shift
+	Notes    []string `json:"notes,omitempty"`   // What raised or lowered the score
+}
+
+// rankShiftCandidates evaluates every rosterable staff member in the
+// organization for a shift, eligible candidates first and best score first.
+// Hard rules (blocked workers, gender preference, required qualifications,
+// leave, availability and the staff overlap check) decide eligibility; the
+// score favours preferred workers and continuity of care and spreads hours.
+// tentative holds assignments proposed earlier in the same run that haven't been saved.
+func (h *Handler) rankShiftCandidates(shift *models.Shift, participant *models.Participant, tentative map[string][]models.Shift) ([]StaffCandidate, error) {
+	orgID := participant.OrganizationID
+	loc := h.organizationLocation(orgID)
+
+	var staff []models.User
+	if err := h.DB.Where("organization_id = ? AND is_active = ? AND role IN ?", orgID, true, rosterableRoles).
+		Order("first_name ASC, last_name ASC").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+	if len(staff) == 0 {
+		return []StaffCandidate{}, nil
+	}
+	ids := make([]string, len(staff))
+	for i, member := range staff {
+		ids[i] = member.ID
+	}
+
+	var requirements []models.QualificationRequirement
+	if err := h.DB.Where("organization_id = ? AND (service_type = ? OR service_type = ?)", orgID, shift.ServiceType, "").
+		Find(&requirements).Error; err != nil {
+		return nil, err
+	}
+	var qualifications []models.StaffQualification
+	if err := h.DB.Where("staff_id IN ?", ids).Find(&qualifications).Error; err != nil {
+		return nil, err
+	}
+	var windows []models.StaffAvailability
+	if err := h.DB.Where("staff_id IN ?", ids).Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	var leave []models.StaffLeave
+	if err := h.DB.Where("staff_id IN ? AND status IN ? AND start_time < ? AND end_time > ?", ids, []string{"approved", "pending"}, shift.EndTime, shift.StartTime).
+		Find(&leave).Error; err != nil {
+		return nil, err
+	}
+	var preferences []models.ParticipantWorkerPreference
+	if err := h.DB.Where("participant_id = ?", participant.ID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+
+	var continuity []struct {
+		StaffID string
+		Total   int
+	}
+	if err := h.DB.Model(&models.Shift{}).Select("staff_id, COUNT(*) AS total").
+		Where("participant_id = ? AND status != ? AND start_time >= ? AND start_time < ?", participant.ID, "cancelled", shift.StartTime.AddDate(0, 0, -continuityWindowDays), shift.StartTime).
+		Group("staff_id").Scan(&continuity).Error; err != nil {
+		return nil, err
+	}
+
+	// Hours already rostered in the shift's week, Monday to Sunday
+	localStart := shift.StartTime.In(loc)
+	weekStart := time.Date(localStart.Year(), localStart.Month(), localStart.Day()-(int(localStart.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	var weekShifts []models.Shift
+	if err := h.DB.Select("id", "staff_id", "start_time", "end_time").
+		Where("staff_id IN ? AND id != ? AND status != ? AND start_time >= ? AND start_time < ?", ids, shift.ID, "cancelled", weekStart, weekStart.AddDate(0, 0, 7)).
+		Find(&weekShifts).Error; err != nil {
+		return nil, err
+	}
+
+	qualificationsByStaff := make(map[string][]models.StaffQualification)
+	for _, q := range qualifications {
+		qualificationsByStaff[q.StaffID] = append(qualificationsByStaff[q.StaffID], q)
+	}
+	windowsByStaff := make(map[string][]models.StaffAvailability)
+	for _, window := range windows {
+		windowsByStaff[window.StaffID] = append(windowsByStaff[window.StaffID], window)
+	}
+	leaveByStaff := make(map[string][]models.StaffLeave)
+	for _, l := range leave {
+		leaveByStaff[l.StaffID] = append(leaveByStaff[l.StaffID], l)
+	}
+	preferenceByStaff := make(map[string]string)
+	for _, p := range preferences {
+		preferenceByStaff[p.StaffID] = p.Preference
+	}
+	continuityByStaff := make(map[string]int)
+	for _, row := range continuity {
+		continuityByStaff[row.StaffID] = row.Total
+	}
+	weekHours := make(map[string]float64)
+	for _, s := range weekShifts {
+		weekHours[s.StaffID] += s.EndTime.Sub(s.StartTime).Hours()
+	}
+	for staffID, proposed := range tentative {
+		for _, s := range proposed {
+			if !s.StartTime.Before(weekStart) && s.StartTime.Before(weekStart.AddDate(0, 0, 7)) {
+				weekHours[staffID] += s.EndTime.Sub(s.StartTime).Hours()
+			}
+		}
+	}
+
+	requiredTypes := make([]string, 0, len(requirements))
+	seen := make(map[string]bool)
+	for _, requirement := range requirements {
+		if !seen[requirement.QualificationType] {
+			seen[requirement.QualificationType] = true
+			requiredTypes = append(requiredTypes, requirement.QualificationType)
+		}
+	}
+	sort.Strings(requiredTypes)
+
+	candidates := make([]StaffCandidate, 0, len(staff))
+	for _, member := range staff {
+		candidate := StaffCandidate{
+			StaffID: member.ID,
+			Name:    strings.TrimSpace(member.FirstName + " " + member.LastName),
+			Score:   100,
+		}
+
+		switch preferenceByStaff[member.ID] {
+		case "blocked":
+			candidate.Reasons = append(candidate.Reasons, "Blocked by the participant")
+		case "preferred":
+			candidate.Score += 30
+			candidate.Notes = append(candidate.Notes, "Preferred by the participant")
+		}
+
+		if participant.GenderPreference != "" && member.Gender != participant.GenderPreference {
+			candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("Participant prefers a %s worker", strings.ReplaceAll(participant.GenderPreference, "_", "-")))
+		}
+
+		for _, requiredType := range requiredTypes {
+			var held *models.StaffQualification
+			for i, q := range qualificationsByStaff[member.ID] {
+				if q.Type == requiredType && (held == nil || q.IsValidAt(shift.EndTime)) {
+					held = &qualificationsByStaff[member.ID][i]
+				}
+			}
+			switch {
+			case held == nil:
+				candidate.Reasons = append(candidate.Reasons, "Missing "+requiredType)
+			case !held.IsValidAt(shift.EndTime):
+				candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("%s expired on %s", requiredType, held.ExpiryDate.Format("2006-01-02")))
+			case !held.IsValidAt(shift.EndTime.AddDate(0, 0, qualificationWarningDays)):
+				candidate.Notes = append(candidate.Notes, fmt.Sprintf("%s expires on %s", requiredType, held.ExpiryDate.Format("2006-01-02")))
+			}
+		}
+
+		for _, l := range leaveByStaff[member.ID] {
+			if l.Status == "approved" {
+				candidate.Reasons = append(candidate.Reasons, "On "+l.LeaveType+" leave")
+			} else {
+				candidate.Score -= 20
+				candidate.Notes = append(candidate.Notes, "Has a pending leave request")
+			}
+		}
+
+		if memberWindows := windowsByStaff[member.ID]; len(memberWindows) == 0 {
+			candidate.Score -= 10
+			candidate.Notes = append(candidate.Notes, "No availability recorded")
+		} else if !availability.Covers(memberWindows, shift.StartTime, shift.EndTime, loc) {
+			candidate.Reasons = append(candidate.Reasons, "Outside availability")
+		}
+
+		overlapping := h.staffHasOverlappingShift(member.ID, shift.ID, shift.StartTime, shift.EndTime)
+		for _, s := range tentative[member.ID] {
+			if s.StartTime.Before(shift.EndTime) && s.EndTime.After(shift.StartTime) {
+				overlapping = true
+			}
+		}
+		if overlapping {
+			candidate.Reasons = append(candidate.Reasons, "Already rostered at this time")
+		}
+
+		if visits := continuityByStaff[member.ID]; visits > 0 {
+			bonus := float64(visits * 2)
+			if bonus > 20 {
+				bonus = 20
+			}
+			candidate.Score += bonus
+			candidate.Notes = append(candidate.Notes, fmt.Sprintf("Worked %d shifts with the participant in the last %d days", visits, continuityWindowDays))
+		}
+		if hours := weekHours[member.ID]; hours > 0 {
+			candidate.Score -= roundCurrency(hours)
+			candidate.Notes = append(candidate.Notes, fmt.Sprintf("%.1f hours already rostered this week", hours))
+		}
+
+		candidate.Eligible = len(candidate.Reasons) == 0
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Eligible != candidates[j].Eligible {
+			return candidates[i].Eligible
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+// autoAssignEnabled reports whether the organization lets the engine assign shifts without review
+func (h *Handler) autoAssignEnabled(orgID interface{}) bool {
+	var settings models.OrganizationSettings
+	if err := h.DB.Where("organization_id = ?", orgID).First(&settings).Error; err != nil {
+		return false
+	}
+	return settings.AutoAssignShifts
+}
+
+// assignBestCandidate gives an unassigned shift to the best eligible staff
+// member, returning nil when nobody is eligible
+func (h *Handler) assignBestCandidate(shift *models.Shift, participant *models.Participant) (*StaffCandidate, error) {
+	candidates, err := h.rankShiftCandidates(shift, participant, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 || !candidates[0].Eligible {
+		return nil, nil
+	}
+
+	best := candidates[0]
+	if err := h.DB.Model(shift).Update("staff_id", best.StaffID).Error; err != nil {
+		return nil, err
+	}
+	return &best, nil
+}
+
+// findOrgShift loads the shift named by :id with its participant, writing the
+// error response when it isn't in the organization
+func (h *Handler) findOrgShift(c *gin.Context, orgID interface{}) (*models.Shift, bool) {
+	var shift models.Shift
+	if err := h.DB.Preload("Participant").Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("shifts.id = ? AND participants.organization_id = ?", c.Param("id"), orgID).
+		First(&shift).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_FOUND",
+					"message": "Shift not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift",
+			},
+		})
+		return nil, false
+	}
+	return &shift, true
+}
+
+// GetShiftCandidates ranks the staff who could work a shift, with the reasons others can't
+func (h *Handler) GetShiftCandidates(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+
+	candidates, err := h.rankShiftCandidates(shift, &shift.Participant, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to evaluate staff for shift",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shift_id":   shift.ID,
+			"candidates": candidates,
+		},
+	})
+}
+
+type AssignShiftRequest struct {
+	StaffID string `json:"staff_id"` // Leave blank to assign the best candidate
+}
+
+// AssignShift assigns a scheduled shift to the given staff member, or to the
+// best candidate when none is given. The staff member must pass every hard rule.
+func (h *Handler) AssignShift(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req AssignShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	shift, ok := h.findOrgShift(c, orgID)
+	if !ok {
+		return
+	}
+	if shift.Status != "scheduled" || shift.InvoiceID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_OPERATION",
+				"message": "Only scheduled shifts can be assigned",
+			},
+		})
+		return
+	}
+
+	candidates, err := h.rankShiftCandidates(shift, &shift.Participant, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to evaluate staff for shift",
+			},
+		})
+		return
+	}
+
+	var chosen *StaffCandidate
+	for i := range candidates {
+		if req.StaffID == "" || candidates[i].StaffID == req.StaffID {
+			chosen = &candidates[i]
+			break
+		}
+	}
+
+	if chosen == nil || !chosen.Eligible {
+		response := gin.H{
+			"code":    "NO_ELIGIBLE_STAFF",
+			"message": "No staff member is eligible for this shift",
+		}
+		if req.StaffID != "" {
+			response = gin.H{
+				"code":    "STAFF_NOT_ELIGIBLE",
+				"message": "Staff member can't be assigned to this shift",
+			}
+			if chosen != nil {
+				response["details"] = chosen.Reasons
+			}
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   response,
+		})
+		return
+	}
+
+	if err := h.DB.Model(shift).Update("staff_id", chosen.StaffID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to assign shift",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(shift, "id = ?", shift.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"shift":     shift,
+			"candidate": chosen,
+		},
+		"message": "Shift assigned to " + chosen.Name,
+	})
+}
+
+type AutoAssignShiftsRequest struct {
+	StartDate string `json:"start_date"` // YYYY-MM-DD, defaults to today
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD, defaults to two weeks ahead
+	DryRun    bool   `json:"dry_run"`    // Propose assignments without saving them
+}
+
+// AutoAssignShifts works through the unassigned shifts in a date range in
+// start order, giving each to its best eligible candidate
+func (h *Handler) AutoAssignShifts(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req AutoAssignShiftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err.Error() != "EOF" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	loc := h.organizationLocation(orgID)
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, 14)
+	for _, bound := range []struct {
+		value  string
+		target *time.Time
+		offset int
+	}{{req.StartDate, &from, 0}, {req.EndDate, &to, 1}} {
+		if bound.value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", bound.value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+		*bound.target = date.AddDate(0, 0, bound.offset)
+	}
+
+	var shifts []models.Shift
+	if err := h.DB.Preload("Participant").Joins("JOIN participants ON shifts.participant_id = participants.id").
+		Where("participants.organization_id = ? AND shifts.staff_id = ? AND shifts.status = ? AND shifts.start_time >= ? AND shifts.start_time < ?", orgID, "", "scheduled", from, to).
+		Order("shifts.start_time ASC").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch unassigned shifts",
+			},
+		})
+		return
+	}
+
+	tentative := make(map[string][]models.Shift)
+	assigned := []gin.H{}
+	unassigned := []gin.H{}
+	for i := range shifts {
+		shift := &shifts[i]
+		candidates, err := h.rankShiftCandidates(shift, &shift.Participant, tentative)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to evaluate staff for shift",
+				},
+			})
+			return
+		}
+
+		if len(candidates) == 0 || !candidates[0].Eligible {
+			unassigned = append(unassigned, gin.H{
+				"shift_id":   shift.ID,
+				"start_time": shift.StartTime,
+				"candidates": candidates,
+			})
+			continue
+		}
+
+		best := candidates[0]
+		if req.DryRun {
+			tentative[best.StaffID] = append(tentative[best.StaffID], *shift)
+		} else if err := h.DB.Model(shift).Update("staff_id", best.StaffID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to assign shift",
+				},
+			})
+			return
+		}
+		assigned = append(assigned, gin.H{
+			"shift_id":   shift.ID,
+			"start_time": shift.StartTime,
+			"staff_id":   best.StaffID,
+			"staff_name": best.Name,
+			"score":      best.Score,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"dry_run":    req.DryRun,
+			"assigned":   assigned,
+			"unassigned": unassigned,
+		},
+	})
+}
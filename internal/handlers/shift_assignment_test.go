@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShiftAssignment(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.OrganizationSettings{}, &models.PublicHoliday{},
+		&models.SupportCatalogueVersion{}, &models.SupportCatalogueItem{},
+		&models.StaffAvailability{}, &models.StaffLeave{}, &models.StaffQualification{},
+		&models.QualificationRequirement{}, &models.ParticipantWorkerPreference{})
+
+	participant := models.Participant{
+		ID:               "assign-participant",
+		FirstName:        "Assign",
+		LastName:         "Participant",
+		DateOfBirth:      time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:       "ASN123",
+		OrganizationID:   "test-org",
+		IsActive:         true,
+		GenderPreference: "female",
+	}
+	handler.DB.Create(&participant)
+
+	for _, worker := range []models.User{
+		{ID: "worker-alice", Email: "alice@example.com", FirstName: "Alice", LastName: "Worker", Gender: "female"},
+		{ID: "worker-bob", Email: "bob@example.com", FirstName: "Bob", LastName: "Worker", Gender: "male"},
+		{ID: "worker-carol", Email: "carol@example.com", FirstName: "Carol", LastName: "Worker", Gender: "female"},
+	} {
+		worker.PasswordHash = "unused"
+		worker.Role = "care_worker"
+		worker.OrganizationID = "test-org"
+		worker.IsActive = true
+		handler.DB.Create(&worker)
+	}
+
+	expired := time.Now().AddDate(0, -1, 0)
+	handler.DB.Create(&models.QualificationRequirement{OrganizationID: "test-org", ServiceType: "Personal Care", QualificationType: "first_aid"})
+	handler.DB.Create(&models.StaffQualification{OrganizationID: "test-org", StaffID: "worker-alice", Type: "first_aid"})
+	handler.DB.Create(&models.StaffQualification{OrganizationID: "test-org", StaffID: "worker-bob", Type: "first_aid"})
+	handler.DB.Create(&models.StaffQualification{OrganizationID: "test-org", StaffID: "worker-carol", Type: "first_aid", ExpiryDate: &expired})
+
+	start := time.Now().AddDate(0, 0, 3).Truncate(time.Hour)
+	newShift := func(offset time.Duration) models.Shift {
+		shift := models.Shift{
+			ParticipantID: participant.ID,
+			StartTime:     start.Add(offset),
+			EndTime:       start.Add(offset + 2*time.Hour),
+			ServiceType:   "Personal Care",
+			Location:      "Home",
+			Status:        "scheduled",
+			HourlyRate:    50,
+		}
+		handler.DB.Create(&shift)
+		return shift
+	}
+
+	t.Run("Candidates are ranked with reasons for ineligible staff", func(t *testing.T) {
+		shift := newShift(0)
+		w, response := doRequest(handler, router, "GET", "/api/v1/shifts/"+shift.ID+"/candidates", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		candidates := response["data"].(map[string]interface{})["candidates"].([]interface{})
+		assert.Len(t, candidates, 3)
+		first := candidates[0].(map[string]interface{})
+		assert.Equal(t, "worker-alice", first["staff_id"])
+		assert.Equal(t, true, first["eligible"])
+
+		reasons := map[string][]interface{}{}
+		for _, candidate := range candidates[1:] {
+			candidate := candidate.(map[string]interface{})
+			assert.Equal(t, false, candidate["eligible"])
+			reasons[candidate["staff_id"].(string)] = candidate["reasons"].([]interface{})
+		}
+		assert.Contains(t, reasons["worker-bob"], "Participant prefers a female worker")
+		assert.Contains(t, reasons["worker-carol"][0], "first_aid expired on")
+	})
+
+	t.Run("Ineligible staff can't be assigned", func(t *testing.T) {
+		shift := newShift(24 * time.Hour)
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/assign", map[string]interface{}{"staff_id": "worker-bob"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "STAFF_NOT_ELIGIBLE", response["error"].(map[string]interface{})["code"])
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/assign", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		handler.DB.First(&shift, "id = ?", shift.ID)
+		assert.Equal(t, "worker-alice", shift.StaffID)
+	})
+
+	t.Run("Approved leave rules staff out", func(t *testing.T) {
+		handler.DB.Create(&models.StaffLeave{OrganizationID: "test-org", StaffID: "worker-alice", LeaveType: "annual", Status: "approved",
+			StartTime: start.Add(47 * time.Hour), EndTime: start.Add(52 * time.Hour)})
+		shift := newShift(48 * time.Hour)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/"+shift.ID+"/assign", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "NO_ELIGIBLE_STAFF", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Auto-assign does not double book within a run", func(t *testing.T) {
+		first := newShift(72 * time.Hour)
+		second := newShift(73 * time.Hour)
+
+		runStart := first.StartTime.In(handler.organizationLocation("test-org"))
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts/auto-assign", map[string]interface{}{
+			"start_date": runStart.Format("2006-01-02"),
+			"end_date":   runStart.AddDate(0, 0, 1).Format("2006-01-02"),
+			"dry_run":    true,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Len(t, data["assigned"], 1)
+		assert.Len(t, data["unassigned"], 1)
+
+		// A dry run leaves the shifts untouched
+		for _, shift := range []models.Shift{first, second} {
+			handler.DB.First(&shift, "id = ?", shift.ID)
+			assert.Empty(t, shift.StaffID)
+		}
+	})
+
+	t.Run("Unassigned shifts can be rescheduled over each other", func(t *testing.T) {
+		first, second := newShift(200*time.Hour), newShift(204*time.Hour)
+		w, _ := doRequest(handler, router, "PUT", "/api/v1/shifts/"+second.ID, map[string]interface{}{
+			"start_time": first.StartTime.Add(time.Hour).Format(time.RFC3339),
+			"end_time":   first.EndTime.Add(time.Hour).Format(time.RFC3339),
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w, _ = doRequest(handler, router, "PUT", "/api/v1/shifts/"+first.ID, map[string]interface{}{
+			"start_time": first.StartTime.Add(30 * time.Minute).Format(time.RFC3339),
+			"end_time":   first.EndTime.Add(30 * time.Minute).Format(time.RFC3339),
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("New unassigned shifts are assigned when the organization enables it", func(t *testing.T) {
+		handler.DB.Create(&models.OrganizationSettings{ID: "assign-settings", OrganizationID: "test-org", AutoAssignShifts: true})
+		shiftStart := start.Add(120 * time.Hour)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/shifts", map[string]interface{}{
+			"participant_id": participant.ID,
+			"start_time":     shiftStart.Format(time.RFC3339),
+			"end_time":       shiftStart.Add(2 * time.Hour).Format(time.RFC3339),
+			"service_type":   "Personal Care",
+			"location":       "Home",
+			"hourly_rate":    50,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "worker-alice", response["data"].(map[string]interface{})["staff_id"])
+	})
+}
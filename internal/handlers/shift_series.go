@@ -0,0 +1,1465 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// seriesGenerationWeeks is how far ahead shifts are generated for active series
+	seriesGenerationWeeks = 12
+	// maxSeriesDays bounds how far a recurrence is expanded
+	maxSeriesDays = 5 * 366
+)
+
+// weekdayCodes lists the RRULE day codes in roster order, Monday first
+var weekdayCodes = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+var weekdayByCode = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// recurrence is the repeat pattern of a shift series
+type recurrence struct {
+	Frequency string // daily, weekly
+	Interval  int
+	ByWeekday string
+	Until     *time.Time
+	Count     int
+}
+
+// seriesSkip records an occurrence that was not turned into a shift
+type seriesSkip struct {
+	StartTime time.Time `json:"start_time"`
+	Reason    string    `json:"reason"` // public_holiday, schedule_conflict, invalid_support_item, price_limit_exceeded
+	Details   string    `json:"details,omitempty"`
+}
+
+// dateOnly returns the calendar date of t as midnight UTC, which keeps day
+// arithmetic clear of daylight saving changes
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// normalizeWeekdays converts day names such as "monday", "Tue" or "WE" into a
+// comma separated BYDAY list in roster order
+func normalizeWeekdays(days []string) (string, error) {
+	selected := make(map[string]bool)
+	for _, day := range days {
+		day = strings.ToUpper(strings.TrimSpace(day))
+		if len(day) < 2 {
+			return "", fmt.Errorf("unknown weekday %q", day)
+		}
+		code := day[:2]
+		if _, ok := weekdayByCode[code]; !ok {
+			return "", fmt.Errorf("unknown weekday %q", day)
+		}
+		selected[code] = true
+	}
+
+	var codes []string
+	for _, code := range weekdayCodes {
+		if selected[code] {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, ","), nil
+}
+
+// parseRRule reads the subset of RFC 5545 recurrence rules rosters need:
+// FREQ (DAILY or WEEKLY), INTERVAL, BYDAY, UNTIL and COUNT
+func parseRRule(rule string) (recurrence, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	r := recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return r, fmt.Errorf("invalid rule part %q", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" {
+				return r, fmt.Errorf("unsupported frequency %q", value)
+			}
+			r.Frequency = strings.ToLower(value)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return r, fmt.Errorf("invalid interval %q", value)
+			}
+			r.Interval = interval
+		case "BYDAY":
+			days, err := normalizeWeekdays(strings.Split(value, ","))
+			if err != nil {
+				return r, err
+			}
+			r.ByWeekday = days
+		case "UNTIL":
+			if len(value) < 8 {
+				return r, fmt.Errorf("invalid until date %q", value)
+			}
+			until, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return r, fmt.Errorf("invalid until date %q", value)
+			}
+			r.Until = &until
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return r, fmt.Errorf("invalid count %q", value)
+			}
+			r.Count = count
+		default:
+			return r, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Frequency == "" {
+		return r, errors.New("FREQ is required")
+	}
+	return r, r.validate()
+}
+
+// buildRecurrence turns the frequency, weekdays, until and count fields of a request into a recurrence
+func buildRecurrence(frequency string, weekdays []string, until string, count int) (recurrence, error) {
+	r := recurrence{Frequency: frequency, Interval: 1, Count: count}
+	switch frequency {
+	case "daily", "weekly":
+	case "fortnightly":
+		r.Frequency, r.Interval = "weekly", 2
+	default:
+		return r, errors.New("frequency must be daily, weekly or fortnightly")
+	}
+
+	days, err := normalizeWeekdays(weekdays)
+	if err != nil {
+		return r, err
+	}
+	r.ByWeekday = days
+
+	if until != "" {
+		date, err := time.Parse("2006-01-02", until)
+		if err != nil {
+			return r, fmt.Errorf("invalid until date %q, use YYYY-MM-DD", until)
+		}
+		r.Until = &date
+	}
+	return r, r.validate()
+}
+
+func (r recurrence) validate() error {
+	if r.Until != nil && r.Count > 0 {
+		return errors.New("use either an until date or a count, not both")
+	}
+	if r.Frequency == "daily" && r.ByWeekday != "" {
+		return errors.New("weekdays only apply to weekly series")
+	}
+	return nil
+}
+
+func (r recurrence) apply(series *models.ShiftSeries) {
+	series.Frequency = r.Frequency
+	series.Interval = r.Interval
+	series.ByWeekday = r.ByWeekday
+	series.UntilDate = r.Until
+	series.Count = r.Count
+}
+
+// seriesOccurrences expands a series into the local start times of its
+// occurrences, from the first one up to but excluding before. Weeks are
+// counted from the Monday of the first occurrence so fortnightly series keep
+// their rhythm. done reports that the rule ran out before reaching before.
+func seriesOccurrences(series *models.ShiftSeries, loc *time.Location, before time.Time) (occurrences []time.Time, done bool) {
+	first := series.StartsAt.In(loc)
+	firstDay := dateOnly(first)
+	weekStart := firstDay.AddDate(0, 0, -((int(firstDay.Weekday()) + 6) % 7))
+
+	weekdays := make(map[time.Weekday]bool)
+	for _, code := range strings.Split(series.ByWeekday, ",") {
+		if day, ok := weekdayByCode[code]; ok {
+			weekdays[day] = true
+		}
+	}
+	if len(weekdays) == 0 {
+		weekdays[firstDay.Weekday()] = true
+	}
+
+	interval := series.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	for i := 0; i < maxSeriesDays; i++ {
+		day := firstDay.AddDate(0, 0, i)
+		if series.UntilDate != nil && day.After(dateOnly(*series.UntilDate)) {
+			return occurrences, true
+		}
+		if series.Count > 0 && len(occurrences) >= series.Count {
+			return occurrences, true
+		}
+
+		match := false
+		if series.Frequency == "daily" {
+			match = i%interval == 0
+		} else {
+			week := int(day.Sub(weekStart).Hours()/24) / 7
+			match = week%interval == 0 && weekdays[day.Weekday()]
+		}
+		if !match {
+			continue
+		}
+
+		start := time.Date(day.Year(), day.Month(), day.Day(), first.Hour(), first.Minute(), 0, 0, loc)
+		if !start.Before(before) {
+			return occurrences, false
+		}
+		occurrences = append(occurrences, start)
+	}
+	return occurrences, true
+}
+
+// nextSeriesOccurrence returns the first occurrence starting at or after from
+func nextSeriesOccurrence(series *models.ShiftSeries, loc *time.Location, from time.Time) (time.Time, bool) {
+	occurrences, _ := seriesOccurrences(series, loc, from.AddDate(0, 0, maxSeriesDays))
+	for _, start := range occurrences {
+		if !start.Before(from) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseTimeOfDay reads a local clock time such as "09:30"
+func parseTimeOfDay(value string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q, use HH:MM", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// seriesLocation returns the timezone a series was created in
+func (h *Handler) seriesLocation(series *models.ShiftSeries) *time.Location {
+	if loc, err := time.LoadLocation(series.Timezone); err == nil && series.Timezone != "" {
+		return loc
+	}
+	return h.organizationLocation(series.OrganizationID)
+}
+
+// generateSeriesShifts creates shifts for the series' occurrences up to
+// horizon. Occurrences on public holidays, on days that already have (or had)
+// a shift from the series, or that fail the staff overlap or price limit
+// checks are skipped. The series is marked ended once its rule runs out.
+// Shifts are created under a lock on the series, so concurrent runs don't
+// both create the same occurrences.
+func (h *Handler) generateSeriesShifts(series *models.ShiftSeries, horizon time.Time) ([]models.Shift, []seriesSkip, error) {
+	if series.Status != "active" {
+		return nil, nil, nil
+	}
+
+	var participant models.Participant
+	if err := h.DB.First(&participant, "id = ?", series.ParticipantID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	loc := h.seriesLocation(series)
+	occurrences, done := seriesOccurrences(series, loc, horizon)
+	duration := time.Duration(series.DurationMinutes) * time.Minute
+
+	candidates := []models.Shift{}
+	skipped := []seriesSkip{}
+	for _, start := range occurrences {
+		if series.GeneratedUntil != nil && start.Before(*series.GeneratedUntil) {
+			continue
+		}
+		end := start.Add(duration)
+
+		taken, err := seriesOccurrenceTaken(h.DB, series.ID, start, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if taken {
+			continue
+		}
+
+		if series.SkipPublicHolidays && h.isPublicHoliday(start, participant.Address.State) {
+			skipped = append(skipped, seriesSkip{StartTime: start, Reason: "public_holiday"})
+			continue
+		}
+
+		if h.staffHasOverlappingShift(series.StaffID, "", start, end) {
+			skipped = append(skipped, seriesSkip{StartTime: start, Reason: "schedule_conflict", Details: "Staff member already has a shift scheduled during this time"})
+			continue
+		}
+
+		item, err := h.resolveSupportItem(series.OrganizationID, &participant, series.ServiceType, series.SupportItemNumber, start, end)
+		if err == errSupportItemNotFound || err == errNoTimeBandVariant {
+			skipped = append(skipped, seriesSkip{StartTime: start, Reason: "invalid_support_item", Details: err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		occurrence := start
+		shift := models.Shift{
+			ParticipantID:   series.ParticipantID,
+			StaffID:         series.StaffID,
+			StartTime:       start,
+			EndTime:         end,
+			ServiceType:     series.ServiceType,
+			Location:        series.Location,
+			Status:          "scheduled",
+			HourlyRate:      series.HourlyRate,
+			Notes:           series.Notes,
+			SeriesID:        &series.ID,
+			OccurrenceStart: &occurrence,
+		}
+		if item != nil {
+			limit := hourlyPriceLimit(item, &participant)
+			if limit > 0 && roundCurrency(series.HourlyRate) > limit {
+				skipped = append(skipped, seriesSkip{
+					StartTime: start,
+					Reason:    "price_limit_exceeded",
+					Details:   fmt.Sprintf("Hourly rate %.2f exceeds the NDIS price limit of %.2f for %s", series.HourlyRate, limit, item.ItemNumber),
+				})
+				continue
+			}
+			shift.SupportItemNumber = item.ItemNumber
+			shift.PriceLimit = limit
+		}
+		candidates = append(candidates, shift)
+	}
+
+	created := []models.Shift{}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var locked models.ShiftSeries
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", series.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != "active" {
+			return nil
+		}
+
+		// Another run may have generated some of these since they were checked
+		for _, shift := range candidates {
+			taken, err := seriesOccurrenceTaken(tx, series.ID, *shift.OccurrenceStart, loc)
+			if err != nil {
+				return err
+			}
+			if taken {
+				continue
+			}
+			// The unique series occurrence index backs up the check above
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&shift)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			created = append(created, shift)
+		}
+
+		updates := map[string]interface{}{"generated_until": horizon}
+		if locked.GeneratedUntil != nil && locked.GeneratedUntil.After(horizon) {
+			updates["generated_until"] = *locked.GeneratedUntil
+		}
+		if done {
+			updates["status"] = "ended"
+		}
+		return tx.Model(series).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, skipped, nil
+}
+
+// seriesOccurrenceTaken reports whether the series already has (or had) a
+// shift on the occurrence's local date. A deleted or moved occurrence keeps
+// its slot so it isn't generated again.
+func seriesOccurrenceTaken(db *gorm.DB, seriesID string, start time.Time, loc *time.Location) (bool, error) {
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	var existing int64
+	if err := db.Unscoped().Model(&models.Shift{}).
+		Where("series_id = ? AND occurrence_start >= ? AND occurrence_start < ?", seriesID, dayStart, dayStart.AddDate(0, 0, 1)).
+		Count(&existing).Error; err != nil {
+		return false, err
+	}
+	return existing > 0, nil
+}
+
+// topUpShiftSeries generates shifts for every active series up to the
+// generation horizon, so rosters stay filled without anyone asking
+func (h *Handler) topUpShiftSeries(ctx context.Context) error {
+	horizon := seriesHorizon(time.Now(), seriesGenerationWeeks)
+	var seriesList []models.ShiftSeries
+	if err := h.DB.WithContext(ctx).Where("status = ? AND (generated_until IS NULL OR generated_until < ?)", "active", horizon).
+		Find(&seriesList).Error; err != nil {
+		return err
+	}
+	for i := range seriesList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, _, err := h.generateSeriesShifts(&seriesList[i], horizon); err != nil {
+			log.Printf("Failed to generate shifts for series %s: %v", seriesList[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// seriesHorizon is the point up to which shifts are generated
+func seriesHorizon(now time.Time, weeks int) time.Time {
+	return now.AddDate(0, 0, 7*weeks)
+}
+
+// regenerableShifts selects the untouched future shifts of a series that a
+// series-wide edit may replace: still scheduled, not edited individually and not billed
+func regenerableShifts(db *gorm.DB, seriesID string, from time.Time) *gorm.DB {
+	return db.Where("series_id = ? AND occurrence_start >= ? AND status = ? AND series_exception = ? AND invoice_id IS NULL",
+		seriesID, from, "scheduled", false)
+}
+
+// findShiftSeries loads a series belonging to the organization, writing a 404 when it doesn't exist
+func (h *Handler) findShiftSeries(c *gin.Context, orgID interface{}) (*models.ShiftSeries, bool) {
+	var series models.ShiftSeries
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&series).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SERIES_NOT_FOUND",
+					"message": "Shift series not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift series",
+			},
+		})
+		return nil, false
+	}
+	return &series, true
+}
+
+// findSeriesOccurrence loads the shift a "this" or "following" change starts from
+func (h *Handler) findSeriesOccurrence(c *gin.Context, series *models.ShiftSeries, shiftID string) (*models.Shift, bool) {
+	if shiftID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "shift_id is required for this scope",
+			},
+		})
+		return nil, false
+	}
+
+	var shift models.Shift
+	if err := h.DB.Where("id = ? AND series_id = ?", shiftID, series.ID).First(&shift).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SHIFT_NOT_FOUND",
+					"message": "Shift not found in this series",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift",
+			},
+		})
+		return nil, false
+	}
+	return &shift, true
+}
+
+// occurrenceOf returns the series slot a shift fills
+func occurrenceOf(shift *models.Shift) time.Time {
+	if shift.OccurrenceStart != nil {
+		return *shift.OccurrenceStart
+	}
+	return shift.StartTime
+}
+
+func (h *Handler) GetShiftSeriesList(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	query := h.DB.Where("organization_id = ?", orgID)
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+	if staffID := c.Query("staff_id"); staffID != "" {
+		query = query.Where("staff_id = ?", staffID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var series []models.ShiftSeries
+	if err := query.Preload("Participant").Preload("Staff").Order("starts_at DESC").Find(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift series",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+func (h *Handler) GetShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	series, ok := h.findShiftSeries(c, orgID)
+	if !ok {
+		return
+	}
+	h.DB.Preload("Participant").Preload("Staff").First(series, "id = ?", series.ID)
+
+	var upcoming []models.Shift
+	h.DB.Where("series_id = ? AND start_time >= ?", series.ID, time.Now()).
+		Order("start_time ASC").Limit(20).Find(&upcoming)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series":          series,
+			"upcoming_shifts": upcoming,
+		},
+	})
+}
+
+type CreateShiftSeriesRequest struct {
+	ParticipantID      string   `json:"participant_id" binding:"required"`
+	StaffID            string   `json:"staff_id" binding:"required"`
+	StartTime          string   `json:"start_time" binding:"required"` // First occurrence; ISO string or local datetime
+	EndTime            string   `json:"end_time" binding:"required"`
+	ServiceType        string   `json:"service_type" binding:"required"`
+	Location           string   `json:"location" binding:"required"`
+	HourlyRate         float64  `json:"hourly_rate" binding:"required,gt=0"`
+	Notes              string   `json:"notes"`
+	SupportItemNumber  string   `json:"support_item_number"`
+	RRule              string   `json:"rrule"`                                                        // e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10
+	Frequency          string   `json:"frequency" binding:"omitempty,oneof=daily weekly fortnightly"` // Used when rrule is blank
+	Weekdays           []string `json:"weekdays"`
+	Until              string   `json:"until"` // Last date, YYYY-MM-DD
+	Count              int      `json:"count" binding:"omitempty,gte=1"`
+	SkipPublicHolidays *bool    `json:"skip_public_holidays"` // Defaults to true
+}
+
+func (h *Handler) CreateShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateShiftSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var rule recurrence
+	var err error
+	if req.RRule != "" {
+		rule, err = parseRRule(req.RRule)
+	} else {
+		rule, err = buildRecurrence(req.Frequency, req.Weekdays, req.Until, req.Count)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RECURRENCE",
+				"message": "Invalid recurrence rule",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	startTime, err := parseTimeFromString(req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_START_TIME",
+				"message": "Invalid start time format. Use ISO format or local datetime.",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	endTime, err := parseTimeFromString(req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_END_TIME",
+				"message": "Invalid end time format. Use ISO format or local datetime.",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	if !endTime.After(startTime) || endTime.Sub(startTime) > 24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME_RANGE",
+				"message": "End time must be after start time and within 24 hours of it",
+			},
+		})
+		return
+	}
+
+	var participant models.Participant
+	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.ParticipantID, orgID, true).First(&participant).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_PARTICIPANT",
+				"message": "Participant not found or inactive",
+			},
+		})
+		return
+	}
+
+	var staff models.User
+	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_STAFF",
+				"message": "Staff member not found or inactive",
+			},
+		})
+		return
+	}
+
+	loc := h.organizationLocation(orgID)
+	series := models.ShiftSeries{
+		OrganizationID:     participant.OrganizationID,
+		ParticipantID:      req.ParticipantID,
+		StaffID:            req.StaffID,
+		ServiceType:        req.ServiceType,
+		Location:           req.Location,
+		HourlyRate:         req.HourlyRate,
+		SupportItemNumber:  req.SupportItemNumber,
+		Notes:              req.Notes,
+		StartsAt:           startTime,
+		DurationMinutes:    int(endTime.Sub(startTime).Minutes()),
+		Timezone:           loc.String(),
+		SkipPublicHolidays: req.SkipPublicHolidays == nil || *req.SkipPublicHolidays,
+		Status:             "active",
+		CreatedBy:          c.GetString("user_id"),
+	}
+	rule.apply(&series)
+
+	// Reject a rate above the price limit up front rather than skipping every occurrence
+	first, found := nextSeriesOccurrence(&series, loc, startTime)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RECURRENCE",
+				"message": "The recurrence rule produces no occurrences",
+			},
+		})
+		return
+	}
+	if _, _, ok := h.priceShift(c, orgID, &participant, series.ServiceType, series.SupportItemNumber, first, first.Add(time.Duration(series.DurationMinutes)*time.Minute), series.HourlyRate); !ok {
+		return
+	}
+
+	if err := h.DB.Create(&series).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create shift series",
+			},
+		})
+		return
+	}
+
+	created, skipped, err := h.generateSeriesShifts(&series, seriesHorizon(time.Now(), seriesGenerationWeeks))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to generate series shifts",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(&series, "id = ?", series.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series":  series,
+			"shifts":  created,
+			"skipped": skipped,
+		},
+		"message": fmt.Sprintf("Shift series created with %d shifts", len(created)),
+	})
+}
+
+type UpdateShiftSeriesRequest struct {
+	Scope             string   `json:"scope" binding:"required,oneof=this following all"`
+	ShiftID           string   `json:"shift_id"` // Occurrence the change starts from; required for this and following
+	StaffID           *string  `json:"staff_id,omitempty"`
+	TimeOfDay         *string  `json:"time_of_day,omitempty"` // Local start time, HH:MM
+	DurationMinutes   *int     `json:"duration_minutes,omitempty" binding:"omitempty,gt=0,lte=1440"`
+	ServiceType       *string  `json:"service_type,omitempty"`
+	Location          *string  `json:"location,omitempty"`
+	HourlyRate        *float64 `json:"hourly_rate,omitempty" binding:"omitempty,gt=0"`
+	SupportItemNumber *string  `json:"support_item_number,omitempty"`
+	Notes             *string  `json:"notes,omitempty"`
+
+	// Recurrence changes, for the following and all scopes only
+	RRule     *string  `json:"rrule,omitempty"`
+	Frequency *string  `json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly fortnightly"`
+	Weekdays  []string `json:"weekdays,omitempty"`
+	Until     *string  `json:"until,omitempty"`
+	Count     *int     `json:"count,omitempty" binding:"omitempty,gte=0"`
+}
+
+func (req *UpdateShiftSeriesRequest) changesRecurrence() bool {
+	return req.RRule != nil || req.Frequency != nil || req.Weekdays != nil || req.Until != nil || req.Count != nil
+}
+
+// recurrenceFor merges the recurrence changes in the request over the series' current rule
+func (req *UpdateShiftSeriesRequest) recurrenceFor(series *models.ShiftSeries) (recurrence, error) {
+	if req.RRule != nil {
+		return parseRRule(*req.RRule)
+	}
+
+	rule := recurrence{Frequency: series.Frequency, Interval: series.Interval, ByWeekday: series.ByWeekday, Until: series.UntilDate, Count: series.Count}
+	if req.Frequency != nil {
+		changed, err := buildRecurrence(*req.Frequency, nil, "", 0)
+		if err != nil {
+			return rule, err
+		}
+		rule.Frequency, rule.Interval = changed.Frequency, changed.Interval
+		if rule.Frequency == "daily" {
+			rule.ByWeekday = ""
+		}
+	}
+	if req.Weekdays != nil {
+		days, err := normalizeWeekdays(req.Weekdays)
+		if err != nil {
+			return rule, err
+		}
+		rule.ByWeekday = days
+	}
+	if req.Until != nil {
+		rule.Until = nil
+		if *req.Until != "" {
+			date, err := time.Parse("2006-01-02", *req.Until)
+			if err != nil {
+				return rule, fmt.Errorf("invalid until date %q, use YYYY-MM-DD", *req.Until)
+			}
+			rule.Until = &date
+			rule.Count = 0
+		}
+	}
+	if req.Count != nil {
+		rule.Count = *req.Count
+		if rule.Count > 0 {
+			rule.Until = nil
+		}
+	}
+	return rule, rule.validate()
+}
+
+// applyTemplate copies the shift template changes in the request onto a series
+func (req *UpdateShiftSeriesRequest) applyTemplate(series *models.ShiftSeries, loc *time.Location) error {
+	if req.StaffID != nil {
+		series.StaffID = *req.StaffID
+	}
+	if req.TimeOfDay != nil {
+		hour, minute, err := parseTimeOfDay(*req.TimeOfDay)
+		if err != nil {
+			return err
+		}
+		start := series.StartsAt.In(loc)
+		series.StartsAt = time.Date(start.Year(), start.Month(), start.Day(), hour, minute, 0, 0, loc)
+	}
+	if req.DurationMinutes != nil {
+		series.DurationMinutes = *req.DurationMinutes
+	}
+	if req.ServiceType != nil {
+		series.ServiceType = *req.ServiceType
+		// A new service type picks its own line item unless one is given explicitly
+		series.SupportItemNumber = ""
+	}
+	if req.SupportItemNumber != nil {
+		series.SupportItemNumber = *req.SupportItemNumber
+	}
+	if req.Location != nil {
+		series.Location = *req.Location
+	}
+	if req.HourlyRate != nil {
+		series.HourlyRate = *req.HourlyRate
+	}
+	if req.Notes != nil {
+		series.Notes = *req.Notes
+	}
+	return nil
+}
+
+// UpdateShiftSeries edits one occurrence, an occurrence and all that follow
+// it, or every future occurrence of a series. "following" splits the series
+// at the chosen occurrence so earlier shifts keep the original template.
+func (h *Handler) UpdateShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateShiftSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	series, ok := h.findShiftSeries(c, orgID)
+	if !ok {
+		return
+	}
+	if series.Status == "cancelled" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SERIES_CANCELLED",
+				"message": "Cancelled series can't be edited",
+			},
+		})
+		return
+	}
+
+	if req.StaffID != nil {
+		var staff models.User
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", *req.StaffID, orgID, true).First(&staff).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return
+		}
+	}
+
+	var participant models.Participant
+	if err := h.DB.First(&participant, "id = ?", series.ParticipantID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch participant",
+			},
+		})
+		return
+	}
+
+	loc := h.seriesLocation(series)
+
+	if req.Scope == "this" {
+		if req.changesRecurrence() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "VALIDATION_ERROR",
+					"message": "Recurrence changes apply to the following or all scopes",
+				},
+			})
+			return
+		}
+		h.updateSeriesOccurrence(c, series, &participant, &req, loc)
+		return
+	}
+
+	now := time.Now()
+	from := now
+	var pivot *models.Shift
+	if req.Scope == "following" {
+		if pivot, ok = h.findSeriesOccurrence(c, series, req.ShiftID); !ok {
+			return
+		}
+		occurrence := occurrenceOf(pivot).In(loc)
+		from = time.Date(occurrence.Year(), occurrence.Month(), occurrence.Day(), 0, 0, 0, 0, loc)
+	}
+
+	updated := *series
+	if err := req.applyTemplate(&updated, loc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	if req.changesRecurrence() {
+		rule, err := req.recurrenceFor(series)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_RECURRENCE",
+					"message": "Invalid recurrence rule",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+		rule.apply(&updated)
+	}
+
+	if pivot != nil {
+		// The new series starts on the chosen occurrence's day
+		start := updated.StartsAt.In(loc)
+		updated.StartsAt = time.Date(from.Year(), from.Month(), from.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if series.Count > 0 && req.Count == nil && updated.UntilDate == nil {
+			earlier, _ := seriesOccurrences(series, loc, from)
+			updated.Count = series.Count - len(earlier)
+		}
+	}
+
+	first, found := nextSeriesOccurrence(&updated, loc, from)
+	if !found {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RECURRENCE",
+				"message": "The updated series produces no further occurrences",
+			},
+		})
+		return
+	}
+	if _, _, ok := h.priceShift(c, orgID, &participant, updated.ServiceType, updated.SupportItemNumber, first, first.Add(time.Duration(updated.DurationMinutes)*time.Minute), updated.HourlyRate); !ok {
+		return
+	}
+
+	tx := h.DB.Begin()
+	if err := regenerableShifts(tx, series.ID, from).Unscoped().Delete(&models.Shift{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to remove replaced shifts",
+			},
+		})
+		return
+	}
+
+	target := &updated
+	if pivot != nil {
+		// End the original series the day before and carry the remaining
+		// individually edited or worked shifts over to the new one
+		updated.ID = ""
+		updated.ParentSeriesID = &series.ID
+		updated.GeneratedUntil = nil
+		updated.Status = "active"
+		updated.CreatedBy = c.GetString("user_id")
+		updated.Participant, updated.Staff = models.Participant{}, models.User{}
+		if err := tx.Create(&updated).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to create shift series",
+				},
+			})
+			return
+		}
+
+		until := dateOnly(from).AddDate(0, 0, -1)
+		err := tx.Model(series).Updates(map[string]interface{}{"until_date": until, "count": 0, "status": "ended"}).Error
+		if err == nil {
+			err = tx.Model(&models.Shift{}).Where("series_id = ? AND occurrence_start >= ?", series.ID, from).
+				Update("series_id", updated.ID).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to split shift series",
+				},
+			})
+			return
+		}
+	} else {
+		updated.Status = "active"
+		updated.GeneratedUntil = &now
+		if err := tx.Model(series).Updates(map[string]interface{}{
+			"staff_id":            updated.StaffID,
+			"starts_at":           updated.StartsAt,
+			"duration_minutes":    updated.DurationMinutes,
+			"service_type":        updated.ServiceType,
+			"support_item_number": updated.SupportItemNumber,
+			"location":            updated.Location,
+			"hourly_rate":         updated.HourlyRate,
+			"notes":               updated.Notes,
+			"frequency":           updated.Frequency,
+			"repeat_interval":     updated.Interval,
+			"by_weekday":          updated.ByWeekday,
+			"until_date":          updated.UntilDate,
+			"count":               updated.Count,
+			"generated_until":     updated.GeneratedUntil,
+			"status":              updated.Status,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update shift series",
+				},
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift series",
+			},
+		})
+		return
+	}
+
+	created, skipped, err := h.generateSeriesShifts(target, seriesHorizon(now, seriesGenerationWeeks))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to generate series shifts",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(target, "id = ?", target.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"series":  target,
+			"shifts":  created,
+			"skipped": skipped,
+		},
+		"message": "Shift series updated successfully",
+	})
+}
+
+// updateSeriesOccurrence applies a "this occurrence" edit to one shift and marks it as an exception
+func (h *Handler) updateSeriesOccurrence(c *gin.Context, series *models.ShiftSeries, participant *models.Participant, req *UpdateShiftSeriesRequest, loc *time.Location) {
+	shift, ok := h.findSeriesOccurrence(c, series, req.ShiftID)
+	if !ok {
+		return
+	}
+	if shift.InvoiceID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_INVOICED",
+				"message": "Shift has been invoiced and can no longer be modified",
+			},
+		})
+		return
+	}
+	if shift.Status != "scheduled" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_OPERATION",
+				"message": "Only scheduled shifts can be edited",
+			},
+		})
+		return
+	}
+
+	// Build the occurrence's template from the shift, then apply the changes to it
+	template := *series
+	template.StaffID = shift.StaffID
+	template.StartsAt = shift.StartTime
+	template.DurationMinutes = int(shift.EndTime.Sub(shift.StartTime).Minutes())
+	template.ServiceType = shift.ServiceType
+	template.SupportItemNumber = shift.SupportItemNumber
+	template.Location = shift.Location
+	template.HourlyRate = shift.HourlyRate
+	template.Notes = shift.Notes
+	if err := req.applyTemplate(&template, loc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+	startTime := template.StartsAt
+	endTime := startTime.Add(time.Duration(template.DurationMinutes) * time.Minute)
+
+	if h.staffHasOverlappingShift(template.StaffID, shift.ID, startTime, endTime) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SCHEDULE_CONFLICT",
+				"message": "Staff member already has a shift scheduled during this time",
+			},
+		})
+		return
+	}
+
+	supportItem, priceLimit, ok := h.priceShift(c, series.OrganizationID, participant, template.ServiceType, template.SupportItemNumber, startTime, endTime, template.HourlyRate)
+	if !ok {
+		return
+	}
+	supportItemNumber := ""
+	if supportItem != nil {
+		supportItemNumber = supportItem.ItemNumber
+	}
+
+	if err := h.DB.Model(shift).Updates(map[string]interface{}{
+		"staff_id":            template.StaffID,
+		"start_time":          startTime,
+		"end_time":            endTime,
+		"service_type":        template.ServiceType,
+		"support_item_number": supportItemNumber,
+		"price_limit":         priceLimit,
+		"location":            template.Location,
+		"hourly_rate":         template.HourlyRate,
+		"notes":               template.Notes,
+		"series_exception":    true,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Participant").Preload("Staff").First(shift, "id = ?", shift.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    shift,
+		"message": "Shift updated successfully",
+	})
+}
+
+// DeleteShiftSeries cancels one occurrence, an occurrence and all that follow
+// it, or the whole series. Shifts that have started, been completed or been
+// invoiced are kept.
+func (h *Handler) DeleteShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	scope := c.DefaultQuery("scope", "all")
+	if scope != "this" && scope != "following" && scope != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "scope must be this, following or all",
+			},
+		})
+		return
+	}
+
+	series, ok := h.findShiftSeries(c, orgID)
+	if !ok {
+		return
+	}
+
+	var from time.Time
+	updates := map[string]interface{}{}
+	switch scope {
+	case "this":
+		shift, ok := h.findSeriesOccurrence(c, series, c.Query("shift_id"))
+		if !ok {
+			return
+		}
+		if shift.InvoiceID != nil || (shift.Status != "scheduled" && shift.Status != "cancelled") {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_OPERATION",
+					"message": "Only scheduled or cancelled shifts can be deleted",
+				},
+			})
+			return
+		}
+		// The soft-deleted row keeps the slot so the occurrence isn't generated again
+		if err := h.DB.Delete(shift).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to delete shift",
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Occurrence deleted successfully",
+		})
+		return
+	case "following":
+		shift, ok := h.findSeriesOccurrence(c, series, c.Query("shift_id"))
+		if !ok {
+			return
+		}
+		loc := h.seriesLocation(series)
+		occurrence := occurrenceOf(shift).In(loc)
+		from = time.Date(occurrence.Year(), occurrence.Month(), occurrence.Day(), 0, 0, 0, 0, loc)
+		updates["until_date"] = dateOnly(from).AddDate(0, 0, -1)
+		updates["count"] = 0
+		updates["status"] = "ended"
+	default:
+		from = time.Now()
+		updates["status"] = "cancelled"
+	}
+
+	tx := h.DB.Begin()
+	result := tx.Where("series_id = ? AND occurrence_start >= ? AND status = ? AND invoice_id IS NULL", series.ID, from, "scheduled").
+		Delete(&models.Shift{})
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete series shifts",
+			},
+		})
+		return
+	}
+	if err := tx.Model(series).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift series",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift series",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"deleted_shifts": result.RowsAffected,
+		},
+		"message": "Shift series cancelled successfully",
+	})
+}
+
+// GenerateShiftSeries tops up the generated shifts of every active series in
+// the organization so the roster always runs the given number of weeks ahead
+func (h *Handler) GenerateShiftSeries(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", strconv.Itoa(seriesGenerationWeeks)))
+	if err != nil || weeks < 1 || weeks > 52 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "weeks must be between 1 and 52",
+			},
+		})
+		return
+	}
+
+	var seriesList []models.ShiftSeries
+	if err := h.DB.Where("organization_id = ? AND status = ?", orgID, "active").Find(&seriesList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch shift series",
+			},
+		})
+		return
+	}
+
+	horizon := seriesHorizon(time.Now(), weeks)
+	results := make([]gin.H, 0, len(seriesList))
+	totalCreated := 0
+	for i := range seriesList {
+		series := &seriesList[i]
+		if series.GeneratedUntil != nil && !series.GeneratedUntil.Before(horizon) {
+			continue
+		}
+		created, skipped, err := h.generateSeriesShifts(series, horizon)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to generate series shifts",
+				},
+			})
+			return
+		}
+		totalCreated += len(created)
+		results = append(results, gin.H{
+			"series_id":      series.ID,
+			"created_shifts": len(created),
+			"skipped":        skipped,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"generated_until": horizon,
+			"created_shifts":  totalCreated,
+			"series":          results,
+		},
+	})
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesOccurrences(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Adelaide")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	t.Run("Fortnightly on two weekdays with a count", func(t *testing.T) {
+		rule, err := parseRRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,MO;COUNT=5")
+		assert.NoError(t, err)
+		assert.Equal(t, "MO,TH", rule.ByWeekday)
+
+		// Wednesday 2026-01-07, so the first occurrence is the Thursday after
+		series := models.ShiftSeries{StartsAt: time.Date(2026, 1, 7, 9, 0, 0, 0, loc)}
+		rule.apply(&series)
+		occurrences, done := seriesOccurrences(&series, loc, time.Date(2027, 1, 1, 0, 0, 0, 0, loc))
+		assert.True(t, done)
+
+		var dates []string
+		for _, start := range occurrences {
+			dates = append(dates, start.Format("2006-01-02"))
+		}
+		assert.Equal(t, []string{"2026-01-08", "2026-01-19", "2026-01-22", "2026-02-02", "2026-02-05"}, dates)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=5", series.RecurrenceRule())
+	})
+
+	t.Run("Local start time is kept across daylight saving", func(t *testing.T) {
+		series := models.ShiftSeries{StartsAt: time.Date(2026, 9, 28, 9, 0, 0, 0, loc)}
+		rule, err := buildRecurrence("weekly", []string{"monday"}, "2026-10-12", 0)
+		assert.NoError(t, err)
+		rule.apply(&series)
+
+		occurrences, done := seriesOccurrences(&series, loc, time.Date(2027, 1, 1, 0, 0, 0, 0, loc))
+		assert.True(t, done)
+		assert.Len(t, occurrences, 3)
+		for _, start := range occurrences {
+			assert.Equal(t, 9, start.Hour())
+		}
+		// Clocks go forward an hour on 2026-10-04
+		assert.Equal(t, 7*24*time.Hour-time.Hour, occurrences[1].Sub(occurrences[0]))
+	})
+
+	t.Run("Invalid rules are rejected", func(t *testing.T) {
+		_, err := parseRRule("FREQ=MONTHLY")
+		assert.Error(t, err)
+		_, err = parseRRule("FREQ=WEEKLY;UNTIL=20260101;COUNT=3")
+		assert.Error(t, err)
+		_, err = buildRecurrence("weekly", []string{"someday"}, "", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestShiftSeries(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Shift{}, &models.ShiftSeries{}, &models.PublicHoliday{}, &models.SupportCatalogueVersion{}, &models.SupportCatalogueItem{})
+
+	participant := models.Participant{
+		ID:             "series-participant",
+		FirstName:      "Series",
+		LastName:       "Participant",
+		DateOfBirth:    time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC),
+		NDISNumber:     "SER123",
+		OrganizationID: "test-org",
+		IsActive:       true,
+	}
+	handler.DB.Create(&participant)
+
+	seriesShifts := func(seriesID string) []models.Shift {
+		var shifts []models.Shift
+		handler.DB.Where("series_id = ?", seriesID).Order("start_time ASC").Find(&shifts)
+		return shifts
+	}
+
+	loc := handler.organizationLocation("test-org")
+	now := time.Now().In(loc)
+	daysToMonday := (8 - int(now.Weekday())) % 7
+	if daysToMonday == 0 {
+		daysToMonday = 7
+	}
+	firstMonday := time.Date(now.Year(), now.Month(), now.Day()+daysToMonday, 9, 0, 0, 0, loc)
+
+	// Second Monday is a public holiday and the staff member is already busy on the third
+	handler.DB.Create(&models.PublicHoliday{ID: "series-holiday", Date: dateOnly(firstMonday.AddDate(0, 0, 7)), Name: "Test Holiday"})
+	handler.DB.Create(&models.Shift{
+		ParticipantID: participant.ID,
+		StaffID:       "test-user",
+		StartTime:     firstMonday.AddDate(0, 0, 14).Add(time.Hour),
+		EndTime:       firstMonday.AddDate(0, 0, 14).Add(3 * time.Hour),
+		ServiceType:   "Personal Care",
+		Location:      "Home",
+		Status:        "scheduled",
+		HourlyRate:    50,
+	})
+
+	w, response := doRequest(handler, router, "POST", "/api/v1/shift-series", map[string]interface{}{
+		"participant_id": participant.ID,
+		"staff_id":       "test-user",
+		"start_time":     firstMonday.Format(time.RFC3339),
+		"end_time":       firstMonday.Add(2 * time.Hour).Format(time.RFC3339),
+		"service_type":   "Personal Care",
+		"location":       "Home",
+		"hourly_rate":    50,
+		"frequency":      "weekly",
+		"weekdays":       []string{"MO"},
+		"count":          6,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	data := response["data"].(map[string]interface{})
+	seriesID := data["series"].(map[string]interface{})["id"].(string)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO;COUNT=6", data["series"].(map[string]interface{})["rrule"])
+	assert.Len(t, data["shifts"], 4)
+	skipped := data["skipped"].([]interface{})
+	assert.Len(t, skipped, 2)
+	assert.Equal(t, "public_holiday", skipped[0].(map[string]interface{})["reason"])
+	assert.Equal(t, "schedule_conflict", skipped[1].(map[string]interface{})["reason"])
+
+	t.Run("Editing one occurrence marks it as an exception", func(t *testing.T) {
+		shifts := seriesShifts(seriesID)
+		w, _ := doRequest(handler, router, "PUT", "/api/v1/shift-series/"+seriesID, map[string]interface{}{
+			"scope":       "this",
+			"shift_id":    shifts[0].ID,
+			"time_of_day": "13:00",
+			"location":    "Community Centre",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var edited models.Shift
+		handler.DB.First(&edited, "id = ?", shifts[0].ID)
+		assert.True(t, edited.SeriesException)
+		assert.Equal(t, 13, edited.StartTime.In(loc).Hour())
+		assert.Equal(t, "Community Centre", edited.Location)
+	})
+
+	t.Run("Editing all occurrences leaves exceptions alone", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PUT", "/api/v1/shift-series/"+seriesID, map[string]interface{}{
+			"scope":       "all",
+			"time_of_day": "10:00",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		shifts := seriesShifts(seriesID)
+		assert.Len(t, shifts, 4)
+		for _, shift := range shifts {
+			if shift.SeriesException {
+				assert.Equal(t, 13, shift.StartTime.In(loc).Hour())
+			} else {
+				assert.Equal(t, 10, shift.StartTime.In(loc).Hour())
+			}
+		}
+	})
+
+	t.Run("Editing this and following splits the series", func(t *testing.T) {
+		shifts := seriesShifts(seriesID)
+		pivot := shifts[2]
+		w, response := doRequest(handler, router, "PUT", "/api/v1/shift-series/"+seriesID, map[string]interface{}{
+			"scope":       "following",
+			"shift_id":    pivot.ID,
+			"hourly_rate": 55,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		newSeries := response["data"].(map[string]interface{})["series"].(map[string]interface{})
+		assert.Equal(t, seriesID, newSeries["parent_series_id"])
+		assert.Equal(t, float64(2), newSeries["count"])
+
+		assert.Len(t, seriesShifts(seriesID), 2)
+		following := seriesShifts(newSeries["id"].(string))
+		assert.Len(t, following, 2)
+		for _, shift := range following {
+			assert.Equal(t, 55.0, shift.HourlyRate)
+		}
+
+		var original models.ShiftSeries
+		handler.DB.First(&original, "id = ?", seriesID)
+		assert.Equal(t, "ended", original.Status)
+	})
+
+	t.Run("Deleted occurrences are not generated again", func(t *testing.T) {
+		var series models.ShiftSeries
+		handler.DB.Where("parent_series_id = ?", seriesID).First(&series)
+		shifts := seriesShifts(series.ID)
+
+		w, _ := doRequest(handler, router, "DELETE", "/api/v1/shift-series/"+series.ID+"?scope=this&shift_id="+shifts[0].ID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		handler.DB.Model(&series).Update("generated_until", nil)
+		series.GeneratedUntil = nil
+		created, _, err := handler.generateSeriesShifts(&series, time.Now().AddDate(0, 0, 7*seriesGenerationWeeks))
+		assert.NoError(t, err)
+		assert.Empty(t, created)
+		assert.Len(t, seriesShifts(series.ID), 1)
+	})
+
+	t.Run("Topping up series doesn't duplicate occurrences", func(t *testing.T) {
+		var series models.ShiftSeries
+		handler.DB.Where("parent_series_id = ?", seriesID).First(&series)
+		handler.DB.Model(&series).Updates(map[string]interface{}{"status": "active", "generated_until": nil})
+
+		assert.NoError(t, handler.topUpShiftSeries(context.Background()))
+		assert.NoError(t, handler.topUpShiftSeries(context.Background()))
+		shifts := seriesShifts(series.ID)
+		assert.Len(t, shifts, 1)
+
+		handler.DB.First(&series, "id = ?", series.ID)
+		assert.NotNil(t, series.GeneratedUntil)
+
+		duplicate := shifts[0]
+		duplicate.ID = ""
+		assert.Error(t, handler.DB.Create(&duplicate).Error)
+	})
+
+	t.Run("Cancelling the series removes its scheduled shifts", func(t *testing.T) {
+		var series models.ShiftSeries
+		handler.DB.Where("parent_series_id = ?", seriesID).First(&series)
+
+		w, _ := doRequest(handler, router, "DELETE", "/api/v1/shift-series/"+series.ID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, seriesShifts(series.ID))
+
+		handler.DB.First(&series, "id = ?", series.ID)
+		assert.Equal(t, "cancelled", series.Status)
+	})
+}
@@ -42,6 +42,25 @@ func parseTimeFromString(timeStr string) (time.Time, error) {
 	return time.Time{}, &time.ParseError{Value: timeStr, Layout: "", ValueElem: ""}
 }
 
+// staffHasOverlappingShift reports whether the staff member already has an
+// open shift overlapping the given time, ignoring the shift being edited.
+// Unassigned shifts never clash with each other.
+func (h *Handler) staffHasOverlappingShift(staffID, excludeShiftID string, start, end time.Time) bool {
+	if staffID == "" {
+		return false
+	}
+	query := h.DB.Model(&models.Shift{}).
+		Where("staff_id = ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
+			staffID, "cancelled", "completed", start, start, end, end)
+	if excludeShiftID != "" {
+		query = query.Where("id != ?", excludeShiftID)
+	}
+
+	var overlappingShifts int64
+	query.Count(&overlappingShifts)
+	return overlappingShifts > 0
+}
+
 func (h *Handler) GetShifts(c *gin.Context) {
 	orgID, exists := c.Get("org_id")
 	if !exists {
@@ -73,6 +92,8 @@ func (h *Handler) GetShifts(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	participantID := c.Query("participant_id")
 	staffID := c.Query("staff_id")
+	seriesID := c.Query("series_id")
+	unassigned := c.Query("unassigned") == "true"
 	status := c.Query("status")
 	serviceType := c.Query("service_type")
 	startDate := c.Query("start_date")
@@ -111,6 +132,14 @@ func (h *Handler) GetShifts(c *gin.Context) {
 		query = query.Where("shifts.staff_id = ?", staffID)
 	}
 
+	if seriesID != "" {
+		query = query.Where("shifts.series_id = ?", seriesID)
+	}
+
+	if unassigned {
+		query = query.Where("shifts.staff_id = ?", "")
+	}
+
 	if status != "" {
 		query = query.Where("shifts.status = ?", status)
 	}
@@ -213,14 +242,15 @@ func (h *Handler) GetShift(c *gin.Context) {
 }
 
 type CreateShiftRequest struct {
-	ParticipantID string `json:"participant_id" binding:"required"`
-	StaffID       string `json:"staff_id" binding:"required"`
-	StartTime     string `json:"start_time" binding:"required"` // Accept ISO string or local datetime
-	EndTime       string `json:"end_time" binding:"required"`   // Accept ISO string or local datetime
-	ServiceType   string `json:"service_type" binding:"required"`
-	Location      string `json:"location" binding:"required"`
-	HourlyRate    float64 `json:"hourly_rate" binding:"required,gt=0"`
-	Notes         string `json:"notes"`
+	ParticipantID     string  `json:"participant_id" binding:"required"`
+	StaffID           string  `json:"staff_id"`                      // Leave blank to roster later or let auto-assignment pick
+	StartTime         string  `json:"start_time" binding:"required"` // Accept ISO string or local datetime
+	EndTime           string  `json:"end_time" binding:"required"`   // Accept ISO string or local datetime
+	ServiceType       string  `json:"service_type" binding:"required"`
+	Location          string  `json:"location" binding:"required"`
+	HourlyRate        float64 `json:"hourly_rate" binding:"required,gt=0"`
+	Notes             string  `json:"notes"`
+	SupportItemNumber string  `json:"support_item_number"` // Optional NDIS line item; resolved from service type when blank
 }
 
 func (h *Handler) CreateShift(c *gin.Context) {
@@ -301,34 +331,36 @@ func (h *Handler) CreateShift(c *gin.Context) {
 		return
 	}
 
-	// Verify staff belongs to organization
-	var staff models.User
-	if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    "INVALID_STAFF",
-				"message": "Staff member not found or inactive",
-			},
-		})
-		return
-	}
+	if req.StaffID != "" {
+		// Verify staff belongs to organization
+		var staff models.User
+		if err := h.DB.Where("id = ? AND organization_id = ? AND is_active = ?", req.StaffID, orgID, true).First(&staff).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_STAFF",
+					"message": "Staff member not found or inactive",
+				},
+			})
+			return
+		}
 
-	// Check for overlapping shifts for the staff member
-	var overlappingShifts int64
-	h.DB.Model(&models.Shift{}).
-		Where("staff_id = ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-			req.StaffID, "cancelled", "completed", startTime, startTime, endTime, endTime).
-		Count(&overlappingShifts)
+		// Check for overlapping shifts for the staff member
+		if h.staffHasOverlappingShift(req.StaffID, "", startTime, endTime) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SCHEDULE_CONFLICT",
+					"message": "Staff member already has a shift scheduled during this time",
+				},
+			})
+			return
+		}
+	}
 
-	if overlappingShifts > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"success": false,
-			"error": gin.H{
-				"code":    "SCHEDULE_CONFLICT",
-				"message": "Staff member already has a shift scheduled during this time",
-			},
-		})
+	// Resolve the NDIS line item for this shift's time band and enforce its price limit
+	supportItem, priceLimit, ok := h.priceShift(c, orgID, &participant, req.ServiceType, req.SupportItemNumber, startTime, endTime, req.HourlyRate)
+	if !ok {
 		return
 	}
 
@@ -344,6 +376,10 @@ func (h *Handler) CreateShift(c *gin.Context) {
 		HourlyRate:    req.HourlyRate,
 		Notes:         req.Notes,
 	}
+	if supportItem != nil {
+		shift.SupportItemNumber = supportItem.ItemNumber
+		shift.PriceLimit = priceLimit
+	}
 
 	if err := h.DB.Create(&shift).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -356,26 +392,37 @@ func (h *Handler) CreateShift(c *gin.Context) {
 		return
 	}
 
+	// Unassigned shifts go to the best eligible staff member when the organization allows it
+	message := "Shift created successfully"
+	if shift.StaffID == "" && h.autoAssignEnabled(orgID) {
+		if candidate, err := h.assignBestCandidate(&shift, &participant); err == nil && candidate != nil {
+			message = "Shift created and assigned to " + candidate.Name
+		} else {
+			message = "Shift created; no eligible staff member was found to assign"
+		}
+	}
+
 	// Fetch shift with related data
 	h.DB.Preload("Participant").Preload("Staff").First(&shift, "id = ?", shift.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data":    shift,
-		"message": "Shift created successfully",
+		"message": message,
 	})
 }
 
 type UpdateShiftRequest struct {
-	StartTime       *string  `json:"start_time,omitempty"`        // Accept string for easier frontend integration
-	EndTime         *string  `json:"end_time,omitempty"`          // Accept string for easier frontend integration
-	ActualStartTime *string  `json:"actual_start_time,omitempty"` // Accept string for easier frontend integration
-	ActualEndTime   *string  `json:"actual_end_time,omitempty"`   // Accept string for easier frontend integration
-	ServiceType     *string  `json:"service_type,omitempty"`
-	Location        *string  `json:"location,omitempty"`
-	HourlyRate      *float64 `json:"hourly_rate,omitempty" binding:"omitempty,gt=0"`
-	Notes           *string  `json:"notes,omitempty"`
-	CompletionNotes *string  `json:"completion_notes,omitempty"`
+	StartTime         *string  `json:"start_time,omitempty"`        // Accept string for easier frontend integration
+	EndTime           *string  `json:"end_time,omitempty"`          // Accept string for easier frontend integration
+	ActualStartTime   *string  `json:"actual_start_time,omitempty"` // Accept string for easier frontend integration
+	ActualEndTime     *string  `json:"actual_end_time,omitempty"`   // Accept string for easier frontend integration
+	ServiceType       *string  `json:"service_type,omitempty"`
+	Location          *string  `json:"location,omitempty"`
+	HourlyRate        *float64 `json:"hourly_rate,omitempty" binding:"omitempty,gt=0"`
+	Notes             *string  `json:"notes,omitempty"`
+	CompletionNotes   *string  `json:"completion_notes,omitempty"`
+	SupportItemNumber *string  `json:"support_item_number,omitempty"`
 }
 
 func (h *Handler) UpdateShift(c *gin.Context) {
@@ -449,6 +496,18 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		return
 	}
 
+	// Invoiced shifts are locked to the amounts that were billed
+	if shift.InvoiceID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_INVOICED",
+				"message": "Shift has been invoiced and can no longer be modified",
+			},
+		})
+		return
+	}
+
 	// Parse and validate time ranges if being updated
 	startTime := shift.StartTime
 	endTime := shift.EndTime
@@ -496,15 +555,9 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		return
 	}
 
-	// Check for overlapping shifts if time is being changed
-	if (req.StartTime != nil || req.EndTime != nil) && shift.Status != "cancelled" && shift.Status != "completed" {
-		var overlappingShifts int64
-		h.DB.Model(&models.Shift{}).
-			Where("staff_id = ? AND id != ? AND status NOT IN (?, ?) AND ((start_time <= ? AND end_time > ?) OR (start_time < ? AND end_time >= ?))",
-				shift.StaffID, shiftID, "cancelled", "completed", startTime, startTime, endTime, endTime).
-			Count(&overlappingShifts)
-
-		if overlappingShifts > 0 {
+	// Check for overlapping shifts if time is being changed; unassigned shifts can't clash
+	if (req.StartTime != nil || req.EndTime != nil) && shift.StaffID != "" && shift.Status != "cancelled" && shift.Status != "completed" {
+		if h.staffHasOverlappingShift(shift.StaffID, shiftID, startTime, endTime) {
 			c.JSON(http.StatusConflict, gin.H{
 				"success": false,
 				"error": gin.H{
@@ -516,6 +569,46 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 		}
 	}
 
+	// Re-price against the catalogue when anything that affects the line item changes
+	repriced := false
+	var supportItemNumber string
+	var priceLimit float64
+	if req.StartTime != nil || req.EndTime != nil || req.ServiceType != nil || req.HourlyRate != nil || req.SupportItemNumber != nil {
+		var participant models.Participant
+		if err := h.DB.First(&participant, "id = ?", shift.ParticipantID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to fetch participant",
+				},
+			})
+			return
+		}
+
+		serviceType, itemNumber, rate := shift.ServiceType, shift.SupportItemNumber, shift.HourlyRate
+		if req.ServiceType != nil {
+			serviceType = *req.ServiceType
+			// A new service type picks its own line item unless one is given explicitly
+			itemNumber = ""
+		}
+		if req.SupportItemNumber != nil {
+			itemNumber = *req.SupportItemNumber
+		}
+		if req.HourlyRate != nil {
+			rate = *req.HourlyRate
+		}
+
+		supportItem, limit, ok := h.priceShift(c, participant.OrganizationID, &participant, serviceType, itemNumber, startTime, endTime, rate)
+		if !ok {
+			return
+		}
+		repriced = true
+		if supportItem != nil {
+			supportItemNumber, priceLimit = supportItem.ItemNumber, limit
+		}
+	}
+
 	// Update fields
 	updates := make(map[string]interface{})
 	hourlyRate := shift.HourlyRate // Default to current rate
@@ -556,6 +649,14 @@ func (h *Handler) UpdateShift(c *gin.Context) {
 	if req.CompletionNotes != nil {
 		updates["completion_notes"] = *req.CompletionNotes
 	}
+	if repriced {
+		updates["support_item_number"] = supportItemNumber
+		updates["price_limit"] = priceLimit
+	}
+	if shift.SeriesID != nil {
+		// Keep series-wide edits from overwriting this occurrence
+		updates["series_exception"] = true
+	}
 	
 	// CRITICAL FIX: Recalculate total cost when time or rate changes
 	if timeChanged && endTime.After(startTime) {
@@ -668,6 +769,18 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 		return
 	}
 
+	// Invoiced shifts are locked to the amounts that were billed
+	if shift.InvoiceID != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SHIFT_INVOICED",
+				"message": "Shift has been invoiced and can no longer be modified",
+			},
+		})
+		return
+	}
+
 	// Role-based permissions check
 	role := fmt.Sprintf("%v", userRole)
 	currentUserID := fmt.Sprintf("%v", userID)
@@ -760,6 +873,18 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 		}
 	}
 
+	// Organizations that require shift notes need a progress note before the shift is completed
+	if req.Status == "completed" && h.progressNoteRequired(orgID, shift.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PROGRESS_NOTE_REQUIRED",
+				"message": "A progress note must be written before the shift is completed",
+			},
+		})
+		return
+	}
+
 	// Update fields
 	updates := map[string]interface{}{
 		"status": req.Status,
@@ -788,7 +913,39 @@ func (h *Handler) UpdateShiftStatus(c *gin.Context) {
 		updates["actual_end_time"] = now
 	}
 
-	if err := h.DB.Model(&shift).Updates(updates).Error; err != nil {
+	tx := h.DB.Begin()
+	if err := tx.Model(&shift).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update shift status",
+			},
+		})
+		return
+	}
+
+	// Completed shifts draw down the participant's plan budget for the support category
+	if req.Status == "completed" {
+		budget, err := drawDownPlanBudget(tx, &shift, c.GetString("user_id"))
+		if err == nil && budget != nil {
+			err = raiseBudgetAlerts(tx, budget, now)
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to draw down plan budget",
+				},
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error": gin.H{
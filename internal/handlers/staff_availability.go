@@ -0,0 +1,1022 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// clockMinutes converts an HH:MM clock time to minutes past midnight. "24:00"
+// is accepted as the end of the day.
+func clockMinutes(value string) (int, bool) {
+	if value == "24:00" {
+		return 24 * 60, true
+	}
+	hour, minute, err := parseTimeOfDay(value)
+	if err != nil {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// canManageStaff reports whether the current user may change the given staff
+// member's roster details: managers can manage anyone, staff only themselves
+func canManageStaff(c *gin.Context, staffID string) bool {
+	switch c.GetString("user_role") {
+	case "super_admin", "admin", "manager":
+		return true
+	}
+	return c.GetString("user_id") == staffID
+}
+
+// findOrgStaff loads the staff member named by :id, writing the error
+// response when they aren't in the organization or the caller may not manage them
+func (h *Handler) findOrgStaff(c *gin.Context, orgID interface{}, manage bool) (*models.User, bool) {
+	var staff models.User
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&staff).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "USER_NOT_FOUND",
+					"message": "User not found",
+				},
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch user",
+			},
+		})
+		return nil, false
+	}
+
+	if manage && !canManageStaff(c, staff.ID) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "FORBIDDEN",
+				"message": "You can only manage your own roster details",
+			},
+		})
+		return nil, false
+	}
+	return &staff, true
+}
+
+func (h *Handler) GetStaffAvailability(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, false)
+	if !ok {
+		return
+	}
+
+	var windows []models.StaffAvailability
+	if err := h.DB.Where("staff_id = ?", staff.ID).Order("weekday ASC, start_time ASC").Find(&windows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch availability",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    windows,
+	})
+}
+
+type StaffAvailabilityWindow struct {
+	Weekday       int     `json:"weekday" binding:"min=0,max=6"` // 0 = Sunday
+	StartTime     string  `json:"start_time" binding:"required"` // HH:MM
+	EndTime       string  `json:"end_time" binding:"required"`   // HH:MM, or 24:00 for midnight
+	EffectiveFrom *string `json:"effective_from,omitempty"`      // YYYY-MM-DD
+	EffectiveTo   *string `json:"effective_to,omitempty"`        // YYYY-MM-DD
+}
+
+type SetStaffAvailabilityRequest struct {
+	Windows []StaffAvailabilityWindow `json:"windows" binding:"dive"`
+}
+
+// SetStaffAvailability replaces a staff member's weekly availability windows
+func (h *Handler) SetStaffAvailability(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req SetStaffAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, true)
+	if !ok {
+		return
+	}
+
+	windows := make([]models.StaffAvailability, 0, len(req.Windows))
+	for _, window := range req.Windows {
+		start, startOK := clockMinutes(window.StartTime)
+		end, endOK := clockMinutes(window.EndTime)
+		if !startOK || !endOK || end <= start {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_TIME_RANGE",
+					"message": "Availability windows need HH:MM times with the end after the start",
+				},
+			})
+			return
+		}
+
+		effectiveFrom, fromErr := parseOptionalDate(window.EffectiveFrom)
+		effectiveTo, toErr := parseOptionalDate(window.EffectiveTo)
+		if fromErr != nil || toErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_DATE",
+					"message": "Invalid date format. Use YYYY-MM-DD",
+				},
+			})
+			return
+		}
+
+		availability := models.StaffAvailability{
+			OrganizationID: staff.OrganizationID,
+			StaffID:        staff.ID,
+			Weekday:        window.Weekday,
+			StartTime:      window.StartTime,
+			EndTime:        window.EndTime,
+			EffectiveFrom:  effectiveFrom,
+			EffectiveTo:    effectiveTo,
+		}
+		windows = append(windows, availability)
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Where("staff_id = ?", staff.ID).Delete(&models.StaffAvailability{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update availability",
+			},
+		})
+		return
+	}
+	if len(windows) > 0 {
+		if err := tx.Create(&windows).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "DATABASE_ERROR",
+					"message": "Failed to update availability",
+				},
+			})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    windows,
+		"message": "Availability updated successfully",
+	})
+}
+
+func (h *Handler) GetStaffLeave(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, false)
+	if !ok {
+		return
+	}
+
+	query := h.DB.Where("staff_id = ?", staff.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if c.Query("upcoming") == "true" {
+		query = query.Where("end_time >= ?", time.Now())
+	}
+
+	var leave []models.StaffLeave
+	if err := query.Order("start_time DESC").Find(&leave).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch leave",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leave,
+	})
+}
+
+type CreateStaffLeaveRequest struct {
+	LeaveType string `json:"leave_type" binding:"required,oneof=annual personal unpaid training other"`
+	StartTime string `json:"start_time" binding:"required"` // ISO string or local datetime
+	EndTime   string `json:"end_time" binding:"required"`
+	Reason    string `json:"reason"`
+}
+
+// CreateStaffLeave records leave. Leave entered by a manager is approved
+// straight away; staff requesting their own leave wait for approval.
+func (h *Handler) CreateStaffLeave(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateStaffLeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, true)
+	if !ok {
+		return
+	}
+
+	startTime, startErr := parseTimeFromString(req.StartTime)
+	endTime, endErr := parseTimeFromString(req.EndTime)
+	if startErr != nil || endErr != nil || !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME_RANGE",
+				"message": "Leave needs valid start and end times with the end after the start",
+			},
+		})
+		return
+	}
+
+	leave := models.StaffLeave{
+		OrganizationID: staff.OrganizationID,
+		StaffID:        staff.ID,
+		LeaveType:      req.LeaveType,
+		Status:         "pending",
+		StartTime:      startTime,
+		EndTime:        endTime,
+		Reason:         req.Reason,
+	}
+	switch c.GetString("user_role") {
+	case "super_admin", "admin", "manager":
+		leave.Status = "approved"
+		leave.ApprovedBy = c.GetString("user_id")
+	}
+
+	if err := h.DB.Create(&leave).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create leave",
+			},
+		})
+		return
+	}
+
+	// Approved leave can clash with shifts that are already rostered
+	var clashes []models.Shift
+	if leave.Status == "approved" {
+		h.DB.Where("staff_id = ? AND status = ? AND start_time < ? AND end_time > ?", staff.ID, "scheduled", leave.EndTime, leave.StartTime).
+			Order("start_time ASC").Find(&clashes)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"leave":           leave,
+			"clashing_shifts": clashes,
+		},
+		"message": "Leave recorded successfully",
+	})
+}
+
+type UpdateStaffLeaveStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=approved rejected"`
+}
+
+func (h *Handler) UpdateStaffLeaveStatus(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req UpdateStaffLeaveStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var leave models.StaffLeave
+	if err := h.DB.Where("id = ? AND staff_id = ? AND organization_id = ?", c.Param("leaveId"), c.Param("id"), orgID).First(&leave).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "LEAVE_NOT_FOUND",
+				"message": "Leave not found",
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Model(&leave).Updates(map[string]interface{}{
+		"status":      req.Status,
+		"approved_by": c.GetString("user_id"),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to update leave",
+			},
+		})
+		return
+	}
+
+	h.DB.First(&leave, "id = ?", leave.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    leave,
+		"message": "Leave " + req.Status,
+	})
+}
+
+func (h *Handler) DeleteStaffLeave(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, true)
+	if !ok {
+		return
+	}
+
+	result := h.DB.Where("id = ? AND staff_id = ?", c.Param("leaveId"), staff.ID).Delete(&models.StaffLeave{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete leave",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "LEAVE_NOT_FOUND",
+				"message": "Leave not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Leave deleted successfully",
+	})
+}
+
+func (h *Handler) GetStaffQualifications(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, false)
+	if !ok {
+		return
+	}
+
+	var qualifications []models.StaffQualification
+	if err := h.DB.Where("staff_id = ?", staff.ID).Order("type ASC").Find(&qualifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch qualifications",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    qualifications,
+	})
+}
+
+type StaffQualificationRequest struct {
+	Type       string  `json:"type" binding:"required"`
+	Reference  string  `json:"reference"`
+	IssuedDate *string `json:"issued_date,omitempty"` // YYYY-MM-DD
+	ExpiryDate *string `json:"expiry_date,omitempty"` // YYYY-MM-DD
+	DocumentID *string `json:"document_id,omitempty"`
+	Notes      string  `json:"notes"`
+}
+
+// parseOptionalDate parses a YYYY-MM-DD value, treating nil and blank as no date
+func parseOptionalDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// SaveStaffQualification adds a qualification, or updates the one named by :qualificationId
+func (h *Handler) SaveStaffQualification(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req StaffQualificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, true)
+	if !ok {
+		return
+	}
+
+	issued, issuedErr := parseOptionalDate(req.IssuedDate)
+	expiry, expiryErr := parseOptionalDate(req.ExpiryDate)
+	if issuedErr != nil || expiryErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+
+	qualification := models.StaffQualification{OrganizationID: staff.OrganizationID, StaffID: staff.ID}
+	status := http.StatusCreated
+	if qualificationID := c.Param("qualificationId"); qualificationID != "" {
+		if err := h.DB.Where("id = ? AND staff_id = ?", qualificationID, staff.ID).First(&qualification).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "QUALIFICATION_NOT_FOUND",
+					"message": "Qualification not found",
+				},
+			})
+			return
+		}
+		status = http.StatusOK
+	}
+
+	qualification.Type = req.Type
+	qualification.Reference = req.Reference
+	qualification.IssuedDate = issued
+	qualification.ExpiryDate = expiry
+	qualification.DocumentID = req.DocumentID
+	qualification.Notes = req.Notes
+
+	if err := h.DB.Save(&qualification).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save qualification",
+			},
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    qualification,
+		"message": "Qualification saved successfully",
+	})
+}
+
+func (h *Handler) DeleteStaffQualification(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	staff, ok := h.findOrgStaff(c, orgID, true)
+	if !ok {
+		return
+	}
+
+	result := h.DB.Where("id = ? AND staff_id = ?", c.Param("qualificationId"), staff.ID).Delete(&models.StaffQualification{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete qualification",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "QUALIFICATION_NOT_FOUND",
+				"message": "Qualification not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Qualification deleted successfully",
+	})
+}
+
+// GetExpiringQualifications lists qualifications that have expired or expire within the given number of days
+func (h *Handler) GetExpiringQualifications(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 0 {
+		days = 30
+	}
+
+	var qualifications []models.StaffQualification
+	if err := h.DB.Preload("Staff").
+		Where("organization_id = ? AND expiry_date IS NOT NULL AND expiry_date <= ?", orgID, time.Now().AddDate(0, 0, days)).
+		Order("expiry_date ASC").Find(&qualifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch qualifications",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    qualifications,
+	})
+}
+
+func (h *Handler) GetQualificationRequirements(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var requirements []models.QualificationRequirement
+	if err := h.DB.Where("organization_id = ?", orgID).Order("service_type ASC, qualification_type ASC").Find(&requirements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch qualification requirements",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requirements,
+	})
+}
+
+type CreateQualificationRequirementRequest struct {
+	ServiceType       string `json:"service_type"` // Blank applies to every shift
+	QualificationType string `json:"qualification_type" binding:"required"`
+}
+
+func (h *Handler) CreateQualificationRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateQualificationRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var existing int64
+	h.DB.Model(&models.QualificationRequirement{}).
+		Where("organization_id = ? AND service_type = ? AND qualification_type = ?", orgID, req.ServiceType, req.QualificationType).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "REQUIREMENT_EXISTS",
+				"message": "This qualification is already required for the service type",
+			},
+		})
+		return
+	}
+
+	requirement := models.QualificationRequirement{
+		OrganizationID:    orgID.(string),
+		ServiceType:       req.ServiceType,
+		QualificationType: req.QualificationType,
+	}
+	if err := h.DB.Create(&requirement).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to create qualification requirement",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    requirement,
+		"message": "Qualification requirement created successfully",
+	})
+}
+
+func (h *Handler) DeleteQualificationRequirement(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	result := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).Delete(&models.QualificationRequirement{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete qualification requirement",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "REQUIREMENT_NOT_FOUND",
+				"message": "Qualification requirement not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Qualification requirement deleted successfully",
+	})
+}
+
+func (h *Handler) GetParticipantWorkerPreferences(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+
+	var preferences []models.ParticipantWorkerPreference
+	if err := h.DB.Preload("Staff").Where("participant_id = ?", participant.ID).Order("preference ASC").Find(&preferences).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch worker preferences",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"gender_preference":  participant.GenderPreference,
+			"worker_preferences": preferences,
+		},
+	})
+}
+
+type CreateWorkerPreferenceRequest struct {
+	StaffID    string `json:"staff_id" binding:"required"`
+	Preference string `json:"preference" binding:"required,oneof=preferred blocked"`
+	Reason     string `json:"reason"`
+}
+
+// CreateParticipantWorkerPreference marks a staff member as preferred or
+// blocked for a participant, replacing any earlier preference for them
+func (h *Handler) CreateParticipantWorkerPreference(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req CreateWorkerPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+
+	var staff models.User
+	if err := h.DB.Where("id = ? AND organization_id = ?", req.StaffID, orgID).First(&staff).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_STAFF",
+				"message": "Staff member not found",
+			},
+		})
+		return
+	}
+
+	preference := models.ParticipantWorkerPreference{
+		ParticipantID: participant.ID,
+		StaffID:       staff.ID,
+		Preference:    req.Preference,
+		Reason:        req.Reason,
+		CreatedBy:     c.GetString("user_id"),
+	}
+
+	tx := h.DB.Begin()
+	if err := tx.Where("participant_id = ? AND staff_id = ?", participant.ID, staff.ID).Delete(&models.ParticipantWorkerPreference{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save worker preference",
+			},
+		})
+		return
+	}
+	if err := tx.Create(&preference).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save worker preference",
+			},
+		})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    preference,
+		"message": "Worker preference saved successfully",
+	})
+}
+
+func (h *Handler) DeleteParticipantWorkerPreference(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	participant, ok := h.findOrgParticipant(c, orgID)
+	if !ok {
+		return
+	}
+
+	result := h.DB.Where("id = ? AND participant_id = ?", c.Param("preferenceId"), participant.ID).Delete(&models.ParticipantWorkerPreference{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete worker preference",
+			},
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "PREFERENCE_NOT_FOUND",
+				"message": "Worker preference not found",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Worker preference deleted successfully",
+	})
+}
@@ -328,6 +328,7 @@ func (h *Handler) ReceivePurchaseOrder(c *gin.Context) {
 	}
 
 	// Process received items
+	var movements []models.InventoryMovement
 	for _, receivedItem := range req.ReceivedItems {
 		var orderItem models.PurchaseOrderItem
 		if err := tx.Where("id = ? AND purchase_order_id = ?", receivedItem.ItemID, orderID).
@@ -373,6 +374,13 @@ func (h *Handler) ReceivePurchaseOrder(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create inventory movement"})
 			return
 		}
+		movements = append(movements, movement)
+	}
+
+	if err := h.postStockReceipt(tx, &order, movements); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post receipt to the ledger"})
+		return
 	}
 
 	// Update order status to received
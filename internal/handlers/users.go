@@ -46,6 +46,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Phone:          user.Phone,
+		Gender:         user.Gender,
 		Role:           user.Role,
 		OrganizationID: user.OrganizationID,
 		IsActive:       user.IsActive,
@@ -164,6 +165,7 @@ func (h *Handler) GetUsers(c *gin.Context) {
 			FirstName:      user.FirstName,
 			LastName:       user.LastName,
 			Phone:          user.Phone,
+			Gender:         user.Gender,
 			Role:           user.Role,
 			OrganizationID: user.OrganizationID,
 			IsActive:       user.IsActive,
@@ -193,6 +195,7 @@ type CreateUserRequest struct {
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Phone     string `json:"phone"`
+	Gender    string `json:"gender" binding:"omitempty,oneof=female male non_binary"`
 	Role      string `json:"role" binding:"required,oneof=admin manager care_worker support_coordinator"`
 }
 
@@ -255,6 +258,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 		FirstName:      req.FirstName,
 		LastName:       req.LastName,
 		Phone:          req.Phone,
+		Gender:         req.Gender,
 		Role:           req.Role,
 		OrganizationID: orgID.(string),
 		IsActive:       true,
@@ -278,6 +282,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Phone:          user.Phone,
+		Gender:         user.Gender,
 		Role:           user.Role,
 		OrganizationID: user.OrganizationID,
 		IsActive:       user.IsActive,
@@ -297,6 +302,7 @@ type UpdateUserRequest struct {
 	FirstName *string `json:"first_name,omitempty"`
 	LastName  *string `json:"last_name,omitempty"`
 	Phone     *string `json:"phone,omitempty"`
+	Gender    *string `json:"gender,omitempty" binding:"omitempty,oneof=female male non_binary"`
 	Role      *string `json:"role,omitempty" binding:"omitempty,oneof=admin manager care_worker support_coordinator"`
 	IsActive  *bool   `json:"is_active,omitempty"`
 }
@@ -397,6 +403,9 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	if req.Phone != nil {
 		updates["phone"] = *req.Phone
 	}
+	if req.Gender != nil {
+		updates["gender"] = *req.Gender
+	}
 	if req.Role != nil {
 		updates["role"] = *req.Role
 	}
@@ -425,6 +434,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
 		Phone:          user.Phone,
+		Gender:         user.Gender,
 		Role:           user.Role,
 		OrganizationID: user.OrganizationID,
 		IsActive:       user.IsActive,
@@ -0,0 +1,791 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/jobs"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"gorm.io/gorm"
+)
+
+type vehicleReminderPayload struct {
+	ReminderID string `json:"reminder_id"`
+}
+
+// recordOdometer saves a reading and keeps the vehicle's mileage at the
+// latest one. Readings can't go backwards unless they correct a mistake;
+// backdated readings fill in history without changing the mileage. problem is
+// set when the reading is rejected.
+func recordOdometer(tx *gorm.DB, vehicle *models.Vehicle, reading models.OdometerReading) (problem string, err error) {
+	if reading.Reading < 0 {
+		return "Odometer reading can't be negative", nil
+	}
+	if reading.RecordedAt.IsZero() {
+		reading.RecordedAt = time.Now()
+	}
+	var latest models.OdometerReading
+	err = tx.Where("vehicle_id = ?", vehicle.ID).Order("recorded_at DESC").First(&latest).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", err
+	}
+	backdated := err == nil && reading.RecordedAt.Before(latest.RecordedAt)
+	if !backdated && reading.Source != "correction" && reading.Reading < vehicle.Mileage {
+		return fmt.Sprintf("Odometer reading is lower than the last reading of %d km", vehicle.Mileage), nil
+	}
+
+	reading.OrganizationID = vehicle.OrganizationID
+	reading.VehicleID = vehicle.ID
+	if err := tx.Create(&reading).Error; err != nil {
+		return "", err
+	}
+	if !backdated {
+		if err := tx.Model(vehicle).Update("mileage", reading.Reading).Error; err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// findVehicle loads one of the organization's vehicles for a request
+func (h *Handler) findVehicle(c *gin.Context, orgID interface{}) (*models.Vehicle, bool) {
+	var vehicle models.Vehicle
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&vehicle).Error; err != nil {
+		status, code, message := http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch vehicle"
+		if err == gorm.ErrRecordNotFound {
+			status, code, message = http.StatusNotFound, "VEHICLE_NOT_FOUND", "Vehicle not found"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    code,
+				"message": message,
+			},
+		})
+		return nil, false
+	}
+	return &vehicle, true
+}
+
+// GetOdometerReadings lists a vehicle's odometer readings, newest first
+func (h *Handler) GetOdometerReadings(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	vehicle, ok := h.findVehicle(c, orgID)
+	if !ok {
+		return
+	}
+
+	var readings []models.OdometerReading
+	if err := h.DB.Where("vehicle_id = ?", vehicle.ID).Order("recorded_at DESC").Find(&readings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch odometer readings",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    readings,
+	})
+}
+
+// RecordOdometerReading adds an odometer reading to a vehicle, optionally
+// taken when it came in for one of its bookings
+func (h *Handler) RecordOdometerReading(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req struct {
+		Reading    int        `json:"reading" binding:"min=0"`
+		BookingID  *string    `json:"booking_id"`
+		RecordedAt *time.Time `json:"recorded_at"`
+		Correction bool       `json:"correction"` // Fixes a mistaken reading, so may be lower than the last
+		Notes      string     `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	vehicle, ok := h.findVehicle(c, orgID)
+	if !ok {
+		return
+	}
+	reading := models.OdometerReading{
+		Reading:    req.Reading,
+		BookingID:  req.BookingID,
+		Source:     "manual",
+		RecordedBy: c.GetString("user_id"),
+		Notes:      req.Notes,
+	}
+	if req.RecordedAt != nil {
+		reading.RecordedAt = *req.RecordedAt
+	}
+	if req.Correction {
+		reading.Source = "correction"
+	}
+	if req.BookingID != nil {
+		var count int64
+		h.DB.Model(&models.Booking{}).Where("id = ? AND vehicle_id = ?", *req.BookingID, vehicle.ID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "INVALID_BOOKING",
+					"message": "Booking isn't for this vehicle",
+				},
+			})
+			return
+		}
+		reading.Source = "booking"
+	}
+
+	var problem string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		problem, err = recordOdometer(tx, vehicle, reading)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to record odometer reading",
+			},
+		})
+		return
+	}
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_READING",
+				"message": problem,
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    vehicle,
+		"message": "Odometer reading recorded",
+	})
+}
+
+// serviceDue is where a vehicle stands against a reminder rule
+type serviceDue struct {
+	RuleID        string     `json:"rule_id"`
+	Rule          string     `json:"rule"`
+	LastServiceAt time.Time  `json:"last_service_at"`
+	LastServiceKm int        `json:"last_service_km"`
+	LastBookingID *string    `json:"last_booking_id,omitempty"` // Nil when the vehicle hasn't had the service with us
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	DueMileage    int        `json:"due_mileage,omitempty"`
+	Status        string     `json:"status"` // ok, due_soon, overdue
+	cycleKey      string
+}
+
+// dueAgainst works out when a vehicle last serviced at lastAt with
+// lastKm on the clock is next due under a rule, given its current mileage
+func dueAgainst(rule *models.ServiceReminderRule, lastAt time.Time, lastKm, currentKm int, now time.Time) serviceDue {
+	due := serviceDue{RuleID: rule.ID, Rule: rule.Name, LastServiceAt: lastAt, LastServiceKm: lastKm, Status: "ok"}
+	soon, overdue := false, false
+	if rule.IntervalMonths > 0 {
+		date := lastAt.AddDate(0, rule.IntervalMonths, 0)
+		due.DueDate = &date
+		overdue = overdue || !now.Before(date)
+		soon = soon || !now.Before(date.AddDate(0, 0, -rule.DueSoonDays))
+	}
+	if rule.IntervalKm > 0 {
+		due.DueMileage = lastKm + rule.IntervalKm
+		overdue = overdue || currentKm >= due.DueMileage
+		soon = soon || currentKm >= due.DueMileage-rule.DueSoonKm
+	}
+	switch {
+	case overdue:
+		due.Status = "overdue"
+	case soon:
+		due.Status = "due_soon"
+	}
+	return due
+}
+
+// vehicleServiceDue works out where a vehicle stands against a rule from its
+// last completed booking for the rule's service. Vehicles that haven't had
+// the service with us count from when they were added.
+func vehicleServiceDue(db *gorm.DB, vehicle *models.Vehicle, rule *models.ServiceReminderRule, now time.Time) (serviceDue, error) {
+	query := db.Model(&models.Booking{}).Where("bookings.vehicle_id = ? AND bookings.status = ?", vehicle.ID, "completed")
+	if rule.ServiceID != nil {
+		query = query.Joins("JOIN booking_services ON booking_services.booking_id = bookings.id").
+			Where("booking_services.service_id = ?", *rule.ServiceID)
+	}
+	var last models.Booking
+	err := query.Order("bookings.end_time DESC").First(&last).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return serviceDue{}, err
+	}
+
+	if err == gorm.ErrRecordNotFound {
+		var first models.OdometerReading
+		lastKm := 0
+		if err := db.Where("vehicle_id = ?", vehicle.ID).Order("recorded_at ASC").First(&first).Error; err == nil {
+			lastKm = first.Reading
+		} else if err != gorm.ErrRecordNotFound {
+			return serviceDue{}, err
+		}
+		due := dueAgainst(rule, vehicle.CreatedAt, lastKm, vehicle.Mileage, now)
+		due.cycleKey = "vehicle:" + vehicle.ID
+		return due, nil
+	}
+
+	// The reading taken for the booking, or the last one before it finished
+	var reading models.OdometerReading
+	err = db.Where("vehicle_id = ? AND booking_id = ?", vehicle.ID, last.ID).Order("recorded_at DESC").First(&reading).Error
+	if err == gorm.ErrRecordNotFound {
+		err = db.Where("vehicle_id = ? AND recorded_at <= ?", vehicle.ID, last.EndTime).Order("recorded_at DESC").First(&reading).Error
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return serviceDue{}, err
+	}
+	due := dueAgainst(rule, last.EndTime, reading.Reading, vehicle.Mileage, now)
+	due.LastBookingID = &last.ID
+	due.cycleKey = "booking:" + last.ID
+	return due, nil
+}
+
+// vehicleServicesDue checks a vehicle against each of the organization's active rules
+func vehicleServicesDue(db *gorm.DB, vehicle *models.Vehicle, now time.Time) ([]serviceDue, error) {
+	var rules []models.ServiceReminderRule
+	if err := db.Where("organization_id = ? AND is_active = ?", vehicle.OrganizationID, true).Order("name ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	dues := make([]serviceDue, 0, len(rules))
+	for i := range rules {
+		due, err := vehicleServiceDue(db, vehicle, &rules[i], now)
+		if err != nil {
+			return nil, err
+		}
+		dues = append(dues, due)
+	}
+	return dues, nil
+}
+
+// GetVehicleServiceHistory returns a vehicle's timeline of completed
+// bookings, with the parts and labour used on each, and odometer readings,
+// newest first, along with when it's next due under each reminder rule
+func (h *Handler) GetVehicleServiceHistory(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	vehicle, ok := h.findVehicle(c, orgID)
+	if !ok {
+		return
+	}
+	fail := func() {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch service history",
+			},
+		})
+	}
+
+	var bookings []models.Booking
+	if err := h.DB.Where("vehicle_id = ? AND status = ?", vehicle.ID, "completed").
+		Preload("Services").Preload("Staff").Find(&bookings).Error; err != nil {
+		fail()
+		return
+	}
+	var readings []models.OdometerReading
+	if err := h.DB.Where("vehicle_id = ?", vehicle.ID).Find(&readings).Error; err != nil {
+		fail()
+		return
+	}
+	items := make(map[string][]models.JobCardItem)
+	if len(bookings) > 0 {
+		ids := make([]string, len(bookings))
+		for i, booking := range bookings {
+			ids[i] = booking.ID
+		}
+		var all []models.JobCardItem
+		if err := h.DB.Where("booking_id IN ?", ids).Order("created_at ASC").Find(&all).Error; err != nil {
+			fail()
+			return
+		}
+		for _, item := range all {
+			items[item.BookingID] = append(items[item.BookingID], item)
+		}
+	}
+
+	type entry struct {
+		at   time.Time
+		data gin.H
+	}
+	var entries []entry
+	odometer := make(map[string]int)
+	for _, reading := range readings {
+		if reading.BookingID != nil {
+			odometer[*reading.BookingID] = reading.Reading
+		}
+		entries = append(entries, entry{reading.RecordedAt, gin.H{
+			"type":       "odometer",
+			"date":       reading.RecordedAt,
+			"reading":    reading.Reading,
+			"source":     reading.Source,
+			"booking_id": reading.BookingID,
+			"notes":      reading.Notes,
+		}})
+	}
+	for _, booking := range bookings {
+		names := make([]string, len(booking.Services))
+		for i, service := range booking.Services {
+			names[i] = service.Name
+		}
+		var parts, labour []models.JobCardItem
+		var partsTotal, labourTotal float64
+		for _, item := range items[booking.ID] {
+			if item.Type == "part" {
+				parts = append(parts, item)
+				partsTotal += item.Total
+			} else {
+				labour = append(labour, item)
+				labourTotal += item.Total
+			}
+		}
+		serviceEntry := gin.H{
+			"type":         "service",
+			"date":         booking.EndTime,
+			"booking_id":   booking.ID,
+			"services":     names,
+			"staff":        booking.Staff,
+			"total_price":  booking.TotalPrice,
+			"parts":        parts,
+			"labour":       labour,
+			"parts_total":  roundCurrency(partsTotal),
+			"labour_total": roundCurrency(labourTotal),
+			"notes":        booking.Notes,
+		}
+		if km, ok := odometer[booking.ID]; ok {
+			serviceEntry["odometer"] = km
+		}
+		entries = append(entries, entry{booking.EndTime, serviceEntry})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.After(entries[j].at) })
+	timeline := make([]gin.H, len(entries))
+	for i, e := range entries {
+		timeline[i] = e.data
+	}
+
+	dues, err := vehicleServicesDue(h.DB, vehicle, time.Now())
+	if err != nil {
+		fail()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"vehicle":  vehicle,
+			"timeline": timeline,
+			"due":      dues,
+		},
+	})
+}
+
+// checkReminderRule validates a reminder rule's intervals
+func checkReminderRule(rule *models.ServiceReminderRule) string {
+	switch {
+	case rule.IntervalKm <= 0 && rule.IntervalMonths <= 0:
+		return "A distance or time interval is required"
+	case rule.IntervalKm < 0 || rule.IntervalMonths < 0 || rule.DueSoonKm < 0 || rule.DueSoonDays < 0:
+		return "Intervals can't be negative"
+	}
+	return ""
+}
+
+// GetServiceReminderRules lists the organization's service reminder rules
+func (h *Handler) GetServiceReminderRules(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var rules []models.ServiceReminderRule
+	if err := h.DB.Where("organization_id = ?", orgID).Preload("Service").Order("name ASC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch reminder rules",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// reminderRuleRequest is the body for creating or updating a reminder rule
+type reminderRuleRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	ServiceID      *string `json:"service_id"`
+	IntervalKm     int     `json:"interval_km"`
+	IntervalMonths int     `json:"interval_months"`
+	DueSoonKm      *int    `json:"due_soon_km"`
+	DueSoonDays    *int    `json:"due_soon_days"`
+	IsActive       *bool   `json:"is_active"`
+}
+
+// saveReminderRule validates a rule request and writes it over rule
+func (h *Handler) saveReminderRule(c *gin.Context, orgID interface{}, rule *models.ServiceReminderRule, status int) {
+	var req reminderRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.ServiceID = req.ServiceID
+	rule.IntervalKm = req.IntervalKm
+	rule.IntervalMonths = req.IntervalMonths
+	if req.DueSoonKm != nil {
+		rule.DueSoonKm = *req.DueSoonKm
+	}
+	if req.DueSoonDays != nil {
+		rule.DueSoonDays = *req.DueSoonDays
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	problem := checkReminderRule(rule)
+	if problem == "" && rule.ServiceID != nil {
+		var count int64
+		h.DB.Model(&models.Service{}).Where("id = ? AND organization_id = ?", *rule.ServiceID, orgID).Count(&count)
+		if count == 0 {
+			problem = "Invalid service ID"
+		}
+	}
+	if problem != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_RULE",
+				"message": problem,
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Save(rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to save reminder rule",
+			},
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    rule,
+		"message": "Reminder rule saved",
+	})
+}
+
+// CreateServiceReminderRule adds a service reminder rule
+func (h *Handler) CreateServiceReminderRule(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	rule := models.ServiceReminderRule{OrganizationID: orgID.(string), DueSoonKm: 1000, DueSoonDays: 30, IsActive: true}
+	h.saveReminderRule(c, orgID, &rule, http.StatusCreated)
+}
+
+// findReminderRule loads one of the organization's reminder rules for a request
+func (h *Handler) findReminderRule(c *gin.Context, orgID interface{}) (*models.ServiceReminderRule, bool) {
+	var rule models.ServiceReminderRule
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("ruleId"), orgID).First(&rule).Error; err != nil {
+		status, code, message := http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch reminder rule"
+		if err == gorm.ErrRecordNotFound {
+			status, code, message = http.StatusNotFound, "RULE_NOT_FOUND", "Reminder rule not found"
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    code,
+				"message": message,
+			},
+		})
+		return nil, false
+	}
+	return &rule, true
+}
+
+// UpdateServiceReminderRule changes a service reminder rule
+func (h *Handler) UpdateServiceReminderRule(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	rule, ok := h.findReminderRule(c, orgID)
+	if !ok {
+		return
+	}
+	h.saveReminderRule(c, orgID, rule, http.StatusOK)
+}
+
+// DeleteServiceReminderRule removes a service reminder rule
+func (h *Handler) DeleteServiceReminderRule(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+	rule, ok := h.findReminderRule(c, orgID)
+	if !ok {
+		return
+	}
+
+	if err := h.DB.Delete(rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to delete reminder rule",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reminder rule deleted",
+	})
+}
+
+// queueServiceReminders reminds owners of vehicles that are due soon or
+// overdue under an active rule. Each interval is reminded about once, on
+// every channel the organization sends booking notifications over.
+func (h *Handler) queueServiceReminders(ctx context.Context) error {
+	var orgIDs []string
+	if err := h.DB.Model(&models.ServiceReminderRule{}).Where("is_active = ?", true).
+		Distinct().Pluck("organization_id", &orgIDs).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, orgID := range orgIDs {
+		var org models.Organization
+		if err := h.DB.Where("id = ?", orgID).First(&org).Error; err != nil {
+			log.Printf("Failed to load organization %s for service reminders: %v", orgID, err)
+			continue
+		}
+		var vehicles []models.Vehicle
+		if err := h.DB.Where("organization_id = ? AND is_active = ?", orgID, true).Preload("Customer").Find(&vehicles).Error; err != nil {
+			return err
+		}
+		for i := range vehicles {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := h.queueVehicleReminders(&org, &vehicles[i], now); err != nil {
+				log.Printf("Failed to queue service reminders for vehicle %s: %v", vehicles[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// queueVehicleReminders queues reminders for one vehicle's due services
+func (h *Handler) queueVehicleReminders(org *models.Organization, vehicle *models.Vehicle, now time.Time) error {
+	dues, err := vehicleServicesDue(h.DB, vehicle, now)
+	if err != nil {
+		return err
+	}
+	recipients := notificationRecipients(org, &vehicle.Customer)
+	for _, due := range dues {
+		if due.Status == "ok" || len(recipients) == 0 {
+			continue
+		}
+		err := h.DB.Transaction(func(tx *gorm.DB) error {
+			var count int64
+			if err := tx.Model(&models.VehicleServiceReminder{}).
+				Where("vehicle_id = ? AND rule_id = ? AND cycle_key = ?", vehicle.ID, due.RuleID, due.cycleKey).
+				Count(&count).Error; err != nil || count > 0 {
+				return err
+			}
+			for channel, recipient := range recipients {
+				reminder := models.VehicleServiceReminder{
+					OrganizationID: org.ID,
+					VehicleID:      vehicle.ID,
+					RuleID:         due.RuleID,
+					CustomerID:     vehicle.CustomerID,
+					CycleKey:       due.cycleKey,
+					DueDate:        due.DueDate,
+					DueMileage:     due.DueMileage,
+					Channel:        channel,
+					Recipient:      recipient,
+					Status:         "scheduled",
+				}
+				if err := tx.Create(&reminder).Error; err != nil {
+					return err
+				}
+				if _, err := jobs.Enqueue(tx, vehicleReminderJob, vehicleReminderPayload{ReminderID: reminder.ID}, now, "vehicle-reminder:"+reminder.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliverVehicleReminder sends a queued service reminder
+func (h *Handler) deliverVehicleReminder(ctx context.Context, job *models.Job) error {
+	var payload vehicleReminderPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	var reminder models.VehicleServiceReminder
+	if err := h.DB.Where("id = ?", payload.ReminderID).First(&reminder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	if reminder.Status == "sent" || reminder.Status == "skipped" {
+		return nil
+	}
+
+	var vehicle models.Vehicle
+	if err := h.DB.Preload("Customer").Where("id = ?", reminder.VehicleID).First(&vehicle).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return h.DB.Model(&reminder).Updates(map[string]interface{}{"status": "skipped", "error": "Vehicle was deleted"}).Error
+		}
+		return err
+	}
+	var rule models.ServiceReminderRule
+	if err := h.DB.Unscoped().Where("id = ?", reminder.RuleID).First(&rule).Error; err != nil {
+		return err
+	}
+	var org models.Organization
+	if err := h.DB.Where("id = ?", reminder.OrganizationID).First(&org).Error; err != nil {
+		return err
+	}
+
+	details := notify.VehicleServiceDetails{
+		CustomerName:      vehicle.Customer.FirstName,
+		OrganizationName:  org.Name,
+		OrganizationPhone: org.Phone,
+		Vehicle:           strings.TrimSpace(strings.Join(nonEmpty(vehicle.Make, vehicle.Model, vehicle.LicensePlate), " ")),
+		Service:           rule.Name,
+	}
+	if reminder.DueDate != nil {
+		details.DueDate = reminder.DueDate.In(h.organizationLocation(org.ID)).Format("2 January 2006")
+	}
+	if reminder.DueMileage > 0 {
+		details.DueMileage = fmt.Sprintf("%d", reminder.DueMileage)
+	}
+	msg, err := notify.Render(notify.VehicleServiceDue, reminder.Channel, reminder.Recipient, details)
+	if err != nil {
+		// Retrying won't fix a broken template
+		h.DB.Model(&reminder).Updates(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return nil
+	}
+	if err := h.Notifier.Send(msg); err != nil {
+		h.DB.Model(&reminder).Updates(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return err
+	}
+	return h.DB.Model(&reminder).Updates(map[string]interface{}{"status": "sent", "error": "", "sent_at": time.Now()}).Error
+}
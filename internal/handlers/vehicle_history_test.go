@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleServiceHistory(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{},
+		&models.Vehicle{}, &models.OdometerReading{}, &models.JobCardItem{}, &models.Product{}, &models.InventoryMovement{},
+		&models.ServiceReminderRule{}, &models.VehicleServiceReminder{})
+	outbox := &notify.Outbox{}
+	handler.Notifier = outbox
+	queue := handler.JobQueue()
+
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Update("booking_notification_channels", "email")
+	handler.DB.Create(&models.Service{ID: "oil-change", OrganizationID: "test-org", Name: "Oil change", Category: "maintenance", Duration: 60, Price: 120, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "driver", OrganizationID: "test-org", FirstName: "Dana", LastName: "Driver", Email: "dana@example.com", IsActive: true})
+	handler.DB.Create(&models.Product{ID: "oil-filter", OrganizationID: "test-org", Name: "Oil filter", SKU: "OF-1", SellingPrice: 25, CostPrice: 10, CurrentStock: 3, IsActive: true})
+
+	stock := func() int {
+		var product models.Product
+		handler.DB.First(&product, "id = ?", "oil-filter")
+		return product.CurrentStock
+	}
+
+	var vehicleID string
+	t.Run("A new vehicle's mileage starts its odometer history", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/vehicles", map[string]interface{}{
+			"customer_id": "driver", "make": "Toyota", "model": "Corolla", "year": 2018,
+			"license_plate": "ABC123", "vin": "JTDBR32E720012345", "mileage": 50000,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		vehicle := response["vehicle"].(map[string]interface{})
+		vehicleID = vehicle["id"].(string)
+		assert.Equal(t, 50000.0, vehicle["mileage"])
+
+		var count int64
+		handler.DB.Model(&models.OdometerReading{}).Where("vehicle_id = ?", vehicleID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Odometer readings can only go down as a correction", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/vehicles/"+vehicleID+"/odometer", map[string]interface{}{"reading": 49000})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/vehicles/"+vehicleID+"/odometer", map[string]interface{}{"reading": 49500, "correction": true})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 49500.0, response["data"].(map[string]interface{})["mileage"])
+
+		// Backdated readings are kept but don't change the mileage
+		w, response = doRequest(handler, router, "POST", "/api/v1/vehicles/"+vehicleID+"/odometer", map[string]interface{}{
+			"reading": 40000, "recorded_at": time.Now().AddDate(-1, 0, 0),
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 49500.0, response["data"].(map[string]interface{})["mileage"])
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/vehicles/"+vehicleID+"/odometer", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"], 3)
+	})
+
+	start := time.Now().AddDate(0, 0, -2).Truncate(time.Hour).UTC()
+	var bookingID string
+	t.Run("Parts on the job card come out of stock and go back when removed", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "driver", "vehicle_id": vehicleID, "service_ids": []string{"oil-change"}, "start_time": start,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		bookingID = response["booking"].(map[string]interface{})["id"].(string)
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/bookings/"+bookingID+"/job-card/items", map[string]interface{}{
+			"type": "part", "product_id": "oil-filter", "quantity": 5,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, response = doRequest(handler, router, "POST", "/api/v1/bookings/"+bookingID+"/job-card/items", map[string]interface{}{
+			"type": "part", "product_id": "oil-filter", "quantity": 2,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 50.0, response["data"].(map[string]interface{})["total"])
+		assert.Equal(t, 1, stock())
+		itemID := response["data"].(map[string]interface{})["id"].(string)
+
+		w, _ = doRequest(handler, router, "DELETE", "/api/v1/bookings/"+bookingID+"/job-card/items/"+itemID, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 3, stock())
+
+		var movements int64
+		handler.DB.Model(&models.InventoryMovement{}).Where("reference = ? AND reference_type = ?", bookingID, "booking").Count(&movements)
+		assert.Equal(t, int64(2), movements)
+
+		doRequest(handler, router, "POST", "/api/v1/bookings/"+bookingID+"/job-card/items", map[string]interface{}{
+			"type": "part", "product_id": "oil-filter", "quantity": 1,
+		})
+		w, _ = doRequest(handler, router, "POST", "/api/v1/bookings/"+bookingID+"/job-card/items", map[string]interface{}{
+			"type": "labour", "description": "Drain and refill", "quantity": 1.5, "unit_price": 80, "staff_id": "test-user",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/bookings/"+bookingID+"/job-card", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		card := response["data"].(map[string]interface{})
+		assert.Len(t, card["items"], 2)
+		assert.Equal(t, 25.0, card["parts_total"])
+		assert.Equal(t, 120.0, card["labour_total"])
+	})
+
+	t.Run("Completing a booking records its odometer reading", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "PATCH", "/api/v1/bookings/"+bookingID+"/status", map[string]interface{}{"status": "completed", "odometer": 1000})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = doRequest(handler, router, "PATCH", "/api/v1/bookings/"+bookingID+"/status", map[string]interface{}{"status": "completed", "odometer": 51000})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var reading models.OdometerReading
+		handler.DB.Where("booking_id = ?", bookingID).First(&reading)
+		assert.Equal(t, 51000, reading.Reading)
+		assert.Equal(t, "booking", reading.Source)
+	})
+
+	t.Run("History shows services with their parts, labour and odometer", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/vehicles/"+vehicleID+"/history", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		timeline := data["timeline"].([]interface{})
+		assert.Len(t, timeline, 5)
+
+		var service map[string]interface{}
+		for _, entry := range timeline {
+			if e := entry.(map[string]interface{}); e["type"] == "service" {
+				service = e
+			}
+		}
+		if assert.NotNil(t, service) {
+			assert.Equal(t, 51000.0, service["odometer"])
+			assert.Len(t, service["parts"], 1)
+			assert.Equal(t, 120.0, service["labour_total"])
+		}
+	})
+
+	t.Run("Job cards download as a PDF", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/bookings/"+bookingID+"/job-card/download", nil)
+		req.Header.Set("Authorization", "Bearer "+getTestToken(handler))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")))
+	})
+
+	t.Run("Rules are due by distance or time, whichever comes first", func(t *testing.T) {
+		rule := &models.ServiceReminderRule{Name: "Service", IntervalKm: 10000, IntervalMonths: 12, DueSoonKm: 1000, DueSoonDays: 30}
+		last := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		assert.Equal(t, "ok", dueAgainst(rule, last, 50000, 55000, last.AddDate(0, 6, 0)).Status)
+		assert.Equal(t, "due_soon", dueAgainst(rule, last, 50000, 59200, last.AddDate(0, 6, 0)).Status)
+		assert.Equal(t, "due_soon", dueAgainst(rule, last, 50000, 51000, last.AddDate(0, 11, 10)).Status)
+		assert.Equal(t, "overdue", dueAgainst(rule, last, 50000, 60000, last.AddDate(0, 6, 0)).Status)
+		assert.Equal(t, "overdue", dueAgainst(rule, last, 50000, 51000, last.AddDate(1, 0, 0)).Status)
+
+		due := dueAgainst(rule, last, 50000, 51000, last)
+		assert.Equal(t, 60000, due.DueMileage)
+		assert.True(t, due.DueDate.Equal(last.AddDate(1, 0, 0)))
+	})
+
+	t.Run("Owners are reminded once per interval", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/vehicles/reminder-rules", map[string]interface{}{"name": "Oil change"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/vehicles/reminder-rules", map[string]interface{}{
+			"name": "Oil change", "service_id": "oil-change", "interval_km": 5000, "interval_months": 6,
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		handler.queueServiceReminders(context.Background())
+		var reminders []models.VehicleServiceReminder
+		handler.DB.Find(&reminders)
+		assert.Len(t, reminders, 0)
+
+		// Nearly 5,000 km since the oil change
+		doRequest(handler, router, "POST", "/api/v1/vehicles/"+vehicleID+"/odometer", map[string]interface{}{"reading": 55500})
+		handler.queueServiceReminders(context.Background())
+		handler.queueServiceReminders(context.Background())
+		handler.DB.Find(&reminders)
+		if assert.Len(t, reminders, 1) {
+			assert.Equal(t, "booking:"+bookingID, reminders[0].CycleKey)
+			assert.Equal(t, 56000, reminders[0].DueMileage)
+		}
+
+		_, err := queue.RunDue(context.Background())
+		assert.NoError(t, err)
+		handler.DB.First(&reminders[0], "id = ?", reminders[0].ID)
+		assert.Equal(t, "sent", reminders[0].Status)
+		messages := outbox.Messages()
+		if assert.Len(t, messages, 1) {
+			assert.Equal(t, "dana@example.com", messages[0].To)
+			assert.Contains(t, messages[0].Body, "56000")
+		}
+	})
+}
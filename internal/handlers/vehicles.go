@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/vehiclelookup"
 	"gorm.io/gorm"
 )
 
@@ -130,6 +134,11 @@ func (h *Handler) CreateVehicle(c *gin.Context) {
 		return
 	}
 
+	if err := h.prefillVehicle(c.Request.Context(), &vehicle, &models.Vehicle{}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check if VIN already exists (if provided)
 	if vehicle.VIN != "" {
 		var existingVehicle models.Vehicle
@@ -148,7 +157,20 @@ func (h *Handler) CreateVehicle(c *gin.Context) {
 		}
 	}
 
-	if err := h.DB.Create(&vehicle).Error; err != nil {
+	// Start the vehicle's odometer history from its mileage
+	mileage := vehicle.Mileage
+	vehicle.Mileage = 0
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vehicle).Error; err != nil {
+			return err
+		}
+		if mileage > 0 {
+			_, err := recordOdometer(tx, &vehicle, models.OdometerReading{Reading: mileage, RecordedBy: c.GetString("user_id")})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vehicle"})
 		return
 	}
@@ -159,6 +181,105 @@ func (h *Handler) CreateVehicle(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"vehicle": vehicle})
 }
 
+// prefillVehicle checks a new VIN and fills in the make, model, year and
+// colour from it, or from the registration when a registry is set up.
+// Only details that neither vehicle nor current has are filled in.
+func (h *Handler) prefillVehicle(ctx context.Context, vehicle, current *models.Vehicle) error {
+	missing := func() bool {
+		return (vehicle.Make == "" && current.Make == "") || (vehicle.Model == "" && current.Model == "") ||
+			(vehicle.Year == 0 && current.Year == 0)
+	}
+
+	var found []*vehiclelookup.Details
+	if vehicle.VIN != "" && vehiclelookup.NormalizeVIN(vehicle.VIN) != current.VIN {
+		details, err := h.VehicleLookup.VIN(vehicle.VIN)
+		if err != nil {
+			return err
+		}
+		vehicle.VIN = details.VIN
+		found = append(found, details)
+	}
+
+	plate, state := vehicle.LicensePlate, vehicle.RegistrationState
+	if plate == "" {
+		plate = current.LicensePlate
+	}
+	if state == "" {
+		state = current.RegistrationState
+	}
+	if plate != "" && state != "" && missing() {
+		details, err := h.VehicleLookup.Plate(ctx, plate, state)
+		switch {
+		case err == nil:
+			found = append(found, details)
+		case errors.Is(err, vehiclelookup.ErrUnknownState):
+			return err
+		case !errors.Is(err, vehiclelookup.ErrNoRegistry) && !errors.Is(err, vehiclelookup.ErrNotFound):
+			// The vehicle can still be saved with what staff entered
+			log.Printf("Failed to look up registration %s %s: %v", state, plate, err)
+		}
+	}
+
+	for _, details := range found {
+		if vehicle.Make == "" && current.Make == "" {
+			vehicle.Make = details.Make
+		}
+		if vehicle.Model == "" && current.Model == "" {
+			vehicle.Model = details.Model
+		}
+		if vehicle.Year == 0 && current.Year == 0 {
+			vehicle.Year = details.Year
+		}
+		if vehicle.Color == "" && current.Color == "" {
+			vehicle.Color = details.Color
+		}
+		if vehicle.VIN == "" && current.VIN == "" {
+			vehicle.VIN = details.VIN
+		}
+	}
+	return nil
+}
+
+// LookupVehicle finds a vehicle's details by VIN, or by registration and
+// state, so they can be filled in before it's added
+func (h *Handler) LookupVehicle(c *gin.Context) {
+	orgID := h.getOrganizationID(c)
+	if orgID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Organization not found"})
+		return
+	}
+
+	var details *vehiclelookup.Details
+	var err error
+	switch {
+	case c.Query("vin") != "":
+		details, err = h.VehicleLookup.VIN(c.Query("vin"))
+	case c.Query("plate") != "":
+		details, err = h.VehicleLookup.Plate(c.Request.Context(), c.Query("plate"), c.Query("state"))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A VIN or registration is required"})
+		return
+	}
+
+	switch {
+	case errors.Is(err, vehiclelookup.ErrInvalidVIN), errors.Is(err, vehiclelookup.ErrUnknownState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, vehiclelookup.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No vehicle with this registration"})
+		return
+	case errors.Is(err, vehiclelookup.ErrNoRegistry):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Registration lookups aren't set up"})
+		return
+	case err != nil:
+		log.Printf("Failed to look up vehicle: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Vehicle lookup failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vehicle": details})
+}
+
 // UpdateVehicle updates an existing vehicle
 func (h *Handler) UpdateVehicle(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
@@ -197,6 +318,11 @@ func (h *Handler) UpdateVehicle(c *gin.Context) {
 		}
 	}
 
+	if err := h.prefillVehicle(c.Request.Context(), &updateData, &vehicle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Check if VIN is being changed and if it conflicts with another vehicle
 	if updateData.VIN != "" && updateData.VIN != vehicle.VIN {
 		var existingVehicle models.Vehicle
@@ -215,8 +341,20 @@ func (h *Handler) UpdateVehicle(c *gin.Context) {
 		}
 	}
 
-	// Update only provided fields
-	if err := h.DB.Model(&vehicle).Updates(updateData).Error; err != nil {
+	// Update only provided fields. A new mileage is recorded as an odometer reading.
+	mileage := updateData.Mileage
+	updateData.Mileage = 0
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&vehicle).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if mileage > 0 && mileage != vehicle.Mileage {
+			_, err := recordOdometer(tx, &vehicle, mileageReading(&vehicle, mileage, c.GetString("user_id"), ""))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vehicle"})
 		return
 	}
@@ -293,6 +431,16 @@ func (h *Handler) ToggleVehicleStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"vehicle": vehicle})
 }
 
+// mileageReading is the odometer reading for setting a vehicle's mileage
+// directly, which may correct a mistaken reading downwards
+func mileageReading(vehicle *models.Vehicle, mileage int, userID, notes string) models.OdometerReading {
+	reading := models.OdometerReading{Reading: mileage, Source: "manual", RecordedBy: userID, Notes: notes}
+	if mileage < vehicle.Mileage {
+		reading.Source = "correction"
+	}
+	return reading
+}
+
 // UpdateVehicleMileage updates the mileage of a vehicle
 func (h *Handler) UpdateVehicleMileage(c *gin.Context) {
 	orgID := h.getOrganizationID(c)
@@ -322,15 +470,16 @@ func (h *Handler) UpdateVehicleMileage(c *gin.Context) {
 		return
 	}
 
-	updates := map[string]interface{}{
-		"mileage": request.Mileage,
-	}
-
-	if request.Notes != "" {
-		updates["notes"] = request.Notes
-	}
-
-	if err := h.DB.Model(&vehicle).Updates(updates).Error; err != nil {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if request.Notes != "" {
+			if err := tx.Model(&vehicle).Update("notes", request.Notes).Error; err != nil {
+				return err
+			}
+		}
+		_, err := recordOdometer(tx, &vehicle, mileageReading(&vehicle, request.Mileage, c.GetString("user_id"), request.Notes))
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vehicle mileage"})
 		return
 	}
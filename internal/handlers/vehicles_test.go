@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/vehiclelookup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleLookup(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.Vehicle{}, &models.OdometerReading{})
+	handler.DB.Create(&models.Customer{ID: "owner", OrganizationID: "test-org", FirstName: "Olive", LastName: "Owner", IsActive: true})
+
+	t.Run("VINs are decoded offline", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/vehicles/lookup?vin=5YJ3E1EA6LF000001", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		vehicle := response["vehicle"].(map[string]interface{})
+		assert.Equal(t, "Tesla", vehicle["make"])
+		assert.Equal(t, 2020.0, vehicle["year"])
+
+		w, _ = doRequest(handler, router, "GET", "/api/v1/vehicles/lookup?vin=5YJ3E1EA7LF000001", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, _ = doRequest(handler, router, "GET", "/api/v1/vehicles/lookup?plate=ABC123&state=NSW", nil)
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+
+	t.Run("New vehicles are filled in from their VIN", func(t *testing.T) {
+		w, _ := doRequest(handler, router, "POST", "/api/v1/vehicles", map[string]interface{}{
+			"customer_id": "owner", "model": "Model 3", "vin": "5YJ3E1EA7LF000001",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/vehicles", map[string]interface{}{
+			"customer_id": "owner", "model": "Model 3", "vin": "5yj3e1ea6lf000001",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		vehicle := response["vehicle"].(map[string]interface{})
+		assert.Equal(t, "5YJ3E1EA6LF000001", vehicle["vin"])
+		assert.Equal(t, "Tesla", vehicle["make"])
+		assert.Equal(t, 2020.0, vehicle["year"])
+	})
+
+	t.Run("New vehicles are filled in from their registration", func(t *testing.T) {
+		registry := &vehiclelookup.FakeRegistry{}
+		registry.Add("XYZ789", "VIC", vehiclelookup.Details{VIN: "6T1BF3FK7RX012345", Model: "Camry", Color: "Silver"})
+		handler.VehicleLookup = &vehiclelookup.Lookup{Registry: registry}
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/vehicles", map[string]interface{}{
+			"customer_id": "owner", "license_plate": "XYZ789", "registration_state": "VIC",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		vehicle := response["vehicle"].(map[string]interface{})
+		assert.Equal(t, "Toyota", vehicle["make"])
+		assert.Equal(t, "Camry", vehicle["model"])
+		assert.Equal(t, "Silver", vehicle["color"])
+		assert.Equal(t, "6T1BF3FK7RX012345", vehicle["vin"])
+
+		// What staff enter wins over the registry
+		w, response = doRequest(handler, router, "PUT", "/api/v1/vehicles/"+vehicle["id"].(string), map[string]interface{}{"color": "Grey"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Grey", response["vehicle"].(map[string]interface{})["color"])
+
+		w, _ = doRequest(handler, router, "POST", "/api/v1/vehicles", map[string]interface{}{
+			"customer_id": "owner", "license_plate": "XYZ789", "registration_state": "XX",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,715 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/availability"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+)
+
+// defaultWaitlistHold is how long a freed slot is held when the organization hasn't set a time
+const defaultWaitlistHold = 30 * time.Minute
+
+// waitlistOfferToken signs an offer ID for the link sent to the customer
+func (h *Handler) waitlistOfferToken(offerID string) string {
+	return h.signedToken("waitlist-offer", offerID)
+}
+
+// waitlistWindowFits reports whether a booking from start to end falls within
+// the dates and times a waitlisted customer can come in
+func waitlistWindowFits(entry *models.WaitlistEntry, start, end time.Time, loc *time.Location) bool {
+	local := start.In(loc)
+	date := local.Format("2006-01-02")
+	if date < entry.EarliestDate.UTC().Format("2006-01-02") || date > entry.LatestDate.UTC().Format("2006-01-02") {
+		return false
+	}
+
+	from := local.Hour()*60 + local.Minute()
+	to := from + int(end.Sub(start)/time.Minute)
+	if earliest, ok := availability.ParseClock(entry.EarliestTime); ok && from < earliest {
+		return false
+	}
+	if latest, ok := availability.ParseClock(entry.LatestTime); ok && to > latest {
+		return false
+	}
+	return true
+}
+
+// offerFreedSlot offers the time a cancelled booking freed to the first
+// waiting customer it suits, skipping anyone already offered it. It returns
+// nil when nobody on the waitlist can take the slot.
+func (h *Handler) offerFreedSlot(source *models.Booking) (*models.WaitlistOffer, error) {
+	if !source.StartTime.After(time.Now()) {
+		return nil, nil
+	}
+
+	var org models.Organization
+	if err := h.DB.Where("id = ?", source.OrganizationID).First(&org).Error; err != nil {
+		return nil, err
+	}
+	loc := h.organizationLocation(org.ID)
+
+	var offered []string
+	if err := h.DB.Model(&models.WaitlistOffer{}).Where("source_booking_id = ?", source.ID).Pluck("entry_id", &offered).Error; err != nil {
+		return nil, err
+	}
+	query := h.DB.Preload("Customer").Preload("Service.ResourceRequirements").
+		Where("organization_id = ? AND status = ?", org.ID, "waiting")
+	if len(offered) > 0 {
+		query = query.Where("id NOT IN ?", offered)
+	}
+	var entries []models.WaitlistEntry
+	if err := query.Order("created_at ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Customer == nil || entry.Service == nil || !entry.Service.IsActive {
+			continue
+		}
+		if entry.StaffID != nil && (source.StaffID == nil || *source.StaffID != *entry.StaffID) {
+			continue
+		}
+
+		services := []models.Service{*entry.Service}
+		duration := servicesDuration(services)
+		if !waitlistWindowFits(entry, source.StartTime, source.StartTime.Add(duration), loc) {
+			continue
+		}
+		slotQuery := availability.Query{
+			Location:     loc,
+			Duration:     duration,
+			Buffer:       bookingBuffer(&org, services),
+			Requirements: serviceRequirements(services),
+		}
+		if source.StaffID != nil {
+			slotQuery.StaffIDs = []string{*source.StaffID}
+		}
+		if _, ok, err := availability.Check(h.DB, &org, slotQuery, source.StartTime); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		hold := time.Duration(org.BookingSettings.WaitlistHoldMinutes) * time.Minute
+		if hold <= 0 {
+			hold = defaultWaitlistHold
+		}
+		offer := models.WaitlistOffer{
+			OrganizationID:  org.ID,
+			EntryID:         entry.ID,
+			SourceBookingID: source.ID,
+			StaffID:         source.StaffID,
+			StartTime:       source.StartTime,
+			EndTime:         source.StartTime.Add(duration),
+			Status:          "pending",
+			ExpiresAt:       time.Now().Add(hold),
+		}
+		tx := h.DB.Begin()
+		if err := tx.Create(&offer).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := tx.Model(entry).Update("status", "offered").Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		tx.Commit()
+
+		h.sendWaitlistOffer(&org, entry, &offer, loc)
+		return &offer, nil
+	}
+	return nil, nil
+}
+
+// sendWaitlistOffer tells the customer about an offer by email, or by SMS
+// when there's no email address on file
+func (h *Handler) sendWaitlistOffer(org *models.Organization, entry *models.WaitlistEntry, offer *models.WaitlistOffer, loc *time.Location) {
+	msg := notify.Message{Channel: notify.Email, To: entry.Customer.Email}
+	if msg.To == "" {
+		msg.Channel, msg.To = notify.SMS, entry.Customer.Phone
+	}
+	msg.Subject = fmt.Sprintf("An appointment has opened up at %s", org.Name)
+	msg.Body = fmt.Sprintf("Hi %s, a %s appointment is available on %s. We're holding it for you until %s. Accept or decline it here: %s/api/v1/public/%s/waitlist-offers/%s",
+		entry.Customer.FirstName, entry.Service.Name,
+		offer.StartTime.In(loc).Format("Monday 2 January at 3:04 PM"),
+		offer.ExpiresAt.In(loc).Format("3:04 PM"),
+		h.Config.AppURL, org.Slug, h.waitlistOfferToken(offer.ID))
+
+	if err := h.Notifier.Send(msg); err != nil {
+		log.Printf("Failed to send waitlist offer %s: %v", offer.ID, err)
+		return
+	}
+	now := time.Now()
+	h.DB.Model(offer).Updates(map[string]interface{}{"channel": msg.Channel, "notified_at": now})
+}
+
+// releaseWaitlistOffer closes a pending offer without a booking, puts the
+// customer back on the waitlist and offers the slot to the next in line
+func (h *Handler) releaseWaitlistOffer(offer *models.WaitlistOffer, status string) {
+	now := time.Now()
+	result := h.DB.Model(&models.WaitlistOffer{}).Where("id = ? AND status = ?", offer.ID, "pending").
+		Updates(map[string]interface{}{"status": status, "responded_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	offer.Status, offer.RespondedAt = status, &now
+	h.DB.Model(&models.WaitlistEntry{}).Where("id = ? AND status = ?", offer.EntryID, "offered").Update("status", "waiting")
+
+	var source models.Booking
+	if err := h.DB.Where("id = ?", offer.SourceBookingID).First(&source).Error; err != nil {
+		return
+	}
+	if _, err := h.offerFreedSlot(&source); err != nil {
+		log.Printf("Failed to offer freed slot from booking %s: %v", source.ID, err)
+	}
+}
+
+// expireWaitlistOffers closes offers whose hold has run out and passes their
+// slots on down the waitlist
+func (h *Handler) expireWaitlistOffers(orgID interface{}) {
+	var expired []models.WaitlistOffer
+	h.DB.Where("organization_id = ? AND status = ? AND expires_at <= ?", orgID, "pending", time.Now()).
+		Order("expires_at ASC").Find(&expired)
+	for i := range expired {
+		h.releaseWaitlistOffer(&expired[i], "expired")
+	}
+}
+
+// GetWaitlist lists the organization's waitlist in the order offers are made
+func (h *Handler) GetWaitlist(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	h.expireWaitlistOffers(orgID)
+
+	query := h.DB.Preload("Customer").Preload("Service").Preload("Staff").Where("organization_id = ?", orgID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{"waiting", "offered"})
+	}
+	if serviceID := c.Query("service_id"); serviceID != "" {
+		query = query.Where("service_id = ?", serviceID)
+	}
+	if customerID := c.Query("customer_id"); customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+
+	var entries []models.WaitlistEntry
+	if err := query.Order("created_at ASC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch waitlist",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+type WaitlistEntryRequest struct {
+	CustomerID   string  `json:"customer_id" binding:"required"`
+	ServiceID    string  `json:"service_id" binding:"required"`
+	StaffID      *string `json:"staff_id"`
+	EarliestDate string  `json:"earliest_date" binding:"required"` // YYYY-MM-DD
+	LatestDate   string  `json:"latest_date" binding:"required"`   // YYYY-MM-DD
+	EarliestTime string  `json:"earliest_time"`                    // HH:MM
+	LatestTime   string  `json:"latest_time"`                      // HH:MM
+	Notes        string  `json:"notes"`
+}
+
+// CreateWaitlistEntry adds a customer to the waitlist for a service
+func (h *Handler) CreateWaitlistEntry(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var req WaitlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "VALIDATION_ERROR",
+				"message": "Invalid request parameters",
+				"details": err.Error(),
+			},
+		})
+		return
+	}
+
+	var customers, services, staff int64
+	h.DB.Model(&models.Customer{}).Where("id = ? AND organization_id = ?", req.CustomerID, orgID).Count(&customers)
+	h.DB.Model(&models.Service{}).Where("id = ? AND organization_id = ?", req.ServiceID, orgID).Count(&services)
+	if req.StaffID != nil && *req.StaffID != "" {
+		h.DB.Model(&models.User{}).Where("id = ? AND organization_id = ?", *req.StaffID, orgID).Count(&staff)
+	} else {
+		req.StaffID, staff = nil, 1
+	}
+	if customers == 0 || services == 0 || staff == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_REFERENCE",
+				"message": "Customer, service and staff member must belong to the organization",
+			},
+		})
+		return
+	}
+
+	earliestDate, earliestErr := time.Parse("2006-01-02", req.EarliestDate)
+	latestDate, latestErr := time.Parse("2006-01-02", req.LatestDate)
+	if earliestErr != nil || latestErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE",
+				"message": "Invalid date format. Use YYYY-MM-DD",
+			},
+		})
+		return
+	}
+	today := dateOnly(time.Now().In(h.organizationLocation(orgID)))
+	if latestDate.Before(earliestDate) || latestDate.Before(today) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_DATE_RANGE",
+				"message": "Latest date must be on or after the earliest date and not in the past",
+			},
+		})
+		return
+	}
+
+	earliestTime, earliestOK := availability.ParseClock(req.EarliestTime)
+	latestTime, latestOK := availability.ParseClock(req.LatestTime)
+	if (req.EarliestTime != "" && !earliestOK) || (req.LatestTime != "" && !latestOK) ||
+		(earliestOK && latestOK && latestTime <= earliestTime) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_TIME",
+				"message": "Times must be HH:MM with the latest time after the earliest",
+			},
+		})
+		return
+	}
+
+	entry := models.WaitlistEntry{
+		OrganizationID: fmt.Sprintf("%v", orgID),
+		CustomerID:     req.CustomerID,
+		ServiceID:      req.ServiceID,
+		StaffID:        req.StaffID,
+		EarliestDate:   earliestDate,
+		LatestDate:     latestDate,
+		EarliestTime:   req.EarliestTime,
+		LatestTime:     req.LatestTime,
+		Status:         "waiting",
+		Notes:          req.Notes,
+		CreatedBy:      c.GetString("user_id"),
+	}
+	if err := h.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to add to waitlist",
+			},
+		})
+		return
+	}
+
+	h.DB.Preload("Customer").Preload("Service").Preload("Staff").First(&entry, "id = ?", entry.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    entry,
+		"message": "Added to waitlist",
+	})
+}
+
+// CancelWaitlistEntry takes a customer off the waitlist, passing any slot
+// they're being offered to the next in line
+func (h *Handler) CancelWaitlistEntry(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	var entry models.WaitlistEntry
+	if err := h.DB.Where("id = ? AND organization_id = ?", c.Param("id"), orgID).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "WAITLIST_ENTRY_NOT_FOUND",
+				"message": "Waitlist entry not found",
+			},
+		})
+		return
+	}
+	if entry.Status == "booked" || entry.Status == "cancelled" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "INVALID_STATUS",
+				"message": fmt.Sprintf("Waitlist entry is already %s", entry.Status),
+			},
+		})
+		return
+	}
+
+	if err := h.DB.Model(&entry).Update("status", "cancelled").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to cancel waitlist entry",
+			},
+		})
+		return
+	}
+
+	var pending []models.WaitlistOffer
+	h.DB.Where("entry_id = ? AND status = ?", entry.ID, "pending").Find(&pending)
+	for i := range pending {
+		h.releaseWaitlistOffer(&pending[i], "withdrawn")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entry,
+		"message": "Removed from waitlist",
+	})
+}
+
+// GetWaitlistOffers lists slots offered to waitlisted customers
+func (h *Handler) GetWaitlistOffers(c *gin.Context) {
+	orgID, exists := c.Get("org_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "UNAUTHORIZED",
+				"message": "Organization not found in context",
+			},
+		})
+		return
+	}
+
+	h.expireWaitlistOffers(orgID)
+
+	query := h.DB.Preload("Entry.Customer").Preload("Entry.Service").Where("organization_id = ?", orgID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if bookingID := c.Query("source_booking_id"); bookingID != "" {
+		query = query.Where("source_booking_id = ?", bookingID)
+	}
+
+	var offers []models.WaitlistOffer
+	if err := query.Order("created_at DESC").Find(&offers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to fetch waitlist offers",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    offers,
+	})
+}
+
+// findWaitlistOffer loads the offer a link was sent for, writing the error
+// response when the token is invalid. Offers stay reachable when online
+// booking is turned off, since staff made them.
+func (h *Handler) findWaitlistOffer(c *gin.Context) (*models.Organization, *models.WaitlistOffer, bool) {
+	notFound := func() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OFFER_NOT_FOUND",
+				"message": "Offer not found",
+			},
+		})
+	}
+
+	offerID, valid := h.idFromSignedToken("waitlist-offer", c.Param("token"))
+	var offer models.WaitlistOffer
+	if !valid || h.DB.Preload("Entry.Customer").Preload("Entry.Service.ResourceRequirements").
+		Where("id = ?", offerID).First(&offer).Error != nil || offer.Entry == nil || offer.Entry.Service == nil {
+		notFound()
+		return nil, nil, false
+	}
+	var org models.Organization
+	if err := h.DB.Where("id = ? AND (slug = ? OR id = ?)", offer.OrganizationID, c.Param("slug"), c.Param("slug")).
+		First(&org).Error; err != nil {
+		notFound()
+		return nil, nil, false
+	}
+
+	if offer.Status == "pending" && !time.Now().Before(offer.ExpiresAt) {
+		h.releaseWaitlistOffer(&offer, "expired")
+	}
+	return &org, &offer, true
+}
+
+// waitlistOfferView is what a customer sees of an offer
+func (h *Handler) waitlistOfferView(org *models.Organization, offer *models.WaitlistOffer) gin.H {
+	return gin.H{
+		"organization": org.Name,
+		"service":      offer.Entry.Service.Name,
+		"price":        offer.Entry.Service.Price,
+		"start_time":   offer.StartTime,
+		"end_time":     offer.EndTime,
+		"timezone":     h.organizationLocation(org.ID).String(),
+		"status":       offer.Status,
+		"expires_at":   offer.ExpiresAt,
+	}
+}
+
+// checkOfferPending writes the error response and returns false once an
+// offer can't be answered
+func checkOfferPending(c *gin.Context, offer *models.WaitlistOffer) bool {
+	if offer.Status == "expired" {
+		c.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OFFER_EXPIRED",
+				"message": "Sorry, this offer has expired",
+			},
+		})
+		return false
+	}
+	if offer.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OFFER_CLOSED",
+				"message": fmt.Sprintf("This offer has already been %s", offer.Status),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// GetPublicWaitlistOffer shows a customer the slot they've been offered
+func (h *Handler) GetPublicWaitlistOffer(c *gin.Context) {
+	org, offer, ok := h.findWaitlistOffer(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.waitlistOfferView(org, offer),
+	})
+}
+
+// AcceptWaitlistOffer books the offered slot for the customer
+func (h *Handler) AcceptWaitlistOffer(c *gin.Context) {
+	org, offer, ok := h.findWaitlistOffer(c)
+	if !ok || !checkOfferPending(c, offer) {
+		return
+	}
+
+	entry := offer.Entry
+	services := []models.Service{*entry.Service}
+	query := availability.Query{
+		Location:      h.organizationLocation(org.ID),
+		Duration:      offer.EndTime.Sub(offer.StartTime),
+		Buffer:        bookingBuffer(org, services),
+		Requirements:  serviceRequirements(services),
+		ExcludeHoldID: offer.ID,
+	}
+	if offer.StaffID != nil {
+		query.StaffIDs = []string{*offer.StaffID}
+	}
+
+	// Check the slot is still free under the schedule lock, so a booking
+	// made at the same moment can't take it before this one is saved
+	tx := h.DB.Begin()
+	err := lockBookingSchedule(tx, org.ID)
+	var slot availability.Slot
+	available := false
+	if err == nil {
+		slot, available, err = availability.Check(tx, org, query, offer.StartTime)
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to check availability",
+			},
+		})
+		return
+	}
+	if !available {
+		tx.Rollback()
+		h.releaseWaitlistOffer(offer, "withdrawn")
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "SLOT_UNAVAILABLE",
+				"message": "Sorry, that time is no longer available",
+			},
+		})
+		return
+	}
+
+	tax, err := defaultTaxRate(tx, org.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to price booking",
+			},
+		})
+		return
+	}
+	steps := stepsForServices(services, offer.StaffID)
+	scheduleBookingSteps(steps, offer.StartTime)
+
+	booking := models.Booking{
+		CustomerID:     entry.CustomerID,
+		OrganizationID: org.ID,
+		StaffID:        offer.StaffID,
+		StartTime:      offer.StartTime,
+		EndTime:        offer.EndTime,
+		Status:         "scheduled",
+		Source:         "waitlist",
+		Notes:          entry.Notes,
+	}
+	priceBooking(steps, nil, tax).applyTo(&booking)
+	h.holdForDeposit(org, &booking, services)
+	now := time.Now()
+	// Only the first acceptance of an offer wins
+	claimed := tx.Model(&models.WaitlistOffer{}).Where("id = ? AND status = ?", offer.ID, "pending").
+		Updates(map[string]interface{}{"status": "accepted", "responded_at": now})
+	if claimed.Error != nil || claimed.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "OFFER_CLOSED",
+				"message": "This offer has already been answered",
+			},
+		})
+		return
+	}
+	err = tx.Create(&booking).Error
+	if err == nil {
+		err = tx.Model(&booking).Association("Services").Append(&services)
+	}
+	if err == nil {
+		err = saveBookingSteps(tx, booking.ID, steps)
+	}
+	if err == nil {
+		err = saveBookingResources(tx, booking.ID, slot.Resources)
+	}
+	if err == nil {
+		err = tx.Model(&models.WaitlistOffer{}).Where("id = ?", offer.ID).Update("booking_id", booking.ID).Error
+	}
+	if err == nil {
+		err = tx.Model(entry).Updates(map[string]interface{}{"status": "booked", "booking_id": booking.ID}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to book the offered slot",
+			},
+		})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    "DATABASE_ERROR",
+				"message": "Failed to book the offered slot",
+			},
+		})
+		return
+	}
+	if booking.Status == "pending_payment" && !h.startDeposit(c, &booking) {
+		return
+	}
+	h.scheduleBookingNotifications(booking.ID)
+
+	h.DB.Preload("Customer").Preload("Services").First(&booking, "id = ?", booking.ID)
+	message := "Booking confirmed"
+	if booking.Status == "pending_payment" {
+		message = "Booking held until the deposit is paid"
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"booking":      h.publicBookingView(org, &booking),
+			"manage_token": h.bookingManageToken(booking.ID),
+		},
+		"message": message,
+	})
+}
+
+// DeclineWaitlistOffer turns an offer down and passes the slot to the next
+// customer. The customer stays on the waitlist for other slots.
+func (h *Handler) DeclineWaitlistOffer(c *gin.Context) {
+	org, offer, ok := h.findWaitlistOffer(c)
+	if !ok || !checkOfferPending(c, offer) {
+		return
+	}
+
+	h.releaseWaitlistOffer(offer, "declined")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.waitlistOfferView(org, offer),
+		"message": "Offer declined",
+	})
+}
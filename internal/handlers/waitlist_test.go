@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitlist(t *testing.T) {
+	handler, router := setupTestHandler()
+	handler.DB.AutoMigrate(&models.OrganizationSettings{}, &models.BusinessBreak{}, &models.BusinessClosure{},
+		&models.Resource{}, &models.ServiceResourceRequirement{}, &models.BookingResource{},
+		&models.WaitlistEntry{}, &models.WaitlistOffer{}, &models.Job{}, &models.BookingNotification{})
+	outbox := &notify.Outbox{}
+	handler.Notifier = outbox
+
+	hours := models.BusinessHours{Timezone: "Australia/Adelaide"}
+	hours.MondayOpen, hours.MondayClose = "09:00", "17:00"
+	hours.TuesdayOpen, hours.TuesdayClose = "09:00", "17:00"
+	hours.WednesdayOpen, hours.WednesdayClose = "09:00", "17:00"
+	hours.ThursdayOpen, hours.ThursdayClose = "09:00", "17:00"
+	hours.FridayOpen, hours.FridayClose = "09:00", "17:00"
+	hours.SaturdayOpen, hours.SaturdayClose = "09:00", "17:00"
+	hours.SundayOpen, hours.SundayClose = "09:00", "17:00"
+	handler.DB.Model(&models.Organization{ID: "test-org"}).Updates(models.Organization{BusinessHours: hours})
+
+	handler.DB.Create(&models.Service{ID: "massage", OrganizationID: "test-org", Name: "Massage", Category: "wellness", Duration: 60, Price: 80, IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "booked-customer", OrganizationID: "test-org", FirstName: "Bea", LastName: "Booked", Email: "bea@example.com", IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "first-waiting", OrganizationID: "test-org", FirstName: "Fay", LastName: "First", Email: "fay@example.com", IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "second-waiting", OrganizationID: "test-org", FirstName: "Sam", LastName: "Second", Phone: "0400222333", IsActive: true})
+	handler.DB.Create(&models.Customer{ID: "afternoon-only", OrganizationID: "test-org", FirstName: "Pat", LastName: "Afternoon", Email: "pat@example.com", IsActive: true})
+
+	loc, _ := time.LoadLocation("Australia/Adelaide")
+	day := time.Now().In(loc).AddDate(0, 0, 3)
+	date := day.Format("2006-01-02")
+	bookAndCancel := func(t *testing.T, hour int) map[string]interface{} {
+		start := time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, loc)
+		w, response := doRequest(handler, router, "POST", "/api/v1/bookings", map[string]interface{}{
+			"customer_id": "booked-customer",
+			"service_ids": []string{"massage"},
+			"start_time":  start,
+			"end_time":    start.Add(time.Hour),
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		id := response["booking"].(map[string]interface{})["id"].(string)
+
+		w, response = doRequest(handler, router, "PATCH", "/api/v1/bookings/"+id+"/status", map[string]interface{}{"status": "cancelled"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		offer, _ := response["waitlist_offer"].(map[string]interface{})
+		return offer
+	}
+	offerToken := func(offer map[string]interface{}) string {
+		return handler.waitlistOfferToken(offer["id"].(string))
+	}
+
+	t.Run("Customers join the waitlist with a date and time window", func(t *testing.T) {
+		for _, entry := range []map[string]interface{}{
+			{"customer_id": "afternoon-only", "earliest_time": "13:00"},
+			{"customer_id": "first-waiting"},
+			{"customer_id": "second-waiting"},
+		} {
+			entry["service_id"], entry["earliest_date"], entry["latest_date"] = "massage", date, date
+			w, _ := doRequest(handler, router, "POST", "/api/v1/waitlist", entry)
+			assert.Equal(t, http.StatusCreated, w.Code)
+		}
+
+		w, response := doRequest(handler, router, "POST", "/api/v1/waitlist", map[string]interface{}{
+			"customer_id": "first-waiting", "service_id": "massage", "earliest_date": date, "latest_date": date,
+			"earliest_time": "15:00", "latest_time": "14:00",
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "INVALID_TIME", response["error"].(map[string]interface{})["code"])
+
+		w, response = doRequest(handler, router, "GET", "/api/v1/waitlist", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, response["data"], 3)
+	})
+
+	var offer map[string]interface{}
+	t.Run("Cancelling a booking offers the slot to the first customer it suits", func(t *testing.T) {
+		offer = bookAndCancel(t, 10)
+		assert.NotNil(t, offer)
+
+		var entry models.WaitlistEntry
+		handler.DB.Where("customer_id = ?", "first-waiting").First(&entry)
+		assert.Equal(t, entry.ID, offer["entry_id"])
+		assert.Equal(t, "offered", entry.Status)
+
+		messages := outbox.Messages()
+		assert.Len(t, messages, 1)
+		assert.Equal(t, notify.Email, messages[0].Channel)
+		assert.Equal(t, "fay@example.com", messages[0].To)
+		assert.True(t, strings.Contains(messages[0].Body, offerToken(offer)))
+	})
+
+	t.Run("The held slot isn't offered to online bookings", func(t *testing.T) {
+		w, response := doRequest(handler, router, "GET", "/api/v1/public/test-org/availability?service_ids=massage&date="+date, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		slots := response["data"].(map[string]interface{})["days"].([]interface{})[0].(map[string]interface{})["slots"].([]interface{})
+		for _, slot := range slots {
+			assert.NotEqual(t, "10:00", slot.(map[string]interface{})["time"])
+		}
+	})
+
+	t.Run("Declining passes the slot to the next customer", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/public/test-org/waitlist-offers/"+offerToken(offer)+"/decline", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "declined", response["data"].(map[string]interface{})["status"])
+
+		var entry models.WaitlistEntry
+		handler.DB.Where("customer_id = ?", "first-waiting").First(&entry)
+		assert.Equal(t, "waiting", entry.Status)
+
+		messages := outbox.Messages()
+		assert.Len(t, messages, 2)
+		assert.Equal(t, notify.SMS, messages[1].Channel)
+		assert.Equal(t, "0400222333", messages[1].To)
+
+		var next models.WaitlistOffer
+		handler.DB.Where("status = ?", "pending").First(&next)
+		offer = map[string]interface{}{"id": next.ID}
+	})
+
+	t.Run("Accepting books the held slot once", func(t *testing.T) {
+		w, response := doRequest(handler, router, "POST", "/api/v1/public/test-org/waitlist-offers/"+offerToken(offer)+"/accept", nil)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "scheduled", data["booking"].(map[string]interface{})["status"])
+		assert.NotEmpty(t, data["manage_token"])
+
+		var booking models.Booking
+		handler.DB.Where("source = ?", "waitlist").First(&booking)
+		assert.Equal(t, "second-waiting", booking.CustomerID)
+		var entry models.WaitlistEntry
+		handler.DB.Where("customer_id = ?", "second-waiting").First(&entry)
+		assert.Equal(t, "booked", entry.Status)
+
+		w, response = doRequest(handler, router, "POST", "/api/v1/public/test-org/waitlist-offers/"+offerToken(offer)+"/accept", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "OFFER_CLOSED", response["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("Offers lapse once the hold runs out", func(t *testing.T) {
+		offer = bookAndCancel(t, 14)
+		var entry models.WaitlistEntry
+		handler.DB.Where("customer_id = ?", "afternoon-only").First(&entry)
+		assert.Equal(t, entry.ID, offer["entry_id"])
+
+		handler.DB.Model(&models.WaitlistOffer{}).Where("id = ?", offer["id"]).Update("expires_at", time.Now().Add(-time.Minute))
+		w, response := doRequest(handler, router, "GET", "/api/v1/public/test-org/waitlist-offers/"+offerToken(offer), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "expired", response["data"].(map[string]interface{})["status"])
+
+		w, response = doRequest(handler, router, "POST", "/api/v1/public/test-org/waitlist-offers/"+offerToken(offer)+"/accept", nil)
+		assert.Equal(t, http.StatusGone, w.Code)
+		assert.Equal(t, "OFFER_EXPIRED", response["error"].(map[string]interface{})["code"])
+
+		// The slot moved on to the next customer still waiting
+		var next models.WaitlistOffer
+		handler.DB.Preload("Entry").Where("status = ?", "pending").First(&next)
+		assert.Equal(t, "first-waiting", next.Entry.CustomerID)
+	})
+}
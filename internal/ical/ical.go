@@ -0,0 +1,119 @@
+// Package ical writes iCalendar (RFC 5545) calendars for subscription feeds
+// and email invites.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Methods for calendars sent as invites (RFC 5546). Feeds have no method.
+const (
+	MethodPublish = "PUBLISH"
+	MethodRequest = "REQUEST"
+	MethodCancel  = "CANCEL"
+)
+
+// Event is a single VEVENT
+type Event struct {
+	UID          string // Stable across changes so calendars update the same event
+	Sequence     int    // Increases each time the event changes
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // CONFIRMED, TENTATIVE or CANCELLED
+	Organizer    string // Email address
+	Attendee     string // Email address
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR holding events
+type Calendar struct {
+	Name    string // Shown by calendar apps for subscribed feeds
+	Method  string
+	Refresh time.Duration // How often subscribers should refetch a feed
+	Events  []Event
+}
+
+const dateTimeFormat = "20060102T150405Z"
+
+// String renders the calendar with CRLF line endings and long lines folded
+func (c *Calendar) String() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//DAS CRM//Bookings//EN")
+	line("CALSCALE", "GREGORIAN")
+	if c.Method != "" {
+		line("METHOD", c.Method)
+	}
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		line("REFRESH-INTERVAL;VALUE=DURATION", fmt.Sprintf("PT%dM", int(c.Refresh/time.Minute)))
+		line("X-PUBLISHED-TTL", fmt.Sprintf("PT%dM", int(c.Refresh/time.Minute)))
+	}
+
+	now := time.Now().UTC().Format(dateTimeFormat)
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("SEQUENCE", fmt.Sprint(e.Sequence))
+		line("DTSTAMP", now)
+		line("DTSTART", e.Start.UTC().Format(dateTimeFormat))
+		line("DTEND", e.End.UTC().Format(dateTimeFormat))
+		if !e.LastModified.IsZero() {
+			line("LAST-MODIFIED", e.LastModified.UTC().Format(dateTimeFormat))
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		if e.Organizer != "" {
+			line("ORGANIZER", "mailto:"+e.Organizer)
+		}
+		if e.Attendee != "" {
+			line("ATTENDEE;ROLE=REQ-PARTICIPANT;RSVP=FALSE", "mailto:"+e.Attendee)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// escape escapes text values
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+}
+
+// writeFolded writes a content line, folding it at 75 octets without
+// splitting a UTF-8 character
+func writeFolded(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // The leading space counts towards the next line
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
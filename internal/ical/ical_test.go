@@ -0,0 +1,55 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarString(t *testing.T) {
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.FixedZone("ACDT", 10*3600+1800))
+	cal := Calendar{
+		Method: MethodCancel,
+		Events: []Event{{
+			UID:         "booking-1@example.com",
+			Sequence:    2,
+			Start:       start,
+			End:         start.Add(time.Hour),
+			Summary:     "Haircut, Colour; with Sam",
+			Description: "Line one\nLine two",
+			Status:      "CANCELLED",
+			Attendee:    "gina@example.com",
+		}},
+	}
+	out := cal.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, out, "METHOD:CANCEL\r\n")
+	assert.Contains(t, out, "UID:booking-1@example.com\r\n")
+	assert.Contains(t, out, "SEQUENCE:2\r\n")
+	assert.Contains(t, out, "DTSTART:20261018T233000Z\r\n")
+	assert.Contains(t, out, "DTEND:20261019T003000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Haircut\, Colour\; with Sam`+"\r\n")
+	assert.Contains(t, out, `DESCRIPTION:Line one\nLine two`+"\r\n")
+	assert.Contains(t, out, "STATUS:CANCELLED\r\n")
+	assert.NotContains(t, out, "ORGANIZER")
+}
+
+func TestLongLinesAreFolded(t *testing.T) {
+	var b strings.Builder
+	writeFolded(&b, "DESCRIPTION:"+strings.Repeat("é", 60))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	unfolded := strings.ReplaceAll(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ", "")
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("é", 60), unfolded)
+}
@@ -0,0 +1,226 @@
+// Package jobs runs background work from a queue kept in the database. Jobs
+// survive restarts, are retried with backoff when they fail and are safe to
+// process from several app instances at once: each instance claims due jobs
+// with SELECT ... FOR UPDATE SKIP LOCKED so no job runs twice.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Func runs a job. Returning an error retries the job later.
+type Func func(ctx context.Context, job *models.Job) error
+
+// Enqueue adds a job to run at runAt. Pass a transaction as db to queue the
+// job only if the surrounding work commits. A non-empty key makes the call a
+// no-op when a job with the same key was already queued; the returned job
+// then has no ID.
+func Enqueue(db *gorm.DB, jobType string, payload interface{}, runAt time.Time, key string) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := models.Job{Type: jobType, Payload: string(data), Status: "pending", RunAt: runAt, MaxAttempts: 5}
+	if key == "" {
+		return &job, db.Create(&job).Error
+	}
+
+	job.Key = &key
+	result := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "key"}}, DoNothing: true}).Create(&job)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		job.ID = ""
+	}
+	return &job, nil
+}
+
+// Decode unmarshals a job's payload
+func Decode(job *models.Job, v interface{}) error {
+	return json.Unmarshal([]byte(job.Payload), v)
+}
+
+type recurring struct {
+	name     string
+	interval time.Duration
+}
+
+// Queue claims due jobs and runs them with the function registered for their type
+type Queue struct {
+	DB           *gorm.DB
+	WorkerID     string        // Identifies this instance in locked_by
+	PollInterval time.Duration // How often to look for due jobs
+	BatchSize    int           // Most jobs claimed at once
+	LockTimeout  time.Duration // When a running job is assumed lost with its instance and run again
+
+	mu        sync.RWMutex
+	funcs     map[string]Func
+	recurring []recurring
+}
+
+// New creates a queue that polls every few seconds
+func New(db *gorm.DB) *Queue {
+	host, _ := os.Hostname()
+	return &Queue{
+		DB:           db,
+		WorkerID:     fmt.Sprintf("%s-%s", host, uuid.New().String()[:8]),
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		LockTimeout:  10 * time.Minute,
+		funcs:        make(map[string]Func),
+	}
+}
+
+// Handle registers the function that runs jobs of a type
+func (q *Queue) Handle(jobType string, fn Func) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.funcs[jobType] = fn
+}
+
+// Every queues a job of the given type once per interval. Instances agree on
+// the slot each run belongs to, so the job runs once per interval however
+// many instances are polling.
+func (q *Queue) Every(jobType string, interval time.Duration, fn Func) {
+	q.Handle(jobType, fn)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.recurring = append(q.recurring, recurring{name: jobType, interval: interval})
+}
+
+// Start polls for due jobs until ctx is cancelled
+func (q *Queue) Start(ctx context.Context) {
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := q.RunDue(ctx); err != nil {
+			log.Printf("jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue queues any recurring jobs that are due, then claims and runs due
+// jobs until none are left. It returns how many jobs it ran.
+func (q *Queue) RunDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	q.mu.RLock()
+	for _, r := range q.recurring {
+		slot := now.Truncate(r.interval)
+		if _, err := Enqueue(q.DB, r.name, nil, slot, fmt.Sprintf("%s@%d", r.name, slot.Unix())); err != nil {
+			q.mu.RUnlock()
+			return 0, err
+		}
+	}
+	q.mu.RUnlock()
+
+	ran := 0
+	for ctx.Err() == nil {
+		claimed, err := q.claim()
+		if err != nil {
+			return ran, err
+		}
+		if len(claimed) == 0 {
+			break
+		}
+		for i := range claimed {
+			q.run(ctx, &claimed[i])
+			ran++
+		}
+	}
+	return ran, nil
+}
+
+// claim locks a batch of due jobs to this instance
+func (q *Queue) claim() ([]models.Job, error) {
+	now := time.Now()
+	var claimed []models.Job
+	err := q.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)", "pending", now, "running", now.Add(-q.LockTimeout)).
+			Order("run_at ASC").Limit(q.BatchSize).Find(&claimed).Error
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+			claimed[i].Status, claimed[i].LockedBy, claimed[i].LockedAt = "running", q.WorkerID, &now
+			claimed[i].Attempts++
+		}
+		return tx.Model(&models.Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":    "running",
+			"locked_by": q.WorkerID,
+			"locked_at": now,
+			"attempts":  gorm.Expr("attempts + 1"),
+		}).Error
+	})
+	return claimed, err
+}
+
+// run runs a claimed job and records how it went
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	q.mu.RLock()
+	fn, ok := q.funcs[job.Type]
+	q.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler for job type %s", job.Type)
+		job.Attempts = job.MaxAttempts
+	} else {
+		err = safely(ctx, fn, job)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"locked_by": "", "locked_at": nil}
+	switch {
+	case err == nil:
+		updates["status"], updates["finished_at"], updates["last_error"] = "done", now, ""
+	case job.Attempts >= job.MaxAttempts:
+		updates["status"], updates["finished_at"], updates["last_error"] = "failed", now, err.Error()
+		log.Printf("jobs: %s job %s failed for good: %v", job.Type, job.ID, err)
+	default:
+		updates["status"], updates["run_at"], updates["last_error"] = "pending", now.Add(Backoff(job.Attempts)), err.Error()
+	}
+	if dbErr := q.DB.Model(&models.Job{}).Where("id = ? AND locked_by = ?", job.ID, q.WorkerID).Updates(updates).Error; dbErr != nil {
+		log.Printf("jobs: failed to record result of job %s: %v", job.ID, dbErr)
+	}
+}
+
+// safely runs fn, turning a panic into an error so one bad job can't stop the queue
+func safely(ctx context.Context, fn Func, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, job)
+}
+
+// Backoff is how long to wait before retrying a job that has failed attempts times
+func Backoff(attempts int) time.Duration {
+	return time.Duration(attempts*attempts) * time.Minute
+}
+
+// Prune deletes jobs that finished before the given time
+func Prune(db *gorm.DB, before time.Time) error {
+	return db.Where("status IN ? AND finished_at < ?", []string{"done", "failed"}, before).Delete(&models.Job{}).Error
+}
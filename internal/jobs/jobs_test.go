@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupQueue(t *testing.T) *Queue {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AutoMigrate(&models.Job{})
+	return New(db)
+}
+
+func TestRunDue(t *testing.T) {
+	queue := setupQueue(t)
+	ctx := context.Background()
+
+	var got []string
+	queue.Handle("greet", func(ctx context.Context, job *models.Job) error {
+		var payload struct{ Name string }
+		Decode(job, &payload)
+		got = append(got, payload.Name)
+		return nil
+	})
+
+	Enqueue(queue.DB, "greet", map[string]string{"Name": "now"}, time.Now(), "")
+	Enqueue(queue.DB, "greet", map[string]string{"Name": "later"}, time.Now().Add(time.Hour), "")
+	first, _ := Enqueue(queue.DB, "greet", map[string]string{"Name": "once"}, time.Now(), "greet-once")
+	again, _ := Enqueue(queue.DB, "greet", map[string]string{"Name": "once"}, time.Now(), "greet-once")
+	assert.NotEmpty(t, first.ID)
+	assert.Empty(t, again.ID)
+
+	ran, err := queue.RunDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ran)
+	assert.ElementsMatch(t, []string{"now", "once"}, got)
+
+	var done int64
+	queue.DB.Model(&models.Job{}).Where("status = ?", "done").Count(&done)
+	assert.Equal(t, int64(2), done)
+
+	ran, _ = queue.RunDue(ctx)
+	assert.Equal(t, 0, ran)
+}
+
+func TestFailedJobsRetryWithBackoff(t *testing.T) {
+	queue := setupQueue(t)
+	ctx := context.Background()
+
+	queue.Handle("flaky", func(ctx context.Context, job *models.Job) error {
+		return errors.New("provider unavailable")
+	})
+	job, _ := Enqueue(queue.DB, "flaky", nil, time.Now(), "")
+	queue.DB.Model(job).Update("max_attempts", 2)
+
+	queue.RunDue(ctx)
+	queue.DB.First(job, "id = ?", job.ID)
+	assert.Equal(t, "pending", job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, "provider unavailable", job.LastError)
+	assert.True(t, job.RunAt.After(time.Now().Add(Backoff(1)-time.Second)))
+
+	queue.DB.Model(job).Update("run_at", time.Now())
+	queue.RunDue(ctx)
+	queue.DB.First(job, "id = ?", job.ID)
+	assert.Equal(t, "failed", job.Status)
+	assert.Equal(t, 2, job.Attempts)
+}
+
+func TestStaleJobsAreReclaimed(t *testing.T) {
+	queue := setupQueue(t)
+	ran := 0
+	queue.Handle("report", func(ctx context.Context, job *models.Job) error {
+		ran++
+		return nil
+	})
+
+	job, _ := Enqueue(queue.DB, "report", nil, time.Now(), "")
+	queue.DB.Model(job).Updates(map[string]interface{}{"status": "running", "locked_by": "gone", "locked_at": time.Now()})
+	queue.RunDue(context.Background())
+	assert.Equal(t, 0, ran)
+
+	queue.DB.Model(job).Update("locked_at", time.Now().Add(-queue.LockTimeout-time.Minute))
+	queue.RunDue(context.Background())
+	assert.Equal(t, 1, ran)
+}
+
+func TestEveryRunsOncePerInterval(t *testing.T) {
+	queue := setupQueue(t)
+	other := New(queue.DB)
+	ran := 0
+	tick := func(ctx context.Context, job *models.Job) error {
+		ran++
+		return nil
+	}
+	queue.Every("tick", time.Hour, tick)
+	other.Every("tick", time.Hour, tick)
+
+	queue.RunDue(context.Background())
+	other.RunDue(context.Background())
+	queue.RunDue(context.Background())
+	assert.Equal(t, 1, ran)
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BusinessBreak is a recurring gap in an organization's business hours, such
+// as a lunch break, which splits the day into separate booking windows
+type BusinessBreak struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	Weekday        *int           `json:"weekday,omitempty"`                          // 0 = Sunday ... 6 = Saturday; blank for every day
+	StartTime      string         `json:"start_time" gorm:"type:varchar(5);not null"` // Local time, HH:MM
+	EndTime        string         `json:"end_time" gorm:"type:varchar(5);not null"`   // Local time, HH:MM
+	Label          string         `json:"label" gorm:"type:varchar(100)"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BusinessClosure closes an organization for bookings on a run of dates, such
+// as a Christmas shutdown
+type BusinessClosure struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	StartDate      time.Time      `json:"start_date" gorm:"not null;index"` // First closed date
+	EndDate        time.Time      `json:"end_date" gorm:"not null;index"`   // Last closed date
+	Reason         string         `json:"reason" gorm:"type:varchar(255)"`
+	CreatedBy      string         `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (b *BusinessBreak) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+func (b *BusinessClosure) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
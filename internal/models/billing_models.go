@@ -0,0 +1,152 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ParticipantInvoice represents an NDIS invoice issued against a participant's plan
+type ParticipantInvoice struct {
+	ID               string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID   string         `json:"organization_id" gorm:"type:varchar(255);not null;index;uniqueIndex:idx_participant_invoices_org_number"`
+	ParticipantID    string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	InvoiceNumber    string         `json:"invoice_number" gorm:"type:varchar(50);not null;uniqueIndex:idx_participant_invoices_org_number"`
+	Amount           float64        `json:"amount" gorm:"type:decimal(12,2);not null"`
+	PaidAmount       float64        `json:"paid_amount" gorm:"type:decimal(12,2);default:0"`
+	Status           string         `json:"status" gorm:"type:varchar(20);default:'draft';index"` // draft, sent, paid, overdue, cancelled
+	IssueDate        time.Time      `json:"issue_date" gorm:"not null"`
+	DueDate          time.Time      `json:"due_date" gorm:"not null;index"`
+	SentAt           *time.Time     `json:"sent_at,omitempty"`
+	PaidDate         *time.Time     `json:"paid_date,omitempty"`
+	CancelledAt      *time.Time     `json:"cancelled_at,omitempty"`
+	PaymentMethod    string         `json:"payment_method" gorm:"type:varchar(50)"`
+	PaymentReference string         `json:"payment_reference" gorm:"type:varchar(255)"`
+	Description      string         `json:"description" gorm:"type:text"`
+	CreatedBy        string         `json:"created_by" gorm:"type:varchar(255);not null"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Participant Participant              `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Lines       []ParticipantInvoiceLine `json:"lines,omitempty" gorm:"foreignKey:InvoiceID"`
+}
+
+// ParticipantInvoiceLine locks a single completed shift to an invoice and
+// snapshots the hours and rate that were billed for it
+type ParticipantInvoiceLine struct {
+	ID          string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	InvoiceID   string    `json:"invoice_id" gorm:"type:varchar(255);not null;index"`
+	ShiftID     string    `json:"shift_id" gorm:"type:varchar(255);not null;index"`
+	ServiceDate time.Time `json:"service_date" gorm:"not null"`
+	ServiceType string    `json:"service_type" gorm:"type:varchar(100);not null"`
+	Hours       float64   `json:"hours" gorm:"type:decimal(8,2);not null"`
+	HourlyRate  float64   `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
+	Amount      float64   `json:"amount" gorm:"type:decimal(12,2);not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Shift Shift `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+}
+
+// NumberSequence hands out an organization's sequential document numbers,
+// such as invoice numbers. The row is locked while a number is taken so
+// concurrent requests are never given the same one.
+type NumberSequence struct {
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(255);primaryKey"`
+	Name           string    `json:"name" gorm:"type:varchar(50);primaryKey"`
+	LastValue      int64     `json:"last_value" gorm:"not null;default:0"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// IsOutstanding reports whether the invoice has been issued but not yet settled
+func (i *ParticipantInvoice) IsOutstanding() bool {
+	return i.Status == "sent" || i.Status == "overdue"
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (i *ParticipantInvoice) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *ParticipantInvoiceLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
+
+// NDISClaimBatch groups claim lines exported together as one NDIS bulk payment request file
+type NDISClaimBatch struct {
+	ID                   string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID       string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	BatchNumber          string         `json:"batch_number" gorm:"type:varchar(50);uniqueIndex"`
+	RegistrationNumber   string         `json:"registration_number" gorm:"type:varchar(50);not null"`
+	PeriodStart          time.Time      `json:"period_start" gorm:"not null"`
+	PeriodEnd            time.Time      `json:"period_end" gorm:"not null"`
+	Status               string         `json:"status" gorm:"type:varchar(20);default:'generated';index"` // generated, submitted, reconciled
+	TotalAmount          float64        `json:"total_amount" gorm:"type:decimal(12,2);default:0"`
+	PaidAmount           float64        `json:"paid_amount" gorm:"type:decimal(12,2);default:0"`
+	LineCount            int            `json:"line_count" gorm:"default:0"`
+	RemittanceImportedAt *time.Time     `json:"remittance_imported_at,omitempty"`
+	CreatedBy            string         `json:"created_by" gorm:"type:varchar(255);not null"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Lines []NDISClaimLine `json:"lines,omitempty" gorm:"foreignKey:BatchID"`
+}
+
+// NDISClaimLine is a single bulk payment request row claiming one shift
+type NDISClaimLine struct {
+	ID                   string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	BatchID              string     `json:"batch_id" gorm:"type:varchar(255);not null;index"`
+	ShiftID              string     `json:"shift_id" gorm:"type:varchar(255);not null;index"`
+	ParticipantID        string     `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	NDISNumber           string     `json:"ndis_number" gorm:"type:varchar(10);not null"`
+	ClaimReference       string     `json:"claim_reference" gorm:"type:varchar(50);uniqueIndex"`
+	SupportItemNumber    string     `json:"support_item_number" gorm:"type:varchar(50);not null"`
+	ClaimType            string     `json:"claim_type" gorm:"type:varchar(10)"` // blank for standard, CANC, REPW, TRAN, NF2F
+	CancellationReason   string     `json:"cancellation_reason" gorm:"type:varchar(10)"`
+	SupportsFrom         time.Time  `json:"supports_from" gorm:"not null"`
+	SupportsTo           time.Time  `json:"supports_to" gorm:"not null"`
+	Quantity             float64    `json:"quantity" gorm:"type:decimal(10,2);not null"`
+	UnitPrice            float64    `json:"unit_price" gorm:"type:decimal(10,2);not null"`
+	GSTCode              string     `json:"gst_code" gorm:"type:varchar(5);default:'P2'"` // P1 taxable, P2 GST free, P5 out of scope
+	Amount               float64    `json:"amount" gorm:"type:decimal(12,2);not null"`
+	Status               string     `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, paid, rejected, superseded (rejected and claimed again on a later batch)
+	PaidAmount           float64    `json:"paid_amount" gorm:"type:decimal(12,2);default:0"`
+	PaymentRequestNumber string     `json:"payment_request_number" gorm:"type:varchar(50)"`
+	RejectionReason      string     `json:"rejection_reason" gorm:"type:text"`
+	ProcessedAt          *time.Time `json:"processed_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+
+	// Relationships
+	Shift       Shift       `json:"shift,omitempty" gorm:"foreignKey:ShiftID"`
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+}
+
+func (b *NDISClaimBatch) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	if b.BatchNumber == "" {
+		b.BatchNumber = "BPR-" + time.Now().Format("20060102") + "-" + uuid.New().String()[:6]
+	}
+	return
+}
+
+func (l *NDISClaimLine) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CalendarFeed is a user's private iCalendar subscription. Anyone with the
+// token can read the feed, so it can be reset to cut off old links.
+type CalendarFeed struct {
+	ID             string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	UserID         string     `json:"user_id" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Token          string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	LastFetchedAt  *time.Time `json:"last_fetched_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == "" {
+		f.ID = uuid.New().String()
+	}
+	return
+}
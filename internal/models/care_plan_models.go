@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CarePlanGoal is a goal in one version of a care plan. GoalKey stays the same
+// when the goal is carried into later versions so progress and changes can be
+// followed across plan reviews.
+type CarePlanGoal struct {
+	ID               string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	CarePlanID       string         `json:"care_plan_id" gorm:"type:varchar(255);not null;index"`
+	GoalKey          string         `json:"goal_key" gorm:"type:varchar(255);not null;index"`
+	Title            string         `json:"title" gorm:"type:varchar(255);not null"`
+	OutcomeDomain    string         `json:"outcome_domain" gorm:"type:varchar(50);index"` // daily_living, home, health_wellbeing, lifelong_learning, work, social_community, relationships, choice_control
+	Description      string         `json:"description" gorm:"type:text"`
+	MeasurableTarget string         `json:"measurable_target" gorm:"type:text"` // What achieving the goal looks like, e.g. "Catches the bus to day program unassisted 3 times a week"
+	ReviewDate       *time.Time     `json:"review_date,omitempty" gorm:"index"`
+	Status           string         `json:"status" gorm:"type:varchar(20);default:'active'"` // active, achieved, discontinued
+	SortOrder        int            `json:"sort_order"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// BeforeCreate hook for generating UUIDs
+func (g *CarePlanGoal) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	if g.GoalKey == "" {
+		g.GoalKey = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShiftClockEvent is the evidence captured when a staff member clocks in to or
+// out of a shift. Events are never edited so they can be produced for audits.
+type ShiftClockEvent struct {
+	ID              string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	ShiftID         string     `json:"shift_id" gorm:"type:varchar(255);not null;index"`
+	StaffID         string     `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	EventType       string     `json:"event_type" gorm:"type:varchar(20);not null"` // clock_in, clock_out
+	RecordedAt      time.Time  `json:"recorded_at" gorm:"not null;index"`           // Server time the event was received
+	DeviceTime      *time.Time `json:"device_time,omitempty"`                       // Time reported by the device, kept for offline capture
+	Latitude        *float64   `json:"latitude,omitempty"`
+	Longitude       *float64   `json:"longitude,omitempty"`
+	AccuracyMeters  *float64   `json:"accuracy_meters,omitempty"`
+	DistanceMeters  *float64   `json:"distance_meters,omitempty"` // From the participant's address; blank when either location is unknown
+	GeofenceRadius  int        `json:"geofence_radius"`
+	WithinGeofence  *bool      `json:"within_geofence,omitempty"`
+	VarianceMinutes int        `json:"variance_minutes"` // Actual minus scheduled time; positive means late
+	PhotoDocumentID *string    `json:"photo_document_id,omitempty" gorm:"type:varchar(255)"`
+	ExceptionReason string     `json:"exception_reason" gorm:"type:text"` // Staff explanation when the event is outside the geofence
+	DeviceInfo      string     `json:"device_info" gorm:"type:varchar(255)"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	// Relationships
+	Staff User `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// BeforeCreate hook for generating UUIDs
+func (e *ShiftClockEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Incident is an incident report raised by staff. Reportable incidents carry
+// the NDIS Commission notification deadlines.
+type Incident struct {
+	ID                    string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID        string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	IncidentNumber        string         `json:"incident_number" gorm:"type:varchar(50);not null;index"`
+	ParticipantID         *string        `json:"participant_id,omitempty" gorm:"type:varchar(255);index"`
+	ShiftID               *string        `json:"shift_id,omitempty" gorm:"type:varchar(255);index"`
+	ReportedBy            string         `json:"reported_by" gorm:"type:varchar(255);not null"`
+	OccurredAt            time.Time      `json:"occurred_at" gorm:"not null;index"`
+	AwareAt               time.Time      `json:"aware_at" gorm:"not null"` // When the provider became aware; deadlines run from here
+	Location              string         `json:"location" gorm:"type:varchar(255)"`
+	Category              string         `json:"category" gorm:"type:varchar(50);not null;index"` // death, serious_injury, abuse_neglect, unlawful_contact, sexual_misconduct, restrictive_practice, injury, illness, property_damage, behaviour, medication_error, other
+	Severity              string         `json:"severity" gorm:"type:varchar(20);not null;index"` // low, medium, high, critical
+	IsReportable          bool           `json:"is_reportable" gorm:"index"`
+	Description           string         `json:"description" gorm:"type:text;not null"`
+	ImmediateActions      string         `json:"immediate_actions" gorm:"type:text"`
+	Witnesses             string         `json:"witnesses" gorm:"type:text"`
+	Status                string         `json:"status" gorm:"type:varchar(30);default:'open';index"` // open, under_investigation, closed
+	NotificationDueAt     *time.Time     `json:"notification_due_at,omitempty" gorm:"index"`          // Initial notification to the NDIS Commission
+	NotifiedAt            *time.Time     `json:"notified_at,omitempty"`
+	NotificationReference string         `json:"notification_reference" gorm:"type:varchar(100)"`
+	FinalReportDueAt      *time.Time     `json:"final_report_due_at,omitempty" gorm:"index"` // Five business day report
+	FinalReportAt         *time.Time     `json:"final_report_at,omitempty"`
+	Outcome               string         `json:"outcome" gorm:"type:text"`
+	ClosedAt              *time.Time     `json:"closed_at,omitempty"`
+	ClosedBy              *string        `json:"closed_by,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	DeletedAt             gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Participant *Participant                `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Reporter    User                        `json:"reporter,omitempty" gorm:"foreignKey:ReportedBy"`
+	Steps       []IncidentInvestigationStep `json:"steps,omitempty" gorm:"foreignKey:IncidentID"`
+	Documents   []Document                  `json:"documents,omitempty" gorm:"foreignKey:IncidentID"`
+}
+
+// IncidentInvestigationStep is a follow-up action assigned while investigating an incident
+type IncidentInvestigationStep struct {
+	ID          string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	IncidentID  string     `json:"incident_id" gorm:"type:varchar(255);not null;index"`
+	Description string     `json:"description" gorm:"type:text;not null"`
+	AssignedTo  *string    `json:"assigned_to,omitempty" gorm:"type:varchar(255);index"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CompletedBy *string    `json:"completed_by,omitempty" gorm:"type:varchar(255)"`
+	Findings    string     `json:"findings" gorm:"type:text"`
+	CreatedBy   string     `json:"created_by" gorm:"type:varchar(255);not null"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (i *Incident) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+func (s *IncidentInvestigationStep) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Job is a unit of background work persisted so it survives restarts and is
+// picked up by exactly one app instance
+type Job struct {
+	ID          string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	Type        string     `json:"type" gorm:"type:varchar(100);not null;index"`
+	Payload     string     `json:"payload" gorm:"type:text"`                               // JSON
+	Key         *string    `json:"key,omitempty" gorm:"type:varchar(255);uniqueIndex"`     // Stops the same job being queued twice
+	Status      string     `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, running, done, failed
+	RunAt       time.Time  `json:"run_at" gorm:"not null;index"`
+	Attempts    int        `json:"attempts" gorm:"default:0"`
+	MaxAttempts int        `json:"max_attempts" gorm:"default:5"`
+	LastError   string     `json:"last_error,omitempty" gorm:"type:text"`
+	LockedBy    string     `json:"locked_by,omitempty" gorm:"type:varchar(255)"` // Instance running the job
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BookingNotification records a confirmation or reminder sent to a customer
+// about a booking, and whether it was delivered
+type BookingNotification struct {
+	ID             string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	BookingID      string     `json:"booking_id" gorm:"type:varchar(255);not null;index"`
+	Kind           string     `json:"kind" gorm:"type:varchar(20);not null"`    // confirmation, reminder, rescheduled, cancellation
+	Channel        string     `json:"channel" gorm:"type:varchar(20);not null"` // email, sms, whatsapp
+	Recipient      string     `json:"recipient" gorm:"type:varchar(255);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'scheduled';index"` // scheduled, sent, failed, skipped
+	ScheduledFor   time.Time  `json:"scheduled_for" gorm:"not null"`
+	BookingStart   time.Time  `json:"booking_start"` // Start time of the booking the message told the customer about
+	Subject        string     `json:"subject,omitempty" gorm:"type:varchar(255)"`
+	Body           string     `json:"body,omitempty" gorm:"type:text"`
+	Attempts       int        `json:"attempts" gorm:"default:0"`
+	Error          string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	JobID          string     `json:"job_id" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (j *Job) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	return
+}
+
+func (n *BookingNotification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return
+}
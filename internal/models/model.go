@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,6 +12,7 @@ import (
 type Organization struct {
 	ID          string         `json:"id" gorm:"type:varchar(255);primaryKey"`
 	Name        string         `json:"name" gorm:"type:varchar(255);not null"`
+	Slug        string         `json:"slug" gorm:"type:varchar(100);uniqueIndex:idx_organizations_unique_slug"` // Identifies the organization on its public booking pages
 	BusinessType string        `json:"business_type" gorm:"type:varchar(100);not null;default:'general';index"` // garage, salon, retail, manufacturing, etc.
 	ABN         string         `json:"abn" gorm:"type:varchar(11);unique"`
 	Phone       string         `json:"phone" gorm:"type:varchar(20)"`
@@ -20,6 +22,7 @@ type Organization struct {
 	Address     Address        `json:"address" gorm:"embedded;embeddedPrefix:address_"`
 	BusinessHours BusinessHours `json:"business_hours" gorm:"embedded;embeddedPrefix:hours_"`
 	BookingSettings BookingSettings `json:"booking_settings" gorm:"embedded;embeddedPrefix:booking_"`
+	NDISReg NDISReg `json:"ndis_registration" gorm:"embedded;embeddedPrefix:ndis_"` // TMPFIX
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -40,6 +43,7 @@ type User struct {
 	FirstName      string         `json:"first_name" gorm:"type:varchar(100);not null"`
 	LastName       string         `json:"last_name" gorm:"type:varchar(100);not null"`
 	Phone          string         `json:"phone" gorm:"type:varchar(20)"`
+	Gender         string         `json:"gender" gorm:"type:varchar(20)"`              // female, male, non_binary; used to honour participant preferences
 	Role           string         `json:"role" gorm:"type:varchar(50);not null;index"` // admin, manager, care_worker, support_coordinator
 	RoleID         *string        `json:"role_id,omitempty" gorm:"type:varchar(255);index"` // New role-based system
 	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
@@ -88,6 +92,7 @@ type Vehicle struct {
 	Model          string         `json:"model" gorm:"type:varchar(50);not null"`
 	Year           int            `json:"year" gorm:"not null"`
 	LicensePlate   string         `json:"license_plate" gorm:"type:varchar(20);index"`
+	RegistrationState string      `json:"registration_state" gorm:"type:varchar(10)"` // State or territory the plate is registered in
 	VIN            string         `json:"vin" gorm:"type:varchar(50);unique"`
 	Color          string         `json:"color" gorm:"type:varchar(30)"`
 	Mileage        int            `json:"mileage" gorm:"default:0"`
@@ -114,6 +119,10 @@ type Service struct {
 	Price          float64        `json:"price" gorm:"type:decimal(10,2);not null"`
 	IsActive       bool           `json:"is_active" gorm:"default:true;index"`
 	RequiresVehicle bool          `json:"requires_vehicle" gorm:"default:false"` // For garage services
+	BufferMinutes  int            `json:"buffer_minutes"` // Gap kept free after the service; 0 uses the organization's buffer time
+	DepositType    string         `json:"deposit_type" gorm:"type:varchar(20)"`          // fixed, percentage; blank or none for no deposit
+	DepositValue   float64        `json:"deposit_value" gorm:"type:decimal(10,2);default:0"` // Amount, or percent of the price, paid when booking online
+	NoShowFee      float64        `json:"no_show_fee" gorm:"type:decimal(10,2);default:0"`    // Charged to the customer's saved card when they don't turn up
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
@@ -121,6 +130,7 @@ type Service struct {
 	// Relationships
 	Organization Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
 	Bookings     []Booking    `json:"bookings,omitempty" gorm:"many2many:booking_services"`
+	ResourceRequirements []ServiceResourceRequirement `json:"resource_requirements,omitempty" gorm:"foreignKey:ServiceID"`
 }
 
 // Booking represents appointments/bookings
@@ -132,8 +142,17 @@ type Booking struct {
 	StaffID        *string        `json:"staff_id,omitempty" gorm:"type:varchar(255);index"`
 	StartTime      time.Time      `json:"start_time" gorm:"not null;index"`
 	EndTime        time.Time      `json:"end_time" gorm:"not null;index"`
-	Status         string         `json:"status" gorm:"type:varchar(50);default:'scheduled';index"` // scheduled, confirmed, in_progress, completed, cancelled, no_show
+	Status         string         `json:"status" gorm:"type:varchar(50);default:'scheduled';index"` // pending_payment, pending_approval, scheduled, confirmed, in_progress, completed, cancelled, no_show
+	Source         string         `json:"source" gorm:"type:varchar(20);default:'staff'"` // staff, online, waitlist
+	SubTotal       float64        `json:"sub_total" gorm:"type:decimal(10,2);default:0"`       // Service prices before discount and tax
+	DiscountID     *string        `json:"discount_id,omitempty" gorm:"type:varchar(255);index"` // POS discount applied to the booking
+	DiscountAmount float64        `json:"discount_amount" gorm:"type:decimal(10,2);default:0"`
+	TaxRate        float64        `json:"tax_rate" gorm:"type:decimal(5,2);default:0"` // Percentage charged, e.g. 10.00
+	TaxAmount      float64        `json:"tax_amount" gorm:"type:decimal(10,2);default:0"`
 	TotalPrice     float64        `json:"total_price" gorm:"type:decimal(10,2);default:0"`
+	DepositAmount  float64        `json:"deposit_amount" gorm:"type:decimal(10,2);default:0"`
+	DepositStatus  string         `json:"deposit_status,omitempty" gorm:"type:varchar(20)"` // pending, paid, refunding, refunded, forfeited, expired, waived; blank when no deposit is due
+	HoldExpiresAt  *time.Time     `json:"hold_expires_at,omitempty" gorm:"index"`           // A pending_payment booking is released if the deposit isn't paid by then
 	Notes          string         `json:"notes" gorm:"type:text"`
 	InternalNotes  string         `json:"internal_notes" gorm:"type:text"`
 	CreatedAt      time.Time      `json:"created_at"`
@@ -146,6 +165,28 @@ type Booking struct {
 	Vehicle      *Vehicle      `json:"vehicle,omitempty" gorm:"foreignKey:VehicleID"`
 	Staff        *User         `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
 	Services     []Service     `json:"services,omitempty" gorm:"many2many:booking_services"`
+	Resources    []BookingResource `json:"resources,omitempty" gorm:"foreignKey:BookingID"`
+	Steps        []BookingStep `json:"steps,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// BookingStep is one service within a booking. Steps run one after another in
+// position order, except that a step marked WithPrevious runs alongside the
+// step before it, usually with a different staff member.
+type BookingStep struct {
+	ID           string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	BookingID    string    `json:"booking_id" gorm:"type:varchar(255);not null;index"`
+	ServiceID    string    `json:"service_id" gorm:"type:varchar(255);not null;index"`
+	StaffID      *string   `json:"staff_id,omitempty" gorm:"type:varchar(255);index"`
+	Position     int       `json:"position" gorm:"not null"`
+	WithPrevious bool      `json:"with_previous" gorm:"default:false"`
+	StartTime    time.Time `json:"start_time" gorm:"not null;index"`
+	EndTime      time.Time `json:"end_time" gorm:"not null;index"`
+	Price        float64   `json:"price" gorm:"type:decimal(10,2);default:0"` // Service price when booked
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relationships
+	Service *Service `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	Staff   *User    `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
 }
 
 // Participant represents care recipients
@@ -160,6 +201,8 @@ type Participant struct {
 	Address        Address            `json:"address" gorm:"embedded;embeddedPrefix:address_"`
 	MedicalInfo    MedicalInformation `json:"medical_information" gorm:"embedded;embeddedPrefix:medical_"`
 	Funding        FundingInformation `json:"funding" gorm:"embedded;embeddedPrefix:funding_"`
+	Remoteness     string             `json:"remoteness" gorm:"type:varchar(20);default:'standard'"` // standard, remote, very_remote (NDIS MMM classification)
+	GenderPreference string           `json:"gender_preference" gorm:"type:varchar(20)"`             // Worker gender the participant prefers; blank for no preference
 	OrganizationID string             `json:"organization_id" gorm:"type:varchar(255);not null;index"`
 	IsActive       bool               `json:"is_active" gorm:"default:true;index"`
 	CreatedAt      time.Time          `json:"created_at"`
@@ -195,7 +238,7 @@ type EmergencyContact struct {
 type Shift struct {
 	ID              string         `json:"id" gorm:"type:varchar(255);primaryKey"`
 	ParticipantID   string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
-	StaffID         string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	StaffID         string         `json:"staff_id" gorm:"type:varchar(255);not null;index"` // Blank while the shift is unassigned
 	StartTime       time.Time      `json:"start_time" gorm:"not null;index"`
 	EndTime         time.Time      `json:"end_time" gorm:"not null;index"`
 	ActualStartTime *time.Time     `json:"actual_start_time,omitempty"`
@@ -204,9 +247,16 @@ type Shift struct {
 	Location        string         `json:"location" gorm:"type:varchar(100);not null"`
 	Status          string         `json:"status" gorm:"type:varchar(50);default:'scheduled';index"` // scheduled, in_progress, completed, cancelled, no_show
 	HourlyRate      float64        `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
+	SupportItemNumber string       `json:"support_item_number" gorm:"type:varchar(50);index"` // NDIS line item resolved from the support catalogue
+	PriceLimit      float64        `json:"price_limit" gorm:"type:decimal(10,2);default:0"`    // Catalogue price cap applied when the shift was priced
 	TotalCost       float64        `json:"total_cost" gorm:"type:decimal(10,2)"`
 	Notes           string         `json:"notes" gorm:"type:text"`
 	CompletionNotes string         `json:"completion_notes" gorm:"type:text"`
+	InvoiceID       *string        `json:"invoice_id,omitempty" gorm:"type:varchar(255);index"` // Set once the shift has been billed
+	BudgetID        *string        `json:"budget_id,omitempty" gorm:"type:varchar(255);index"`  // Plan budget drawn down when the shift completed
+	SeriesID        *string        `json:"series_id,omitempty" gorm:"type:varchar(255);index;uniqueIndex:idx_shifts_series_occurrence"` // Recurring series that generated the shift
+	OccurrenceStart *time.Time     `json:"occurrence_start,omitempty" gorm:"uniqueIndex:idx_shifts_series_occurrence"`                   // Slot in the series this shift fills, kept when the shift is moved
+	SeriesException bool           `json:"series_exception" gorm:"default:false"`                 // Edited individually, so series-wide edits leave it alone
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
@@ -226,6 +276,7 @@ type Document struct {
 	Title            string         `json:"title" gorm:"type:varchar(255);not null"`
 	Description      string         `json:"description" gorm:"type:text"`
 	Category         string         `json:"category" gorm:"type:varchar(100);not null;index"` // care_plan, medical_record, incident_report, assessment, etc.
+	IncidentID       *string        `json:"incident_id,omitempty" gorm:"type:varchar(255);index"` // Set for incident_report documents attached to an incident
 	FileType         string         `json:"file_type" gorm:"type:varchar(100);not null"`
 	FileSize         int64          `json:"file_size" gorm:"not null"`
 	FilePath         string         `json:"file_path" gorm:"type:varchar(500);not null"`
@@ -247,21 +298,30 @@ type CarePlan struct {
 	ParticipantID string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
 	Title         string         `json:"title" gorm:"type:varchar(255);not null"`
 	Description   string         `json:"description" gorm:"type:text"`
-	Goals         string         `json:"goals" gorm:"type:text"` // JSON string of goals
+	Goals         string         `json:"goals" gorm:"type:text"` // JSON array of goal titles, kept in step with GoalItems for older clients
 	StartDate     time.Time      `json:"start_date" gorm:"not null"`
 	EndDate       *time.Time     `json:"end_date,omitempty"`
-	Status        string         `json:"status" gorm:"type:varchar(50);default:'active';index"` // active, completed, cancelled
+	Status        string         `json:"status" gorm:"type:varchar(50);default:'active';index"` // draft, active, superseded, completed, cancelled
+	Version       int            `json:"version" gorm:"default:1"`
+	LineageID     string         `json:"lineage_id" gorm:"type:varchar(255);index"` // Shared by every version of the plan; the first version's ID
+	PreviousVersionID *string    `json:"previous_version_id,omitempty" gorm:"type:varchar(255)"`
 	CreatedBy     string         `json:"created_by" gorm:"type:varchar(255);not null"`
 	ApprovedBy    *string        `json:"approved_by,omitempty" gorm:"type:varchar(255)"`
-	ApprovedAt    *time.Time     `json:"approved_at,omitempty"`
+	ApprovedAt    *time.Time     `json:"approved_at,omitempty"` // Once set the version is locked; edits go to a new draft version
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// Relationships
-	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
-	Creator     User        `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
-	Approver    *User       `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
+	Participant Participant    `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Creator     User           `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
+	Approver    *User          `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
+	GoalItems   []CarePlanGoal `json:"goal_items,omitempty" gorm:"foreignKey:CarePlanID"`
+}
+
+// IsLocked reports whether the plan version has been approved or rejected and can no longer be edited
+func (c *CarePlan) IsLocked() bool {
+	return c.ApprovedAt != nil
 }
 
 // RefreshToken stores JWT refresh tokens
@@ -298,6 +358,8 @@ type Address struct {
 	State    string `json:"state" gorm:"type:varchar(50)"`
 	Postcode string `json:"postcode" gorm:"type:varchar(10)"`
 	Country  string `json:"country" gorm:"type:varchar(100);default:'Australia'"`
+	Latitude  *float64 `json:"latitude,omitempty"`  // Used as the geofence centre for visit verification
+	Longitude *float64 `json:"longitude,omitempty"`
 }
 
 // NDISReg represents NDIS registration information
@@ -360,6 +422,10 @@ type BookingSettings struct {
 	ReminderHours        int    `json:"reminder_hours" gorm:"default:24"` // Hours before appointment
 	AllowCancellation    bool   `json:"allow_cancellation" gorm:"default:true"`
 	CancellationWindow   int    `json:"cancellation_window" gorm:"default:24"` // Hours before appointment
+	CloseOnPublicHolidays bool  `json:"close_on_public_holidays"` // No bookings on public holidays in the organization's state
+	WaitlistHoldMinutes   int   `json:"waitlist_hold_minutes" gorm:"default:30"` // How long a freed slot is held for a waitlisted customer
+	NotificationChannels  string `json:"notification_channels" gorm:"type:varchar(50);default:'email,sms'"` // Comma separated: email, sms, whatsapp
+	DepositHoldMinutes    int    `json:"deposit_hold_minutes" gorm:"default:15"` // How long an online booking is held while the deposit is paid
 }
 
 // BeforeCreate hooks for generating UUIDs
@@ -367,9 +433,46 @@ func (o *Organization) BeforeCreate(tx *gorm.DB) (err error) {
 	if o.ID == "" {
 		o.ID = uuid.New().String()
 	}
+	if o.Slug == "" {
+		o.Slug = Slugify(o.Name)
+		var taken int64
+		if err := tx.Model(&Organization{}).Unscoped().Where("slug = ?", o.Slug).Count(&taken).Error; err != nil {
+			return err
+		}
+		if o.Slug == "" || taken > 0 {
+			o.Slug = suffixedSlug(o.Slug, o.ID)
+		}
+	}
 	return
 }
 
+// suffixedSlug tells apart organizations whose names slugify the same way
+func suffixedSlug(slug, id string) string {
+	suffix := strings.ReplaceAll(id, "-", "")
+	if len(suffix) > 6 {
+		suffix = suffix[:6]
+	}
+	return strings.Trim(slug+"-"+suffix, "-")
+}
+
+// Slugify lowercases a name and joins its letters and digits with hyphens
+func Slugify(name string) string {
+	var slug strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return slug.String()
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	if u.ID == "" {
 		u.ID = uuid.New().String()
@@ -412,6 +515,9 @@ func (c *CarePlan) BeforeCreate(tx *gorm.DB) (err error) {
 	if c.ID == "" {
 		c.ID = uuid.New().String()
 	}
+	if c.LineageID == "" {
+		c.LineageID = c.ID
+	}
 	return
 }
 
@@ -454,12 +560,13 @@ func (b *Booking) BeforeCreate(tx *gorm.DB) (err error) {
 	if b.ID == "" {
 		b.ID = uuid.New().String()
 	}
-	// Calculate total price based on services
-	var totalPrice float64
-	for _, service := range b.Services {
-		totalPrice += service.Price
+	return
+}
+
+func (b *BookingStep) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
 	}
-	b.TotalPrice = totalPrice
 	return
 }
 
@@ -479,6 +586,38 @@ func (p *Participant) BeforeUpdate(tx *gorm.DB) (err error) {
 	return
 }
 
+// backfillOrganizationSlugs gives a slug to organizations without one, and a
+// new one to organizations sharing a slug with an older organization
+func backfillOrganizationSlugs(db *gorm.DB) error {
+	var orgs []Organization
+	if err := db.Unscoped().Select("id", "name", "slug").Order("created_at ASC").Find(&orgs).Error; err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(orgs))
+	var unslugged []Organization
+	for _, org := range orgs {
+		if org.Slug == "" || taken[org.Slug] {
+			unslugged = append(unslugged, org)
+			continue
+		}
+		taken[org.Slug] = true
+	}
+	for _, org := range unslugged {
+		slug := Slugify(org.Name)
+		if slug == "" || taken[slug] {
+			slug = suffixedSlug(slug, org.ID)
+		}
+		if taken[slug] {
+			slug = org.ID
+		}
+		taken[slug] = true
+		if err := db.Model(&Organization{}).Unscoped().Where("id = ?", org.ID).UpdateColumn("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Database migration function
 func MigrateDB(db *gorm.DB) error {
 	// Handle custom migrations manually
@@ -498,6 +637,7 @@ func MigrateDB(db *gorm.DB) error {
 		&Shift{},
 		&Document{},
 		&CarePlan{},
+		&CarePlanGoal{},
 		&RefreshToken{},
 		&UserPermission{},
 		// ERP Models
@@ -521,6 +661,64 @@ func MigrateDB(db *gorm.DB) error {
 		&LaybyPayment{},
 		&LaybyItem{},
 		&LaybyPaymentEntry{},
+		// Billing Models
+		&ParticipantInvoice{},
+		&ParticipantInvoiceLine{},
+		&NumberSequence{},
+		&NDISClaimBatch{},
+		&NDISClaimLine{},
+		// NDIS Support Catalogue
+		&SupportCatalogueVersion{},
+		&SupportCatalogueItem{},
+		&PublicHoliday{},
+		// NDIS Plan Budgets
+		&PlanBudget{},
+		&PlanBudgetTransaction{},
+		&BudgetAlert{},
+		// Recurring shifts
+		&ShiftSeries{},
+		// Staff rostering
+		&StaffAvailability{},
+		&StaffLeave{},
+		&StaffQualification{},
+		&QualificationRequirement{},
+		&ParticipantWorkerPreference{},
+
+		// Electronic visit verification
+		&ShiftClockEvent{},
+
+		// Progress notes and incidents
+		&ShiftProgressNote{},
+		&ProgressNoteGoal{},
+		&Incident{},
+		&IncidentInvestigationStep{},
+
+		// Booking availability
+		&BusinessBreak{},
+		&BusinessClosure{},
+
+		// Bookable resources
+		&Resource{},
+		&ServiceResourceRequirement{},
+		&BookingResource{},
+
+		// Booking waitlist
+		&WaitlistEntry{},
+		&WaitlistOffer{},
+		// Background jobs and booking notifications
+		&Job{},
+		&BookingNotification{},
+		// Calendar feeds
+		&CalendarFeed{},
+		// Booking deposits and fees
+		&BookingPayment{},
+		// Multi-service bookings
+		&BookingStep{},
+		// Vehicle service history
+		&OdometerReading{},
+		&JobCardItem{},
+		&ServiceReminderRule{},
+		&VehicleServiceReminder{},
 	)
 }
 
@@ -540,6 +738,36 @@ func handleCustomMigrations(db *gorm.DB) error {
 				return err
 			}
 		}
+
+		// Public pages find organizations by slug, so each needs its own
+		// before the unique index replaces the plain one
+		if !db.Migrator().HasColumn(&Organization{}, "slug") {
+			if err := db.Exec("ALTER TABLE organizations ADD COLUMN slug varchar(100)").Error; err != nil {
+				return err
+			}
+		}
+		if db.Migrator().HasIndex(&Organization{}, "idx_organizations_slug") {
+			if err := db.Migrator().DropIndex(&Organization{}, "idx_organizations_slug"); err != nil {
+				return err
+			}
+		}
+		if err := backfillOrganizationSlugs(db); err != nil {
+			return err
+		}
+	}
+
+	// Each series occurrence gets one shift. Before the unique index goes on,
+	// later duplicates left by racing generation are detached from the series
+	// rather than deleted, since staff may already be working them
+	if db.Migrator().HasTable(&Shift{}) && db.Migrator().HasColumn(&Shift{}, "occurrence_start") &&
+		!db.Migrator().HasIndex(&Shift{}, "idx_shifts_series_occurrence") {
+		if err := db.Exec(`UPDATE shifts SET series_id = NULL, occurrence_start = NULL
+			WHERE series_id IS NOT NULL AND occurrence_start IS NOT NULL AND EXISTS (
+				SELECT 1 FROM shifts earlier
+				WHERE earlier.series_id = shifts.series_id AND earlier.occurrence_start = shifts.occurrence_start
+				AND (earlier.created_at < shifts.created_at OR (earlier.created_at = shifts.created_at AND earlier.id < shifts.id)))`).Error; err != nil {
+			return err
+		}
 	}
 	
 	// Handle users table
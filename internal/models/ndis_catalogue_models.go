@@ -0,0 +1,127 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SupportCatalogueVersion represents one imported release of the NDIS Support Catalogue
+type SupportCatalogueVersion struct {
+	ID            string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	Name          string     `json:"name" gorm:"type:varchar(100);not null"` // e.g. "2025-26 v1.0"
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"not null;index"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" gorm:"index"`
+	SourceFile    string     `json:"source_file" gorm:"type:varchar(255)"`
+	ItemCount     int        `json:"item_count" gorm:"default:0"`
+	IsActive      bool       `json:"is_active" gorm:"default:true;index"`
+	ImportedBy    string     `json:"imported_by" gorm:"type:varchar(255);not null"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// SupportCatalogueItem represents a single NDIS line item and its price limits
+type SupportCatalogueItem struct {
+	ID                string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	VersionID         string    `json:"version_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_catalogue_version_item"`
+	ItemNumber        string    `json:"item_number" gorm:"type:varchar(50);not null;uniqueIndex:idx_catalogue_version_item;index"`
+	ItemName          string    `json:"item_name" gorm:"type:varchar(500);not null"`
+	SupportGroup      string    `json:"support_group" gorm:"type:varchar(500);index"`          // Item name without the time band, shared by all variants
+	TimeBand          string    `json:"time_band" gorm:"type:varchar(30);default:'any';index"` // any, weekday_daytime, weekday_evening, night, saturday, sunday, public_holiday
+	RegistrationGroup string    `json:"registration_group" gorm:"type:varchar(255)"`
+	SupportCategory   string    `json:"support_category" gorm:"type:varchar(255);index"`
+	Unit              string    `json:"unit" gorm:"type:varchar(10)"` // H (hour), E (each), D (day), WK (week), YR (year)
+	QuoteRequired     bool      `json:"quote_required" gorm:"default:false"`
+	PriceACT          float64   `json:"price_act" gorm:"type:decimal(10,2);default:0"`
+	PriceNSW          float64   `json:"price_nsw" gorm:"type:decimal(10,2);default:0"`
+	PriceNT           float64   `json:"price_nt" gorm:"type:decimal(10,2);default:0"`
+	PriceQLD          float64   `json:"price_qld" gorm:"type:decimal(10,2);default:0"`
+	PriceSA           float64   `json:"price_sa" gorm:"type:decimal(10,2);default:0"`
+	PriceTAS          float64   `json:"price_tas" gorm:"type:decimal(10,2);default:0"`
+	PriceVIC          float64   `json:"price_vic" gorm:"type:decimal(10,2);default:0"`
+	PriceWA           float64   `json:"price_wa" gorm:"type:decimal(10,2);default:0"`
+	PriceRemote       float64   `json:"price_remote" gorm:"type:decimal(10,2);default:0"`
+	PriceVeryRemote   float64   `json:"price_very_remote" gorm:"type:decimal(10,2);default:0"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// Relationships
+	Version SupportCatalogueVersion `json:"version,omitempty" gorm:"foreignKey:VersionID"`
+}
+
+// PublicHoliday represents a gazetted public holiday; a blank State applies nationally
+type PublicHoliday struct {
+	ID        string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	State     string    `json:"state" gorm:"type:varchar(10);index"`
+	Date      time.Time `json:"date" gorm:"not null;index"`
+	Name      string    `json:"name" gorm:"type:varchar(255);not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PriceFor returns the price limit that applies to a participant in the given
+// state and remoteness classification. Zero means the item has no price limit.
+func (i *SupportCatalogueItem) PriceFor(state, remoteness string) float64 {
+	switch remoteness {
+	case "very_remote":
+		if i.PriceVeryRemote > 0 {
+			return i.PriceVeryRemote
+		}
+	case "remote":
+		if i.PriceRemote > 0 {
+			return i.PriceRemote
+		}
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "ACT":
+		return i.PriceACT
+	case "NSW":
+		return i.PriceNSW
+	case "NT":
+		return i.PriceNT
+	case "QLD":
+		return i.PriceQLD
+	case "SA":
+		return i.PriceSA
+	case "TAS":
+		return i.PriceTAS
+	case "VIC":
+		return i.PriceVIC
+	case "WA":
+		return i.PriceWA
+	}
+
+	// Unknown state: fall back to the highest national limit
+	highest := i.PriceACT
+	for _, price := range []float64{i.PriceNSW, i.PriceNT, i.PriceQLD, i.PriceSA, i.PriceTAS, i.PriceVIC, i.PriceWA} {
+		if price > highest {
+			highest = price
+		}
+	}
+	return highest
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (v *SupportCatalogueVersion) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return
+}
+
+func (i *SupportCatalogueItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *PublicHoliday) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
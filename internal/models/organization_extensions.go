@@ -42,6 +42,7 @@ type OrganizationSettings struct {
 	RequireShiftNotes     bool      `json:"require_shift_notes" gorm:"default:false"`
 	RequirePhotoEvidence  bool      `json:"require_photo_evidence" gorm:"default:false"`
 	AutoAssignShifts      bool      `json:"auto_assign_shifts" gorm:"default:false"`
+	GeofenceRadiusMeters  int       `json:"geofence_radius_meters" gorm:"default:200"` // How far from the participant's address staff may clock in or out
 	EnableSMSNotifications bool      `json:"enable_sms_notifications" gorm:"default:true"`
 	EnableEmailNotifications bool    `json:"enable_email_notifications" gorm:"default:true"`
 	CreatedAt             time.Time `json:"created_at"`
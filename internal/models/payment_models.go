@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookingPayment is money taken from or returned to a customer for a
+// booking: a deposit, a refund of it, a forfeited deposit or a no-show fee.
+// Each successful payment is posted to the general ledger once.
+type BookingPayment struct {
+	ID             string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	BookingID      string     `json:"booking_id" gorm:"type:varchar(255);not null;index"`
+	CustomerID     string     `json:"customer_id" gorm:"type:varchar(255);not null;index"`
+	Kind           string     `json:"kind" gorm:"type:varchar(20);not null"` // deposit, refund, forfeit, no_show_fee
+	Amount         float64    `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Currency       string     `json:"currency" gorm:"type:varchar(3)"`
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, succeeded, failed, cancelled
+	Provider       string     `json:"provider,omitempty" gorm:"type:varchar(50)"`
+	ProviderRef    string     `json:"provider_ref,omitempty" gorm:"type:varchar(255);index"`
+	PaymentMethod  string     `json:"-" gorm:"type:varchar(255)"` // Saved card a deposit was paid with, charged for no-show fees
+	CheckoutURL    string     `json:"checkout_url,omitempty" gorm:"type:text"`
+	FailureReason  string     `json:"failure_reason,omitempty" gorm:"type:text"`
+	JournalEntryID *string    `json:"journal_entry_id,omitempty" gorm:"type:varchar(255)"` // Ledger posting, set once posted
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (p *BookingPayment) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
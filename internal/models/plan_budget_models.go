@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NDISSupportCategory describes one of the fifteen NDIS support categories
+type NDISSupportCategory struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Purpose string `json:"purpose"` // core, capacity_building, capital
+}
+
+// NDISSupportCategories lists the support categories keyed by the two digit
+// code that prefixes every support item number
+var NDISSupportCategories = map[string]NDISSupportCategory{
+	"01": {Code: "01", Name: "Assistance with Daily Life", Purpose: "core"},
+	"02": {Code: "02", Name: "Transport", Purpose: "core"},
+	"03": {Code: "03", Name: "Consumables", Purpose: "core"},
+	"04": {Code: "04", Name: "Assistance with Social, Economic and Community Participation", Purpose: "core"},
+	"05": {Code: "05", Name: "Assistive Technology", Purpose: "capital"},
+	"06": {Code: "06", Name: "Home Modifications and SDA", Purpose: "capital"},
+	"07": {Code: "07", Name: "Support Coordination", Purpose: "capacity_building"},
+	"08": {Code: "08", Name: "Improved Living Arrangements", Purpose: "capacity_building"},
+	"09": {Code: "09", Name: "Increased Social and Community Participation", Purpose: "capacity_building"},
+	"10": {Code: "10", Name: "Finding and Keeping a Job", Purpose: "capacity_building"},
+	"11": {Code: "11", Name: "Improved Relationships", Purpose: "capacity_building"},
+	"12": {Code: "12", Name: "Improved Health and Wellbeing", Purpose: "capacity_building"},
+	"13": {Code: "13", Name: "Improved Learning", Purpose: "capacity_building"},
+	"14": {Code: "14", Name: "Improved Life Choices", Purpose: "capacity_building"},
+	"15": {Code: "15", Name: "Improved Daily Living Skills", Purpose: "capacity_building"},
+}
+
+// SupportCategoryForItem returns the category code of a support item number such as "01_011_0107_1_1"
+func SupportCategoryForItem(itemNumber string) string {
+	if len(itemNumber) < 2 {
+		return ""
+	}
+	if _, ok := NDISSupportCategories[itemNumber[:2]]; !ok {
+		return ""
+	}
+	return itemNumber[:2]
+}
+
+// PlanBudget represents the funds allocated to one support category of a participant's NDIS plan
+type PlanBudget struct {
+	ID              string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID  string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	ParticipantID   string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	SupportPurpose  string         `json:"support_purpose" gorm:"type:varchar(30);not null"`       // core, capacity_building, capital
+	SupportCategory string         `json:"support_category" gorm:"type:varchar(2);not null;index"` // 01-15
+	CategoryName    string         `json:"category_name" gorm:"type:varchar(255)"`
+	PlanStartDate   time.Time      `json:"plan_start_date" gorm:"not null"`
+	PlanEndDate     time.Time      `json:"plan_end_date" gorm:"not null"`
+	AllocatedAmount float64        `json:"allocated_amount" gorm:"type:decimal(12,2);not null"`
+	SpentAmount     float64        `json:"spent_amount" gorm:"type:decimal(12,2);default:0"`
+	AlertThreshold  float64        `json:"alert_threshold" gorm:"type:decimal(5,2);default:80"` // Percentage of allocation that raises an alert
+	Notes           string         `json:"notes" gorm:"type:text"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+}
+
+// RemainingAmount returns the unspent part of the allocation
+func (b *PlanBudget) RemainingAmount() float64 {
+	return b.AllocatedAmount - b.SpentAmount
+}
+
+// PlanBudgetTransaction records a drawdown or adjustment against a plan budget
+type PlanBudgetTransaction struct {
+	ID          string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	BudgetID    string    `json:"budget_id" gorm:"type:varchar(255);not null;index"`
+	ShiftID     *string   `json:"shift_id,omitempty" gorm:"type:varchar(255);index"`
+	Type        string    `json:"type" gorm:"type:varchar(20);not null"`     // drawdown, adjustment
+	Amount      float64   `json:"amount" gorm:"type:decimal(12,2);not null"` // Positive amounts consume budget
+	Description string    `json:"description" gorm:"type:varchar(500)"`
+	OccurredAt  time.Time `json:"occurred_at" gorm:"not null;index"`
+	CreatedBy   string    `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BudgetAlert flags a plan budget that has crossed its threshold or is forecast to run out early
+type BudgetAlert struct {
+	ID                      string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID          string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	ParticipantID           string     `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	BudgetID                string     `json:"budget_id" gorm:"type:varchar(255);not null;index"`
+	Type                    string     `json:"type" gorm:"type:varchar(30);not null"` // threshold, forecast_overspend, overspent
+	Message                 string     `json:"message" gorm:"type:text"`
+	ProjectedExhaustionDate *time.Time `json:"projected_exhaustion_date,omitempty"`
+	AcknowledgedAt          *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy          string     `json:"acknowledged_by,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt               time.Time  `json:"created_at"`
+
+	// Relationships
+	Budget      PlanBudget  `json:"budget,omitempty" gorm:"foreignKey:BudgetID"`
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (b *PlanBudget) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	return
+}
+
+func (t *PlanBudgetTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.OccurredAt.IsZero() {
+		t.OccurredAt = time.Now()
+	}
+	return
+}
+
+func (a *BudgetAlert) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return
+}
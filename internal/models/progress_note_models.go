@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShiftProgressNote is the structured record of what happened on a shift
+type ShiftProgressNote struct {
+	ID            string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	ShiftID       string         `json:"shift_id" gorm:"type:varchar(255);not null;uniqueIndex"`
+	ParticipantID string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	StaffID       string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	CarePlanID    *string        `json:"care_plan_id,omitempty" gorm:"type:varchar(255);index"`
+	Mood          string         `json:"mood" gorm:"type:varchar(20)"` // very_low, low, neutral, good, very_good
+	Activities    string         `json:"activities" gorm:"type:text"`  // JSON array of activities
+	Summary       string         `json:"summary" gorm:"type:text;not null"`
+	Concerns      string         `json:"concerns" gorm:"type:text"` // Anything the next worker or coordinator should know
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Goals []ProgressNoteGoal `json:"goals,omitempty" gorm:"foreignKey:ProgressNoteID"`
+	Staff User               `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// ProgressNoteGoal records progress against one care plan goal during a shift
+type ProgressNoteGoal struct {
+	ID             string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	ProgressNoteID string    `json:"progress_note_id" gorm:"type:varchar(255);not null;index"`
+	CarePlanID     string    `json:"care_plan_id" gorm:"type:varchar(255);not null;index"`
+	GoalID         *string   `json:"goal_id,omitempty" gorm:"type:varchar(255);index"`  // Goal record, for plans with structured goals
+	GoalKey        string    `json:"goal_key,omitempty" gorm:"type:varchar(255);index"` // Follows the goal across plan versions
+	Goal           string    `json:"goal" gorm:"type:varchar(255);not null"`
+	Rating         string    `json:"rating" gorm:"type:varchar(20);not null"` // no_progress, some_progress, good_progress, achieved
+	Comment        string    `json:"comment" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (n *ShiftProgressNote) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return
+}
+
+func (g *ProgressNoteGoal) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	return
+}
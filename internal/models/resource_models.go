@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Resource is something a booking occupies besides staff time, such as a
+// service bay, hoist, treatment room or chair. Capacity is how many bookings
+// it can hold at once.
+type Resource struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	Name           string         `json:"name" gorm:"type:varchar(255);not null"`
+	Type           string         `json:"type" gorm:"type:varchar(50);not null;index"` // bay, lift, hoist, room, chair, equipment
+	Capacity       int            `json:"capacity" gorm:"default:1"`
+	IsActive       bool           `json:"is_active" gorm:"default:true;index"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ServiceResourceRequirement is a resource a service needs for its whole
+// duration: either one particular resource or any resource of a type
+type ServiceResourceRequirement struct {
+	ID           string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	ServiceID    string    `json:"service_id" gorm:"type:varchar(255);not null;index"`
+	ResourceID   *string   `json:"resource_id,omitempty" gorm:"type:varchar(255);index"`
+	ResourceType string    `json:"resource_type,omitempty" gorm:"type:varchar(50)"`
+	Quantity     int       `json:"quantity" gorm:"default:1"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relationships
+	Resource *Resource `json:"resource,omitempty" gorm:"foreignKey:ResourceID"`
+}
+
+// BookingResource is the units of a resource set aside for a booking
+type BookingResource struct {
+	ID         string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	BookingID  string    `json:"booking_id" gorm:"type:varchar(255);not null;index"`
+	ResourceID string    `json:"resource_id" gorm:"type:varchar(255);not null;index"`
+	Quantity   int       `json:"quantity" gorm:"default:1"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Resource *Resource `json:"resource,omitempty" gorm:"foreignKey:ResourceID"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (r *Resource) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *ServiceResourceRequirement) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *BookingResource) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShiftSeries is a recurring roster entry that generates individual shifts ahead of time
+type ShiftSeries struct {
+	ID                 string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID     string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	ParticipantID      string         `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	StaffID            string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	ParentSeriesID     *string        `json:"parent_series_id,omitempty" gorm:"type:varchar(255);index"` // Series this one was split from by a "this and following" edit
+	ServiceType        string         `json:"service_type" gorm:"type:varchar(100);not null"`
+	Location           string         `json:"location" gorm:"type:varchar(100);not null"`
+	HourlyRate         float64        `json:"hourly_rate" gorm:"type:decimal(10,2);not null"`
+	SupportItemNumber  string         `json:"support_item_number" gorm:"type:varchar(50)"`
+	Notes              string         `json:"notes" gorm:"type:text"`
+	StartsAt           time.Time      `json:"starts_at" gorm:"not null"` // Start of the first occurrence; its local time of day is kept for every occurrence
+	DurationMinutes    int            `json:"duration_minutes" gorm:"not null"`
+	Timezone           string         `json:"timezone" gorm:"type:varchar(50);not null"`
+	Frequency          string         `json:"frequency" gorm:"type:varchar(20);not null"`       // daily, weekly
+	Interval           int            `json:"interval" gorm:"column:repeat_interval;default:1"` // 2 for fortnightly
+	ByWeekday          string         `json:"by_weekday" gorm:"type:varchar(30)"`               // Comma separated RRULE days such as MO,WE,FR
+	UntilDate          *time.Time     `json:"until_date,omitempty"`                             // Last local date an occurrence may fall on
+	Count              int            `json:"count" gorm:"default:0"`                           // Total occurrences, 0 for no limit
+	SkipPublicHolidays bool           `json:"skip_public_holidays"`
+	GeneratedUntil     *time.Time     `json:"generated_until,omitempty"`                             // Shifts have been generated for occurrences before this time
+	Status             string         `json:"status" gorm:"type:varchar(20);default:'active';index"` // active, ended, cancelled
+	RRule              string         `json:"rrule" gorm:"-"`
+	CreatedBy          string         `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Participant Participant `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	Staff       User        `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// RecurrenceRule renders the series recurrence as an iCalendar RRULE value
+func (s *ShiftSeries) RecurrenceRule() string {
+	parts := []string{"FREQ=" + strings.ToUpper(s.Frequency)}
+	if s.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", s.Interval))
+	}
+	if s.ByWeekday != "" {
+		parts = append(parts, "BYDAY="+s.ByWeekday)
+	}
+	if s.UntilDate != nil {
+		parts = append(parts, "UNTIL="+s.UntilDate.Format("20060102"))
+	}
+	if s.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", s.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+// BeforeCreate hook for generating UUIDs
+func (s *ShiftSeries) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	s.RRule = s.RecurrenceRule()
+	return
+}
+
+// AfterFind fills in the RRULE rendering of the stored recurrence
+func (s *ShiftSeries) AfterFind(tx *gorm.DB) (err error) {
+	s.RRule = s.RecurrenceRule()
+	return
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OdometerReading is a vehicle's odometer at a point in time. The latest
+// reading is kept on Vehicle.Mileage.
+type OdometerReading struct {
+	ID             string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	VehicleID      string    `json:"vehicle_id" gorm:"type:varchar(255);not null;index"`
+	BookingID      *string   `json:"booking_id,omitempty" gorm:"type:varchar(255);index"` // Set when read as the vehicle came in for a booking
+	Reading        int       `json:"reading" gorm:"not null"`                             // Kilometres
+	Source         string    `json:"source" gorm:"type:varchar(20);default:'manual'"`     // manual, booking, correction
+	RecordedAt     time.Time `json:"recorded_at" gorm:"not null;index"`
+	RecordedBy     string    `json:"recorded_by,omitempty" gorm:"type:varchar(255)"`
+	Notes          string    `json:"notes" gorm:"type:text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// JobCardItem is a part fitted or labour done on a booking. Parts come out of
+// stock through an inventory movement, which is reversed if the item is removed.
+type JobCardItem struct {
+	ID                  string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID      string    `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	BookingID           string    `json:"booking_id" gorm:"type:varchar(255);not null;index"`
+	Type                string    `json:"type" gorm:"type:varchar(20);not null"` // part, labour
+	ProductID           *string   `json:"product_id,omitempty" gorm:"type:varchar(255);index"`
+	InventoryMovementID *string   `json:"inventory_movement_id,omitempty" gorm:"type:varchar(255)"`
+	StaffID             *string   `json:"staff_id,omitempty" gorm:"type:varchar(255);index"` // Who did the labour
+	Description         string    `json:"description" gorm:"type:varchar(255);not null"`
+	Quantity            float64   `json:"quantity" gorm:"type:decimal(10,2);not null"` // Units for parts, hours for labour
+	UnitPrice           float64   `json:"unit_price" gorm:"type:decimal(10,2);default:0"`
+	Total               float64   `json:"total" gorm:"type:decimal(10,2);default:0"`
+	CreatedBy           string    `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// Relationships
+	Product *Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Staff   *User    `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// ServiceReminderRule says how often vehicles need a service, e.g. every
+// 10,000 km or 12 months, whichever comes first. The interval restarts when
+// a booking for the rule's service (or any booking, without one) is completed.
+type ServiceReminderRule struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	Name           string         `json:"name" gorm:"type:varchar(100);not null"`
+	ServiceID      *string        `json:"service_id,omitempty" gorm:"type:varchar(255);index"`
+	IntervalKm     int            `json:"interval_km" gorm:"default:0"`     // 0 = no distance interval
+	IntervalMonths int            `json:"interval_months" gorm:"default:0"` // 0 = no time interval
+	DueSoonKm      int            `json:"due_soon_km" gorm:"default:1000"`  // Remind this far before the distance is reached
+	DueSoonDays    int            `json:"due_soon_days" gorm:"default:30"`  // Remind this long before the date
+	IsActive       bool           `json:"is_active" gorm:"default:true;index"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Service *Service `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+}
+
+// VehicleServiceReminder is a due-soon message sent to a vehicle's owner.
+// CycleKey identifies the service the interval was counted from, so each
+// interval is reminded about once.
+type VehicleServiceReminder struct {
+	ID             string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	VehicleID      string     `json:"vehicle_id" gorm:"type:varchar(255);not null;index"`
+	RuleID         string     `json:"rule_id" gorm:"type:varchar(255);not null;index"`
+	CustomerID     string     `json:"customer_id" gorm:"type:varchar(255);not null;index"`
+	CycleKey       string     `json:"cycle_key" gorm:"type:varchar(255);not null;index"`
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	DueMileage     int        `json:"due_mileage,omitempty"`
+	Channel        string     `json:"channel" gorm:"type:varchar(20);not null"`
+	Recipient      string     `json:"recipient" gorm:"type:varchar(255);not null"`
+	Status         string     `json:"status" gorm:"type:varchar(20);default:'scheduled';index"` // scheduled, sent, failed, skipped
+	Error          string     `json:"error,omitempty" gorm:"type:text"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (r *OdometerReading) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (i *JobCardItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *ServiceReminderRule) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *VehicleServiceReminder) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
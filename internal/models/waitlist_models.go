@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitlistEntry is a customer waiting for a service to come free within a
+// window of dates and times, optionally with a particular staff member
+type WaitlistEntry struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	CustomerID     string         `json:"customer_id" gorm:"type:varchar(255);not null;index"`
+	ServiceID      string         `json:"service_id" gorm:"type:varchar(255);not null;index"`
+	StaffID        *string        `json:"staff_id,omitempty" gorm:"type:varchar(255);index"` // Blank for anyone
+	EarliestDate   time.Time      `json:"earliest_date" gorm:"not null"`
+	LatestDate     time.Time      `json:"latest_date" gorm:"not null"`                            // Inclusive
+	EarliestTime   string         `json:"earliest_time,omitempty" gorm:"type:varchar(5)"`         // Local HH:MM; blank for any time
+	LatestTime     string         `json:"latest_time,omitempty" gorm:"type:varchar(5)"`           // Local HH:MM the service must finish by
+	Status         string         `json:"status" gorm:"type:varchar(20);default:'waiting';index"` // waiting, offered, booked, cancelled
+	BookingID      *string        `json:"booking_id,omitempty" gorm:"type:varchar(255)"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedBy      string         `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Customer *Customer `json:"customer,omitempty" gorm:"foreignKey:CustomerID"`
+	Service  *Service  `json:"service,omitempty" gorm:"foreignKey:ServiceID"`
+	Staff    *User     `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// WaitlistOffer is a freed slot held for a waitlisted customer until they
+// accept, decline or the hold runs out
+type WaitlistOffer struct {
+	ID              string     `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID  string     `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	EntryID         string     `json:"entry_id" gorm:"type:varchar(255);not null;index"`
+	SourceBookingID string     `json:"source_booking_id" gorm:"type:varchar(255);not null;index"` // The cancelled booking that freed the slot
+	StaffID         *string    `json:"staff_id,omitempty" gorm:"type:varchar(255)"`
+	StartTime       time.Time  `json:"start_time" gorm:"not null"`
+	EndTime         time.Time  `json:"end_time" gorm:"not null"`
+	Status          string     `json:"status" gorm:"type:varchar(20);default:'pending';index"` // pending, accepted, declined, expired, withdrawn
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null;index"`
+	RespondedAt     *time.Time `json:"responded_at,omitempty"`
+	BookingID       *string    `json:"booking_id,omitempty" gorm:"type:varchar(255)"`
+	Channel         string     `json:"channel" gorm:"type:varchar(20)"` // email, sms
+	NotifiedAt      *time.Time `json:"notified_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	Entry *WaitlistEntry `json:"entry,omitempty" gorm:"foreignKey:EntryID"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return
+}
+
+func (w *WaitlistOffer) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return
+}
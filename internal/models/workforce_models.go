@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StaffAvailability is a weekly window in which a staff member can be rostered
+type StaffAvailability struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	StaffID        string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	Weekday        int            `json:"weekday" gorm:"not null"`                    // 0 = Sunday ... 6 = Saturday
+	StartTime      string         `json:"start_time" gorm:"type:varchar(5);not null"` // Local time, HH:MM
+	EndTime        string         `json:"end_time" gorm:"type:varchar(5);not null"`   // Local time, HH:MM; 24:00 runs to midnight
+	EffectiveFrom  *time.Time     `json:"effective_from,omitempty"`
+	EffectiveTo    *time.Time     `json:"effective_to,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// StaffLeave blocks a staff member from being rostered
+type StaffLeave struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	StaffID        string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	LeaveType      string         `json:"leave_type" gorm:"type:varchar(30);not null"`             // annual, personal, unpaid, training, other
+	Status         string         `json:"status" gorm:"type:varchar(20);default:'approved';index"` // pending, approved, rejected
+	StartTime      time.Time      `json:"start_time" gorm:"not null;index"`
+	EndTime        time.Time      `json:"end_time" gorm:"not null;index"`
+	Reason         string         `json:"reason" gorm:"type:text"`
+	ApprovedBy     string         `json:"approved_by,omitempty" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// StaffQualification records a certification or screening held by a staff member
+type StaffQualification struct {
+	ID             string         `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID string         `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	StaffID        string         `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	Type           string         `json:"type" gorm:"type:varchar(50);not null;index"` // first_aid, cpr, ndis_worker_screening, manual_handling, medication, wwcc, drivers_licence
+	Reference      string         `json:"reference" gorm:"type:varchar(100)"`          // Certificate or clearance number
+	IssuedDate     *time.Time     `json:"issued_date,omitempty"`
+	ExpiryDate     *time.Time     `json:"expiry_date,omitempty" gorm:"index"`
+	DocumentID     *string        `json:"document_id,omitempty" gorm:"type:varchar(255)"`
+	Notes          string         `json:"notes" gorm:"type:text"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relationships
+	Staff User `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// IsValidAt reports whether the qualification is current at the given time
+func (q *StaffQualification) IsValidAt(at time.Time) bool {
+	return q.ExpiryDate == nil || q.ExpiryDate.After(at)
+}
+
+// QualificationRequirement lists a qualification staff must hold to work a
+// service type. A blank service type applies to every shift.
+type QualificationRequirement struct {
+	ID                string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	OrganizationID    string    `json:"organization_id" gorm:"type:varchar(255);not null;index"`
+	ServiceType       string    `json:"service_type" gorm:"type:varchar(100);index"`
+	QualificationType string    `json:"qualification_type" gorm:"type:varchar(50);not null"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ParticipantWorkerPreference marks a staff member as preferred or blocked for a participant
+type ParticipantWorkerPreference struct {
+	ID            string    `json:"id" gorm:"type:varchar(255);primaryKey"`
+	ParticipantID string    `json:"participant_id" gorm:"type:varchar(255);not null;index"`
+	StaffID       string    `json:"staff_id" gorm:"type:varchar(255);not null;index"`
+	Preference    string    `json:"preference" gorm:"type:varchar(20);not null"` // preferred, blocked
+	Reason        string    `json:"reason" gorm:"type:text"`
+	CreatedBy     string    `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Relationships
+	Staff User `json:"staff,omitempty" gorm:"foreignKey:StaffID"`
+}
+
+// BeforeCreate hooks for generating UUIDs
+func (a *StaffAvailability) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return
+}
+
+func (l *StaffLeave) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == "" {
+		l.ID = uuid.New().String()
+	}
+	return
+}
+
+func (q *StaffQualification) BeforeCreate(tx *gorm.DB) (err error) {
+	if q.ID == "" {
+		q.ID = uuid.New().String()
+	}
+	return
+}
+
+func (r *QualificationRequirement) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == "" {
+		r.ID = uuid.New().String()
+	}
+	return
+}
+
+func (p *ParticipantWorkerPreference) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+	return
+}
@@ -0,0 +1,233 @@
+// Package notify sends messages to customers and staff over email, SMS or
+// WhatsApp. Channels without a configured provider are written to the log.
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
+)
+
+// Channels a message can be sent over
+const (
+	Email    = "email"
+	SMS      = "sms"
+	WhatsApp = "whatsapp"
+)
+
+// Message is a single message to one recipient
+type Message struct {
+	Channel     string
+	To          string // Email address or phone number
+	Subject     string // Email only
+	Body        string
+	Attachments []Attachment // Email only
+}
+
+// Attachment is a file sent with an email
+type Attachment struct {
+	Filename    string
+	ContentType string // Including any parameters, such as text/calendar; method=REQUEST
+	Data        []byte
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Mux sends each message with the sender for its channel, falling back to
+// Fallback for channels without one
+type Mux struct {
+	Channels map[string]Sender
+	Fallback Sender
+}
+
+func (m *Mux) Send(msg Message) error {
+	if sender, ok := m.Channels[msg.Channel]; ok {
+		return sender.Send(msg)
+	}
+	if m.Fallback == nil {
+		return fmt.Errorf("no sender for %s messages", msg.Channel)
+	}
+	return m.Fallback.Send(msg)
+}
+
+// LogSender writes messages to the log instead of sending them
+type LogSender struct{}
+
+func (LogSender) Send(msg Message) error {
+	log.Printf("notify: %s to %s: %s %s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPSender sends email through an SMTP server
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	from := s.From
+	if from == "" {
+		from = s.Username
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", from, msg.To, msg.Subject)
+	if len(msg.Attachments) == 0 {
+		body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		body.WriteString(msg.Body)
+	} else {
+		writeMultipart(&body, msg)
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(fmt.Sprintf("%s:%d", s.Host, s.Port), auth, from, []string{msg.To}, []byte(body.String()))
+}
+
+// writeMultipart writes the body and attachments of msg as multipart/mixed
+func writeMultipart(w *strings.Builder, msg Message) {
+	mw := multipart.NewWriter(w)
+	fmt.Fprintf(w, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, _ := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	part.Write([]byte(msg.Body))
+	for _, a := range msg.Attachments {
+		part, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded))
+	}
+	mw.Close()
+}
+
+// httpClient is shared by the senders that call HTTP APIs
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// postJSON posts payload to url and fails on any non-2xx response
+func postJSON(url, token string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	return nil
+}
+
+// SMSSender sends text messages through an HTTP gateway that accepts
+// {"from", "to", "body"} as JSON
+type SMSSender struct {
+	URL    string
+	APIKey string
+	From   string
+}
+
+func (s *SMSSender) Send(msg Message) error {
+	return postJSON(s.URL, s.APIKey, map[string]string{"from": s.From, "to": msg.To, "body": msg.Body})
+}
+
+// WhatsAppSender sends text messages through the WhatsApp Cloud API
+type WhatsAppSender struct {
+	BaseURL     string
+	PhoneID     string
+	AccessToken string
+}
+
+func (s *WhatsAppSender) Send(msg Message) error {
+	return postJSON(fmt.Sprintf("%s/%s/messages", strings.TrimRight(s.BaseURL, "/"), s.PhoneID), s.AccessToken, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                msg.To,
+		"type":              "text",
+		"text":              map[string]string{"body": msg.Body},
+	})
+}
+
+// Outbox keeps messages in memory instead of sending them, for tests
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (o *Outbox) Send(msg Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// FromConfig sends each channel through its configured provider and logs
+// messages for channels without one
+func FromConfig(cfg *config.Config) Sender {
+	mux := &Mux{Channels: make(map[string]Sender), Fallback: LogSender{}}
+	if cfg == nil {
+		return mux
+	}
+	if cfg.SMTPHost != "" {
+		mux.Channels[Email] = &SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}
+	}
+	if cfg.SMSAPIURL != "" {
+		mux.Channels[SMS] = &SMSSender{URL: cfg.SMSAPIURL, APIKey: cfg.SMSAPIKey, From: cfg.SMSFrom}
+	}
+	if cfg.WhatsAppPhoneID != "" {
+		mux.Channels[WhatsApp] = &WhatsAppSender{
+			BaseURL:     cfg.WhatsAppAPIURL,
+			PhoneID:     cfg.WhatsAppPhoneID,
+			AccessToken: cfg.WhatsAppToken,
+		}
+	}
+	return mux
+}
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	details := BookingDetails{
+		CustomerName:     "Gina",
+		OrganizationName: "Test Salon",
+		Services:         "Haircut, Colour",
+		When:             "Monday 19 October at 10:00 AM",
+		ManageURL:        "http://localhost:8080/manage",
+	}
+
+	msg, err := Render(BookingConfirmation, Email, "gina@example.com", details)
+	assert.NoError(t, err)
+	assert.Equal(t, "Your booking with Test Salon is confirmed", msg.Subject)
+	assert.Contains(t, msg.Body, "Hi Gina,")
+	assert.Contains(t, msg.Body, "When: Monday 19 October at 10:00 AM")
+	assert.NotContains(t, msg.Body, "With:")
+
+	msg, err = Render(BookingReminder, WhatsApp, "0400111222", details)
+	assert.NoError(t, err)
+	assert.Equal(t, WhatsApp, msg.Channel)
+	assert.Empty(t, msg.Subject)
+	assert.Equal(t, "Reminder from Test Salon: Haircut, Colour on Monday 19 October at 10:00 AM. Reschedule or cancel: http://localhost:8080/manage", msg.Body)
+
+	_, err = Render("invoice_overdue", Email, "gina@example.com", details)
+	assert.Error(t, err)
+}
+
+func TestSenderDeliverToSink(t *testing.T) {
+	sink := &Sink{}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go sink.ServeSMTP(listener)
+	server := httptest.NewServer(sink)
+	defer server.Close()
+
+	cfg := &config.Config{
+		SMTPHost:        "127.0.0.1",
+		SMTPPort:        listener.Addr().(*net.TCPAddr).Port,
+		SMTPFrom:        "bookings@example.com",
+		SMSAPIURL:       server.URL + "/sms",
+		WhatsAppAPIURL:  server.URL,
+		WhatsAppPhoneID: "local",
+	}
+	sender := FromConfig(cfg)
+
+	assert.NoError(t, sender.Send(Message{Channel: Email, To: "gina@example.com", Subject: "Booked", Body: "See you soon"}))
+	assert.NoError(t, sender.Send(Message{Channel: SMS, To: "0400111222", Body: "See you soon"}))
+	assert.NoError(t, sender.Send(Message{Channel: WhatsApp, To: "0400111222", Body: "See you soon"}))
+
+	messages := sink.Messages()
+	assert.Len(t, messages, 3)
+	assert.Equal(t, Email, messages[0].Channel)
+	assert.Equal(t, "gina@example.com", messages[0].To)
+	assert.Equal(t, "Booked", messages[0].Subject)
+	assert.Equal(t, "See you soon", strings.TrimSpace(messages[0].Body))
+	assert.Equal(t, SMS, messages[1].Channel)
+	assert.Equal(t, WhatsApp, messages[2].Channel)
+	assert.Equal(t, "See you soon", messages[2].Body)
+
+	invite := strings.Repeat("BEGIN:VCALENDAR\r\n", 20)
+	assert.NoError(t, sender.Send(Message{Channel: Email, To: "gina@example.com", Subject: "Booked", Body: "Invite attached",
+		Attachments: []Attachment{{Filename: "invite.ics", ContentType: "text/calendar; method=REQUEST", Data: []byte(invite)}}}))
+	withInvite := sink.Messages()[3]
+	assert.Equal(t, "Invite attached", withInvite.Body)
+	assert.Len(t, withInvite.Attachments, 1)
+	assert.Equal(t, "invite.ics", withInvite.Attachments[0].Filename)
+	assert.Equal(t, "text/calendar; method=REQUEST", withInvite.Attachments[0].ContentType)
+	assert.Equal(t, invite, string(withInvite.Attachments[0].Data))
+
+	assert.Error(t, (&SMSSender{URL: server.URL + "/nowhere"}).Send(Message{Channel: SMS, To: "0400111222", Body: "Hi"}))
+}
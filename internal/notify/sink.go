@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink stands in for an SMTP server and the SMS and WhatsApp APIs during
+// development. It accepts whatever SMTPSender, SMSSender and WhatsAppSender
+// send it, logs each message and lists them at GET /messages.
+type Sink struct {
+	mu       sync.Mutex
+	messages []SinkMessage
+}
+
+// SinkMessage is a message the sink received
+type SinkMessage struct {
+	Message
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+func (s *Sink) record(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, SinkMessage{Message: msg, ReceivedAt: time.Now()})
+	log.Printf("sink: %s to %s: %s", msg.Channel, msg.To, strings.TrimSpace(msg.Subject+" "+msg.Body))
+}
+
+// Messages returns a copy of the messages received so far
+func (s *Sink) Messages() []SinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkMessage(nil), s.messages...)
+}
+
+// ServeSMTP accepts mail on l until it is closed. It speaks just enough SMTP
+// for net/smtp without TLS or authentication.
+func (s *Sink) ServeSMTP(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleSMTP(conn)
+	}
+}
+
+func (s *Sink) handleSMTP(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 notify-sink ready")
+	var to []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 notify-sink")
+		case strings.HasPrefix(command, "MAIL FROM"):
+			to = nil
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO"):
+			to = append(to, strings.Trim(strings.TrimSpace(line[strings.Index(line, ":")+1:]), "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(line, "\r\n") == "." {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.record(parseEmail(strings.Join(to, ", "), data.String()))
+			reply("250 OK")
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// parseEmail reads the subject, text and attachments out of a raw email
+func parseEmail(to, data string) Message {
+	msg := Message{Channel: Email, To: to, Body: data}
+	parsed, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		return msg
+	}
+	msg.Subject = parsed.Header.Get("Subject")
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, _ := io.ReadAll(parsed.Body)
+		msg.Body = string(body)
+		return msg
+	}
+
+	msg.Body = ""
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return msg
+		}
+		content, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			content, _ = base64.StdEncoding.DecodeString(strings.NewReplacer("\r", "", "\n", "").Replace(string(content)))
+		}
+		if part.FileName() == "" && msg.Body == "" {
+			msg.Body = string(content)
+			continue
+		}
+		msg.Attachments = append(msg.Attachments, Attachment{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Data:        content,
+		})
+	}
+}
+
+// ServeHTTP accepts SMSSender posts at /sms, WhatsAppSender posts at
+// /{phone_id}/messages and lists everything received at GET /messages
+func (s *Sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/messages":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Messages())
+	case r.Method == http.MethodPost && r.URL.Path == "/sms":
+		var payload struct {
+			To   string `json:"to"`
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.record(Message{Channel: SMS, To: payload.To, Body: payload.Body})
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/messages"):
+		var payload struct {
+			To   string `json:"to"`
+			Text struct {
+				Body string `json:"body"`
+			} `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.record(Message{Channel: WhatsApp, To: payload.To, Body: payload.Text.Body})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]string{{"id": "sink-" + time.Now().Format("20060102150405.000000")}},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
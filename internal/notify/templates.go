@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Events with built-in message templates
+const (
+	BookingConfirmation = "booking_confirmation"
+	BookingReminder     = "booking_reminder"
+	BookingRescheduled  = "booking_rescheduled"
+	BookingCancellation = "booking_cancellation"
+	VehicleServiceDue   = "vehicle_service_due"
+)
+
+// BookingDetails is the data booking templates are rendered with
+type BookingDetails struct {
+	CustomerName      string
+	OrganizationName  string
+	OrganizationPhone string
+	Services          string
+	Staff             string
+	When              string // Start time formatted in the organization's timezone
+	ManageURL         string
+}
+
+// VehicleServiceDetails is the data vehicle service reminders are rendered with
+type VehicleServiceDetails struct {
+	CustomerName      string
+	OrganizationName  string
+	OrganizationPhone string
+	Vehicle           string // Make, model and registration
+	Service           string
+	DueDate           string // Blank when the service isn't due by date
+	DueMileage        string // Blank when the service isn't due by distance
+}
+
+// Template is the subject and body of a message. Subject is only used for email.
+type Template struct {
+	Subject string
+	Body    string
+}
+
+// templates holds the built-in templates by event and channel. WhatsApp uses
+// the SMS wording when it has none of its own.
+var templates = map[string]map[string]Template{
+	BookingConfirmation: {
+		Email: {
+			Subject: "Your booking with {{.OrganizationName}} is confirmed",
+			Body: `Hi {{.CustomerName}},
+
+Your booking is confirmed.
+
+What: {{.Services}}
+When: {{.When}}{{if .Staff}}
+With: {{.Staff}}{{end}}
+{{if .ManageURL}}
+To reschedule or cancel, visit {{.ManageURL}}
+{{end}}
+{{.OrganizationName}}{{if .OrganizationPhone}}
+{{.OrganizationPhone}}{{end}}
+`,
+		},
+		SMS: {
+			Body: `{{.OrganizationName}}: booking confirmed for {{.Services}} on {{.When}}.{{if .ManageURL}} Manage: {{.ManageURL}}{{end}}`,
+		},
+	},
+	BookingReminder: {
+		Email: {
+			Subject: "Reminder: your booking with {{.OrganizationName}}",
+			Body: `Hi {{.CustomerName}},
+
+This is a reminder of your upcoming booking.
+
+What: {{.Services}}
+When: {{.When}}{{if .Staff}}
+With: {{.Staff}}{{end}}
+{{if .ManageURL}}
+Can't make it? Reschedule or cancel at {{.ManageURL}}
+{{end}}
+{{.OrganizationName}}{{if .OrganizationPhone}}
+{{.OrganizationPhone}}{{end}}
+`,
+		},
+		SMS: {
+			Body: `Reminder from {{.OrganizationName}}: {{.Services}} on {{.When}}.{{if .ManageURL}} Reschedule or cancel: {{.ManageURL}}{{end}}`,
+		},
+	},
+	BookingRescheduled: {
+		Email: {
+			Subject: "Your booking with {{.OrganizationName}} has moved",
+			Body: `Hi {{.CustomerName}},
+
+Your booking has moved to a new time.
+
+What: {{.Services}}
+When: {{.When}}{{if .Staff}}
+With: {{.Staff}}{{end}}
+{{if .ManageURL}}
+To reschedule or cancel, visit {{.ManageURL}}
+{{end}}
+{{.OrganizationName}}{{if .OrganizationPhone}}
+{{.OrganizationPhone}}{{end}}
+`,
+		},
+		SMS: {
+			Body: `{{.OrganizationName}}: your {{.Services}} booking has moved to {{.When}}.{{if .ManageURL}} Manage: {{.ManageURL}}{{end}}`,
+		},
+	},
+	BookingCancellation: {
+		Email: {
+			Subject: "Your booking with {{.OrganizationName}} has been cancelled",
+			Body: `Hi {{.CustomerName}},
+
+Your booking for {{.Services}} on {{.When}} has been cancelled.
+
+{{.OrganizationName}}{{if .OrganizationPhone}}
+{{.OrganizationPhone}}{{end}}
+`,
+		},
+		SMS: {
+			Body: `{{.OrganizationName}}: your {{.Services}} booking on {{.When}} has been cancelled.`,
+		},
+	},
+	VehicleServiceDue: {
+		Email: {
+			Subject: "Your {{.Vehicle}} is due for a service",
+			Body: `Hi {{.CustomerName}},
+
+Your {{.Vehicle}} is coming up for its {{.Service}}.
+{{if .DueDate}}
+Due by: {{.DueDate}}{{end}}{{if .DueMileage}}
+Due at: {{.DueMileage}} km{{end}}{{if and .DueDate .DueMileage}}
+(whichever comes first){{end}}
+
+Get in touch to book it in.
+
+{{.OrganizationName}}{{if .OrganizationPhone}}
+{{.OrganizationPhone}}{{end}}
+`,
+		},
+		SMS: {
+			Body: `{{.OrganizationName}}: your {{.Vehicle}} is due for its {{.Service}}{{if .DueDate}} by {{.DueDate}}{{end}}{{if .DueMileage}}{{if .DueDate}} or{{end}} at {{.DueMileage}} km{{end}}. Get in touch to book it in.`,
+		},
+	},
+}
+
+// Render builds the message for an event on a channel
+func Render(event, channel, to string, data interface{}) (Message, error) {
+	byChannel, ok := templates[event]
+	if !ok {
+		return Message{}, fmt.Errorf("no templates for %s", event)
+	}
+	tmpl, ok := byChannel[channel]
+	if !ok && channel == WhatsApp {
+		tmpl, ok = byChannel[SMS]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("no %s template for %s", channel, event)
+	}
+
+	msg := Message{Channel: channel, To: to}
+	var err error
+	if msg.Subject, err = execute(event+".subject", tmpl.Subject, data); err != nil {
+		return Message{}, err
+	}
+	if msg.Body, err = execute(event+".body", tmpl.Body, data); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func execute(name, text string, data interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,181 @@
+// Package payments takes money from customers through a card payment
+// provider. Providers sit behind Provider so the booking code doesn't depend
+// on any one of them; Fake stands in for a real provider in tests and
+// development.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
+)
+
+// Payment statuses reported by providers
+const (
+	Pending   = "pending"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// ErrDeclined is returned when the provider refuses a charge or refund
+var ErrDeclined = errors.New("payment declined")
+
+// Request asks a provider to take an amount from a customer
+type Request struct {
+	Amount        float64
+	Currency      string
+	Description   string
+	Reference     string // Our ID for the payment; providers use it to ignore repeated requests
+	CustomerEmail string
+	// PaymentMethod is a method saved by an earlier payment. When set the
+	// amount is charged straight away; otherwise the customer is sent to a
+	// checkout page to pay.
+	PaymentMethod string
+}
+
+// Result is a provider's view of a payment or refund
+type Result struct {
+	Ref           string // Provider's ID for the payment
+	Status        string
+	CheckoutURL   string // Where the customer completes a pending payment
+	PaymentMethod string // Saved method that can be charged later without the customer
+	FailureReason string
+}
+
+// Provider is a card payment service
+type Provider interface {
+	// Name identifies the provider on stored payments
+	Name() string
+	// Create starts a payment, or charges a saved payment method
+	Create(ctx context.Context, req Request) (*Result, error)
+	// Get returns the current state of a payment
+	Get(ctx context.Context, ref string) (*Result, error)
+	// Refund returns part or all of a successful payment
+	Refund(ctx context.Context, ref string, amount float64) (*Result, error)
+}
+
+// FromConfig returns the provider named by PAYMENT_PROVIDER, or nil when
+// payments aren't set up, in which case no deposits or fees are collected
+func FromConfig(cfg *config.Config) Provider {
+	switch cfg.PaymentProvider {
+	case "fake":
+		return &Fake{AutoComplete: true, CheckoutURL: strings.TrimRight(cfg.AppURL, "/") + "/fake-checkout"}
+	default:
+		return nil
+	}
+}
+
+// DeclinedMethod is a payment method Fake always declines
+const DeclinedMethod = "pm_card_declined"
+
+// Fake keeps payments in memory. Checkouts stay pending until Complete or
+// Fail is called, unless AutoComplete is set.
+type Fake struct {
+	AutoComplete bool
+	CheckoutURL  string
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	byRef    map[string]string
+}
+
+type fakePayment struct {
+	Result
+	amount   float64
+	refunded float64
+}
+
+// Name implements Provider
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Create implements Provider
+func (f *Fake) Create(ctx context.Context, req Request) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.payments == nil {
+		f.payments, f.byRef = make(map[string]*fakePayment), make(map[string]string)
+	}
+	if ref, ok := f.byRef[req.Reference]; ok && req.Reference != "" {
+		result := f.payments[ref].Result
+		return &result, nil
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive, got %.2f", req.Amount)
+	}
+
+	p := &fakePayment{amount: req.Amount}
+	p.Ref = fmt.Sprintf("fake_pay_%d", len(f.payments)+1)
+	switch {
+	case req.PaymentMethod == DeclinedMethod:
+		p.Status, p.FailureReason = Failed, "Card declined"
+	case req.PaymentMethod != "", f.AutoComplete:
+		p.Status, p.PaymentMethod = Succeeded, req.PaymentMethod
+		if p.PaymentMethod == "" {
+			p.PaymentMethod = "pm_fake_" + p.Ref
+		}
+	default:
+		p.Status = Pending
+		p.CheckoutURL = strings.TrimRight(f.CheckoutURL, "/") + "/" + p.Ref
+	}
+	f.payments[p.Ref] = p
+	if req.Reference != "" {
+		f.byRef[req.Reference] = p.Ref
+	}
+	result := p.Result
+	return &result, nil
+}
+
+// Get implements Provider
+func (f *Fake) Get(ctx context.Context, ref string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[ref]
+	if !ok {
+		return nil, fmt.Errorf("payment %s not found", ref)
+	}
+	result := p.Result
+	return &result, nil
+}
+
+// Refund implements Provider
+func (f *Fake) Refund(ctx context.Context, ref string, amount float64) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[ref]
+	if !ok {
+		return nil, fmt.Errorf("payment %s not found", ref)
+	}
+	if p.Status != Succeeded {
+		return nil, fmt.Errorf("%w: payment %s is %s", ErrDeclined, ref, p.Status)
+	}
+	if math.Round((p.refunded+amount)*100) > math.Round(p.amount*100) {
+		return nil, fmt.Errorf("%w: refund exceeds the amount paid", ErrDeclined)
+	}
+	p.refunded += amount
+	return &Result{Ref: fmt.Sprintf("%s_refund_%.0f", ref, math.Round(p.refunded*100)), Status: Succeeded}, nil
+}
+
+// Complete pays a pending checkout with the given saved payment method
+func (f *Fake) Complete(ref, paymentMethod string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.payments[ref]; ok && p.Status == Pending {
+		p.Status, p.PaymentMethod, p.CheckoutURL = Succeeded, paymentMethod, ""
+	}
+}
+
+// Fail declines a pending checkout
+func (f *Fake) Fail(ref, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.payments[ref]; ok && p.Status == Pending {
+		p.Status, p.FailureReason = Failed, reason
+	}
+}
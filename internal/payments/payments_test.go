@@ -0,0 +1,51 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+	fake := &Fake{CheckoutURL: "https://pay.example.com/"}
+
+	checkout, err := fake.Create(ctx, Request{Amount: 20, Currency: "AUD", Reference: "deposit-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Pending, checkout.Status)
+	assert.Equal(t, "https://pay.example.com/"+checkout.Ref, checkout.CheckoutURL)
+
+	// Repeating a request returns the same payment
+	again, _ := fake.Create(ctx, Request{Amount: 20, Currency: "AUD", Reference: "deposit-1"})
+	assert.Equal(t, checkout.Ref, again.Ref)
+
+	_, err = fake.Refund(ctx, checkout.Ref, 20)
+	assert.True(t, errors.Is(err, ErrDeclined))
+
+	fake.Complete(checkout.Ref, "pm_card_visa")
+	paid, _ := fake.Get(ctx, checkout.Ref)
+	assert.Equal(t, Succeeded, paid.Status)
+	assert.Equal(t, "pm_card_visa", paid.PaymentMethod)
+
+	refund, err := fake.Refund(ctx, checkout.Ref, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, Succeeded, refund.Status)
+	_, err = fake.Refund(ctx, checkout.Ref, 10)
+	assert.True(t, errors.Is(err, ErrDeclined))
+
+	charge, err := fake.Create(ctx, Request{Amount: 30, Reference: "fee-1", PaymentMethod: "pm_card_visa"})
+	assert.NoError(t, err)
+	assert.Equal(t, Succeeded, charge.Status)
+
+	declined, err := fake.Create(ctx, Request{Amount: 30, Reference: "fee-2", PaymentMethod: DeclinedMethod})
+	assert.NoError(t, err)
+	assert.Equal(t, Failed, declined.Status)
+	assert.Equal(t, "Card declined", declined.FailureReason)
+
+	auto := &Fake{AutoComplete: true}
+	instant, _ := auto.Create(ctx, Request{Amount: 20, Reference: "deposit-2"})
+	assert.Equal(t, Succeeded, instant.Status)
+	assert.NotEmpty(t, instant.PaymentMethod)
+}
@@ -0,0 +1,364 @@
+// Package pdf writes simple PDF documents using the standard Helvetica fonts.
+// Pages are written to the underlying writer as soon as they are finished so
+// long documents are never held in memory.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Reserved object numbers; everything else is allocated as the document is written
+const (
+	catalogID   = 1
+	pagesID     = 2
+	resourcesID = 3
+	fontID      = 4
+	boldFontID  = 5
+	firstFreeID = 6
+)
+
+// Color is an RGB colour
+type Color struct {
+	R, G, B uint8
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+	Gray  = Color{110, 110, 110}
+)
+
+// ParseHexColor parses colours such as "#667eea", returning fallback when the value is invalid
+func ParseHexColor(value string, fallback Color) Color {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return fallback
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return fallback
+	}
+	return Color{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// Document is a PDF being written. Coordinates are in points with the origin
+// at the top left of the page.
+type Document struct {
+	Width, Height float64
+
+	out     *countingWriter
+	offsets map[int]int64
+	nextID  int
+	pages   []int
+	images  []int
+	content *bytes.Buffer
+	bold    bool
+	size    float64
+	err     error
+}
+
+// New starts a document with the given page size
+func New(w io.Writer, width, height float64) *Document {
+	d := &Document{
+		Width:   width,
+		Height:  height,
+		out:     &countingWriter{w: bufio.NewWriter(w)},
+		offsets: make(map[int]int64),
+		nextID:  firstFreeID,
+		size:    10,
+	}
+
+	d.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	d.writeObject(fontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	d.writeObject(boldFontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	return d
+}
+
+func (d *Document) printf(format string, args ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.out, format, args...)
+}
+
+func (d *Document) allocID() int {
+	id := d.nextID
+	d.nextID++
+	return id
+}
+
+func (d *Document) writeObject(id int, body string) {
+	d.offsets[id] = d.out.n
+	d.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (d *Document) writeStream(id int, dict string, data []byte) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	d.offsets[id] = d.out.n
+	d.printf("%d 0 obj\n<< %s /Filter /FlateDecode /Length %d >>\nstream\n", id, dict, compressed.Len())
+	if d.err == nil {
+		_, d.err = d.out.Write(compressed.Bytes())
+	}
+	d.printf("\nendstream\nendobj\n")
+}
+
+// AddPage finishes the current page, if any, and starts a new one
+func (d *Document) AddPage() {
+	d.flushPage()
+	d.content = &bytes.Buffer{}
+}
+
+// PageCount returns the number of pages started so far
+func (d *Document) PageCount() int {
+	count := len(d.pages)
+	if d.content != nil {
+		count++
+	}
+	return count
+}
+
+func (d *Document) flushPage() {
+	if d.content == nil {
+		return
+	}
+	contentID := d.allocID()
+	d.writeStream(contentID, "", d.content.Bytes())
+
+	pageID := d.allocID()
+	d.writeObject(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %d 0 R /Contents %d 0 R >>",
+		pagesID, num(d.Width), num(d.Height), resourcesID, contentID))
+	d.pages = append(d.pages, pageID)
+	d.content = nil
+	d.out.w.Flush()
+}
+
+func (d *Document) draw(format string, args ...interface{}) {
+	if d.content == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.content, format, args...)
+	d.content.WriteByte('\n')
+}
+
+// SetFont selects regular or bold Helvetica at the given size
+func (d *Document) SetFont(bold bool, size float64) {
+	d.bold = bold
+	d.size = size
+}
+
+// SetFillColor sets the colour used for text and filled shapes
+func (d *Document) SetFillColor(c Color) {
+	d.draw("%s %s %s rg", colorComponent(c.R), colorComponent(c.G), colorComponent(c.B))
+}
+
+// SetStrokeColor sets the colour used for lines and outlines
+func (d *Document) SetStrokeColor(c Color) {
+	d.draw("%s %s %s RG", colorComponent(c.R), colorComponent(c.G), colorComponent(c.B))
+}
+
+// Text draws a string with its baseline at y
+func (d *Document) Text(x, y float64, text string) {
+	font := "F1"
+	if d.bold {
+		font = "F2"
+	}
+	d.draw("BT /%s %s Tf %s %s Td (%s) Tj ET", font, num(d.size), num(x), num(d.Height-y), escape(encode(text)))
+}
+
+// TextRight draws a string whose right edge is at x
+func (d *Document) TextRight(x, y float64, text string) {
+	d.Text(x-d.TextWidth(text), y, text)
+}
+
+// TextWidth returns the width of text in the current font
+func (d *Document) TextWidth(text string) float64 {
+	widths := &helveticaWidths
+	if d.bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	encoded := encode(text)
+	for i := 0; i < len(encoded); i++ {
+		if ch := encoded[i]; ch >= 32 && ch <= 126 {
+			total += widths[ch-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * d.size / 1000
+}
+
+// Fit shortens text with an ellipsis so it fits within width
+func (d *Document) Fit(text string, width float64) string {
+	if d.TextWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && d.TextWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// Rect draws a rectangle, filled with the fill colour or outlined with the stroke colour
+func (d *Document) Rect(x, y, width, height float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	d.draw("%s %s %s %s re %s", num(x), num(d.Height-y-height), num(width), num(height), op)
+}
+
+// Line draws a line in the stroke colour
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	d.draw("%s w %s %s m %s %s l S", num(width), num(x1), num(d.Height-y1), num(x2), num(d.Height-y2))
+}
+
+// AddImage embeds img in the document and returns a reference for DrawImage.
+// Transparent pixels are flattened onto white.
+func (d *Document) AddImage(img image.Image) int {
+	bounds := img.Bounds()
+	pixels := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			r, g, b, a := img.At(px, py).RGBA()
+			pixels = append(pixels, flatten(r, a), flatten(g, a), flatten(b, a))
+		}
+	}
+
+	imageID := d.allocID()
+	d.writeStream(imageID, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		bounds.Dx(), bounds.Dy()), pixels)
+	d.images = append(d.images, imageID)
+	return len(d.images)
+}
+
+// DrawImage draws an image added with AddImage scaled into the given box
+func (d *Document) DrawImage(ref int, x, y, width, height float64) {
+	d.draw("q %s 0 0 %s %s %s cm /Im%d Do Q", num(width), num(height), num(x), num(d.Height-y-height), ref)
+}
+
+// Close writes the remaining pages and the document trailer
+func (d *Document) Close() error {
+	if len(d.pages) == 0 && d.content == nil {
+		d.AddPage()
+	}
+	d.flushPage()
+
+	var xobjects strings.Builder
+	for i, id := range d.images {
+		fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i+1, id)
+	}
+	d.writeObject(resourcesID, fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R >> /XObject <<%s >> >>", fontID, boldFontID, xobjects.String()))
+
+	kids := make([]string, len(d.pages))
+	for i, id := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	d.writeObject(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	d.writeObject(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	xref := d.out.n
+	d.printf("xref\n0 %d\n0000000000 65535 f \n", d.nextID)
+	for id := 1; id < d.nextID; id++ {
+		d.printf("%010d 00000 n \n", d.offsets[id])
+	}
+	d.printf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", d.nextID, catalogID, xref)
+
+	if d.err != nil {
+		return d.err
+	}
+	return d.out.w.Flush()
+}
+
+func flatten(channel, alpha uint32) byte {
+	return byte((channel + (0xffff - alpha)) >> 8)
+}
+
+func colorComponent(v uint8) string {
+	return num(float64(v) / 255)
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// winAnsi maps the punctuation commonly pasted from word processors to WinAnsiEncoding
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// encode converts text to WinAnsiEncoding bytes, replacing unsupported characters
+func encode(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			b.WriteByte(' ')
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		case winAnsi[r] != 0:
+			b.WriteByte(winAnsi[r])
+		case r < 32:
+			// drop control characters
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
+
+// Character widths for ASCII 32-126 from the Adobe font metrics
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
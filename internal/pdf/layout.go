@@ -0,0 +1,295 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	margin       = 40.0
+	headerHeight = 70.0
+	footerHeight = 30.0
+	rowHeight    = 16.0
+)
+
+// Letterhead is the organization branding printed on every page
+type Letterhead struct {
+	OrganizationName string
+	Details          []string // Address, ABN and contact lines shown under the name
+	Title            string
+	Subtitle         string
+	Footer           string
+	Primary          Color
+	Logo             image.Image
+}
+
+// Branded is a document whose pages carry the organization letterhead and footer
+type Branded struct {
+	*Document
+	head    Letterhead
+	logoRef int
+}
+
+// NewBranded starts an A4 document with the given letterhead
+func NewBranded(w io.Writer, head Letterhead) *Branded {
+	b := &Branded{Document: New(w, A4Width, A4Height), head: head}
+	if head.Logo != nil && head.Logo.Bounds().Dx() > 0 && head.Logo.Bounds().Dy() > 0 {
+		b.logoRef = b.AddImage(head.Logo)
+	}
+	return b
+}
+
+// ContentWidth is the usable width between the page margins
+func (b *Branded) ContentWidth() float64 {
+	return b.Width - 2*margin
+}
+
+// Bottom is the lowest y position body content may use
+func (b *Branded) Bottom() float64 {
+	return b.Height - margin - footerHeight
+}
+
+// NewPage starts a page, draws the letterhead and footer, and returns the y
+// position where body content starts
+func (b *Branded) NewPage() float64 {
+	b.AddPage()
+
+	x := margin
+	if b.logoRef > 0 {
+		bounds := b.head.Logo.Bounds()
+		height := 40.0
+		width := height * float64(bounds.Dx()) / float64(bounds.Dy())
+		if width > 120 {
+			width = 120
+			height = width * float64(bounds.Dy()) / float64(bounds.Dx())
+		}
+		b.DrawImage(b.logoRef, x, margin, width, height)
+		x += width + 12
+	}
+
+	b.SetFillColor(b.head.Primary)
+	b.SetFont(true, 15)
+	b.Text(x, margin+14, b.Fit(b.head.OrganizationName, b.Width/2-x))
+	b.SetFillColor(Gray)
+	b.SetFont(false, 8)
+	for i, line := range b.head.Details {
+		if i == 3 {
+			break
+		}
+		b.Text(x, margin+27+float64(i)*10, b.Fit(line, b.Width/2-x))
+	}
+
+	right := b.Width - margin
+	b.SetFillColor(Black)
+	b.SetFont(true, 14)
+	b.TextRight(right, margin+14, b.head.Title)
+	if b.head.Subtitle != "" {
+		b.SetFillColor(Gray)
+		b.SetFont(false, 9)
+		b.TextRight(right, margin+28, b.head.Subtitle)
+	}
+
+	b.SetStrokeColor(b.head.Primary)
+	b.Line(margin, margin+headerHeight-10, right, margin+headerHeight-10, 2)
+
+	// Footer
+	footerY := b.Height - margin
+	b.SetStrokeColor(Color{210, 210, 210})
+	b.Line(margin, footerY-14, right, footerY-14, 0.5)
+	b.SetFillColor(Gray)
+	b.SetFont(false, 8)
+	page := fmt.Sprintf("Page %d", b.PageCount())
+	b.TextRight(right, footerY, page)
+	if b.head.Footer != "" {
+		footer := strings.Join(strings.Fields(b.head.Footer), " ")
+		b.Text(margin, footerY, b.Fit(footer, b.ContentWidth()-b.TextWidth(page)-20))
+	}
+
+	b.SetFillColor(Black)
+	return margin + headerHeight + 10
+}
+
+// Align controls the horizontal alignment of a table column
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Column describes a table column; widths are relative to the other columns
+type Column struct {
+	Header string
+	Width  float64
+	Align  Align
+}
+
+// Table draws rows across as many pages as needed, repeating the header on each page
+type Table struct {
+	doc    *Branded
+	cols   []Column
+	x      []float64
+	widths []float64
+	y      float64
+	rows   int
+	accent Color
+}
+
+// NewTable starts a table at y, drawing its header row
+func (b *Branded) NewTable(y float64, cols []Column) *Table {
+	t := &Table{doc: b, cols: cols, accent: b.head.Primary}
+
+	total := 0.0
+	for _, col := range cols {
+		total += col.Width
+	}
+	x := margin
+	for _, col := range cols {
+		width := b.ContentWidth() * col.Width / total
+		t.x = append(t.x, x)
+		t.widths = append(t.widths, width)
+		x += width
+	}
+
+	t.y = y
+	t.drawHeader()
+	return t
+}
+
+// Y returns the position just below the last row drawn
+func (t *Table) Y() float64 {
+	return t.y
+}
+
+func (t *Table) drawHeader() {
+	d := t.doc
+	d.SetFillColor(t.accent)
+	d.Rect(margin, t.y, d.ContentWidth(), rowHeight+2, true)
+	d.SetFillColor(White)
+	d.SetFont(true, 8.5)
+	for i, col := range t.cols {
+		t.cell(i, t.y+12, col.Header)
+	}
+	d.SetFillColor(Black)
+	t.y += rowHeight + 2
+}
+
+func (t *Table) cell(i int, baseline float64, text string) {
+	if text == "" {
+		return
+	}
+	d := t.doc
+	text = d.Fit(text, t.widths[i]-8)
+	if t.cols[i].Align == AlignRight {
+		d.TextRight(t.x[i]+t.widths[i]-4, baseline, text)
+		return
+	}
+	d.Text(t.x[i]+4, baseline, text)
+}
+
+// Row draws a row, starting a new page first when the current one is full
+func (t *Table) Row(values []string) {
+	d := t.doc
+	if t.y+rowHeight > d.Bottom() {
+		t.y = d.NewPage()
+		t.drawHeader()
+	}
+
+	if t.rows%2 == 1 {
+		d.SetFillColor(Color{245, 246, 248})
+		d.Rect(margin, t.y, d.ContentWidth(), rowHeight, true)
+		d.SetFillColor(Black)
+	}
+	d.SetFont(false, 8.5)
+	for i := range t.cols {
+		if i < len(values) {
+			t.cell(i, t.y+11, values[i])
+		}
+	}
+	t.y += rowHeight
+	t.rows++
+}
+
+// Ensure starts a new page when fewer than height points remain and returns the y to draw at
+func (t *Table) Ensure(height float64) float64 {
+	if t.y+height > t.doc.Bottom() {
+		t.y = t.doc.NewPage()
+	}
+	return t.y
+}
+
+// TableReport is a branded document containing a single table, written row by row
+type TableReport struct {
+	*Branded
+	table *Table
+}
+
+// NewTableReport starts a report and draws the first page and table header
+func NewTableReport(w io.Writer, head Letterhead, cols []Column) *TableReport {
+	r := &TableReport{Branded: NewBranded(w, head)}
+	r.table = r.NewTable(r.NewPage(), cols)
+	return r
+}
+
+// WriteRow adds a row to the report. Values are formatted with FormatValue.
+func (r *TableReport) WriteRow(values []interface{}) error {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = FormatValue(value)
+	}
+	r.table.Row(formatted)
+	return r.err
+}
+
+// FormatValue renders a cell value for display
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return FormatAmount(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("02/01/2006")
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return FormatValue(*v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FormatAmount formats a number with two decimals and thousands separators
+func FormatAmount(amount float64) string {
+	text := strconv.FormatFloat(amount, 'f', 2, 64)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+
+	whole, fraction := text[:len(text)-3], text[len(text)-3:]
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+
+	if negative {
+		return "-" + b.String() + fraction
+	}
+	return b.String() + fraction
+}
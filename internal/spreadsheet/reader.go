@@ -0,0 +1,171 @@
+// Package spreadsheet reads and writes tabular files (CSV and XLSX) without
+// pulling in an office-format dependency.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxFileSize is the largest file ReadRows will read
+const MaxFileSize = 20 << 20
+
+// maxPartSize caps how much of each XLSX part is decompressed, so a small
+// workbook can't expand without limit
+const maxPartSize = 200 << 20
+
+// ErrTooLarge is returned for files over MaxFileSize
+var ErrTooLarge = errors.New("spreadsheet file is too large")
+
+// ReadRows returns every row of a CSV file or the first worksheet of an XLSX
+// workbook. The format is chosen from the filename extension.
+func ReadRows(r io.Reader, filename string) ([][]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, ErrTooLarge
+	}
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".xlsx":
+		return readXLSX(data)
+	case ".csv", ".txt", "":
+		return readCSV(data)
+	default:
+		return nil, fmt.Errorf("unsupported spreadsheet format %q", path.Ext(filename))
+	}
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	var shared []string
+	var sheet *zip.File
+	for _, f := range archive.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			var sst xlsxSharedStrings
+			if err := decodeZipXML(f, &sst); err != nil {
+				return nil, err
+			}
+			for _, item := range sst.Items {
+				text := item.Text
+				for _, run := range item.Runs {
+					text += run.Text
+				}
+				shared = append(shared, text)
+			}
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && strings.HasSuffix(f.Name, ".xml"):
+			if sheet == nil || f.Name < sheet.Name {
+				sheet = f
+			}
+		}
+	}
+	if sheet == nil {
+		return nil, fmt.Errorf("xlsx file contains no worksheets")
+	}
+
+	var ws xlsxWorksheet
+	if err := decodeZipXML(sheet, &ws); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(ws.Rows))
+	for _, row := range ws.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if idx, err := strconv.Atoi(cell.Value); err == nil && idx < len(shared) {
+					value = shared[idx]
+				}
+			case "inlineStr":
+				value = cell.Inline.Text
+			}
+			values[col] = value
+		}
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > maxPartSize {
+		return fmt.Errorf("%s: %w", f.Name, ErrTooLarge)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// The size in the zip header can't be trusted, so stop reading at the cap too
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex converts a cell reference such as "AB12" to a zero-based column index
+func columnIndex(ref string) int {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,83 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRowsCSV(t *testing.T) {
+	rows, err := ReadRows(strings.NewReader("\ufeffSupport Item Number,Support Item Name, Price\n01_011_0107_1_1,\"Assistance, weekday\",70.23\nshort\n"), "catalogue.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Support Item Number", "Support Item Name", "Price"},
+		{"01_011_0107_1_1", "Assistance, weekday", "70.23"},
+		{"short"},
+	}, rows)
+
+	_, err = ReadRows(strings.NewReader("a,\"b\n"), "broken.csv")
+	assert.Error(t, err)
+}
+
+func TestReadRowsXLSX(t *testing.T) {
+	t.Run("Workbooks written by the export writer read back", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer, err := NewXLSXWriter(&buf, "Shifts", []string{"Participant", "Hours"})
+		assert.NoError(t, err)
+		assert.NoError(t, writer.WriteRow([]interface{}{"Jane Citizen", 2.5}))
+		assert.NoError(t, writer.Close())
+
+		rows, err := ReadRows(&buf, "shifts.XLSX")
+		assert.NoError(t, err)
+		assert.Equal(t, "Participant", rows[0][0])
+		assert.Equal(t, "Jane Citizen", rows[1][0])
+		assert.Equal(t, "2.5", rows[1][1])
+	})
+
+	t.Run("Shared strings, rich text and skipped cells", func(t *testing.T) {
+		rows, err := ReadRows(bytes.NewReader(buildXLSX(t, map[string]string{
+			"xl/sharedStrings.xml": `<sst><si><t>Item</t></si><si><r><t>Week</t></r><r><t>day</t></r></si></sst>`,
+			"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row><c r="A1"><v>ignored</v></c></row></sheetData></worksheet>`,
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+				`<row><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+				`<row><c r="B2" t="inlineStr"><is><t>inline</t></is></c><c r="AA2"><v>42</v></c></row>` +
+				`</sheetData></worksheet>`,
+		})), "catalogue.xlsx")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Item", "", "Weekday"}, rows[0])
+		assert.Len(t, rows[1], 27)
+		assert.Equal(t, "inline", rows[1][1])
+		assert.Equal(t, "42", rows[1][26])
+	})
+
+	t.Run("Files that aren't workbooks are rejected", func(t *testing.T) {
+		_, err := ReadRows(strings.NewReader("not a zip"), "catalogue.xlsx")
+		assert.Error(t, err)
+		_, err = ReadRows(bytes.NewReader(buildXLSX(t, map[string]string{"xl/workbook.xml": "<workbook/>"})), "empty.xlsx")
+		assert.Error(t, err)
+	})
+}
+
+func TestReadRowsLimits(t *testing.T) {
+	_, err := ReadRows(bytes.NewReader(make([]byte, MaxFileSize+1)), "huge.csv")
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = ReadRows(strings.NewReader("a,b"), "catalogue.pdf")
+	assert.Error(t, err)
+}
+
+// buildXLSX zips the given parts into a workbook
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(body))
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
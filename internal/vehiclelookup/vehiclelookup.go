@@ -0,0 +1,160 @@
+// Package vehiclelookup works out what a vehicle is from its VIN or
+// registration so staff don't have to type it in. VINs are decoded offline;
+// registrations are looked up with a state or territory registry through
+// Registry, which isn't set up by default. FakeRegistry stands in for a real
+// registry in tests and development.
+package vehiclelookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenkinoti/gofiber-das-crm-backend/internal/config"
+)
+
+var (
+	// ErrInvalidVIN is returned for VINs that can't be right
+	ErrInvalidVIN = errors.New("invalid VIN")
+	// ErrNotFound is returned when a registry has no vehicle with a registration
+	ErrNotFound = errors.New("vehicle not found")
+	// ErrNoRegistry is returned for registration lookups when no registry is set up
+	ErrNoRegistry = errors.New("registration lookups aren't set up")
+	// ErrUnknownState is returned for registrations without a valid state or territory
+	ErrUnknownState = errors.New("unknown state")
+)
+
+// States are the registering states and territories
+var States = []string{"ACT", "NSW", "NT", "QLD", "SA", "TAS", "VIC", "WA"}
+
+// Details is what's known about a vehicle. Fields that couldn't be worked
+// out are left empty.
+type Details struct {
+	VIN             string `json:"vin,omitempty"`
+	Make            string `json:"make,omitempty"`
+	Model           string `json:"model,omitempty"`
+	Year            int    `json:"year,omitempty"`
+	Color           string `json:"color,omitempty"`
+	Country         string `json:"country,omitempty"` // Where the VIN was issued
+	LicensePlate    string `json:"license_plate,omitempty"`
+	State           string `json:"state,omitempty"`
+	CheckDigitValid bool   `json:"check_digit_valid"` // Only required of North American VINs
+	Source          string `json:"source"`            // vin, or the registry's name
+}
+
+// Registry is a state or territory registration lookup service
+type Registry interface {
+	// Name identifies the registry on looked up details
+	Name() string
+	// LookupPlate finds a vehicle by registration, returning ErrNotFound
+	// when there isn't one
+	LookupPlate(ctx context.Context, plate, state string) (*Details, error)
+}
+
+// Lookup finds vehicle details by VIN or registration
+type Lookup struct {
+	Registry Registry         // nil when registration lookups aren't set up
+	Now      func() time.Time // Model years are decoded relative to now; defaults to time.Now
+}
+
+// FromConfig returns a lookup using the registry named by
+// REGO_LOOKUP_PROVIDER. VINs are always decoded offline.
+func FromConfig(cfg *config.Config) *Lookup {
+	switch cfg.RegoLookupProvider {
+	case "fake":
+		return &Lookup{Registry: &FakeRegistry{}}
+	default:
+		return &Lookup{}
+	}
+}
+
+// NormalizePlate upper-cases a registration and drops spaces and dashes
+func NormalizePlate(plate string) string {
+	return NormalizeVIN(plate)
+}
+
+// VIN decodes a VIN
+func (l *Lookup) VIN(vin string) (*Details, error) {
+	now := time.Now
+	if l.Now != nil {
+		now = l.Now
+	}
+	details, err := DecodeVIN(vin, now())
+	if err != nil {
+		return nil, err
+	}
+	details.Source = "vin"
+	return details, nil
+}
+
+// Plate looks a registration up with the registry, filling in anything the
+// registry left out from the VIN it returns
+func (l *Lookup) Plate(ctx context.Context, plate, state string) (*Details, error) {
+	if l.Registry == nil {
+		return nil, ErrNoRegistry
+	}
+	plate, state = NormalizePlate(plate), strings.ToUpper(strings.TrimSpace(state))
+	if plate == "" {
+		return nil, errors.New("registration is required")
+	}
+	valid := false
+	for _, s := range States {
+		valid = valid || s == state
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w %q", ErrUnknownState, state)
+	}
+
+	details, err := l.Registry.LookupPlate(ctx, plate, state)
+	if err != nil {
+		return nil, err
+	}
+	details.LicensePlate, details.State, details.Source = plate, state, l.Registry.Name()
+	if details.VIN != "" {
+		if decoded, err := l.VIN(details.VIN); err == nil {
+			details.VIN, details.CheckDigitValid, details.Country = decoded.VIN, decoded.CheckDigitValid, decoded.Country
+			if details.Make == "" {
+				details.Make = decoded.Make
+			}
+			if details.Year == 0 {
+				details.Year = decoded.Year
+			}
+		}
+	}
+	return details, nil
+}
+
+// FakeRegistry keeps registrations in memory
+type FakeRegistry struct {
+	mu       sync.Mutex
+	vehicles map[string]Details
+}
+
+// Add registers a vehicle with the fake registry
+func (f *FakeRegistry) Add(plate, state string, details Details) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.vehicles == nil {
+		f.vehicles = make(map[string]Details)
+	}
+	f.vehicles[strings.ToUpper(state)+":"+NormalizePlate(plate)] = details
+}
+
+// Name implements Registry
+func (f *FakeRegistry) Name() string {
+	return "fake"
+}
+
+// LookupPlate implements Registry
+func (f *FakeRegistry) LookupPlate(ctx context.Context, plate, state string) (*Details, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	details, ok := f.vehicles[state+":"+plate]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &details, nil
+}
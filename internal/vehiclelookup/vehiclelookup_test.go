@@ -0,0 +1,69 @@
+package vehiclelookup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeVIN(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	details, err := DecodeVIN("5yj3e1ea6lf000001", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "5YJ3E1EA6LF000001", details.VIN)
+	assert.Equal(t, "Tesla", details.Make)
+	assert.Equal(t, "United States", details.Country)
+	assert.Equal(t, 2020, details.Year)
+	assert.True(t, details.CheckDigitValid)
+
+	// A numeric position 7 puts North American VINs in the earlier cycle
+	details, err = DecodeVIN("1M8GDM9AXKP042788", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1989, details.Year)
+
+	details, err = DecodeVIN("6T1 BF3FK7 RX012345", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Toyota", details.Make)
+	assert.Equal(t, "Australia", details.Country)
+	assert.Equal(t, 2024, details.Year)
+
+	// Check digits are only required in North America
+	details, err = DecodeVIN("JTDBR32E720012345", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Toyota", details.Make)
+	assert.Equal(t, 2002, details.Year)
+	assert.False(t, details.CheckDigitValid)
+
+	for _, vin := range []string{"5YJ3E1EA7LF000001", "5YJ3E1EA6LF00000", "5YJ3E1EA6LF00000I", "5YJ3E1EA6UF000001"} {
+		_, err := DecodeVIN(vin, now)
+		assert.True(t, errors.Is(err, ErrInvalidVIN), vin)
+	}
+}
+
+func TestLookupPlate(t *testing.T) {
+	ctx := context.Background()
+	lookup := &Lookup{}
+	_, err := lookup.Plate(ctx, "ABC123", "NSW")
+	assert.True(t, errors.Is(err, ErrNoRegistry))
+
+	registry := &FakeRegistry{}
+	registry.Add("ABC-123", "nsw", Details{VIN: "6T1BF3FK7RX012345", Model: "Camry", Color: "White"})
+	lookup = &Lookup{Registry: registry, Now: func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }}
+
+	details, err := lookup.Plate(ctx, "abc 123", "NSW")
+	assert.NoError(t, err)
+	assert.Equal(t, "ABC123", details.LicensePlate)
+	assert.Equal(t, "Toyota", details.Make)
+	assert.Equal(t, "Camry", details.Model)
+	assert.Equal(t, 2024, details.Year)
+	assert.Equal(t, "fake", details.Source)
+
+	_, err = lookup.Plate(ctx, "ABC123", "VIC")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = lookup.Plate(ctx, "ABC123", "XYZ")
+	assert.True(t, errors.Is(err, ErrUnknownState))
+}
@@ -0,0 +1,160 @@
+package vehiclelookup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// vinChars are the characters a VIN can use; I, O and Q are left out so
+// they can't be mistaken for 1 and 0
+const vinChars = "ABCDEFGHJKLMNPRSTUVWXYZ0123456789"
+
+// yearCodes are the model year codes in position 10, starting from 1980.
+// They repeat every 30 years.
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// checkWeights weigh each position's value in the check digit
+var checkWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// manufacturers maps world manufacturer identifiers, the first three
+// characters of a VIN, to makes. Where every identifier starting with two
+// characters belongs to one make, the two character prefix is listed.
+var manufacturers = map[string]string{
+	// Australia and New Zealand
+	"6T1": "Toyota", "6FP": "Ford", "6G1": "Holden", "6H8": "Holden", "6MM": "Mitsubishi",
+	// Japan
+	"JT": "Toyota", "JH": "Honda", "JN": "Nissan", "JM": "Mazda", "JS": "Suzuki", "JF": "Subaru",
+	"JA3": "Mitsubishi", "JA4": "Mitsubishi", "JMB": "Mitsubishi", "JMY": "Mitsubishi", "JAA": "Isuzu",
+	// Korea
+	"KM": "Hyundai", "KN": "Kia", "KPT": "SsangYong",
+	// Thailand
+	"MR0": "Toyota", "MNT": "Nissan", "MPA": "Isuzu", "MNB": "Ford", "MMB": "Mitsubishi", "MM8": "Mazda",
+	// China
+	"LSJ": "MG", "LGW": "GWM", "LVV": "Chery", "LGX": "BYD", "LC0": "BYD", "L6T": "Geely", "LRW": "Tesla", "LFV": "Volkswagen",
+	// Europe
+	"WBA": "BMW", "WBS": "BMW", "WBY": "BMW", "WMW": "MINI",
+	"WDB": "Mercedes-Benz", "WDC": "Mercedes-Benz", "WDD": "Mercedes-Benz", "WDF": "Mercedes-Benz",
+	"W1K": "Mercedes-Benz", "W1N": "Mercedes-Benz", "W1V": "Mercedes-Benz",
+	"WVW": "Volkswagen", "WVG": "Volkswagen", "WV1": "Volkswagen", "WV2": "Volkswagen",
+	"WAU": "Audi", "WUA": "Audi", "TRU": "Audi", "WP0": "Porsche", "WP1": "Porsche", "WF0": "Ford", "W0L": "Opel",
+	"VF1": "Renault", "VF3": "Peugeot", "VR3": "Peugeot", "VF7": "Citroen", "VSS": "SEAT", "TMB": "Skoda",
+	"ZFA": "Fiat", "ZAR": "Alfa Romeo", "ZFF": "Ferrari", "ZHW": "Lamborghini",
+	"SAL": "Land Rover", "SAJ": "Jaguar", "SCC": "Lotus", "SCF": "Aston Martin", "SCA": "Rolls-Royce",
+	"SJN": "Nissan", "SB1": "Toyota", "YV1": "Volvo", "YV4": "Volvo", "NMT": "Toyota",
+	// North America
+	"1FA": "Ford", "1FM": "Ford", "1FT": "Ford", "1G1": "Chevrolet", "1GC": "Chevrolet", "1J4": "Jeep", "1C6": "Ram",
+	"1HG": "Honda", "2HG": "Honda", "1N4": "Nissan", "2T1": "Toyota", "4T1": "Toyota", "5TD": "Toyota",
+	"3VW": "Volkswagen", "5YJ": "Tesla", "7SA": "Tesla",
+}
+
+// countries are the ranges of the first two characters of a VIN assigned to
+// each country, compared as plain strings
+var countries = []struct{ from, to, country string }{
+	{"10", "1Z", "United States"}, {"40", "5Z", "United States"}, {"20", "2Z", "Canada"}, {"3A", "3W", "Mexico"},
+	{"60", "6Z", "Australia"}, {"7A", "7E", "New Zealand"}, {"7F", "7Z", "United States"},
+	{"J0", "JZ", "Japan"}, {"KL", "KR", "South Korea"}, {"L0", "LZ", "China"},
+	{"MA", "ME", "India"}, {"MF", "MK", "Indonesia"}, {"ML", "MR", "Thailand"}, {"NM", "NT", "Turkey"},
+	{"SA", "SM", "United Kingdom"}, {"TJ", "TP", "Czech Republic"}, {"TR", "TV", "Hungary"},
+	{"VF", "VR", "France"}, {"VS", "VW", "Spain"}, {"W0", "WZ", "Germany"},
+	{"YA", "YE", "Belgium"}, {"YF", "YK", "Finland"}, {"YS", "YW", "Sweden"}, {"ZA", "ZR", "Italy"},
+}
+
+// NormalizeVIN upper-cases a VIN and drops spaces and dashes
+func NormalizeVIN(vin string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(strings.TrimSpace(vin)))
+}
+
+// northAmerican reports whether a VIN was issued in North America, where the
+// check digit and model year code are required
+func northAmerican(vin string) bool {
+	return strings.IndexByte("12345", vin[0]) >= 0
+}
+
+// CheckDigit works out the check digit, position 9, for a 17 character VIN
+func CheckDigit(vin string) byte {
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += transliterate(vin[i]) * checkWeights[i]
+	}
+	if sum%11 == 10 {
+		return 'X'
+	}
+	return byte('0' + sum%11)
+}
+
+// letterValues are what VIN letters count as in the check digit
+var letterValues = map[byte]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+// transliterate gives a VIN character its number for the check digit
+func transliterate(ch byte) int {
+	if ch >= '0' && ch <= '9' {
+		return int(ch - '0')
+	}
+	return letterValues[ch]
+}
+
+// modelYear decodes position 10. Outside North America makers don't all
+// follow the convention, so the year is only a best guess there.
+func modelYear(vin string, now time.Time) int {
+	i := strings.IndexByte(yearCodes, vin[9])
+	if i < 0 {
+		return 0
+	}
+	if northAmerican(vin) {
+		// Position 7 is a letter from 2010 on
+		if vin[6] >= 'A' && vin[6] <= 'Z' {
+			return 2010 + i
+		}
+		return 1980 + i
+	}
+	// The latest year the code could mean that isn't in the future
+	year := 1980 + i
+	for year+30 <= now.Year()+1 {
+		year += 30
+	}
+	return year
+}
+
+// DecodeVIN validates a VIN and works out the make, country and model year
+// from it without going to the network. Models aren't encoded in a standard
+// way, so are left for a registry to fill in.
+func DecodeVIN(vin string, now time.Time) (*Details, error) {
+	vin = NormalizeVIN(vin)
+	if len(vin) != 17 {
+		return nil, fmt.Errorf("%w: must be 17 characters, got %d", ErrInvalidVIN, len(vin))
+	}
+	for i := 0; i < len(vin); i++ {
+		if strings.IndexByte(vinChars, vin[i]) < 0 {
+			return nil, fmt.Errorf("%w: %q isn't allowed in a VIN", ErrInvalidVIN, vin[i])
+		}
+	}
+
+	details := &Details{VIN: vin, Year: modelYear(vin, now)}
+	details.CheckDigitValid = vin[8] == CheckDigit(vin)
+	if northAmerican(vin) {
+		if !details.CheckDigitValid {
+			return nil, fmt.Errorf("%w: check digit should be %c", ErrInvalidVIN, CheckDigit(vin))
+		}
+		if details.Year == 0 {
+			return nil, fmt.Errorf("%w: %q isn't a model year code", ErrInvalidVIN, vin[9])
+		}
+	}
+
+	if name, ok := manufacturers[vin[:3]]; ok {
+		details.Make = name
+	} else if name, ok := manufacturers[vin[:2]]; ok {
+		details.Make = name
+	}
+	for _, r := range countries {
+		if prefix := vin[:2]; prefix >= r.from && prefix <= r.to {
+			details.Country = r.country
+			break
+		}
+	}
+	return details, nil
+}
@@ -0,0 +1,532 @@
+package finance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Kinds of ledger item a bank statement line can be reconciled against
+const (
+	MatchPayment       = "payment"
+	MatchPOSSettlement = "pos_settlement"
+	MatchJournalLine   = "journal_line"
+)
+
+var (
+	// ErrMatchMismatch is returned when a statement line is reconciled
+	// against ledger items that don't add up to it
+	ErrMatchMismatch = errors.New("match doesn't agree with the bank transaction")
+	// ErrAlreadyReconciled is returned when a statement line or ledger item
+	// has already been reconciled
+	ErrAlreadyReconciled = errors.New("already reconciled")
+)
+
+// GetBankAccount returns one of an organization's bank accounts
+func (s *Service) GetBankAccount(orgID, bankAccountID string) (*BankAccount, error) {
+	var account BankAccount
+	if err := s.db.Where("id = ? AND organization_id = ?", bankAccountID, orgID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// bankLedgerAccount is the account a bank account is kept in on the ledger
+func (s *Service) bankLedgerAccount(db *gorm.DB, account *BankAccount) (*ChartOfAccount, error) {
+	if account.LedgerAccountID != nil && *account.LedgerAccountID != "" {
+		return s.AccountFor(db, account.OrganizationID, accountPrefix+*account.LedgerAccountID)
+	}
+	return s.AccountFor(db, account.OrganizationID, AccountBank)
+}
+
+// ImportBankStatement adds a statement's transactions to a bank account.
+// Transactions already imported, from this file or an overlapping one, are
+// recognised by the bank's transaction ID, or failing that by date, amount,
+// description and reference, and skipped. Lines without a running balance
+// get one carried on from the transactions before them.
+func (s *Service) ImportBankStatement(orgID, bankAccountID, fileName string, statement *Statement, userID string) (*BankStatementImport, []BankTransaction, error) {
+	record := &BankStatementImport{
+		ID:             uuid.New().String(),
+		OrganizationID: orgID,
+		BankAccountID:  bankAccountID,
+		Format:         statement.Format,
+		FileName:       fileName,
+		ClosingBalance: statement.ClosingBalance,
+		ImportedBy:     userID,
+		CreatedAt:      time.Now(),
+	}
+	lines := make([]StatementLine, len(statement.Lines))
+	copy(lines, statement.Lines)
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+
+	// OFX gives a closing balance rather than running balances, so work
+	// them out backwards from it
+	if statement.ClosingBalance != nil {
+		balance := toCents(*statement.ClosingBalance)
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].Balance == nil {
+				value := fromCents(balance)
+				lines[i].Balance = &value
+			}
+			balance = toCents(*lines[i].Balance) - toCents(lines[i].Amount)
+		}
+	}
+
+	var imported []BankTransaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var account BankAccount
+		if err := tx.Where("id = ? AND organization_id = ?", bankAccountID, orgID).First(&account).Error; err != nil {
+			return err
+		}
+
+		// The balance before the statement's first line
+		var previous BankTransaction
+		balance := toCents(account.Balance)
+		err := tx.Where("bank_account_id = ? AND date < ?", bankAccountID, lines[0].Date).
+			Order("date DESC, created_at DESC").First(&previous).Error
+		if err == nil {
+			balance = toCents(previous.Balance)
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		seen := make(map[string]int)
+		for _, line := range lines {
+			fingerprint := statementFingerprint(line, seen)
+			if line.Balance != nil {
+				balance = toCents(*line.Balance)
+			} else {
+				balance += toCents(line.Amount)
+			}
+
+			var existing int64
+			if err := tx.Model(&BankTransaction{}).Where("bank_account_id = ? AND fingerprint = ?", bankAccountID, fingerprint).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				record.Duplicates++
+				continue
+			}
+
+			transaction := BankTransaction{
+				ID:            uuid.New().String(),
+				BankAccountID: bankAccountID,
+				Date:          line.Date,
+				Description:   line.Description,
+				Amount:        line.Amount,
+				Balance:       fromCents(balance),
+				Reference:     line.Reference,
+				ExternalID:    line.ExternalID,
+				Fingerprint:   fingerprint,
+				ImportID:      &record.ID,
+			}
+			if err := tx.Create(&transaction).Error; err != nil {
+				return err
+			}
+			imported = append(imported, transaction)
+			record.Imported++
+		}
+
+		record.StartDate, record.EndDate = &lines[0].Date, &lines[len(lines)-1].Date
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		// The account's balance is that of its latest transaction
+		var latest BankTransaction
+		if err := tx.Where("bank_account_id = ?", bankAccountID).Order("date DESC, created_at DESC").First(&latest).Error; err != nil {
+			return err
+		}
+		return tx.Model(&account).Updates(map[string]interface{}{"balance": latest.Balance, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, imported, nil
+}
+
+// statementFingerprint identifies a statement line across imports. seen
+// counts lines that look the same, so two identical purchases on one day
+// are both kept.
+func statementFingerprint(line StatementLine, seen map[string]int) string {
+	key := "id:" + line.ExternalID
+	if line.ExternalID == "" {
+		description := strings.Join(strings.Fields(strings.ToLower(line.Description)), " ")
+		key = fmt.Sprintf("%s|%d|%s|%s", line.Date.Format("2006-01-02"), toCents(line.Amount), description,
+			strings.ToLower(strings.TrimSpace(line.Reference)))
+	}
+	seen[key]++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", key, seen[key])))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetBankTransactions lists a bank account's transactions, optionally only
+// reconciled or unreconciled ones
+func (s *Service) GetBankTransactions(orgID, bankAccountID string, reconciled *bool) ([]BankTransaction, error) {
+	if _, err := s.GetBankAccount(orgID, bankAccountID); err != nil {
+		return nil, err
+	}
+	query := s.db.Where("bank_account_id = ?", bankAccountID)
+	if reconciled != nil {
+		query = query.Where("is_reconciled = ?", *reconciled)
+	}
+	var transactions []BankTransaction
+	err := query.Order("date, created_at").Find(&transactions).Error
+	return transactions, err
+}
+
+// bankMatches lists the ledger items on a bank's ledger account that
+// haven't been reconciled yet: customer and vendor payments, each day's POS
+// takings and anything else posted to the account, line by line. Reversed
+// entries and their reversals cancel out and are left out.
+func (s *Service) bankMatches(db *gorm.DB, orgID, ledgerAccountID string) ([]BankMatch, error) {
+	var lines []GeneralLedger
+	if err := db.Where("organization_id = ? AND account_id = ? AND bank_transaction_id IS NULL AND reversed = ?", orgID, ledgerAccountID, false).
+		Where("journal_entry_id NOT IN (?)", db.Model(&LedgerPosting{}).Select("journal_entry_id").
+			Where("organization_id = ? AND source_type = ?", orgID, SourceReversal)).
+		Order("transaction_date, created_at").Find(&lines).Error; err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	entryIDs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		entryIDs = append(entryIDs, line.JournalEntryID)
+	}
+	var postings []LedgerPosting
+	if err := db.Where("organization_id = ? AND journal_entry_id IN ?", orgID, entryIDs).Find(&postings).Error; err != nil {
+		return nil, err
+	}
+	sources := make(map[string]LedgerPosting, len(postings))
+	for _, posting := range postings {
+		sources[posting.JournalEntryID] = posting
+	}
+
+	var matches []BankMatch
+	payments := make(map[string]int)
+	settlements := make(map[string]int)
+	for _, line := range lines {
+		amount := line.DebitAmount - line.CreditAmount
+		source := sources[line.JournalEntryID]
+		switch source.SourceType {
+		case SourcePayment:
+			if i, ok := payments[source.SourceID]; ok {
+				matches[i].Amount += amount
+				continue
+			}
+			var payment Payment
+			if err := db.Where("id = ? AND organization_id = ?", source.SourceID, orgID).First(&payment).Error; err != nil {
+				return nil, err
+			}
+			payments[source.SourceID] = len(matches)
+			matches = append(matches, BankMatch{
+				Type:        MatchPayment,
+				ID:          payment.ID,
+				Date:        payment.PaymentDate,
+				Amount:      amount,
+				Description: line.Description,
+				Reference:   payment.Reference,
+			})
+		case SourcePOSSale, SourcePOSVoid:
+			// Card takings are settled into the bank a day at a time
+			day := line.TransactionDate.Format("2006-01-02")
+			if i, ok := settlements[day]; ok {
+				matches[i].Amount += amount
+				continue
+			}
+			date, _ := time.Parse("2006-01-02", day)
+			settlements[day] = len(matches)
+			matches = append(matches, BankMatch{
+				Type:        MatchPOSSettlement,
+				ID:          day,
+				Date:        date,
+				Amount:      amount,
+				Description: "POS card takings for " + day,
+			})
+		default:
+			matches = append(matches, BankMatch{
+				Type:        MatchJournalLine,
+				ID:          line.ID,
+				Date:        line.TransactionDate,
+				Amount:      amount,
+				Description: line.Description,
+				Reference:   line.Reference,
+			})
+		}
+	}
+
+	// Drop settlements and payments that net to nothing, e.g. a sale and its void
+	kept := matches[:0]
+	for _, match := range matches {
+		match.Amount = round2(match.Amount)
+		if match.Amount != 0 {
+			kept = append(kept, match)
+		}
+	}
+	return kept, nil
+}
+
+// matchLines are the unreconciled ledger lines that make up a match
+func (s *Service) matchLines(db *gorm.DB, orgID, ledgerAccountID, matchType, matchID string) ([]GeneralLedger, error) {
+	query := db.Where("organization_id = ? AND account_id = ? AND reversed = ?", orgID, ledgerAccountID, false)
+	switch matchType {
+	case MatchPayment:
+		query = query.Where("journal_entry_id IN (?)", db.Model(&LedgerPosting{}).Select("journal_entry_id").
+			Where("organization_id = ? AND source_type = ? AND source_id = ?", orgID, SourcePayment, matchID))
+	case MatchPOSSettlement:
+		day, err := time.Parse("2006-01-02", matchID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: settlements are matched by date, YYYY-MM-DD", ErrMatchMismatch)
+		}
+		var all []GeneralLedger
+		if err := query.Where("journal_entry_id IN (?)", db.Model(&LedgerPosting{}).Select("journal_entry_id").
+			Where("organization_id = ? AND source_type IN ?", orgID, []string{SourcePOSSale, SourcePOSVoid})).
+			Where("transaction_date >= ? AND transaction_date < ?", day.AddDate(0, 0, -1), day.AddDate(0, 0, 2)).
+			Find(&all).Error; err != nil {
+			return nil, err
+		}
+		// Lines are grouped on the day they were posted in their own time zone
+		var lines []GeneralLedger
+		for _, line := range all {
+			if line.TransactionDate.Format("2006-01-02") == matchID {
+				lines = append(lines, line)
+			}
+		}
+		return lines, nil
+	case MatchJournalLine:
+		query = query.Where("id = ?", matchID)
+	default:
+		return nil, fmt.Errorf("%w: unknown match type %q", ErrMatchMismatch, matchType)
+	}
+	var lines []GeneralLedger
+	err := query.Find(&lines).Error
+	return lines, err
+}
+
+// SuggestBankMatches suggests ledger items for each of a bank account's
+// unreconciled transactions. Amounts must agree to the cent, dates must be
+// within windowDays of each other, and closer dates and matching references
+// score higher. The best matches come first.
+func (s *Service) SuggestBankMatches(orgID, bankAccountID string, windowDays int) ([]BankMatchSuggestion, error) {
+	account, err := s.GetBankAccount(orgID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	ledgerAccount, err := s.bankLedgerAccount(nil, account)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := s.bankMatches(s.db, orgID, ledgerAccount.ID)
+	if err != nil {
+		return nil, err
+	}
+	unreconciled := false
+	transactions, err := s.GetBankTransactions(orgID, bankAccountID, &unreconciled)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]BankMatchSuggestion, 0, len(transactions))
+	for _, transaction := range transactions {
+		suggestion := BankMatchSuggestion{Transaction: transaction, Matches: []BankMatch{}}
+		for _, candidate := range candidates {
+			if score, ok := matchScore(transaction, candidate, windowDays); ok {
+				candidate.Score = score
+				suggestion.Matches = append(suggestion.Matches, candidate)
+			}
+		}
+		sort.SliceStable(suggestion.Matches, func(i, j int) bool { return suggestion.Matches[i].Score > suggestion.Matches[j].Score })
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// matchScore scores how likely a ledger item is to be a statement line:
+// 50 for the amount, up to 30 for the date and 20 for the reference
+func matchScore(transaction BankTransaction, candidate BankMatch, windowDays int) (int, bool) {
+	if toCents(transaction.Amount) != toCents(candidate.Amount) {
+		return 0, false
+	}
+	days := math.Abs(transaction.Date.Sub(candidate.Date).Hours()) / 24
+	if days > float64(windowDays)+0.5 {
+		return 0, false
+	}
+
+	score := 50 + int(math.Round(30*(1-days/float64(windowDays+1))))
+	reference := strings.ToLower(strings.TrimSpace(candidate.Reference))
+	if len(reference) >= 3 {
+		statement := strings.ToLower(transaction.Reference + " " + transaction.Description)
+		if strings.Contains(statement, reference) {
+			score += 20
+		}
+	}
+	return score, true
+}
+
+// ReconcileBankTransaction reconciles a statement line against a payment, a
+// day's POS takings or a journal line, which must add up to it
+func (s *Service) ReconcileBankTransaction(orgID, transactionID, matchType, matchID, userID string) (*BankTransaction, error) {
+	var transaction BankTransaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var account BankAccount
+		if err := tx.Joins("JOIN bank_transactions ON bank_transactions.bank_account_id = bank_accounts.id").
+			Where("bank_transactions.id = ? AND bank_accounts.organization_id = ?", transactionID, orgID).
+			First(&account).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&transaction, "id = ?", transactionID).Error; err != nil {
+			return err
+		}
+		if transaction.IsReconciled {
+			return fmt.Errorf("%w: the bank transaction is matched to %s %s", ErrAlreadyReconciled, transaction.MatchType, transaction.MatchID)
+		}
+		ledgerAccount, err := s.bankLedgerAccount(tx, &account)
+		if err != nil {
+			return err
+		}
+
+		lines, err := s.matchLines(tx, orgID, ledgerAccount.ID, matchType, matchID)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("%w: nothing to match for %s %s", gorm.ErrRecordNotFound, matchType, matchID)
+		}
+		var total int64
+		ids := make([]string, 0, len(lines))
+		for _, line := range lines {
+			if line.BankTransactionID != nil {
+				return fmt.Errorf("%w: %s %s is matched to another bank transaction", ErrAlreadyReconciled, matchType, matchID)
+			}
+			total += toCents(line.DebitAmount) - toCents(line.CreditAmount)
+			ids = append(ids, line.ID)
+		}
+		if total != toCents(transaction.Amount) {
+			return fmt.Errorf("%w: the bank transaction is %.2f but %s %s is %.2f", ErrMatchMismatch, transaction.Amount, matchType, matchID, fromCents(total))
+		}
+
+		if err := tx.Model(&GeneralLedger{}).Where("id IN ?", ids).Update("bank_transaction_id", transaction.ID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		transaction.IsReconciled, transaction.MatchType, transaction.MatchID = true, matchType, matchID
+		transaction.ReconciledBy, transaction.ReconciledAt = &userID, &now
+		if err := tx.Save(&transaction).Error; err != nil {
+			return err
+		}
+		s.createAuditTrail(tx, orgID, "bank_transactions", transaction.ID, "UPDATE", "", matchType+" "+matchID, userID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// UnreconcileBankTransaction undoes a reconciliation, freeing the ledger
+// items it was matched to
+func (s *Service) UnreconcileBankTransaction(orgID, transactionID, userID string) (*BankTransaction, error) {
+	var transaction BankTransaction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Joins("JOIN bank_accounts ON bank_accounts.id = bank_transactions.bank_account_id").
+			Where("bank_transactions.id = ? AND bank_accounts.organization_id = ?", transactionID, orgID).
+			First(&transaction).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&GeneralLedger{}).Where("bank_transaction_id = ?", transaction.ID).
+			Update("bank_transaction_id", nil).Error; err != nil {
+			return err
+		}
+		previous := transaction.MatchType + " " + transaction.MatchID
+		transaction.IsReconciled, transaction.MatchType, transaction.MatchID = false, "", ""
+		transaction.ReconciledBy, transaction.ReconciledAt = nil, nil
+		if err := tx.Save(&transaction).Error; err != nil {
+			return err
+		}
+		s.createAuditTrail(tx, orgID, "bank_transactions", transaction.ID, "UPDATE", previous, "", userID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// GetBankReconciliation compares a bank account's statement balance at the
+// end of asOf with the balance of the ledger account it's kept in. Items on
+// one side that weren't matched to the other by then explain the
+// difference; anything left over is unexplained.
+func (s *Service) GetBankReconciliation(orgID, bankAccountID string, asOf time.Time) (*BankReconciliation, error) {
+	account, err := s.GetBankAccount(orgID, bankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	ledgerAccount, err := s.bankLedgerAccount(nil, account)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location()).AddDate(0, 0, 1)
+	report := &BankReconciliation{
+		BankAccountID:              bankAccountID,
+		LedgerAccountID:            ledgerAccount.ID,
+		AsOfDate:                   asOf,
+		UnreconciledStatementItems: []BankTransaction{},
+		UnreconciledLedgerItems:    []GeneralLedger{},
+	}
+
+	var latest BankTransaction
+	err = s.db.Where("bank_account_id = ? AND date < ?", bankAccountID, until).Order("date DESC, created_at DESC").First(&latest).Error
+	if err == nil {
+		report.StatementBalance = latest.Balance
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err := s.db.Model(&GeneralLedger{}).Select("COALESCE(SUM(debit_amount - credit_amount), 0)").
+		Where("organization_id = ? AND account_id = ? AND transaction_date < ?", orgID, ledgerAccount.ID, until).
+		Row().Scan(&report.LedgerBalance); err != nil {
+		return nil, err
+	}
+
+	// Statement lines not matched by asOf, including those matched to ledger
+	// items dated later
+	if err := s.db.Where("bank_account_id = ? AND date < ?", bankAccountID, until).
+		Where("is_reconciled = ? OR id IN (?)", false, s.db.Model(&GeneralLedger{}).Select("bank_transaction_id").
+			Where("organization_id = ? AND bank_transaction_id IS NOT NULL AND transaction_date >= ?", orgID, until)).
+		Order("date, created_at").Find(&report.UnreconciledStatementItems).Error; err != nil {
+		return nil, err
+	}
+	// Ledger lines not matched by asOf, including those matched to statement
+	// lines dated later
+	if err := s.db.Where("organization_id = ? AND account_id = ? AND transaction_date < ?", orgID, ledgerAccount.ID, until).
+		Where("bank_transaction_id IS NULL OR bank_transaction_id IN (?)", s.db.Model(&BankTransaction{}).Select("id").
+			Where("bank_account_id = ? AND date >= ?", bankAccountID, until)).
+		Order("transaction_date, created_at").Find(&report.UnreconciledLedgerItems).Error; err != nil {
+		return nil, err
+	}
+
+	for _, item := range report.UnreconciledStatementItems {
+		report.UnreconciledStatementTotal += item.Amount
+	}
+	for _, item := range report.UnreconciledLedgerItems {
+		report.UnreconciledLedgerTotal += item.DebitAmount - item.CreditAmount
+	}
+	report.StatementBalance = round2(report.StatementBalance)
+	report.LedgerBalance = round2(report.LedgerBalance)
+	report.UnreconciledStatementTotal = round2(report.UnreconciledStatementTotal)
+	report.UnreconciledLedgerTotal = round2(report.UnreconciledLedgerTotal)
+	report.Difference = round2(report.StatementBalance - report.LedgerBalance)
+	report.UnexplainedDifference = round2(report.Difference - report.UnreconciledStatementTotal + report.UnreconciledLedgerTotal)
+	report.Reconciled = toCents(report.UnexplainedDifference) == 0
+	return report, nil
+}
@@ -0,0 +1,220 @@
+package finance
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// cashSubTypes are the account sub-types holding cash and cash equivalents
+var cashSubTypes = map[string]bool{"cash": true, "bank": true, "cash and cash equivalents": true}
+
+// Where an account's movements go on the cash flow statement
+const (
+	flowCash      = "cash"
+	flowIncome    = "income"
+	flowOperating = "operating"
+	flowInvesting = "investing"
+	flowFinancing = "financing"
+)
+
+// cashFlowClass sorts an account into a cash flow section by its type and
+// sub-type. Current assets and liabilities are working capital; long-term
+// assets are investing; equity and long-term debt are financing.
+func cashFlowClass(account ChartOfAccount) string {
+	subType := strings.ToLower(account.SubType)
+	containsAny := func(words ...string) bool {
+		for _, word := range words {
+			if strings.Contains(subType, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch account.AccountType {
+	case "Revenue", "Expense":
+		return flowIncome
+	case "Asset":
+		switch {
+		case cashSubTypes[subType]:
+			return flowCash
+		case containsAny("depreciation", "amortisation", "amortization"):
+			// Added back to net income like any other non-cash expense
+			return flowOperating
+		case containsAny("fixed", "non-current", "noncurrent", "long-term", "investment", "intangible", "property"):
+			return flowInvesting
+		}
+		return flowOperating
+	case "Liability":
+		if containsAny("long-term", "non-current", "noncurrent", "loan", "borrowing") {
+			return flowFinancing
+		}
+		return flowOperating
+	case "Equity":
+		return flowFinancing
+	}
+	return flowOperating
+}
+
+// cashAccountIDs are the accounts the organization keeps cash in: bank and
+// cash sub-types, and whatever cash and tenders are posted to
+func (s *Service) cashAccountIDs(orgID string, accounts []ChartOfAccount) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	for _, account := range accounts {
+		if cashFlowClass(account) == flowCash {
+			ids[account.ID] = true
+		}
+	}
+
+	var mappings []AccountMapping
+	if err := s.db.Where("organization_id = ? AND (key IN ? OR key LIKE ?)", orgID, []string{AccountCash, AccountBank}, "tender:%").
+		Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	for _, mapping := range mappings {
+		ids[mapping.AccountID] = true
+	}
+	// Unmapped cash and bank keys post to their default accounts
+	for _, key := range []string{AccountCash, AccountBank} {
+		fallback := defaultAccounts[key]
+		for _, account := range accounts {
+			if account.Code == fallback.Code && account.AccountType == "Asset" {
+				ids[account.ID] = true
+			}
+		}
+	}
+	return ids, nil
+}
+
+// GetCashFlowStatement works out where cash came from and went between start
+// and end, both inclusive, using the indirect method: net income, adjusted
+// for movements in working capital, then investing and financing movements.
+// Year-end closing entries move no cash and are left out. Beginning and
+// ending cash are the balances of the cash and bank accounts, and the
+// statement is reconciled when they differ by the net cash flow.
+func (s *Service) GetCashFlowStatement(orgID string, start, end time.Time) (*CashFlowStatement, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	until := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location()).AddDate(0, 0, 1)
+	statement := &CashFlowStatement{
+		Period: fmt.Sprintf("%s to %s", start.Format("2006-01-02"), end.Format("2006-01-02")),
+	}
+
+	var accounts []ChartOfAccount
+	if err := s.db.Where("organization_id = ?", orgID).Order("code").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	cashAccounts, err := s.cashAccountIDs(orgID, accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	closingEntries := s.db.Model(&LedgerPosting{}).Select("journal_entry_id").
+		Where("organization_id = ? AND source_type = ?", orgID, SourceYearEndClose)
+	movements := func(from *time.Time, to time.Time, excludeClosing bool) (map[string]float64, error) {
+		var rows []struct {
+			AccountID string
+			Movement  float64
+		}
+		query := s.db.Model(&GeneralLedger{}).
+			Select("account_id, SUM(debit_amount - credit_amount) as movement").
+			Where("organization_id = ? AND transaction_date < ?", orgID, to)
+		if from != nil {
+			query = query.Where("transaction_date >= ?", *from)
+		}
+		if excludeClosing {
+			query = query.Where("journal_entry_id NOT IN (?)", closingEntries)
+		}
+		if err := query.Group("account_id").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		result := make(map[string]float64, len(rows))
+		for _, row := range rows {
+			result[row.AccountID] = row.Movement
+		}
+		return result, nil
+	}
+
+	period, err := movements(&start, until, true)
+	if err != nil {
+		return nil, err
+	}
+	opening, err := movements(nil, start, false)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := movements(nil, until, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var netIncome float64
+	for _, account := range accounts {
+		movement := round2(period[account.ID])
+		class := cashFlowClass(account)
+		if cashAccounts[account.ID] {
+			class = flowCash
+		}
+
+		switch class {
+		case flowCash:
+			statement.BeginningCash += opening[account.ID]
+			statement.EndingCash += closing[account.ID]
+			statement.CashAccounts = append(statement.CashAccounts, FinancialReportLine{
+				AccountCode: account.Code,
+				AccountName: account.Name,
+				Amount:      round2(closing[account.ID]),
+			})
+			continue
+		case flowIncome:
+			netIncome -= movement
+			continue
+		}
+		if movement == 0 {
+			continue
+		}
+
+		// More of an asset uses cash; more of a liability or equity brings it in
+		activity := CashFlowActivity{Description: cashFlowDescription(account, movement), Amount: -movement}
+		switch class {
+		case flowInvesting:
+			statement.InvestingActivities = append(statement.InvestingActivities, activity)
+			statement.NetInvestingCash += activity.Amount
+		case flowFinancing:
+			statement.FinancingActivities = append(statement.FinancingActivities, activity)
+			statement.NetFinancingCash += activity.Amount
+		default:
+			statement.OperatingActivities = append(statement.OperatingActivities, activity)
+			statement.NetOperatingCash += activity.Amount
+		}
+	}
+
+	statement.OperatingActivities = append([]CashFlowActivity{{Description: "Net income", Amount: round2(netIncome)}},
+		statement.OperatingActivities...)
+	statement.NetOperatingCash = round2(statement.NetOperatingCash + netIncome)
+	statement.NetInvestingCash = round2(statement.NetInvestingCash)
+	statement.NetFinancingCash = round2(statement.NetFinancingCash)
+	statement.NetCashFlow = round2(statement.NetOperatingCash + statement.NetInvestingCash + statement.NetFinancingCash)
+	statement.BeginningCash = round2(statement.BeginningCash)
+	statement.EndingCash = round2(statement.EndingCash)
+	statement.Reconciled = toCents(statement.BeginningCash+statement.NetCashFlow) == toCents(statement.EndingCash)
+	return statement, nil
+}
+
+// cashFlowDescription describes the movement in an account, e.g. "Increase
+// in Accounts Receivable"
+func cashFlowDescription(account ChartOfAccount, movement float64) string {
+	increase := movement > 0
+	if account.AccountType != "Asset" {
+		increase = movement < 0
+	}
+	if increase {
+		return "Increase in " + account.Name
+	}
+	return "Decrease in " + account.Name
+}
+
+func round2(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -1,19 +1,48 @@
 package finance
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
 	service *Service
+
+	// CustomerNames looks up how customers are shown on receivables reports.
+	// Customers are kept outside the ledger, so without it reports leave
+	// names blank and statements aren't checked against a customer list.
+	CustomerNames func(orgID string, customerIDs []string) (map[string]string, error)
+	// RequireAdmin, when set, guards locking a period, which can't be undone
+	RequireAdmin gin.HandlerFunc
 }
 
+// maxStatementSize is the largest bank statement that can be imported
+const maxStatementSize = 10 << 20
+
 func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
+// errorStatus picks the status for an error from the service
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrPeriodClosed), errors.Is(err, ErrNotReversible), errors.Is(err, ErrAlreadyReconciled):
+		return http.StatusConflict
+	case errors.Is(err, ErrInvalidPeriod), errors.Is(err, ErrUnbalanced), errors.Is(err, ErrInvalidStatement),
+		errors.Is(err, ErrMatchMismatch), errors.Is(err, ErrInvalidAllocation):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func (h *Handler) GetChartOfAccounts(c *gin.Context) {
 	orgID := c.GetString("organization_id")
 	if orgID == "" {
@@ -75,7 +104,7 @@ func (h *Handler) CreateJournalEntry(c *gin.Context) {
 
 	entry.OrganizationID = orgID
 	if err := h.service.CreateJournalEntry(&entry); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -95,7 +124,7 @@ func (h *Handler) PostJournalEntry(c *gin.Context) {
 	}
 
 	if err := h.service.PostJournalEntry(entryID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -109,12 +138,23 @@ func (h *Handler) GetTrialBalance(c *gin.Context) {
 		orgID = "default-org"
 	}
 
-	asOfDateStr := c.Query("as_of_date")
 	asOfDate := time.Now()
-	if asOfDateStr != "" {
-		if parsed, err := time.Parse("2006-01-02", asOfDateStr); err == nil {
-			asOfDate = parsed
+	if date := c.Query("as_of_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of_date format"})
+			return
+		}
+		asOfDate = parsed
+	}
+	// A period's trial balance is as at its last day
+	if periodID := c.Query("period_id"); periodID != "" {
+		period, err := h.service.GetAccountingPeriod(orgID, periodID)
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
 		}
+		asOfDate = period.EndDate
 	}
 
 	trialBalance, err := h.service.GetTrialBalance(orgID, asOfDate)
@@ -122,8 +162,18 @@ func (h *Handler) GetTrialBalance(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	var debits, credits int64
+	for _, account := range trialBalance {
+		debits += toCents(account.DebitBalance)
+		credits += toCents(account.CreditBalance)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": trialBalance})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
+		"as_of_date":    asOfDate.Format("2006-01-02"),
+		"accounts":      trialBalance,
+		"total_debits":  fromCents(debits),
+		"total_credits": fromCents(credits),
+	}})
 }
 
 func (h *Handler) GetProfitLoss(c *gin.Context) {
@@ -158,6 +208,46 @@ func (h *Handler) GetProfitLoss(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": profitLoss})
 }
 
+// GetCashFlowStatement reports where cash came from and went between
+// start_date and end_date, defaulting to the last month
+func (h *Handler) GetCashFlowStatement(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, -1, 0)
+	if date := c.Query("start_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+		startDate = parsed
+	}
+	if date := c.Query("end_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+		endDate = parsed
+	}
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be on or after start_date"})
+		return
+	}
+
+	cashFlow, err := h.service.GetCashFlowStatement(orgID, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": cashFlow})
+}
+
 func (h *Handler) GetBalanceSheet(c *gin.Context) {
 	orgID := c.GetString("organization_id")
 	if orgID == "" {
@@ -360,13 +450,39 @@ func (h *Handler) CreateBankAccount(c *gin.Context) {
 		orgID = "default-org"
 	}
 
-	var account BankAccount
-	if err := c.ShouldBindJSON(&account); err != nil {
+	var req struct {
+		AccountName     string  `json:"account_name" binding:"required"`
+		AccountNumber   string  `json:"account_number" binding:"required"`
+		BankName        string  `json:"bank_name" binding:"required"`
+		AccountType     string  `json:"account_type"`
+		LedgerAccountID *string `json:"ledger_account_id"`
+		OpeningBalance  float64 `json:"opening_balance"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Without a ledger account the bank reconciles against the bank mapping
+	if req.LedgerAccountID != nil {
+		if _, err := h.service.AccountFor(nil, orgID, accountPrefix+*req.LedgerAccountID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ledger account not found"})
+			return
+		}
+	}
+	if req.AccountType == "" {
+		req.AccountType = "checking"
+	}
 
-	account.OrganizationID = orgID
+	account := BankAccount{
+		OrganizationID:  orgID,
+		AccountName:     req.AccountName,
+		AccountNumber:   req.AccountNumber,
+		BankName:        req.BankName,
+		AccountType:     req.AccountType,
+		LedgerAccountID: req.LedgerAccountID,
+		Balance:         req.OpeningBalance,
+		IsActive:        true,
+	}
 	if err := h.service.CreateBankAccount(&account); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -375,6 +491,588 @@ func (h *Handler) CreateBankAccount(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"success": true, "data": account})
 }
 
+// ImportBankStatement imports an OFX, QIF or CSV statement uploaded as file
+func (h *Handler) ImportBankStatement(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement file is required"})
+		return
+	}
+	if header.Size > maxStatementSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement file must be 10MB or less"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxStatementSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > maxStatementSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement file must be 10MB or less"})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = DetectStatementFormat(header.Filename, data)
+	}
+	statement, err := ParseStatement(format, data, c.PostForm("date_format"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	record, transactions, err := h.service.ImportBankStatement(orgID, c.Param("id"), header.Filename, statement, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": gin.H{"import": record, "transactions": transactions}})
+}
+
+// GetBankTransactions lists a bank account's transactions; reconciled=false
+// lists those still to be reconciled
+func (h *Handler) GetBankTransactions(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var reconciled *bool
+	if value := c.Query("reconciled"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reconciled value"})
+			return
+		}
+		reconciled = &parsed
+	}
+
+	transactions, err := h.service.GetBankTransactions(orgID, c.Param("id"), reconciled)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": transactions})
+}
+
+// SuggestBankMatches suggests ledger items for unreconciled bank transactions
+// dated within days (default 5) of each other
+func (h *Handler) SuggestBankMatches(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "5"))
+	if err != nil || days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+
+	suggestions, err := h.service.SuggestBankMatches(orgID, c.Param("id"), days)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": suggestions})
+}
+
+// ReconcileBankTransaction matches a bank transaction to a ledger item
+func (h *Handler) ReconcileBankTransaction(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var req struct {
+		MatchType string `json:"match_type" binding:"required,oneof=payment pos_settlement journal_line"`
+		MatchID   string `json:"match_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.service.ReconcileBankTransaction(orgID, c.Param("id"), req.MatchType, req.MatchID, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": transaction})
+}
+
+// UnreconcileBankTransaction undoes a bank transaction's match
+func (h *Handler) UnreconcileBankTransaction(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	transaction, err := h.service.UnreconcileBankTransaction(orgID, c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": transaction})
+}
+
+// GetBankReconciliation compares a bank account's statement and ledger
+// balances as of as_of_date, defaulting to today
+func (h *Handler) GetBankReconciliation(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	asOf := time.Now()
+	if date := c.Query("as_of_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of_date format"})
+			return
+		}
+		asOf = parsed
+	}
+
+	report, err := h.service.GetBankReconciliation(orgID, c.Param("id"), asOf)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
+
+// Payment handlers
+func (h *Handler) CreatePayment(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var payment Payment
+	if err := c.ShouldBindJSON(&payment); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment.OrganizationID = orgID
+	if err := h.service.CreatePayment(&payment); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": payment})
+}
+
+// AllocatePayment applies what's left of a customer payment to invoices
+func (h *Handler) AllocatePayment(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var req struct {
+		Allocations []PaymentAllocation `json:"allocations" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.service.AllocatePayment(orgID, c.Param("id"), req.Allocations)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": payment})
+}
+
+// Credit note handlers
+func (h *Handler) GetCreditNotes(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	notes, err := h.service.GetCreditNotes(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": notes})
+}
+
+func (h *Handler) CreateCreditNote(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var note CreditNote
+	if err := c.ShouldBindJSON(&note); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note.OrganizationID = orgID
+	if err := h.service.CreateCreditNote(&note); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": note})
+}
+
+// AllocateCreditNote applies what's left of a credit note to invoices
+func (h *Handler) AllocateCreditNote(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var req struct {
+		Allocations []PaymentAllocation `json:"allocations" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.service.AllocateCreditNote(orgID, c.Param("id"), req.Allocations)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": note})
+}
+
+// GetARAging reports what customers owe by age as of as_of_date, defaulting
+// to today
+func (h *Handler) GetARAging(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	asOf := time.Now()
+	if date := c.Query("as_of_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of_date format"})
+			return
+		}
+		asOf = parsed
+	}
+
+	report, err := h.service.GetARAging(orgID, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if h.CustomerNames != nil {
+		ids := make([]string, 0, len(report.Customers))
+		for _, aging := range report.Customers {
+			ids = append(ids, aging.CustomerID)
+		}
+		names, err := h.CustomerNames(orgID, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range report.Customers {
+			report.Customers[i].CustomerName = names[report.Customers[i].CustomerID]
+		}
+		sort.SliceStable(report.Customers, func(i, j int) bool {
+			return report.Customers[i].CustomerName < report.Customers[j].CustomerName
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
+
+// GetCustomerStatement builds a customer's statement between start_date and
+// end_date, defaulting to the last month
+func (h *Handler) GetCustomerStatement(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, -1, 0)
+	if date := c.Query("start_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+		start = parsed
+	}
+	if date := c.Query("end_date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be on or after start_date"})
+		return
+	}
+
+	customerID := c.Param("id")
+	var customerName string
+	if h.CustomerNames != nil {
+		names, err := h.CustomerNames(orgID, []string{customerID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		name, ok := names[customerID]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+			return
+		}
+		customerName = name
+	}
+
+	statement, err := h.service.GetCustomerStatement(orgID, customerID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	statement.CustomerName, statement.Aging.CustomerName = customerName, customerName
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": statement})
+}
+
+// Account mapping handlers
+func (h *Handler) GetAccountMappings(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	mappings, err := h.service.GetAccountMappings(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": mappings})
+}
+
+func (h *Handler) SetAccountMapping(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var req struct {
+		AccountID string `json:"account_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mapping, err := h.service.SetAccountMapping(orgID, c.Param("key"), req.AccountID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": mapping})
+}
+
+func (h *Handler) DeleteAccountMapping(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	if err := h.service.DeleteAccountMapping(orgID, c.Param("key")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account mapping reset to default"})
+}
+
+func (h *Handler) ReverseJournalEntry(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+	userID := c.GetString("user_id")
+	if userID == "" {
+		userID = "system"
+	}
+
+	var req struct {
+		Date string `json:"date"` // YYYY-MM-DD, defaults to today
+	}
+	c.ShouldBindJSON(&req)
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+		date = parsed
+	}
+
+	entry, err := h.service.ReverseJournalEntry(orgID, c.Param("id"), date, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": entry})
+}
+
+// Accounting period handlers
+func (h *Handler) GetAccountingPeriods(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	periods, err := h.service.GetAccountingPeriods(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": periods})
+}
+
+func (h *Handler) CreateAccountingPeriod(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+
+	var req struct {
+		Name      string `json:"name" binding:"required"`
+		StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+		EndDate   string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		return
+	}
+
+	period := AccountingPeriod{OrganizationID: orgID, Name: req.Name, StartDate: start, EndDate: end}
+	if err := h.service.CreateAccountingPeriod(&period); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": period})
+}
+
+// UpdateAccountingPeriodStatus closes, reopens or locks a period
+func (h *Handler) UpdateAccountingPeriodStatus(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID := c.GetString("organization_id")
+		if orgID == "" {
+			orgID = "default-org"
+		}
+		userID := c.GetString("user_id")
+		if userID == "" {
+			userID = "system"
+		}
+
+		var period *AccountingPeriod
+		var err error
+		switch action {
+		case "close":
+			period, err = h.service.CloseAccountingPeriod(orgID, c.Param("id"), userID)
+		case "reopen":
+			period, err = h.service.ReopenAccountingPeriod(orgID, c.Param("id"), userID)
+		case "lock":
+			period, err = h.service.LockAccountingPeriod(orgID, c.Param("id"), userID)
+		}
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": period})
+	}
+}
+
+func (h *Handler) CloseYear(c *gin.Context) {
+	orgID := c.GetString("organization_id")
+	if orgID == "" {
+		orgID = "default-org"
+	}
+	userID := c.GetString("user_id")
+	if userID == "" {
+		userID = "system"
+	}
+
+	var req struct {
+		StartDate string `json:"start_date" binding:"required"`
+		EndDate   string `json:"end_date" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		return
+	}
+
+	entry, err := h.service.CloseYear(orgID, start, end, userID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entry})
+}
+
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	// Chart of Accounts
 	r.GET("/chart-of-accounts", h.GetChartOfAccounts)
@@ -388,6 +1086,16 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	r.GET("/bills", h.GetBills)
 	r.POST("/bills", h.CreateBill)
 
+	// Payments
+	r.POST("/payments", h.CreatePayment)
+	r.POST("/payments/:id/allocate", h.AllocatePayment)
+
+	// Credit notes
+	r.GET("/credit-notes", h.GetCreditNotes)
+	r.POST("/credit-notes", h.CreateCreditNote)
+	r.POST("/credit-notes/:id/allocate", h.AllocateCreditNote)
+	r.GET("/customers/:id/statement", h.GetCustomerStatement)
+
 	// Vendors
 	r.GET("/vendors", h.GetVendors)
 	r.POST("/vendors", h.CreateVendor)
@@ -395,16 +1103,42 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	// Banking
 	r.GET("/bank-accounts", h.GetBankAccounts)
 	r.POST("/bank-accounts", h.CreateBankAccount)
+	r.POST("/bank-accounts/:id/import", h.ImportBankStatement)
+	r.GET("/bank-accounts/:id/transactions", h.GetBankTransactions)
+	r.GET("/bank-accounts/:id/matches", h.SuggestBankMatches)
+	r.GET("/bank-accounts/:id/reconciliation", h.GetBankReconciliation)
+	r.POST("/bank-transactions/:id/reconcile", h.ReconcileBankTransaction)
+	r.POST("/bank-transactions/:id/unreconcile", h.UnreconcileBankTransaction)
 
 	// Journal Entries & Ledger
 	r.GET("/journal-entries", h.GetJournalEntries)
 	r.POST("/journal-entries", h.CreateJournalEntry)
 	r.POST("/journal-entries/:id/post", h.PostJournalEntry)
+	r.POST("/journal-entries/:id/reverse", h.ReverseJournalEntry)
+
+	// Period close
+	r.GET("/periods", h.GetAccountingPeriods)
+	r.POST("/periods", h.CreateAccountingPeriod)
+	r.POST("/periods/:id/close", h.UpdateAccountingPeriodStatus("close"))
+	r.POST("/periods/:id/reopen", h.UpdateAccountingPeriodStatus("reopen"))
+	lock := []gin.HandlerFunc{h.UpdateAccountingPeriodStatus("lock")}
+	if h.RequireAdmin != nil {
+		lock = append([]gin.HandlerFunc{h.RequireAdmin}, lock...)
+	}
+	r.POST("/periods/:id/lock", lock...)
+	r.POST("/year-end-close", h.CloseYear)
+
+	// Where automatic postings go
+	r.GET("/account-mappings", h.GetAccountMappings)
+	r.PUT("/account-mappings/:key", h.SetAccountMapping)
+	r.DELETE("/account-mappings/:key", h.DeleteAccountMapping)
 
 	// Financial Reports
 	r.GET("/reports/trial-balance", h.GetTrialBalance)
 	r.GET("/reports/profit-loss", h.GetProfitLoss)
 	r.GET("/reports/balance-sheet", h.GetBalanceSheet)
+	r.GET("/reports/cash-flow", h.GetCashFlowStatement)
+	r.GET("/reports/ar-aging", h.GetARAging)
 	r.GET("/reports/general-ledger", h.GetGeneralLedger)
 
 	// Dashboard
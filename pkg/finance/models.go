@@ -61,6 +61,7 @@ type Invoice struct {
 	TaxAmount      float64         `json:"tax_amount" gorm:"default:0"`
 	Total          float64         `json:"total" gorm:"not null"`
 	PaidAmount     float64         `json:"paid_amount" gorm:"default:0"`
+	CreditedAmount float64         `json:"credited_amount" gorm:"default:0"` // Credit notes allocated to the invoice
 	Notes          string          `json:"notes"`
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
@@ -134,6 +135,7 @@ type Payment struct {
 	ID             string    `json:"id" gorm:"primaryKey"`
 	OrganizationID string    `json:"organization_id" gorm:"not null"`
 	Type           string    `json:"type" gorm:"not null"` // customer_payment, vendor_payment
+	CustomerID     string    `json:"customer_id" gorm:"index"`
 	InvoiceID      *string   `json:"invoice_id"`
 	BillID         *string   `json:"bill_id"`
 	Amount         float64   `json:"amount" gorm:"not null"`
@@ -141,8 +143,42 @@ type Payment struct {
 	PaymentMethod  string    `json:"payment_method" gorm:"not null"` // cash, check, credit_card, bank_transfer
 	Reference      string    `json:"reference"`
 	Notes          string    `json:"notes"`
+	AllocatedAmount float64  `json:"allocated_amount" gorm:"default:0"` // Allocated to invoices; the rest is held as credit
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	Allocations    []PaymentAllocation `json:"allocations" gorm:"foreignKey:PaymentID"`
+}
+
+// CreditNote reduces what a customer owes, e.g. for returned goods or an
+// invoicing mistake. It's allocated to invoices like a payment.
+type CreditNote struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	OrganizationID   string    `json:"organization_id" gorm:"not null;index"`
+	CreditNoteNumber string    `json:"credit_note_number" gorm:"unique;not null"`
+	CustomerID       string    `json:"customer_id" gorm:"not null;index"`
+	InvoiceID        *string   `json:"invoice_id"` // Invoice being credited, if any
+	IssueDate        time.Time `json:"issue_date" gorm:"not null"`
+	Reason           string    `json:"reason"`
+	SubTotal         float64   `json:"subtotal" gorm:"not null"`
+	TaxAmount        float64   `json:"tax_amount" gorm:"default:0"`
+	Total            float64   `json:"total" gorm:"not null"`
+	AllocatedAmount  float64   `json:"allocated_amount" gorm:"default:0"`
+	Status           string    `json:"status" gorm:"default:'open'"` // open, applied
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Allocations      []PaymentAllocation `json:"allocations" gorm:"foreignKey:CreditNoteID"`
+}
+
+// PaymentAllocation applies part of a payment or credit note to an invoice
+type PaymentAllocation struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;index"`
+	InvoiceID      string    `json:"invoice_id" gorm:"not null;index"`
+	PaymentID      *string   `json:"payment_id" gorm:"index"`
+	CreditNoteID   *string   `json:"credit_note_id" gorm:"index"`
+	Amount         float64   `json:"amount" gorm:"not null"`
+	Date           time.Time `json:"date" gorm:"not null"` // Date of the payment or credit note
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // BankAccount represents company bank accounts
@@ -153,6 +189,7 @@ type BankAccount struct {
 	AccountNumber  string    `json:"account_number" gorm:"not null"`
 	BankName       string    `json:"bank_name" gorm:"not null"`
 	AccountType    string    `json:"account_type" gorm:"not null"` // checking, savings, credit
+	LedgerAccountID *string  `json:"ledger_account_id"` // Account the bank is kept in; the bank mapping if not set
 	Balance        float64   `json:"balance" gorm:"default:0"`
 	IsActive       bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -168,11 +205,35 @@ type BankTransaction struct {
 	Amount        float64   `json:"amount" gorm:"not null"` // positive for deposits, negative for withdrawals
 	Balance       float64   `json:"balance" gorm:"not null"`
 	Category      string    `json:"category"`
+	Reference     string    `json:"reference"`
+	ExternalID    string    `json:"external_id"` // The bank's ID for the transaction, e.g. an OFX FITID
+	Fingerprint   string    `json:"-" gorm:"index"` // Identifies the transaction when a statement is imported again
+	ImportID      *string   `json:"import_id" gorm:"index"`
 	IsReconciled  bool      `json:"is_reconciled" gorm:"default:false"`
+	MatchType     string    `json:"match_type"` // payment, pos_settlement, journal_line
+	MatchID       string    `json:"match_id"`
+	ReconciledBy  *string   `json:"reconciled_by"`
+	ReconciledAt  *time.Time `json:"reconciled_at"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// BankStatementImport records a statement file imported into a bank account
+type BankStatementImport struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;index"`
+	BankAccountID  string    `json:"bank_account_id" gorm:"not null;index"`
+	Format         string    `json:"format" gorm:"not null"` // ofx, qif, csv
+	FileName       string    `json:"file_name"`
+	StartDate      *time.Time `json:"start_date"`
+	EndDate        *time.Time `json:"end_date"`
+	ClosingBalance *float64  `json:"closing_balance"`
+	Imported       int       `json:"imported"`
+	Duplicates     int       `json:"duplicates"` // Transactions already imported, which were skipped
+	ImportedBy     string    `json:"imported_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 // GeneralLedger represents the complete ledger with all transactions
 type GeneralLedger struct {
 	ID             string    `json:"id" gorm:"primaryKey"`
@@ -190,6 +251,7 @@ type GeneralLedger struct {
 	Reversed       bool      `json:"reversed" gorm:"default:false"`
 	ReversedBy     *string   `json:"reversed_by"`
 	ReversedAt     *time.Time `json:"reversed_at"`
+	BankTransactionID *string `json:"bank_transaction_id" gorm:"index"` // Statement line it was reconciled against
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	ChartOfAccount ChartOfAccount `json:"chart_of_account" gorm:"foreignKey:AccountID"`
@@ -225,6 +287,29 @@ type AuditTrail struct {
 	Timestamp      time.Time `json:"timestamp" gorm:"not null;index"`
 }
 
+// AccountMapping points a posting key (sales, inventory, tender:card, ...)
+// at one of an organization's accounts
+type AccountMapping struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;uniqueIndex:idx_account_mapping_key"`
+	Key            string    `json:"key" gorm:"not null;uniqueIndex:idx_account_mapping_key"`
+	AccountID      string    `json:"account_id" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ChartOfAccount ChartOfAccount `json:"chart_of_account" gorm:"foreignKey:AccountID"`
+}
+
+// LedgerPosting records the journal entry a source document (a sale, bill,
+// stock receipt, ...) was posted as, so it's only ever posted once
+type LedgerPosting struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"not null;uniqueIndex:idx_ledger_posting_source"`
+	SourceType     string    `json:"source_type" gorm:"not null;uniqueIndex:idx_ledger_posting_source"`
+	SourceID       string    `json:"source_id" gorm:"not null;uniqueIndex:idx_ledger_posting_source"`
+	JournalEntryID string    `json:"journal_entry_id" gorm:"not null;index"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
 // TrialBalance represents trial balance report data
 type TrialBalance struct {
 	AccountCode    string  `json:"account_code"`
@@ -282,6 +367,8 @@ type CashFlowStatement struct {
 	NetCashFlow         float64              `json:"net_cash_flow"`
 	BeginningCash       float64              `json:"beginning_cash"`
 	EndingCash          float64              `json:"ending_cash"`
+	CashAccounts        []FinancialReportLine `json:"cash_accounts"` // Ending balance of each cash and bank account
+	Reconciled          bool                 `json:"reconciled"`    // Beginning cash plus net cash flow is ending cash
 }
 
 // CashFlowActivity represents a cash flow activity
@@ -290,6 +377,106 @@ type CashFlowActivity struct {
 	Amount      float64 `json:"amount"`
 }
 
+// BankMatch is a ledger item a bank statement line could be reconciled
+// against: a payment, a day's POS card takings or a journal line
+type BankMatch struct {
+	Type        string    `json:"type"` // payment, pos_settlement, journal_line
+	ID          string    `json:"id"`   // Payment ID, settlement date or general ledger line ID
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description"`
+	Reference   string    `json:"reference"`
+	Score       int       `json:"score"` // Out of 100
+}
+
+// BankMatchSuggestion lists the likely matches for an unreconciled statement line
+type BankMatchSuggestion struct {
+	Transaction BankTransaction `json:"transaction"`
+	Matches     []BankMatch     `json:"matches"`
+}
+
+// BankReconciliation compares a bank statement with the ledger account the
+// bank is kept in
+type BankReconciliation struct {
+	BankAccountID              string            `json:"bank_account_id"`
+	LedgerAccountID            string            `json:"ledger_account_id"`
+	AsOfDate                   time.Time         `json:"as_of_date"`
+	StatementBalance           float64           `json:"statement_balance"`
+	LedgerBalance              float64           `json:"ledger_balance"`
+	Difference                 float64           `json:"difference"` // Statement less ledger balance
+	UnreconciledStatementItems []BankTransaction `json:"unreconciled_statement_items"`
+	UnreconciledLedgerItems    []GeneralLedger   `json:"unreconciled_ledger_items"`
+	UnreconciledStatementTotal float64           `json:"unreconciled_statement_total"`
+	UnreconciledLedgerTotal    float64           `json:"unreconciled_ledger_total"`
+	UnexplainedDifference      float64           `json:"unexplained_difference"` // Difference not accounted for by unreconciled items
+	Reconciled                 bool              `json:"reconciled"`
+}
+
+// AgingBuckets splits amounts owed by how long they're overdue
+type AgingBuckets struct {
+	Current    float64 `json:"current"` // Not yet due
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+// CustomerAging is what a customer owes, by age
+type CustomerAging struct {
+	CustomerID   string `json:"customer_id"`
+	CustomerName string `json:"customer_name,omitempty"`
+	AgingBuckets
+	UnappliedCredits float64 `json:"unapplied_credits"` // Payments and credit notes not yet allocated to invoices
+	Balance          float64 `json:"balance"`           // Total less unapplied credits
+}
+
+// ARAgingReport is the accounts receivable aging report
+type ARAgingReport struct {
+	AsOfDate         time.Time       `json:"as_of_date"`
+	Customers        []CustomerAging `json:"customers"`
+	Totals           AgingBuckets    `json:"totals"`
+	UnappliedCredits float64         `json:"unapplied_credits"`
+	Balance          float64         `json:"balance"`
+}
+
+// CustomerStatement lists a customer's invoices, payments and credit notes
+// over a period with a running balance
+type CustomerStatement struct {
+	CustomerID          string                  `json:"customer_id"`
+	CustomerName        string                  `json:"customer_name,omitempty"`
+	StartDate           time.Time               `json:"start_date"`
+	EndDate             time.Time               `json:"end_date"`
+	OpeningBalance      float64                 `json:"opening_balance"`
+	Lines               []CustomerStatementLine `json:"lines"`
+	ClosingBalance      float64                 `json:"closing_balance"`
+	Aging               CustomerAging           `json:"aging"` // As at the end date
+	OutstandingInvoices []OutstandingInvoice    `json:"outstanding_invoices"`
+}
+
+// CustomerStatementLine is an invoice, payment or credit note on a statement
+type CustomerStatementLine struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"` // invoice, payment, credit_note
+	ID          string    `json:"id"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+// OutstandingInvoice is an invoice with something still owing on it
+type OutstandingInvoice struct {
+	InvoiceID     string    `json:"invoice_id"`
+	InvoiceNumber string    `json:"invoice_number"`
+	IssueDate     time.Time `json:"issue_date"`
+	DueDate       time.Time `json:"due_date"`
+	Total         float64   `json:"total"`
+	Outstanding   float64   `json:"outstanding"`
+	DaysOverdue   int       `json:"days_overdue"`
+}
+
 // AccountingPeriod represents fiscal periods
 type AccountingPeriod struct {
 	ID             string    `json:"id" gorm:"primaryKey"`
@@ -0,0 +1,302 @@
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPeriodClosed is returned for postings dated in or before a closed period
+	ErrPeriodClosed = errors.New("accounting period is closed")
+	// ErrInvalidPeriod is returned for periods that can't be created or
+	// changed as asked
+	ErrInvalidPeriod = errors.New("invalid accounting period")
+	// ErrNotReversible is returned for entries that can't be reversed
+	ErrNotReversible = errors.New("journal entry can't be reversed")
+)
+
+// lockPeriods locks the organization's periods until tx ends. Postings take a
+// share lock so they only wait on period changes, not on each other.
+func lockPeriods(tx *gorm.DB, orgID, strength string) error {
+	var ids []string
+	return tx.Model(&AccountingPeriod{}).Clauses(clause.Locking{Strength: strength}).
+		Where("organization_id = ?", orgID).
+		Order("start_date").
+		Pluck("id", &ids).Error
+}
+
+// checkPeriodOpen refuses postings dated on or before the last day of a
+// closed or locked period. Periods are closed in order, so this keeps the
+// ledger up to any closed period from changing. The periods stay share
+// locked until tx ends, so none can close under the posting.
+func checkPeriodOpen(tx *gorm.DB, orgID string, date time.Time) error {
+	if err := lockPeriods(tx, orgID, "SHARE"); err != nil {
+		return err
+	}
+
+	var period AccountingPeriod
+	err := tx.Where("organization_id = ? AND status IN ? AND end_date > ?", orgID, []string{"closed", "locked"}, date.Add(-24*time.Hour)).
+		Order("end_date DESC").
+		First(&period).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s is %s up to %s", ErrPeriodClosed, period.Name, period.Status, period.EndDate.Format("2006-01-02"))
+}
+
+// GetAccountingPeriods lists an organization's periods in date order
+func (s *Service) GetAccountingPeriods(orgID string) ([]AccountingPeriod, error) {
+	var periods []AccountingPeriod
+	err := s.db.Where("organization_id = ?", orgID).Order("start_date").Find(&periods).Error
+	return periods, err
+}
+
+// CreateAccountingPeriod adds an open period. Periods can't overlap. Start
+// and end dates are whole days; the end date is included in the period.
+func (s *Service) CreateAccountingPeriod(period *AccountingPeriod) error {
+	if period.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPeriod)
+	}
+	if period.StartDate.IsZero() || period.EndDate.Before(period.StartDate) {
+		return fmt.Errorf("%w: end date must be on or after the start date", ErrInvalidPeriod)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPeriods(tx, period.OrganizationID, "UPDATE"); err != nil {
+			return err
+		}
+
+		var overlapping int64
+		if err := tx.Model(&AccountingPeriod{}).
+			Where("organization_id = ? AND start_date <= ? AND end_date >= ?", period.OrganizationID, period.EndDate, period.StartDate).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return fmt.Errorf("%w: overlaps another period", ErrInvalidPeriod)
+		}
+
+		period.ID = uuid.New().String()
+		period.Status = "open"
+		period.ClosedBy, period.ClosedAt = nil, nil
+		return tx.Create(period).Error
+	})
+}
+
+// CloseAccountingPeriod stops anything being posted on or before the end of
+// a period. Earlier periods have to be closed first.
+func (s *Service) CloseAccountingPeriod(orgID, periodID, userID string) (*AccountingPeriod, error) {
+	var period AccountingPeriod
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		period, err = s.closePeriod(tx, orgID, periodID, userID)
+		return err
+	})
+	return &period, err
+}
+
+// closePeriod closes a period in tx, waiting for postings that have checked
+// the periods to finish first
+func (s *Service) closePeriod(tx *gorm.DB, orgID, periodID, userID string) (AccountingPeriod, error) {
+	var period AccountingPeriod
+	if err := lockPeriods(tx, orgID, "UPDATE"); err != nil {
+		return period, err
+	}
+	if err := tx.Where("id = ? AND organization_id = ?", periodID, orgID).First(&period).Error; err != nil {
+		return period, err
+	}
+	if period.Status != "open" {
+		return period, fmt.Errorf("%w: %s is already %s", ErrInvalidPeriod, period.Name, period.Status)
+	}
+
+	var earlier int64
+	if err := tx.Model(&AccountingPeriod{}).
+		Where("organization_id = ? AND status = ? AND start_date < ?", orgID, "open", period.StartDate).
+		Count(&earlier).Error; err != nil {
+		return period, err
+	}
+	if earlier > 0 {
+		return period, fmt.Errorf("%w: earlier periods must be closed first", ErrInvalidPeriod)
+	}
+
+	now := time.Now()
+	period.Status, period.ClosedBy, period.ClosedAt = "closed", &userID, &now
+	if err := tx.Save(&period).Error; err != nil {
+		return period, err
+	}
+	s.createAuditTrail(tx, orgID, "accounting_periods", period.ID, "UPDATE", "open", "closed", userID)
+	return period, nil
+}
+
+// ReopenAccountingPeriod opens the most recently closed period for
+// corrections. Locked periods can't be reopened.
+func (s *Service) ReopenAccountingPeriod(orgID, periodID, userID string) (*AccountingPeriod, error) {
+	var period AccountingPeriod
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPeriods(tx, orgID, "UPDATE"); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND organization_id = ?", periodID, orgID).First(&period).Error; err != nil {
+			return err
+		}
+		if period.Status != "closed" {
+			return fmt.Errorf("%w: only closed periods can be reopened, %s is %s", ErrInvalidPeriod, period.Name, period.Status)
+		}
+
+		var later int64
+		if err := tx.Model(&AccountingPeriod{}).
+			Where("organization_id = ? AND status IN ? AND start_date > ?", orgID, []string{"closed", "locked"}, period.StartDate).
+			Count(&later).Error; err != nil {
+			return err
+		}
+		if later > 0 {
+			return fmt.Errorf("%w: later periods must be reopened first", ErrInvalidPeriod)
+		}
+
+		period.Status, period.ClosedBy, period.ClosedAt = "open", nil, nil
+		if err := tx.Save(&period).Error; err != nil {
+			return err
+		}
+		s.createAuditTrail(tx, orgID, "accounting_periods", period.ID, "UPDATE", "closed", "open", userID)
+		return nil
+	})
+	return &period, err
+}
+
+// LockAccountingPeriod makes a closed period permanent, e.g. once its
+// returns have been lodged
+func (s *Service) LockAccountingPeriod(orgID, periodID, userID string) (*AccountingPeriod, error) {
+	var period AccountingPeriod
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND organization_id = ?", periodID, orgID).First(&period).Error; err != nil {
+			return err
+		}
+		if period.Status != "closed" {
+			return fmt.Errorf("%w: only closed periods can be locked, %s is %s", ErrInvalidPeriod, period.Name, period.Status)
+		}
+
+		period.Status = "locked"
+		if err := tx.Save(&period).Error; err != nil {
+			return err
+		}
+		s.createAuditTrail(tx, orgID, "accounting_periods", period.ID, "UPDATE", "closed", "locked", userID)
+		return nil
+	})
+	return &period, err
+}
+
+// CloseYear posts a closing entry on the last day of the year moving the
+// balance of every revenue and expense account to retained earnings, then
+// closes the year's open periods. The year's last period must still be open.
+// Closing a year again returns the entry it was first closed with.
+func (s *Service) CloseYear(orgID string, start, end time.Time, userID string) (*JournalEntry, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end date must be on or after the start date", ErrInvalidPeriod)
+	}
+
+	var entry *JournalEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var balances []struct {
+			AccountID string
+			Balance   float64
+		}
+		if err := tx.Table("general_ledgers gl").
+			Select("gl.account_id, SUM(gl.debit_amount - gl.credit_amount) as balance").
+			Joins("JOIN chart_of_accounts coa ON coa.id = gl.account_id").
+			Where("gl.organization_id = ? AND coa.account_type IN ?", orgID, []string{"Revenue", "Expense"}).
+			Where("gl.transaction_date >= ? AND gl.transaction_date < ?", start, end.AddDate(0, 0, 1)).
+			Group("gl.account_id").
+			Scan(&balances).Error; err != nil {
+			return err
+		}
+
+		posting := Posting{
+			SourceType:  SourceYearEndClose,
+			SourceID:    end.Format("2006-01-02"),
+			Date:        end,
+			Description: fmt.Sprintf("Year-end close %s to %s", start.Format("2006-01-02"), end.Format("2006-01-02")),
+			Reference:   "year-end:" + end.Format("2006-01-02"),
+			PostedBy:    userID,
+		}
+		var netIncome float64
+		for _, balance := range balances {
+			posting.Credit(accountPrefix+balance.AccountID, "Closing balance", balance.Balance)
+			netIncome -= balance.Balance
+		}
+		posting.Credit(AccountRetainedEarnings, "Net income for the year", netIncome)
+
+		var err error
+		if entry, err = s.Post(tx, orgID, posting); err != nil {
+			return err
+		}
+
+		var periods []AccountingPeriod
+		if err := tx.Where("organization_id = ? AND status = ? AND start_date <= ? AND end_date >= ?", orgID, "open", end, start).
+			Order("start_date").
+			Find(&periods).Error; err != nil {
+			return err
+		}
+		for _, period := range periods {
+			if _, err := s.closePeriod(tx, orgID, period.ID, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// ReverseJournalEntry corrects a posted entry by posting its opposite on
+// date, which must be in an open period. The original entry's ledger lines
+// are marked as reversed. An entry can only be reversed once.
+func (s *Service) ReverseJournalEntry(orgID, entryID string, date time.Time, userID string) (*JournalEntry, error) {
+	var reversal *JournalEntry
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var original JournalEntry
+		if err := tx.Where("id = ? AND organization_id = ?", entryID, orgID).First(&original).Error; err != nil {
+			return err
+		}
+		if original.Status != "posted" {
+			return fmt.Errorf("%w: only posted entries can be reversed, %s is %s", ErrNotReversible, original.EntryNumber, original.Status)
+		}
+		var reversed int64
+		tx.Model(&LedgerPosting{}).Where("organization_id = ? AND source_type = ? AND source_id = ?", orgID, SourceReversal, entryID).Count(&reversed)
+		if reversed > 0 {
+			return fmt.Errorf("%w: %s has already been reversed", ErrNotReversible, original.EntryNumber)
+		}
+
+		posting, err := entryReversal(tx, entryID)
+		if err != nil {
+			return err
+		}
+		posting.SourceType, posting.SourceID, posting.Date = SourceReversal, entryID, date
+		posting.Description = "Reversal of " + original.EntryNumber
+		posting.Reference, posting.PostedBy = original.EntryNumber, userID
+		if reversal, err = s.Post(tx, orgID, *posting); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&GeneralLedger{}).
+			Where("organization_id = ? AND journal_entry_id = ?", orgID, entryID).
+			Updates(map[string]interface{}{"reversed": true, "reversed_by": userID, "reversed_at": now}).Error
+	})
+	return reversal, err
+}
+
+// GetAccountingPeriod returns one of an organization's periods
+func (s *Service) GetAccountingPeriod(orgID, periodID string) (*AccountingPeriod, error) {
+	var period AccountingPeriod
+	if err := s.db.Where("id = ? AND organization_id = ?", periodID, orgID).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
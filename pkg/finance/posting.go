@@ -0,0 +1,460 @@
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Account mapping keys. Business events post amounts against these keys,
+// which an organization maps to accounts in its chart of accounts.
+const (
+	AccountCash                 = "cash"
+	AccountBank                 = "bank"
+	AccountReceivable           = "accounts_receivable"
+	AccountInventory            = "inventory"
+	AccountPayable              = "accounts_payable"
+	AccountGoodsReceived        = "goods_received_not_invoiced"
+	AccountGSTCollected         = "gst_collected"
+	AccountGSTPaid              = "gst_paid"
+	AccountSales                = "sales"
+	AccountCostOfSales          = "cost_of_sales"
+	AccountInventoryAdjustments = "inventory_adjustments"
+	AccountExpenses             = "expenses"
+	AccountRetainedEarnings     = "retained_earnings"
+	AccountCustomerDeposits     = "customer_deposits"
+	AccountBookingFees          = "booking_fees"
+)
+
+// Source document types postings are keyed on
+const (
+	SourcePOSSale             = "pos_sale"
+	SourcePOSVoid             = "pos_void"
+	SourcePurchaseReceipt     = "purchase_receipt"
+	SourceInventoryAdjustment = "inventory_adjustment"
+	SourceInvoice             = "invoice"
+	SourceCreditNote          = "credit_note"
+	SourceBill                = "bill"
+	SourcePayment             = "payment"
+	SourceReversal            = "reversal"
+	SourceYearEndClose        = "year_end_close"
+	SourceBookingPayment      = "booking_payment"
+)
+
+// ErrUnbalanced is returned for postings whose debits and credits differ
+var ErrUnbalanced = errors.New("posting doesn't balance")
+
+// defaultAccounts are the accounts each key posts to until an organization
+// maps it elsewhere. They're added to the chart of accounts when first used.
+var defaultAccounts = map[string]ChartOfAccount{
+	AccountCash:                 {Code: "1000", Name: "Cash", AccountType: "Asset", SubType: "Cash"},
+	AccountBank:                 {Code: "1100", Name: "Bank", AccountType: "Asset", SubType: "Bank"},
+	AccountReceivable:           {Code: "1200", Name: "Accounts Receivable", AccountType: "Asset", SubType: "Current Asset"},
+	AccountInventory:            {Code: "1300", Name: "Inventory", AccountType: "Asset", SubType: "Current Asset"},
+	AccountPayable:              {Code: "2000", Name: "Accounts Payable", AccountType: "Liability", SubType: "Current Liability"},
+	AccountGoodsReceived:        {Code: "2050", Name: "Goods Received Not Invoiced", AccountType: "Liability", SubType: "Current Liability"},
+	AccountGSTCollected:         {Code: "2200", Name: "GST Collected", AccountType: "Liability", SubType: "Current Liability"},
+	AccountGSTPaid:              {Code: "2210", Name: "GST Paid", AccountType: "Liability", SubType: "Current Liability"},
+	AccountSales:                {Code: "4000", Name: "Sales Revenue", AccountType: "Revenue", SubType: "Operating Revenue"},
+	AccountCostOfSales:          {Code: "5200", Name: "Cost of Goods Sold", AccountType: "Expense", SubType: "Cost of Sales"},
+	AccountInventoryAdjustments: {Code: "5300", Name: "Inventory Adjustments", AccountType: "Expense", SubType: "Cost of Sales"},
+	AccountExpenses:             {Code: "5000", Name: "Operating Expenses", AccountType: "Expense", SubType: "Operating Expense"},
+	AccountRetainedEarnings:     {Code: "3200", Name: "Retained Earnings", AccountType: "Equity", SubType: "Retained Earnings"},
+	AccountCustomerDeposits:     {Code: "2100", Name: "Customer Deposits", AccountType: "Liability", SubType: "Current Liability"},
+	AccountBookingFees:          {Code: "4100", Name: "Cancellation and No-show Fees", AccountType: "Revenue", SubType: "Operating Revenue"},
+}
+
+// accountPrefix names an account directly rather than through a mapping
+const accountPrefix = "account:"
+
+// TenderAccount is the mapping key for money taken or paid by a payment
+// method. Cash goes to the cash account and anything else to the bank
+// unless the organization maps the method, e.g. to a clearing account.
+func TenderAccount(method string) string {
+	return "tender:" + strings.ToLower(strings.TrimSpace(method))
+}
+
+// defaultAccount is the account a key posts to without a mapping
+func defaultAccount(key string) (ChartOfAccount, bool) {
+	if account, ok := defaultAccounts[key]; ok {
+		return account, true
+	}
+	if method := strings.TrimPrefix(key, "tender:"); method != key {
+		if method == "cash" {
+			return defaultAccounts[AccountCash], true
+		}
+		return defaultAccounts[AccountBank], true
+	}
+	return ChartOfAccount{}, false
+}
+
+// Posting is a journal entry generated from a business event. Lines name
+// mapping keys rather than accounts.
+type Posting struct {
+	SourceType  string
+	SourceID    string
+	Date        time.Time
+	Description string
+	Reference   string
+	PostedBy    string
+	Lines       []PostingLine
+}
+
+// PostingLine debits or credits the account mapped to a key
+type PostingLine struct {
+	Account     string
+	Description string
+	Debit       float64
+	Credit      float64
+}
+
+// Debit adds a debit line to the posting
+func (p *Posting) Debit(account, description string, amount float64) {
+	p.Lines = append(p.Lines, PostingLine{Account: account, Description: description, Debit: amount})
+}
+
+// Credit adds a credit line to the posting
+func (p *Posting) Credit(account, description string, amount float64) {
+	p.Lines = append(p.Lines, PostingLine{Account: account, Description: description, Credit: amount})
+}
+
+// AccountFor returns the account an organization posts a key to
+func (s *Service) AccountFor(db *gorm.DB, orgID, key string) (*ChartOfAccount, error) {
+	if db == nil {
+		db = s.db
+	}
+	if id := strings.TrimPrefix(key, accountPrefix); id != key {
+		var account ChartOfAccount
+		if err := db.Where("id = ? AND organization_id = ?", id, orgID).First(&account).Error; err != nil {
+			return nil, err
+		}
+		return &account, nil
+	}
+	var mapping AccountMapping
+	err := db.Where("organization_id = ? AND key = ?", orgID, key).Preload("ChartOfAccount").First(&mapping).Error
+	if err == nil {
+		return &mapping.ChartOfAccount, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	account, ok := defaultAccount(key)
+	if !ok {
+		return nil, fmt.Errorf("no account for %q", key)
+	}
+	return accountByCode(db, orgID, account)
+}
+
+// Post turns a posting into a posted journal entry in tx, or in its own
+// transaction if tx is nil. Each source document is posted once; posting it
+// again returns the entry it was first posted as. Nothing is posted for
+// postings whose lines are all zero.
+func (s *Service) Post(tx *gorm.DB, orgID string, posting Posting) (*JournalEntry, error) {
+	if tx == nil {
+		var entry *JournalEntry
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			entry, err = s.Post(tx, orgID, posting)
+			return err
+		})
+		return entry, err
+	}
+
+	var existing LedgerPosting
+	err := tx.Where("organization_id = ? AND source_type = ? AND source_id = ?", orgID, posting.SourceType, posting.SourceID).
+		First(&existing).Error
+	if err == nil {
+		var entry JournalEntry
+		if err := tx.Preload("LineItems").First(&entry, "id = ?", existing.JournalEntryID).Error; err != nil {
+			return nil, err
+		}
+		return &entry, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	entry := JournalEntry{
+		OrganizationID: orgID,
+		Date:           posting.Date,
+		Description:    posting.Description,
+		Reference:      posting.Reference,
+		Status:         "posted",
+	}
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
+	}
+	var debits, credits int64
+	for _, line := range posting.Lines {
+		debit, credit := toCents(line.Debit), toCents(line.Credit)
+		// A negative amount goes on the other side
+		if debit < 0 {
+			debit, credit = 0, credit-debit
+		}
+		if credit < 0 {
+			debit, credit = debit-credit, 0
+		}
+		if debit == credit {
+			continue
+		}
+		if debit > credit {
+			debit, credit = debit-credit, 0
+		} else {
+			debit, credit = 0, credit-debit
+		}
+
+		account, err := s.AccountFor(tx, orgID, line.Account)
+		if err != nil {
+			return nil, err
+		}
+		description := line.Description
+		if description == "" {
+			description = posting.Description
+		}
+		entry.LineItems = append(entry.LineItems, JournalEntryLine{
+			ChartOfAccountID: account.ID,
+			Description:      description,
+			DebitAmount:      fromCents(debit),
+			CreditAmount:     fromCents(credit),
+		})
+		debits += debit
+		credits += credit
+	}
+	if debits != credits {
+		return nil, fmt.Errorf("%w: %s %s debits %.2f, credits %.2f", ErrUnbalanced,
+			posting.SourceType, posting.SourceID, fromCents(debits), fromCents(credits))
+	}
+	if len(entry.LineItems) == 0 {
+		return nil, nil
+	}
+	entry.TotalDebit, entry.TotalCredit = fromCents(debits), fromCents(credits)
+
+	postedBy := posting.PostedBy
+	if postedBy == "" {
+		postedBy = "system"
+	}
+	if err := s.createJournalEntry(tx, &entry, postedBy); err != nil {
+		return nil, err
+	}
+	record := LedgerPosting{
+		ID:             uuid.New().String(),
+		OrganizationID: orgID,
+		SourceType:     posting.SourceType,
+		SourceID:       posting.SourceID,
+		JournalEntryID: entry.ID,
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Reversal is a posting that undoes the entry a source document was posted
+// as, e.g. when a sale is voided. Nothing is posted if the original wasn't.
+func (s *Service) Reversal(tx *gorm.DB, orgID, sourceType, sourceID string) (*Posting, error) {
+	if tx == nil {
+		tx = s.db
+	}
+	var original LedgerPosting
+	err := tx.Where("organization_id = ? AND source_type = ? AND source_id = ?", orgID, sourceType, sourceID).First(&original).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entryReversal(tx, original.JournalEntryID)
+}
+
+// entryReversal is a posting that swaps the debits and credits of an entry
+func entryReversal(tx *gorm.DB, entryID string) (*Posting, error) {
+	var lines []JournalEntryLine
+	if err := tx.Where("journal_entry_id = ?", entryID).Find(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	// Reverse against the same accounts even if the mappings have changed since
+	posting := &Posting{}
+	for _, line := range lines {
+		posting.Lines = append(posting.Lines, PostingLine{
+			Account:     accountPrefix + line.ChartOfAccountID,
+			Description: "Reversal: " + line.Description,
+			Debit:       line.CreditAmount,
+			Credit:      line.DebitAmount,
+		})
+	}
+	return posting, nil
+}
+
+// EffectiveMapping is where an organization posts one key
+type EffectiveMapping struct {
+	Key       string          `json:"key"`
+	Account   *ChartOfAccount `json:"account,omitempty"` // Nil until the default account is first used
+	IsDefault bool            `json:"is_default"`
+	Default   ChartOfAccount  `json:"default_account"`
+}
+
+// GetAccountMappings lists where the organization posts each standard key,
+// along with any tender mappings it has set up
+func (s *Service) GetAccountMappings(orgID string) ([]EffectiveMapping, error) {
+	var mappings []AccountMapping
+	if err := s.db.Where("organization_id = ?", orgID).Preload("ChartOfAccount").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	mapped := make(map[string]*AccountMapping)
+	for i := range mappings {
+		mapped[mappings[i].Key] = &mappings[i]
+	}
+
+	keys := make([]string, 0, len(defaultAccounts)+len(mappings))
+	for key := range defaultAccounts {
+		keys = append(keys, key)
+	}
+	for key := range mapped {
+		if _, ok := defaultAccounts[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := make([]EffectiveMapping, 0, len(keys))
+	for _, key := range keys {
+		fallback, _ := defaultAccount(key)
+		m := EffectiveMapping{Key: key, Default: fallback, IsDefault: mapped[key] == nil}
+		if mapping := mapped[key]; mapping != nil {
+			m.Account = &mapping.ChartOfAccount
+		} else {
+			var account ChartOfAccount
+			if err := s.db.Where("organization_id = ? AND code = ?", orgID, fallback.Code).First(&account).Error; err == nil {
+				m.Account = &account
+			}
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// SetAccountMapping points a key at one of the organization's accounts
+func (s *Service) SetAccountMapping(orgID, key, accountID string) (*AccountMapping, error) {
+	if _, ok := defaultAccount(key); !ok {
+		return nil, fmt.Errorf("unknown account key %q", key)
+	}
+	var account ChartOfAccount
+	if err := s.db.Where("id = ? AND organization_id = ?", accountID, orgID).First(&account).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("account %s not found", accountID)
+		}
+		return nil, err
+	}
+
+	var mapping AccountMapping
+	err := s.db.Where("organization_id = ? AND key = ?", orgID, key).First(&mapping).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if err == gorm.ErrRecordNotFound {
+		mapping = AccountMapping{ID: uuid.New().String(), OrganizationID: orgID, Key: key}
+	}
+	mapping.AccountID = account.ID
+	if err := s.db.Save(&mapping).Error; err != nil {
+		return nil, err
+	}
+	mapping.ChartOfAccount = account
+	return &mapping, nil
+}
+
+// DeleteAccountMapping puts a key back on its default account
+func (s *Service) DeleteAccountMapping(orgID, key string) error {
+	return s.db.Where("organization_id = ? AND key = ?", orgID, key).Delete(&AccountMapping{}).Error
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// invoicePosting raises the receivable for an issued invoice
+func invoicePosting(invoice *Invoice) Posting {
+	posting := Posting{
+		SourceType:  SourceInvoice,
+		SourceID:    invoice.ID,
+		Date:        invoice.IssueDate,
+		Description: "Invoice " + invoice.InvoiceNumber,
+		Reference:   invoice.InvoiceNumber,
+	}
+	posting.Debit(AccountReceivable, "", invoice.Total)
+	posting.Credit(AccountSales, "", invoice.Total-invoice.TaxAmount)
+	posting.Credit(AccountGSTCollected, "", invoice.TaxAmount)
+	return posting
+}
+
+// billPosting raises the payable for a bill. Lines for products clear the
+// goods received not invoiced account, as the stock went into inventory
+// when it was received; anything else is an expense.
+func billPosting(bill *Bill) Posting {
+	posting := Posting{
+		SourceType:  SourceBill,
+		SourceID:    bill.ID,
+		Date:        bill.BillDate,
+		Description: "Bill " + bill.BillNumber,
+		Reference:   bill.BillNumber,
+	}
+	stock := 0.0
+	for _, line := range bill.LineItems {
+		if line.ProductID != nil && *line.ProductID != "" {
+			stock += line.LineTotal
+		}
+	}
+	posting.Debit(AccountGoodsReceived, "", stock)
+	posting.Debit(AccountExpenses, "", bill.Total-bill.TaxAmount-stock)
+	posting.Debit(AccountGSTPaid, "", bill.TaxAmount)
+	posting.Credit(AccountPayable, "", bill.Total)
+	return posting
+}
+
+// creditNotePosting takes a credit note off sales, GST collected and the
+// customer's receivable
+func creditNotePosting(note *CreditNote) Posting {
+	posting := Posting{
+		SourceType:  SourceCreditNote,
+		SourceID:    note.ID,
+		Date:        note.IssueDate,
+		Description: "Credit note " + note.CreditNoteNumber,
+		Reference:   note.CreditNoteNumber,
+	}
+	posting.Debit(AccountSales, note.Reason, note.Total-note.TaxAmount)
+	posting.Debit(AccountGSTCollected, "", note.TaxAmount)
+	posting.Credit(AccountReceivable, "", note.Total)
+	return posting
+}
+
+// paymentPosting settles a receivable or payable against the account the
+// payment method banks to
+func paymentPosting(payment *Payment) Posting {
+	posting := Posting{
+		SourceType: SourcePayment,
+		SourceID:   payment.ID,
+		Date:       payment.PaymentDate,
+		Reference:  payment.Reference,
+	}
+	tender := TenderAccount(payment.PaymentMethod)
+	if payment.Type == "vendor_payment" {
+		posting.Description = "Payment to vendor"
+		posting.Debit(AccountPayable, "", payment.Amount)
+		posting.Credit(tender, "", payment.Amount)
+	} else {
+		posting.Description = "Payment from customer"
+		posting.Debit(tender, "", payment.Amount)
+		posting.Credit(AccountReceivable, "", payment.Amount)
+	}
+	return posting
+}
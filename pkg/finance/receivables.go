@@ -0,0 +1,475 @@
+package finance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidAllocation is returned for payments and credit notes that can't
+// be allocated as asked
+var ErrInvalidAllocation = errors.New("invalid allocation")
+
+// setCustomerFromInvoice fills in a payment's or credit note's customer from
+// the invoice it's for
+func (s *Service) setCustomerFromInvoice(tx *gorm.DB, orgID, invoiceID string, customerID *string) error {
+	var invoice Invoice
+	if err := tx.Where("id = ? AND organization_id = ?", invoiceID, orgID).First(&invoice).Error; err != nil {
+		return fmt.Errorf("invoice %s: %w", invoiceID, err)
+	}
+	*customerID = invoice.CustomerID
+	return nil
+}
+
+// allocate applies up to available to each requested invoice. A request
+// without an amount takes whatever is owed on the invoice, or what's left
+// to allocate if that's less. The caller locks the payment or credit note
+// that available comes from.
+func (s *Service) allocate(tx *gorm.DB, orgID, customerID string, available float64, requests []PaymentAllocation,
+	source PaymentAllocation) ([]PaymentAllocation, float64, error) {
+	// Lock the invoices in a fixed order so concurrent allocations to the
+	// same invoices wait for each other rather than deadlock
+	invoiceIDs := make([]string, len(requests))
+	for i, request := range requests {
+		invoiceIDs[i] = request.InvoiceID
+	}
+	var locked []Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id IN ? AND organization_id = ?", invoiceIDs, orgID).Order("id").Find(&locked).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var allocations []PaymentAllocation
+	left := toCents(available)
+	for _, request := range requests {
+		var invoice Invoice
+		if err := tx.Where("id = ? AND organization_id = ?", request.InvoiceID, orgID).First(&invoice).Error; err != nil {
+			return nil, 0, fmt.Errorf("invoice %s: %w", request.InvoiceID, err)
+		}
+		if invoice.CustomerID != customerID {
+			return nil, 0, fmt.Errorf("%w: %s is another customer's invoice", ErrInvalidAllocation, invoice.InvoiceNumber)
+		}
+		owing := toCents(invoice.Total) - toCents(invoice.PaidAmount) - toCents(invoice.CreditedAmount)
+		if (invoice.Status != "sent" && invoice.Status != "overdue") || owing <= 0 {
+			return nil, 0, fmt.Errorf("%w: %s is %s and can't be paid", ErrInvalidAllocation, invoice.InvoiceNumber, invoice.Status)
+		}
+
+		amount := toCents(request.Amount)
+		if amount == 0 {
+			amount = min(owing, left)
+		}
+		switch {
+		case amount < 0:
+			return nil, 0, fmt.Errorf("%w: allocations can't be negative", ErrInvalidAllocation)
+		case amount > owing:
+			return nil, 0, fmt.Errorf("%w: %.2f is more than the %.2f owing on %s", ErrInvalidAllocation, fromCents(amount), fromCents(owing), invoice.InvoiceNumber)
+		case amount > left:
+			return nil, 0, fmt.Errorf("%w: only %.2f is left to allocate", ErrInvalidAllocation, fromCents(left))
+		case amount == 0:
+			continue
+		}
+
+		allocation := source
+		allocation.ID = uuid.New().String()
+		allocation.OrganizationID = orgID
+		allocation.InvoiceID = invoice.ID
+		allocation.Amount = fromCents(amount)
+		allocation.CreatedAt = time.Now()
+		if err := tx.Create(&allocation).Error; err != nil {
+			return nil, 0, err
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if allocation.CreditNoteID != nil {
+			updates["credited_amount"] = fromCents(toCents(invoice.CreditedAmount) + amount)
+		} else {
+			updates["paid_amount"] = fromCents(toCents(invoice.PaidAmount) + amount)
+		}
+		if amount == owing {
+			updates["status"] = "paid"
+		}
+		if err := tx.Model(&invoice).Updates(updates).Error; err != nil {
+			return nil, 0, err
+		}
+		allocations = append(allocations, allocation)
+		left -= amount
+	}
+	return allocations, fromCents(toCents(available) - left), nil
+}
+
+// allocatePayment applies a customer payment to invoices
+func (s *Service) allocatePayment(tx *gorm.DB, payment *Payment, requests []PaymentAllocation) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	if payment.Type == "vendor_payment" {
+		return fmt.Errorf("%w: vendor payments aren't allocated to invoices", ErrInvalidAllocation)
+	}
+	allocations, allocated, err := s.allocate(tx, payment.OrganizationID, payment.CustomerID, payment.Amount-payment.AllocatedAmount,
+		requests, PaymentAllocation{PaymentID: &payment.ID, Date: payment.PaymentDate})
+	if err != nil {
+		return err
+	}
+	payment.AllocatedAmount = fromCents(toCents(payment.AllocatedAmount) + toCents(allocated))
+	payment.Allocations = append(payment.Allocations, allocations...)
+	return tx.Model(payment).Updates(map[string]interface{}{"allocated_amount": payment.AllocatedAmount, "updated_at": time.Now()}).Error
+}
+
+// AllocatePayment applies what's left of a customer payment to invoices
+func (s *Service) AllocatePayment(orgID, paymentID string, requests []PaymentAllocation) (*Payment, error) {
+	var payment Payment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND organization_id = ?", paymentID, orgID).
+			Preload("Allocations").First(&payment).Error
+		if err != nil {
+			return err
+		}
+		if payment.CustomerID == "" && len(requests) > 0 {
+			if err := s.setCustomerFromInvoice(tx, orgID, requests[0].InvoiceID, &payment.CustomerID); err != nil {
+				return err
+			}
+			if err := tx.Model(&payment).Update("customer_id", payment.CustomerID).Error; err != nil {
+				return err
+			}
+		}
+		return s.allocatePayment(tx, &payment, requests)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CreateCreditNote issues and posts a credit note, allocating it to the
+// invoices in its allocations or to the invoice it credits. Anything not
+// allocated is held as credit for the customer.
+func (s *Service) CreateCreditNote(note *CreditNote) error {
+	note.ID = uuid.New().String()
+	note.CreditNoteNumber = fmt.Sprintf("CN-%d", time.Now().UnixNano())
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = time.Now()
+	if note.IssueDate.IsZero() {
+		note.IssueDate = time.Now()
+	}
+	if note.Total == 0 {
+		note.Total = note.SubTotal + note.TaxAmount
+	}
+	if note.Total <= 0 {
+		return fmt.Errorf("%w: credit notes must be for more than zero", ErrInvalidAllocation)
+	}
+	requests := note.Allocations
+	note.Allocations, note.AllocatedAmount, note.Status = nil, 0, "open"
+	if len(requests) == 0 && note.InvoiceID != nil {
+		requests = []PaymentAllocation{{InvoiceID: *note.InvoiceID}}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if note.CustomerID == "" && note.InvoiceID != nil {
+			if err := s.setCustomerFromInvoice(tx, note.OrganizationID, *note.InvoiceID, &note.CustomerID); err != nil {
+				return err
+			}
+		}
+		if note.CustomerID == "" {
+			return fmt.Errorf("%w: a credit note needs a customer or an invoice", ErrInvalidAllocation)
+		}
+		if err := tx.Create(note).Error; err != nil {
+			return err
+		}
+		if _, err := s.Post(tx, note.OrganizationID, creditNotePosting(note)); err != nil {
+			return err
+		}
+		return s.allocateCreditNote(tx, note, requests)
+	})
+}
+
+// allocateCreditNote applies a credit note to invoices, marking it applied
+// once it's all used
+func (s *Service) allocateCreditNote(tx *gorm.DB, note *CreditNote, requests []PaymentAllocation) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	allocations, allocated, err := s.allocate(tx, note.OrganizationID, note.CustomerID, note.Total-note.AllocatedAmount,
+		requests, PaymentAllocation{CreditNoteID: &note.ID, Date: note.IssueDate})
+	if err != nil {
+		return err
+	}
+	note.AllocatedAmount = fromCents(toCents(note.AllocatedAmount) + toCents(allocated))
+	note.Allocations = append(note.Allocations, allocations...)
+	if toCents(note.AllocatedAmount) == toCents(note.Total) {
+		note.Status = "applied"
+	}
+	return tx.Model(note).Updates(map[string]interface{}{
+		"allocated_amount": note.AllocatedAmount,
+		"status":           note.Status,
+		"updated_at":       time.Now(),
+	}).Error
+}
+
+// AllocateCreditNote applies what's left of a credit note to invoices
+func (s *Service) AllocateCreditNote(orgID, noteID string, requests []PaymentAllocation) (*CreditNote, error) {
+	var note CreditNote
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND organization_id = ?", noteID, orgID).
+			Preload("Allocations").First(&note).Error
+		if err != nil {
+			return err
+		}
+		return s.allocateCreditNote(tx, &note, requests)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// GetCreditNotes lists an organization's credit notes, newest first
+func (s *Service) GetCreditNotes(orgID string) ([]CreditNote, error) {
+	var notes []CreditNote
+	err := s.db.Where("organization_id = ?", orgID).Preload("Allocations").Order("issue_date DESC").Find(&notes).Error
+	return notes, err
+}
+
+// MarkOverdueInvoices moves sent invoices still owing after their due date
+// to overdue, for every organization
+func (s *Service) MarkOverdueInvoices(now time.Time) (int64, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	result := s.db.Model(&Invoice{}).
+		Where("status = ? AND due_date < ? AND total - paid_amount - credited_amount > ?", "sent", today, 0.005).
+		Updates(map[string]interface{}{"status": "overdue", "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// receivables works out what each customer owed at the end of asOf: the
+// invoices issued by then less what had been allocated to them from
+// payments and credit notes dated by then, by age, and the credits not
+// allocated. customerID limits it to one customer.
+func (s *Service) receivables(orgID, customerID string, asOf time.Time) (map[string]*CustomerAging, map[string][]OutstandingInvoice, error) {
+	asOfDay := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	until := asOfDay.AddDate(0, 0, 1)
+	scope := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("organization_id = ?", orgID)
+		if customerID != "" {
+			db = db.Where("customer_id = ?", customerID)
+		}
+		return db
+	}
+
+	var invoices []Invoice
+	if err := s.db.Scopes(scope).Where("status NOT IN ? AND issue_date < ?", []string{"", "draft", "cancelled"}, until).
+		Order("due_date, issue_date").Find(&invoices).Error; err != nil {
+		return nil, nil, err
+	}
+	invoiceIDs := make([]string, 0, len(invoices))
+	for _, invoice := range invoices {
+		invoiceIDs = append(invoiceIDs, invoice.ID)
+	}
+	allocated := make(map[string]int64)
+	if len(invoiceIDs) > 0 {
+		var allocations []PaymentAllocation
+		if err := s.db.Where("organization_id = ? AND invoice_id IN ? AND date < ?", orgID, invoiceIDs, until).
+			Find(&allocations).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, allocation := range allocations {
+			allocated[allocation.InvoiceID] += toCents(allocation.Amount)
+		}
+	}
+
+	customers := make(map[string]*CustomerAging)
+	customer := func(id string) *CustomerAging {
+		if customers[id] == nil {
+			customers[id] = &CustomerAging{CustomerID: id}
+		}
+		return customers[id]
+	}
+	unapplied := make(map[string]int64)
+	outstanding := make(map[string][]OutstandingInvoice)
+	for _, invoice := range invoices {
+		owing := toCents(invoice.Total) - allocated[invoice.ID]
+		// Allocations to the invoice came out of the customer's credits
+		unapplied[invoice.CustomerID] -= allocated[invoice.ID]
+		if owing <= 0 {
+			continue
+		}
+		dueDay := time.Date(invoice.DueDate.Year(), invoice.DueDate.Month(), invoice.DueDate.Day(), 0, 0, 0, 0, asOf.Location())
+		days := int(asOfDay.Sub(dueDay).Hours() / 24)
+		customer(invoice.CustomerID).add(days, fromCents(owing))
+		outstanding[invoice.CustomerID] = append(outstanding[invoice.CustomerID], OutstandingInvoice{
+			InvoiceID:     invoice.ID,
+			InvoiceNumber: invoice.InvoiceNumber,
+			IssueDate:     invoice.IssueDate,
+			DueDate:       invoice.DueDate,
+			Total:         invoice.Total,
+			Outstanding:   fromCents(owing),
+			DaysOverdue:   max(days, 0),
+		})
+	}
+
+	var credits []struct {
+		CustomerID string
+		Amount     float64
+	}
+	if err := s.db.Model(&Payment{}).Scopes(scope).Select("customer_id, SUM(amount) as amount").
+		Where("type = ? AND customer_id <> ? AND payment_date < ?", "customer_payment", "", until).
+		Group("customer_id").Scan(&credits).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, credit := range credits {
+		unapplied[credit.CustomerID] += toCents(credit.Amount)
+	}
+	credits = nil
+	if err := s.db.Model(&CreditNote{}).Scopes(scope).Select("customer_id, SUM(total) as amount").
+		Where("issue_date < ?", until).Group("customer_id").Scan(&credits).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, credit := range credits {
+		unapplied[credit.CustomerID] += toCents(credit.Amount)
+	}
+	for id, cents := range unapplied {
+		if cents != 0 {
+			customer(id).UnappliedCredits = fromCents(cents)
+		}
+	}
+
+	for _, aging := range customers {
+		aging.round()
+		aging.Balance = round2(aging.Total - aging.UnappliedCredits)
+	}
+	return customers, outstanding, nil
+}
+
+// add puts an amount owed into the bucket for how many days overdue it is
+func (b *AgingBuckets) add(daysOverdue int, amount float64) {
+	switch {
+	case daysOverdue <= 0:
+		b.Current += amount
+	case daysOverdue <= 30:
+		b.Days1To30 += amount
+	case daysOverdue <= 60:
+		b.Days31To60 += amount
+	case daysOverdue <= 90:
+		b.Days61To90 += amount
+	default:
+		b.Over90 += amount
+	}
+	b.Total += amount
+}
+
+func (b *AgingBuckets) round() {
+	b.Current, b.Days1To30, b.Days31To60 = round2(b.Current), round2(b.Days1To30), round2(b.Days31To60)
+	b.Days61To90, b.Over90, b.Total = round2(b.Days61To90), round2(b.Over90), round2(b.Total)
+}
+
+// GetARAging reports what each customer owed at the end of asOf, split into
+// not yet due and 1-30, 31-60, 61-90 and over 90 days overdue
+func (s *Service) GetARAging(orgID string, asOf time.Time) (*ARAgingReport, error) {
+	customers, _, err := s.receivables(orgID, "", asOf)
+	if err != nil {
+		return nil, err
+	}
+	report := &ARAgingReport{AsOfDate: asOf, Customers: []CustomerAging{}}
+	for _, aging := range customers {
+		if aging.Total == 0 && aging.UnappliedCredits == 0 {
+			continue
+		}
+		report.Customers = append(report.Customers, *aging)
+		report.Totals.Current += aging.Current
+		report.Totals.Days1To30 += aging.Days1To30
+		report.Totals.Days31To60 += aging.Days31To60
+		report.Totals.Days61To90 += aging.Days61To90
+		report.Totals.Over90 += aging.Over90
+		report.Totals.Total += aging.Total
+		report.UnappliedCredits += aging.UnappliedCredits
+	}
+	sort.Slice(report.Customers, func(i, j int) bool { return report.Customers[i].CustomerID < report.Customers[j].CustomerID })
+	report.Totals.round()
+	report.UnappliedCredits = round2(report.UnappliedCredits)
+	report.Balance = round2(report.Totals.Total - report.UnappliedCredits)
+	return report, nil
+}
+
+// GetCustomerStatement lists a customer's invoices, payments and credit
+// notes between start and end, both inclusive, with a running balance from
+// what they owed before start. It ends with the customer's aging and
+// outstanding invoices as at end.
+func (s *Service) GetCustomerStatement(orgID, customerID string, start, end time.Time) (*CustomerStatement, error) {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	until := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location()).AddDate(0, 0, 1)
+	statement := &CustomerStatement{
+		CustomerID:          customerID,
+		StartDate:           start,
+		EndDate:             end,
+		Lines:               []CustomerStatementLine{},
+		OutstandingInvoices: []OutstandingInvoice{},
+	}
+
+	var lines []CustomerStatementLine
+	var invoices []Invoice
+	if err := s.db.Where("organization_id = ? AND customer_id = ? AND status NOT IN ? AND issue_date < ?",
+		orgID, customerID, []string{"", "draft", "cancelled"}, until).Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	for _, invoice := range invoices {
+		lines = append(lines, CustomerStatementLine{Date: invoice.IssueDate, Type: "invoice", ID: invoice.ID,
+			Reference: invoice.InvoiceNumber, Description: "Invoice " + invoice.InvoiceNumber, Debit: invoice.Total})
+	}
+	var payments []Payment
+	if err := s.db.Where("organization_id = ? AND customer_id = ? AND type = ? AND payment_date < ?",
+		orgID, customerID, "customer_payment", until).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		lines = append(lines, CustomerStatementLine{Date: payment.PaymentDate, Type: "payment", ID: payment.ID,
+			Reference: payment.Reference, Description: "Payment received, thank you", Credit: payment.Amount})
+	}
+	var notes []CreditNote
+	if err := s.db.Where("organization_id = ? AND customer_id = ? AND issue_date < ?", orgID, customerID, until).
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		description := "Credit note " + note.CreditNoteNumber
+		if note.Reason != "" {
+			description += ": " + note.Reason
+		}
+		lines = append(lines, CustomerStatementLine{Date: note.IssueDate, Type: "credit_note", ID: note.ID,
+			Reference: note.CreditNoteNumber, Description: description, Credit: note.Total})
+	}
+
+	// Invoices come before payments on the same day
+	sort.SliceStable(lines, func(i, j int) bool {
+		if !lines[i].Date.Equal(lines[j].Date) {
+			return lines[i].Date.Before(lines[j].Date)
+		}
+		return lines[i].Type == "invoice" && lines[j].Type != "invoice"
+	})
+	balance := int64(0)
+	for _, line := range lines {
+		balance += toCents(line.Debit) - toCents(line.Credit)
+		if line.Date.Before(start) {
+			statement.OpeningBalance = fromCents(balance)
+			continue
+		}
+		line.Balance = fromCents(balance)
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = fromCents(balance)
+
+	customers, outstanding, err := s.receivables(orgID, customerID, end)
+	if err != nil {
+		return nil, err
+	}
+	statement.Aging = CustomerAging{CustomerID: customerID}
+	if aging := customers[customerID]; aging != nil {
+		statement.Aging = *aging
+	}
+	if invoices := outstanding[customerID]; invoices != nil {
+		statement.OutstandingInvoices = invoices
+	}
+	return statement, nil
+}
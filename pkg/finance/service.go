@@ -2,6 +2,7 @@ package finance
 
 import (
 	"fmt"
+	"math"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
@@ -18,7 +19,7 @@ func NewService(db *gorm.DB) *Service {
 // InitializeDefaultAccounts creates standard chart of accounts
 func (s *Service) InitializeDefaultAccounts(orgID string) error {
 	accounts := []ChartOfAccount{
-		{ID: uuid.New().String(), OrganizationID: orgID, Code: "1000", Name: "Cash", AccountType: "Asset", SubType: "Current Asset"},
+		{ID: uuid.New().String(), OrganizationID: orgID, Code: "1000", Name: "Cash", AccountType: "Asset", SubType: "Cash"},
 		{ID: uuid.New().String(), OrganizationID: orgID, Code: "1200", Name: "Accounts Receivable", AccountType: "Asset", SubType: "Current Asset"},
 		{ID: uuid.New().String(), OrganizationID: orgID, Code: "1500", Name: "Equipment", AccountType: "Asset", SubType: "Fixed Asset"},
 		{ID: uuid.New().String(), OrganizationID: orgID, Code: "2000", Name: "Accounts Payable", AccountType: "Liability", SubType: "Current Liability"},
@@ -44,17 +45,41 @@ func (s *Service) CreateAccount(account *ChartOfAccount) error {
 	return s.db.Create(account).Error
 }
 
+// accountByCode returns the organization's account with the given code,
+// adding it to the chart of accounts first if the organization doesn't have it
+func accountByCode(db *gorm.DB, orgID string, account ChartOfAccount) (*ChartOfAccount, error) {
+	var existing ChartOfAccount
+	err := db.Where("organization_id = ? AND code = ?", orgID, account.Code).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	account.ID = uuid.New().String()
+	account.OrganizationID = orgID
+	account.IsActive = true
+	if err := db.Create(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
 func (s *Service) CreateJournalEntry(entry *JournalEntry) error {
-	// Start transaction for journal entry posting
-	tx := s.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.createJournalEntry(tx, entry, "system")
+	})
+}
 
+// createJournalEntry saves an entry in tx, posting it to the general ledger
+// as userID if it's marked posted
+func (s *Service) createJournalEntry(tx *gorm.DB, entry *JournalEntry, userID string) error {
 	entry.ID = uuid.New().String()
-	entry.EntryNumber = fmt.Sprintf("JE-%d", time.Now().Unix())
+	if entry.EntryNumber == "" {
+		// Nanoseconds so entries posted automatically in the same second don't collide
+		entry.EntryNumber = fmt.Sprintf("JE-%d", time.Now().UnixNano())
+	}
 	entry.CreatedAt = time.Now()
 	entry.UpdatedAt = time.Now()
 
@@ -68,19 +93,14 @@ func (s *Service) CreateJournalEntry(entry *JournalEntry) error {
 
 	// Create journal entry
 	if err := tx.Create(entry).Error; err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	// Post to General Ledger and update account balances
 	if entry.Status == "posted" {
-		if err := s.postToGeneralLedger(tx, entry, "system"); err != nil {
-			tx.Rollback()
-			return err
-		}
+		return s.postToGeneralLedger(tx, entry, userID)
 	}
-
-	return tx.Commit().Error
+	return nil
 }
 
 // PostJournalEntry posts a draft journal entry to the general ledger
@@ -122,6 +142,11 @@ func (s *Service) PostJournalEntry(entryID, userID string) error {
 
 // postToGeneralLedger posts journal entry to general ledger with running balances
 func (s *Service) postToGeneralLedger(tx *gorm.DB, entry *JournalEntry, userID string) error {
+	// Nothing can be posted into a closed period
+	if err := checkPeriodOpen(tx, entry.OrganizationID, entry.Date); err != nil {
+		return err
+	}
+
 	for _, lineItem := range entry.LineItems {
 		// Get current account balance
 		var currentBalance AccountBalance
@@ -200,7 +225,8 @@ func (s *Service) postToGeneralLedger(tx *gorm.DB, entry *JournalEntry, userID s
 	return nil
 }
 
-// GetTrialBalance generates trial balance report
+// GetTrialBalance generates trial balance report from the general ledger up
+// to the end of asOfDate, so it comes out the same for any closed period
 func (s *Service) GetTrialBalance(orgID string, asOfDate time.Time) ([]TrialBalance, error) {
 	var results []TrialBalance
 
@@ -209,16 +235,31 @@ func (s *Service) GetTrialBalance(orgID string, asOfDate time.Time) ([]TrialBala
 			coa.code as account_code,
 			coa.name as account_name,
 			coa.account_type,
-			COALESCE(ab.debit_balance, 0) as debit_balance,
-			COALESCE(ab.credit_balance, 0) as credit_balance
+			COALESCE(SUM(gl.debit_amount - gl.credit_amount), 0) as debit_balance
 		FROM chart_of_accounts coa
-		LEFT JOIN account_balances ab ON coa.id = ab.account_id
+		LEFT JOIN general_ledgers gl ON coa.id = gl.account_id
+			AND gl.organization_id = ? AND gl.transaction_date < ?
 		WHERE coa.organization_id = ? AND coa.is_active = ?
+		GROUP BY coa.id, coa.code, coa.name, coa.account_type
 		ORDER BY coa.code
 	`
 
-	err := s.db.Raw(query, orgID, true).Scan(&results).Error
-	return results, err
+	day := time.Date(asOfDate.Year(), asOfDate.Month(), asOfDate.Day(), 0, 0, 0, 0, asOfDate.Location())
+	if err := s.db.Raw(query, orgID, day.AddDate(0, 0, 1), orgID, true).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	// Each account's balance goes in the column for the side it's on
+	for i := range results {
+		balance := math.Round(results[i].DebitBalance*100) / 100
+		results[i].DebitBalance, results[i].CreditBalance = 0, 0
+		if balance > 0 {
+			results[i].DebitBalance = balance
+		} else if balance < 0 {
+			results[i].CreditBalance = -balance
+		}
+	}
+	return results, nil
 }
 
 // GetProfitLoss generates P&L statement
@@ -368,7 +409,9 @@ func (s *Service) createAuditTrail(tx *gorm.DB, orgID, tableName, recordID, acti
 // Invoice methods
 func (s *Service) CreateInvoice(invoice *Invoice) error {
 	invoice.ID = uuid.New().String()
-	invoice.InvoiceNumber = fmt.Sprintf("INV-%d", time.Now().Unix())
+	if invoice.InvoiceNumber == "" {
+		invoice.InvoiceNumber = fmt.Sprintf("INV-%d", time.Now().UnixNano())
+	}
 	invoice.CreatedAt = time.Now()
 	invoice.UpdatedAt = time.Now()
 
@@ -379,7 +422,17 @@ func (s *Service) CreateInvoice(invoice *Invoice) error {
 		invoice.LineItems[i].UpdatedAt = time.Now()
 	}
 
-	return s.db.Create(invoice).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(invoice).Error; err != nil {
+			return err
+		}
+		// Drafts are posted when they're issued
+		if invoice.Status == "" || invoice.Status == "draft" || invoice.Status == "cancelled" {
+			return nil
+		}
+		_, err := s.Post(tx, invoice.OrganizationID, invoicePosting(invoice))
+		return err
+	})
 }
 
 func (s *Service) GetInvoices(orgID string) ([]Invoice, error) {
@@ -391,7 +444,7 @@ func (s *Service) GetInvoices(orgID string) ([]Invoice, error) {
 // Bill methods
 func (s *Service) CreateBill(bill *Bill) error {
 	bill.ID = uuid.New().String()
-	bill.BillNumber = fmt.Sprintf("BILL-%d", time.Now().Unix())
+	bill.BillNumber = fmt.Sprintf("BILL-%d", time.Now().UnixNano())
 	bill.CreatedAt = time.Now()
 	bill.UpdatedAt = time.Now()
 
@@ -402,7 +455,13 @@ func (s *Service) CreateBill(bill *Bill) error {
 		bill.LineItems[i].UpdatedAt = time.Now()
 	}
 
-	return s.db.Create(bill).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bill).Error; err != nil {
+			return err
+		}
+		_, err := s.Post(tx, bill.OrganizationID, billPosting(bill))
+		return err
+	})
 }
 
 func (s *Service) GetBills(orgID string) ([]Bill, error) {
@@ -426,11 +485,40 @@ func (s *Service) GetVendors(orgID string) ([]Vendor, error) {
 }
 
 // Payment methods
+
+// CreatePayment records and posts a payment. A customer payment is allocated
+// to the invoices in its allocations, or to its invoice_id; whatever isn't
+// allocated is held as credit for the customer.
 func (s *Service) CreatePayment(payment *Payment) error {
 	payment.ID = uuid.New().String()
 	payment.CreatedAt = time.Now()
 	payment.UpdatedAt = time.Now()
-	return s.db.Create(payment).Error
+	requests := payment.Allocations
+	payment.Allocations, payment.AllocatedAmount = nil, 0
+	if len(requests) == 0 && payment.InvoiceID != nil {
+		requests = []PaymentAllocation{{InvoiceID: *payment.InvoiceID}}
+	}
+	if payment.Type == "vendor_payment" {
+		requests = nil
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("%w: payments must be for more than zero", ErrInvalidAllocation)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if payment.CustomerID == "" && len(requests) > 0 {
+			if err := s.setCustomerFromInvoice(tx, payment.OrganizationID, requests[0].InvoiceID, &payment.CustomerID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		if _, err := s.Post(tx, payment.OrganizationID, paymentPosting(payment)); err != nil {
+			return err
+		}
+		return s.allocatePayment(tx, payment, requests)
+	})
 }
 
 // Bank Account methods
@@ -0,0 +1,393 @@
+package finance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bank statement file formats
+const (
+	FormatOFX = "ofx"
+	FormatQIF = "qif"
+	FormatCSV = "csv"
+)
+
+// ErrInvalidStatement is returned for statement files that can't be read
+var ErrInvalidStatement = errors.New("invalid bank statement")
+
+// StatementLine is a transaction read from a bank statement
+type StatementLine struct {
+	Date        time.Time
+	Amount      float64 // Positive for deposits, negative for withdrawals
+	Description string
+	Reference   string
+	ExternalID  string
+	Balance     *float64 // Running balance, where the statement gives one
+}
+
+// Statement is a parsed bank statement file
+type Statement struct {
+	Format         string
+	Lines          []StatementLine
+	ClosingBalance *float64 // Ledger balance given by OFX statements
+}
+
+// statementDateLayouts are the layouts tried for each date_format, after
+// dates have been normalised to use slashes
+var statementDateLayouts = map[string][]string{
+	"DD/MM/YYYY": {"2/1/2006", "2/1/06"},
+	"MM/DD/YYYY": {"1/2/2006", "1/2/06"},
+	"YYYY-MM-DD": {"2006/1/2"},
+}
+
+// DetectStatementFormat works out a statement's format from its file name,
+// or failing that its content
+func DetectStatementFormat(fileName string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ofx", ".qfx":
+		return FormatOFX
+	case ".qif":
+		return FormatQIF
+	case ".csv":
+		return FormatCSV
+	}
+	head := strings.ToUpper(string(data[:min(len(data), 512)]))
+	switch {
+	case strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>"):
+		return FormatOFX
+	case strings.HasPrefix(strings.TrimSpace(head), "!TYPE:"):
+		return FormatQIF
+	}
+	return FormatCSV
+}
+
+// ParseStatement reads a statement in one of the supported formats.
+// dateFormat (DD/MM/YYYY, MM/DD/YYYY or YYYY-MM-DD) says how to read QIF and
+// CSV dates; QIF defaults to month first and CSV to day first.
+func ParseStatement(format string, data []byte, dateFormat string) (*Statement, error) {
+	var layouts []string
+	if dateFormat != "" {
+		var ok bool
+		if layouts, ok = statementDateLayouts[strings.ToUpper(dateFormat)]; !ok {
+			return nil, fmt.Errorf("%w: unknown date format %q", ErrInvalidStatement, dateFormat)
+		}
+	}
+
+	var statement *Statement
+	var err error
+	switch strings.ToLower(format) {
+	case FormatOFX:
+		statement, err = parseOFX(data)
+	case FormatQIF:
+		if layouts == nil {
+			layouts = append([]string{"2006/1/2"}, statementDateLayouts["MM/DD/YYYY"]...)
+		}
+		statement, err = parseQIF(data, layouts)
+	case FormatCSV:
+		if layouts == nil {
+			layouts = append([]string{"2006/1/2"}, statementDateLayouts["DD/MM/YYYY"]...)
+		}
+		statement, err = parseCSV(data, layouts)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidStatement, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(statement.Lines) == 0 {
+		return nil, fmt.Errorf("%w: no transactions found", ErrInvalidStatement)
+	}
+	return statement, nil
+}
+
+// parseOFX reads OFX 1.x (SGML, where closing tags are optional) and 2.x
+// (XML) statements by walking their tags
+func parseOFX(data []byte) (*Statement, error) {
+	statement := &Statement{Format: FormatOFX}
+	var line *StatementLine
+	var inLedgerBalance bool
+	var memo string
+	// Some banks leave transactions unclosed, so one also ends at the next
+	// or at the end of the list
+	endLine := func() error {
+		if line == nil {
+			return nil
+		}
+		if line.Date.IsZero() {
+			return fmt.Errorf("%w: transaction %q has no DTPOSTED", ErrInvalidStatement, line.ExternalID)
+		}
+		if memo != "" && memo != line.Description {
+			line.Description = strings.TrimSpace(line.Description + " " + memo)
+		}
+		statement.Lines = append(statement.Lines, *line)
+		line = nil
+		return nil
+	}
+
+	for _, token := range strings.Split(string(data), "<")[1:] {
+		tag, value, ok := strings.Cut(token, ">")
+		if !ok {
+			continue
+		}
+		tag = strings.ToUpper(strings.TrimSpace(tag))
+		value = strings.TrimSpace(html.UnescapeString(value))
+
+		switch tag {
+		case "STMTTRN", "/STMTTRN", "/BANKTRANLIST":
+			if err := endLine(); err != nil {
+				return nil, err
+			}
+			if tag == "STMTTRN" {
+				line, memo = &StatementLine{}, ""
+			}
+			continue
+		case "LEDGERBAL":
+			inLedgerBalance = true
+		case "/LEDGERBAL":
+			inLedgerBalance = false
+		case "BALAMT":
+			if inLedgerBalance {
+				amount, err := parseStatementAmount(value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: ledger balance: %v", ErrInvalidStatement, err)
+				}
+				statement.ClosingBalance = &amount
+			}
+		}
+		if line == nil || value == "" {
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			// YYYYMMDD, optionally followed by a time and time zone
+			if len(value) < 8 {
+				return nil, fmt.Errorf("%w: invalid DTPOSTED %q", ErrInvalidStatement, value)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid DTPOSTED %q", ErrInvalidStatement, value)
+			}
+			line.Date = date
+		case "TRNAMT":
+			amount, err := parseStatementAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+			}
+			line.Amount = amount
+		case "FITID":
+			line.ExternalID = value
+		case "NAME", "PAYEE":
+			line.Description = value
+		case "MEMO":
+			memo = value
+		case "CHECKNUM", "REFNUM":
+			if line.Reference == "" {
+				line.Reference = value
+			}
+		}
+	}
+	return statement, nil
+}
+
+// parseQIF reads a QIF bank register: one field per line, each transaction
+// ending with ^
+func parseQIF(data []byte, layouts []string) (*Statement, error) {
+	statement := &Statement{Format: FormatQIF}
+	var line StatementLine
+	var started bool
+	var memo string
+
+	for n, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(text, "\r")
+		if strings.TrimSpace(text) == "" || text[0] == '!' {
+			continue
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+		switch code {
+		case 'D':
+			date, err := parseStatementDate(value, layouts)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidStatement, n+1, err)
+			}
+			line.Date, started = date, true
+		case 'T', 'U':
+			amount, err := parseStatementAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidStatement, n+1, err)
+			}
+			line.Amount, started = amount, true
+		case 'P':
+			line.Description = value
+		case 'M':
+			memo = value
+		case 'N':
+			line.Reference = value
+		case '^':
+			if started {
+				if line.Date.IsZero() {
+					return nil, fmt.Errorf("%w: line %d: transaction has no date", ErrInvalidStatement, n+1)
+				}
+				if line.Description == "" {
+					line.Description = memo
+				} else if memo != "" && memo != line.Description {
+					line.Description += " " + memo
+				}
+				statement.Lines = append(statement.Lines, line)
+			}
+			line, started, memo = StatementLine{}, false, ""
+		}
+	}
+	return statement, nil
+}
+
+// csvColumns are the header names recognised for each CSV field
+var csvColumns = map[string][]string{
+	"date":        {"date", "transaction date", "posted date", "posting date", "value date"},
+	"amount":      {"amount", "transaction amount"},
+	"debit":       {"debit", "debit amount", "withdrawal", "withdrawals", "money out"},
+	"credit":      {"credit", "credit amount", "deposit", "deposits", "money in"},
+	"description": {"description", "narrative", "details", "transaction details", "payee", "memo"},
+	"reference":   {"reference", "ref", "cheque number", "check number", "transaction id"},
+	"balance":     {"balance", "running balance"},
+}
+
+// parseCSV reads a CSV export with a header row. Amounts are either signed
+// in one column or split into debit and credit columns.
+func parseCSV(data []byte, layouts []string) (*Statement, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidStatement, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidStatement)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, names := range csvColumns {
+			if _, found := columns[field]; found {
+				continue
+			}
+			for _, candidate := range names {
+				if name == candidate {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	_, hasAmount := columns["amount"]
+	_, hasDebit := columns["debit"]
+	_, hasCredit := columns["credit"]
+	if _, ok := columns["date"]; !ok || !(hasAmount || hasDebit || hasCredit) {
+		return nil, fmt.Errorf("%w: the header row needs a date column and an amount, or debit and credit, column", ErrInvalidStatement)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	statement := &Statement{Format: FormatCSV}
+	for n, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		row := n + 2
+		date, err := parseStatementDate(field(record, "date"), layouts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidStatement, row, err)
+		}
+		line := StatementLine{
+			Date:        date,
+			Description: field(record, "description"),
+			Reference:   field(record, "reference"),
+		}
+
+		if hasAmount {
+			if line.Amount, err = parseStatementAmount(field(record, "amount")); err != nil {
+				return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidStatement, row, err)
+			}
+		} else {
+			for _, name := range []string{"credit", "debit"} {
+				value := field(record, name)
+				if value == "" {
+					continue
+				}
+				amount, err := parseStatementAmount(value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidStatement, row, err)
+				}
+				if amount < 0 {
+					amount = -amount
+				}
+				if name == "debit" {
+					amount = -amount
+				}
+				line.Amount += amount
+			}
+		}
+		if value := field(record, "balance"); value != "" {
+			balance, err := parseStatementAmount(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidStatement, row, err)
+			}
+			line.Balance = &balance
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	return statement, nil
+}
+
+// parseStatementDate reads a date like 15/01/2025, 1/15'25, 2025-01-15 or
+// 15 Jan 2025
+func parseStatementDate(value string, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2 Jan 2006", "2-Jan-2006", "Jan 2, 2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	normalised := strings.NewReplacer("'", "/", "-", "/", ".", "/", " ", "").Replace(value)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, normalised); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseStatementAmount reads an amount like -12.50, $1,234.00, (45.00) or
+// 45.00 DR
+func parseStatementAmount(value string) (float64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	negative := false
+	if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+		negative, text = true, strings.Trim(text, "()")
+	}
+	if strings.HasSuffix(text, "DR") {
+		negative, text = true, strings.TrimSuffix(text, "DR")
+	}
+	text = strings.TrimSuffix(text, "CR")
+	text = strings.NewReplacer("$", "", ",", "", " ", "").Replace(text)
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil || text == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}